@@ -14,10 +14,17 @@ import (
 	"github.com/liquorpro/go-backend/internal/auth/handlers"
 	"github.com/liquorpro/go-backend/internal/auth/routes"
 	"github.com/liquorpro/go-backend/internal/auth/services"
+	"github.com/liquorpro/go-backend/pkg/shared/audit"
 	"github.com/liquorpro/go-backend/pkg/shared/cache"
 	"github.com/liquorpro/go-backend/pkg/shared/config"
 	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/logger"
 	"github.com/liquorpro/go-backend/pkg/shared/middleware"
+	"github.com/liquorpro/go-backend/pkg/shared/monitoring"
+	"github.com/liquorpro/go-backend/pkg/shared/notification"
+	"github.com/liquorpro/go-backend/pkg/shared/permissions"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
+	"github.com/liquorpro/go-backend/pkg/shared/webhook"
 )
 
 func main() {
@@ -27,6 +34,16 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Structured JSON request logs, correlated across services by request ID
+	if err := logger.Initialize(logger.Config{
+		Level:       cfg.App.LogLevel,
+		Environment: cfg.App.Environment,
+		ServiceName: "auth",
+	}); err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer logger.Sync()
+
 	// Set Gin mode
 	if cfg.App.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -66,12 +83,23 @@ func main() {
 	defer redisCache.Close()
 
 	// Initialize services
-	authService := services.NewAuthService(db, redisCache, &cfg.JWT)
+	notifier := notification.NewService(db, notification.NewSender(cfg.Email))
+	authService := services.NewAuthService(db, redisCache, &cfg.JWT, notifier, cfg.App.PasswordResetURL)
 	userService := services.NewUserService(db, redisCache)
 	tenantService := services.NewTenantService(db, redisCache)
+	emailTemplateService := services.NewEmailTemplateService(db, redisCache, notifier)
+	settingsService := settings.NewService(db, redisCache)
+	permissionService := permissions.NewService(db, redisCache)
+	auditLogger := audit.NewLogger(db)
+	webhookService := webhook.NewService(db)
+
+	// Deliver tenant webhook events raised by every service
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	webhook.NewWorker(webhookService, 15*time.Second).Start(workerCtx)
 
 	// Initialize handlers
-	authHandlers := handlers.NewAuthHandlers(authService, userService, tenantService)
+	authHandlers := handlers.NewAuthHandlers(authService, userService, tenantService, emailTemplateService, settingsService, permissionService, auditLogger, webhookService)
 
 	// Create router
 	router := gin.New()
@@ -82,6 +110,13 @@ func main() {
 	router.Use(middleware.RequestIDMiddleware())
 	router.Use(middleware.CORSMiddleware())
 
+	// Request, database pool and cache metrics on /metrics
+	metrics := monitoring.NewPrometheus("auth", db.DB, redisCache)
+	metrics.Register(router)
+
+	// Liveness and readiness probes
+	monitoring.NewHealthChecker("auth", db.DB, redisCache).Register(router)
+
 	// Setup routes
 	routes.SetupRoutes(router, cfg, redisCache, authHandlers)
 
@@ -107,6 +142,8 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down Authentication service...")
+	log.Printf("Draining %d in-flight requests", metrics.InFlight())
+	stopWorkers()
 
 	// Graceful shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -14,10 +14,19 @@ import (
 	"github.com/liquorpro/go-backend/internal/finance/handlers"
 	"github.com/liquorpro/go-backend/internal/finance/routes"
 	"github.com/liquorpro/go-backend/internal/finance/services"
+	"github.com/liquorpro/go-backend/pkg/shared/approval"
+	"github.com/liquorpro/go-backend/pkg/shared/audit"
 	"github.com/liquorpro/go-backend/pkg/shared/cache"
 	"github.com/liquorpro/go-backend/pkg/shared/config"
 	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/logger"
 	"github.com/liquorpro/go-backend/pkg/shared/middleware"
+	"github.com/liquorpro/go-backend/pkg/shared/monitoring"
+	"github.com/liquorpro/go-backend/pkg/shared/notification"
+	"github.com/liquorpro/go-backend/pkg/shared/permissions"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
+	"github.com/liquorpro/go-backend/pkg/shared/storage"
 )
 
 func main() {
@@ -27,6 +36,16 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Structured JSON request logs, correlated across services by request ID
+	if err := logger.Initialize(logger.Config{
+		Level:       cfg.App.LogLevel,
+		Environment: cfg.App.Environment,
+		ServiceName: "finance",
+	}); err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer logger.Sync()
+
 	// Set Gin mode
 	if cfg.App.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -57,6 +76,7 @@ func main() {
 		Port:     cfg.Redis.Port,
 		Password: cfg.Redis.Password,
 		DB:       cfg.Redis.DB,
+		TTLs:     cache.NewTTLs(cfg.Cache),
 	}
 
 	redisCache, err := cache.NewCache(cacheConfig)
@@ -65,16 +85,44 @@ func main() {
 	}
 	defer redisCache.Close()
 
+	// Initialize file storage for expense receipts
+	fileStore, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatalf("Failed to initialize file storage: %v", err)
+	}
+
 	// Initialize services
-	vendorService := services.NewVendorService(db, redisCache)
-	expenseService := services.NewExpenseService(db, redisCache)
-	assistantManagerService := services.NewAssistantManagerService(db, redisCache)
+	settingsService := settings.NewService(db, redisCache)
+	vendorService := services.NewVendorService(db, redisCache, settingsService)
+	expenseService := services.NewExpenseService(db, redisCache, settingsService, fileStore, cfg.Storage.MaxUploadSize)
+	permissionService := permissions.NewService(db, redisCache)
+	autoApprover := approval.NewAutoApprover(db, settingsService)
+	readAuditor := audit.NewReadAuditor(db, settingsService)
+	auditLogger := audit.NewLogger(db)
+	shopScopes := scope.NewResolver(db)
+	notifier := notification.NewService(db, notification.NewSender(cfg.Email))
+	assistantManagerService := services.NewAssistantManagerService(db, redisCache, cfg.Finance, autoApprover, settingsService, notifier)
+	financeService := services.NewFinanceService(db, redisCache)
+	exportService := services.NewAccountingExportService(db, redisCache)
+
+	// Expire collections that miss their approval deadline
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	if cfg.Finance.OverdueSweepInterval > 0 {
+		services.NewOverdueCollectionWorker(assistantManagerService, time.Duration(cfg.Finance.OverdueSweepInterval)*time.Second).Start(workerCtx)
+	}
+	// Raise recurring expenses as they come due
+	if cfg.Finance.RecurringExpenseInterval > 0 {
+		services.NewRecurringExpenseWorker(expenseService, time.Duration(cfg.Finance.RecurringExpenseInterval)*time.Second).Start(workerCtx)
+	}
 
 	// Initialize handlers
 	financeHandlers := handlers.NewFinanceHandlers(
 		vendorService,
 		expenseService,
 		assistantManagerService,
+		financeService,
+		exportService,
 	)
 
 	// Create router
@@ -84,10 +132,18 @@ func main() {
 	router.Use(gin.Recovery())
 	router.Use(middleware.LoggingMiddleware())
 	router.Use(middleware.RequestIDMiddleware())
+	router.Use(middleware.APIVersionMiddleware())
 	router.Use(middleware.CORSMiddleware())
 
+	// Request, database pool and cache metrics on /metrics
+	metrics := monitoring.NewPrometheus("finance", db.DB, redisCache)
+	metrics.Register(router)
+
+	// Liveness and readiness probes
+	monitoring.NewHealthChecker("finance", db.DB, redisCache).Register(router)
+
 	// Setup routes
-	routes.SetupRoutes(router, cfg, redisCache, financeHandlers)
+	routes.SetupRoutes(router, cfg, redisCache, financeHandlers, settingsService, permissionService, readAuditor, auditLogger, shopScopes)
 
 	// Start server
 	srv := &http.Server{
@@ -111,6 +167,8 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down Finance service...")
+	log.Printf("Draining %d in-flight requests", metrics.InFlight())
+	stopWorkers()
 
 	// Graceful shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
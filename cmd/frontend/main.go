@@ -20,7 +20,9 @@ import (
 	"github.com/liquorpro/go-backend/pkg/shared/cache"
 	"github.com/liquorpro/go-backend/pkg/shared/config"
 	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/logger"
 	"github.com/liquorpro/go-backend/pkg/shared/middleware"
+	"github.com/liquorpro/go-backend/pkg/shared/monitoring"
 )
 
 func main() {
@@ -30,6 +32,16 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Structured JSON request logs, correlated across services by request ID
+	if err := logger.Initialize(logger.Config{
+		Level:       cfg.App.LogLevel,
+		Environment: cfg.App.Environment,
+		ServiceName: "frontend",
+	}); err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer logger.Sync()
+
 	// Set Gin mode
 	if cfg.App.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -83,6 +95,10 @@ func main() {
 	router.Use(middleware.RequestIDMiddleware())
 	router.Use(middleware.CORSMiddleware())
 
+	// Request, database pool and cache metrics on /metrics
+	metrics := monitoring.NewPrometheus("frontend", db.DB, redisCache)
+	metrics.Register(router)
+
 	// Setup custom template functions
 	router.SetFuncMap(template.FuncMap{
 		"formatDate": func(t time.Time) string {
@@ -91,9 +107,7 @@ func main() {
 		"formatDateTime": func(t time.Time) string {
 			return t.Format("2006-01-02 15:04:05")
 		},
-		"formatCurrency": func(amount float64) string {
-			return fmt.Sprintf("₹%.2f", amount)
-		},
+		"formatCurrency": formatCurrency,
 		"add": func(a, b int) int {
 			return a + b
 		},
@@ -135,6 +149,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down Frontend service...")
+	log.Printf("Draining %d in-flight requests", metrics.InFlight())
 
 	// Graceful shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -145,4 +160,26 @@ func main() {
 	}
 
 	log.Println("Frontend service stopped")
-}
\ No newline at end of file
+}
+
+// currencySymbols are the symbols formatCurrency uses; other currencies are
+// shown by their ISO 4217 code
+var currencySymbols = map[string]string{
+	"INR": "₹",
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+}
+
+// formatCurrency formats an amount in the record's currency, INR when none is
+// given, as in {{formatCurrency .Amount .Currency}}
+func formatCurrency(amount float64, currency ...string) string {
+	code := "INR"
+	if len(currency) > 0 && strings.TrimSpace(currency[0]) != "" {
+		code = strings.ToUpper(strings.TrimSpace(currency[0]))
+	}
+	if symbol, ok := currencySymbols[code]; ok {
+		return fmt.Sprintf("%s%.2f", symbol, amount)
+	}
+	return fmt.Sprintf("%s %.2f", code, amount)
+}
@@ -16,7 +16,9 @@ import (
 	"github.com/liquorpro/go-backend/pkg/shared/cache"
 	"github.com/liquorpro/go-backend/pkg/shared/config"
 	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/logger"
 	"github.com/liquorpro/go-backend/pkg/shared/middleware"
+	"github.com/liquorpro/go-backend/pkg/shared/monitoring"
 )
 
 func main() {
@@ -26,6 +28,16 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Structured JSON request logs, correlated across services by request ID
+	if err := logger.Initialize(logger.Config{
+		Level:       cfg.App.LogLevel,
+		Environment: cfg.App.Environment,
+		ServiceName: "gateway",
+	}); err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer logger.Sync()
+
 	// Set Gin mode based on configuration
 	if cfg.App.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -69,10 +81,9 @@ func main() {
 	}
 	defer redisCache.Close()
 
-	// Initialize HTTP client for service communication
-	httpClient := &http.Client{
-		Timeout: 30 * time.Second,
-	}
+	// Initialize HTTP client for service communication; timeouts are set per
+	// service on each proxied request
+	httpClient := &http.Client{}
 
 	// Initialize handlers
 	gatewayHandlers := handlers.NewGatewayHandlers(cfg, httpClient)
@@ -86,6 +97,13 @@ func main() {
 	router.Use(middleware.RequestIDMiddleware())
 	router.Use(middleware.CORSMiddleware())
 
+	// Request, database pool and cache metrics on /metrics
+	metrics := monitoring.NewPrometheus("gateway", db.DB, redisCache)
+	metrics.Register(router)
+
+	// Liveness and readiness probes
+	monitoring.NewHealthChecker("gateway", db.DB, redisCache).Register(router)
+
 	// Setup routes
 	routes.SetupRoutes(router, cfg, redisCache, gatewayHandlers)
 
@@ -111,6 +129,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down API Gateway...")
+	log.Printf("Draining %d in-flight requests", metrics.InFlight())
 
 	// Give outstanding requests 30 seconds to complete
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
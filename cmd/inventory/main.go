@@ -14,10 +14,16 @@ import (
 	"github.com/liquorpro/go-backend/internal/inventory/handlers"
 	"github.com/liquorpro/go-backend/internal/inventory/routes"
 	"github.com/liquorpro/go-backend/internal/inventory/services"
+	"github.com/liquorpro/go-backend/pkg/shared/audit"
 	"github.com/liquorpro/go-backend/pkg/shared/cache"
 	"github.com/liquorpro/go-backend/pkg/shared/config"
 	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/logger"
 	"github.com/liquorpro/go-backend/pkg/shared/middleware"
+	"github.com/liquorpro/go-backend/pkg/shared/monitoring"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/notification"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
 )
 
 func main() {
@@ -27,6 +33,16 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Structured JSON request logs, correlated across services by request ID
+	if err := logger.Initialize(logger.Config{
+		Level:       cfg.App.LogLevel,
+		Environment: cfg.App.Environment,
+		ServiceName: "inventory",
+	}); err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer logger.Sync()
+
 	// Set Gin mode
 	if cfg.App.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -57,6 +73,7 @@ func main() {
 		Port:     cfg.Redis.Port,
 		Password: cfg.Redis.Password,
 		DB:       cfg.Redis.DB,
+		TTLs:     cache.NewTTLs(cfg.Cache),
 	}
 
 	redisCache, err := cache.NewCache(cacheConfig)
@@ -67,9 +84,17 @@ func main() {
 
 	// Initialize services
 	productService := services.NewProductService(db, redisCache)
-	stockService := services.NewStockService(db, redisCache)
+	settingsService := settings.NewService(db, redisCache)
+	notifier := notification.NewService(db, notification.NewSender(cfg.Email))
+	stockService := services.NewStockService(db, redisCache, settingsService, notifier, cfg.Inventory)
 	purchaseService := services.NewPurchaseService(db, redisCache)
 	categoryService := services.NewCategoryService(db, redisCache)
+	shopScopes := scope.NewResolver(db)
+	auditLogger := audit.NewLogger(db)
+	reportService := services.NewReportService(db, redisCache, settingsService)
+	writeOffService := services.NewWriteOffService(db, redisCache, settingsService, stockService)
+	catalogService := services.NewCatalogService(db, redisCache, settingsService)
+	countService := services.NewStockCountService(db, redisCache, stockService)
 
 	// Initialize handlers
 	inventoryHandlers := handlers.NewInventoryHandlers(
@@ -77,6 +102,10 @@ func main() {
 		stockService,
 		purchaseService,
 		categoryService,
+		reportService,
+		writeOffService,
+		catalogService,
+		countService,
 	)
 
 	// Create router
@@ -86,10 +115,18 @@ func main() {
 	router.Use(gin.Recovery())
 	router.Use(middleware.LoggingMiddleware())
 	router.Use(middleware.RequestIDMiddleware())
+	router.Use(middleware.APIVersionMiddleware())
 	router.Use(middleware.CORSMiddleware())
 
+	// Request, database pool and cache metrics on /metrics
+	metrics := monitoring.NewPrometheus("inventory", db.DB, redisCache)
+	metrics.Register(router)
+
+	// Liveness and readiness probes
+	monitoring.NewHealthChecker("inventory", db.DB, redisCache).Register(router)
+
 	// Setup routes
-	routes.SetupRoutes(router, cfg, redisCache, inventoryHandlers)
+	routes.SetupRoutes(router, cfg, redisCache, inventoryHandlers, auditLogger, shopScopes)
 
 	// Start server
 	srv := &http.Server{
@@ -113,6 +150,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down Inventory service...")
+	log.Printf("Draining %d in-flight requests", metrics.InFlight())
 
 	// Graceful shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -19,7 +19,11 @@ import (
 	"github.com/liquorpro/go-backend/pkg/shared/cache"
 	"github.com/liquorpro/go-backend/pkg/shared/config"
 	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/logger"
 	"github.com/liquorpro/go-backend/pkg/shared/middleware"
+	"github.com/liquorpro/go-backend/pkg/shared/monitoring"
+	"github.com/liquorpro/go-backend/pkg/shared/notification"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
 )
 
 func main() {
@@ -29,6 +33,16 @@ func main() {
 		log.Fatal("Failed to load configuration:", err)
 	}
 
+	// Structured JSON request logs, correlated across services by request ID
+	if err := logger.Initialize(logger.Config{
+		Level:       cfg.App.LogLevel,
+		Environment: cfg.App.Environment,
+		ServiceName: "saas",
+	}); err != nil {
+		log.Fatal("Failed to initialize logger:", err)
+	}
+	defer logger.Sync()
+
 	// Connect to database
 	dbConfig := database.Config{
 		Host:     cfg.Database.Host,
@@ -65,30 +79,53 @@ func main() {
 	}
 
 	// Initialize services
-	subscriptionService := services.NewSubscriptionService(db, cfg)
+	settingsService := settings.NewService(dbConn, cacheClient)
+	subscriptionService := services.NewSubscriptionService(db, cfg, settingsService)
 	planService := services.NewPlanService(db, cfg)
-	paymentService := services.NewPaymentService(db, cfg)
+	notifier := notification.NewService(dbConn, notification.NewSender(cfg.Email))
+	paymentService := services.NewPaymentService(db, cfg, notifier)
+	invoiceService := services.NewInvoiceService(db, settingsService)
 	adminService := services.NewAdminService(db, cfg)
 	analyticsService := services.NewAnalyticsService(db, cfg)
+	couponService := services.NewCouponService(db)
+
+	// Snapshot tenant usage daily for the usage analytics
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	services.NewUsageSnapshotWorker(subscriptionService, 24*time.Hour).Start(workerCtx)
+	services.NewPlanChangeWorker(subscriptionService, time.Hour).Start(workerCtx)
+	if cfg.Billing.DunningInterval > 0 {
+		services.NewDunningWorker(paymentService, time.Duration(cfg.Billing.DunningInterval)*time.Second).Start(workerCtx)
+	}
+	services.NewWebhookRetryWorker(paymentService, 5*time.Minute).Start(workerCtx)
 
 	// Initialize handlers
 	subscriptionHandler := handlers.NewSubscriptionHandler(subscriptionService)
 	planHandler := handlers.NewPlanHandler(planService)
-	paymentHandler := handlers.NewPaymentHandler(paymentService)
+	paymentHandler := handlers.NewPaymentHandler(paymentService, invoiceService)
 	adminHandler := handlers.NewAdminHandler(adminService)
 	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
+	couponHandler := handlers.NewCouponHandler(couponService)
+
+	// Request, database pool and cache metrics on /metrics
+	metrics := monitoring.NewPrometheus("saas", db, cacheClient)
 
 	// Setup routes
 	router := setupRoutes(
 		cfg,
 		cacheClient,
+		metrics,
 		subscriptionHandler,
 		planHandler,
 		paymentHandler,
 		adminHandler,
 		analyticsHandler,
+		couponHandler,
 	)
 
+	// Liveness and readiness probes
+	monitoring.NewHealthChecker("saas", db, cacheClient).Register(router)
+
 	// Create server
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%d", 8095),
@@ -108,6 +145,8 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down SaaS Admin service...")
+	log.Printf("Draining %d in-flight requests", metrics.InFlight())
+	stopWorkers()
 
 	// Give server 30 seconds to gracefully shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -127,6 +166,10 @@ func runMigrations(db *gorm.DB) error {
 		&models.Payment{},
 		&models.Invoice{},
 		&models.UsageRecord{},
+		&models.PlanChange{},
+		&models.Coupon{},
+		&models.CouponRedemption{},
+		&models.DunningAttempt{},
 		&models.WebhookEvent{},
 		&models.AdminUser{},
 		&models.AuditLog{},
@@ -136,16 +179,20 @@ func runMigrations(db *gorm.DB) error {
 func setupRoutes(
 	cfg *config.Config,
 	cacheClient *cache.Cache,
+	metrics *monitoring.Prometheus,
 	subscriptionHandler *handlers.SubscriptionHandler,
 	planHandler *handlers.PlanHandler,
 	paymentHandler *handlers.PaymentHandler,
 	adminHandler *handlers.AdminHandler,
 	analyticsHandler *handlers.AnalyticsHandler,
+	couponHandler *handlers.CouponHandler,
 ) *gin.Engine {
 	router := gin.New()
-	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
+	router.Use(middleware.LoggingMiddleware())
+	router.Use(middleware.RequestIDMiddleware())
 	router.Use(middleware.CORSMiddleware())
+	metrics.Register(router)
 
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
@@ -182,7 +229,7 @@ func setupRoutes(
 			payments := protected.Group("/payments")
 			{
 				payments.GET("", paymentHandler.GetPayments)
-				payments.POST("", paymentHandler.CreatePayment)
+				payments.POST("", middleware.IdempotencyMiddleware(cacheClient), paymentHandler.CreatePayment)
 				payments.GET("/:id", paymentHandler.GetPayment)
 				payments.POST("/:id/refund", paymentHandler.RefundPayment)
 			}
@@ -210,12 +257,24 @@ func setupRoutes(
 				plans.DELETE("/:id", planHandler.DeletePlan)
 			}
 
+			// Coupon management
+			coupons := superAdmin.Group("/coupons")
+			{
+				coupons.GET("", couponHandler.GetCoupons)
+				coupons.POST("", couponHandler.CreateCoupon)
+				coupons.GET("/:id", couponHandler.GetCoupon)
+				coupons.PUT("/:id", couponHandler.UpdateCoupon)
+				coupons.DELETE("/:id", couponHandler.DeleteCoupon)
+			}
+
 			// Subscription management
 			subscriptions := superAdmin.Group("/subscriptions")
 			{
 				subscriptions.GET("", adminHandler.GetAllSubscriptions)
 				subscriptions.GET("/:id", adminHandler.GetSubscriptionDetails)
 				subscriptions.PUT("/:id/status", adminHandler.UpdateSubscriptionStatus)
+				subscriptions.POST("/:id/extend-trial", adminHandler.ExtendTrial)
+				subscriptions.POST("/:id/convert-trial", adminHandler.ConvertTrial)
 			}
 
 			// Analytics
@@ -225,6 +284,7 @@ func setupRoutes(
 				analytics.GET("/revenue", analyticsHandler.GetRevenue)
 				analytics.GET("/subscriptions", analyticsHandler.GetSubscriptionMetrics)
 				analytics.GET("/tenants", analyticsHandler.GetTenantMetrics)
+				analytics.GET("/cohorts", analyticsHandler.GetCohortRetention)
 			}
 
 			// System management
@@ -11,13 +11,22 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	inventory "github.com/liquorpro/go-backend/internal/inventory/services"
 	"github.com/liquorpro/go-backend/internal/sales/handlers"
 	"github.com/liquorpro/go-backend/internal/sales/routes"
 	"github.com/liquorpro/go-backend/internal/sales/services"
+	"github.com/liquorpro/go-backend/pkg/shared/approval"
+	"github.com/liquorpro/go-backend/pkg/shared/audit"
 	"github.com/liquorpro/go-backend/pkg/shared/cache"
 	"github.com/liquorpro/go-backend/pkg/shared/config"
 	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/logger"
 	"github.com/liquorpro/go-backend/pkg/shared/middleware"
+	"github.com/liquorpro/go-backend/pkg/shared/monitoring"
+	"github.com/liquorpro/go-backend/pkg/shared/notification"
+	"github.com/liquorpro/go-backend/pkg/shared/permissions"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
 )
 
 func main() {
@@ -27,6 +36,16 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Structured JSON request logs, correlated across services by request ID
+	if err := logger.Initialize(logger.Config{
+		Level:       cfg.App.LogLevel,
+		Environment: cfg.App.Environment,
+		ServiceName: "sales",
+	}); err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer logger.Sync()
+
 	// Set Gin mode
 	if cfg.App.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -57,6 +76,7 @@ func main() {
 		Port:     cfg.Redis.Port,
 		Password: cfg.Redis.Password,
 		DB:       cfg.Redis.DB,
+		TTLs:     cache.NewTTLs(cfg.Cache),
 	}
 
 	redisCache, err := cache.NewCache(cacheConfig)
@@ -66,10 +86,33 @@ func main() {
 	defer redisCache.Close()
 
 	// Initialize services
-	dailySalesService := services.NewDailySalesService(db, redisCache)
-	salesService := services.NewSalesService(db, redisCache)
-	returnsService := services.NewReturnsService(db, redisCache)
-	dashboardService := services.NewDashboardService(db, redisCache)
+	settingsService := settings.NewService(db, redisCache)
+	permissionService := permissions.NewService(db, redisCache)
+	autoApprover := approval.NewAutoApprover(db, settingsService)
+	shopScopes := scope.NewResolver(db)
+	notifier := notification.NewService(db, notification.NewSender(cfg.Email))
+	stockService := inventory.NewStockService(db, redisCache, settingsService, notifier, cfg.Inventory)
+	dailySalesService := services.NewDailySalesService(db, redisCache, autoApprover, settingsService, stockService, cfg.RequestLimits)
+	salesService := services.NewSalesService(db, redisCache, settingsService)
+	returnsService := services.NewReturnsService(db, redisCache, settingsService, stockService)
+	dashboardService := services.NewDashboardService(db, redisCache, settingsService)
+	reportService := services.NewReportService(db, redisCache)
+	commissionService := services.NewCommissionService(db, redisCache)
+	targetService := services.NewTargetService(db, redisCache)
+	readAuditor := audit.NewReadAuditor(db, settingsService)
+	auditLogger := audit.NewLogger(db)
+	summaryMailer := services.NewDailySummaryMailer(db, redisCache, dashboardService, settingsService, notifier, cfg.Database.TimeZone)
+
+	// Email each tenant's summary of the previous day once its send time passes
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	services.NewDailySummaryWorker(summaryMailer, 5*time.Minute).Start(workerCtx)
+
+	// Pre-populate dashboards shortly before peak hours
+	if cfg.Cache.WarmDashboards {
+		dashboardWarmer := services.NewDashboardWarmer(db, redisCache, dashboardService, settingsService, cfg.Cache.WarmTimes, cfg.Database.TimeZone)
+		services.NewDashboardWarmWorker(dashboardWarmer, time.Minute).Start(workerCtx)
+	}
 
 	// Initialize handlers
 	salesHandlers := handlers.NewSalesHandlers(
@@ -77,6 +120,9 @@ func main() {
 		salesService,
 		returnsService,
 		dashboardService,
+		reportService,
+		commissionService,
+		targetService,
 	)
 
 	// Create router
@@ -86,10 +132,18 @@ func main() {
 	router.Use(gin.Recovery())
 	router.Use(middleware.LoggingMiddleware())
 	router.Use(middleware.RequestIDMiddleware())
+	router.Use(middleware.APIVersionMiddleware())
 	router.Use(middleware.CORSMiddleware())
 
+	// Request, database pool and cache metrics on /metrics
+	metrics := monitoring.NewPrometheus("sales", db.DB, redisCache)
+	metrics.Register(router)
+
+	// Liveness and readiness probes
+	monitoring.NewHealthChecker("sales", db.DB, redisCache).Register(router)
+
 	// Setup routes
-	routes.SetupRoutes(router, cfg, redisCache, salesHandlers)
+	routes.SetupRoutes(router, cfg, redisCache, salesHandlers, settingsService, permissionService, readAuditor, auditLogger, shopScopes)
 
 	// Start server
 	srv := &http.Server{
@@ -113,6 +167,8 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down Sales service...")
+	log.Printf("Draining %d in-flight requests", metrics.InFlight())
+	stopWorkers()
 
 	// Graceful shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
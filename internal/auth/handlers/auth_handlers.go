@@ -1,28 +1,47 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/liquorpro/go-backend/internal/auth/services"
+	"github.com/liquorpro/go-backend/pkg/shared/audit"
+	"github.com/liquorpro/go-backend/pkg/shared/permissions"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
+	"github.com/liquorpro/go-backend/pkg/shared/usage"
 	"github.com/liquorpro/go-backend/pkg/shared/validators"
+	"github.com/liquorpro/go-backend/pkg/shared/webhook"
 )
 
 // AuthHandlers handles HTTP requests for authentication
 type AuthHandlers struct {
-	authService   *services.AuthService
-	userService   *services.UserService
-	tenantService *services.TenantService
+	authService          *services.AuthService
+	userService          *services.UserService
+	tenantService        *services.TenantService
+	emailTemplateService *services.EmailTemplateService
+	settingsService      *settings.Service
+	permissionService    *permissions.Service
+	auditLogger          *audit.Logger
+	webhookService       *webhook.Service
 }
 
 // NewAuthHandlers creates new auth handlers
-func NewAuthHandlers(authService *services.AuthService, userService *services.UserService, tenantService *services.TenantService) *AuthHandlers {
+func NewAuthHandlers(authService *services.AuthService, userService *services.UserService, tenantService *services.TenantService, emailTemplateService *services.EmailTemplateService, settingsService *settings.Service, permissionService *permissions.Service, auditLogger *audit.Logger, webhookService *webhook.Service) *AuthHandlers {
 	return &AuthHandlers{
-		authService:   authService,
-		userService:   userService,
-		tenantService: tenantService,
+		authService:          authService,
+		userService:          userService,
+		tenantService:        tenantService,
+		emailTemplateService: emailTemplateService,
+		settingsService:      settingsService,
+		permissionService:    permissionService,
+		auditLogger:          auditLogger,
+		webhookService:       webhookService,
 	}
 }
 
@@ -206,6 +225,10 @@ func (h *AuthHandlers) UpdateProfile(c *gin.Context) {
 
 	user, err := h.userService.UpdateUser(c.Request.Context(), userID, tenantID, req)
 	if err != nil {
+		if status, ok := usage.HTTPStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -255,6 +278,140 @@ func (h *AuthHandlers) ChangePassword(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
 }
 
+// ForgotPassword emails a password reset link. It always reports success so the
+// response does not reveal which emails are registered.
+func (h *AuthHandlers) ForgotPassword(c *gin.Context) {
+	var req services.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.authService.RequestPasswordReset(c.Request.Context(), req.Email); err != nil {
+		fmt.Printf("Warning: Failed to process password reset request: %v\n", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If an account exists for that email, a password reset link has been sent"})
+}
+
+// ResetPassword sets a new password using a reset token
+func (h *AuthHandlers) ResetPassword(c *gin.Context) {
+	var req services.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	validator := validators.New()
+	validator.Required(req.Token, "token")
+	validator.Password(req.NewPassword, "new_password")
+
+	if validator.HasErrors() {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": validator.Errors()})
+		return
+	}
+
+	if err := h.authService.ResetPassword(c.Request.Context(), req.Token, req.NewPassword); err != nil {
+		if strings.HasPrefix(err.Error(), "failed to") {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
+}
+
+// EnrollTOTP starts two-factor enrollment for the current user
+func (h *AuthHandlers) EnrollTOTP(c *gin.Context) {
+	userID, tenantID, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.authService.EnrollTOTP(c.Request.Context(), userID, tenantID)
+	if err != nil {
+		h.twoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// VerifyTOTPEnrollment enables two-factor authentication and returns backup codes
+func (h *AuthHandlers) VerifyTOTPEnrollment(c *gin.Context) {
+	userID, tenantID, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req services.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	backupCodes, err := h.authService.VerifyTOTPEnrollment(c.Request.Context(), userID, tenantID, req.Code)
+	if err != nil {
+		h.twoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, backupCodes)
+}
+
+// DisableTOTP turns two-factor authentication off for the current user
+func (h *AuthHandlers) DisableTOTP(c *gin.Context) {
+	userID, tenantID, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req services.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.authService.DisableTOTP(c.Request.Context(), userID, tenantID, req.Code); err != nil {
+		h.twoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// currentUser reads the authenticated user and tenant IDs, responding with 400 when
+// either is missing
+func (h *AuthHandlers) currentUser(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, tenantID, true
+}
+
+// twoFactorError maps two-factor service errors to responses
+func (h *AuthHandlers) twoFactorError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "user not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "two-factor authentication is only available"):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "failed to"):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
 // User Management Endpoints (Admin only)
 
 // GetUsers returns paginated list of users
@@ -331,6 +488,10 @@ func (h *AuthHandlers) CreateUser(c *gin.Context) {
 
 	user, err := h.userService.CreateUser(c.Request.Context(), req, tenantID)
 	if err != nil {
+		if status, ok := usage.HTTPStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -399,6 +560,10 @@ func (h *AuthHandlers) UpdateUser(c *gin.Context) {
 
 	user, err := h.userService.UpdateUser(c.Request.Context(), userID, tenantID, req)
 	if err != nil {
+		if status, ok := usage.HTTPStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -406,6 +571,42 @@ func (h *AuthHandlers) UpdateUser(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
+// SetUserAutoApproval updates a user's auto-approval trust settings (Admin only)
+func (h *AuthHandlers) SetUserAutoApproval(c *gin.Context) {
+	tenantIDStr := c.GetString("tenant_id")
+	userIDStr := c.Param("id")
+
+	tenantID, err := uuid.Parse(tenantIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req services.AutoApprovalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.userService.SetAutoApproval(c.Request.Context(), userID, tenantID, req)
+	if err != nil {
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 // DeleteUser deletes user (Admin only)
 func (h *AuthHandlers) DeleteUser(c *gin.Context) {
 	tenantIDStr := c.GetString("tenant_id")
@@ -481,6 +682,10 @@ func (h *AuthHandlers) CreateShop(c *gin.Context) {
 
 	shop, err := h.tenantService.CreateShop(c.Request.Context(), req, tenantID)
 	if err != nil {
+		if status, ok := usage.HTTPStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -556,6 +761,191 @@ func (h *AuthHandlers) UpdateShop(c *gin.Context) {
 	c.JSON(http.StatusOK, shop)
 }
 
+// Shop Group Management Endpoints
+
+// GetShopGroups returns all shop groups
+func (h *AuthHandlers) GetShopGroups(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	groups, err := h.tenantService.GetShopGroups(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"shop_groups": groups})
+}
+
+// CreateShopGroup creates a new shop group
+func (h *AuthHandlers) CreateShopGroup(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	var req services.ShopGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group, err := h.tenantService.CreateShopGroup(c.Request.Context(), req, tenantID)
+	if err != nil {
+		h.shopGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, group)
+}
+
+// GetShopGroupByID returns shop group by ID
+func (h *AuthHandlers) GetShopGroupByID(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	groupID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop group ID"})
+		return
+	}
+
+	group, err := h.tenantService.GetShopGroupByID(c.Request.Context(), groupID, tenantID)
+	if err != nil {
+		h.shopGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// UpdateShopGroup updates shop group information
+func (h *AuthHandlers) UpdateShopGroup(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	groupID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop group ID"})
+		return
+	}
+
+	var req services.UpdateShopGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group, err := h.tenantService.UpdateShopGroup(c.Request.Context(), groupID, tenantID, req)
+	if err != nil {
+		h.shopGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// SetShopGroupShops replaces the shops in a shop group
+func (h *AuthHandlers) SetShopGroupShops(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	groupID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop group ID"})
+		return
+	}
+
+	var req services.ShopGroupShopsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group, err := h.tenantService.SetShopGroupShops(c.Request.Context(), groupID, tenantID, req.ShopIDs)
+	if err != nil {
+		h.shopGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// DeleteShopGroup deletes a shop group (Admin only)
+func (h *AuthHandlers) DeleteShopGroup(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	groupID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop group ID"})
+		return
+	}
+
+	if err := h.tenantService.DeleteShopGroup(c.Request.Context(), groupID, tenantID); err != nil {
+		h.shopGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Shop group deleted successfully"})
+}
+
+// AssignUserShopGroup sets the shop group a regional manager oversees
+func (h *AuthHandlers) AssignUserShopGroup(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req services.ShopGroupAssignmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.tenantService.AssignShopGroup(c.Request.Context(), userID, tenantID, req.ShopGroupID)
+	if err != nil {
+		h.shopGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *AuthHandlers) shopGroupError(c *gin.Context, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "failed to"):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
 // Salesman Management Endpoints
 
 // GetSalesmen returns all salesmen
@@ -681,36 +1071,737 @@ func (h *AuthHandlers) UpdateSalesman(c *gin.Context) {
 	c.JSON(http.StatusOK, salesman)
 }
 
-// SaaS Admin Endpoints (placeholder implementations)
-
-// GetTenants returns all tenants (SaaS Admin only)
-func (h *AuthHandlers) GetTenants(c *gin.Context) {
-	// TODO: Implement tenant listing for SaaS admins
-	c.JSON(http.StatusNotImplemented, gin.H{"message": "Not implemented yet"})
-}
+// Email Template Endpoints (Admin only)
 
-// CreateTenant creates a new tenant (SaaS Admin only)
-func (h *AuthHandlers) CreateTenant(c *gin.Context) {
-	// TODO: Implement tenant creation for SaaS admins
-	c.JSON(http.StatusNotImplemented, gin.H{"message": "Not implemented yet"})
-}
+// GetEmailTemplates returns the tenant's custom email templates and the built-in defaults
+func (h *AuthHandlers) GetEmailTemplates(c *gin.Context) {
+	tenantIDStr := c.GetString("tenant_id")
+	tenantID, err := uuid.Parse(tenantIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
 
-// GetTenantByID returns tenant by ID (SaaS Admin only)
-func (h *AuthHandlers) GetTenantByID(c *gin.Context) {
-	// TODO: Implement tenant retrieval for SaaS admins
-	c.JSON(http.StatusNotImplemented, gin.H{"message": "Not implemented yet"})
+	templates, err := h.emailTemplateService.GetEmailTemplates(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"templates": templates,
+		"defaults":  h.emailTemplateService.GetDefaultEmailTemplates(),
+	})
+}
+
+// CreateEmailTemplate creates a custom email template
+func (h *AuthHandlers) CreateEmailTemplate(c *gin.Context) {
+	tenantIDStr := c.GetString("tenant_id")
+	tenantID, err := uuid.Parse(tenantIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	var req services.EmailTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := h.emailTemplateService.CreateEmailTemplate(c.Request.Context(), req, tenantID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// GetEmailTemplateByID returns an email template by ID
+func (h *AuthHandlers) GetEmailTemplateByID(c *gin.Context) {
+	tenantIDStr := c.GetString("tenant_id")
+	templateIDStr := c.Param("id")
+
+	tenantID, err := uuid.Parse(tenantIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	templateID, err := uuid.Parse(templateIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	template, err := h.emailTemplateService.GetEmailTemplateByID(c.Request.Context(), templateID, tenantID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// UpdateEmailTemplate updates a custom email template
+func (h *AuthHandlers) UpdateEmailTemplate(c *gin.Context) {
+	tenantIDStr := c.GetString("tenant_id")
+	templateIDStr := c.Param("id")
+
+	tenantID, err := uuid.Parse(tenantIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	templateID, err := uuid.Parse(templateIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	var req services.EmailTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := h.emailTemplateService.UpdateEmailTemplate(c.Request.Context(), templateID, tenantID, req)
+	if err != nil {
+		if err.Error() == "email template not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// DeleteEmailTemplate deletes a custom email template, reverting to the default
+func (h *AuthHandlers) DeleteEmailTemplate(c *gin.Context) {
+	tenantIDStr := c.GetString("tenant_id")
+	templateIDStr := c.Param("id")
+
+	tenantID, err := uuid.Parse(tenantIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	templateID, err := uuid.Parse(templateIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	if err := h.emailTemplateService.DeleteEmailTemplate(c.Request.Context(), templateID, tenantID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email template deleted successfully"})
+}
+
+// PreviewEmailTemplate renders a template with sample variables without sending it
+func (h *AuthHandlers) PreviewEmailTemplate(c *gin.Context) {
+	tenantIDStr := c.GetString("tenant_id")
+	tenantID, err := uuid.Parse(tenantIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	var req services.EmailTemplatePreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preview, err := h.emailTemplateService.PreviewEmailTemplate(c.Request.Context(), req, tenantID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// Tenant Settings Endpoints
+
+// GetSettings returns the tenant's settings with defaults and their definitions
+func (h *AuthHandlers) GetSettings(c *gin.Context) {
+	tenantIDStr := c.GetString("tenant_id")
+	tenantID, err := uuid.Parse(tenantIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	values, err := h.settingsService.Get(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"settings":    values,
+		"definitions": settings.Definitions(),
+	})
+}
+
+// UpdateSettings validates and stores tenant settings (Admin only)
+func (h *AuthHandlers) UpdateSettings(c *gin.Context) {
+	tenantIDStr := c.GetString("tenant_id")
+	userIDStr := c.GetString("user_id")
+
+	tenantID, err := uuid.Parse(tenantIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req settings.UpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	values, err := h.settingsService.Update(c.Request.Context(), tenantID, userID, req.Settings)
+	if err != nil {
+		var validationErr *settings.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErr.Errors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"settings": values})
+}
+
+// GetApprovers returns which roles may approve each entity type
+func (h *AuthHandlers) GetApprovers(c *gin.Context) {
+	tenantIDStr := c.GetString("tenant_id")
+	tenantID, err := uuid.Parse(tenantIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"approvers": h.settingsService.GetApprovers(c.Request.Context(), tenantID),
+		"entities":  settings.ApprovalEntities(),
+		"roles":     settings.ApproverRoleOptions(),
+	})
+}
+
+// UpdateApprovers changes which roles may approve each entity type (Admin only)
+func (h *AuthHandlers) UpdateApprovers(c *gin.Context) {
+	tenantIDStr := c.GetString("tenant_id")
+	userIDStr := c.GetString("user_id")
+
+	tenantID, err := uuid.Parse(tenantIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req settings.ApproversRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	approvers, err := h.settingsService.UpdateApprovers(c.Request.Context(), tenantID, userID, req.Approvers)
+	if err != nil {
+		var validationErr *settings.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErr.Errors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"approvers": approvers})
+}
+
+// GetRolePermissions returns the permission catalog and what each role holds
+func (h *AuthHandlers) GetRolePermissions(c *gin.Context) {
+	tenantIDStr := c.GetString("tenant_id")
+	tenantID, err := uuid.Parse(tenantIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"permissions": permissions.Catalog,
+		"roles":       h.permissionService.Matrix(c.Request.Context(), tenantID),
+	})
+}
+
+// UpdateRolePermissions sets the permissions a role holds in the tenant (Admin only)
+func (h *AuthHandlers) UpdateRolePermissions(c *gin.Context) {
+	tenantIDStr := c.GetString("tenant_id")
+	tenantID, err := uuid.Parse(tenantIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	var req permissions.RolePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role := c.Param("role")
+	granted, err := h.permissionService.SetRolePermissions(c.Request.Context(), tenantID, role, req.Permissions)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "failed to") {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"role": role, "permissions": granted})
+}
+
+// ResetRolePermissions restores a role's default permissions (Admin only)
+func (h *AuthHandlers) ResetRolePermissions(c *gin.Context) {
+	tenantIDStr := c.GetString("tenant_id")
+	tenantID, err := uuid.Parse(tenantIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	role := c.Param("role")
+	granted, err := h.permissionService.ResetRolePermissions(c.Request.Context(), tenantID, role)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "failed to") {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"role": role, "permissions": granted})
+}
+
+// GetSettingsHistory returns the audit trail of settings changes
+func (h *AuthHandlers) GetSettingsHistory(c *gin.Context) {
+	tenantIDStr := c.GetString("tenant_id")
+	tenantID, err := uuid.Parse(tenantIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	changes, total, err := h.settingsService.GetAudit(c.Request.Context(), tenantID, c.Query("key"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"changes": changes,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+// GetAuditLogs searches the tenant's audit log by entity, actor, action and date
+func (h *AuthHandlers) GetAuditLogs(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	filter := audit.AuditLogFilter{
+		EntityType: c.Query("entity_type"),
+		Action:     c.Query("action"),
+	}
+	if entityID := c.Query("entity_id"); entityID != "" {
+		parsed, err := uuid.Parse(entityID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity ID"})
+			return
+		}
+		filter.EntityID = &parsed
+	}
+	if userID := c.Query("user_id"); userID != "" {
+		parsed, err := uuid.Parse(userID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		filter.UserID = &parsed
+	}
+	if start := c.Query("start_date"); start != "" {
+		parsed, err := time.Parse("2006-01-02", start)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must be YYYY-MM-DD"})
+			return
+		}
+		filter.Start = &parsed
+	}
+	if end := c.Query("end_date"); end != "" {
+		parsed, err := time.Parse("2006-01-02", end)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must be YYYY-MM-DD"})
+			return
+		}
+		parsed = parsed.AddDate(0, 0, 1)
+		filter.End = &parsed
+	}
+
+	logs, total, err := h.auditLogger.GetAuditLogs(c.Request.Context(), tenantID, filter, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"audit_logs": logs,
+		"total":      total,
+		"limit":      limit,
+		"offset":     offset,
+	})
+}
+
+// Webhook Endpoints
+
+// GetWebhookEvents lists the event types a webhook can subscribe to
+func (h *AuthHandlers) GetWebhookEvents(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"events": webhook.Events()})
+}
+
+// GetWebhooks lists the tenant's webhooks (Admin only)
+func (h *AuthHandlers) GetWebhooks(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	hooks, err := h.webhookService.GetWebhooks(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": hooks})
+}
+
+// CreateWebhook registers a webhook. The signing secret is only returned here and
+// when it is rotated. (Admin only)
+func (h *AuthHandlers) CreateWebhook(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req webhook.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hook, err := h.webhookService.CreateWebhook(c.Request.Context(), tenantID, userID, req)
+	if err != nil {
+		h.webhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, hook)
+}
+
+// UpdateWebhook changes a webhook or rotates its secret (Admin only)
+func (h *AuthHandlers) UpdateWebhook(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+	webhookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	var req webhook.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hook, err := h.webhookService.UpdateWebhook(c.Request.Context(), webhookID, tenantID, req)
+	if err != nil {
+		h.webhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, hook)
+}
+
+// DeleteWebhook removes a webhook (Admin only)
+func (h *AuthHandlers) DeleteWebhook(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+	webhookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(c.Request.Context(), webhookID, tenantID); err != nil {
+		h.webhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// GetWebhookDeliveries lists delivery attempts, filtered by webhook_id, status and
+// event_type (Admin only)
+func (h *AuthHandlers) GetWebhookDeliveries(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	filter := webhook.DeliveryFilter{
+		Status:    c.Query("status"),
+		EventType: c.Query("event_type"),
+	}
+	if webhookID := c.Query("webhook_id"); webhookID != "" {
+		parsed, err := uuid.Parse(webhookID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+			return
+		}
+		filter.WebhookID = &parsed
+	}
+
+	deliveries, total, err := h.webhookService.GetDeliveries(c.Request.Context(), tenantID, filter, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"total":      total,
+		"limit":      limit,
+		"offset":     offset,
+	})
+}
+
+// RetryWebhookDelivery sends a delivery again immediately (Admin only)
+func (h *AuthHandlers) RetryWebhookDelivery(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+	deliveryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+
+	delivery, err := h.webhookService.RetryDelivery(c.Request.Context(), deliveryID, tenantID)
+	if err != nil {
+		h.webhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// webhookError maps webhook service errors to HTTP statuses
+func (h *AuthHandlers) webhookError(c *gin.Context, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "failed to"):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+// SaaS Admin Endpoints
+
+// GetTenants returns a page of tenants, optionally searched by company name (super admin only)
+func (h *AuthHandlers) GetTenants(c *gin.Context) {
+	page := 1
+	pageSize := 20
+
+	if p := c.Query("page"); p != "" {
+		if parsed, err := strconv.Atoi(p); err == nil && parsed > 0 {
+			page = parsed
+		}
+	}
+
+	if ps := c.Query("page_size"); ps != "" {
+		if parsed, err := strconv.Atoi(ps); err == nil && parsed > 0 && parsed <= 100 {
+			pageSize = parsed
+		}
+	}
+
+	tenants, err := h.tenantService.GetTenants(c.Request.Context(), c.Query("search"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tenants)
+}
+
+// CreateTenant creates a tenant with its initial admin user (super admin only)
+func (h *AuthHandlers) CreateTenant(c *gin.Context) {
+	var req services.CreateTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, err := h.tenantService.CreateTenant(c.Request.Context(), req)
+	if err != nil {
+		h.tenantError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, tenant)
+}
+
+// GetTenantByID returns a tenant with its shop and user counts (super admin only)
+func (h *AuthHandlers) GetTenantByID(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	tenant, err := h.tenantService.GetTenantByID(c.Request.Context(), tenantID)
+	if err != nil {
+		h.tenantError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tenant)
 }
 
-// UpdateTenant updates tenant (SaaS Admin only)
+// UpdateTenant updates a tenant's company details (super admin only)
 func (h *AuthHandlers) UpdateTenant(c *gin.Context) {
-	// TODO: Implement tenant updates for SaaS admins
-	c.JSON(http.StatusNotImplemented, gin.H{"message": "Not implemented yet"})
+	tenantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	var req services.UpdateTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, err := h.tenantService.UpdateTenant(c.Request.Context(), tenantID, req)
+	if err != nil {
+		h.tenantError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tenant)
 }
 
-// DeleteTenant deletes tenant (SaaS Admin only)
+// DeleteTenant soft deletes a tenant and deactivates its users (super admin only)
 func (h *AuthHandlers) DeleteTenant(c *gin.Context) {
-	// TODO: Implement tenant deletion for SaaS admins
-	c.JSON(http.StatusNotImplemented, gin.H{"message": "Not implemented yet"})
+	tenantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	if err := h.tenantService.DeleteTenant(c.Request.Context(), tenantID); err != nil {
+		h.tenantError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tenant deleted successfully"})
+}
+
+func (h *AuthHandlers) tenantError(c *gin.Context, err error) {
+	switch {
+	case strings.HasPrefix(err.Error(), "tenant has an active subscription"):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		h.shopGroupError(c, err)
+	}
 }
 
 // GetAllUsers returns users across all tenants (SaaS Admin only)
@@ -18,7 +18,9 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, aut
 	{
 		auth.POST("/login", authHandlers.Login)
 		auth.POST("/register", authHandlers.Register)
-		// TODO: Add forgot-password, reset-password, verify-email endpoints
+		auth.POST("/forgot-password", authHandlers.ForgotPassword)
+		auth.POST("/reset-password", authHandlers.ResetPassword)
+		// TODO: Add verify-email endpoint
 	}
 
 	// Protected authentication routes
@@ -31,6 +33,11 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, aut
 		authProtected.GET("/profile", authHandlers.GetProfile)
 		authProtected.PUT("/profile", authHandlers.UpdateProfile)
 		authProtected.PUT("/change-password", authHandlers.ChangePassword)
+
+		// Two-factor authentication
+		authProtected.POST("/2fa/enroll", middleware.RoleMiddleware("admin", "manager"), authHandlers.EnrollTOTP)
+		authProtected.POST("/2fa/verify", middleware.RoleMiddleware("admin", "manager"), authHandlers.VerifyTOTPEnrollment)
+		authProtected.POST("/2fa/disable", authHandlers.DisableTOTP)
 	}
 
 	// Admin routes for user management
@@ -44,6 +51,8 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, aut
 		admin.POST("/users", authHandlers.CreateUser)
 		admin.GET("/users/:id", authHandlers.GetUserByID)
 		admin.PUT("/users/:id", authHandlers.UpdateUser)
+		admin.PUT("/users/:id/auto-approval", middleware.RoleMiddleware("admin"), authHandlers.SetUserAutoApproval)
+		admin.PUT("/users/:id/shop-group", middleware.RoleMiddleware("admin"), authHandlers.AssignUserShopGroup)
 		admin.DELETE("/users/:id", middleware.RoleMiddleware("admin"), authHandlers.DeleteUser) // Only admin can delete
 
 		// Shop management
@@ -52,24 +61,85 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, aut
 		admin.GET("/shops/:id", authHandlers.GetShopByID)
 		admin.PUT("/shops/:id", authHandlers.UpdateShop)
 
+		// Shop group management (regional manager scopes)
+		admin.GET("/shop-groups", authHandlers.GetShopGroups)
+		admin.POST("/shop-groups", middleware.RoleMiddleware("admin"), authHandlers.CreateShopGroup)
+		admin.GET("/shop-groups/:id", authHandlers.GetShopGroupByID)
+		admin.PUT("/shop-groups/:id", middleware.RoleMiddleware("admin"), authHandlers.UpdateShopGroup)
+		admin.PUT("/shop-groups/:id/shops", middleware.RoleMiddleware("admin"), authHandlers.SetShopGroupShops)
+		admin.DELETE("/shop-groups/:id", middleware.RoleMiddleware("admin"), authHandlers.DeleteShopGroup)
+
 		// Salesman management
 		admin.GET("/salesmen", authHandlers.GetSalesmen)
 		admin.POST("/salesmen", authHandlers.CreateSalesman)
 		admin.GET("/salesmen/:id", authHandlers.GetSalesmanByID)
 		admin.PUT("/salesmen/:id", authHandlers.UpdateSalesman)
+
+		// Email template management
+		admin.GET("/email-templates", authHandlers.GetEmailTemplates)
+		admin.POST("/email-templates", middleware.RoleMiddleware("admin"), authHandlers.CreateEmailTemplate)
+		admin.POST("/email-templates/preview", authHandlers.PreviewEmailTemplate)
+		admin.GET("/email-templates/:id", authHandlers.GetEmailTemplateByID)
+		admin.PUT("/email-templates/:id", middleware.RoleMiddleware("admin"), authHandlers.UpdateEmailTemplate)
+		admin.DELETE("/email-templates/:id", middleware.RoleMiddleware("admin"), authHandlers.DeleteEmailTemplate)
+
+		// Role permission matrix
+		admin.GET("/permissions", authHandlers.GetRolePermissions)
+		admin.PUT("/permissions/:role", middleware.RoleMiddleware("admin"), authHandlers.UpdateRolePermissions)
+		admin.DELETE("/permissions/:role", middleware.RoleMiddleware("admin"), authHandlers.ResetRolePermissions)
+	}
+
+	// Tenant settings routes
+	tenantSettings := router.Group("/api/settings")
+	tenantSettings.Use(middleware.AuthMiddleware(cfg.JWT, cache))
+	tenantSettings.Use(middleware.TenantMiddleware())
+	{
+		tenantSettings.GET("", authHandlers.GetSettings)
+		tenantSettings.PUT("", middleware.RoleMiddleware("admin"), authHandlers.UpdateSettings)
+		tenantSettings.GET("/history", middleware.RoleMiddleware("admin", "manager"), authHandlers.GetSettingsHistory)
+		tenantSettings.GET("/approvers", middleware.RoleMiddleware("admin", "manager"), authHandlers.GetApprovers)
+		tenantSettings.PUT("/approvers", middleware.RoleMiddleware("admin"), authHandlers.UpdateApprovers)
+	}
+
+	// Tenant audit log of writes, approvals and audited reads
+	auditLogs := router.Group("/api/audit-logs")
+	auditLogs.Use(middleware.AuthMiddleware(cfg.JWT, cache))
+	auditLogs.Use(middleware.TenantMiddleware())
+	auditLogs.Use(middleware.RoleMiddleware("admin", "manager"))
+	{
+		auditLogs.GET("", authHandlers.GetAuditLogs)
+	}
+
+	// Tenant webhooks for outbound event notifications
+	webhooks := router.Group("/api/webhooks")
+	webhooks.Use(middleware.AuthMiddleware(cfg.JWT, cache))
+	webhooks.Use(middleware.TenantMiddleware())
+	webhooks.Use(middleware.RoleMiddleware("admin"))
+	{
+		webhooks.GET("", authHandlers.GetWebhooks)
+		webhooks.POST("", authHandlers.CreateWebhook)
+		webhooks.GET("/events", authHandlers.GetWebhookEvents)
+		webhooks.GET("/deliveries", authHandlers.GetWebhookDeliveries)
+		webhooks.POST("/deliveries/:id/retry", authHandlers.RetryWebhookDelivery)
+		webhooks.PUT("/:id", authHandlers.UpdateWebhook)
+		webhooks.DELETE("/:id", authHandlers.DeleteWebhook)
 	}
 
 	// SaaS Admin routes (super admin functionality)
 	saasAdmin := router.Group("/api/saas-admin")
 	saasAdmin.Use(middleware.AuthMiddleware(cfg.JWT, cache))
-	saasAdmin.Use(middleware.RoleMiddleware("saas_admin"))
+	saasAdmin.Use(middleware.RoleMiddleware("saas_admin", "super_admin"))
 	{
-		// Tenant management
-		saasAdmin.GET("/tenants", authHandlers.GetTenants)
-		saasAdmin.POST("/tenants", authHandlers.CreateTenant)
-		saasAdmin.GET("/tenants/:id", authHandlers.GetTenantByID)
-		saasAdmin.PUT("/tenants/:id", authHandlers.UpdateTenant)
-		saasAdmin.DELETE("/tenants/:id", authHandlers.DeleteTenant)
+		// Tenant management (super admin only)
+		tenants := saasAdmin.Group("/tenants")
+		tenants.Use(middleware.RoleMiddleware("super_admin"))
+		{
+			tenants.GET("", authHandlers.GetTenants)
+			tenants.POST("", authHandlers.CreateTenant)
+			tenants.GET("/:id", authHandlers.GetTenantByID)
+			tenants.PUT("/:id", authHandlers.UpdateTenant)
+			tenants.DELETE("/:id", authHandlers.DeleteTenant)
+		}
 		
 		// Global user management (across all tenants)
 		saasAdmin.GET("/all-users", authHandlers.GetAllUsers)
@@ -88,6 +158,8 @@ func SetupPublicRoutes(router *gin.Engine, authHandlers *handlers.AuthHandlers)
 	// Public authentication routes
 	router.POST("/login", authHandlers.Login)
 	router.POST("/register", authHandlers.Register)
+	router.POST("/forgot-password", authHandlers.ForgotPassword)
+	router.POST("/reset-password", authHandlers.ResetPassword)
 }
 
 // SetupProtectedRoutes sets up only protected routes (for gateway routing)
@@ -102,6 +174,16 @@ func SetupProtectedRoutes(router *gin.Engine, cfg *config.Config, cache *cache.C
 	router.GET("/profile", authHandlers.GetProfile)
 	router.PUT("/profile", authHandlers.UpdateProfile)
 	router.PUT("/change-password", authHandlers.ChangePassword)
+	router.POST("/2fa/enroll", middleware.RoleMiddleware("admin", "manager"), authHandlers.EnrollTOTP)
+	router.POST("/2fa/verify", middleware.RoleMiddleware("admin", "manager"), authHandlers.VerifyTOTPEnrollment)
+	router.POST("/2fa/disable", authHandlers.DisableTOTP)
+
+	// Tenant settings routes
+	router.GET("/settings", authHandlers.GetSettings)
+	router.PUT("/settings", middleware.RoleMiddleware("admin"), authHandlers.UpdateSettings)
+	router.GET("/settings/history", middleware.RoleMiddleware("admin", "manager"), authHandlers.GetSettingsHistory)
+	router.GET("/settings/approvers", middleware.RoleMiddleware("admin", "manager"), authHandlers.GetApprovers)
+	router.PUT("/settings/approvers", middleware.RoleMiddleware("admin"), authHandlers.UpdateApprovers)
 
 	// Admin routes
 	admin := router.Group("/admin")
@@ -112,6 +194,8 @@ func SetupProtectedRoutes(router *gin.Engine, cfg *config.Config, cache *cache.C
 		admin.POST("/users", authHandlers.CreateUser)
 		admin.GET("/users/:id", authHandlers.GetUserByID)
 		admin.PUT("/users/:id", authHandlers.UpdateUser)
+		admin.PUT("/users/:id/auto-approval", middleware.RoleMiddleware("admin"), authHandlers.SetUserAutoApproval)
+		admin.PUT("/users/:id/shop-group", middleware.RoleMiddleware("admin"), authHandlers.AssignUserShopGroup)
 		admin.DELETE("/users/:id", middleware.RoleMiddleware("admin"), authHandlers.DeleteUser)
 
 		// Shop management
@@ -120,10 +204,31 @@ func SetupProtectedRoutes(router *gin.Engine, cfg *config.Config, cache *cache.C
 		admin.GET("/shops/:id", authHandlers.GetShopByID)
 		admin.PUT("/shops/:id", authHandlers.UpdateShop)
 
+		// Shop group management (regional manager scopes)
+		admin.GET("/shop-groups", authHandlers.GetShopGroups)
+		admin.POST("/shop-groups", middleware.RoleMiddleware("admin"), authHandlers.CreateShopGroup)
+		admin.GET("/shop-groups/:id", authHandlers.GetShopGroupByID)
+		admin.PUT("/shop-groups/:id", middleware.RoleMiddleware("admin"), authHandlers.UpdateShopGroup)
+		admin.PUT("/shop-groups/:id/shops", middleware.RoleMiddleware("admin"), authHandlers.SetShopGroupShops)
+		admin.DELETE("/shop-groups/:id", middleware.RoleMiddleware("admin"), authHandlers.DeleteShopGroup)
+
 		// Salesman management
 		admin.GET("/salesmen", authHandlers.GetSalesmen)
 		admin.POST("/salesmen", authHandlers.CreateSalesman)
 		admin.GET("/salesmen/:id", authHandlers.GetSalesmanByID)
 		admin.PUT("/salesmen/:id", authHandlers.UpdateSalesman)
+
+		// Email template management
+		admin.GET("/email-templates", authHandlers.GetEmailTemplates)
+		admin.POST("/email-templates", middleware.RoleMiddleware("admin"), authHandlers.CreateEmailTemplate)
+		admin.POST("/email-templates/preview", authHandlers.PreviewEmailTemplate)
+		admin.GET("/email-templates/:id", authHandlers.GetEmailTemplateByID)
+		admin.PUT("/email-templates/:id", middleware.RoleMiddleware("admin"), authHandlers.UpdateEmailTemplate)
+		admin.DELETE("/email-templates/:id", middleware.RoleMiddleware("admin"), authHandlers.DeleteEmailTemplate)
+
+		// Role permission matrix
+		admin.GET("/permissions", authHandlers.GetRolePermissions)
+		admin.PUT("/permissions/:role", middleware.RoleMiddleware("admin"), authHandlers.UpdateRolePermissions)
+		admin.DELETE("/permissions/:role", middleware.RoleMiddleware("admin"), authHandlers.ResetRolePermissions)
 	}
 }
\ No newline at end of file
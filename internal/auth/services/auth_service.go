@@ -12,23 +12,29 @@ import (
 	"github.com/liquorpro/go-backend/pkg/shared/config"
 	"github.com/liquorpro/go-backend/pkg/shared/database"
 	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/notification"
 	"github.com/liquorpro/go-backend/pkg/shared/utils"
 	"gorm.io/gorm"
 )
 
 // AuthService handles authentication operations
 type AuthService struct {
-	db     *database.DB
-	cache  *cache.Cache
-	config *config.JWTConfig
+	db       *database.DB
+	cache    *cache.Cache
+	config   *config.JWTConfig
+	notifier *notification.Service
+	resetURL string
 }
 
-// NewAuthService creates a new auth service
-func NewAuthService(db *database.DB, cache *cache.Cache, jwtConfig *config.JWTConfig) *AuthService {
+// NewAuthService creates a new auth service. resetURL is the frontend page linked
+// from password reset emails.
+func NewAuthService(db *database.DB, cache *cache.Cache, jwtConfig *config.JWTConfig, notifier *notification.Service, resetURL string) *AuthService {
 	return &AuthService{
-		db:     db,
-		cache:  cache,
-		config: jwtConfig,
+		db:       db,
+		cache:    cache,
+		config:   jwtConfig,
+		notifier: notifier,
+		resetURL: resetURL,
 	}
 }
 
@@ -36,6 +42,7 @@ func NewAuthService(db *database.DB, cache *cache.Cache, jwtConfig *config.JWTCo
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	TOTPCode string `json:"totp_code"` // authenticator or backup code, when 2FA is enabled
 }
 
 // LoginResponse represents login response data
@@ -45,6 +52,9 @@ type LoginResponse struct {
 	ExpiresAt    time.Time        `json:"expires_at"`
 	User         *UserResponse    `json:"user"`
 	Tenant       *TenantResponse  `json:"tenant"`
+
+	// Set, with no tokens, when the password was correct but a two-factor code is needed
+	RequiresTwoFactor bool `json:"requires_two_factor,omitempty"`
 }
 
 // UserResponse represents user data in responses
@@ -109,14 +119,24 @@ func (s *AuthService) Login(ctx context.Context, req LoginRequest) (*LoginRespon
 		return nil, errors.New("tenant account is inactive")
 	}
 
+	// Require the second factor when the account has 2FA enabled
+	if user.TOTPEnabled {
+		if req.TOTPCode == "" {
+			return &LoginResponse{RequiresTwoFactor: true}, nil
+		}
+		if err := s.verifySecondFactor(ctx, &user, req.TOTPCode); err != nil {
+			return nil, err
+		}
+	}
+
 	// Generate JWT token
 	token, expiresAt, err := s.generateJWTToken(&user)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
-	// Generate refresh token
-	refreshToken, err := s.generateRefreshToken(&user)
+	// Generate refresh token, starting a new token family for this login
+	refreshToken, err := s.issueRefreshToken(ctx, user.ID, uuid.Nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
@@ -124,11 +144,10 @@ func (s *AuthService) Login(ctx context.Context, req LoginRequest) (*LoginRespon
 	// Store session in cache
 	sessionKey := fmt.Sprintf(cache.UserSessionKey, user.ID.String())
 	sessionData := map[string]interface{}{
-		"user_id":       user.ID.String(),
-		"tenant_id":     user.TenantID.String(),
-		"role":          user.Role,
-		"login_time":    time.Now(),
-		"refresh_token": refreshToken,
+		"user_id":    user.ID.String(),
+		"tenant_id":  user.TenantID.String(),
+		"role":       user.Role,
+		"login_time": time.Now(),
 	}
 
 	if err := s.cache.Set(ctx, sessionKey, sessionData, cache.SessionTTL); err != nil {
@@ -198,7 +217,7 @@ func (s *AuthService) Register(ctx context.Context, req RegisterRequest) (*Login
 			return fmt.Errorf("failed to generate token: %w", err)
 		}
 
-		refreshToken, err := s.generateRefreshToken(&user)
+		refreshToken, err := s.issueRefreshToken(ctx, user.ID, uuid.Nil)
 		if err != nil {
 			return fmt.Errorf("failed to generate refresh token: %w", err)
 		}
@@ -206,11 +225,10 @@ func (s *AuthService) Register(ctx context.Context, req RegisterRequest) (*Login
 		// Store session in cache
 		sessionKey := fmt.Sprintf(cache.UserSessionKey, user.ID.String())
 		sessionData := map[string]interface{}{
-			"user_id":       user.ID.String(),
-			"tenant_id":     user.TenantID.String(),
-			"role":          user.Role,
-			"login_time":    time.Now(),
-			"refresh_token": refreshToken,
+			"user_id":    user.ID.String(),
+			"tenant_id":  user.TenantID.String(),
+			"role":       user.Role,
+			"login_time": time.Now(),
 		}
 
 		if err := s.cache.Set(ctx, sessionKey, sessionData, cache.SessionTTL); err != nil {
@@ -230,52 +248,62 @@ func (s *AuthService) Register(ctx context.Context, req RegisterRequest) (*Login
 	if err != nil {
 		return nil, err
 	}
+
+	// Send welcome email in the background; registration must not fail on mail errors
+	go func(user *UserResponse, tenant *TenantResponse) {
+		vars := map[string]interface{}{
+			"user_name":    user.FirstName,
+			"username":     user.Username,
+			"company_name": tenant.Name,
+		}
+		if err := s.notifier.Send(context.Background(), tenant.ID, models.EmailEventWelcome, []string{user.Email}, vars); err != nil {
+			fmt.Printf("Warning: Failed to send welcome email: %v\n", err)
+		}
+	}(result.User, result.Tenant)
 	
 	return result, nil
 }
 
-// Logout invalidates user session
+// Logout invalidates user session and revokes all of the user's refresh tokens
 func (s *AuthService) Logout(ctx context.Context, userID uuid.UUID) error {
+	if err := s.revokeUserRefreshTokens(ctx, userID); err != nil {
+		return err
+	}
 	sessionKey := fmt.Sprintf(cache.UserSessionKey, userID.String())
 	return s.cache.Delete(ctx, sessionKey)
 }
 
-// RefreshToken generates a new access token using refresh token
+// RefreshToken generates a new access token using refresh token. The refresh token
+// is rotated: it cannot be used again, and the response carries its replacement.
 func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string, userID uuid.UUID) (*LoginResponse, error) {
-	// Verify refresh token from cache
-	sessionKey := fmt.Sprintf(cache.UserSessionKey, userID.String())
-	var sessionData map[string]interface{}
-	
-	if err := s.cache.Get(ctx, sessionKey, &sessionData); err != nil {
-		return nil, errors.New("invalid refresh token")
-	}
-
-	storedRefreshToken, ok := sessionData["refresh_token"].(string)
-	if !ok || storedRefreshToken != refreshToken {
-		return nil, errors.New("invalid refresh token")
-	}
-
 	// Get user from database
 	var user models.User
 	err := s.db.Where("id = ?", userID).Preload("Tenant").First(&user).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to find user: %w", err)
 	}
+	if !user.IsActive {
+		return nil, errors.New("account is inactive")
+	}
 
-	// Generate new tokens
-	newToken, expiresAt, err := s.generateJWTToken(&user)
+	newRefreshToken, err := s.rotateRefreshToken(ctx, refreshToken, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+		return nil, err
 	}
 
-	newRefreshToken, err := s.generateRefreshToken(&user)
+	newToken, expiresAt, err := s.generateJWTToken(&user)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
 	// Update session in cache
-	sessionData["refresh_token"] = newRefreshToken
-	sessionData["login_time"] = time.Now()
+	sessionKey := fmt.Sprintf(cache.UserSessionKey, userID.String())
+	sessionData := map[string]interface{}{
+		"user_id":    user.ID.String(),
+		"tenant_id":  user.TenantID.String(),
+		"role":       user.Role,
+		"login_time": time.Now(),
+	}
 
 	if err := s.cache.Set(ctx, sessionKey, sessionData, cache.SessionTTL); err != nil {
 		fmt.Printf("Warning: Failed to update session in cache: %v\n", err)
@@ -334,15 +362,6 @@ func (s *AuthService) generateJWTToken(user *models.User) (string, time.Time, er
 	return tokenString, expiresAt, nil
 }
 
-// generateRefreshToken creates a refresh token
-func (s *AuthService) generateRefreshToken(user *models.User) (string, error) {
-	refreshToken, err := utils.GenerateRandomString(64)
-	if err != nil {
-		return "", err
-	}
-	return refreshToken, nil
-}
-
 // mapUserToResponse converts user model to response format
 func (s *AuthService) mapUserToResponse(user *models.User) *UserResponse {
 	return &UserResponse{
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/cache"
+	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/notification"
+	"gorm.io/gorm"
+)
+
+// EmailTemplateService handles tenant email template management
+type EmailTemplateService struct {
+	db       *database.DB
+	cache    *cache.Cache
+	notifier *notification.Service
+}
+
+// NewEmailTemplateService creates a new email template service
+func NewEmailTemplateService(db *database.DB, cache *cache.Cache, notifier *notification.Service) *EmailTemplateService {
+	return &EmailTemplateService{
+		db:       db,
+		cache:    cache,
+		notifier: notifier,
+	}
+}
+
+// EmailTemplateRequest represents create/update template request
+type EmailTemplateRequest struct {
+	Event    string `json:"event" binding:"required"`
+	Subject  string `json:"subject" binding:"required"`
+	Body     string `json:"body" binding:"required"`
+	IsHTML   bool   `json:"is_html"`
+	IsActive *bool  `json:"is_active"`
+}
+
+// EmailTemplatePreviewRequest represents a preview request. When subject and body
+// are empty the tenant's current template (or the default) is previewed.
+type EmailTemplatePreviewRequest struct {
+	Event     string                 `json:"event" binding:"required"`
+	Subject   string                 `json:"subject"`
+	Body      string                 `json:"body"`
+	IsHTML    bool                   `json:"is_html"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// EmailTemplateResponse represents email template in responses
+type EmailTemplateResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Event     string    `json:"event"`
+	Subject   string    `json:"subject"`
+	Body      string    `json:"body"`
+	IsHTML    bool      `json:"is_html"`
+	IsActive  bool      `json:"is_active"`
+	Variables []string  `json:"variables"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GetEmailTemplates returns the tenant's custom templates
+func (s *EmailTemplateService) GetEmailTemplates(ctx context.Context, tenantID uuid.UUID) ([]*EmailTemplateResponse, error) {
+	var templates []models.EmailTemplate
+	if err := s.db.Where("tenant_id = ?", tenantID).Order("event").Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("failed to get email templates: %w", err)
+	}
+
+	responses := make([]*EmailTemplateResponse, len(templates))
+	for i, template := range templates {
+		responses[i] = s.mapEmailTemplateToResponse(&template)
+	}
+
+	return responses, nil
+}
+
+// GetDefaultEmailTemplates returns the built-in templates tenants can override
+func (s *EmailTemplateService) GetDefaultEmailTemplates() []notification.Template {
+	templates := make([]notification.Template, len(notification.Events))
+	for i, event := range notification.Events {
+		templates[i] = notification.DefaultTemplates[event]
+	}
+	return templates
+}
+
+// GetEmailTemplateByID returns a template by ID
+func (s *EmailTemplateService) GetEmailTemplateByID(ctx context.Context, templateID, tenantID uuid.UUID) (*EmailTemplateResponse, error) {
+	var template models.EmailTemplate
+	err := s.db.Where("id = ? AND tenant_id = ?", templateID, tenantID).First(&template).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("email template not found")
+		}
+		return nil, fmt.Errorf("failed to get email template: %w", err)
+	}
+
+	return s.mapEmailTemplateToResponse(&template), nil
+}
+
+// CreateEmailTemplate creates a custom template for an event
+func (s *EmailTemplateService) CreateEmailTemplate(ctx context.Context, req EmailTemplateRequest, tenantID uuid.UUID) (*EmailTemplateResponse, error) {
+	if !notification.IsValidEvent(req.Event) {
+		return nil, fmt.Errorf("unknown email event: %s", req.Event)
+	}
+
+	// One template per event per tenant
+	var existing models.EmailTemplate
+	if err := s.db.Where("tenant_id = ? AND event = ?", tenantID, req.Event).First(&existing).Error; err == nil {
+		return nil, errors.New("email template for this event already exists")
+	}
+
+	if err := s.validateTemplate(req.Subject, req.Body, req.IsHTML); err != nil {
+		return nil, err
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	template := models.EmailTemplate{
+		TenantModel: models.TenantModel{TenantID: tenantID},
+		Event:       req.Event,
+		Subject:     req.Subject,
+		Body:        req.Body,
+		IsHTML:      req.IsHTML,
+		IsActive:    isActive,
+	}
+
+	if err := s.db.Create(&template).Error; err != nil {
+		return nil, fmt.Errorf("failed to create email template: %w", err)
+	}
+
+	return s.mapEmailTemplateToResponse(&template), nil
+}
+
+// UpdateEmailTemplate updates a custom template
+func (s *EmailTemplateService) UpdateEmailTemplate(ctx context.Context, templateID, tenantID uuid.UUID, req EmailTemplateRequest) (*EmailTemplateResponse, error) {
+	var template models.EmailTemplate
+	err := s.db.Where("id = ? AND tenant_id = ?", templateID, tenantID).First(&template).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("email template not found")
+		}
+		return nil, fmt.Errorf("failed to find email template: %w", err)
+	}
+
+	if req.Event != template.Event {
+		return nil, errors.New("template event cannot be changed")
+	}
+
+	if err := s.validateTemplate(req.Subject, req.Body, req.IsHTML); err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{
+		"subject": req.Subject,
+		"body":    req.Body,
+		"is_html": req.IsHTML,
+	}
+	template.Subject = req.Subject
+	template.Body = req.Body
+	template.IsHTML = req.IsHTML
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+		template.IsActive = *req.IsActive
+	}
+
+	if err := s.db.Model(&template).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update email template: %w", err)
+	}
+
+	return s.mapEmailTemplateToResponse(&template), nil
+}
+
+// DeleteEmailTemplate removes a custom template so the default is used again
+func (s *EmailTemplateService) DeleteEmailTemplate(ctx context.Context, templateID, tenantID uuid.UUID) error {
+	result := s.db.Where("id = ? AND tenant_id = ?", templateID, tenantID).Delete(&models.EmailTemplate{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete email template: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("email template not found")
+	}
+	return nil
+}
+
+// PreviewEmailTemplate renders a draft or the effective template with sample variables
+func (s *EmailTemplateService) PreviewEmailTemplate(ctx context.Context, req EmailTemplatePreviewRequest, tenantID uuid.UUID) (*notification.Message, error) {
+	if req.Subject == "" && req.Body == "" {
+		return s.notifier.Render(ctx, tenantID, req.Event, req.Variables)
+	}
+
+	if !notification.IsValidEvent(req.Event) {
+		return nil, fmt.Errorf("unknown email event: %s", req.Event)
+	}
+
+	return notification.RenderTemplate(&notification.Template{
+		Event:   req.Event,
+		Subject: req.Subject,
+		Body:    req.Body,
+		IsHTML:  req.IsHTML,
+	}, req.Variables)
+}
+
+// validateTemplate makes sure the template parses before it is stored
+func (s *EmailTemplateService) validateTemplate(subject, body string, isHTML bool) error {
+	if err := notification.ValidateSubject(subject); err != nil {
+		return err
+	}
+	_, err := notification.RenderTemplate(&notification.Template{
+		Subject: subject,
+		Body:    body,
+		IsHTML:  isHTML,
+	}, nil)
+	return err
+}
+
+// mapEmailTemplateToResponse converts model to response format
+func (s *EmailTemplateService) mapEmailTemplateToResponse(template *models.EmailTemplate) *EmailTemplateResponse {
+	return &EmailTemplateResponse{
+		ID:        template.ID,
+		Event:     template.Event,
+		Subject:   template.Subject,
+		Body:      template.Body,
+		IsHTML:    template.IsHTML,
+		IsActive:  template.IsActive,
+		Variables: notification.DefaultTemplates[template.Event].Variables,
+		CreatedAt: template.CreatedAt,
+		UpdatedAt: template.UpdatedAt,
+	}
+}
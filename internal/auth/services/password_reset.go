@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/cache"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+	"gorm.io/gorm"
+)
+
+// ForgotPasswordRequest represents a password reset request
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest represents setting a new password with a reset token
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=8"`
+}
+
+// RequestPasswordReset emails a single-use reset link to the user with the given
+// email. It returns nil when no active user has that email, so callers cannot tell
+// which emails are registered.
+func (s *AuthService) RequestPasswordReset(ctx context.Context, email string) error {
+	var user models.User
+	err := s.db.WithContext(ctx).Where("LOWER(email) = ?", strings.ToLower(strings.TrimSpace(email))).
+		Preload("Tenant").
+		First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+	if !user.IsActive || (user.Tenant != nil && !user.Tenant.IsActive) {
+		return nil
+	}
+
+	token, err := utils.GenerateRandomString(64)
+	if err != nil {
+		return fmt.Errorf("failed to generate reset token: %w", err)
+	}
+
+	// Only the latest token is valid; drop any earlier one still outstanding
+	userKey := fmt.Sprintf(cache.PasswordResetUserKey, user.ID.String())
+	var previous string
+	if err := s.cache.Take(ctx, userKey, &previous); err == nil {
+		s.cache.Delete(ctx, fmt.Sprintf(cache.PasswordResetKey, previous))
+	}
+
+	if err := s.cache.Set(ctx, fmt.Sprintf(cache.PasswordResetKey, token), user.ID.String(), cache.PasswordResetTTL); err != nil {
+		return fmt.Errorf("failed to store reset token: %w", err)
+	}
+	if err := s.cache.Set(ctx, userKey, token, cache.PasswordResetTTL); err != nil {
+		return fmt.Errorf("failed to store reset token: %w", err)
+	}
+
+	companyName := ""
+	if user.Tenant != nil {
+		companyName = user.Tenant.Name
+	}
+	vars := map[string]interface{}{
+		"user_name":    user.FirstName,
+		"company_name": companyName,
+		"reset_link":   s.resetLink(token),
+		"expires_in":   fmt.Sprintf("%d minutes", int(cache.PasswordResetTTL.Minutes())),
+	}
+
+	// Send in the background so the response time does not reveal whether the email exists
+	go func(tenantID uuid.UUID, to string) {
+		if err := s.notifier.Send(context.Background(), tenantID, models.EmailEventPasswordReset, []string{to}, vars); err != nil {
+			fmt.Printf("Warning: Failed to send password reset email: %v\n", err)
+		}
+	}(user.TenantID, user.Email)
+
+	return nil
+}
+
+// ResetPassword sets a new password for the user a reset token was issued to. The
+// token is consumed whether or not the reset succeeds, and all of the user's
+// sessions are ended.
+func (s *AuthService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	var userIDStr string
+	if err := s.cache.Take(ctx, fmt.Sprintf(cache.PasswordResetKey, token), &userIDStr); err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return errors.New("invalid or expired reset token")
+		}
+		return fmt.Errorf("failed to verify reset token: %w", err)
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return errors.New("invalid or expired reset token")
+	}
+	s.cache.Delete(ctx, fmt.Sprintf(cache.PasswordResetUserKey, userIDStr))
+
+	var user models.User
+	if err := s.db.WithContext(ctx).Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("invalid or expired reset token")
+		}
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+	if !user.IsActive {
+		return errors.New("account is inactive")
+	}
+
+	hashedPassword, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	if err := s.db.WithContext(ctx).Model(&user).Update("password_hash", hashedPassword).Error; err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	// Force re-login everywhere with the new password
+	if err := s.revokeUserRefreshTokens(ctx, userID); err != nil {
+		fmt.Printf("Warning: Failed to revoke refresh tokens after password reset: %v\n", err)
+	}
+	sessionKey := fmt.Sprintf(cache.UserSessionKey, userID.String())
+	s.cache.Delete(ctx, sessionKey)
+
+	return nil
+}
+
+// resetLink builds the frontend URL for a reset token
+func (s *AuthService) resetLink(token string) string {
+	link, err := url.Parse(s.resetURL)
+	if err != nil || s.resetURL == "" {
+		return token
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/cache"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+)
+
+// Refresh tokens are opaque random strings tracked in Redis by the SHA-256 of the
+// token (its jti), so raw tokens are never stored. Every login starts a token
+// family; each refresh marks the presented token as used and issues the next token
+// in the same family. A used token presented again means it was copied, so the
+// whole family is revoked.
+const (
+	refreshTokenKey        = "refresh_token:%s"        // jti
+	refreshFamilyKey       = "refresh_family:%s"       // family_id
+	refreshRevokedAfterKey = "refresh_revoked:user:%s" // user_id
+	refreshRotateLockKey   = "refresh_token_rotate:%s" // jti
+)
+
+// refreshTokenRecord is the stored state of one refresh token
+type refreshTokenRecord struct {
+	UserID   uuid.UUID `json:"user_id"`
+	FamilyID uuid.UUID `json:"family_id"`
+	IssuedAt time.Time `json:"issued_at"`
+	Used     bool      `json:"used"`
+}
+
+// refreshTokenTTL is how long a refresh token, and the record of its use, is kept
+func (s *AuthService) refreshTokenTTL() time.Duration {
+	if s.config.RefreshHours <= 0 {
+		return 7 * 24 * time.Hour
+	}
+	return time.Duration(s.config.RefreshHours) * time.Hour
+}
+
+// issueRefreshToken creates a refresh token for the user in the given family,
+// starting a new family when familyID is nil
+func (s *AuthService) issueRefreshToken(ctx context.Context, userID, familyID uuid.UUID) (string, error) {
+	if familyID == uuid.Nil {
+		familyID = uuid.New()
+	}
+
+	token, err := utils.GenerateRandomString(64)
+	if err != nil {
+		return "", err
+	}
+
+	ttl := s.refreshTokenTTL()
+	record := refreshTokenRecord{
+		UserID:   userID,
+		FamilyID: familyID,
+		IssuedAt: time.Now(),
+	}
+	if err := s.cache.Set(ctx, fmt.Sprintf(refreshFamilyKey, familyID.String()), userID.String(), ttl); err != nil {
+		return "", fmt.Errorf("failed to store refresh token family: %w", err)
+	}
+	if err := s.cache.Set(ctx, fmt.Sprintf(refreshTokenKey, refreshTokenID(token)), record, ttl); err != nil {
+		return "", fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return token, nil
+}
+
+// rotateRefreshToken exchanges a valid refresh token for the next one in its family.
+// Presenting a token that was already rotated revokes the family.
+func (s *AuthService) rotateRefreshToken(ctx context.Context, token string, userID uuid.UUID) (string, error) {
+	jti := refreshTokenID(token)
+	key := fmt.Sprintf(refreshTokenKey, jti)
+
+	var record refreshTokenRecord
+	if err := s.cache.Get(ctx, key, &record); err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return "", errors.New("invalid refresh token")
+		}
+		return "", fmt.Errorf("failed to verify refresh token: %w", err)
+	}
+	if record.UserID != userID {
+		return "", errors.New("invalid refresh token")
+	}
+
+	if record.Used {
+		s.revokeRefreshFamily(ctx, record.FamilyID)
+		return "", errors.New("refresh token reuse detected; please log in again")
+	}
+
+	if exists, err := s.cache.Exists(ctx, fmt.Sprintf(refreshFamilyKey, record.FamilyID.String())); err != nil {
+		return "", fmt.Errorf("failed to verify refresh token: %w", err)
+	} else if !exists {
+		return "", errors.New("refresh token has been revoked")
+	}
+
+	var revokedAfter time.Time
+	if err := s.cache.Get(ctx, fmt.Sprintf(refreshRevokedAfterKey, userID.String()), &revokedAfter); err == nil && !record.IssuedAt.After(revokedAfter) {
+		return "", errors.New("refresh token has been revoked")
+	}
+
+	// Only one request may rotate a token; a concurrent second use counts as reuse
+	ttl := s.refreshTokenTTL()
+	acquired, err := s.cache.Lock(ctx, fmt.Sprintf(refreshRotateLockKey, jti), ttl)
+	if err != nil {
+		return "", fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	if !acquired {
+		s.revokeRefreshFamily(ctx, record.FamilyID)
+		return "", errors.New("refresh token reuse detected; please log in again")
+	}
+
+	// Keep the used token on the denylist until it would have expired anyway
+	record.Used = true
+	remaining := ttl - time.Since(record.IssuedAt)
+	if remaining < time.Minute {
+		remaining = time.Minute
+	}
+	if err := s.cache.Set(ctx, key, record, remaining); err != nil {
+		return "", fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	return s.issueRefreshToken(ctx, userID, record.FamilyID)
+}
+
+// revokeRefreshFamily invalidates every refresh token descended from one login
+func (s *AuthService) revokeRefreshFamily(ctx context.Context, familyID uuid.UUID) {
+	if err := s.cache.Delete(ctx, fmt.Sprintf(refreshFamilyKey, familyID.String())); err != nil {
+		fmt.Printf("Warning: Failed to revoke refresh token family %s: %v\n", familyID, err)
+	}
+}
+
+// revokeUserRefreshTokens invalidates every refresh token issued to the user so far
+func (s *AuthService) revokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	key := fmt.Sprintf(refreshRevokedAfterKey, userID.String())
+	if err := s.cache.Set(ctx, key, time.Now(), s.refreshTokenTTL()); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// refreshTokenID derives the stored identifier (jti) of a refresh token
+func refreshTokenID(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
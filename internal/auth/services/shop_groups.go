@@ -0,0 +1,303 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"gorm.io/gorm"
+)
+
+// ShopGroupRequest represents shop group creation request
+type ShopGroupRequest struct {
+	Name        string      `json:"name" binding:"required"`
+	Description string      `json:"description"`
+	ShopIDs     []uuid.UUID `json:"shop_ids"`
+}
+
+// UpdateShopGroupRequest represents shop group update request
+type UpdateShopGroupRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	IsActive    *bool   `json:"is_active"`
+}
+
+// ShopGroupShopsRequest replaces the shops in a shop group
+type ShopGroupShopsRequest struct {
+	ShopIDs []uuid.UUID `json:"shop_ids" binding:"required"`
+}
+
+// ShopGroupAssignmentRequest assigns a regional manager to a shop group; a null
+// shop_group_id removes the assignment
+type ShopGroupAssignmentRequest struct {
+	ShopGroupID *uuid.UUID `json:"shop_group_id"`
+}
+
+// ShopGroupResponse represents shop group data in responses
+type ShopGroupResponse struct {
+	ID          uuid.UUID       `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	IsActive    bool            `json:"is_active"`
+	Shops       []*ShopResponse `json:"shops"`
+	Managers    []*UserResponse `json:"managers"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// ShopGroupAssignmentResponse represents a regional manager's shop group assignment
+type ShopGroupAssignmentResponse struct {
+	UserID      uuid.UUID  `json:"user_id"`
+	ShopGroupID *uuid.UUID `json:"shop_group_id"`
+}
+
+// GetShopGroups returns all shop groups for a tenant
+func (s *TenantService) GetShopGroups(ctx context.Context, tenantID uuid.UUID) ([]*ShopGroupResponse, error) {
+	var groups []models.ShopGroup
+	err := s.db.Where("tenant_id = ?", tenantID).
+		Preload("Shops").
+		Preload("Managers").
+		Order("name ASC").
+		Find(&groups).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shop groups: %w", err)
+	}
+
+	responses := make([]*ShopGroupResponse, len(groups))
+	for i := range groups {
+		responses[i] = s.mapShopGroupToResponse(&groups[i])
+	}
+
+	return responses, nil
+}
+
+// GetShopGroupByID returns shop group by ID
+func (s *TenantService) GetShopGroupByID(ctx context.Context, groupID, tenantID uuid.UUID) (*ShopGroupResponse, error) {
+	group, err := s.findShopGroup(s.db.DB, groupID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.mapShopGroupToResponse(group), nil
+}
+
+// CreateShopGroup creates a new shop group
+func (s *TenantService) CreateShopGroup(ctx context.Context, req ShopGroupRequest, tenantID uuid.UUID) (*ShopGroupResponse, error) {
+	var existing models.ShopGroup
+	if err := s.db.Where("name = ? AND tenant_id = ?", req.Name, tenantID).First(&existing).Error; err == nil {
+		return nil, errors.New("shop group with this name already exists")
+	}
+
+	group := models.ShopGroup{
+		TenantModel: models.TenantModel{TenantID: tenantID},
+		Name:        req.Name,
+		Description: req.Description,
+		IsActive:    true,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		shops, err := s.tenantShops(tx, tenantID, req.ShopIDs)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Create(&group).Error; err != nil {
+			return fmt.Errorf("failed to create shop group: %w", err)
+		}
+		if len(shops) > 0 {
+			if err := tx.Model(&group).Association("Shops").Replace(shops); err != nil {
+				return fmt.Errorf("failed to set shop group shops: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetShopGroupByID(ctx, group.ID, tenantID)
+}
+
+// UpdateShopGroup updates shop group information
+func (s *TenantService) UpdateShopGroup(ctx context.Context, groupID, tenantID uuid.UUID, req UpdateShopGroupRequest) (*ShopGroupResponse, error) {
+	group, err := s.findShopGroup(s.db.DB, groupID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+
+	if req.Name != nil {
+		var existing models.ShopGroup
+		if err := s.db.Where("name = ? AND tenant_id = ? AND id != ?", *req.Name, tenantID, groupID).First(&existing).Error; err == nil {
+			return nil, errors.New("shop group with this name already exists")
+		}
+		updates["name"] = *req.Name
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+
+	if len(updates) > 0 {
+		if err := s.db.Model(group).Updates(updates).Error; err != nil {
+			return nil, fmt.Errorf("failed to update shop group: %w", err)
+		}
+	}
+
+	return s.GetShopGroupByID(ctx, groupID, tenantID)
+}
+
+// SetShopGroupShops replaces the shops in a shop group
+func (s *TenantService) SetShopGroupShops(ctx context.Context, groupID, tenantID uuid.UUID, shopIDs []uuid.UUID) (*ShopGroupResponse, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		group, err := s.findShopGroup(tx, groupID, tenantID)
+		if err != nil {
+			return err
+		}
+
+		shops, err := s.tenantShops(tx, tenantID, shopIDs)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(group).Association("Shops").Replace(shops); err != nil {
+			return fmt.Errorf("failed to set shop group shops: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetShopGroupByID(ctx, groupID, tenantID)
+}
+
+// DeleteShopGroup deletes a shop group, unassigning its regional managers
+func (s *TenantService) DeleteShopGroup(ctx context.Context, groupID, tenantID uuid.UUID) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		group, err := s.findShopGroup(tx, groupID, tenantID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.User{}).
+			Where("shop_group_id = ? AND tenant_id = ?", groupID, tenantID).
+			Update("shop_group_id", nil).Error; err != nil {
+			return fmt.Errorf("failed to unassign shop group managers: %w", err)
+		}
+		if err := tx.Model(group).Association("Shops").Clear(); err != nil {
+			return fmt.Errorf("failed to clear shop group shops: %w", err)
+		}
+		if err := tx.Delete(group).Error; err != nil {
+			return fmt.Errorf("failed to delete shop group: %w", err)
+		}
+		return nil
+	})
+}
+
+// AssignShopGroup sets the shop group a regional manager oversees
+func (s *TenantService) AssignShopGroup(ctx context.Context, userID, tenantID uuid.UUID, groupID *uuid.UUID) (*ShopGroupAssignmentResponse, error) {
+	var user models.User
+	if err := s.db.Where("id = ? AND tenant_id = ?", userID, tenantID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	if groupID != nil {
+		if user.Role != models.RoleRegionalManager {
+			return nil, errors.New("only regional managers can be assigned to a shop group")
+		}
+		if _, err := s.findShopGroup(s.db.DB, *groupID, tenantID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.db.Model(&user).Update("shop_group_id", groupID).Error; err != nil {
+		return nil, fmt.Errorf("failed to assign shop group: %w", err)
+	}
+
+	return &ShopGroupAssignmentResponse{
+		UserID:      user.ID,
+		ShopGroupID: groupID,
+	}, nil
+}
+
+// findShopGroup loads a tenant's shop group with its shops and managers
+func (s *TenantService) findShopGroup(db *gorm.DB, groupID, tenantID uuid.UUID) (*models.ShopGroup, error) {
+	var group models.ShopGroup
+	err := db.Where("id = ? AND tenant_id = ?", groupID, tenantID).
+		Preload("Shops").
+		Preload("Managers").
+		First(&group).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("shop group not found")
+		}
+		return nil, fmt.Errorf("failed to find shop group: %w", err)
+	}
+	return &group, nil
+}
+
+// tenantShops loads the requested shops, failing if any is not one of the tenant's
+func (s *TenantService) tenantShops(db *gorm.DB, tenantID uuid.UUID, shopIDs []uuid.UUID) ([]models.Shop, error) {
+	if len(shopIDs) == 0 {
+		return []models.Shop{}, nil
+	}
+
+	unique := make([]uuid.UUID, 0, len(shopIDs))
+	seen := make(map[uuid.UUID]bool, len(shopIDs))
+	for _, id := range shopIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	var shops []models.Shop
+	if err := db.Where("id IN ? AND tenant_id = ?", unique, tenantID).Find(&shops).Error; err != nil {
+		return nil, fmt.Errorf("failed to find shops: %w", err)
+	}
+	if len(shops) != len(unique) {
+		return nil, errors.New("one or more shops not found")
+	}
+	return shops, nil
+}
+
+func (s *TenantService) mapShopGroupToResponse(group *models.ShopGroup) *ShopGroupResponse {
+	response := &ShopGroupResponse{
+		ID:          group.ID,
+		Name:        group.Name,
+		Description: group.Description,
+		IsActive:    group.IsActive,
+		Shops:       make([]*ShopResponse, len(group.Shops)),
+		Managers:    make([]*UserResponse, len(group.Managers)),
+		CreatedAt:   group.CreatedAt,
+		UpdatedAt:   group.UpdatedAt,
+	}
+
+	for i := range group.Shops {
+		response.Shops[i] = s.mapShopToResponse(&group.Shops[i])
+	}
+	for i, manager := range group.Managers {
+		response.Managers[i] = &UserResponse{
+			ID:           manager.ID,
+			Username:     manager.Username,
+			Email:        manager.Email,
+			FirstName:    manager.FirstName,
+			LastName:     manager.LastName,
+			Role:         manager.Role,
+			IsActive:     manager.IsActive,
+			ProfileImage: manager.ProfileImage,
+		}
+	}
+
+	return response
+}
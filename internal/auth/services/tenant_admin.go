@@ -0,0 +1,339 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/cache"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/usage"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+	"gorm.io/gorm"
+)
+
+// CreateTenantRequest creates a tenant together with its first admin user
+type CreateTenantRequest struct {
+	CompanyName string     `json:"company_name" binding:"required"`
+	Domain      string     `json:"domain" binding:"required"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	Admin       struct {
+		Username  string `json:"username" binding:"required,min=3,max=50"`
+		Email     string `json:"email" binding:"required,email"`
+		Password  string `json:"password" binding:"required,min=8"`
+		FirstName string `json:"first_name" binding:"required"`
+		LastName  string `json:"last_name" binding:"required"`
+		Phone     string `json:"phone"`
+	} `json:"admin" binding:"required"`
+}
+
+// UpdateTenantRequest updates a tenant's company details
+type UpdateTenantRequest struct {
+	CompanyName *string    `json:"company_name"`
+	Domain      *string    `json:"domain"`
+	IsActive    *bool      `json:"is_active"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+// TenantDetailResponse represents a tenant in SaaS admin responses
+type TenantDetailResponse struct {
+	ID           uuid.UUID     `json:"id"`
+	CompanyName  string        `json:"company_name"`
+	Domain       string        `json:"domain"`
+	IsActive     bool          `json:"is_active"`
+	SubscribedAt time.Time     `json:"subscribed_at"`
+	ExpiresAt    *time.Time    `json:"expires_at"`
+	ShopCount    int64         `json:"shop_count"`
+	UserCount    int64         `json:"user_count"`
+	Admin        *UserResponse `json:"admin,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+// TenantListResponse represents a page of tenants
+type TenantListResponse struct {
+	Tenants    []*TenantDetailResponse `json:"tenants"`
+	TotalCount int64                   `json:"total_count"`
+	Page       int                     `json:"page"`
+	PageSize   int                     `json:"page_size"`
+	TotalPages int                     `json:"total_pages"`
+}
+
+// GetTenants returns a page of tenants, optionally searching by company name
+func (s *TenantService) GetTenants(ctx context.Context, search string, page, pageSize int) (*TenantListResponse, error) {
+	query := s.db.Model(&models.Tenant{})
+	if search = strings.TrimSpace(search); search != "" {
+		query = query.Where("name ILIKE ?", "%"+search+"%")
+	}
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, fmt.Errorf("failed to count tenants: %w", err)
+	}
+
+	var tenants []models.Tenant
+	if err := query.Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&tenants).Error; err != nil {
+		return nil, fmt.Errorf("failed to get tenants: %w", err)
+	}
+
+	counts, err := s.tenantCounts(tenants)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*TenantDetailResponse, len(tenants))
+	for i := range tenants {
+		response := s.mapTenantToDetailResponse(&tenants[i])
+		response.ShopCount = counts[tenants[i].ID].shops
+		response.UserCount = counts[tenants[i].ID].users
+		responses[i] = response
+	}
+
+	return &TenantListResponse{
+		Tenants:    responses,
+		TotalCount: totalCount,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((totalCount + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// GetTenantByID returns a tenant with its shop and user counts
+func (s *TenantService) GetTenantByID(ctx context.Context, tenantID uuid.UUID) (*TenantDetailResponse, error) {
+	var tenant models.Tenant
+	if err := s.db.Where("id = ?", tenantID).First(&tenant).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("tenant not found")
+		}
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	counts, err := s.tenantCounts([]models.Tenant{tenant})
+	if err != nil {
+		return nil, err
+	}
+
+	response := s.mapTenantToDetailResponse(&tenant)
+	response.ShopCount = counts[tenant.ID].shops
+	response.UserCount = counts[tenant.ID].users
+	return response, nil
+}
+
+// CreateTenant creates a tenant and its first admin user in one transaction
+func (s *TenantService) CreateTenant(ctx context.Context, req CreateTenantRequest) (*TenantDetailResponse, error) {
+	var tenant models.Tenant
+	var admin models.User
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var existingTenant models.Tenant
+		if err := tx.Where("domain = ?", req.Domain).First(&existingTenant).Error; err == nil {
+			return errors.New("tenant with this domain already exists")
+		}
+
+		var existingUser models.User
+		if err := tx.Where("username = ? OR email = ?", req.Admin.Username, req.Admin.Email).First(&existingUser).Error; err == nil {
+			return errors.New("username or email already exists")
+		}
+
+		tenant = models.Tenant{
+			Name:         req.CompanyName,
+			Domain:       req.Domain,
+			IsActive:     true,
+			SubscribedAt: time.Now(),
+			ExpiresAt:    req.ExpiresAt,
+		}
+		if err := tx.Create(&tenant).Error; err != nil {
+			return fmt.Errorf("failed to create tenant: %w", err)
+		}
+
+		hashedPassword, err := utils.HashPassword(req.Admin.Password)
+		if err != nil {
+			return fmt.Errorf("failed to hash password: %w", err)
+		}
+
+		admin = models.User{
+			TenantModel:  models.TenantModel{TenantID: tenant.ID},
+			Username:     req.Admin.Username,
+			Email:        req.Admin.Email,
+			FirstName:    req.Admin.FirstName,
+			LastName:     req.Admin.LastName,
+			Phone:        req.Admin.Phone,
+			PasswordHash: hashedPassword,
+			Role:         models.RoleAdmin,
+			IsActive:     true,
+		}
+		if err := tx.Create(&admin).Error; err != nil {
+			return fmt.Errorf("failed to create admin user: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := s.mapTenantToDetailResponse(&tenant)
+	response.UserCount = 1
+	response.Admin = &UserResponse{
+		ID:        admin.ID,
+		Username:  admin.Username,
+		Email:     admin.Email,
+		FirstName: admin.FirstName,
+		LastName:  admin.LastName,
+		Role:      admin.Role,
+		IsActive:  admin.IsActive,
+	}
+	return response, nil
+}
+
+// UpdateTenant updates a tenant's company details
+func (s *TenantService) UpdateTenant(ctx context.Context, tenantID uuid.UUID, req UpdateTenantRequest) (*TenantDetailResponse, error) {
+	var tenant models.Tenant
+	if err := s.db.Where("id = ?", tenantID).First(&tenant).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("tenant not found")
+		}
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	updates := make(map[string]interface{})
+	if req.CompanyName != nil {
+		if strings.TrimSpace(*req.CompanyName) == "" {
+			return nil, errors.New("company name cannot be empty")
+		}
+		updates["name"] = *req.CompanyName
+	}
+	if req.Domain != nil && *req.Domain != tenant.Domain {
+		var existing models.Tenant
+		if err := s.db.Where("domain = ? AND id != ?", *req.Domain, tenantID).First(&existing).Error; err == nil {
+			return nil, errors.New("tenant with this domain already exists")
+		}
+		updates["domain"] = *req.Domain
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+	if req.ExpiresAt != nil {
+		updates["expires_at"] = *req.ExpiresAt
+	}
+
+	if len(updates) > 0 {
+		if err := s.db.Model(&tenant).Updates(updates).Error; err != nil {
+			return nil, fmt.Errorf("failed to update tenant: %w", err)
+		}
+	}
+
+	return s.GetTenantByID(ctx, tenantID)
+}
+
+// DeleteTenant soft deletes a tenant and deactivates its users, ending their
+// sessions. Tenants with a trial or active subscription must cancel it first.
+func (s *TenantService) DeleteTenant(ctx context.Context, tenantID uuid.UUID) error {
+	var tenant models.Tenant
+	if err := s.db.Where("id = ?", tenantID).First(&tenant).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("tenant not found")
+		}
+		return fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	// Subscriptions belong to the SaaS service and share this database when it runs
+	if s.db.Migrator().HasTable("subscriptions") {
+		var live int64
+		if err := s.db.Table("subscriptions").
+			Where("tenant_id = ? AND status IN ? AND deleted_at IS NULL", tenantID, usage.LiveSubscriptionStatuses).
+			Count(&live).Error; err != nil {
+			return fmt.Errorf("failed to check subscriptions: %w", err)
+		}
+		if live > 0 {
+			return errors.New("tenant has an active subscription; cancel it before deleting the tenant")
+		}
+	}
+
+	var userIDs []uuid.UUID
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("tenant_id = ?", tenantID).Pluck("id", &userIDs).Error; err != nil {
+			return fmt.Errorf("failed to get tenant users: %w", err)
+		}
+		if err := tx.Model(&models.User{}).Where("tenant_id = ?", tenantID).Update("is_active", false).Error; err != nil {
+			return fmt.Errorf("failed to deactivate tenant users: %w", err)
+		}
+		if err := tx.Model(&tenant).Update("is_active", false).Error; err != nil {
+			return fmt.Errorf("failed to deactivate tenant: %w", err)
+		}
+		if err := tx.Delete(&tenant).Error; err != nil {
+			return fmt.Errorf("failed to delete tenant: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		s.cache.Delete(ctx, fmt.Sprintf(cache.UserSessionKey, userID.String()))
+	}
+	return nil
+}
+
+type tenantCount struct {
+	shops int64
+	users int64
+}
+
+// tenantCounts counts the shops and users of each tenant
+func (s *TenantService) tenantCounts(tenants []models.Tenant) (map[uuid.UUID]tenantCount, error) {
+	counts := make(map[uuid.UUID]tenantCount, len(tenants))
+	if len(tenants) == 0 {
+		return counts, nil
+	}
+	ids := make([]uuid.UUID, len(tenants))
+	for i, tenant := range tenants {
+		ids[i] = tenant.ID
+	}
+
+	var rows []struct {
+		TenantID uuid.UUID
+		Count    int64
+	}
+	if err := s.db.Model(&models.Shop{}).Select("tenant_id, COUNT(*) AS count").
+		Where("tenant_id IN ?", ids).Group("tenant_id").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to count shops: %w", err)
+	}
+	for _, row := range rows {
+		count := counts[row.TenantID]
+		count.shops = row.Count
+		counts[row.TenantID] = count
+	}
+
+	rows = nil
+	if err := s.db.Model(&models.User{}).Select("tenant_id, COUNT(*) AS count").
+		Where("tenant_id IN ?", ids).Group("tenant_id").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to count users: %w", err)
+	}
+	for _, row := range rows {
+		count := counts[row.TenantID]
+		count.users = row.Count
+		counts[row.TenantID] = count
+	}
+
+	return counts, nil
+}
+
+func (s *TenantService) mapTenantToDetailResponse(tenant *models.Tenant) *TenantDetailResponse {
+	return &TenantDetailResponse{
+		ID:           tenant.ID,
+		CompanyName:  tenant.Name,
+		Domain:       tenant.Domain,
+		IsActive:     tenant.IsActive,
+		SubscribedAt: tenant.SubscribedAt,
+		ExpiresAt:    tenant.ExpiresAt,
+		CreatedAt:    tenant.CreatedAt,
+		UpdatedAt:    tenant.UpdatedAt,
+	}
+}
@@ -10,21 +10,24 @@ import (
 	"github.com/liquorpro/go-backend/pkg/shared/cache"
 	"github.com/liquorpro/go-backend/pkg/shared/database"
 	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/usage"
 	"github.com/liquorpro/go-backend/pkg/shared/utils"
 	"gorm.io/gorm"
 )
 
 // TenantService handles tenant and shop management operations
 type TenantService struct {
-	db    *database.DB
-	cache *cache.Cache
+	db     *database.DB
+	cache  *cache.Cache
+	limits *usage.Enforcer
 }
 
 // NewTenantService creates a new tenant service
 func NewTenantService(db *database.DB, cache *cache.Cache) *TenantService {
 	return &TenantService{
-		db:    db,
-		cache: cache,
+		db:     db,
+		cache:  cache,
+		limits: usage.NewEnforcer(db.DB),
 	}
 }
 
@@ -37,6 +40,7 @@ type CreateShopRequest struct {
 	LicenseFile    string  `json:"license_file"`
 	Latitude       float64 `json:"latitude"`
 	Longitude      float64 `json:"longitude"`
+	ManagerID      *uuid.UUID `json:"manager_id"`
 }
 
 // UpdateShopRequest represents shop update request
@@ -49,6 +53,8 @@ type UpdateShopRequest struct {
 	Latitude       *float64 `json:"latitude"`
 	Longitude      *float64 `json:"longitude"`
 	IsActive       *bool    `json:"is_active"`
+	// ManagerID assigns the shop's manager; the nil UUID removes it
+	ManagerID      *uuid.UUID `json:"manager_id"`
 }
 
 // ShopResponse represents shop data in responses
@@ -62,6 +68,7 @@ type ShopResponse struct {
 	Latitude      float64   `json:"latitude"`
 	Longitude     float64   `json:"longitude"`
 	IsActive      bool      `json:"is_active"`
+	ManagerID     *uuid.UUID `json:"manager_id"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 }
@@ -154,6 +161,16 @@ func (s *TenantService) CreateShop(ctx context.Context, req CreateShopRequest, t
 		return nil, errors.New("shop with this license number already exists")
 	}
 
+	if err := s.limits.Check(ctx, tenantID, usage.ResourceLocations); err != nil {
+		return nil, err
+	}
+
+	if req.ManagerID != nil {
+		if err := s.checkShopManager(*req.ManagerID, tenantID); err != nil {
+			return nil, err
+		}
+	}
+
 	shop := models.Shop{
 		TenantModel:   models.TenantModel{TenantID: tenantID},
 		Name:          req.Name,
@@ -164,6 +181,7 @@ func (s *TenantService) CreateShop(ctx context.Context, req CreateShopRequest, t
 		Latitude:      req.Latitude,
 		Longitude:     req.Longitude,
 		IsActive:      true,
+		ManagerID:     req.ManagerID,
 	}
 
 	if err := s.db.Create(&shop).Error; err != nil {
@@ -230,6 +248,18 @@ func (s *TenantService) UpdateShop(ctx context.Context, shopID, tenantID uuid.UU
 		updates["is_active"] = *req.IsActive
 		shop.IsActive = *req.IsActive
 	}
+	if req.ManagerID != nil {
+		if *req.ManagerID == uuid.Nil {
+			updates["manager_id"] = nil
+			shop.ManagerID = nil
+		} else {
+			if err := s.checkShopManager(*req.ManagerID, tenantID); err != nil {
+				return nil, err
+			}
+			updates["manager_id"] = *req.ManagerID
+			shop.ManagerID = req.ManagerID
+		}
+	}
 
 	if len(updates) > 0 {
 		if err := s.db.Model(&shop).Updates(updates).Error; err != nil {
@@ -402,6 +432,19 @@ func (s *TenantService) UpdateSalesman(ctx context.Context, salesmanID, tenantID
 
 // Helper methods
 
+// checkShopManager checks a shop's manager is an active manager of the tenant
+func (s *TenantService) checkShopManager(userID, tenantID uuid.UUID) error {
+	var user models.User
+	if err := s.db.Where("id = ? AND tenant_id = ? AND is_active = ?", userID, tenantID, true).First(&user).Error; err != nil {
+		return errors.New("shop manager not found")
+	}
+	switch user.Role {
+	case models.RoleAdmin, models.RoleManager, models.RoleAssistantManager:
+		return nil
+	}
+	return fmt.Errorf("a %s cannot manage a shop", user.Role)
+}
+
 func (s *TenantService) mapShopToResponse(shop *models.Shop) *ShopResponse {
 	return &ShopResponse{
 		ID:            shop.ID,
@@ -413,6 +456,7 @@ func (s *TenantService) mapShopToResponse(shop *models.Shop) *ShopResponse {
 		Latitude:      shop.Latitude,
 		Longitude:     shop.Longitude,
 		IsActive:      shop.IsActive,
+		ManagerID:     shop.ManagerID,
 		CreatedAt:     shop.CreatedAt,
 		UpdatedAt:     shop.UpdatedAt,
 	}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+	"gorm.io/gorm"
+)
+
+// backupCodeCount is how many one-time backup codes are issued when 2FA is enabled
+const backupCodeCount = 10
+
+// totpUsedKey records a consumed TOTP step so a code cannot be replayed
+const totpUsedKey = "totp_used:%s:%d" // user_id:step
+
+// twoFactorRoles may enable two-factor authentication
+var twoFactorRoles = []string{models.RoleAdmin, models.RoleManager}
+
+// TOTPEnrollmentResponse carries a new TOTP secret for the authenticator app
+type TOTPEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"` // encode as a QR code
+}
+
+// TOTPCodeRequest carries a code from the authenticator app
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TOTPBackupCodesResponse lists backup codes; they are only ever shown once
+type TOTPBackupCodesResponse struct {
+	BackupCodes []string `json:"backup_codes"`
+}
+
+// EnrollTOTP starts two-factor enrollment by generating a new secret. 2FA is not
+// required at login until the enrollment is verified with VerifyTOTPEnrollment.
+func (s *AuthService) EnrollTOTP(ctx context.Context, userID, tenantID uuid.UUID) (*TOTPEnrollmentResponse, error) {
+	user, err := s.findTwoFactorUser(ctx, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+	encrypted, err := utils.EncryptString(secret, s.totpKey())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt TOTP secret: %w", err)
+	}
+
+	if err := s.db.WithContext(ctx).Model(user).Update("totp_secret", encrypted).Error; err != nil {
+		return nil, fmt.Errorf("failed to save TOTP secret: %w", err)
+	}
+
+	return &TOTPEnrollmentResponse{
+		Secret:     secret,
+		OTPAuthURL: utils.TOTPAuthURL(s.config.Issuer, user.Email, secret),
+	}, nil
+}
+
+// VerifyTOTPEnrollment enables two-factor authentication once the user proves their
+// authenticator app produces valid codes, and returns a fresh set of backup codes
+func (s *AuthService) VerifyTOTPEnrollment(ctx context.Context, userID, tenantID uuid.UUID, code string) (*TOTPBackupCodesResponse, error) {
+	user, err := s.findTwoFactorUser(ctx, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+	if user.TOTPSecret == "" {
+		return nil, errors.New("two-factor enrollment has not been started")
+	}
+
+	if err := s.checkTOTPCode(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateBackupCodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate backup codes: %w", err)
+	}
+
+	now := time.Now()
+	if err := s.db.WithContext(ctx).Model(user).Updates(map[string]interface{}{
+		"totp_enabled":      true,
+		"totp_enabled_at":   now,
+		"totp_backup_codes": hashes,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+
+	return &TOTPBackupCodesResponse{BackupCodes: codes}, nil
+}
+
+// DisableTOTP turns two-factor authentication off after checking a current code or
+// backup code
+func (s *AuthService) DisableTOTP(ctx context.Context, userID, tenantID uuid.UUID, code string) error {
+	var user models.User
+	if err := s.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", userID, tenantID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("user not found")
+		}
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+	if !user.TOTPEnabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	if err := s.verifySecondFactor(ctx, &user, code); err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Model(&user).Updates(map[string]interface{}{
+		"totp_enabled":      false,
+		"totp_enabled_at":   nil,
+		"totp_secret":       "",
+		"totp_backup_codes": "",
+	}).Error; err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+	return nil
+}
+
+// verifySecondFactor accepts either a current TOTP code or an unused backup code,
+// which is consumed
+func (s *AuthService) verifySecondFactor(ctx context.Context, user *models.User, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == utils.TOTPDigits {
+		return s.checkTOTPCode(ctx, user, code)
+	}
+
+	var hashes []string
+	if user.TOTPBackupCodes != "" {
+		if err := json.Unmarshal([]byte(user.TOTPBackupCodes), &hashes); err != nil {
+			return fmt.Errorf("failed to read backup codes: %w", err)
+		}
+	}
+
+	hash := hashBackupCode(code)
+	for i, stored := range hashes {
+		if stored != hash {
+			continue
+		}
+		remaining, _ := json.Marshal(append(hashes[:i:i], hashes[i+1:]...))
+		result := s.db.WithContext(ctx).Model(&models.User{}).
+			Where("id = ? AND totp_backup_codes = ?", user.ID, user.TOTPBackupCodes).
+			Update("totp_backup_codes", string(remaining))
+		if result.Error != nil {
+			return fmt.Errorf("failed to consume backup code: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			// Another login consumed a backup code at the same time
+			return errors.New("invalid two-factor code")
+		}
+		user.TOTPBackupCodes = string(remaining)
+		return nil
+	}
+
+	return errors.New("invalid two-factor code")
+}
+
+// checkTOTPCode validates a code against the user's secret and rejects replays
+func (s *AuthService) checkTOTPCode(ctx context.Context, user *models.User, code string) error {
+	secret, err := utils.DecryptString(user.TOTPSecret, s.totpKey())
+	if err != nil {
+		return fmt.Errorf("failed to read TOTP secret: %w", err)
+	}
+
+	step, ok := utils.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return errors.New("invalid two-factor code")
+	}
+
+	window := time.Duration(2*utils.TOTPSkew+1) * utils.TOTPPeriod
+	fresh, err := s.cache.Lock(ctx, fmt.Sprintf(totpUsedKey, user.ID.String(), step), window)
+	if err != nil {
+		return fmt.Errorf("failed to verify two-factor code: %w", err)
+	}
+	if !fresh {
+		return errors.New("two-factor code has already been used")
+	}
+	return nil
+}
+
+// findTwoFactorUser loads a user who is allowed to use two-factor authentication
+func (s *AuthService) findTwoFactorUser(ctx context.Context, userID, tenantID uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := s.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", userID, tenantID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	for _, role := range twoFactorRoles {
+		if user.Role == role {
+			return &user, nil
+		}
+	}
+	return nil, errors.New("two-factor authentication is only available to admins and managers")
+}
+
+// totpKey is the key stored TOTP secrets are encrypted with
+func (s *AuthService) totpKey() string {
+	if s.config.TOTPKey != "" {
+		return s.config.TOTPKey
+	}
+	return s.config.Secret
+}
+
+// generateBackupCodes returns new backup codes and the JSON list of their hashes
+func generateBackupCodes() ([]string, string, error) {
+	codes := make([]string, backupCodeCount)
+	hashes := make([]string, backupCodeCount)
+	for i := range codes {
+		random, err := utils.GenerateRandomString(10)
+		if err != nil {
+			return nil, "", err
+		}
+		codes[i] = random[:5] + "-" + random[5:]
+		hashes[i] = hashBackupCode(codes[i])
+	}
+
+	encoded, err := json.Marshal(hashes)
+	if err != nil {
+		return nil, "", err
+	}
+	return codes, string(encoded), nil
+}
+
+// hashBackupCode normalises and hashes a backup code for storage
+func hashBackupCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -9,21 +9,24 @@ import (
 	"github.com/liquorpro/go-backend/pkg/shared/cache"
 	"github.com/liquorpro/go-backend/pkg/shared/database"
 	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/usage"
 	"github.com/liquorpro/go-backend/pkg/shared/utils"
 	"gorm.io/gorm"
 )
 
 // UserService handles user management operations
 type UserService struct {
-	db    *database.DB
-	cache *cache.Cache
+	db     *database.DB
+	cache  *cache.Cache
+	limits *usage.Enforcer
 }
 
 // NewUserService creates a new user service
 func NewUserService(db *database.DB, cache *cache.Cache) *UserService {
 	return &UserService{
-		db:    db,
-		cache: cache,
+		db:     db,
+		cache:  cache,
+		limits: usage.NewEnforcer(db.DB),
 	}
 }
 
@@ -48,6 +51,19 @@ type UpdateUserRequest struct {
 	IsActive     *bool   `json:"is_active"`
 }
 
+// AutoApprovalRequest represents a user's auto-approval trust settings
+type AutoApprovalRequest struct {
+	IsTrusted         *bool   `json:"is_trusted" binding:"required"`
+	AutoApprovalLimit float64 `json:"auto_approval_limit" binding:"gte=0"`
+}
+
+// AutoApprovalResponse represents a user's auto-approval trust settings in responses
+type AutoApprovalResponse struct {
+	UserID            uuid.UUID `json:"user_id"`
+	IsTrusted         bool      `json:"is_trusted"`
+	AutoApprovalLimit float64   `json:"auto_approval_limit"`
+}
+
 // ChangePasswordRequest represents password change request
 type ChangePasswordRequest struct {
 	CurrentPassword string `json:"current_password" binding:"required"`
@@ -149,6 +165,13 @@ func (s *UserService) CreateUser(ctx context.Context, req CreateUserRequest, ten
 		return nil, errors.New("username or email already exists")
 	}
 
+	// Active users count towards the subscription plan's user limit
+	if req.IsActive {
+		if err := s.limits.Check(ctx, tenantID, usage.ResourceUsers); err != nil {
+			return nil, err
+		}
+	}
+
 	// Hash password
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
@@ -216,6 +239,12 @@ func (s *UserService) UpdateUser(ctx context.Context, userID, tenantID uuid.UUID
 		user.ProfileImage = *req.ProfileImage
 	}
 	if req.IsActive != nil {
+		// Reactivating a user takes a seat on the subscription plan
+		if *req.IsActive && !user.IsActive {
+			if err := s.limits.Check(ctx, tenantID, usage.ResourceUsers); err != nil {
+				return nil, err
+			}
+		}
 		updates["is_active"] = *req.IsActive
 		user.IsActive = *req.IsActive
 	}
@@ -244,6 +273,37 @@ func (s *UserService) UpdateUser(ctx context.Context, userID, tenantID uuid.UUID
 	}, nil
 }
 
+// SetAutoApproval updates whether a user's submissions may be auto-approved and up to what amount
+func (s *UserService) SetAutoApproval(ctx context.Context, userID, tenantID uuid.UUID, req AutoApprovalRequest) (*AutoApprovalResponse, error) {
+	var user models.User
+	
+	err := s.db.Where("id = ? AND tenant_id = ?", userID, tenantID).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	if *req.IsTrusted && req.AutoApprovalLimit <= 0 {
+		return nil, errors.New("auto approval limit must be greater than zero for trusted users")
+	}
+
+	updates := map[string]interface{}{
+		"is_trusted":          *req.IsTrusted,
+		"auto_approval_limit": req.AutoApprovalLimit,
+	}
+	if err := s.db.Model(&user).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update auto approval settings: %w", err)
+	}
+
+	return &AutoApprovalResponse{
+		UserID:            user.ID,
+		IsTrusted:         *req.IsTrusted,
+		AutoApprovalLimit: req.AutoApprovalLimit,
+	}, nil
+}
+
 // ChangePassword changes user password
 func (s *UserService) ChangePassword(ctx context.Context, userID, tenantID uuid.UUID, req ChangePasswordRequest) error {
 	var user models.User
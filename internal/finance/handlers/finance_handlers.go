@@ -1,31 +1,41 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/liquorpro/go-backend/internal/finance/services"
+	"github.com/liquorpro/go-backend/pkg/shared/approval"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
 )
 
 type FinanceHandlers struct {
 	vendorService           *services.VendorService
 	expenseService          *services.ExpenseService
 	assistantManagerService *services.AssistantManagerService
+	financeService          *services.FinanceService
+	exportService           *services.AccountingExportService
 }
 
 func NewFinanceHandlers(
 	vendorService *services.VendorService,
 	expenseService *services.ExpenseService,
 	assistantManagerService *services.AssistantManagerService,
+	financeService *services.FinanceService,
+	exportService *services.AccountingExportService,
 ) *FinanceHandlers {
 	return &FinanceHandlers{
 		vendorService:           vendorService,
 		expenseService:          expenseService,
 		assistantManagerService: assistantManagerService,
+		financeService:          financeService,
+		exportService:           exportService,
 	}
 }
 
@@ -75,7 +85,8 @@ func (h *FinanceHandlers) GetVendors(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"vendors": vendors})
+	list := utils.PaginateSlice(vendors, utils.ParsePageRequest(c, 50, 100))
+	utils.RespondPaginated(c, list, gin.H{"vendors": list.Data})
 }
 
 func (h *FinanceHandlers) GetVendorByID(c *gin.Context) {
@@ -201,7 +212,14 @@ func (h *FinanceHandlers) CreateVendorTransaction(c *gin.Context) {
 
 	transaction, err := h.vendorService.CreateVendorTransaction(c.Request.Context(), req, tenantID, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch {
+		case strings.HasSuffix(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "failed to"):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
@@ -221,22 +239,116 @@ func (h *FinanceHandlers) GetVendorTransactions(c *gin.Context) {
 		return
 	}
 
-	limit, offset := h.getPagination(c)
+	page := utils.ParsePageRequest(c, 50, 100)
 
-	transactions, total, err := h.vendorService.GetVendorTransactions(c.Request.Context(), vendorID, tenantID, limit, offset)
+	transactions, total, err := h.vendorService.GetVendorTransactions(c.Request.Context(), vendorID, tenantID, page.PageSize, page.Offset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"transactions": transactions,
-		"total":        total,
-		"limit":        limit,
-		"offset":       offset,
+	list := utils.NewPaginatedResponse(transactions, total, page)
+	utils.RespondPaginated(c, list, gin.H{
+		"transactions": list.Data,
+		"total":        list.TotalCount,
+		"limit":        page.PageSize,
+		"offset":       page.Offset,
 	})
 }
 
+// CreateVendorInvoice records a bill received from a vendor
+func (h *FinanceHandlers) CreateVendorInvoice(c *gin.Context) {
+	vendorID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid vendor ID"})
+		return
+	}
+
+	var req services.VendorInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantID, err := h.extractTenantID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	invoice, err := h.vendorService.CreateVendorInvoice(c.Request.Context(), vendorID, req, tenantID)
+	if err != nil {
+		switch {
+		case err.Error() == "vendor not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "failed to"):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, invoice)
+}
+
+// GetVendorInvoices lists a vendor's invoices with what is still due on each
+func (h *FinanceHandlers) GetVendorInvoices(c *gin.Context) {
+	vendorID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid vendor ID"})
+		return
+	}
+
+	tenantID, err := h.extractTenantID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	invoices, err := h.vendorService.GetVendorInvoices(c.Request.Context(), vendorID, tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	list := utils.PaginateSlice(invoices, utils.ParsePageRequest(c, 50, 100))
+	utils.RespondPaginated(c, list, gin.H{"invoices": list.Data})
+}
+
+// GetVendorStatement returns a vendor's statement with a running balance and an aging
+// breakdown of unpaid invoices, defaulting to the current month
+func (h *FinanceHandlers) GetVendorStatement(c *gin.Context) {
+	vendorID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid vendor ID"})
+		return
+	}
+
+	tenantID, err := h.extractTenantID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	startDate, endDate, ok := h.statementDateRange(c)
+	if !ok {
+		return
+	}
+
+	statement, err := h.vendorService.GetVendorStatement(c.Request.Context(), vendorID, tenantID, startDate, endDate)
+	if err != nil {
+		if err.Error() == "vendor not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, statement)
+}
+
 // Expense handlers
 func (h *FinanceHandlers) CreateExpense(c *gin.Context) {
 	var req services.ExpenseRequest
@@ -302,19 +414,20 @@ func (h *FinanceHandlers) GetExpenses(c *gin.Context) {
 	
 	filters.PaymentMethod = c.Query("payment_method")
 
-	limit, offset := h.getPagination(c)
+	page := utils.ParsePageRequest(c, 50, 100)
 
-	expenses, total, err := h.expenseService.GetExpenses(c.Request.Context(), tenantID, filters, limit, offset)
+	expenses, total, err := h.expenseService.GetExpenses(c.Request.Context(), tenantID, filters, page.PageSize, page.Offset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"expenses": expenses,
-		"total":    total,
-		"limit":    limit,
-		"offset":   offset,
+	list := utils.NewPaginatedResponse(expenses, total, page)
+	utils.RespondPaginated(c, list, gin.H{
+		"expenses": list.Data,
+		"total":    list.TotalCount,
+		"limit":    page.PageSize,
+		"offset":   page.Offset,
 	})
 }
 
@@ -398,10 +511,11 @@ func (h *FinanceHandlers) DeleteExpense(c *gin.Context) {
 	c.JSON(http.StatusNoContent, nil)
 }
 
-func (h *FinanceHandlers) CreateExpenseCategory(c *gin.Context) {
-	var req services.ExpenseCategoryRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+// ApproveExpense approves an expense that is waiting for approval
+func (h *FinanceHandlers) ApproveExpense(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expense ID"})
 		return
 	}
 
@@ -411,61 +525,75 @@ func (h *FinanceHandlers) CreateExpenseCategory(c *gin.Context) {
 		return
 	}
 
-	category, err := h.expenseService.CreateExpenseCategory(c.Request.Context(), req, tenantID, userID)
+	expense, err := h.expenseService.ApproveExpense(c.Request.Context(), id, tenantID, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.expenseDecisionError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, category)
+	c.JSON(http.StatusOK, expense)
 }
 
-func (h *FinanceHandlers) GetExpenseCategories(c *gin.Context) {
-	tenantID, err := h.extractTenantID(c)
+// RejectExpense rejects an expense that is waiting for approval
+func (h *FinanceHandlers) RejectExpense(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expense ID"})
+		return
+	}
+
+	tenantID, userID, err := h.extractTenantAndUser(c)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
-	includeInactive := c.Query("include_inactive") == "true"
+	var reqBody struct {
+		Reason string `json:"reason"`
+	}
+	c.ShouldBindJSON(&reqBody)
 
-	categories, err := h.expenseService.GetExpenseCategories(c.Request.Context(), tenantID, includeInactive)
+	expense, err := h.expenseService.RejectExpense(c.Request.Context(), id, tenantID, userID, reqBody.Reason)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.expenseDecisionError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"categories": categories})
+	c.JSON(http.StatusOK, expense)
 }
 
-func (h *FinanceHandlers) GetExpenseSummary(c *gin.Context) {
+func (h *FinanceHandlers) expenseDecisionError(c *gin.Context, err error) {
+	var processed *approval.AlreadyProcessedError
+	switch {
+	case errors.As(err, &processed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "status": processed.Status})
+	case err.Error() == "expense not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetPendingExpenseSummary returns how many expenses are waiting for approval and
+// their total, optionally for one shop
+func (h *FinanceHandlers) GetPendingExpenseSummary(c *gin.Context) {
 	tenantID, err := h.extractTenantID(c)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Parse date range
-	var startDate, endDate time.Time
-	if startDateStr := c.Query("start_date"); startDateStr != "" {
-		if parsed, err := time.Parse("2006-01-02", startDateStr); err == nil {
-			startDate = parsed
-		}
-	}
-	if endDateStr := c.Query("end_date"); endDateStr != "" {
-		if parsed, err := time.Parse("2006-01-02", endDateStr); err == nil {
-			endDate = parsed
+	var shopID *uuid.UUID
+	if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		id, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop ID"})
+			return
 		}
+		shopID = &id
 	}
 
-	// Default to current month if no dates provided
-	if startDate.IsZero() && endDate.IsZero() {
-		now := time.Now()
-		startDate = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-		endDate = startDate.AddDate(0, 1, -1)
-	}
-
-	summary, err := h.expenseService.GetExpenseSummary(c.Request.Context(), tenantID, startDate, endDate)
+	summary, err := h.expenseService.GetPendingExpenseSummary(c.Request.Context(), tenantID, shopID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -474,11 +602,12 @@ func (h *FinanceHandlers) GetExpenseSummary(c *gin.Context) {
 	c.JSON(http.StatusOK, summary)
 }
 
-// Assistant Manager handlers
-func (h *FinanceHandlers) CreateMoneyCollection(c *gin.Context) {
-	var req services.MoneyCollectionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+// UploadExpenseAttachment attaches a receipt or bill, sent as a multipart "file"
+// field, to an expense
+func (h *FinanceHandlers) UploadExpenseAttachment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expense ID"})
 		return
 	}
 
@@ -488,44 +617,53 @@ func (h *FinanceHandlers) CreateMoneyCollection(c *gin.Context) {
 		return
 	}
 
-	collection, err := h.assistantManagerService.CreateMoneyCollection(c.Request.Context(), req, tenantID, userID)
+	// Leave room for the multipart headers around the file itself
+	maxSize := h.expenseService.MaxAttachmentSize()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+	fileHeader, err := c.FormFile("file")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Attachment is larger than the %d byte limit", maxSize)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Attachment is required in the \"file\" field"})
 		return
 	}
-
-	c.JSON(http.StatusCreated, collection)
-}
-
-func (h *FinanceHandlers) GetMoneyCollections(c *gin.Context) {
-	tenantID, err := h.extractTenantID(c)
+	if fileHeader.Size > maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Attachment is larger than the %d byte limit", maxSize)})
+		return
+	}
+	file, err := fileHeader.Open()
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
 		return
 	}
+	defer file.Close()
 
-	status := c.Query("status")
-	includeOverdue := c.Query("include_overdue") == "true"
-	limit, offset := h.getPagination(c)
-
-	collections, total, err := h.assistantManagerService.GetMoneyCollections(c.Request.Context(), tenantID, status, includeOverdue, limit, offset)
+	attachment, err := h.expenseService.AddExpenseAttachment(c.Request.Context(), id, tenantID, userID, fileHeader.Filename, file)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch {
+		case err.Error() == "expense not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "attachment is larger"):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "failed to"):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"collections": collections,
-		"total":       total,
-		"limit":       limit,
-		"offset":      offset,
-	})
+	c.JSON(http.StatusCreated, attachment)
 }
 
-func (h *FinanceHandlers) GetMoneyCollectionByID(c *gin.Context) {
+// GetExpenseAttachments lists the receipts and bills attached to an expense
+func (h *FinanceHandlers) GetExpenseAttachments(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expense ID"})
 		return
 	}
 
@@ -535,9 +673,9 @@ func (h *FinanceHandlers) GetMoneyCollectionByID(c *gin.Context) {
 		return
 	}
 
-	collection, err := h.assistantManagerService.GetMoneyCollectionByID(c.Request.Context(), id, tenantID)
+	attachments, err := h.expenseService.GetExpenseAttachments(c.Request.Context(), id, tenantID)
 	if err != nil {
-		if err.Error() == "money collection not found" {
+		if err.Error() == "expense not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
@@ -545,35 +683,50 @@ func (h *FinanceHandlers) GetMoneyCollectionByID(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, collection)
+	c.JSON(http.StatusOK, gin.H{"attachments": attachments})
 }
 
-func (h *FinanceHandlers) ApproveMoneyCollection(c *gin.Context) {
+// DownloadExpenseAttachment sends an attached file with its original name
+func (h *FinanceHandlers) DownloadExpenseAttachment(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expense ID"})
 		return
 	}
 
-	tenantID, userID, err := h.extractTenantAndUser(c)
+	attachmentID, err := uuid.Parse(c.Param("attachment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+
+	tenantID, err := h.extractTenantID(c)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
-	err = h.assistantManagerService.ApproveMoneyCollection(c.Request.Context(), id, tenantID, userID)
+	attachment, file, err := h.expenseService.OpenExpenseAttachment(c.Request.Context(), id, attachmentID, tenantID)
 	if err != nil {
+		if err.Error() == "attachment not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	defer file.Close()
 
-	c.JSON(http.StatusOK, gin.H{"message": "Money collection approved successfully"})
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, file, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", attachment.FileName),
+	})
 }
 
-func (h *FinanceHandlers) RejectMoneyCollection(c *gin.Context) {
-	id, err := uuid.Parse(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+// CreateRecurringExpense sets up an expense that is raised on a schedule
+func (h *FinanceHandlers) CreateRecurringExpense(c *gin.Context) {
+	var req services.RecurringExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -583,44 +736,71 @@ func (h *FinanceHandlers) RejectMoneyCollection(c *gin.Context) {
 		return
 	}
 
-	var reqBody struct {
-		Reason string `json:"reason"`
+	recurring, err := h.expenseService.CreateRecurringExpense(c.Request.Context(), req, tenantID, userID)
+	if err != nil {
+		switch {
+		case strings.HasSuffix(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "failed to"):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
 	}
-	c.ShouldBindJSON(&reqBody)
 
-	err = h.assistantManagerService.RejectMoneyCollection(c.Request.Context(), id, tenantID, userID, reqBody.Reason)
+	c.JSON(http.StatusCreated, recurring)
+}
+
+// GetRecurringExpenses lists recurring expenses; pass include_inactive=true to see
+// disabled and finished ones too
+func (h *FinanceHandlers) GetRecurringExpenses(c *gin.Context) {
+	tenantID, err := h.extractTenantID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	includeInactive := c.Query("include_inactive") == "true"
+
+	recurring, err := h.expenseService.GetRecurringExpenses(c.Request.Context(), tenantID, includeInactive)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Money collection rejected successfully"})
+	list := utils.PaginateSlice(recurring, utils.ParsePageRequest(c, 50, 100))
+	utils.RespondPaginated(c, list, gin.H{"recurring_expenses": list.Data})
 }
 
-func (h *FinanceHandlers) CreateAssistantManagerExpense(c *gin.Context) {
-	var req services.AssistantManagerExpenseRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+// DisableRecurringExpense stops a recurring expense from raising further expenses
+func (h *FinanceHandlers) DisableRecurringExpense(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recurring expense ID"})
 		return
 	}
 
-	tenantID, userID, err := h.extractTenantAndUser(c)
+	tenantID, err := h.extractTenantID(c)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
-	expense, err := h.assistantManagerService.CreateAssistantManagerExpense(c.Request.Context(), req, tenantID, userID)
-	if err != nil {
+	if err := h.expenseService.DisableRecurringExpense(c.Request.Context(), id, tenantID); err != nil {
+		if err.Error() == "recurring expense not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, expense)
+	c.JSON(http.StatusOK, gin.H{"message": "Recurring expense disabled"})
 }
 
-func (h *FinanceHandlers) CreateAssistantManagerFinance(c *gin.Context) {
-	var req services.AssistantManagerFinanceRequest
+func (h *FinanceHandlers) CreateExpenseCategory(c *gin.Context) {
+	var req services.ExpenseCategoryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -632,71 +812,977 @@ func (h *FinanceHandlers) CreateAssistantManagerFinance(c *gin.Context) {
 		return
 	}
 
-	finance, err := h.assistantManagerService.CreateAssistantManagerFinance(c.Request.Context(), req, tenantID, userID)
+	category, err := h.expenseService.CreateExpenseCategory(c.Request.Context(), req, tenantID, userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, finance)
+	c.JSON(http.StatusCreated, category)
 }
 
-// Helper functions
-func (h *FinanceHandlers) extractTenantID(c *gin.Context) (uuid.UUID, error) {
-	tenantID, exists := c.Get("tenant_id")
-	if !exists {
-		return uuid.Nil, fmt.Errorf("tenant ID not found")
-	}
-
-	tenantUUID, err := uuid.Parse(tenantID.(string))
+func (h *FinanceHandlers) GetExpenseCategories(c *gin.Context) {
+	tenantID, err := h.extractTenantID(c)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("invalid tenant ID")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
 	}
 
-	return tenantUUID, nil
-}
-
-func (h *FinanceHandlers) extractUserID(c *gin.Context) (uuid.UUID, error) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		return uuid.Nil, fmt.Errorf("user ID not found")
+	filters := services.ExpenseCategoryFilters{
+		Search:          c.Query("search"),
+		IncludeInactive: c.Query("include_inactive") == "true",
 	}
 
-	userUUID, err := uuid.Parse(userID.(string))
+	page := utils.ParsePageRequest(c, 50, 100)
+
+	categories, total, err := h.expenseService.GetExpenseCategories(c.Request.Context(), tenantID, filters, page.PageSize, page.Offset)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("invalid user ID")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
-	return userUUID, nil
+	list := utils.NewPaginatedResponse(categories, total, page)
+	utils.RespondPaginated(c, list, gin.H{
+		"categories": list.Data,
+		"total":      list.TotalCount,
+		"limit":      page.PageSize,
+		"offset":     page.Offset,
+	})
 }
 
-func (h *FinanceHandlers) extractTenantAndUser(c *gin.Context) (uuid.UUID, uuid.UUID, error) {
+func (h *FinanceHandlers) GetExpenseSummary(c *gin.Context) {
 	tenantID, err := h.extractTenantID(c)
 	if err != nil {
-		return uuid.Nil, uuid.Nil, err
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
 	}
 
-	userID, err := h.extractUserID(c)
+	// Parse date range
+	var startDate, endDate time.Time
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		if parsed, err := time.Parse("2006-01-02", startDateStr); err == nil {
+			startDate = parsed
+		}
+	}
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		if parsed, err := time.Parse("2006-01-02", endDateStr); err == nil {
+			endDate = parsed
+		}
+	}
+
+	// Default to current month if no dates provided
+	if startDate.IsZero() && endDate.IsZero() {
+		now := time.Now()
+		startDate = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		endDate = startDate.AddDate(0, 1, -1)
+	}
+
+	summary, err := h.expenseService.GetExpenseSummary(c.Request.Context(), tenantID, startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// GetBreakEven returns the sales needed to cover fixed costs and month-to-date progress
+func (h *FinanceHandlers) GetBreakEven(c *gin.Context) {
+	tenantID, err := h.extractTenantID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var shopID *uuid.UUID
+	if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		parsed, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop ID"})
+			return
+		}
+		shopID = &parsed
+	}
+
+	months := services.DefaultBreakEvenMonths
+	if monthsStr := c.Query("months"); monthsStr != "" {
+		parsed, err := strconv.Atoi(monthsStr)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "months must be a positive integer"})
+			return
+		}
+		months = parsed
+	}
+
+	report, err := h.expenseService.GetBreakEven(c.Request.Context(), tenantID, shopID, months)
+	if err != nil {
+		switch {
+		case err.Error() == "shop not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "failed to"):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetCollectionSheet returns the salesman-wise collection sheet for a day as JSON
+// or, with format=pdf, as a printable document
+func (h *FinanceHandlers) GetCollectionSheet(c *gin.Context) {
+	tenantID, err := h.extractTenantID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	date := time.Now()
+	if dateStr := c.Query("date"); dateStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", dateStr, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must be in YYYY-MM-DD format"})
+			return
+		}
+		date = parsed
+	}
+
+	var shopID *uuid.UUID
+	if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		parsed, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop ID"})
+			return
+		}
+		shopID = &parsed
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "pdf" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or pdf"})
+		return
+	}
+
+	sheet, err := h.assistantManagerService.GetCollectionSheet(c.Request.Context(), tenantID, date, shopID)
+	if err != nil {
+		switch {
+		case err.Error() == "shop not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "failed to"):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if format == "pdf" {
+		filename := fmt.Sprintf("collection-sheet-%s.pdf", date.Format("20060102"))
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Data(http.StatusOK, "application/pdf", h.assistantManagerService.RenderCollectionSheetPDF(sheet))
+		return
+	}
+
+	c.JSON(http.StatusOK, sheet)
+}
+
+// Assistant Manager handlers
+func (h *FinanceHandlers) CreateMoneyCollection(c *gin.Context) {
+	var req services.MoneyCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantID, userID, err := h.extractTenantAndUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.OverrideBalance {
+		role := c.GetString("role")
+		if role != "manager" && role != "admin" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only managers and admins can override the balance check"})
+			return
+		}
+	}
+
+	collection, err := h.assistantManagerService.CreateMoneyCollection(c.Request.Context(), req, tenantID, userID)
+	if err != nil {
+		var exceeded *services.CollectionExceedsBalanceError
+		switch {
+		case errors.As(err, &exceeded):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "balance": exceeded.Balance})
+		case err.Error() == "executive not found" || err.Error() == "shop not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "failed to"):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, collection)
+}
+
+// GetExecutiveBalance returns an executive's sales not yet covered by money collections
+func (h *FinanceHandlers) GetExecutiveBalance(c *gin.Context) {
+	tenantID, err := h.extractTenantID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	executiveID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid executive ID"})
+		return
+	}
+
+	balance, err := h.assistantManagerService.GetExecutiveBalance(c.Request.Context(), tenantID, executiveID)
+	if err != nil {
+		if err.Error() == "executive not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, balance)
+}
+
+// GetAssistantManagerLedger returns an assistant manager's ledger statement for a date
+// range, defaulting to the current month. Assistant managers can only see their own.
+func (h *FinanceHandlers) GetAssistantManagerLedger(c *gin.Context) {
+	tenantID, userID, err := h.extractTenantAndUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	assistantManagerID := userID
+	if idStr := c.Query("assistant_manager_id"); idStr != "" {
+		assistantManagerID, err = uuid.Parse(idStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assistant manager ID"})
+			return
+		}
+	}
+	if assistantManagerID != userID && c.GetString("role") == "assistant_manager" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Assistant managers can only view their own ledger"})
+		return
+	}
+
+	startDate, endDate, ok := h.statementDateRange(c)
+	if !ok {
+		return
+	}
+
+	page := utils.ParsePageRequest(c, 50, 100)
+
+	statement, err := h.assistantManagerService.GetLedger(c.Request.Context(), tenantID, assistantManagerID, startDate, endDate, page.PageSize, page.Offset)
+	if err != nil {
+		if err.Error() == "assistant manager not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, statement)
+}
+
+// CreateBankDeposit records the current user's cash deposit into a bank account
+func (h *FinanceHandlers) CreateBankDeposit(c *gin.Context) {
+	var req services.BankDepositRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantID, userID, err := h.extractTenantAndUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	deposit, err := h.assistantManagerService.CreateBankDeposit(c.Request.Context(), req, tenantID, userID)
+	if err != nil {
+		switch {
+		case strings.HasSuffix(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "failed to"):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, deposit)
+}
+
+// GetBankDeposits lists bank deposits. Assistant managers only see their own.
+func (h *FinanceHandlers) GetBankDeposits(c *gin.Context) {
+	tenantID, userID, err := h.extractTenantAndUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var createdByID *uuid.UUID
+	if c.GetString("role") == "assistant_manager" {
+		createdByID = &userID
+	} else if idStr := c.Query("assistant_manager_id"); idStr != "" {
+		parsed, err := uuid.Parse(idStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assistant manager ID"})
+			return
+		}
+		createdByID = &parsed
+	}
+
+	page := utils.ParsePageRequest(c, 50, 100)
+
+	deposits, total, err := h.assistantManagerService.GetBankDeposits(c.Request.Context(), tenantID, createdByID, c.Query("status"), page.PageSize, page.Offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	list := utils.NewPaginatedResponse(deposits, total, page)
+	utils.RespondPaginated(c, list, gin.H{
+		"deposits": list.Data,
+		"total":    list.TotalCount,
+		"limit":    page.PageSize,
+		"offset":   page.Offset,
+	})
+}
+
+// ApproveBankDeposit confirms a pending bank deposit
+func (h *FinanceHandlers) ApproveBankDeposit(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deposit ID"})
+		return
+	}
+
+	tenantID, userID, err := h.extractTenantAndUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	deposit, err := h.assistantManagerService.ApproveBankDeposit(c.Request.Context(), id, tenantID, userID)
+	if err != nil {
+		switch {
+		case err.Error() == "bank deposit not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "bank deposit is already"):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, deposit)
+}
+
+// ReconcileDeposits matches an assistant manager's collections against their bank
+// deposits for a date range, defaulting to the current month
+func (h *FinanceHandlers) ReconcileDeposits(c *gin.Context) {
+	tenantID, err := h.extractTenantID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	assistantManagerID, err := uuid.Parse(c.Query("assistant_manager_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A valid assistant_manager_id is required"})
+		return
+	}
+
+	startDate, endDate, ok := h.statementDateRange(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.assistantManagerService.ReconcileDeposits(c.Request.Context(), tenantID, assistantManagerID, startDate, endDate)
+	if err != nil {
+		if err.Error() == "assistant manager not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// CreateCashDrawer records a shop's end-of-day cash count and reconciles it against
+// the cash the shop's sales and expenses say it should hold
+func (h *FinanceHandlers) CreateCashDrawer(c *gin.Context) {
+	var req services.CashDrawerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantID, userID, err := h.extractTenantAndUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	drawer, err := h.assistantManagerService.CreateCashDrawer(c.Request.Context(), req, tenantID, userID)
+	if err != nil {
+		switch {
+		case err.Error() == "shop not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.HasSuffix(err.Error(), "already counted"):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "failed to"):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, drawer)
+}
+
+// GetCashDrawers lists cash drawer counts, optionally only the over or short ones
+// still unresolved
+func (h *FinanceHandlers) GetCashDrawers(c *gin.Context) {
+	tenantID, err := h.extractTenantID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter := services.CashDrawerFilter{
+		Status:     c.Query("status"),
+		Unresolved: c.Query("unresolved") == "true",
+	}
+	if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		shopID, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop ID"})
+			return
+		}
+		filter.ShopID = &shopID
+	}
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		startDate, err := time.ParseInLocation("2006-01-02", startDateStr, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date, expected YYYY-MM-DD"})
+			return
+		}
+		filter.StartDate = &startDate
+	}
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		endDate, err := time.ParseInLocation("2006-01-02", endDateStr, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date, expected YYYY-MM-DD"})
+			return
+		}
+		filter.EndDate = &endDate
+	}
+
+	page := utils.ParsePageRequest(c, 50, 100)
+
+	drawers, total, err := h.assistantManagerService.GetCashDrawers(c.Request.Context(), tenantID, filter, page.PageSize, page.Offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	list := utils.NewPaginatedResponse(drawers, total, page)
+	utils.RespondPaginated(c, list, gin.H{
+		"cash_drawers": list.Data,
+		"total":        list.TotalCount,
+		"limit":        page.PageSize,
+		"offset":       page.Offset,
+	})
+}
+
+// ResolveCashDrawer records the explanation for an over or short cash drawer
+func (h *FinanceHandlers) ResolveCashDrawer(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cash drawer ID"})
+		return
+	}
+
+	var req services.ResolveCashDrawerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantID, userID, err := h.extractTenantAndUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	drawer, err := h.assistantManagerService.ResolveCashDrawer(c.Request.Context(), id, req, tenantID, userID)
+	if err != nil {
+		switch {
+		case err.Error() == "cash drawer not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "failed to"):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, drawer)
+}
+
+func (h *FinanceHandlers) GetMoneyCollections(c *gin.Context) {
+	tenantID, err := h.extractTenantID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := c.Query("status")
+	includeOverdue := c.Query("include_overdue") == "true"
+	page := utils.ParsePageRequest(c, 50, 100)
+
+	collections, total, err := h.assistantManagerService.GetMoneyCollections(c.Request.Context(), tenantID, status, includeOverdue, page.PageSize, page.Offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	list := utils.NewPaginatedResponse(collections, total, page)
+	utils.RespondPaginated(c, list, gin.H{
+		"collections": list.Data,
+		"total":       list.TotalCount,
+		"limit":       page.PageSize,
+		"offset":      page.Offset,
+	})
+}
+
+func (h *FinanceHandlers) GetMoneyCollectionByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return
+	}
+
+	tenantID, err := h.extractTenantID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection, err := h.assistantManagerService.GetMoneyCollectionByID(c.Request.Context(), id, tenantID)
+	if err != nil {
+		if err.Error() == "money collection not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+func (h *FinanceHandlers) ApproveMoneyCollection(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return
+	}
+
+	tenantID, userID, err := h.extractTenantAndUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The body is optional; without a version the collection is approved as it stands
+	var reqBody struct {
+		Version *int `json:"version"`
+	}
+	c.ShouldBindJSON(&reqBody)
+
+	err = h.assistantManagerService.ApproveMoneyCollection(c.Request.Context(), id, tenantID, userID, reqBody.Version)
+	if err != nil {
+		h.collectionDecisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Money collection approved successfully"})
+}
+
+func (h *FinanceHandlers) RejectMoneyCollection(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		return uuid.Nil, uuid.Nil, err
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return
 	}
 
-	return tenantID, userID, nil
+	tenantID, userID, err := h.extractTenantAndUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var reqBody struct {
+		Reason  string `json:"reason"`
+		Version *int   `json:"version"`
+	}
+	c.ShouldBindJSON(&reqBody)
+
+	err = h.assistantManagerService.RejectMoneyCollection(c.Request.Context(), id, tenantID, userID, reqBody.Reason, reqBody.Version)
+	if err != nil {
+		h.collectionDecisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Money collection rejected successfully"})
+}
+
+// collectionDecisionError answers a failed approve or reject, reporting a collection
+// another request already decided or modified as a conflict
+func (h *FinanceHandlers) collectionDecisionError(c *gin.Context, err error) {
+	var processed *approval.AlreadyProcessedError
+	if errors.As(err, &processed) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "status": processed.Status})
+		return
+	}
+	var modified *approval.ModifiedError
+	if errors.As(err, &modified) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+func (h *FinanceHandlers) CreateAssistantManagerExpense(c *gin.Context) {
+	var req services.AssistantManagerExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantID, userID, err := h.extractTenantAndUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	expense, err := h.assistantManagerService.CreateAssistantManagerExpense(c.Request.Context(), req, tenantID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, expense)
+}
+
+func (h *FinanceHandlers) CreateAssistantManagerFinance(c *gin.Context) {
+	var req services.AssistantManagerFinanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantID, userID, err := h.extractTenantAndUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	finance, err := h.assistantManagerService.CreateAssistantManagerFinance(c.Request.Context(), req, tenantID, userID)
+	if err != nil {
+		var mismatch *services.NetAmountMismatchError
+		if errors.As(err, &mismatch) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "breakdown": mismatch.Breakdown})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, finance)
+}
+
+// Bank account handlers
+func (h *FinanceHandlers) CreateBankAccount(c *gin.Context) {
+	var req services.BankAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantID, userID, err := h.extractTenantAndUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := h.financeService.CreateBankAccount(c.Request.Context(), req, tenantID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, account)
+}
+
+func (h *FinanceHandlers) GetBankAccounts(c *gin.Context) {
+	tenantID, err := h.extractTenantID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	includeInactive := c.Query("include_inactive") == "true"
+
+	accounts, err := h.financeService.GetBankAccounts(c.Request.Context(), tenantID, includeInactive)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"bank_accounts": accounts})
+}
+
+func (h *FinanceHandlers) GetBankAccountByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bank account ID"})
+		return
+	}
+
+	tenantID, err := h.extractTenantID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := h.financeService.GetBankAccountByID(c.Request.Context(), id, tenantID)
+	if err != nil {
+		if err.Error() == "bank account not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+func (h *FinanceHandlers) TransferBetweenAccounts(c *gin.Context) {
+	var req services.BankTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantID, userID, err := h.extractTenantAndUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	transfer, err := h.financeService.TransferBetweenAccounts(c.Request.Context(), req.FromAccountID, req.ToAccountID, req.Amount, tenantID, userID)
+	if err != nil {
+		switch err.Error() {
+		case "bank account not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "insufficient balance":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case "cannot transfer to the same account", "bank account is inactive", "transfer amount must be greater than zero":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, transfer)
+}
+
+// Accounting export handlers
+func (h *FinanceHandlers) ExportAccounting(c *gin.Context) {
+	tenantID, err := h.extractTenantID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	format := c.Query("format")
+	if !services.ValidExportFormat(format) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be tally_xml or quickbooks_iif"})
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", c.Query("start"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start must be a date in YYYY-MM-DD format"})
+		return
+	}
+	endDate, err := time.Parse("2006-01-02", c.Query("end"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end must be a date in YYYY-MM-DD format"})
+		return
+	}
+	if endDate.Before(startDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end must not be before start"})
+		return
+	}
+
+	contentType := "application/xml; charset=utf-8"
+	extension := "xml"
+	if format == services.ExportFormatQuickBooksIIF {
+		contentType = "text/plain; charset=utf-8"
+		extension = "iif"
+	}
+	filename := fmt.Sprintf("accounting-%s-%s.%s", startDate.Format("20060102"), endDate.Format("20060102"), extension)
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	// The end date is inclusive
+	if err := h.exportService.ExportAccounting(c.Request.Context(), c.Writer, tenantID, format, startDate, endDate.AddDate(0, 0, 1)); err != nil {
+		// Headers are already sent, so the error can only be recorded
+		c.Error(err)
+	}
+}
+
+func (h *FinanceHandlers) GetAccountMappings(c *gin.Context) {
+	tenantID, err := h.extractTenantID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	mappings, err := h.exportService.GetAccountMappings(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"mappings": mappings})
+}
+
+func (h *FinanceHandlers) SaveAccountMappings(c *gin.Context) {
+	var req services.AccountMappingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantID, err := h.extractTenantID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	mappings, err := h.exportService.SaveAccountMappings(c.Request.Context(), req, tenantID)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "failed to") {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"mappings": mappings})
+}
+
+// Helper functions
+func (h *FinanceHandlers) extractTenantID(c *gin.Context) (uuid.UUID, error) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, fmt.Errorf("tenant ID not found")
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID.(string))
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid tenant ID")
+	}
+
+	return tenantUUID, nil
 }
 
-func (h *FinanceHandlers) getPagination(c *gin.Context) (int, int) {
-	limitStr := c.DefaultQuery("limit", "50")
-	offsetStr := c.DefaultQuery("offset", "0")
+func (h *FinanceHandlers) extractUserID(c *gin.Context) (uuid.UUID, error) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, fmt.Errorf("user ID not found")
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid user ID")
+	}
+
+	return userUUID, nil
+}
+
+func (h *FinanceHandlers) extractTenantAndUser(c *gin.Context) (uuid.UUID, uuid.UUID, error) {
+	tenantID, err := h.extractTenantID(c)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
 
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 || limit > 100 {
-		limit = 50
+	userID, err := h.extractUserID(c)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
 	}
 
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		offset = 0
+	return tenantID, userID, nil
+}
+
+// statementDateRange reads the start_date and end_date query parameters, defaulting
+// to the current month. The end date is inclusive. It responds with 400 and returns
+// false when the range is invalid.
+func (h *FinanceHandlers) statementDateRange(c *gin.Context) (time.Time, time.Time, bool) {
+	now := time.Now()
+	startDate := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	endDate := startDate.AddDate(0, 1, -1)
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		parsed, err := time.Parse("2006-01-02", startDateStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date, expected YYYY-MM-DD"})
+			return time.Time{}, time.Time{}, false
+		}
+		startDate = parsed
+	}
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		parsed, err := time.Parse("2006-01-02", endDateStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date, expected YYYY-MM-DD"})
+			return time.Time{}, time.Time{}, false
+		}
+		endDate = parsed
+	}
+	if endDate.Before(startDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+		return time.Time{}, time.Time{}, false
 	}
 
-	return limit, offset
-}
\ No newline at end of file
+	return startDate, endDate.Add(24*time.Hour - time.Nanosecond), true
+}
@@ -3,13 +3,33 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/liquorpro/go-backend/internal/finance/handlers"
+	"github.com/liquorpro/go-backend/pkg/shared/audit"
 	"github.com/liquorpro/go-backend/pkg/shared/cache"
 	"github.com/liquorpro/go-backend/pkg/shared/config"
 	"github.com/liquorpro/go-backend/pkg/shared/middleware"
+	"github.com/liquorpro/go-backend/pkg/shared/permissions"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
 )
 
+// auditTables maps the resources written through this service to their tables for
+// the write audit log
+var auditTables = map[string]string{
+	"vendors":                    "vendors",
+	"transactions":               "vendor_transactions",
+	"expenses":                   "expenses",
+	"recurring":                  "recurring_expenses",
+	"expense-categories":         "expense_categories",
+	"money-collections":          "money_collections",
+	"assistant-manager/expenses": "assistant_manager_expenses",
+	"assistant-manager/finance":  "assistant_manager_finances",
+	"bank-deposits":              "bank_deposits",
+	"bank-accounts":              "bank_accounts",
+	"cash-drawers":               "cash_drawers",
+}
+
 // SetupRoutes configures all finance service routes
-func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, financeHandlers *handlers.FinanceHandlers) {
+func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, financeHandlers *handlers.FinanceHandlers, settingsService *settings.Service, permissionService *permissions.Service, readAuditor *audit.ReadAuditor, auditLogger *audit.Logger, shopScopes *scope.Resolver) {
 	// Health check
 	router.GET("/health", financeHandlers.Health)
 
@@ -17,13 +37,14 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, fin
 	api := router.Group("/api")
 	api.Use(middleware.AuthMiddleware(cfg.JWT, cache))
 	api.Use(middleware.TenantMiddleware())
+	api.Use(middleware.WriteAuditMiddleware(auditLogger, auditTables))
 
 	// Vendor Management Routes (Core supplier management)
 	vendors := api.Group("/vendors")
 	{
-		vendors.GET("", financeHandlers.GetVendors)
+		vendors.GET("", middleware.ReadAuditMiddleware(readAuditor, settings.ReadAuditVendorBankAccounts), financeHandlers.GetVendors)
 		vendors.POST("", middleware.RoleMiddleware("manager", "admin"), financeHandlers.CreateVendor)
-		vendors.GET("/:id", financeHandlers.GetVendorByID)
+		vendors.GET("/:id", middleware.ReadAuditMiddleware(readAuditor, settings.ReadAuditVendorBankAccounts), financeHandlers.GetVendorByID)
 		vendors.PUT("/:id", middleware.RoleMiddleware("manager", "admin"), financeHandlers.UpdateVendor)
 		vendors.DELETE("/:id", middleware.RoleMiddleware("admin"), financeHandlers.DeleteVendor)
 		
@@ -33,16 +54,36 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, fin
 		// Vendor transactions (payments/purchases)
 		vendors.POST("/transactions", middleware.RoleMiddleware("manager", "admin"), financeHandlers.CreateVendorTransaction)
 		vendors.GET("/:id/transactions", financeHandlers.GetVendorTransactions)
+		vendors.GET("/:id/statement", middleware.RoleMiddleware("manager", "admin"), financeHandlers.GetVendorStatement)
+
+		// Vendor invoices; payments recorded against an invoice update its balance
+		vendors.POST("/:id/invoices", middleware.RoleMiddleware("manager", "admin"), financeHandlers.CreateVendorInvoice)
+		vendors.GET("/:id/invoices", financeHandlers.GetVendorInvoices)
 	}
 
 	// Expense Management Routes (Business expenses)
 	expenses := api.Group("/expenses")
 	{
-		expenses.GET("", financeHandlers.GetExpenses)
-		expenses.POST("", middleware.RoleMiddleware("salesman", "manager", "admin"), financeHandlers.CreateExpense)
+		expenses.GET("", middleware.ShopScopeMiddleware(shopScopes), financeHandlers.GetExpenses)
+		expenses.POST("", middleware.PermissionMiddleware(permissionService, permissions.ExpensesCreate), financeHandlers.CreateExpense)
 		expenses.GET("/:id", financeHandlers.GetExpenseByID)
 		expenses.PUT("/:id", middleware.RoleMiddleware("manager", "admin"), financeHandlers.UpdateExpense)
 		expenses.DELETE("/:id", middleware.RoleMiddleware("admin"), financeHandlers.DeleteExpense)
+
+		// Approval of expenses above the tenant's threshold
+		expenses.GET("/pending-summary", financeHandlers.GetPendingExpenseSummary)
+		expenses.POST("/:id/approve", middleware.PermissionMiddleware(permissionService, permissions.ExpensesApprove), middleware.ApproverMiddleware(settingsService, settings.ApprovalExpenses), financeHandlers.ApproveExpense)
+		expenses.POST("/:id/reject", middleware.PermissionMiddleware(permissionService, permissions.ExpensesApprove), middleware.ApproverMiddleware(settingsService, settings.ApprovalExpenses), financeHandlers.RejectExpense)
+
+		// Receipts and bills kept with an expense
+		expenses.POST("/:id/attachments", financeHandlers.UploadExpenseAttachment)
+		expenses.GET("/:id/attachments", financeHandlers.GetExpenseAttachments)
+		expenses.GET("/:id/attachments/:attachment_id", financeHandlers.DownloadExpenseAttachment)
+
+		// Recurring expenses (rent, salaries, licences) raised automatically on a schedule
+		expenses.GET("/recurring", financeHandlers.GetRecurringExpenses)
+		expenses.POST("/recurring", middleware.RoleMiddleware("manager", "admin"), financeHandlers.CreateRecurringExpense)
+		expenses.POST("/recurring/:id/disable", middleware.RoleMiddleware("manager", "admin"), financeHandlers.DisableRecurringExpense)
 	}
 
 	// Expense Category Routes
@@ -60,12 +101,18 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, fin
 		collections := assistantManager.Group("/money-collections")
 		{
 			collections.GET("", financeHandlers.GetMoneyCollections)
-			collections.POST("", financeHandlers.CreateMoneyCollection)
+			collections.POST("", middleware.PermissionMiddleware(permissionService, permissions.CollectionsRecord), middleware.IdempotencyMiddleware(cache), financeHandlers.CreateMoneyCollection)
 			collections.GET("/:id", financeHandlers.GetMoneyCollectionByID)
-			collections.POST("/:id/approve", middleware.RoleMiddleware("manager", "admin"), financeHandlers.ApproveMoneyCollection)
-			collections.POST("/:id/reject", middleware.RoleMiddleware("manager", "admin"), financeHandlers.RejectMoneyCollection)
+			collections.POST("/:id/approve", middleware.PermissionMiddleware(permissionService, permissions.CollectionsApprove), middleware.ApproverMiddleware(settingsService, settings.ApprovalCollections), financeHandlers.ApproveMoneyCollection)
+			collections.POST("/:id/reject", middleware.PermissionMiddleware(permissionService, permissions.CollectionsApprove), middleware.ApproverMiddleware(settingsService, settings.ApprovalCollections), financeHandlers.RejectMoneyCollection)
 		}
 
+		// Executive balances (approved sales not yet collected)
+		assistantManager.GET("/executives/:id/balance", financeHandlers.GetExecutiveBalance)
+
+		// Ledger statement of cash collected, deposited and still held
+		assistantManager.GET("/ledger", financeHandlers.GetAssistantManagerLedger)
+
 		// Assistant Manager Expenses
 		assistantExpenses := assistantManager.Group("/expenses")
 		{
@@ -79,10 +126,51 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, fin
 		}
 	}
 
+	// Bank deposits of collected cash, reconciled against collections
+	bankDeposits := api.Group("/bank-deposits")
+	bankDeposits.Use(middleware.RoleMiddleware("assistant_manager", "manager", "admin"))
+	{
+		bankDeposits.GET("", financeHandlers.GetBankDeposits)
+		bankDeposits.POST("", middleware.PermissionMiddleware(permissionService, permissions.DepositsCreate), financeHandlers.CreateBankDeposit)
+		bankDeposits.POST("/:id/approve", middleware.PermissionMiddleware(permissionService, permissions.DepositsApprove), financeHandlers.ApproveBankDeposit)
+		bankDeposits.POST("/reconcile", middleware.RoleMiddleware("manager", "admin"), financeHandlers.ReconcileDeposits)
+	}
+
+	// End-of-day cash drawer counts, reconciled against cash sales and expenses
+	cashDrawers := api.Group("/cash-drawers")
+	cashDrawers.Use(middleware.RoleMiddleware("assistant_manager", "manager", "admin"))
+	cashDrawers.Use(middleware.ShopScopeMiddleware(shopScopes))
+	{
+		cashDrawers.GET("", financeHandlers.GetCashDrawers)
+		cashDrawers.POST("", financeHandlers.CreateCashDrawer)
+		cashDrawers.POST("/:id/resolve", middleware.RoleMiddleware("manager", "admin"), financeHandlers.ResolveCashDrawer)
+	}
+
+	// Bank Account Routes
+	bankAccounts := api.Group("/bank-accounts")
+	{
+		bankAccounts.GET("", middleware.ReadAuditMiddleware(readAuditor, settings.ReadAuditBankAccounts), financeHandlers.GetBankAccounts)
+		bankAccounts.POST("", middleware.RoleMiddleware("manager", "admin"), financeHandlers.CreateBankAccount)
+		bankAccounts.GET("/:id", middleware.ReadAuditMiddleware(readAuditor, settings.ReadAuditBankAccounts), financeHandlers.GetBankAccountByID)
+		bankAccounts.POST("/transfer", middleware.RoleMiddleware("manager", "admin"), financeHandlers.TransferBetweenAccounts)
+	}
+
+	// Accounting Export Routes
+	exports := api.Group("/exports")
+	exports.Use(middleware.RoleMiddleware("manager", "admin"))
+	{
+		exports.GET("/accounting", middleware.ReadAuditMiddleware(readAuditor, settings.ReadAuditFinancialReports), financeHandlers.ExportAccounting)
+		exports.GET("/account-mappings", financeHandlers.GetAccountMappings)
+		exports.PUT("/account-mappings", financeHandlers.SaveAccountMappings)
+	}
+
 	// Financial Reports and Analytics
 	reports := api.Group("/reports")
+	reports.Use(middleware.ReadAuditMiddleware(readAuditor, settings.ReadAuditFinancialReports))
 	{
-		reports.GET("/expense-summary", financeHandlers.GetExpenseSummary)
+		reports.GET("/expense-summary", middleware.ShopScopeMiddleware(shopScopes), financeHandlers.GetExpenseSummary)
+		reports.GET("/break-even", middleware.RoleMiddleware("manager", "admin"), middleware.ShopScopeMiddleware(shopScopes), financeHandlers.GetBreakEven)
+		reports.GET("/collection-sheet", middleware.RoleMiddleware("assistant_manager", "manager", "admin"), middleware.ShopScopeMiddleware(shopScopes), financeHandlers.GetCollectionSheet)
 		
 		// TODO: Add more financial reports
 		reports.GET("/vendor-aging", func(c *gin.Context) {
@@ -110,7 +198,7 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, fin
 }
 
 // SetupProtectedRoutes sets up routes with gateway-style auth handling
-func SetupProtectedRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, financeHandlers *handlers.FinanceHandlers) {
+func SetupProtectedRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, financeHandlers *handlers.FinanceHandlers, settingsService *settings.Service, permissionService *permissions.Service, readAuditor *audit.ReadAuditor, auditLogger *audit.Logger, shopScopes *scope.Resolver) {
 	// Health check (no auth required)
 	router.GET("/health", financeHandlers.Health)
 
@@ -127,23 +215,36 @@ func SetupProtectedRoutes(router *gin.Engine, cfg *config.Config, cache *cache.C
 		}
 		c.Next()
 	})
+	router.Use(middleware.WriteAuditMiddleware(auditLogger, auditTables))
 
 	// Vendor Routes
-	router.GET("/vendors", financeHandlers.GetVendors)
+	router.GET("/vendors", middleware.ReadAuditMiddleware(readAuditor, settings.ReadAuditVendorBankAccounts), financeHandlers.GetVendors)
 	router.POST("/vendors", financeHandlers.CreateVendor)
-	router.GET("/vendors/:id", financeHandlers.GetVendorByID)
+	router.GET("/vendors/:id", middleware.ReadAuditMiddleware(readAuditor, settings.ReadAuditVendorBankAccounts), financeHandlers.GetVendorByID)
 	router.PUT("/vendors/:id", financeHandlers.UpdateVendor)
 	router.DELETE("/vendors/:id", financeHandlers.DeleteVendor)
 	router.POST("/vendors/:id/bank-accounts", financeHandlers.AddVendorBankAccount)
 	router.POST("/vendors/transactions", financeHandlers.CreateVendorTransaction)
 	router.GET("/vendors/:id/transactions", financeHandlers.GetVendorTransactions)
+	router.GET("/vendors/:id/statement", financeHandlers.GetVendorStatement)
+	router.POST("/vendors/:id/invoices", financeHandlers.CreateVendorInvoice)
+	router.GET("/vendors/:id/invoices", financeHandlers.GetVendorInvoices)
 
 	// Expense Routes
-	router.GET("/expenses", financeHandlers.GetExpenses)
-	router.POST("/expenses", financeHandlers.CreateExpense)
+	router.GET("/expenses", middleware.ShopScopeMiddleware(shopScopes), financeHandlers.GetExpenses)
+	router.POST("/expenses", middleware.PermissionMiddleware(permissionService, permissions.ExpensesCreate), financeHandlers.CreateExpense)
 	router.GET("/expenses/:id", financeHandlers.GetExpenseByID)
 	router.PUT("/expenses/:id", financeHandlers.UpdateExpense)
 	router.DELETE("/expenses/:id", financeHandlers.DeleteExpense)
+	router.GET("/expenses/pending-summary", financeHandlers.GetPendingExpenseSummary)
+	router.POST("/expenses/:id/approve", middleware.PermissionMiddleware(permissionService, permissions.ExpensesApprove), middleware.ApproverMiddleware(settingsService, settings.ApprovalExpenses), financeHandlers.ApproveExpense)
+	router.POST("/expenses/:id/reject", middleware.PermissionMiddleware(permissionService, permissions.ExpensesApprove), middleware.ApproverMiddleware(settingsService, settings.ApprovalExpenses), financeHandlers.RejectExpense)
+	router.POST("/expenses/:id/attachments", financeHandlers.UploadExpenseAttachment)
+	router.GET("/expenses/:id/attachments", financeHandlers.GetExpenseAttachments)
+	router.GET("/expenses/:id/attachments/:attachment_id", financeHandlers.DownloadExpenseAttachment)
+	router.GET("/expenses/recurring", financeHandlers.GetRecurringExpenses)
+	router.POST("/expenses/recurring", financeHandlers.CreateRecurringExpense)
+	router.POST("/expenses/recurring/:id/disable", financeHandlers.DisableRecurringExpense)
 
 	// Expense Category Routes
 	router.GET("/expense-categories", financeHandlers.GetExpenseCategories)
@@ -151,13 +252,39 @@ func SetupProtectedRoutes(router *gin.Engine, cfg *config.Config, cache *cache.C
 
 	// Assistant Manager Routes
 	router.GET("/assistant-manager/money-collections", financeHandlers.GetMoneyCollections)
-	router.POST("/assistant-manager/money-collections", financeHandlers.CreateMoneyCollection)
+	router.POST("/assistant-manager/money-collections", middleware.PermissionMiddleware(permissionService, permissions.CollectionsRecord), middleware.IdempotencyMiddleware(cache), financeHandlers.CreateMoneyCollection)
 	router.GET("/assistant-manager/money-collections/:id", financeHandlers.GetMoneyCollectionByID)
-	router.POST("/assistant-manager/money-collections/:id/approve", financeHandlers.ApproveMoneyCollection)
-	router.POST("/assistant-manager/money-collections/:id/reject", financeHandlers.RejectMoneyCollection)
+	router.POST("/assistant-manager/money-collections/:id/approve", middleware.PermissionMiddleware(permissionService, permissions.CollectionsApprove), middleware.ApproverMiddleware(settingsService, settings.ApprovalCollections), financeHandlers.ApproveMoneyCollection)
+	router.POST("/assistant-manager/money-collections/:id/reject", middleware.PermissionMiddleware(permissionService, permissions.CollectionsApprove), middleware.ApproverMiddleware(settingsService, settings.ApprovalCollections), financeHandlers.RejectMoneyCollection)
+	router.GET("/assistant-manager/executives/:id/balance", financeHandlers.GetExecutiveBalance)
+	router.GET("/assistant-manager/ledger", financeHandlers.GetAssistantManagerLedger)
 	router.POST("/assistant-manager/expenses", financeHandlers.CreateAssistantManagerExpense)
 	router.POST("/assistant-manager/finance", financeHandlers.CreateAssistantManagerFinance)
 
+	// Bank Deposit Routes
+	router.GET("/bank-deposits", financeHandlers.GetBankDeposits)
+	router.POST("/bank-deposits", middleware.PermissionMiddleware(permissionService, permissions.DepositsCreate), financeHandlers.CreateBankDeposit)
+	router.POST("/bank-deposits/:id/approve", middleware.PermissionMiddleware(permissionService, permissions.DepositsApprove), financeHandlers.ApproveBankDeposit)
+	router.POST("/bank-deposits/reconcile", financeHandlers.ReconcileDeposits)
+
+	// Cash Drawer Routes
+	router.GET("/cash-drawers", middleware.ShopScopeMiddleware(shopScopes), financeHandlers.GetCashDrawers)
+	router.POST("/cash-drawers", middleware.ShopScopeMiddleware(shopScopes), financeHandlers.CreateCashDrawer)
+	router.POST("/cash-drawers/:id/resolve", middleware.ShopScopeMiddleware(shopScopes), financeHandlers.ResolveCashDrawer)
+
+	// Bank Account Routes
+	router.GET("/bank-accounts", middleware.ReadAuditMiddleware(readAuditor, settings.ReadAuditBankAccounts), financeHandlers.GetBankAccounts)
+	router.POST("/bank-accounts", financeHandlers.CreateBankAccount)
+	router.GET("/bank-accounts/:id", middleware.ReadAuditMiddleware(readAuditor, settings.ReadAuditBankAccounts), financeHandlers.GetBankAccountByID)
+	router.POST("/bank-accounts/transfer", financeHandlers.TransferBetweenAccounts)
+
+	// Accounting Export Routes
+	router.GET("/exports/accounting", middleware.ReadAuditMiddleware(readAuditor, settings.ReadAuditFinancialReports), financeHandlers.ExportAccounting)
+	router.GET("/exports/account-mappings", financeHandlers.GetAccountMappings)
+	router.PUT("/exports/account-mappings", financeHandlers.SaveAccountMappings)
+
 	// Reports Routes
-	router.GET("/reports/expense-summary", financeHandlers.GetExpenseSummary)
+	router.GET("/reports/expense-summary", middleware.ReadAuditMiddleware(readAuditor, settings.ReadAuditFinancialReports), middleware.ShopScopeMiddleware(shopScopes), financeHandlers.GetExpenseSummary)
+	router.GET("/reports/break-even", middleware.ReadAuditMiddleware(readAuditor, settings.ReadAuditFinancialReports), middleware.ShopScopeMiddleware(shopScopes), financeHandlers.GetBreakEven)
+	router.GET("/reports/collection-sheet", middleware.ReadAuditMiddleware(readAuditor, settings.ReadAuditFinancialReports), middleware.ShopScopeMiddleware(shopScopes), financeHandlers.GetCollectionSheet)
 }
\ No newline at end of file
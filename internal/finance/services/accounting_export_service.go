@@ -0,0 +1,496 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/cache"
+	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Accounting export formats
+const (
+	ExportFormatTallyXML      = "tally_xml"
+	ExportFormatQuickBooksIIF = "quickbooks_iif"
+)
+
+// Account mapping types
+const (
+	MappingSales            = "sales"
+	MappingPurchases        = "purchases"
+	MappingPaymentMethod    = "payment_method"
+	MappingExpenseCategory  = "expense_category"
+	MappingVendor           = "vendor"
+	MappingVendorAdjustment = "vendor_adjustment"
+)
+
+// Default ledger names used when a tenant has not mapped a source
+var defaultAccounts = map[string]string{
+	MappingSales:            "Sales Account",
+	MappingPurchases:        "Purchase Accounts",
+	MappingExpenseCategory:  "Indirect Expenses",
+	MappingVendorAdjustment: "Vendor Adjustments",
+}
+
+var defaultPaymentAccounts = map[string]string{
+	"cash":   "Cash",
+	"card":   "Bank Account",
+	"upi":    "Bank Account",
+	"bank":   "Bank Account",
+	"credit": "Sundry Debtors",
+}
+
+const exportBatchSize = 500
+
+// AccountingExportService exports approved sales, expenses and vendor transactions
+// for external accounting software
+type AccountingExportService struct {
+	db    *database.DB
+	cache *cache.Cache
+}
+
+func NewAccountingExportService(db *database.DB, cache *cache.Cache) *AccountingExportService {
+	return &AccountingExportService{
+		db:    db,
+		cache: cache,
+	}
+}
+
+type AccountMappingRequest struct {
+	MappingType string `json:"mapping_type" binding:"required"`
+	SourceKey   string `json:"source_key"`
+	AccountName string `json:"account_name" binding:"required"`
+}
+
+type AccountMappingsRequest struct {
+	Mappings []AccountMappingRequest `json:"mappings" binding:"required,min=1,dive"`
+}
+
+type AccountMappingResponse struct {
+	ID          uuid.UUID `json:"id"`
+	MappingType string    `json:"mapping_type"`
+	SourceKey   string    `json:"source_key"`
+	AccountName string    `json:"account_name"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// voucher is a balanced double-entry transaction; positive amounts are debits
+type voucher struct {
+	Date      time.Time
+	Type      string // Sales, Payment, Purchase, Journal
+	Number    string
+	Narration string
+	Lines     []voucherLine
+}
+
+type voucherLine struct {
+	Account string
+	Amount  float64
+}
+
+// voucherWriter renders vouchers in a provider-specific format
+type voucherWriter interface {
+	begin() error
+	write(v *voucher) error
+	end() error
+}
+
+// GetAccountMappings returns the tenant's account mappings
+func (s *AccountingExportService) GetAccountMappings(ctx context.Context, tenantID uuid.UUID) ([]*AccountMappingResponse, error) {
+	var mappings []models.AccountMapping
+	if err := s.db.DB.Where("tenant_id = ?", tenantID).Order("mapping_type, source_key").Find(&mappings).Error; err != nil {
+		return nil, fmt.Errorf("failed to get account mappings: %w", err)
+	}
+
+	responses := make([]*AccountMappingResponse, len(mappings))
+	for i, mapping := range mappings {
+		responses[i] = &AccountMappingResponse{
+			ID:          mapping.ID,
+			MappingType: mapping.MappingType,
+			SourceKey:   mapping.SourceKey,
+			AccountName: mapping.AccountName,
+			UpdatedAt:   mapping.UpdatedAt,
+		}
+	}
+
+	return responses, nil
+}
+
+// SaveAccountMappings creates or replaces account mappings
+func (s *AccountingExportService) SaveAccountMappings(ctx context.Context, req AccountMappingsRequest, tenantID uuid.UUID) ([]*AccountMappingResponse, error) {
+	for _, mapping := range req.Mappings {
+		switch mapping.MappingType {
+		case MappingSales, MappingPurchases, MappingVendorAdjustment:
+			if mapping.SourceKey != "" {
+				return nil, fmt.Errorf("mapping type %s does not take a source key", mapping.MappingType)
+			}
+		case MappingPaymentMethod, MappingExpenseCategory, MappingVendor:
+			if mapping.SourceKey == "" {
+				return nil, fmt.Errorf("mapping type %s requires a source key", mapping.MappingType)
+			}
+		default:
+			return nil, fmt.Errorf("unknown mapping type: %s", mapping.MappingType)
+		}
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, mapping := range req.Mappings {
+			record := models.AccountMapping{
+				TenantModel: models.TenantModel{TenantID: tenantID},
+				MappingType: mapping.MappingType,
+				SourceKey:   strings.TrimSpace(mapping.SourceKey),
+				AccountName: strings.TrimSpace(mapping.AccountName),
+			}
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "mapping_type"}, {Name: "source_key"}},
+				DoUpdates: clause.AssignmentColumns([]string{"account_name", "updated_at"}),
+			}).Create(&record).Error
+			if err != nil {
+				return fmt.Errorf("failed to save account mapping: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetAccountMappings(ctx, tenantID)
+}
+
+// ValidExportFormat reports whether the export format is supported
+func ValidExportFormat(format string) bool {
+	return format == ExportFormatTallyXML || format == ExportFormatQuickBooksIIF
+}
+
+// ExportAccounting streams vouchers for approved daily sales, expenses and vendor
+// transactions dated within [start, end) to w in the requested format
+func (s *AccountingExportService) ExportAccounting(ctx context.Context, w io.Writer, tenantID uuid.UUID, format string, start, end time.Time) error {
+	if !ValidExportFormat(format) {
+		return errors.New("format must be tally_xml or quickbooks_iif")
+	}
+
+	accounts, err := s.loadAccountResolver(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	buf := bufio.NewWriter(w)
+	var out voucherWriter
+	if format == ExportFormatTallyXML {
+		out = &tallyWriter{w: buf}
+	} else {
+		out = &iifWriter{w: buf}
+	}
+
+	if err := out.begin(); err != nil {
+		return err
+	}
+	if err := s.exportDailySales(ctx, out, accounts, tenantID, start, end); err != nil {
+		return err
+	}
+	if err := s.exportExpenses(ctx, out, accounts, tenantID, start, end); err != nil {
+		return err
+	}
+	if err := s.exportVendorTransactions(ctx, out, accounts, tenantID, start, end); err != nil {
+		return err
+	}
+	if err := out.end(); err != nil {
+		return err
+	}
+
+	return buf.Flush()
+}
+
+func (s *AccountingExportService) exportDailySales(ctx context.Context, out voucherWriter, accounts *accountResolver, tenantID uuid.UUID, start, end time.Time) error {
+	var records []models.DailySalesRecord
+	var writeErr error
+	result := s.db.WithContext(ctx).Preload("Shop").
+		Where("tenant_id = ? AND status = ? AND record_date >= ? AND record_date < ?", tenantID, models.StatusApproved, start, end).
+		Order("record_date, id").
+		FindInBatches(&records, exportBatchSize, func(tx *gorm.DB, batch int) error {
+			for _, record := range records {
+				shopName := ""
+				if record.Shop != nil {
+					shopName = record.Shop.Name
+				}
+
+				v := &voucher{
+					Date:      record.RecordDate,
+					Type:      "Sales",
+					Number:    "DS-" + strings.ToUpper(record.ID.String()[:8]),
+					Narration: strings.TrimSpace(fmt.Sprintf("Daily sales %s %s", shopName, record.RecordDate.Format("2006-01-02"))),
+				}
+				v.addLine(accounts.payment("cash"), record.TotalCashAmount.Float64())
+				v.addLine(accounts.payment("card"), record.TotalCardAmount.Float64())
+				v.addLine(accounts.payment("upi"), record.TotalUpiAmount.Float64())
+				v.addLine(accounts.payment("credit"), record.TotalCreditAmount.Float64())
+				v.addLine(accounts.get(MappingSales, ""), -record.TotalSalesAmount.Float64())
+
+				if writeErr = out.write(v); writeErr != nil {
+					return writeErr
+				}
+			}
+			return nil
+		})
+	if writeErr != nil {
+		return writeErr
+	}
+	if result.Error != nil {
+		return fmt.Errorf("failed to export daily sales: %w", result.Error)
+	}
+	return nil
+}
+
+func (s *AccountingExportService) exportExpenses(ctx context.Context, out voucherWriter, accounts *accountResolver, tenantID uuid.UUID, start, end time.Time) error {
+	var expenses []models.Expense
+	var writeErr error
+	result := s.db.WithContext(ctx).Preload("Category").
+		Where("tenant_id = ? AND status != ? AND expense_date >= ? AND expense_date < ?", tenantID, models.StatusRejected, start, end).
+		Order("expense_date, id").
+		FindInBatches(&expenses, exportBatchSize, func(tx *gorm.DB, batch int) error {
+			for _, expense := range expenses {
+				expenseAccount := accounts.get(MappingExpenseCategory, "")
+				if expense.Category != nil {
+					expenseAccount = accounts.getOr(MappingExpenseCategory, expense.Category.ID.String(), expense.Category.Name)
+				}
+
+				number := expense.ReceiptNo
+				if number == "" {
+					number = "EXP-" + strings.ToUpper(expense.ID.String()[:8])
+				}
+
+				v := &voucher{
+					Date:      expense.ExpenseDate,
+					Type:      "Payment",
+					Number:    number,
+					Narration: expense.Description,
+				}
+				v.addLine(expenseAccount, expense.Amount)
+				v.addLine(accounts.payment(expense.PaymentMethod), -expense.Amount)
+
+				if writeErr = out.write(v); writeErr != nil {
+					return writeErr
+				}
+			}
+			return nil
+		})
+	if writeErr != nil {
+		return writeErr
+	}
+	if result.Error != nil {
+		return fmt.Errorf("failed to export expenses: %w", result.Error)
+	}
+	return nil
+}
+
+func (s *AccountingExportService) exportVendorTransactions(ctx context.Context, out voucherWriter, accounts *accountResolver, tenantID uuid.UUID, start, end time.Time) error {
+	var transactions []models.VendorTransaction
+	var writeErr error
+	result := s.db.WithContext(ctx).Preload("Vendor").
+		Where("tenant_id = ? AND transaction_date >= ? AND transaction_date < ?", tenantID, start, end).
+		Order("transaction_date, id").
+		FindInBatches(&transactions, exportBatchSize, func(tx *gorm.DB, batch int) error {
+			for _, txn := range transactions {
+				vendorName := "Sundry Creditors"
+				if txn.Vendor != nil {
+					vendorName = txn.Vendor.Name
+				}
+				vendorAccount := accounts.getOr(MappingVendor, txn.VendorID.String(), vendorName)
+
+				number := txn.ReferenceNo
+				if number == "" {
+					number = txn.Reference
+				}
+				if number == "" {
+					number = "VT-" + strings.ToUpper(txn.ID.String()[:8])
+				}
+
+				v := &voucher{
+					Date:      txn.TransactionDate,
+					Number:    number,
+					Narration: txn.Description,
+				}
+				switch txn.TransactionType {
+				case "purchase":
+					v.Type = "Purchase"
+					v.addLine(accounts.get(MappingPurchases, ""), txn.Amount)
+					v.addLine(vendorAccount, -txn.Amount)
+				case "payment":
+					v.Type = "Payment"
+					v.addLine(vendorAccount, txn.Amount)
+					v.addLine(accounts.payment(txn.PaymentMethod), -txn.Amount)
+				default:
+					// Adjustments reduce what we owe the vendor when positive
+					v.Type = "Journal"
+					v.addLine(vendorAccount, txn.Amount)
+					v.addLine(accounts.get(MappingVendorAdjustment, ""), -txn.Amount)
+				}
+
+				if writeErr = out.write(v); writeErr != nil {
+					return writeErr
+				}
+			}
+			return nil
+		})
+	if writeErr != nil {
+		return writeErr
+	}
+	if result.Error != nil {
+		return fmt.Errorf("failed to export vendor transactions: %w", result.Error)
+	}
+	return nil
+}
+
+func (v *voucher) addLine(account string, amount float64) {
+	amount = utils.RoundToTwoDecimals(amount)
+	if amount == 0 {
+		return
+	}
+	v.Lines = append(v.Lines, voucherLine{Account: account, Amount: amount})
+}
+
+// accountResolver looks up ledger names from the tenant's mappings with defaults
+type accountResolver struct {
+	mappings map[string]string
+}
+
+func (s *AccountingExportService) loadAccountResolver(ctx context.Context, tenantID uuid.UUID) (*accountResolver, error) {
+	var mappings []models.AccountMapping
+	if err := s.db.WithContext(ctx).Where("tenant_id = ?", tenantID).Find(&mappings).Error; err != nil {
+		return nil, fmt.Errorf("failed to get account mappings: %w", err)
+	}
+
+	resolver := &accountResolver{mappings: make(map[string]string, len(mappings))}
+	for _, mapping := range mappings {
+		resolver.mappings[mapping.MappingType+":"+mapping.SourceKey] = mapping.AccountName
+	}
+	return resolver, nil
+}
+
+func (r *accountResolver) get(mappingType, sourceKey string) string {
+	return r.getOr(mappingType, sourceKey, defaultAccounts[mappingType])
+}
+
+func (r *accountResolver) getOr(mappingType, sourceKey, fallback string) string {
+	if name, ok := r.mappings[mappingType+":"+sourceKey]; ok {
+		return name
+	}
+	return fallback
+}
+
+func (r *accountResolver) payment(method string) string {
+	method = strings.ToLower(strings.TrimSpace(method))
+	fallback, ok := defaultPaymentAccounts[method]
+	if !ok {
+		fallback = defaultPaymentAccounts["bank"]
+	}
+	return r.getOr(MappingPaymentMethod, method, fallback)
+}
+
+// tallyWriter renders vouchers as a Tally ERP/Prime XML import envelope.
+// Tally records debits as negative amounts with ISDEEMEDPOSITIVE set.
+type tallyWriter struct {
+	w *bufio.Writer
+}
+
+func (t *tallyWriter) begin() error {
+	_, err := t.w.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<ENVELOPE>
+ <HEADER><TALLYREQUEST>Import Data</TALLYREQUEST></HEADER>
+ <BODY>
+  <IMPORTDATA>
+   <REQUESTDESC><REPORTNAME>Vouchers</REPORTNAME></REQUESTDESC>
+   <REQUESTDATA>
+`)
+	return err
+}
+
+func (t *tallyWriter) write(v *voucher) error {
+	fmt.Fprintf(t.w, "    <TALLYMESSAGE xmlns:UDF=\"TallyUDF\">\n")
+	fmt.Fprintf(t.w, "     <VOUCHER VCHTYPE=\"%s\" ACTION=\"Create\">\n", xmlEscape(v.Type))
+	fmt.Fprintf(t.w, "      <DATE>%s</DATE>\n", v.Date.Format("20060102"))
+	fmt.Fprintf(t.w, "      <VOUCHERTYPENAME>%s</VOUCHERTYPENAME>\n", xmlEscape(v.Type))
+	fmt.Fprintf(t.w, "      <VOUCHERNUMBER>%s</VOUCHERNUMBER>\n", xmlEscape(v.Number))
+	fmt.Fprintf(t.w, "      <NARRATION>%s</NARRATION>\n", xmlEscape(v.Narration))
+	for _, line := range v.Lines {
+		deemedPositive := "No"
+		if line.Amount > 0 {
+			deemedPositive = "Yes"
+		}
+		fmt.Fprintf(t.w, "      <ALLLEDGERENTRIES.LIST>\n")
+		fmt.Fprintf(t.w, "       <LEDGERNAME>%s</LEDGERNAME>\n", xmlEscape(line.Account))
+		fmt.Fprintf(t.w, "       <ISDEEMEDPOSITIVE>%s</ISDEEMEDPOSITIVE>\n", deemedPositive)
+		fmt.Fprintf(t.w, "       <AMOUNT>%.2f</AMOUNT>\n", -line.Amount)
+		fmt.Fprintf(t.w, "      </ALLLEDGERENTRIES.LIST>\n")
+	}
+	fmt.Fprintf(t.w, "     </VOUCHER>\n")
+	_, err := fmt.Fprintf(t.w, "    </TALLYMESSAGE>\n")
+	return err
+}
+
+func (t *tallyWriter) end() error {
+	_, err := t.w.WriteString(`   </REQUESTDATA>
+  </IMPORTDATA>
+ </BODY>
+</ENVELOPE>
+`)
+	return err
+}
+
+func xmlEscape(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
+
+// iifWriter renders vouchers as QuickBooks Desktop IIF general journal entries.
+// IIF records debits as positive and credits as negative amounts.
+type iifWriter struct {
+	w *bufio.Writer
+}
+
+func (q *iifWriter) begin() error {
+	_, err := q.w.WriteString("!TRNS\tTRNSTYPE\tDATE\tACCNT\tAMOUNT\tDOCNUM\tMEMO\r\n" +
+		"!SPL\tTRNSTYPE\tDATE\tACCNT\tAMOUNT\tDOCNUM\tMEMO\r\n" +
+		"!ENDTRNS\r\n")
+	return err
+}
+
+func (q *iifWriter) write(v *voucher) error {
+	if len(v.Lines) == 0 {
+		return nil
+	}
+
+	date := v.Date.Format("01/02/2006")
+	for i, line := range v.Lines {
+		row := "SPL"
+		if i == 0 {
+			row = "TRNS"
+		}
+		fmt.Fprintf(q.w, "%s\tGENERAL JOURNAL\t%s\t%s\t%.2f\t%s\t%s\r\n",
+			row, date, iifField(line.Account), line.Amount, iifField(v.Number), iifField(v.Narration))
+	}
+	_, err := q.w.WriteString("ENDTRNS\r\n")
+	return err
+}
+
+func (q *iifWriter) end() error {
+	return nil
+}
+
+// iifField strips characters that would break the tab-delimited IIF layout
+func iifField(value string) string {
+	return strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(value)
+}
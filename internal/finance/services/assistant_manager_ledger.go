@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Assistant manager ledger transaction types. The balance is the cash an assistant
+// manager holds: approved collections add to it and bank deposits take from it.
+const (
+	LedgerCollection = "collection"
+	LedgerDeposit    = "deposit"
+	LedgerAdjustment = "adjustment"
+	LedgerOverdue    = "overdue"
+)
+
+// LedgerEntryResponse represents one assistant manager ledger entry
+type LedgerEntryResponse struct {
+	ID                uuid.UUID  `json:"id"`
+	TransactionDate   time.Time  `json:"transaction_date"`
+	TransactionType   string     `json:"transaction_type"`
+	Amount            float64    `json:"amount"`
+	Description       string     `json:"description"`
+	Reference         string     `json:"reference"`
+	MoneyCollectionID *uuid.UUID `json:"money_collection_id,omitempty"`
+	PreviousBalance   float64    `json:"previous_balance"`
+	NewBalance        float64    `json:"new_balance"`
+	CreatedByID       uuid.UUID  `json:"created_by_id"`
+}
+
+// LedgerSummary totals an assistant manager's ledger over a statement period
+type LedgerSummary struct {
+	TotalCollected   float64 `json:"total_collected"`
+	TotalDeposited   float64 `json:"total_deposited"`
+	TotalAdjustments float64 `json:"total_adjustments"`
+	Outstanding      float64 `json:"outstanding"` // cash still held at the end of the period
+}
+
+// LedgerStatement is an assistant manager's ledger over a date range
+type LedgerStatement struct {
+	AssistantManagerID   uuid.UUID             `json:"assistant_manager_id"`
+	AssistantManagerName string                `json:"assistant_manager_name"`
+	StartDate            time.Time             `json:"start_date"`
+	EndDate              time.Time             `json:"end_date"`
+	OpeningBalance       float64               `json:"opening_balance"`
+	ClosingBalance       float64               `json:"closing_balance"`
+	Summary              LedgerSummary         `json:"summary"`
+	Entries              []LedgerEntryResponse `json:"entries"`
+	Total                int64                 `json:"total"`
+	Limit                int                   `json:"limit"`
+	Offset               int                   `json:"offset"`
+}
+
+// GetLedger returns an assistant manager's ledger entries between startDate and
+// endDate, oldest first, with the balances either side of the range. The summary
+// covers the whole range, not just the returned page.
+func (s *AssistantManagerService) GetLedger(ctx context.Context, tenantID, assistantManagerID uuid.UUID, startDate, endDate time.Time, limit, offset int) (*LedgerStatement, error) {
+	var assistantManager models.User
+	if err := s.db.DB.WithContext(ctx).Where("id = ? AND tenant_id = ?", assistantManagerID, tenantID).First(&assistantManager).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("assistant manager not found")
+		}
+		return nil, fmt.Errorf("failed to get assistant manager: %w", err)
+	}
+
+	db := s.db.DB.WithContext(ctx)
+	ledger := func() *gorm.DB {
+		return db.Model(&models.AssistantManagerLedger{}).
+			Where("tenant_id = ? AND assistant_manager_id = ?", tenantID, assistantManagerID)
+	}
+
+	openingBalance, err := balanceAt(ledger().Where("transaction_date < ?", startDate))
+	if err != nil {
+		return nil, err
+	}
+	closingBalance := openingBalance
+	if closing, err := balanceAt(ledger().Where("transaction_date <= ?", endDate)); err != nil {
+		return nil, err
+	} else if closing != nil {
+		closingBalance = closing
+	}
+
+	inRange := func() *gorm.DB {
+		return ledger().Where("transaction_date >= ? AND transaction_date <= ?", startDate, endDate)
+	}
+
+	var total int64
+	if err := inRange().Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count ledger entries: %w", err)
+	}
+
+	var totals []struct {
+		TransactionType string
+		Amount          float64
+	}
+	if err := inRange().
+		Select("transaction_type, COALESCE(SUM(amount), 0) as amount").
+		Group("transaction_type").
+		Scan(&totals).Error; err != nil {
+		return nil, fmt.Errorf("failed to total ledger entries: %w", err)
+	}
+
+	var entries []models.AssistantManagerLedger
+	if err := inRange().
+		Order("transaction_date ASC, created_at ASC").
+		Limit(limit).Offset(offset).
+		Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get ledger entries: %w", err)
+	}
+
+	statement := &LedgerStatement{
+		AssistantManagerID:   assistantManagerID,
+		AssistantManagerName: assistantManager.FullName(),
+		StartDate:            startDate,
+		EndDate:              endDate,
+		Entries:              make([]LedgerEntryResponse, len(entries)),
+		Total:                total,
+		Limit:                limit,
+		Offset:               offset,
+	}
+	if openingBalance != nil {
+		statement.OpeningBalance = *openingBalance
+	}
+	if closingBalance != nil {
+		statement.ClosingBalance = *closingBalance
+	}
+
+	for _, t := range totals {
+		switch t.TransactionType {
+		case LedgerCollection:
+			statement.Summary.TotalCollected = s.round(t.Amount)
+		case LedgerDeposit:
+			statement.Summary.TotalDeposited = s.round(t.Amount)
+		case LedgerAdjustment:
+			statement.Summary.TotalAdjustments = s.round(t.Amount)
+		}
+	}
+	statement.Summary.Outstanding = statement.ClosingBalance
+
+	for i, entry := range entries {
+		statement.Entries[i] = LedgerEntryResponse{
+			ID:                entry.ID,
+			TransactionDate:   entry.TransactionDate,
+			TransactionType:   entry.TransactionType,
+			Amount:            entry.Amount,
+			Description:       entry.Description,
+			Reference:         entry.Reference,
+			MoneyCollectionID: entry.MoneyCollectionID,
+			PreviousBalance:   entry.PreviousBalance,
+			NewBalance:        entry.NewBalance,
+			CreatedByID:       entry.CreatedByID,
+		}
+	}
+
+	return statement, nil
+}
+
+// balanceAt returns the balance after the latest entry matched by query, or nil when
+// there is none
+func balanceAt(query *gorm.DB) (*float64, error) {
+	var last models.AssistantManagerLedger
+	err := query.Order("transaction_date DESC, created_at DESC").First(&last).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get ledger balance: %w", err)
+	}
+	return &last.NewBalance, nil
+}
+
+// appendLedgerEntry writes a ledger entry on top of the assistant manager's current
+// balance. The assistant manager row is locked so concurrent entries chain correctly.
+func (s *AssistantManagerService) appendLedgerEntry(tx *gorm.DB, entry *models.AssistantManagerLedger) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND tenant_id = ?", entry.AssistantManagerID, entry.TenantID).
+		First(&models.User{}).Error; err != nil {
+		return fmt.Errorf("failed to lock assistant manager: %w", err)
+	}
+
+	balance, err := s.ledgerBalance(tx, entry.TenantID, entry.AssistantManagerID)
+	if err != nil {
+		return err
+	}
+
+	entry.PreviousBalance = balance
+	switch entry.TransactionType {
+	case LedgerCollection, LedgerAdjustment:
+		entry.NewBalance = s.round(balance + entry.Amount)
+	case LedgerDeposit:
+		entry.NewBalance = s.round(balance - entry.Amount)
+	default:
+		entry.NewBalance = balance
+	}
+
+	if err := tx.Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to record ledger entry: %w", err)
+	}
+	return nil
+}
+
+// recordCollectionLedgerEntry credits an approved collection to the assistant
+// manager who took it
+func (s *AssistantManagerService) recordCollectionLedgerEntry(tx *gorm.DB, collection *models.AssistantManagerMoneyCollection, approvedAt time.Time, approvedBy uuid.UUID) error {
+	if collection.AssistantManagerID == uuid.Nil {
+		return nil
+	}
+	collectionID := collection.ID
+	return s.appendLedgerEntry(tx, &models.AssistantManagerLedger{
+		TenantModel:        models.TenantModel{TenantID: collection.TenantID},
+		AssistantManagerID: collection.AssistantManagerID,
+		MoneyCollectionID:  &collectionID,
+		TransactionDate:    approvedAt,
+		TransactionType:    LedgerCollection,
+		Amount:             collection.Amount,
+		Description:        fmt.Sprintf("Collection from executive %s approved", collection.ExecutiveID),
+		Reference:          collection.ID.String(),
+		CreatedByID:        approvedBy,
+	})
+}
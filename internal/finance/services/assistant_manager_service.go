@@ -2,29 +2,46 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/approval"
 	"github.com/liquorpro/go-backend/pkg/shared/cache"
+	"github.com/liquorpro/go-backend/pkg/shared/config"
 	"github.com/liquorpro/go-backend/pkg/shared/database"
 	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/notification"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AssistantManagerService struct {
-	db    *database.DB
-	cache *cache.Cache
+	db           *database.DB
+	cache        *cache.Cache
+	finance      config.FinanceConfig
+	autoApprover *approval.AutoApprover
+	settings     *settings.Service
+	notifier     *notification.Service
 }
 
-func NewAssistantManagerService(db *database.DB, cache *cache.Cache) *AssistantManagerService {
+func NewAssistantManagerService(db *database.DB, cache *cache.Cache, financeConfig config.FinanceConfig, autoApprover *approval.AutoApprover, settingsService *settings.Service, notifier *notification.Service) *AssistantManagerService {
 	return &AssistantManagerService{
-		db:    db,
-		cache: cache,
+		db:           db,
+		cache:        cache,
+		finance:      financeConfig,
+		autoApprover: autoApprover,
+		settings:     settingsService,
+		notifier:     notifier,
 	}
 }
 
-// Critical Business Logic: 15-minute deadline for collection approval
+// Critical Business Logic: default 15-minute deadline for collection approval. Tenants
+// can change it with the approval_deadline_minutes setting.
 const APPROVAL_DEADLINE_MINUTES = 15
 
 type MoneyCollectionRequest struct {
@@ -32,6 +49,33 @@ type MoneyCollectionRequest struct {
 	ShopID      uuid.UUID `json:"shop_id" binding:"required"`
 	Amount      float64   `json:"amount" binding:"required,gt=0"`
 	Notes       string    `json:"notes"`
+
+	// Accept a collection above the executive's outstanding balance; managers and admins only
+	OverrideBalance bool   `json:"override_balance"`
+	OverrideReason  string `json:"override_reason"`
+}
+
+// ExecutiveBalance is what an executive has sold but not yet handed over. Pending
+// collections are held against the balance so parallel submissions cannot both claim it.
+type ExecutiveBalance struct {
+	ExecutiveID         uuid.UUID `json:"executive_id"`
+	ExecutiveName       string    `json:"executive_name"`
+	ApprovedSales       float64   `json:"approved_sales"`
+	ApprovedCollections float64   `json:"approved_collections"`
+	PendingCollections  float64   `json:"pending_collections"`
+	Balance             float64   `json:"balance"`   // approved sales minus approved collections
+	Available           float64   `json:"available"` // balance minus pending collections
+}
+
+// CollectionExceedsBalanceError is returned when a collection is larger than the
+// executive's available balance and the tenant policy blocks it
+type CollectionExceedsBalanceError struct {
+	Amount  float64
+	Balance *ExecutiveBalance
+}
+
+func (e *CollectionExceedsBalanceError) Error() string {
+	return fmt.Sprintf("collection amount %.2f exceeds executive's available balance %.2f", e.Amount, e.Balance.Available)
 }
 
 type MoneyCollectionResponse struct {
@@ -47,9 +91,15 @@ type MoneyCollectionResponse struct {
 	ApprovedAt      *time.Time `json:"approved_at"`
 	ApprovedBy      *uuid.UUID `json:"approved_by"`
 	ApproverName    string     `json:"approver_name,omitempty"`
+	AutoApproved    bool       `json:"auto_approved"`
+	Version         int        `json:"version"`
 	DeadlineAt      time.Time  `json:"deadline_at"`
 	IsOverdue       bool       `json:"is_overdue"`
 	MinutesRemaining int       `json:"minutes_remaining"`
+	SecondsRemaining int       `json:"seconds_remaining"`
+	BalanceOverridden bool     `json:"balance_overridden"`
+	OverrideReason  string     `json:"override_reason,omitempty"`
+	Warning         string     `json:"warning,omitempty"`
 	CreatedBy       uuid.UUID  `json:"created_by"`
 	CreatedAt       time.Time  `json:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at"`
@@ -96,11 +146,37 @@ type AssistantManagerFinanceRequest struct {
 	UpiCollected          float64   `json:"upi_collected" binding:"required,gte=0"`
 	CreditCollected       float64   `json:"credit_collected" binding:"required,gte=0"`
 	TotalExpenses         float64   `json:"total_expenses" binding:"required,gte=0"`
-	NetAmountToDeposit    float64   `json:"net_amount_to_deposit"`
+	NetAmountToDeposit    *float64  `json:"net_amount_to_deposit"` // optional; verified against the server computation
 	Notes                 string    `json:"notes"`
 	FinanceDate           time.Time `json:"finance_date" binding:"required"`
 }
 
+// NetAmountBreakdown shows how the net amount to deposit was derived
+type NetAmountBreakdown struct {
+	CashCollected   float64  `json:"cash_collected"`
+	CardCollected   float64  `json:"card_collected"`
+	UpiCollected    float64  `json:"upi_collected"`
+	CreditCollected float64  `json:"credit_collected"`
+	TotalCollected  float64  `json:"total_collected"`
+	TotalExpenses   float64  `json:"total_expenses"`
+	NetAmount       float64  `json:"net_amount"`
+	ClientNetAmount *float64 `json:"client_net_amount,omitempty"`
+	Difference      float64  `json:"difference"`
+	Tolerance       float64  `json:"tolerance"`
+	RoundingPlaces  int      `json:"rounding_places"`
+	RoundingMode    string   `json:"rounding_mode"`
+}
+
+// NetAmountMismatchError is returned when the client-provided net diverges beyond tolerance
+type NetAmountMismatchError struct {
+	Breakdown *NetAmountBreakdown
+}
+
+func (e *NetAmountMismatchError) Error() string {
+	return fmt.Sprintf("net amount to deposit %.2f does not match computed net amount %.2f (tolerance %.2f)",
+		*e.Breakdown.ClientNetAmount, e.Breakdown.NetAmount, e.Breakdown.Tolerance)
+}
+
 type AssistantManagerFinanceResponse struct {
 	ID                    uuid.UUID  `json:"id"`
 	ExecutiveID           uuid.UUID  `json:"executive_id"`
@@ -112,8 +188,11 @@ type AssistantManagerFinanceResponse struct {
 	CardCollected         float64    `json:"card_collected"`
 	UpiCollected          float64    `json:"upi_collected"`
 	CreditCollected       float64    `json:"credit_collected"`
+	TotalCollected        float64    `json:"total_collected"`
 	TotalExpenses         float64    `json:"total_expenses"`
+	NetAmount             float64    `json:"net_amount"`
 	NetAmountToDeposit    float64    `json:"net_amount_to_deposit"`
+	Breakdown             *NetAmountBreakdown `json:"breakdown,omitempty"`
 	Notes                 string     `json:"notes"`
 	FinanceDate           time.Time  `json:"finance_date"`
 	Status                string     `json:"status"`
@@ -145,33 +224,155 @@ func (s *AssistantManagerService) CreateMoneyCollection(ctx context.Context, req
 		return nil, fmt.Errorf("failed to validate shop: %w", err)
 	}
 
+	if req.OverrideBalance && req.OverrideReason == "" {
+		return nil, fmt.Errorf("override reason is required when overriding the balance check")
+	}
+
 	now := time.Now()
-	deadlineAt := now.Add(APPROVAL_DEADLINE_MINUTES * time.Minute)
+	deadlineAt := now.Add(s.approvalDeadline(ctx, tenantID))
 
 	collection := models.AssistantManagerMoneyCollection{
 		TenantModel: models.TenantModel{
 			BaseModel: models.BaseModel{ID: uuid.New()},
 			TenantID:  tenantID,
 		},
-		ExecutiveID: req.ExecutiveID,
-		ShopID:      req.ShopID,
-		Amount:      req.Amount,
-		Status:      "pending",
-		Notes:       req.Notes,
-		CollectedAt: now,
-		DeadlineAt:  deadlineAt,
-		CreatedBy:   userID,
+		ExecutiveID:        req.ExecutiveID,
+		AssistantManagerID: userID,
+		ShopID:             req.ShopID,
+		Amount:             req.Amount,
+		Status:             "pending",
+		Notes:              req.Notes,
+		CollectedAt:        now,
+		DeadlineAt:         deadlineAt,
+		CreatedBy:          userID,
 	}
 
-	if err := s.db.DB.Create(&collection).Error; err != nil {
-		return nil, fmt.Errorf("failed to create money collection: %w", err)
+	// Trusted executives skip manual approval below their limit
+	if s.autoApprover.Eligible(ctx, tenantID, req.ExecutiveID, req.Amount) {
+		collection.Status = "approved"
+		collection.ApprovedAt = &now
+		collection.AutoApproved = true
+	}
+
+	policy := s.settings.GetString(ctx, tenantID, settings.KeyCollectionBalancePolicy)
+
+	var warning string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the executive so concurrent collections see each other's pending amounts
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND tenant_id = ?", req.ExecutiveID, tenantID).
+			First(&models.User{}).Error; err != nil {
+			return fmt.Errorf("failed to lock executive: %w", err)
+		}
+
+		balance, err := s.executiveBalance(tx, tenantID, req.ExecutiveID)
+		if err != nil {
+			return err
+		}
+
+		if policy != settings.CollectionBalanceAllow && req.Amount > balance.Available {
+			switch {
+			case req.OverrideBalance:
+				collection.BalanceOverridden = true
+				collection.OverrideReason = req.OverrideReason
+			case policy == settings.CollectionBalanceWarn:
+				warning = fmt.Sprintf("collection amount %.2f exceeds executive's available balance %.2f", req.Amount, balance.Available)
+			default:
+				balance.ExecutiveName = executive.FullName()
+				return &CollectionExceedsBalanceError{Amount: req.Amount, Balance: balance}
+			}
+		}
+
+		if err := tx.Create(&collection).Error; err != nil {
+			return fmt.Errorf("failed to create money collection: %w", err)
+		}
+		if collection.AutoApproved {
+			if err := s.recordCollectionLedgerEntry(tx, &collection, now, userID); err != nil {
+				return err
+			}
+			return approval.RecordAutoApproval(tx, tenantID, req.ExecutiveID, approval.EntityMoneyCollection, collection.ID, collection.Amount)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Clear cache
 	cacheKey := fmt.Sprintf("collections:tenant:%s", tenantID.String())
 	s.cache.Delete(ctx, cacheKey)
+	s.cache.InvalidateDashboard(ctx, tenantID.String())
 
-	return s.buildMoneyCollectionResponse(collection, executive.FullName(), shop.Name, ""), nil
+	response := s.buildMoneyCollectionResponse(collection, executive.FullName(), shop.Name, "")
+	response.Warning = warning
+	return response, nil
+}
+
+// GetExecutiveBalance returns an executive's uncollected sales balance
+func (s *AssistantManagerService) GetExecutiveBalance(ctx context.Context, tenantID, executiveID uuid.UUID) (*ExecutiveBalance, error) {
+	var executive models.User
+	if err := s.db.DB.Where("id = ? AND tenant_id = ? AND role = ?", executiveID, tenantID, "executive").First(&executive).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("executive not found")
+		}
+		return nil, fmt.Errorf("failed to validate executive: %w", err)
+	}
+
+	balance, err := s.executiveBalance(s.db.DB.WithContext(ctx), tenantID, executiveID)
+	if err != nil {
+		return nil, err
+	}
+	balance.ExecutiveName = executive.FullName()
+
+	return balance, nil
+}
+
+// executiveBalance sums the executive's approved sales and daily sales records
+// against their approved and pending money collections
+func (s *AssistantManagerService) executiveBalance(tx *gorm.DB, tenantID, executiveID uuid.UUID) (*ExecutiveBalance, error) {
+	var sales float64
+	if err := tx.Model(&models.Sale{}).
+		Where("tenant_id = ? AND created_by_id = ? AND status = ?", tenantID, executiveID, models.StatusApproved).
+		Select("COALESCE(SUM(total_amount), 0)").
+		Scan(&sales).Error; err != nil {
+		return nil, fmt.Errorf("failed to get executive sales: %w", err)
+	}
+
+	var dailySales float64
+	if err := tx.Model(&models.DailySalesRecord{}).
+		Where("tenant_id = ? AND created_by_id = ? AND status = ?", tenantID, executiveID, models.StatusApproved).
+		Select("COALESCE(SUM(total_sales_amount), 0)").
+		Scan(&dailySales).Error; err != nil {
+		return nil, fmt.Errorf("failed to get executive daily sales: %w", err)
+	}
+
+	var collections []struct {
+		Status string
+		Amount float64
+	}
+	if err := tx.Model(&models.AssistantManagerMoneyCollection{}).
+		Where("tenant_id = ? AND executive_id = ? AND status IN ?", tenantID, executiveID, []string{"approved", "pending"}).
+		Select("status, COALESCE(SUM(amount), 0) as amount").
+		Group("status").
+		Scan(&collections).Error; err != nil {
+		return nil, fmt.Errorf("failed to get executive collections: %w", err)
+	}
+
+	balance := &ExecutiveBalance{
+		ExecutiveID:   executiveID,
+		ApprovedSales: s.round(sales + dailySales),
+	}
+	for _, c := range collections {
+		if c.Status == "approved" {
+			balance.ApprovedCollections = s.round(c.Amount)
+		} else {
+			balance.PendingCollections = s.round(c.Amount)
+		}
+	}
+	balance.Balance = s.round(balance.ApprovedSales - balance.ApprovedCollections)
+	balance.Available = s.round(balance.Balance - balance.PendingCollections)
+
+	return balance, nil
 }
 
 func (s *AssistantManagerService) GetMoneyCollections(ctx context.Context, tenantID uuid.UUID, status string, includeOverdue bool, limit, offset int) ([]MoneyCollectionResponse, int64, error) {
@@ -232,80 +433,120 @@ func (s *AssistantManagerService) GetMoneyCollectionByID(ctx context.Context, id
 	return s.buildMoneyCollectionResponseFromModel(collection), nil
 }
 
-func (s *AssistantManagerService) ApproveMoneyCollection(ctx context.Context, id, tenantID, userID uuid.UUID) error {
+// ApproveMoneyCollection approves a pending collection. The collection is locked and
+// its status re-checked inside the transaction, so concurrent decisions take effect once.
+// When a version is given, a collection changed since the approver read it is refused.
+func (s *AssistantManagerService) ApproveMoneyCollection(ctx context.Context, id, tenantID, userID uuid.UUID, version *int) error {
 	var collection models.AssistantManagerMoneyCollection
-	if err := s.db.DB.Where("id = ? AND tenant_id = ?", id, tenantID).First(&collection).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return fmt.Errorf("money collection not found")
+	now := time.Now()
+	overdue := false
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.lockMoneyCollection(tx, &collection, id, tenantID); err != nil {
+			return err
+		}
+		if err := approval.Decide("money collection", collection.Status, "approved"); err != nil {
+			return err
+		}
+		if err := approval.CheckVersion("money collection", version, collection.Version); err != nil {
+			return err
 		}
-		return fmt.Errorf("failed to get collection: %w", err)
-	}
 
-	if collection.Status != "pending" {
-		return fmt.Errorf("collection is not in pending status")
-	}
+		// Check if deadline has passed
+		if now.After(collection.DeadlineAt) {
+			// Automatically mark as overdue
+			overdue = true
+			return approval.UpdateVersioned(tx, "money collection", &collection, &collection.Version, map[string]interface{}{
+				"status": "overdue",
+			})
+		}
 
-	// Check if deadline has passed
-	now := time.Now()
-	if now.After(collection.DeadlineAt) {
-		// Automatically mark as overdue
-		s.db.DB.Model(&collection).Updates(map[string]interface{}{
-			"status": "overdue",
-		})
-		return fmt.Errorf("collection deadline has passed - marked as overdue")
+		// Approve collection
+		if err := approval.UpdateVersioned(tx, "money collection", &collection, &collection.Version, map[string]interface{}{
+			"status":      "approved",
+			"approved_at": &now,
+			"approved_by": &userID,
+		}); err != nil {
+			return err
+		}
+		return s.recordCollectionLedgerEntry(tx, &collection, now, userID)
+	})
+	if err != nil {
+		if approval.Replay(ctx, s.settings, tenantID, err) {
+			return nil
+		}
+		return err
 	}
-
-	// Approve collection
-	if err := s.db.DB.Model(&collection).Updates(map[string]interface{}{
-		"status":      "approved",
-		"approved_at": &now,
-		"approved_by": &userID,
-	}).Error; err != nil {
-		return fmt.Errorf("failed to approve collection: %w", err)
+	if overdue {
+		return fmt.Errorf("collection deadline has passed - marked as overdue")
 	}
 
 	// Clear cache
 	cacheKey := fmt.Sprintf("collections:tenant:%s", tenantID.String())
 	s.cache.Delete(ctx, cacheKey)
+	s.cache.InvalidateDashboard(ctx, tenantID.String())
 
 	return nil
 }
 
-func (s *AssistantManagerService) RejectMoneyCollection(ctx context.Context, id, tenantID, userID uuid.UUID, reason string) error {
+// RejectMoneyCollection rejects a pending collection under the same lock and version
+// check as approval
+func (s *AssistantManagerService) RejectMoneyCollection(ctx context.Context, id, tenantID, userID uuid.UUID, reason string, version *int) error {
 	var collection models.AssistantManagerMoneyCollection
-	if err := s.db.DB.Where("id = ? AND tenant_id = ?", id, tenantID).First(&collection).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return fmt.Errorf("money collection not found")
-		}
-		return fmt.Errorf("failed to get collection: %w", err)
-	}
 
-	if collection.Status != "pending" {
-		return fmt.Errorf("collection is not in pending status")
-	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.lockMoneyCollection(tx, &collection, id, tenantID); err != nil {
+			return err
+		}
+		if err := approval.Decide("money collection", collection.Status, "rejected"); err != nil {
+			return err
+		}
+		if err := approval.CheckVersion("money collection", version, collection.Version); err != nil {
+			return err
+		}
 
-	now := time.Now()
-	notes := collection.Notes
-	if reason != "" {
-		notes = fmt.Sprintf("%s\nRejected: %s", notes, reason)
-	}
+		now := time.Now()
+		notes := collection.Notes
+		if reason != "" {
+			notes = fmt.Sprintf("%s\nRejected: %s", notes, reason)
+		}
 
-	if err := s.db.DB.Model(&collection).Updates(map[string]interface{}{
-		"status":      "rejected",
-		"approved_at": &now,
-		"approved_by": &userID,
-		"notes":       notes,
-	}).Error; err != nil {
-		return fmt.Errorf("failed to reject collection: %w", err)
+		return approval.UpdateVersioned(tx, "money collection", &collection, &collection.Version, map[string]interface{}{
+			"status":      "rejected",
+			"approved_at": &now,
+			"approved_by": &userID,
+			"notes":       notes,
+		})
+	})
+	if err != nil {
+		if approval.Replay(ctx, s.settings, tenantID, err) {
+			return nil
+		}
+		return err
 	}
 
 	// Clear cache
 	cacheKey := fmt.Sprintf("collections:tenant:%s", tenantID.String())
 	s.cache.Delete(ctx, cacheKey)
+	s.cache.InvalidateDashboard(ctx, tenantID.String())
 
 	return nil
 }
 
+// lockMoneyCollection loads a collection with a row lock held until the transaction ends
+func (s *AssistantManagerService) lockMoneyCollection(tx *gorm.DB, collection *models.AssistantManagerMoneyCollection, id, tenantID uuid.UUID) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		First(collection).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("money collection not found")
+		}
+		return fmt.Errorf("failed to get collection: %w", err)
+	}
+	return nil
+}
+
 // Assistant Manager Expense Operations
 func (s *AssistantManagerService) CreateAssistantManagerExpense(ctx context.Context, req AssistantManagerExpenseRequest, tenantID, userID uuid.UUID) (*AssistantManagerExpenseResponse, error) {
 	// Validate category exists
@@ -370,11 +611,10 @@ func (s *AssistantManagerService) CreateAssistantManagerFinance(ctx context.Cont
 		return nil, fmt.Errorf("failed to validate shop: %w", err)
 	}
 
-	// Calculate net amount if not provided
-	netAmount := req.NetAmountToDeposit
-	if netAmount == 0 {
-		totalCollected := req.CashCollected + req.CardCollected + req.UpiCollected + req.CreditCollected
-		netAmount = totalCollected - req.TotalExpenses
+	// Net amount is always computed server-side; a client value is only cross-checked
+	breakdown := s.ComputeNetAmount(req)
+	if breakdown.ClientNetAmount != nil && breakdown.Difference > breakdown.Tolerance {
+		return nil, &NetAmountMismatchError{Breakdown: breakdown}
 	}
 
 	finance := models.AssistantManagerFinance{
@@ -383,16 +623,20 @@ func (s *AssistantManagerService) CreateAssistantManagerFinance(ctx context.Cont
 			TenantID:  tenantID,
 		},
 		ExecutiveID:        req.ExecutiveID,
+		AssistantManagerID: userID,
 		ShopID:             req.ShopID,
-		TotalSalesAmount:   req.TotalSalesAmount,
-		CashCollected:      req.CashCollected,
-		CardCollected:      req.CardCollected,
-		UpiCollected:       req.UpiCollected,
-		CreditCollected:    req.CreditCollected,
-		TotalExpenses:      req.TotalExpenses,
-		NetAmountToDeposit: netAmount,
+		TotalSalesAmount:   s.round(req.TotalSalesAmount),
+		CashCollected:      breakdown.CashCollected,
+		CardCollected:      breakdown.CardCollected,
+		UpiCollected:       breakdown.UpiCollected,
+		CreditCollected:    breakdown.CreditCollected,
+		TotalCollected:     breakdown.TotalCollected,
+		TotalExpenses:      breakdown.TotalExpenses,
+		NetAmount:          breakdown.NetAmount,
+		NetAmountToDeposit: breakdown.NetAmount,
 		Notes:              req.Notes,
 		FinanceDate:        req.FinanceDate,
+		RecordDate:         req.FinanceDate,
 		Status:             "pending",
 		CreatedBy:          userID,
 	}
@@ -401,36 +645,181 @@ func (s *AssistantManagerService) CreateAssistantManagerFinance(ctx context.Cont
 		return nil, fmt.Errorf("failed to create finance record: %w", err)
 	}
 
-	return s.buildAssistantManagerFinanceResponse(finance, executive.FullName(), shop.Name, ""), nil
+	response := s.buildAssistantManagerFinanceResponse(finance, executive.FullName(), shop.Name, "")
+	response.Breakdown = breakdown
+	return response, nil
+}
+
+// ComputeNetAmount derives the net amount to deposit from the collected and expense figures,
+// rounding each component with the configured precision and mode
+func (s *AssistantManagerService) ComputeNetAmount(req AssistantManagerFinanceRequest) *NetAmountBreakdown {
+	breakdown := &NetAmountBreakdown{
+		CashCollected:   s.round(req.CashCollected),
+		CardCollected:   s.round(req.CardCollected),
+		UpiCollected:    s.round(req.UpiCollected),
+		CreditCollected: s.round(req.CreditCollected),
+		TotalExpenses:   s.round(req.TotalExpenses),
+		Tolerance:       s.finance.NetAmountTolerance,
+		RoundingPlaces:  s.finance.RoundingPlaces,
+		RoundingMode:    s.finance.RoundingMode,
+	}
+
+	breakdown.TotalCollected = s.round(breakdown.CashCollected + breakdown.CardCollected + breakdown.UpiCollected + breakdown.CreditCollected)
+	breakdown.NetAmount = s.round(breakdown.TotalCollected - breakdown.TotalExpenses)
+
+	if req.NetAmountToDeposit != nil {
+		clientNet := s.round(*req.NetAmountToDeposit)
+		breakdown.ClientNetAmount = &clientNet
+		breakdown.Difference = s.round(utils.AbsFloat(clientNet - breakdown.NetAmount))
+	}
+
+	return breakdown
+}
+
+func (s *AssistantManagerService) round(amount float64) float64 {
+	return utils.RoundAmount(amount, s.finance.RoundingPlaces, s.finance.RoundingMode)
 }
 
 // Mark overdue collections automatically
 func (s *AssistantManagerService) MarkOverdueCollections(ctx context.Context, tenantID uuid.UUID) error {
+	_, err := s.expireOverdueCollections(ctx, &tenantID)
+	return err
+}
+
+// ExpireOverdueCollections marks pending collections past their deadline as overdue
+// across all tenants and returns how many were expired
+func (s *AssistantManagerService) ExpireOverdueCollections(ctx context.Context) (int, error) {
+	return s.expireOverdueCollections(ctx, nil)
+}
+
+// expireOverdueCollections flips pending collections past their deadline to overdue,
+// writing a ledger entry for each transition. Rows locked by an approval in flight
+// are skipped; the approval itself handles a passed deadline.
+func (s *AssistantManagerService) expireOverdueCollections(ctx context.Context, tenantID *uuid.UUID) (int, error) {
 	now := time.Now()
-	
-	result := s.db.DB.Model(&models.AssistantManagerMoneyCollection{}).
-		Where("tenant_id = ? AND status = 'pending' AND deadline_at < ?", tenantID, now).
-		Update("status", "overdue")
-	
-	if result.Error != nil {
-		return fmt.Errorf("failed to mark overdue collections: %w", result.Error)
+	var expired []models.AssistantManagerMoneyCollection
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND deadline_at < ?", "pending", now)
+		if tenantID != nil {
+			query = query.Where("tenant_id = ?", *tenantID)
+		}
+		if err := query.Find(&expired).Error; err != nil {
+			return fmt.Errorf("failed to find overdue collections: %w", err)
+		}
+		if len(expired) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, len(expired))
+		for i, collection := range expired {
+			ids[i] = collection.ID
+		}
+		if err := tx.Model(&models.AssistantManagerMoneyCollection{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"status":  "overdue",
+				"version": gorm.Expr("version + 1"),
+			}).Error; err != nil {
+			return fmt.Errorf("failed to mark overdue collections: %w", err)
+		}
+
+		entries := make([]models.AssistantManagerLedger, len(expired))
+		for i, collection := range expired {
+			balance, err := s.ledgerBalance(tx, collection.TenantID, collection.AssistantManagerID)
+			if err != nil {
+				return err
+			}
+			collectionID := collection.ID
+			entries[i] = models.AssistantManagerLedger{
+				TenantModel:        models.TenantModel{TenantID: collection.TenantID},
+				AssistantManagerID: collection.AssistantManagerID,
+				MoneyCollectionID:  &collectionID,
+				TransactionDate:    now,
+				TransactionType:    LedgerOverdue,
+				Amount:             collection.Amount,
+				Description:        fmt.Sprintf("Collection not approved by deadline %s", collection.DeadlineAt.Format(time.RFC3339)),
+				Reference:          "pending -> overdue",
+				PreviousBalance:    balance,
+				NewBalance:         balance,
+				CreatedByID:        collection.AssistantManagerID,
+			}
+		}
+		if err := tx.Create(&entries).Error; err != nil {
+			return fmt.Errorf("failed to record overdue collections: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Clear cache for every tenant with expired collections
+	tenants := make(map[uuid.UUID]bool)
+	for _, collection := range expired {
+		if !tenants[collection.TenantID] {
+			tenants[collection.TenantID] = true
+			cacheKey := fmt.Sprintf("collections:tenant:%s", collection.TenantID.String())
+			s.cache.Delete(ctx, cacheKey)
+		}
 	}
 
-	// Clear cache if any rows were updated
-	if result.RowsAffected > 0 {
-		cacheKey := fmt.Sprintf("collections:tenant:%s", tenantID.String())
-		s.cache.Delete(ctx, cacheKey)
+	if len(expired) > 0 {
+		go s.notifyOverdueCollections(expired)
 	}
 
-	return nil
+	return len(expired), nil
+}
+
+// ledgerBalance returns an assistant manager's running ledger balance. Expiry moves
+// no money, so overdue entries carry the balance forward unchanged.
+func (s *AssistantManagerService) ledgerBalance(tx *gorm.DB, tenantID, assistantManagerID uuid.UUID) (float64, error) {
+	var last models.AssistantManagerLedger
+	err := tx.Where("tenant_id = ? AND assistant_manager_id = ?", tenantID, assistantManagerID).
+		Order("transaction_date DESC, created_at DESC").
+		First(&last).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get ledger balance: %w", err)
+	}
+	return last.NewBalance, nil
+}
+
+// approvalDeadline returns how long the tenant allows a collection to stay pending.
+// The deadline is fixed on the collection when it is recorded, so the overdue sweep
+// and countdown keep the window that applied at the time.
+func (s *AssistantManagerService) approvalDeadline(ctx context.Context, tenantID uuid.UUID) time.Duration {
+	minutes := APPROVAL_DEADLINE_MINUTES
+	if s.settings != nil {
+		if configured := s.settings.GetInt(ctx, tenantID, settings.KeyApprovalDeadlineMinutes); configured > 0 {
+			minutes = configured
+		}
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// CollectionCountdown returns the time left before a collection deadline. Minutes are
+// rounded up so a collection with seconds left never shows 0 while still open, and
+// the collection is only overdue strictly after the deadline.
+func CollectionCountdown(deadline, now time.Time) (minutesRemaining, secondsRemaining int, overdue bool) {
+	if now.After(deadline) {
+		return 0, 0, true
+	}
+
+	remaining := deadline.Sub(now)
+	secondsRemaining = int(math.Ceil(remaining.Seconds()))
+	minutesRemaining = int(math.Ceil(remaining.Minutes()))
+	return minutesRemaining, secondsRemaining, false
 }
 
 // Helper functions
 func (s *AssistantManagerService) buildMoneyCollectionResponse(collection models.AssistantManagerMoneyCollection, executiveName, shopName, approverName string) *MoneyCollectionResponse {
-	now := time.Now()
-	minutesRemaining := 0
-	if collection.Status == "pending" && collection.DeadlineAt.After(now) {
-		minutesRemaining = int(collection.DeadlineAt.Sub(now).Minutes())
+	minutesRemaining, secondsRemaining, isOverdue := 0, 0, false
+	if collection.Status == "pending" {
+		minutesRemaining, secondsRemaining, isOverdue = CollectionCountdown(collection.DeadlineAt, time.Now())
 	}
 
 	return &MoneyCollectionResponse{
@@ -446,9 +835,14 @@ func (s *AssistantManagerService) buildMoneyCollectionResponse(collection models
 		ApprovedAt:       collection.ApprovedAt,
 		ApprovedBy:       collection.ApprovedByID,
 		ApproverName:     approverName,
+		AutoApproved:     collection.AutoApproved,
+		Version:          collection.Version,
 		DeadlineAt:       collection.DeadlineAt,
-		IsOverdue:        now.After(collection.DeadlineAt) && collection.Status == "pending",
+		IsOverdue:        isOverdue,
 		MinutesRemaining: minutesRemaining,
+		SecondsRemaining: secondsRemaining,
+		BalanceOverridden: collection.BalanceOverridden,
+		OverrideReason:   collection.OverrideReason,
 		CreatedBy:        collection.CreatedBy,
 		CreatedAt:        collection.CreatedAt,
 		UpdatedAt:        collection.UpdatedAt,
@@ -509,7 +903,9 @@ func (s *AssistantManagerService) buildAssistantManagerFinanceResponse(finance m
 		CardCollected:      finance.CardCollected,
 		UpiCollected:       finance.UpiCollected,
 		CreditCollected:    finance.CreditCollected,
+		TotalCollected:     finance.TotalCollected,
 		TotalExpenses:      finance.TotalExpenses,
+		NetAmount:          finance.NetAmount,
 		NetAmountToDeposit: finance.NetAmountToDeposit,
 		Notes:              finance.Notes,
 		FinanceDate:        finance.FinanceDate,
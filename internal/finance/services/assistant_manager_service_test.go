@@ -0,0 +1,36 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectionCountdownBoundaries(t *testing.T) {
+	deadline := time.Date(2024, 1, 15, 10, 15, 0, 0, time.UTC)
+
+	cases := []struct {
+		name    string
+		now     time.Time
+		minutes int
+		seconds int
+		overdue bool
+	}{
+		{"Full Window", deadline.Add(-15 * time.Minute), 15, 900, false},
+		{"Just Under A Minute", deadline.Add(-59 * time.Second), 1, 59, false},
+		{"Sub-Second Left", deadline.Add(-500 * time.Millisecond), 1, 1, false},
+		{"Exactly At Deadline", deadline, 0, 0, false},
+		{"Just Past Deadline", deadline.Add(time.Nanosecond), 0, 0, true},
+		{"Long Past Deadline", deadline.Add(time.Hour), 0, 0, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			minutes, seconds, overdue := CollectionCountdown(deadline, tc.now)
+			assert.Equal(t, tc.minutes, minutes)
+			assert.Equal(t, tc.seconds, seconds)
+			assert.Equal(t, tc.overdue, overdue)
+		})
+	}
+}
@@ -0,0 +1,436 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Bank deposit reconciliation statuses
+const (
+	DepositUnreconciled = "unreconciled"
+	DepositMatched      = "matched"
+	DepositExcess       = "excess"
+)
+
+// Collection statuses in a deposit reconciliation
+const (
+	CollectionDeposited          = "deposited"
+	CollectionPartiallyDeposited = "partially_deposited"
+	CollectionUndeposited        = "undeposited"
+)
+
+// BankDepositRequest represents an assistant manager's cash deposit into a bank account
+type BankDepositRequest struct {
+	BankAccountID     uuid.UUID  `json:"bank_account_id" binding:"required"`
+	MoneyCollectionID *uuid.UUID `json:"money_collection_id"` // the collection the cash came from, when known
+	Amount            float64    `json:"amount" binding:"required,gt=0"`
+	DepositDate       time.Time  `json:"deposit_date" binding:"required"`
+	SlipNumber        string     `json:"slip_number"`
+}
+
+// BankDepositResponse represents a bank deposit in responses
+type BankDepositResponse struct {
+	ID                   uuid.UUID  `json:"id"`
+	BankAccountID        uuid.UUID  `json:"bank_account_id"`
+	BankName             string     `json:"bank_name"`
+	MoneyCollectionID    *uuid.UUID `json:"money_collection_id,omitempty"`
+	DepositDate          time.Time  `json:"deposit_date"`
+	Amount               float64    `json:"amount"`
+	SlipNumber           string     `json:"slip_number"`
+	Status               string     `json:"status"`
+	ApprovedAt           *time.Time `json:"approved_at,omitempty"`
+	ApprovedByID         *uuid.UUID `json:"approved_by_id,omitempty"`
+	ReconciliationStatus string     `json:"reconciliation_status"`
+	ReconciledAmount     float64    `json:"reconciled_amount"`
+	ReconciledAt         *time.Time `json:"reconciled_at,omitempty"`
+	CreatedByID          uuid.UUID  `json:"created_by_id"`
+	CreatedByName        string     `json:"created_by_name"`
+	CreatedAt            time.Time  `json:"created_at"`
+}
+
+// CollectionReconciliation shows how much of an approved collection reached the bank
+type CollectionReconciliation struct {
+	CollectionID uuid.UUID `json:"collection_id"`
+	ExecutiveID  uuid.UUID `json:"executive_id"`
+	ShopID       uuid.UUID `json:"shop_id"`
+	CollectedAt  time.Time `json:"collected_at"`
+	Amount       float64   `json:"amount"`
+	Deposited    float64   `json:"deposited"`
+	Shortfall    float64   `json:"shortfall"` // cash collected but not deposited
+	Status       string    `json:"status"`
+}
+
+// DepositReconciliation shows how much of a deposit is backed by collections
+type DepositReconciliation struct {
+	DepositID         uuid.UUID  `json:"deposit_id"`
+	MoneyCollectionID *uuid.UUID `json:"money_collection_id,omitempty"`
+	DepositDate       time.Time  `json:"deposit_date"`
+	SlipNumber        string     `json:"slip_number"`
+	Amount            float64    `json:"amount"`
+	Matched           float64    `json:"matched"`
+	Excess            float64    `json:"excess"` // deposited beyond what was collected
+	Status            string     `json:"status"`
+}
+
+// DepositReconciliationReport matches an assistant manager's approved collections
+// against their approved bank deposits over a date range
+type DepositReconciliationReport struct {
+	AssistantManagerID   uuid.UUID                  `json:"assistant_manager_id"`
+	AssistantManagerName string                     `json:"assistant_manager_name"`
+	StartDate            time.Time                  `json:"start_date"`
+	EndDate              time.Time                  `json:"end_date"`
+	TotalCollected       float64                    `json:"total_collected"`
+	TotalDeposited       float64                    `json:"total_deposited"`
+	Shortfall            float64                    `json:"shortfall"`
+	Excess               float64                    `json:"excess"`
+	Collections          []CollectionReconciliation `json:"collections"`
+	Deposits             []DepositReconciliation    `json:"deposits"`
+	ReconciledAt         time.Time                  `json:"reconciled_at"`
+}
+
+// CreateBankDeposit records cash the assistant manager paid into a bank account. It
+// waits for approval before it moves the bank balance.
+func (s *AssistantManagerService) CreateBankDeposit(ctx context.Context, req BankDepositRequest, tenantID, userID uuid.UUID) (*BankDepositResponse, error) {
+	var account models.BankAccount
+	if err := s.db.DB.Where("id = ? AND tenant_id = ?", req.BankAccountID, tenantID).First(&account).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("bank account not found")
+		}
+		return nil, fmt.Errorf("failed to validate bank account: %w", err)
+	}
+	if !account.IsActive {
+		return nil, fmt.Errorf("bank account is inactive")
+	}
+
+	if req.MoneyCollectionID != nil {
+		var collection models.AssistantManagerMoneyCollection
+		if err := s.db.DB.Where("id = ? AND tenant_id = ?", *req.MoneyCollectionID, tenantID).First(&collection).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("money collection not found")
+			}
+			return nil, fmt.Errorf("failed to validate money collection: %w", err)
+		}
+		if collection.Status != "approved" {
+			return nil, fmt.Errorf("only approved collections can be deposited")
+		}
+	}
+
+	deposit := models.BankDeposit{
+		TenantModel:          models.TenantModel{TenantID: tenantID},
+		MoneyCollectionID:    req.MoneyCollectionID,
+		BankAccountID:        req.BankAccountID,
+		DepositDate:          req.DepositDate,
+		Amount:               s.round(req.Amount),
+		SlipNumber:           req.SlipNumber,
+		Status:               "pending",
+		ReconciliationStatus: DepositUnreconciled,
+		CreatedByID:          userID,
+	}
+	if err := s.db.DB.Create(&deposit).Error; err != nil {
+		return nil, fmt.Errorf("failed to create bank deposit: %w", err)
+	}
+	deposit.BankAccount = &account
+
+	return s.buildBankDepositResponse(&deposit), nil
+}
+
+// GetBankDeposits returns the tenant's bank deposits, newest first, optionally limited
+// to one assistant manager or status
+func (s *AssistantManagerService) GetBankDeposits(ctx context.Context, tenantID uuid.UUID, createdByID *uuid.UUID, status string, limit, offset int) ([]*BankDepositResponse, int64, error) {
+	query := s.db.DB.WithContext(ctx).Model(&models.BankDeposit{}).Where("tenant_id = ?", tenantID)
+	if createdByID != nil {
+		query = query.Where("created_by_id = ?", *createdByID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count bank deposits: %w", err)
+	}
+
+	var deposits []models.BankDeposit
+	if err := query.Preload("BankAccount").Preload("CreatedBy").
+		Order("deposit_date DESC, created_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&deposits).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get bank deposits: %w", err)
+	}
+
+	responses := make([]*BankDepositResponse, len(deposits))
+	for i := range deposits {
+		responses[i] = s.buildBankDepositResponse(&deposits[i])
+	}
+	return responses, total, nil
+}
+
+// ApproveBankDeposit confirms a deposit reached the bank: it credits the bank account
+// and takes the amount off the depositing assistant manager's cash ledger
+func (s *AssistantManagerService) ApproveBankDeposit(ctx context.Context, id, tenantID, userID uuid.UUID) (*BankDepositResponse, error) {
+	var deposit models.BankDeposit
+	now := time.Now()
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND tenant_id = ?", id, tenantID).
+			First(&deposit).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("bank deposit not found")
+			}
+			return fmt.Errorf("failed to get bank deposit: %w", err)
+		}
+		if deposit.Status != "pending" {
+			return fmt.Errorf("bank deposit is already %s", deposit.Status)
+		}
+
+		var account models.BankAccount
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND tenant_id = ?", deposit.BankAccountID, tenantID).
+			First(&account).Error; err != nil {
+			return fmt.Errorf("failed to lock bank account: %w", err)
+		}
+
+		previous := account.CurrentBalance
+		account.CurrentBalance = s.round(previous + deposit.Amount)
+		if err := tx.Model(&account).Update("current_balance", account.CurrentBalance).Error; err != nil {
+			return fmt.Errorf("failed to update bank account balance: %w", err)
+		}
+
+		reference := deposit.SlipNumber
+		if reference == "" {
+			reference = deposit.ID.String()
+		}
+		credit := models.BankTransaction{
+			TenantModel:     models.TenantModel{TenantID: tenantID},
+			BankAccountID:   account.ID,
+			TransactionType: "credit",
+			Amount:          deposit.Amount,
+			TransactionDate: deposit.DepositDate,
+			Description:     "Cash deposit",
+			Reference:       reference,
+			PreviousBalance: previous,
+			NewBalance:      account.CurrentBalance,
+			CreatedByID:     userID,
+		}
+		if err := tx.Create(&credit).Error; err != nil {
+			return fmt.Errorf("failed to record deposit transaction: %w", err)
+		}
+
+		if err := s.appendLedgerEntry(tx, &models.AssistantManagerLedger{
+			TenantModel:        models.TenantModel{TenantID: tenantID},
+			AssistantManagerID: deposit.CreatedByID,
+			MoneyCollectionID:  deposit.MoneyCollectionID,
+			TransactionDate:    deposit.DepositDate,
+			TransactionType:    LedgerDeposit,
+			Amount:             deposit.Amount,
+			Description:        fmt.Sprintf("Deposited to %s %s", account.BankName, account.AccountNumber),
+			Reference:          reference,
+			CreatedByID:        userID,
+		}); err != nil {
+			return err
+		}
+
+		deposit.Status = "approved"
+		deposit.ApprovedAt = &now
+		deposit.ApprovedByID = &userID
+		if err := tx.Model(&deposit).Updates(map[string]interface{}{
+			"status":         deposit.Status,
+			"approved_at":    deposit.ApprovedAt,
+			"approved_by_id": deposit.ApprovedByID,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to approve bank deposit: %w", err)
+		}
+		deposit.BankAccount = &account
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildBankDepositResponse(&deposit), nil
+}
+
+// ReconcileDeposits matches an assistant manager's approved collections between
+// startDate and endDate against their approved deposits over the same dates.
+// Deposits naming a collection are matched to it first; the rest are matched to the
+// oldest collections still short, in deposit date order. Collections left short are
+// cash unaccounted for and deposits left over exceed what was collected. Each
+// deposit's reconciliation status is saved.
+func (s *AssistantManagerService) ReconcileDeposits(ctx context.Context, tenantID, assistantManagerID uuid.UUID, startDate, endDate time.Time) (*DepositReconciliationReport, error) {
+	var assistantManager models.User
+	if err := s.db.DB.WithContext(ctx).Where("id = ? AND tenant_id = ?", assistantManagerID, tenantID).First(&assistantManager).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("assistant manager not found")
+		}
+		return nil, fmt.Errorf("failed to get assistant manager: %w", err)
+	}
+
+	now := time.Now()
+	report := &DepositReconciliationReport{
+		AssistantManagerID:   assistantManagerID,
+		AssistantManagerName: assistantManager.FullName(),
+		StartDate:            startDate,
+		EndDate:              endDate,
+		Collections:          []CollectionReconciliation{},
+		Deposits:             []DepositReconciliation{},
+		ReconciledAt:         now,
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var collections []models.AssistantManagerMoneyCollection
+		if err := tx.Where("tenant_id = ? AND assistant_manager_id = ? AND status = ? AND collected_at >= ? AND collected_at <= ?",
+			tenantID, assistantManagerID, "approved", startDate, endDate).
+			Order("collected_at ASC").
+			Find(&collections).Error; err != nil {
+			return fmt.Errorf("failed to get collections: %w", err)
+		}
+
+		var deposits []models.BankDeposit
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("tenant_id = ? AND created_by_id = ? AND status = ? AND deposit_date >= ? AND deposit_date <= ?",
+				tenantID, assistantManagerID, "approved", startDate, endDate).
+			Order("deposit_date ASC, created_at ASC").
+			Find(&deposits).Error; err != nil {
+			return fmt.Errorf("failed to get bank deposits: %w", err)
+		}
+
+		report.Collections = make([]CollectionReconciliation, len(collections))
+		byID := make(map[uuid.UUID]*CollectionReconciliation, len(collections))
+		for i, collection := range collections {
+			report.Collections[i] = CollectionReconciliation{
+				CollectionID: collection.ID,
+				ExecutiveID:  collection.ExecutiveID,
+				ShopID:       collection.ShopID,
+				CollectedAt:  collection.CollectedAt,
+				Amount:       collection.Amount,
+			}
+			byID[collection.ID] = &report.Collections[i]
+			report.TotalCollected += collection.Amount
+		}
+
+		report.Deposits = make([]DepositReconciliation, len(deposits))
+		for i, deposit := range deposits {
+			report.Deposits[i] = DepositReconciliation{
+				DepositID:         deposit.ID,
+				MoneyCollectionID: deposit.MoneyCollectionID,
+				DepositDate:       deposit.DepositDate,
+				SlipNumber:        deposit.SlipNumber,
+				Amount:            deposit.Amount,
+			}
+			report.TotalDeposited += deposit.Amount
+		}
+
+		allocate := func(deposit *DepositReconciliation, collection *CollectionReconciliation) {
+			amount := s.round(min(deposit.Amount-deposit.Matched, collection.Amount-collection.Deposited))
+			if amount <= 0 {
+				return
+			}
+			deposit.Matched = s.round(deposit.Matched + amount)
+			collection.Deposited = s.round(collection.Deposited + amount)
+		}
+
+		// Deposits that name their collection
+		for i := range report.Deposits {
+			deposit := &report.Deposits[i]
+			if deposit.MoneyCollectionID == nil {
+				continue
+			}
+			if collection, ok := byID[*deposit.MoneyCollectionID]; ok {
+				allocate(deposit, collection)
+			}
+		}
+
+		// The rest, oldest deposit to oldest collection still short
+		for i := range report.Deposits {
+			deposit := &report.Deposits[i]
+			if deposit.MoneyCollectionID != nil {
+				if _, ok := byID[*deposit.MoneyCollectionID]; ok {
+					continue
+				}
+			}
+			for j := range report.Collections {
+				if deposit.Matched >= deposit.Amount {
+					break
+				}
+				allocate(deposit, &report.Collections[j])
+			}
+		}
+
+		for i := range report.Collections {
+			collection := &report.Collections[i]
+			collection.Shortfall = s.round(collection.Amount - collection.Deposited)
+			switch {
+			case collection.Shortfall <= 0:
+				collection.Status = CollectionDeposited
+			case collection.Deposited > 0:
+				collection.Status = CollectionPartiallyDeposited
+			default:
+				collection.Status = CollectionUndeposited
+			}
+			report.Shortfall += collection.Shortfall
+		}
+
+		for i := range report.Deposits {
+			deposit := &report.Deposits[i]
+			deposit.Excess = s.round(deposit.Amount - deposit.Matched)
+			deposit.Status = DepositMatched
+			if deposit.Excess > 0 {
+				deposit.Status = DepositExcess
+			}
+			report.Excess += deposit.Excess
+
+			if err := tx.Model(&models.BankDeposit{}).Where("id = ?", deposit.DepositID).Updates(map[string]interface{}{
+				"reconciliation_status": deposit.Status,
+				"reconciled_amount":     deposit.Matched,
+				"reconciled_at":         now,
+			}).Error; err != nil {
+				return fmt.Errorf("failed to save deposit reconciliation: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.TotalCollected = s.round(report.TotalCollected)
+	report.TotalDeposited = s.round(report.TotalDeposited)
+	report.Shortfall = s.round(report.Shortfall)
+	report.Excess = s.round(report.Excess)
+
+	return report, nil
+}
+
+func (s *AssistantManagerService) buildBankDepositResponse(deposit *models.BankDeposit) *BankDepositResponse {
+	response := &BankDepositResponse{
+		ID:                   deposit.ID,
+		BankAccountID:        deposit.BankAccountID,
+		MoneyCollectionID:    deposit.MoneyCollectionID,
+		DepositDate:          deposit.DepositDate,
+		Amount:               deposit.Amount,
+		SlipNumber:           deposit.SlipNumber,
+		Status:               deposit.Status,
+		ApprovedAt:           deposit.ApprovedAt,
+		ApprovedByID:         deposit.ApprovedByID,
+		ReconciliationStatus: deposit.ReconciliationStatus,
+		ReconciledAmount:     deposit.ReconciledAmount,
+		ReconciledAt:         deposit.ReconciledAt,
+		CreatedByID:          deposit.CreatedByID,
+		CreatedAt:            deposit.CreatedAt,
+	}
+	if deposit.BankAccount != nil {
+		response.BankName = deposit.BankAccount.BankName
+	}
+	if deposit.CreatedBy != nil {
+		response.CreatedByName = deposit.CreatedBy.FullName()
+	}
+	return response
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+	"gorm.io/gorm"
+)
+
+// Default and maximum number of complete months averaged for the break-even baseline
+const (
+	DefaultBreakEvenMonths = 3
+	MaxBreakEvenMonths     = 12
+)
+
+// BreakEvenReport shows the sales a shop needs to cover its fixed costs and how the
+// current month is tracking against that target
+type BreakEvenReport struct {
+	ShopID               *uuid.UUID        `json:"shop_id,omitempty"`
+	ShopName             string            `json:"shop_name,omitempty"`
+	LookbackMonths       int               `json:"lookback_months"`
+	BaselineStart        time.Time         `json:"baseline_start"`
+	BaselineEnd          time.Time         `json:"baseline_end"`
+	MonthlyFixedCosts    float64           `json:"monthly_fixed_costs"`
+	BaselineRevenue      float64           `json:"baseline_revenue"`
+	BaselineCostOfGoods  float64           `json:"baseline_cost_of_goods"`
+	GrossMarginPercent   float64           `json:"gross_margin_percent"`
+	Reachable            bool              `json:"reachable"` // false when sales carry no positive margin
+	RequiredMonthlySales float64           `json:"required_monthly_sales"`
+	RequiredDailySales   float64           `json:"required_daily_sales"`
+	Progress             BreakEvenProgress `json:"progress"`
+}
+
+// BreakEvenProgress tracks month-to-date sales against the break-even target
+type BreakEvenProgress struct {
+	Month                       string  `json:"month"`
+	DaysElapsed                 int     `json:"days_elapsed"`
+	DaysRemaining               int     `json:"days_remaining"`
+	SalesToDate                 float64 `json:"sales_to_date"`
+	ContributionToDate          float64 `json:"contribution_to_date"` // gross margin earned towards fixed costs
+	ExpectedSalesToDate         float64 `json:"expected_sales_to_date"`
+	ProgressPercent             float64 `json:"progress_percent"`
+	OnTrack                     bool    `json:"on_track"`
+	BreakEvenReached            bool    `json:"break_even_reached"`
+	RequiredDailySalesRemaining float64 `json:"required_daily_sales_remaining"`
+}
+
+type salesMargin struct {
+	Revenue     float64
+	CostOfGoods float64
+}
+
+// GetBreakEven computes the sales needed to break even for a shop, or for the whole
+// tenant when shopID is nil. Fixed costs are the average monthly non-rejected expenses
+// over the last `months` complete months and the margin is the gross margin of
+// approved sales over the same window, costed at batch or product cost price.
+func (s *ExpenseService) GetBreakEven(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, months int) (*BreakEvenReport, error) {
+	if months <= 0 {
+		months = DefaultBreakEvenMonths
+	}
+	if months > MaxBreakEvenMonths {
+		return nil, fmt.Errorf("months cannot exceed %d", MaxBreakEvenMonths)
+	}
+
+	report := &BreakEvenReport{
+		ShopID:         shopID,
+		LookbackMonths: months,
+	}
+
+	if shopID != nil {
+		var shop models.Shop
+		if err := s.db.DB.Where("id = ? AND tenant_id = ?", *shopID, tenantID).First(&shop).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("shop not found")
+			}
+			return nil, fmt.Errorf("failed to get shop: %w", err)
+		}
+		report.ShopName = shop.Name
+	}
+
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	nextMonth := monthStart.AddDate(0, 1, 0)
+	report.BaselineStart = monthStart.AddDate(0, -months, 0)
+	report.BaselineEnd = monthStart
+
+	// Fixed costs
+	expenseQuery := s.db.DB.Model(&models.Expense{}).
+		Where("tenant_id = ? AND status <> ? AND expense_date >= ? AND expense_date < ?",
+			tenantID, models.StatusRejected, report.BaselineStart, report.BaselineEnd)
+	if shopID != nil {
+		expenseQuery = expenseQuery.Where("shop_id = ?", *shopID)
+	}
+	expenseQuery = scope.FromContext(ctx).Apply(expenseQuery, "shop_id")
+	var totalExpenses float64
+	if err := expenseQuery.Select("COALESCE(SUM(amount), 0)").Scan(&totalExpenses).Error; err != nil {
+		return nil, fmt.Errorf("failed to get expenses: %w", err)
+	}
+	report.MonthlyFixedCosts = utils.RoundToTwoDecimals(totalExpenses / float64(months))
+
+	// Gross margin
+	baseline, err := s.salesMargin(ctx, tenantID, shopID, report.BaselineStart, report.BaselineEnd)
+	if err != nil {
+		return nil, err
+	}
+	report.BaselineRevenue = utils.RoundToTwoDecimals(baseline.Revenue)
+	report.BaselineCostOfGoods = utils.RoundToTwoDecimals(baseline.CostOfGoods)
+
+	var marginRatio float64
+	if baseline.Revenue > 0 {
+		marginRatio = (baseline.Revenue - baseline.CostOfGoods) / baseline.Revenue
+		report.GrossMarginPercent = utils.RoundToTwoDecimals(marginRatio * 100)
+	}
+
+	daysInMonth := nextMonth.AddDate(0, 0, -1).Day()
+	report.Reachable = marginRatio > 0
+	if report.Reachable {
+		requiredMonthly := report.MonthlyFixedCosts / marginRatio
+		report.RequiredMonthlySales = utils.RoundToTwoDecimals(requiredMonthly)
+		report.RequiredDailySales = utils.RoundToTwoDecimals(requiredMonthly / float64(daysInMonth))
+	}
+
+	// Progress for the current month
+	current, err := s.salesMargin(ctx, tenantID, shopID, monthStart, nextMonth)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := BreakEvenProgress{
+		Month:         monthStart.Format("2006-01"),
+		DaysElapsed:   now.Day(),
+		DaysRemaining: daysInMonth - now.Day(),
+		SalesToDate:   utils.RoundToTwoDecimals(current.Revenue),
+	}
+	progress.ContributionToDate = utils.RoundToTwoDecimals(current.Revenue * marginRatio)
+
+	if report.Reachable {
+		progress.ExpectedSalesToDate = utils.RoundToTwoDecimals(report.RequiredMonthlySales * float64(progress.DaysElapsed) / float64(daysInMonth))
+		if report.RequiredMonthlySales > 0 {
+			progress.ProgressPercent = utils.RoundToTwoDecimals(current.Revenue / report.RequiredMonthlySales * 100)
+		} else {
+			progress.ProgressPercent = 100
+		}
+		progress.OnTrack = current.Revenue >= progress.ExpectedSalesToDate
+		progress.BreakEvenReached = current.Revenue >= report.RequiredMonthlySales
+
+		if !progress.BreakEvenReached {
+			shortfall := report.RequiredMonthlySales - current.Revenue
+			// Today still counts as a selling day
+			progress.RequiredDailySalesRemaining = utils.RoundToTwoDecimals(shortfall / float64(progress.DaysRemaining+1))
+		}
+	}
+	report.Progress = progress
+
+	return report, nil
+}
+
+// salesMargin totals revenue and cost of goods for approved sales and approved daily
+// sales records in [start, end)
+func (s *ExpenseService) salesMargin(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, start, end time.Time) (*salesMargin, error) {
+	var fromSales salesMargin
+	salesQuery := s.db.DB.WithContext(ctx).Table("sale_items").
+		Select(`COALESCE(SUM(sale_items.total_price), 0) as revenue,
+			COALESCE(SUM(sale_items.quantity * COALESCE(stock_batches.cost_price, products.cost_price, 0)), 0) as cost_of_goods`).
+		Joins("JOIN sales ON sale_items.sale_id = sales.id").
+		Joins("JOIN products ON sale_items.product_id = products.id").
+		Joins("LEFT JOIN stock_batches ON sale_items.stock_batch_id = stock_batches.id").
+		Where("sale_items.tenant_id = ? AND sales.status = ? AND sales.sale_date >= ? AND sales.sale_date < ?",
+			tenantID, models.StatusApproved, start, end).
+		Where("sales.deleted_at IS NULL AND sale_items.deleted_at IS NULL")
+	if shopID != nil {
+		salesQuery = salesQuery.Where("sales.shop_id = ?", *shopID)
+	}
+	salesQuery = scope.FromContext(ctx).Apply(salesQuery, "sales.shop_id")
+	if err := salesQuery.Scan(&fromSales).Error; err != nil {
+		return nil, fmt.Errorf("failed to get sales margin: %w", err)
+	}
+
+	var fromDailySales salesMargin
+	dailyQuery := s.db.DB.WithContext(ctx).Table("daily_sales_items").
+		Select(`COALESCE(SUM(daily_sales_items.total_amount), 0) as revenue,
+			COALESCE(SUM(daily_sales_items.quantity * COALESCE(products.cost_price, 0)), 0) as cost_of_goods`).
+		Joins("JOIN daily_sales_records ON daily_sales_items.daily_sales_record_id = daily_sales_records.id").
+		Joins("JOIN products ON daily_sales_items.product_id = products.id").
+		Where("daily_sales_items.tenant_id = ? AND daily_sales_records.status = ? AND daily_sales_records.record_date >= ? AND daily_sales_records.record_date < ?",
+			tenantID, models.StatusApproved, start, end).
+		Where("daily_sales_records.deleted_at IS NULL AND daily_sales_items.deleted_at IS NULL")
+	if shopID != nil {
+		dailyQuery = dailyQuery.Where("daily_sales_records.shop_id = ?", *shopID)
+	}
+	dailyQuery = scope.FromContext(ctx).Apply(dailyQuery, "daily_sales_records.shop_id")
+	if err := dailyQuery.Scan(&fromDailySales).Error; err != nil {
+		return nil, fmt.Errorf("failed to get daily sales margin: %w", err)
+	}
+
+	return &salesMargin{
+		Revenue:     fromSales.Revenue + fromDailySales.Revenue,
+		CostOfGoods: fromSales.CostOfGoods + fromDailySales.CostOfGoods,
+	}, nil
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Cash drawer statuses
+const (
+	DrawerBalanced = "balanced"
+	DrawerOver     = "over"
+	DrawerShort    = "short"
+)
+
+// CashDrawerRequest is a shop's end-of-day cash count
+type CashDrawerRequest struct {
+	ShopID        uuid.UUID `json:"shop_id" binding:"required"`
+	DrawerDate    time.Time `json:"drawer_date" binding:"required"`
+	CountedAmount *float64  `json:"counted_amount" binding:"required,gte=0"`
+	Notes         string    `json:"notes"`
+}
+
+// ResolveCashDrawerRequest explains an over or short drawer
+type ResolveCashDrawerRequest struct {
+	Notes string `json:"notes" binding:"required"`
+}
+
+// CashDrawerFilter narrows a cash drawer listing
+type CashDrawerFilter struct {
+	ShopID     *uuid.UUID
+	Status     string
+	Unresolved bool // only over or short drawers nobody has resolved yet
+	StartDate  *time.Time
+	EndDate    *time.Time
+}
+
+// CashDrawerResponse represents a cash drawer reconciliation in responses
+type CashDrawerResponse struct {
+	ID              uuid.UUID  `json:"id"`
+	ShopID          uuid.UUID  `json:"shop_id"`
+	ShopName        string     `json:"shop_name"`
+	DrawerDate      string     `json:"drawer_date"`
+	CashSales       float64    `json:"cash_sales"`
+	CashExpenses    float64    `json:"cash_expenses"`
+	ExpectedAmount  float64    `json:"expected_amount"`
+	CountedAmount   float64    `json:"counted_amount"`
+	Difference      float64    `json:"difference"` // counted minus expected; negative is a shortfall
+	Status          string     `json:"status"`
+	Notes           string     `json:"notes"`
+	Resolved        bool       `json:"resolved"`
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
+	ResolvedByID    *uuid.UUID `json:"resolved_by_id,omitempty"`
+	ResolutionNotes string     `json:"resolution_notes,omitempty"`
+	CountedByID     uuid.UUID  `json:"counted_by_id"`
+	CountedByName   string     `json:"counted_by_name"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// CreateCashDrawer records a shop's counted cash for a day and reconciles it. The
+// expected cash is the cash part of the shop's approved daily sales records for the
+// day less the assistant managers' cash expenses for the shop that day; rejected
+// expenses still left the drawer but are not allowed for, so they show up as a
+// shortfall. A shop's drawer can be counted once a day.
+func (s *AssistantManagerService) CreateCashDrawer(ctx context.Context, req CashDrawerRequest, tenantID, userID uuid.UUID) (*CashDrawerResponse, error) {
+	if !scope.FromContext(ctx).Allows(req.ShopID) {
+		return nil, fmt.Errorf("shop not found")
+	}
+
+	var shop models.Shop
+	if err := s.db.DB.WithContext(ctx).Where("id = ? AND tenant_id = ?", req.ShopID, tenantID).First(&shop).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("shop not found")
+		}
+		return nil, fmt.Errorf("failed to get shop: %w", err)
+	}
+
+	day := utils.StartOfDay(req.DrawerDate)
+	if day.After(time.Now()) {
+		return nil, fmt.Errorf("cannot count a cash drawer for a future date")
+	}
+
+	drawer := models.CashDrawer{
+		TenantModel:   models.TenantModel{TenantID: tenantID},
+		ShopID:        req.ShopID,
+		DrawerDate:    day,
+		CountedAmount: s.round(*req.CountedAmount),
+		Notes:         req.Notes,
+		CountedByID:   userID,
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the shop so two counts of the same drawer can't both pass the check
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", shop.ID).First(&models.Shop{}).Error; err != nil {
+			return fmt.Errorf("failed to lock shop: %w", err)
+		}
+
+		var existing int64
+		if err := tx.Model(&models.CashDrawer{}).
+			Where("tenant_id = ? AND shop_id = ? AND drawer_date = ?", tenantID, req.ShopID, day).
+			Count(&existing).Error; err != nil {
+			return fmt.Errorf("failed to check existing cash drawer: %w", err)
+		}
+		if existing > 0 {
+			return fmt.Errorf("cash drawer for this shop and date is already counted")
+		}
+
+		cashSales, cashExpenses, err := s.expectedDrawerCash(tx, tenantID, req.ShopID, day)
+		if err != nil {
+			return err
+		}
+
+		drawer.CashSales = cashSales
+		drawer.CashExpenses = cashExpenses
+		drawer.ExpectedAmount = s.round(cashSales - cashExpenses)
+		drawer.Difference = s.round(drawer.CountedAmount - drawer.ExpectedAmount)
+		switch {
+		case drawer.Difference > 0:
+			drawer.Status = DrawerOver
+		case drawer.Difference < 0:
+			drawer.Status = DrawerShort
+		default:
+			drawer.Status = DrawerBalanced
+		}
+
+		if err := tx.Create(&drawer).Error; err != nil {
+			return fmt.Errorf("failed to create cash drawer: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.InvalidateDashboard(ctx, tenantID.String())
+
+	drawer.Shop = &shop
+	return s.buildCashDrawerResponse(&drawer), nil
+}
+
+// GetCashDrawers returns the cash drawers in the caller's shop scope, newest first
+func (s *AssistantManagerService) GetCashDrawers(ctx context.Context, tenantID uuid.UUID, filter CashDrawerFilter, limit, offset int) ([]*CashDrawerResponse, int64, error) {
+	query := s.db.DB.WithContext(ctx).Model(&models.CashDrawer{}).Where("tenant_id = ?", tenantID)
+	if filter.ShopID != nil {
+		query = query.Where("shop_id = ?", *filter.ShopID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Unresolved {
+		query = query.Where("status <> ? AND resolved_at IS NULL", DrawerBalanced)
+	}
+	if filter.StartDate != nil {
+		query = query.Where("drawer_date >= ?", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		query = query.Where("drawer_date <= ?", *filter.EndDate)
+	}
+	query = scope.FromContext(ctx).Apply(query, "shop_id")
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count cash drawers: %w", err)
+	}
+
+	var drawers []models.CashDrawer
+	if err := query.Preload("Shop").Preload("CountedBy").
+		Order("drawer_date DESC, created_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&drawers).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get cash drawers: %w", err)
+	}
+
+	responses := make([]*CashDrawerResponse, len(drawers))
+	for i := range drawers {
+		responses[i] = s.buildCashDrawerResponse(&drawers[i])
+	}
+	return responses, total, nil
+}
+
+// ResolveCashDrawer records how an over or short drawer was explained, taking it
+// off the dashboard's unresolved discrepancies
+func (s *AssistantManagerService) ResolveCashDrawer(ctx context.Context, id uuid.UUID, req ResolveCashDrawerRequest, tenantID, userID uuid.UUID) (*CashDrawerResponse, error) {
+	var drawer models.CashDrawer
+	now := time.Now()
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND tenant_id = ?", id, tenantID)
+		if err := scope.FromContext(ctx).Apply(query, "shop_id").First(&drawer).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("cash drawer not found")
+			}
+			return fmt.Errorf("failed to get cash drawer: %w", err)
+		}
+		if drawer.Status == DrawerBalanced {
+			return fmt.Errorf("cash drawer is balanced and needs no resolution")
+		}
+		if drawer.ResolvedAt != nil {
+			return fmt.Errorf("cash drawer is already resolved")
+		}
+
+		drawer.ResolvedAt = &now
+		drawer.ResolvedByID = &userID
+		drawer.ResolutionNotes = req.Notes
+		if err := tx.Model(&drawer).Updates(map[string]interface{}{
+			"resolved_at":      drawer.ResolvedAt,
+			"resolved_by_id":   drawer.ResolvedByID,
+			"resolution_notes": drawer.ResolutionNotes,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to resolve cash drawer: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.InvalidateDashboard(ctx, tenantID.String())
+
+	s.db.DB.WithContext(ctx).Preload("Shop").Preload("CountedBy").First(&drawer, "id = ?", drawer.ID)
+	return s.buildCashDrawerResponse(&drawer), nil
+}
+
+// expectedDrawerCash totals the cash a shop took from approved daily sales records
+// on a day and the cash its assistant managers paid out in expenses that day
+func (s *AssistantManagerService) expectedDrawerCash(tx *gorm.DB, tenantID, shopID uuid.UUID, day time.Time) (float64, float64, error) {
+	next := day.AddDate(0, 0, 1)
+
+	var cashSales float64
+	if err := tx.Model(&models.DailySalesRecord{}).
+		Select("COALESCE(SUM(total_cash_amount), 0)").
+		Where("tenant_id = ? AND shop_id = ? AND status = ? AND record_date >= ? AND record_date < ?",
+			tenantID, shopID, models.StatusApproved, day, next).
+		Scan(&cashSales).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to get cash sales: %w", err)
+	}
+
+	var cashExpenses float64
+	if err := tx.Model(&models.AssistantManagerExpense{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("tenant_id = ? AND shop_id = ? AND payment_method = ? AND status <> ? AND expense_date >= ? AND expense_date < ?",
+			tenantID, shopID, models.PaymentCash, models.StatusRejected, day, next).
+		Scan(&cashExpenses).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to get cash expenses: %w", err)
+	}
+
+	return s.round(cashSales), s.round(cashExpenses), nil
+}
+
+func (s *AssistantManagerService) buildCashDrawerResponse(drawer *models.CashDrawer) *CashDrawerResponse {
+	response := &CashDrawerResponse{
+		ID:              drawer.ID,
+		ShopID:          drawer.ShopID,
+		DrawerDate:      drawer.DrawerDate.Format("2006-01-02"),
+		CashSales:       drawer.CashSales,
+		CashExpenses:    drawer.CashExpenses,
+		ExpectedAmount:  drawer.ExpectedAmount,
+		CountedAmount:   drawer.CountedAmount,
+		Difference:      drawer.Difference,
+		Status:          drawer.Status,
+		Notes:           drawer.Notes,
+		Resolved:        drawer.ResolvedAt != nil,
+		ResolvedAt:      drawer.ResolvedAt,
+		ResolvedByID:    drawer.ResolvedByID,
+		ResolutionNotes: drawer.ResolutionNotes,
+		CountedByID:     drawer.CountedByID,
+		CreatedAt:       drawer.CreatedAt,
+	}
+	if drawer.Shop != nil {
+		response.ShopName = drawer.Shop.Name
+	}
+	if drawer.CountedBy != nil {
+		response.CountedByName = drawer.CountedBy.FullName()
+	}
+	return response
+}
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/pdf"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"gorm.io/gorm"
+)
+
+// CollectionSheet compares the cash each salesman should hand over for a day with
+// the money collections recorded against them
+type CollectionSheet struct {
+	Date        string                `json:"date"`
+	ShopID      *uuid.UUID            `json:"shop_id,omitempty"`
+	ShopName    string                `json:"shop_name,omitempty"`
+	Salesmen    []*CollectionSheetRow `json:"salesmen"`
+	Totals      CollectionSheetTotals `json:"totals"`
+	GeneratedAt time.Time             `json:"generated_at"`
+}
+
+// CollectionSheetRow is one salesman's line on the collection sheet. Expected
+// collection is the cash part of their approved sales and daily sales records;
+// card and UPI settle to the bank and credit is still owed by customers.
+type CollectionSheetRow struct {
+	SalesmanID         uuid.UUID `json:"salesman_id"`
+	SalesmanName       string    `json:"salesman_name"`
+	EmployeeID         string    `json:"employee_id"`
+	ShopID             uuid.UUID `json:"shop_id"`
+	ShopName           string    `json:"shop_name"`
+	SalesAmount        float64   `json:"sales_amount"`
+	CashAmount         float64   `json:"cash_amount"`
+	CardAmount         float64   `json:"card_amount"`
+	UpiAmount          float64   `json:"upi_amount"`
+	CreditAmount       float64   `json:"credit_amount"`
+	ExpectedCollection float64   `json:"expected_collection"`
+	CollectedApproved  float64   `json:"collected_approved"`
+	CollectedPending   float64   `json:"collected_pending"`
+	Collected          float64   `json:"collected"`
+	Variance           float64   `json:"variance"` // collected minus expected; negative is a shortfall
+}
+
+// CollectionSheetTotals sums the sheet's rows
+type CollectionSheetTotals struct {
+	SalesAmount        float64 `json:"sales_amount"`
+	CashAmount         float64 `json:"cash_amount"`
+	CardAmount         float64 `json:"card_amount"`
+	UpiAmount          float64 `json:"upi_amount"`
+	CreditAmount       float64 `json:"credit_amount"`
+	ExpectedCollection float64 `json:"expected_collection"`
+	CollectedApproved  float64 `json:"collected_approved"`
+	CollectedPending   float64 `json:"collected_pending"`
+	Collected          float64 `json:"collected"`
+	Variance           float64 `json:"variance"`
+}
+
+type salesmanSalesSplit struct {
+	SalesmanID   uuid.UUID
+	SalesAmount  float64
+	CashAmount   float64
+	CardAmount   float64
+	UpiAmount    float64
+	CreditAmount float64
+}
+
+// GetCollectionSheet builds the per-salesman collection sheet for a day, for one
+// shop or every shop in the caller's scope. Collections count when they were
+// collected that day by the salesman's user and are not rejected or overdue.
+func (s *AssistantManagerService) GetCollectionSheet(ctx context.Context, tenantID uuid.UUID, date time.Time, shopID *uuid.UUID) (*CollectionSheet, error) {
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	end := start.AddDate(0, 0, 1)
+	shopScope := scope.FromContext(ctx)
+	db := s.db.DB.WithContext(ctx)
+
+	sheet := &CollectionSheet{
+		Date:        start.Format("2006-01-02"),
+		ShopID:      shopID,
+		Salesmen:    []*CollectionSheetRow{},
+		GeneratedAt: time.Now(),
+	}
+
+	if shopID != nil {
+		var shop models.Shop
+		if err := db.Where("id = ? AND tenant_id = ?", *shopID, tenantID).First(&shop).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("shop not found")
+			}
+			return nil, fmt.Errorf("failed to get shop: %w", err)
+		}
+		sheet.ShopName = shop.Name
+	}
+
+	forShop := func(query *gorm.DB, column string) *gorm.DB {
+		if shopID != nil {
+			query = query.Where(column+" = ?", *shopID)
+		}
+		return shopScope.Apply(query, column)
+	}
+
+	// Approved sales split by payment method
+	var fromSales []salesmanSalesSplit
+	salesQuery := db.Model(&models.Sale{}).
+		Select(`salesman_id,
+			COALESCE(SUM(total_amount), 0) as sales_amount,
+			COALESCE(SUM(CASE WHEN payment_method = ? THEN paid_amount ELSE 0 END), 0) as cash_amount,
+			COALESCE(SUM(CASE WHEN payment_method = ? THEN paid_amount ELSE 0 END), 0) as card_amount,
+			COALESCE(SUM(CASE WHEN payment_method = ? THEN paid_amount ELSE 0 END), 0) as upi_amount,
+			COALESCE(SUM(due_amount), 0) as credit_amount`,
+			models.PaymentCash, models.PaymentCard, models.PaymentUPI).
+		Where("tenant_id = ? AND status = ? AND sale_date >= ? AND sale_date < ? AND salesman_id IS NOT NULL",
+			tenantID, models.StatusApproved, start, end)
+	if err := forShop(salesQuery, "shop_id").Group("salesman_id").Scan(&fromSales).Error; err != nil {
+		return nil, fmt.Errorf("failed to get salesman sales: %w", err)
+	}
+
+	// Approved daily sales records carry their own split
+	var fromDailySales []salesmanSalesSplit
+	dailyQuery := db.Model(&models.DailySalesRecord{}).
+		Select(`salesman_id,
+			COALESCE(SUM(total_sales_amount), 0) as sales_amount,
+			COALESCE(SUM(total_cash_amount), 0) as cash_amount,
+			COALESCE(SUM(total_card_amount), 0) as card_amount,
+			COALESCE(SUM(total_upi_amount), 0) as upi_amount,
+			COALESCE(SUM(total_credit_amount), 0) as credit_amount`).
+		Where("tenant_id = ? AND status = ? AND record_date >= ? AND record_date < ? AND salesman_id IS NOT NULL",
+			tenantID, models.StatusApproved, start, end)
+	if err := forShop(dailyQuery, "shop_id").Group("salesman_id").Scan(&fromDailySales).Error; err != nil {
+		return nil, fmt.Errorf("failed to get salesman daily sales: %w", err)
+	}
+
+	splits := make(map[uuid.UUID]*salesmanSalesSplit)
+	for _, split := range append(fromSales, fromDailySales...) {
+		total, ok := splits[split.SalesmanID]
+		if !ok {
+			total = &salesmanSalesSplit{SalesmanID: split.SalesmanID}
+			splits[split.SalesmanID] = total
+		}
+		total.SalesAmount += split.SalesAmount
+		total.CashAmount += split.CashAmount
+		total.CardAmount += split.CardAmount
+		total.UpiAmount += split.UpiAmount
+		total.CreditAmount += split.CreditAmount
+	}
+
+	// Active salesmen at the shop(s), plus anyone who sold there that day
+	var salesmen []models.Salesman
+	salesmenQuery := db.Preload("Shop").Where("tenant_id = ? AND is_active = ?", tenantID, true)
+	if err := forShop(salesmenQuery, "shop_id").Find(&salesmen).Error; err != nil {
+		return nil, fmt.Errorf("failed to get salesmen: %w", err)
+	}
+	listed := make(map[uuid.UUID]bool, len(salesmen))
+	for _, salesman := range salesmen {
+		listed[salesman.ID] = true
+	}
+	var missing []uuid.UUID
+	for id := range splits {
+		if !listed[id] {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		var others []models.Salesman
+		if err := db.Preload("Shop").Where("tenant_id = ? AND id IN ?", tenantID, missing).Find(&others).Error; err != nil {
+			return nil, fmt.Errorf("failed to get salesmen: %w", err)
+		}
+		salesmen = append(salesmen, others...)
+	}
+	if len(salesmen) == 0 {
+		return sheet, nil
+	}
+
+	// Recorded collections by the salesmen's users
+	userIDs := make([]uuid.UUID, len(salesmen))
+	for i, salesman := range salesmen {
+		userIDs[i] = salesman.UserID
+	}
+	var collections []struct {
+		ExecutiveID uuid.UUID
+		Status      string
+		Amount      float64
+	}
+	collectionQuery := db.Model(&models.AssistantManagerMoneyCollection{}).
+		Select("executive_id, status, COALESCE(SUM(amount), 0) as amount").
+		Where("tenant_id = ? AND executive_id IN ? AND status IN ? AND collection_date >= ? AND collection_date < ?",
+			tenantID, userIDs, []string{"approved", "pending"}, start, end)
+	if err := forShop(collectionQuery, "shop_id").Group("executive_id, status").Scan(&collections).Error; err != nil {
+		return nil, fmt.Errorf("failed to get salesman collections: %w", err)
+	}
+	approved := make(map[uuid.UUID]float64)
+	pending := make(map[uuid.UUID]float64)
+	for _, c := range collections {
+		if c.Status == "approved" {
+			approved[c.ExecutiveID] += c.Amount
+		} else {
+			pending[c.ExecutiveID] += c.Amount
+		}
+	}
+
+	for _, salesman := range salesmen {
+		row := &CollectionSheetRow{
+			SalesmanID:        salesman.ID,
+			SalesmanName:      salesman.Name,
+			EmployeeID:        salesman.EmployeeID,
+			ShopID:            salesman.ShopID,
+			CollectedApproved: s.round(approved[salesman.UserID]),
+			CollectedPending:  s.round(pending[salesman.UserID]),
+		}
+		if salesman.Shop != nil {
+			row.ShopName = salesman.Shop.Name
+		}
+		if split, ok := splits[salesman.ID]; ok {
+			row.SalesAmount = s.round(split.SalesAmount)
+			row.CashAmount = s.round(split.CashAmount)
+			row.CardAmount = s.round(split.CardAmount)
+			row.UpiAmount = s.round(split.UpiAmount)
+			row.CreditAmount = s.round(split.CreditAmount)
+		}
+		row.ExpectedCollection = row.CashAmount
+		row.Collected = s.round(row.CollectedApproved + row.CollectedPending)
+		row.Variance = s.round(row.Collected - row.ExpectedCollection)
+
+		sheet.Salesmen = append(sheet.Salesmen, row)
+
+		totals := &sheet.Totals
+		totals.SalesAmount = s.round(totals.SalesAmount + row.SalesAmount)
+		totals.CashAmount = s.round(totals.CashAmount + row.CashAmount)
+		totals.CardAmount = s.round(totals.CardAmount + row.CardAmount)
+		totals.UpiAmount = s.round(totals.UpiAmount + row.UpiAmount)
+		totals.CreditAmount = s.round(totals.CreditAmount + row.CreditAmount)
+		totals.ExpectedCollection = s.round(totals.ExpectedCollection + row.ExpectedCollection)
+		totals.CollectedApproved = s.round(totals.CollectedApproved + row.CollectedApproved)
+		totals.CollectedPending = s.round(totals.CollectedPending + row.CollectedPending)
+		totals.Collected = s.round(totals.Collected + row.Collected)
+		totals.Variance = s.round(totals.Variance + row.Variance)
+	}
+
+	sort.Slice(sheet.Salesmen, func(i, j int) bool {
+		a, b := sheet.Salesmen[i], sheet.Salesmen[j]
+		if a.ShopName != b.ShopName {
+			return a.ShopName < b.ShopName
+		}
+		return a.SalesmanName < b.SalesmanName
+	})
+
+	return sheet, nil
+}
+
+// RenderCollectionSheetPDF lays the collection sheet out for printing, with a
+// signature line per salesman for the cash handover
+func (s *AssistantManagerService) RenderCollectionSheetPDF(sheet *CollectionSheet) []byte {
+	doc := pdf.New("Collection Sheet " + sheet.Date)
+
+	doc.Heading("SALESMAN COLLECTION SHEET")
+	doc.Line("Date: %s", sheet.Date)
+	if sheet.ShopName != "" {
+		doc.Line("Shop: %s", sheet.ShopName)
+	}
+	doc.Line("Generated: %s", sheet.GeneratedAt.Format("2006-01-02 15:04"))
+	doc.Blank()
+
+	header := fmt.Sprintf("%-20s %-12s %11s %11s %11s %11s %11s", "Salesman", "Shop", "Sales", "Expected", "Collected", "Pending", "Variance")
+	doc.Heading(header)
+	doc.Rule()
+	for _, row := range sheet.Salesmen {
+		doc.Line("%-20.20s %-12.12s %11.2f %11.2f %11.2f %11.2f %11.2f",
+			row.SalesmanName, row.ShopName, row.SalesAmount, row.ExpectedCollection,
+			row.CollectedApproved, row.CollectedPending, row.Variance)
+		doc.Line("  %-20.20s Cash %.2f  Card %.2f  UPI %.2f  Credit %.2f",
+			row.EmployeeID, row.CashAmount, row.CardAmount, row.UpiAmount, row.CreditAmount)
+		doc.Line("  Handed over: ____________   Salesman: ______________   Supervisor: ______________")
+		doc.Blank()
+	}
+	doc.Rule()
+	doc.Heading(fmt.Sprintf("%-33s %11.2f %11.2f %11.2f %11.2f %11.2f", "TOTAL",
+		sheet.Totals.SalesAmount, sheet.Totals.ExpectedCollection, sheet.Totals.CollectedApproved,
+		sheet.Totals.CollectedPending, sheet.Totals.Variance))
+
+	return doc.Bytes()
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/approval"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PendingExpenseSummary counts the expenses waiting for approval
+type PendingExpenseSummary struct {
+	Count     int64   `json:"count"`
+	Amount    float64 `json:"amount"`
+	Threshold float64 `json:"threshold"` // expenses above this need approval
+}
+
+// requiresApproval reports whether an expense of amount must wait for approval
+// under the tenant's expense approval threshold
+func (s *ExpenseService) requiresApproval(ctx context.Context, tenantID uuid.UUID, amount float64) bool {
+	if s.settings == nil {
+		return true
+	}
+	return amount > s.settings.GetFloat(ctx, tenantID, settings.KeyExpenseApprovalThreshold)
+}
+
+// ApproveExpense approves a pending expense, recording who approved it and when
+func (s *ExpenseService) ApproveExpense(ctx context.Context, id, tenantID, userID uuid.UUID) (*ExpenseResponse, error) {
+	return s.decideExpense(ctx, id, tenantID, userID, models.StatusApproved, "")
+}
+
+// RejectExpense rejects a pending expense with an optional reason
+func (s *ExpenseService) RejectExpense(ctx context.Context, id, tenantID, userID uuid.UUID, reason string) (*ExpenseResponse, error) {
+	return s.decideExpense(ctx, id, tenantID, userID, models.StatusRejected, reason)
+}
+
+// decideExpense moves a pending expense to approved or rejected. The expense is
+// locked so two approvers acting at once cannot both decide it.
+func (s *ExpenseService) decideExpense(ctx context.Context, id, tenantID, userID uuid.UUID, status, reason string) (*ExpenseResponse, error) {
+	err := s.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var expense models.Expense
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND tenant_id = ?", id, tenantID).
+			First(&expense).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("expense not found")
+			}
+			return fmt.Errorf("failed to get expense: %w", err)
+		}
+		if err := approval.Decide("expense", expense.Status, status); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"status":         status,
+			"approved_at":    &now,
+			"approved_by_id": &userID,
+		}
+		if status == models.StatusRejected {
+			updates["rejection_reason"] = reason
+		}
+		if err := tx.Model(&expense).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update expense: %w", err)
+		}
+		return nil
+	})
+	if err != nil && !approval.Replay(ctx, s.settings, tenantID, err) {
+		return nil, err
+	}
+
+	// Clear cache
+	cacheKey := fmt.Sprintf("expenses:tenant:%s", tenantID.String())
+	s.cache.Delete(ctx, cacheKey)
+	s.invalidateCategoryCache(ctx, tenantID)
+	s.cache.InvalidateDashboard(ctx, tenantID.String())
+
+	return s.GetExpenseByID(ctx, id, tenantID)
+}
+
+// GetPendingExpenseSummary counts the tenant's expenses awaiting approval, limited
+// to one shop when shopID is set
+func (s *ExpenseService) GetPendingExpenseSummary(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID) (*PendingExpenseSummary, error) {
+	query := s.db.DB.WithContext(ctx).Model(&models.Expense{}).
+		Where("tenant_id = ? AND status = ?", tenantID, models.StatusPending)
+	if shopID != nil {
+		query = query.Where("shop_id = ?", *shopID)
+	}
+
+	summary := &PendingExpenseSummary{}
+	if err := query.
+		Select("COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount").
+		Scan(summary).Error; err != nil {
+		return nil, fmt.Errorf("failed to count pending expenses: %w", err)
+	}
+	if s.settings != nil {
+		summary.Threshold = s.settings.GetFloat(ctx, tenantID, settings.KeyExpenseApprovalThreshold)
+	}
+	return summary, nil
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/storage"
+	"gorm.io/gorm"
+)
+
+// allowedAttachmentTypes are the receipt formats accepted, by sniffed content type
+var allowedAttachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+type ExpenseAttachmentResponse struct {
+	ID           uuid.UUID `json:"id"`
+	ExpenseID    uuid.UUID `json:"expense_id"`
+	FileName     string    `json:"file_name"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	UploadedByID uuid.UUID `json:"uploaded_by_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// MaxAttachmentSize is the largest attachment accepted, in bytes
+func (s *ExpenseService) MaxAttachmentSize() int64 {
+	return s.maxAttachmentSize
+}
+
+// AddExpenseAttachment stores a receipt or bill for an expense. The content type is
+// taken from the file contents rather than trusted from the client, and only images
+// and PDFs up to the configured size are accepted.
+func (s *ExpenseService) AddExpenseAttachment(ctx context.Context, expenseID, tenantID, userID uuid.UUID, fileName string, file io.Reader) (*ExpenseAttachmentResponse, error) {
+	var expense models.Expense
+	if err := s.db.DB.WithContext(ctx).Where("id = ? AND tenant_id = ?", expenseID, tenantID).First(&expense).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("expense not found")
+		}
+		return nil, fmt.Errorf("failed to get expense: %w", err)
+	}
+
+	reader := bufio.NewReaderSize(file, 512)
+	head, err := reader.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	if len(head) == 0 {
+		return nil, fmt.Errorf("attachment is empty")
+	}
+	contentType := http.DetectContentType(head)
+	if !allowedAttachmentTypes[contentType] {
+		return nil, fmt.Errorf("attachment type %s is not allowed; upload a JPEG, PNG, WebP or PDF file", contentType)
+	}
+
+	attachment := models.ExpenseAttachment{
+		TenantModel: models.TenantModel{
+			BaseModel: models.BaseModel{ID: uuid.New()},
+			TenantID:  tenantID,
+		},
+		ExpenseID:    expenseID,
+		FileName:     sanitizeFileName(fileName),
+		ContentType:  contentType,
+		UploadedByID: userID,
+	}
+	attachment.StorageKey = fmt.Sprintf("%s/expenses/%s/%s", tenantID, expenseID, attachment.ID)
+
+	// Read one byte past the limit so an oversized file is caught without trusting
+	// the declared size
+	limited := io.LimitReader(reader, s.maxAttachmentSize+1)
+	size, err := s.attachments.Put(ctx, attachment.StorageKey, limited)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store attachment: %w", err)
+	}
+	if size > s.maxAttachmentSize {
+		s.attachments.Delete(ctx, attachment.StorageKey)
+		return nil, fmt.Errorf("attachment is larger than the %d byte limit", s.maxAttachmentSize)
+	}
+	attachment.Size = size
+
+	if err := s.db.DB.WithContext(ctx).Create(&attachment).Error; err != nil {
+		s.attachments.Delete(ctx, attachment.StorageKey)
+		return nil, fmt.Errorf("failed to record attachment: %w", err)
+	}
+
+	return buildExpenseAttachmentResponse(attachment), nil
+}
+
+// GetExpenseAttachments lists the files attached to an expense, oldest first
+func (s *ExpenseService) GetExpenseAttachments(ctx context.Context, expenseID, tenantID uuid.UUID) ([]ExpenseAttachmentResponse, error) {
+	var count int64
+	if err := s.db.DB.WithContext(ctx).Model(&models.Expense{}).
+		Where("id = ? AND tenant_id = ?", expenseID, tenantID).
+		Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to get expense: %w", err)
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("expense not found")
+	}
+
+	var attachments []models.ExpenseAttachment
+	if err := s.db.DB.WithContext(ctx).
+		Where("expense_id = ? AND tenant_id = ?", expenseID, tenantID).
+		Order("created_at ASC").
+		Find(&attachments).Error; err != nil {
+		return nil, fmt.Errorf("failed to get attachments: %w", err)
+	}
+
+	responses := make([]ExpenseAttachmentResponse, len(attachments))
+	for i, attachment := range attachments {
+		responses[i] = *buildExpenseAttachmentResponse(attachment)
+	}
+	return responses, nil
+}
+
+// OpenExpenseAttachment returns an attachment's details and its contents. The
+// caller must close the reader.
+func (s *ExpenseService) OpenExpenseAttachment(ctx context.Context, expenseID, attachmentID, tenantID uuid.UUID) (*ExpenseAttachmentResponse, io.ReadCloser, error) {
+	var attachment models.ExpenseAttachment
+	if err := s.db.DB.WithContext(ctx).
+		Where("id = ? AND expense_id = ? AND tenant_id = ?", attachmentID, expenseID, tenantID).
+		First(&attachment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, fmt.Errorf("attachment not found")
+		}
+		return nil, nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+
+	file, err := s.attachments.Open(ctx, attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, fmt.Errorf("attachment not found")
+		}
+		return nil, nil, fmt.Errorf("failed to open attachment: %w", err)
+	}
+
+	return buildExpenseAttachmentResponse(attachment), file, nil
+}
+
+// sanitizeFileName keeps only the base name of an uploaded file so it is safe to
+// echo back in a Content-Disposition header
+func sanitizeFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 32 || r == '"' || r == 127 {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	return name
+}
+
+func buildExpenseAttachmentResponse(attachment models.ExpenseAttachment) *ExpenseAttachmentResponse {
+	return &ExpenseAttachmentResponse{
+		ID:           attachment.ID,
+		ExpenseID:    attachment.ExpenseID,
+		FileName:     attachment.FileName,
+		ContentType:  attachment.ContentType,
+		Size:         attachment.Size,
+		UploadedByID: attachment.UploadedByID,
+		CreatedAt:    attachment.CreatedAt,
+	}
+}
@@ -3,24 +3,34 @@ package services
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/liquorpro/go-backend/pkg/shared/cache"
 	"github.com/liquorpro/go-backend/pkg/shared/database"
 	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
+	"github.com/liquorpro/go-backend/pkg/shared/storage"
 	"gorm.io/gorm"
 )
 
 type ExpenseService struct {
-	db    *database.DB
-	cache *cache.Cache
+	db                *database.DB
+	cache             *cache.Cache
+	settings          *settings.Service
+	attachments       storage.Store
+	maxAttachmentSize int64
 }
 
-func NewExpenseService(db *database.DB, cache *cache.Cache) *ExpenseService {
+func NewExpenseService(db *database.DB, cache *cache.Cache, settingsService *settings.Service, attachments storage.Store, maxAttachmentSize int64) *ExpenseService {
 	return &ExpenseService{
-		db:    db,
-		cache: cache,
+		db:                db,
+		cache:             cache,
+		settings:          settingsService,
+		attachments:       attachments,
+		maxAttachmentSize: maxAttachmentSize,
 	}
 }
 
@@ -37,22 +47,27 @@ type ExpenseRequest struct {
 }
 
 type ExpenseResponse struct {
-	ID            uuid.UUID `json:"id"`
-	CategoryID    uuid.UUID `json:"category_id"`
-	CategoryName  string    `json:"category_name"`
-	ShopID        uuid.UUID `json:"shop_id"`
-	ShopName      string    `json:"shop_name"`
-	Amount        float64   `json:"amount"`
-	Description   string    `json:"description"`
-	ExpenseDate   time.Time `json:"expense_date"`
-	ReceiptNo     string    `json:"receipt_no"`
-	PaymentMethod string    `json:"payment_method"`
-	VendorID      *uuid.UUID `json:"vendor_id"`
-	VendorName    string    `json:"vendor_name,omitempty"`
-	Notes         string    `json:"notes"`
-	CreatedBy     uuid.UUID `json:"created_by"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID              uuid.UUID  `json:"id"`
+	CategoryID      uuid.UUID  `json:"category_id"`
+	CategoryName    string     `json:"category_name"`
+	ShopID          uuid.UUID  `json:"shop_id"`
+	ShopName        string     `json:"shop_name"`
+	Amount          float64    `json:"amount"`
+	Description     string     `json:"description"`
+	ExpenseDate     time.Time  `json:"expense_date"`
+	ReceiptNo       string     `json:"receipt_no"`
+	PaymentMethod   string     `json:"payment_method"`
+	VendorID        *uuid.UUID `json:"vendor_id"`
+	VendorName      string     `json:"vendor_name,omitempty"`
+	Notes           string     `json:"notes"`
+	Status          string     `json:"status"`
+	ApprovedAt      *time.Time `json:"approved_at"`
+	ApprovedByID    *uuid.UUID `json:"approved_by_id"`
+	AutoApproved    bool       `json:"auto_approved"`
+	RejectionReason string     `json:"rejection_reason,omitempty"`
+	CreatedBy       uuid.UUID  `json:"created_by"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
 type ExpenseCategoryRequest struct {
@@ -153,9 +168,18 @@ func (s *ExpenseService) CreateExpense(ctx context.Context, req ExpenseRequest,
 		PaymentMethod: req.PaymentMethod,
 		VendorID:      req.VendorID,
 		Notes:         req.Notes,
+		Status:        models.StatusPending,
 		CreatedByID:   userID,
 	}
 
+	// Expenses within the tenant's threshold do not need anyone to approve them
+	if !s.requiresApproval(ctx, tenantID, expense.Amount) {
+		now := time.Now()
+		expense.Status = models.StatusApproved
+		expense.ApprovedAt = &now
+		expense.AutoApproved = true
+	}
+
 	if err := s.db.DB.Create(&expense).Error; err != nil {
 		return nil, fmt.Errorf("failed to create expense: %w", err)
 	}
@@ -163,6 +187,8 @@ func (s *ExpenseService) CreateExpense(ctx context.Context, req ExpenseRequest,
 	// Clear cache
 	cacheKey := fmt.Sprintf("expenses:tenant:%s", tenantID.String())
 	s.cache.Delete(ctx, cacheKey)
+	s.invalidateCategoryCache(ctx, tenantID)
+	s.cache.InvalidateDashboard(ctx, tenantID.String())
 
 	// Build response
 	response := s.buildExpenseResponse(expense, category.Name, shop.Name, "")
@@ -186,6 +212,7 @@ func (s *ExpenseService) GetExpenses(ctx context.Context, tenantID uuid.UUID, fi
 	if filters.ShopID != nil {
 		query = query.Where("shop_id = ?", *filters.ShopID)
 	}
+	query = scope.FromContext(ctx).Apply(query, "shop_id")
 	if filters.VendorID != nil {
 		query = query.Where("vendor_id = ?", *filters.VendorID)
 	}
@@ -298,6 +325,8 @@ func (s *ExpenseService) UpdateExpense(ctx context.Context, id uuid.UUID, req Ex
 	// Clear cache
 	cacheKey := fmt.Sprintf("expenses:tenant:%s", tenantID.String())
 	s.cache.Delete(ctx, cacheKey)
+	s.invalidateCategoryCache(ctx, tenantID)
+	s.cache.InvalidateDashboard(ctx, tenantID.String())
 
 	return s.GetExpenseByID(ctx, id, tenantID)
 }
@@ -310,6 +339,8 @@ func (s *ExpenseService) DeleteExpense(ctx context.Context, id, tenantID uuid.UU
 	// Clear cache
 	cacheKey := fmt.Sprintf("expenses:tenant:%s", tenantID.String())
 	s.cache.Delete(ctx, cacheKey)
+	s.invalidateCategoryCache(ctx, tenantID)
+	s.cache.InvalidateDashboard(ctx, tenantID.String())
 
 	return nil
 }
@@ -346,61 +377,114 @@ func (s *ExpenseService) CreateExpenseCategory(ctx context.Context, req ExpenseC
 	}
 
 	// Clear cache
-	cacheKey := fmt.Sprintf("expense_categories:tenant:%s", tenantID.String())
-	s.cache.Delete(ctx, cacheKey)
+	s.invalidateCategoryCache(ctx, tenantID)
 
 	return s.buildExpenseCategoryResponse(category, 0, 0), nil
 }
 
-func (s *ExpenseService) GetExpenseCategories(ctx context.Context, tenantID uuid.UUID, includeInactive bool) ([]ExpenseCategoryResponse, error) {
-	cacheKey := fmt.Sprintf("expense_categories:tenant:%s:inactive:%t", tenantID.String(), includeInactive)
-	
+type ExpenseCategoryFilters struct {
+	Search          string
+	IncludeInactive bool
+}
+
+// categoryTotals holds the aggregated expense figures for one category
+type categoryTotals struct {
+	CategoryID   uuid.UUID
+	TotalAmount  float64
+	ExpenseCount int64
+}
+
+type cachedCategoryPage struct {
+	Categories []ExpenseCategoryResponse `json:"categories"`
+	Total      int64                     `json:"total"`
+}
+
+func (s *ExpenseService) GetExpenseCategories(ctx context.Context, tenantID uuid.UUID, filters ExpenseCategoryFilters, limit, offset int) ([]ExpenseCategoryResponse, int64, error) {
+	search := strings.TrimSpace(filters.Search)
+	cacheKey := fmt.Sprintf("expense_categories:tenant:%s:v%d:inactive:%t:search:%s:limit:%d:offset:%d",
+		tenantID.String(), s.categoryCacheVersion(ctx, tenantID), filters.IncludeInactive, strings.ToLower(search), limit, offset)
+
 	// Try to get from cache
-	var cachedCategories []ExpenseCategoryResponse
-	if err := s.cache.Get(ctx, cacheKey, &cachedCategories); err == nil {
-		return cachedCategories, nil
+	var cached cachedCategoryPage
+	if err := s.cache.Get(ctx, cacheKey, &cached); err == nil {
+		return cached.Categories, cached.Total, nil
 	}
 
-	var categories []models.ExpenseCategory
-	query := s.db.DB.Where("tenant_id = ?", tenantID)
-	
-	if !includeInactive {
+	query := s.db.DB.Model(&models.ExpenseCategory{}).Where("tenant_id = ?", tenantID)
+
+	if !filters.IncludeInactive {
 		query = query.Where("is_active = ?", true)
 	}
+	if search != "" {
+		query = query.Where("name ILIKE ?", "%"+search+"%")
+	}
 
-	if err := query.Order("name ASC").Find(&categories).Error; err != nil {
-		return nil, fmt.Errorf("failed to get expense categories: %w", err)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count expense categories: %w", err)
 	}
 
-	// Calculate totals for each category
-	var responses []ExpenseCategoryResponse
+	var categories []models.ExpenseCategory
+	if err := query.Order("name ASC").Limit(limit).Offset(offset).Find(&categories).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get expense categories: %w", err)
+	}
+
+	// Aggregate totals for the whole page in a single grouped query
+	categoryIDs := make([]uuid.UUID, len(categories))
+	for i, category := range categories {
+		categoryIDs[i] = category.ID
+	}
+
+	totalsByCategory := make(map[uuid.UUID]categoryTotals, len(categories))
+	if len(categoryIDs) > 0 {
+		var totals []categoryTotals
+		err := s.db.DB.Model(&models.Expense{}).
+			Select("category_id, COALESCE(SUM(amount), 0) as total_amount, COUNT(*) as expense_count").
+			Where("tenant_id = ? AND category_id IN ?", tenantID, categoryIDs).
+			Group("category_id").
+			Scan(&totals).Error
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get expense category totals: %w", err)
+		}
+		for _, t := range totals {
+			totalsByCategory[t.CategoryID] = t
+		}
+	}
+
+	responses := make([]ExpenseCategoryResponse, 0, len(categories))
 	for _, category := range categories {
-		var totalAmount float64
-		var expenseCount int64
-		
-		s.db.DB.Model(&models.Expense{}).
-			Where("category_id = ? AND tenant_id = ?", category.ID, tenantID).
-			Select("COALESCE(SUM(amount), 0)").
-			Scan(&totalAmount)
-		
-		s.db.DB.Model(&models.Expense{}).
-			Where("category_id = ? AND tenant_id = ?", category.ID, tenantID).
-			Count(&expenseCount)
-
-		response := s.buildExpenseCategoryResponse(category, totalAmount, expenseCount)
+		t := totalsByCategory[category.ID]
+		response := s.buildExpenseCategoryResponse(category, t.TotalAmount, t.ExpenseCount)
 		responses = append(responses, *response)
 	}
 
 	// Cache the result
-	s.cache.Set(ctx, cacheKey, responses, 5*time.Minute) // Cache for 5 minutes
+	s.cache.Set(ctx, cacheKey, cachedCategoryPage{Categories: responses, Total: total}, s.cache.TTLs().Categories)
+
+	return responses, total, nil
+}
+
+// categoryCacheVersion returns the tenant's current category cache generation.
+// Cached pages embed it in their key, so bumping it invalidates every page at once.
+func (s *ExpenseService) categoryCacheVersion(ctx context.Context, tenantID uuid.UUID) int64 {
+	var version int64
+	s.cache.Get(ctx, fmt.Sprintf("expense_categories:tenant:%s:version", tenantID.String()), &version)
+	return version
+}
 
-	return responses, nil
+// invalidateCategoryCache drops all cached category pages for the tenant
+func (s *ExpenseService) invalidateCategoryCache(ctx context.Context, tenantID uuid.UUID) {
+	s.cache.Increment(ctx, fmt.Sprintf("expense_categories:tenant:%s:version", tenantID.String()))
 }
 
 // Summary and Reports
 func (s *ExpenseService) GetExpenseSummary(ctx context.Context, tenantID uuid.UUID, startDate, endDate time.Time) (*ExpenseSummaryResponse, error) {
 	cacheKey := fmt.Sprintf("expense_summary:tenant:%s:start:%s:end:%s", 
 		tenantID.String(), startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	shopScope := scope.FromContext(ctx)
+	if shopScope != nil && shopScope.Restricted {
+		cacheKey = fmt.Sprintf("%s:scope:%s", cacheKey, shopScope.Key())
+	}
 	
 	// Try to get from cache
 	var cachedSummary ExpenseSummaryResponse
@@ -408,7 +492,7 @@ func (s *ExpenseService) GetExpenseSummary(ctx context.Context, tenantID uuid.UU
 		return &cachedSummary, nil
 	}
 
-	query := s.db.DB.Where("tenant_id = ?", tenantID)
+	query := s.db.DB.Where("tenant_id = ?", tenantID).Scopes(shopScope.Filter("shop_id"))
 	if !startDate.IsZero() {
 		query = query.Where("expense_date >= ?", startDate)
 	}
@@ -430,6 +514,7 @@ func (s *ExpenseService) GetExpenseSummary(ctx context.Context, tenantID uuid.UU
 		Select("e.category_id, ec.name as category_name, SUM(e.amount) as amount, COUNT(*) as count").
 		Joins("JOIN expense_categories ec ON e.category_id = ec.id").
 		Where("e.tenant_id = ? AND e.expense_date BETWEEN ? AND ?", tenantID, startDate, endDate).
+		Scopes(shopScope.Filter("e.shop_id")).
 		Group("e.category_id, ec.name").
 		Scan(&categorySummaries)
 	summary.ExpensesByCategory = categorySummaries
@@ -439,6 +524,7 @@ func (s *ExpenseService) GetExpenseSummary(ctx context.Context, tenantID uuid.UU
 	s.db.DB.Table("expenses").
 		Select("payment_method, SUM(amount) as amount, COUNT(*) as count").
 		Where("tenant_id = ? AND expense_date BETWEEN ? AND ?", tenantID, startDate, endDate).
+		Scopes(shopScope.Filter("shop_id")).
 		Group("payment_method").
 		Scan(&paymentMethodSummaries)
 	summary.ExpensesByPaymentMethod = paymentMethodSummaries
@@ -449,6 +535,7 @@ func (s *ExpenseService) GetExpenseSummary(ctx context.Context, tenantID uuid.UU
 		Select("e.shop_id, s.name as shop_name, SUM(e.amount) as amount, COUNT(*) as count").
 		Joins("JOIN shops s ON e.shop_id = s.id").
 		Where("e.tenant_id = ? AND e.expense_date BETWEEN ? AND ?", tenantID, startDate, endDate).
+		Scopes(shopScope.Filter("e.shop_id")).
 		Group("e.shop_id, s.name").
 		Scan(&shopSummaries)
 	summary.ExpensesByShop = shopSummaries
@@ -458,13 +545,14 @@ func (s *ExpenseService) GetExpenseSummary(ctx context.Context, tenantID uuid.UU
 	s.db.DB.Table("expenses").
 		Select("EXTRACT(YEAR FROM expense_date) as year, EXTRACT(MONTH FROM expense_date) as month, SUM(amount) as amount, COUNT(*) as count").
 		Where("tenant_id = ? AND expense_date BETWEEN ? AND ?", tenantID, startDate, endDate).
+		Scopes(shopScope.Filter("shop_id")).
 		Group("EXTRACT(YEAR FROM expense_date), EXTRACT(MONTH FROM expense_date)").
 		Order("year, month").
 		Scan(&monthlySummaries)
 	summary.MonthlyTrend = monthlySummaries
 
 	// Cache the result
-	s.cache.Set(ctx, cacheKey, summary, 600) // Cache for 10 minutes
+	s.cache.Set(ctx, cacheKey, summary, s.cache.TTLs().ExpenseSummary)
 
 	return summary, nil
 }
@@ -478,24 +566,29 @@ func (s *ExpenseService) buildExpenseResponse(expense models.Expense, categoryNa
 	if expense.ShopID != nil {
 		shopID = *expense.ShopID
 	}
-	
+
 	return &ExpenseResponse{
-		ID:            expense.ID,
-		CategoryID:    categoryID,
-		CategoryName:  categoryName,
-		ShopID:        shopID,
-		ShopName:      shopName,
-		Amount:        expense.Amount,
-		Description:   expense.Description,
-		ExpenseDate:   expense.ExpenseDate,
-		ReceiptNo:     expense.ReceiptNo,
-		PaymentMethod: expense.PaymentMethod,
-		VendorID:      expense.VendorID,
-		VendorName:    vendorName,
-		Notes:         expense.Notes,
-		CreatedBy:     expense.CreatedByID,
-		CreatedAt:     expense.CreatedAt,
-		UpdatedAt:     expense.UpdatedAt,
+		ID:              expense.ID,
+		CategoryID:      categoryID,
+		CategoryName:    categoryName,
+		ShopID:          shopID,
+		ShopName:        shopName,
+		Amount:          expense.Amount,
+		Description:     expense.Description,
+		ExpenseDate:     expense.ExpenseDate,
+		ReceiptNo:       expense.ReceiptNo,
+		PaymentMethod:   expense.PaymentMethod,
+		VendorID:        expense.VendorID,
+		VendorName:      vendorName,
+		Notes:           expense.Notes,
+		Status:          expense.Status,
+		ApprovedAt:      expense.ApprovedAt,
+		ApprovedByID:    expense.ApprovedByID,
+		AutoApproved:    expense.AutoApproved,
+		RejectionReason: expense.RejectionReason,
+		CreatedBy:       expense.CreatedByID,
+		CreatedAt:       expense.CreatedAt,
+		UpdatedAt:       expense.UpdatedAt,
 	}
 }
 
@@ -507,21 +600,26 @@ func (s *ExpenseService) buildExpenseResponseFromModel(expense models.Expense) *
 	if expense.ShopID != nil {
 		shopID = *expense.ShopID
 	}
-	
+
 	response := &ExpenseResponse{
-		ID:            expense.ID,
-		CategoryID:    categoryID,
-		ShopID:        shopID,
-		Amount:        expense.Amount,
-		Description:   expense.Description,
-		ExpenseDate:   expense.ExpenseDate,
-		ReceiptNo:     expense.ReceiptNo,
-		PaymentMethod: expense.PaymentMethod,
-		VendorID:      expense.VendorID,
-		Notes:         expense.Notes,
-		CreatedBy:     expense.CreatedByID,
-		CreatedAt:     expense.CreatedAt,
-		UpdatedAt:     expense.UpdatedAt,
+		ID:              expense.ID,
+		CategoryID:      categoryID,
+		ShopID:          shopID,
+		Amount:          expense.Amount,
+		Description:     expense.Description,
+		ExpenseDate:     expense.ExpenseDate,
+		ReceiptNo:       expense.ReceiptNo,
+		PaymentMethod:   expense.PaymentMethod,
+		VendorID:        expense.VendorID,
+		Notes:           expense.Notes,
+		Status:          expense.Status,
+		ApprovedAt:      expense.ApprovedAt,
+		ApprovedByID:    expense.ApprovedByID,
+		AutoApproved:    expense.AutoApproved,
+		RejectionReason: expense.RejectionReason,
+		CreatedBy:       expense.CreatedByID,
+		CreatedAt:       expense.CreatedAt,
+		UpdatedAt:       expense.UpdatedAt,
 	}
 
 	if expense.Category != nil {
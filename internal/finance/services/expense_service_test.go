@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquorpro/go-backend/pkg/shared/cache"
+	"github.com/liquorpro/go-backend/pkg/shared/cache/cachetest"
+	"github.com/liquorpro/go-backend/pkg/shared/database/dbtest"
+)
+
+func TestGetExpenseSummaryCachesForExpenseSummaryTTL(t *testing.T) {
+	c, recorder := cachetest.New(cache.TTLs{ExpenseSummary: 10 * time.Minute})
+	s := NewExpenseService(dbtest.DryRun(t), c, nil, nil, 0)
+	tenantID := uuid.New()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, err := s.GetExpenseSummary(context.Background(), tenantID, start, start.AddDate(0, 1, 0))
+	require.NoError(t, err)
+
+	ttl, err := recorder.TTLWithPrefix("expense_summary:tenant:" + tenantID.String())
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, ttl)
+}
+
+func TestGetExpenseCategoriesCachesForCategoriesTTL(t *testing.T) {
+	c, recorder := cachetest.New(cache.TTLs{Categories: 7 * time.Minute})
+	s := NewExpenseService(dbtest.DryRun(t), c, nil, nil, 0)
+	tenantID := uuid.New()
+
+	_, _, err := s.GetExpenseCategories(context.Background(), tenantID, ExpenseCategoryFilters{}, 20, 0)
+	require.NoError(t, err)
+
+	ttl, err := recorder.TTLWithPrefix("expense_categories:tenant:" + tenantID.String())
+	require.NoError(t, err)
+	assert.Equal(t, 7*time.Minute, ttl)
+}
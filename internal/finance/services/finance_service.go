@@ -0,0 +1,313 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/cache"
+	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FinanceService handles tenant bank accounts and the money moving between them
+type FinanceService struct {
+	db    *database.DB
+	cache *cache.Cache
+}
+
+func NewFinanceService(db *database.DB, cache *cache.Cache) *FinanceService {
+	return &FinanceService{
+		db:    db,
+		cache: cache,
+	}
+}
+
+type BankAccountRequest struct {
+	BankName          string  `json:"bank_name" binding:"required"`
+	AccountNumber     string  `json:"account_number" binding:"required"`
+	IFSCCode          string  `json:"ifsc_code" binding:"required"`
+	AccountHolderName string  `json:"account_holder_name" binding:"required"`
+	AccountType       string  `json:"account_type"`
+	OpeningBalance    float64 `json:"opening_balance" binding:"gte=0"`
+	IsPrimary         bool    `json:"is_primary"`
+}
+
+type BankAccountResponse struct {
+	ID                uuid.UUID `json:"id"`
+	BankName          string    `json:"bank_name"`
+	AccountNumber     string    `json:"account_number"`
+	IFSCCode          string    `json:"ifsc_code"`
+	AccountHolderName string    `json:"account_holder_name"`
+	AccountType       string    `json:"account_type"`
+	CurrentBalance    float64   `json:"current_balance"`
+	IsActive          bool      `json:"is_active"`
+	IsPrimary         bool      `json:"is_primary"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+type BankTransferRequest struct {
+	FromAccountID uuid.UUID `json:"from_account_id" binding:"required"`
+	ToAccountID   uuid.UUID `json:"to_account_id" binding:"required"`
+	Amount        float64   `json:"amount" binding:"required,gt=0"`
+}
+
+type BankTransactionResponse struct {
+	ID              uuid.UUID `json:"id"`
+	BankAccountID   uuid.UUID `json:"bank_account_id"`
+	TransactionType string    `json:"transaction_type"`
+	Amount          float64   `json:"amount"`
+	TransactionDate time.Time `json:"transaction_date"`
+	Description     string    `json:"description"`
+	Reference       string    `json:"reference"`
+	PreviousBalance float64   `json:"previous_balance"`
+	NewBalance      float64   `json:"new_balance"`
+	CreatedByID     uuid.UUID `json:"created_by_id"`
+}
+
+type BankTransferResponse struct {
+	Reference   string                   `json:"reference"`
+	Amount      float64                  `json:"amount"`
+	FromAccount *BankAccountResponse     `json:"from_account"`
+	ToAccount   *BankAccountResponse     `json:"to_account"`
+	Debit       *BankTransactionResponse `json:"debit"`
+	Credit      *BankTransactionResponse `json:"credit"`
+}
+
+// CreateBankAccount creates a bank account, recording any opening balance as a credit
+func (s *FinanceService) CreateBankAccount(ctx context.Context, req BankAccountRequest, tenantID, userID uuid.UUID) (*BankAccountResponse, error) {
+	accountType := req.AccountType
+	if accountType == "" {
+		accountType = "savings"
+	}
+
+	account := models.BankAccount{
+		TenantModel:       models.TenantModel{TenantID: tenantID},
+		BankName:          req.BankName,
+		AccountNumber:     req.AccountNumber,
+		IFSCCode:          req.IFSCCode,
+		AccountHolderName: req.AccountHolderName,
+		AccountType:       accountType,
+		CurrentBalance:    money.FromFloat(req.OpeningBalance).Float64(),
+		IsActive:          true,
+		IsPrimary:         req.IsPrimary,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&account).Error; err != nil {
+			return fmt.Errorf("failed to create bank account: %w", err)
+		}
+
+		if account.CurrentBalance > 0 {
+			opening := models.BankTransaction{
+				TenantModel:     models.TenantModel{TenantID: tenantID},
+				BankAccountID:   account.ID,
+				TransactionType: "credit",
+				Amount:          account.CurrentBalance,
+				TransactionDate: time.Now(),
+				Description:     "Opening balance",
+				PreviousBalance: 0,
+				NewBalance:      account.CurrentBalance,
+				CreatedByID:     userID,
+			}
+			if err := tx.Create(&opening).Error; err != nil {
+				return fmt.Errorf("failed to record opening balance: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.mapBankAccountToResponse(&account), nil
+}
+
+// GetBankAccounts returns the tenant's bank accounts
+func (s *FinanceService) GetBankAccounts(ctx context.Context, tenantID uuid.UUID, includeInactive bool) ([]*BankAccountResponse, error) {
+	query := s.db.DB.Where("tenant_id = ?", tenantID)
+	if !includeInactive {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var accounts []models.BankAccount
+	if err := query.Order("is_primary DESC, bank_name").Find(&accounts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get bank accounts: %w", err)
+	}
+
+	responses := make([]*BankAccountResponse, len(accounts))
+	for i, account := range accounts {
+		responses[i] = s.mapBankAccountToResponse(&account)
+	}
+
+	return responses, nil
+}
+
+// GetBankAccountByID returns a bank account by ID
+func (s *FinanceService) GetBankAccountByID(ctx context.Context, id, tenantID uuid.UUID) (*BankAccountResponse, error) {
+	var account models.BankAccount
+	if err := s.db.DB.Where("id = ? AND tenant_id = ?", id, tenantID).First(&account).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("bank account not found")
+		}
+		return nil, fmt.Errorf("failed to get bank account: %w", err)
+	}
+
+	return s.mapBankAccountToResponse(&account), nil
+}
+
+// TransferBetweenAccounts moves money between two of the tenant's bank accounts.
+// Both rows are locked for the duration of the transaction so concurrent transfers
+// cannot overdraw an account or lose an update.
+func (s *FinanceService) TransferBetweenAccounts(ctx context.Context, fromID, toID uuid.UUID, amount float64, tenantID, userID uuid.UUID) (*BankTransferResponse, error) {
+	if fromID == toID {
+		return nil, fmt.Errorf("cannot transfer to the same account")
+	}
+
+	// Balances are moved in whole paise so a transfer cannot lose or create money
+	transfer := money.FromFloat(amount)
+	amount = transfer.Float64()
+	if transfer <= 0 {
+		return nil, fmt.Errorf("transfer amount must be greater than zero")
+	}
+
+	reference := fmt.Sprintf("TRF-%s-%s", time.Now().Format("20060102150405"), strings.ToUpper(uuid.New().String()[:8]))
+
+	var response *BankTransferResponse
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock in a fixed order so two opposing transfers cannot deadlock
+		lockOrder := []uuid.UUID{fromID, toID}
+		if bytes.Compare(toID[:], fromID[:]) < 0 {
+			lockOrder = []uuid.UUID{toID, fromID}
+		}
+
+		accounts := make(map[uuid.UUID]*models.BankAccount, 2)
+		for _, id := range lockOrder {
+			var account models.BankAccount
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ? AND tenant_id = ?", id, tenantID).
+				First(&account).Error
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("bank account not found")
+				}
+				return fmt.Errorf("failed to lock bank account: %w", err)
+			}
+			if !account.IsActive {
+				return fmt.Errorf("bank account is inactive")
+			}
+			accounts[id] = &account
+		}
+
+		from := accounts[fromID]
+		to := accounts[toID]
+
+		fromBalance := money.FromFloat(from.CurrentBalance)
+		toBalance := money.FromFloat(to.CurrentBalance)
+		if fromBalance < transfer {
+			return fmt.Errorf("insufficient balance")
+		}
+
+		now := time.Now()
+		fromPrevious := fromBalance.Float64()
+		toPrevious := toBalance.Float64()
+		fromNew := fromBalance - transfer
+		toNew := toBalance + transfer
+		from.CurrentBalance = fromNew.Float64()
+		to.CurrentBalance = toNew.Float64()
+
+		if err := tx.Model(from).Update("current_balance", from.CurrentBalance).Error; err != nil {
+			return fmt.Errorf("failed to update source account balance: %w", err)
+		}
+		if err := tx.Model(to).Update("current_balance", to.CurrentBalance).Error; err != nil {
+			return fmt.Errorf("failed to update destination account balance: %w", err)
+		}
+
+		debit := models.BankTransaction{
+			TenantModel:     models.TenantModel{TenantID: tenantID},
+			BankAccountID:   from.ID,
+			TransactionType: "debit",
+			Amount:          amount,
+			TransactionDate: now,
+			Description:     fmt.Sprintf("Transfer to %s %s", to.BankName, to.AccountNumber),
+			Reference:       reference,
+			PreviousBalance: fromPrevious,
+			NewBalance:      from.CurrentBalance,
+			CreatedByID:     userID,
+		}
+		if err := tx.Create(&debit).Error; err != nil {
+			return fmt.Errorf("failed to record debit transaction: %w", err)
+		}
+
+		credit := models.BankTransaction{
+			TenantModel:     models.TenantModel{TenantID: tenantID},
+			BankAccountID:   to.ID,
+			TransactionType: "credit",
+			Amount:          amount,
+			TransactionDate: now,
+			Description:     fmt.Sprintf("Transfer from %s %s", from.BankName, from.AccountNumber),
+			Reference:       reference,
+			PreviousBalance: toPrevious,
+			NewBalance:      to.CurrentBalance,
+			CreatedByID:     userID,
+		}
+		if err := tx.Create(&credit).Error; err != nil {
+			return fmt.Errorf("failed to record credit transaction: %w", err)
+		}
+
+		response = &BankTransferResponse{
+			Reference:   reference,
+			Amount:      amount,
+			FromAccount: s.mapBankAccountToResponse(from),
+			ToAccount:   s.mapBankAccountToResponse(to),
+			Debit:       s.mapBankTransactionToResponse(&debit),
+			Credit:      s.mapBankTransactionToResponse(&credit),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (s *FinanceService) mapBankAccountToResponse(account *models.BankAccount) *BankAccountResponse {
+	return &BankAccountResponse{
+		ID:                account.ID,
+		BankName:          account.BankName,
+		AccountNumber:     account.AccountNumber,
+		IFSCCode:          account.IFSCCode,
+		AccountHolderName: account.AccountHolderName,
+		AccountType:       account.AccountType,
+		CurrentBalance:    account.CurrentBalance,
+		IsActive:          account.IsActive,
+		IsPrimary:         account.IsPrimary,
+		CreatedAt:         account.CreatedAt,
+		UpdatedAt:         account.UpdatedAt,
+	}
+}
+
+func (s *FinanceService) mapBankTransactionToResponse(txn *models.BankTransaction) *BankTransactionResponse {
+	return &BankTransactionResponse{
+		ID:              txn.ID,
+		BankAccountID:   txn.BankAccountID,
+		TransactionType: txn.TransactionType,
+		Amount:          txn.Amount,
+		TransactionDate: txn.TransactionDate,
+		Description:     txn.Description,
+		Reference:       txn.Reference,
+		PreviousBalance: txn.PreviousBalance,
+		NewBalance:      txn.NewBalance,
+		CreatedByID:     txn.CreatedByID,
+	}
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
+)
+
+// overdueDigest is the overdue collections one recipient is told about
+type overdueDigest struct {
+	user        models.User
+	collections []models.AssistantManagerMoneyCollection
+}
+
+// notifyOverdueCollections emails each tenant's managers one digest of the
+// collections that just went overdue. Regional managers only hear about shops in
+// their shop group; tenant managers hear about every shop. Shops no manager covers
+// fall back to the tenant's admins. Failures are logged and never affect the
+// overdue transition itself.
+func (s *AssistantManagerService) notifyOverdueCollections(expired []models.AssistantManagerMoneyCollection) {
+	if s.notifier == nil {
+		return
+	}
+	ctx := context.Background()
+
+	byTenant := make(map[uuid.UUID][]models.AssistantManagerMoneyCollection)
+	for _, collection := range expired {
+		byTenant[collection.TenantID] = append(byTenant[collection.TenantID], collection)
+	}
+
+	for tenantID, collections := range byTenant {
+		if !s.settings.GetBool(ctx, tenantID, settings.KeyOverdueCollectionEmails) {
+			continue
+		}
+		if err := s.sendOverdueDigests(ctx, tenantID, collections); err != nil {
+			fmt.Printf("Warning: Failed to send overdue collection notifications: %v\n", err)
+		}
+	}
+}
+
+// sendOverdueDigests works out who should hear about each collection and sends
+// every recipient a single digest
+func (s *AssistantManagerService) sendOverdueDigests(ctx context.Context, tenantID uuid.UUID, collections []models.AssistantManagerMoneyCollection) error {
+	var managers []models.User
+	if err := s.db.WithContext(ctx).
+		Where("tenant_id = ? AND role IN ? AND is_active = ?", tenantID,
+			[]string{models.RoleManager, models.RoleRegionalManager}, true).
+		Find(&managers).Error; err != nil {
+		return fmt.Errorf("failed to get managers: %w", err)
+	}
+
+	// Shops each regional manager covers; tenant managers cover every shop
+	resolver := scope.NewResolver(s.db)
+	groupShops := make(map[uuid.UUID]map[uuid.UUID]bool)
+	for _, manager := range managers {
+		if manager.Role != models.RoleRegionalManager || manager.ShopGroupID == nil {
+			continue
+		}
+		if _, ok := groupShops[*manager.ShopGroupID]; ok {
+			continue
+		}
+		shopIDs, err := resolver.GroupShopIDs(ctx, tenantID, *manager.ShopGroupID)
+		if err != nil {
+			return err
+		}
+		shops := make(map[uuid.UUID]bool, len(shopIDs))
+		for _, shopID := range shopIDs {
+			shops[shopID] = true
+		}
+		groupShops[*manager.ShopGroupID] = shops
+	}
+
+	digests := make(map[uuid.UUID]*overdueDigest)
+	var uncovered []models.AssistantManagerMoneyCollection
+	for _, collection := range collections {
+		covered := false
+		for _, manager := range managers {
+			if manager.Role == models.RoleRegionalManager {
+				if manager.ShopGroupID == nil || !groupShops[*manager.ShopGroupID][collection.ShopID] {
+					continue
+				}
+			}
+			addToDigest(digests, manager, collection)
+			covered = true
+		}
+		if !covered {
+			uncovered = append(uncovered, collection)
+		}
+	}
+
+	if len(uncovered) > 0 {
+		var admins []models.User
+		if err := s.db.WithContext(ctx).
+			Where("tenant_id = ? AND role = ? AND is_active = ?", tenantID, models.RoleAdmin, true).
+			Find(&admins).Error; err != nil {
+			return fmt.Errorf("failed to get admins: %w", err)
+		}
+		for _, admin := range admins {
+			for _, collection := range uncovered {
+				addToDigest(digests, admin, collection)
+			}
+		}
+	}
+
+	if len(digests) == 0 {
+		return nil
+	}
+
+	executiveIDs := make([]uuid.UUID, 0, len(collections))
+	shopIDs := make([]uuid.UUID, 0, len(collections))
+	for _, collection := range collections {
+		executiveIDs = append(executiveIDs, collection.ExecutiveID)
+		shopIDs = append(shopIDs, collection.ShopID)
+	}
+
+	var executives []models.User
+	if err := s.db.WithContext(ctx).Where("id IN ? AND tenant_id = ?", executiveIDs, tenantID).Find(&executives).Error; err != nil {
+		return fmt.Errorf("failed to get executives: %w", err)
+	}
+	executiveNames := make(map[uuid.UUID]string, len(executives))
+	for _, executive := range executives {
+		executiveNames[executive.ID] = executive.FullName()
+	}
+
+	var shops []models.Shop
+	if err := s.db.WithContext(ctx).Unscoped().Where("id IN ? AND tenant_id = ?", shopIDs, tenantID).Find(&shops).Error; err != nil {
+		return fmt.Errorf("failed to get shops: %w", err)
+	}
+	shopNames := make(map[uuid.UUID]string, len(shops))
+	for _, shop := range shops {
+		shopNames[shop.ID] = shop.Name
+	}
+
+	var tenant models.Tenant
+	s.db.WithContext(ctx).Select("name").Where("id = ?", tenantID).First(&tenant)
+
+	now := time.Now()
+	for _, digest := range digests {
+		sort.Slice(digest.collections, func(i, j int) bool {
+			return digest.collections[i].DeadlineAt.Before(digest.collections[j].DeadlineAt)
+		})
+
+		items := make([]map[string]interface{}, len(digest.collections))
+		total := 0.0
+		for i, collection := range digest.collections {
+			items[i] = map[string]interface{}{
+				"executive_name": executiveNames[collection.ExecutiveID],
+				"shop_name":      shopNames[collection.ShopID],
+				"amount":         fmt.Sprintf("%.2f", collection.Amount),
+				"overdue_for":    formatOverdue(now.Sub(collection.DeadlineAt)),
+			}
+			total += collection.Amount
+		}
+
+		vars := map[string]interface{}{
+			"manager_name": digest.user.FullName(),
+			"count":        len(items),
+			"collections":  items,
+			"total_amount": fmt.Sprintf("%.2f", total),
+			"company_name": tenant.Name,
+		}
+		if err := s.notifier.Send(ctx, tenantID, models.EmailEventCollectionOverdue, []string{digest.user.Email}, vars); err != nil {
+			fmt.Printf("Warning: Failed to send overdue collection digest to %s: %v\n", digest.user.Email, err)
+		}
+	}
+	return nil
+}
+
+// addToDigest adds a collection to a user's digest
+func addToDigest(digests map[uuid.UUID]*overdueDigest, user models.User, collection models.AssistantManagerMoneyCollection) {
+	digest, ok := digests[user.ID]
+	if !ok {
+		digest = &overdueDigest{user: user}
+		digests[user.ID] = digest
+	}
+	digest.collections = append(digest.collections, collection)
+}
+
+// formatOverdue renders how long a collection has been overdue, to the minute
+func formatOverdue(d time.Duration) string {
+	if d < time.Minute {
+		return "less than a minute"
+	}
+	d = d.Round(time.Minute)
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	if hours == 0 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %dm", hours, minutes)
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// OverdueCollectionWorker periodically expires pending money collections whose
+// approval deadline has passed, so they do not sit in "pending" until someone
+// happens to load them
+type OverdueCollectionWorker struct {
+	service  *AssistantManagerService
+	interval time.Duration
+}
+
+// NewOverdueCollectionWorker creates a worker that sweeps at the given interval
+func NewOverdueCollectionWorker(service *AssistantManagerService, interval time.Duration) *OverdueCollectionWorker {
+	return &OverdueCollectionWorker{
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Start runs the sweep in the background until ctx is cancelled
+func (w *OverdueCollectionWorker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				w.sweep(ctx)
+			}
+		}
+	}()
+}
+
+func (w *OverdueCollectionWorker) sweep(ctx context.Context) {
+	expired, err := w.service.ExpireOverdueCollections(ctx)
+	if err != nil {
+		log.Printf("Overdue collection sweep failed: %v", err)
+		return
+	}
+	log.Printf("Overdue collection sweep expired %d collection(s)", expired)
+}
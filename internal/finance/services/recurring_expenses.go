@@ -0,0 +1,328 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RecurringExpenseRequest struct {
+	CategoryID    uuid.UUID  `json:"category_id" binding:"required"`
+	ShopID        uuid.UUID  `json:"shop_id" binding:"required"`
+	VendorID      *uuid.UUID `json:"vendor_id"`
+	Amount        float64    `json:"amount" binding:"required,gt=0"`
+	Description   string     `json:"description" binding:"required"`
+	PaymentMethod string     `json:"payment_method" binding:"required"`
+	Notes         string     `json:"notes"`
+	Frequency     string     `json:"frequency" binding:"required,oneof=daily weekly monthly"`
+	StartDate     time.Time  `json:"start_date" binding:"required"`
+	EndDate       *time.Time `json:"end_date"`
+}
+
+type RecurringExpenseResponse struct {
+	ID            uuid.UUID  `json:"id"`
+	CategoryID    uuid.UUID  `json:"category_id"`
+	CategoryName  string     `json:"category_name"`
+	ShopID        uuid.UUID  `json:"shop_id"`
+	ShopName      string     `json:"shop_name"`
+	VendorID      *uuid.UUID `json:"vendor_id"`
+	Amount        float64    `json:"amount"`
+	Description   string     `json:"description"`
+	PaymentMethod string     `json:"payment_method"`
+	Notes         string     `json:"notes"`
+	Frequency     string     `json:"frequency"`
+	StartDate     time.Time  `json:"start_date"`
+	NextRunDate   time.Time  `json:"next_run_date"`
+	EndDate       *time.Time `json:"end_date"`
+	LastRunDate   *time.Time `json:"last_run_date"`
+	IsActive      bool       `json:"is_active"`
+	CreatedBy     uuid.UUID  `json:"created_by"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// CreateRecurringExpense sets up an expense that is raised automatically on a daily,
+// weekly or monthly schedule from StartDate until EndDate, if one is given
+func (s *ExpenseService) CreateRecurringExpense(ctx context.Context, req RecurringExpenseRequest, tenantID, userID uuid.UUID) (*RecurringExpenseResponse, error) {
+	if req.EndDate != nil && req.EndDate.Before(req.StartDate) {
+		return nil, fmt.Errorf("end date cannot be before the start date")
+	}
+
+	var category models.ExpenseCategory
+	if err := s.db.DB.Where("id = ? AND tenant_id = ?", req.CategoryID, tenantID).First(&category).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("expense category not found")
+		}
+		return nil, fmt.Errorf("failed to validate category: %w", err)
+	}
+
+	var shop models.Shop
+	if err := s.db.DB.Where("id = ? AND tenant_id = ?", req.ShopID, tenantID).First(&shop).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("shop not found")
+		}
+		return nil, fmt.Errorf("failed to validate shop: %w", err)
+	}
+
+	if req.VendorID != nil {
+		var vendor models.Vendor
+		if err := s.db.DB.Where("id = ? AND tenant_id = ?", *req.VendorID, tenantID).First(&vendor).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("vendor not found")
+			}
+			return nil, fmt.Errorf("failed to validate vendor: %w", err)
+		}
+	}
+
+	recurring := models.RecurringExpense{
+		TenantModel: models.TenantModel{
+			BaseModel: models.BaseModel{ID: uuid.New()},
+			TenantID:  tenantID,
+		},
+		CategoryID:    &req.CategoryID,
+		ShopID:        &req.ShopID,
+		VendorID:      req.VendorID,
+		Description:   req.Description,
+		Amount:        req.Amount,
+		PaymentMethod: req.PaymentMethod,
+		Notes:         req.Notes,
+		Frequency:     req.Frequency,
+		StartDate:     req.StartDate,
+		NextRunDate:   req.StartDate,
+		EndDate:       req.EndDate,
+		IsActive:      true,
+		CreatedByID:   userID,
+	}
+
+	if err := s.db.DB.WithContext(ctx).Create(&recurring).Error; err != nil {
+		return nil, fmt.Errorf("failed to create recurring expense: %w", err)
+	}
+
+	recurring.Category = &category
+	recurring.Shop = &shop
+	return buildRecurringExpenseResponse(recurring), nil
+}
+
+// GetRecurringExpenses lists the tenant's recurring expenses, soonest run first
+func (s *ExpenseService) GetRecurringExpenses(ctx context.Context, tenantID uuid.UUID, includeInactive bool) ([]RecurringExpenseResponse, error) {
+	query := s.db.DB.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if !includeInactive {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var recurring []models.RecurringExpense
+	if err := query.
+		Preload("Category").
+		Preload("Shop").
+		Order("next_run_date ASC").
+		Find(&recurring).Error; err != nil {
+		return nil, fmt.Errorf("failed to get recurring expenses: %w", err)
+	}
+
+	responses := make([]RecurringExpenseResponse, len(recurring))
+	for i := range recurring {
+		responses[i] = *buildRecurringExpenseResponse(recurring[i])
+	}
+	return responses, nil
+}
+
+// DisableRecurringExpense stops a recurring expense from raising any more expenses.
+// Expenses it has already raised are left as they are.
+func (s *ExpenseService) DisableRecurringExpense(ctx context.Context, id, tenantID uuid.UUID) error {
+	result := s.db.DB.WithContext(ctx).Model(&models.RecurringExpense{}).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		Update("is_active", false)
+	if result.Error != nil {
+		return fmt.Errorf("failed to disable recurring expense: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("recurring expense not found")
+	}
+	return nil
+}
+
+// GenerateDueRecurringExpenses raises a pending expense for every scheduled run of an
+// active recurring expense that has come due, catching up on any runs missed while
+// the job was not running. Recurring expenses past their end date are deactivated.
+func (s *ExpenseService) GenerateDueRecurringExpenses(ctx context.Context) (int, error) {
+	now := time.Now()
+
+	var dueIDs []uuid.UUID
+	if err := s.db.DB.WithContext(ctx).Model(&models.RecurringExpense{}).
+		Where("is_active = ? AND next_run_date <= ?", true, now).
+		Pluck("id", &dueIDs).Error; err != nil {
+		return 0, fmt.Errorf("failed to get due recurring expenses: %w", err)
+	}
+
+	generated := 0
+	for _, id := range dueIDs {
+		var tenantID uuid.UUID
+		count := 0
+		err := s.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			// Skip templates another instance is already working through
+			var recurring models.RecurringExpense
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("id = ? AND is_active = ? AND next_run_date <= ?", id, true, now).
+				First(&recurring).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil
+				}
+				return fmt.Errorf("failed to lock recurring expense: %w", err)
+			}
+			tenantID = recurring.TenantID
+
+			for !recurring.NextRunDate.After(now) {
+				if recurring.EndDate != nil && recurring.NextRunDate.After(*recurring.EndDate) {
+					break
+				}
+
+				runDate := recurring.NextRunDate
+				recurringID := recurring.ID
+				expense := models.Expense{
+					TenantModel: models.TenantModel{
+						BaseModel: models.BaseModel{ID: uuid.New()},
+						TenantID:  recurring.TenantID,
+					},
+					CategoryID:         recurring.CategoryID,
+					ShopID:             recurring.ShopID,
+					VendorID:           recurring.VendorID,
+					ExpenseDate:        runDate,
+					Description:        recurring.Description,
+					Amount:             recurring.Amount,
+					PaymentMethod:      recurring.PaymentMethod,
+					Notes:              recurring.Notes,
+					Status:             "pending",
+					RecurringExpenseID: &recurringID,
+					CreatedByID:        recurring.CreatedByID,
+				}
+				if err := tx.Create(&expense).Error; err != nil {
+					return fmt.Errorf("failed to create recurring expense run: %w", err)
+				}
+
+				recurring.LastRunDate = &runDate
+				recurring.NextRunDate = nextRecurringRun(recurring.StartDate, runDate, recurring.Frequency)
+				count++
+			}
+
+			if recurring.EndDate != nil && recurring.NextRunDate.After(*recurring.EndDate) {
+				recurring.IsActive = false
+			}
+
+			if err := tx.Model(&recurring).Updates(map[string]interface{}{
+				"next_run_date": recurring.NextRunDate,
+				"last_run_date": recurring.LastRunDate,
+				"is_active":     recurring.IsActive,
+			}).Error; err != nil {
+				return fmt.Errorf("failed to advance recurring expense: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return generated, err
+		}
+
+		if count > 0 {
+			s.cache.Delete(ctx, fmt.Sprintf("expenses:tenant:%s", tenantID.String()))
+			s.invalidateCategoryCache(ctx, tenantID)
+		}
+		generated += count
+	}
+
+	return generated, nil
+}
+
+// nextRecurringRun returns the run after last. Monthly runs keep to the start date's
+// day of the month, falling back to the last day in shorter months.
+func nextRecurringRun(start, last time.Time, frequency string) time.Time {
+	switch frequency {
+	case models.RecurringDaily:
+		return last.AddDate(0, 0, 1)
+	case models.RecurringWeekly:
+		return last.AddDate(0, 0, 7)
+	default:
+		year, month, _ := last.Date()
+		firstOfNext := time.Date(year, month+1, 1, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+		day := start.Day()
+		if lastDay := firstOfNext.AddDate(0, 1, -1).Day(); day > lastDay {
+			day = lastDay
+		}
+		return firstOfNext.AddDate(0, 0, day-1)
+	}
+}
+
+func buildRecurringExpenseResponse(recurring models.RecurringExpense) *RecurringExpenseResponse {
+	response := &RecurringExpenseResponse{
+		ID:            recurring.ID,
+		VendorID:      recurring.VendorID,
+		Amount:        recurring.Amount,
+		Description:   recurring.Description,
+		PaymentMethod: recurring.PaymentMethod,
+		Notes:         recurring.Notes,
+		Frequency:     recurring.Frequency,
+		StartDate:     recurring.StartDate,
+		NextRunDate:   recurring.NextRunDate,
+		EndDate:       recurring.EndDate,
+		LastRunDate:   recurring.LastRunDate,
+		IsActive:      recurring.IsActive,
+		CreatedBy:     recurring.CreatedByID,
+		CreatedAt:     recurring.CreatedAt,
+	}
+	if recurring.CategoryID != nil {
+		response.CategoryID = *recurring.CategoryID
+	}
+	if recurring.ShopID != nil {
+		response.ShopID = *recurring.ShopID
+	}
+	if recurring.Category != nil {
+		response.CategoryName = recurring.Category.Name
+	}
+	if recurring.Shop != nil {
+		response.ShopName = recurring.Shop.Name
+	}
+	return response
+}
+
+// RecurringExpenseWorker raises expenses from recurring expense templates as they
+// come due
+type RecurringExpenseWorker struct {
+	service  *ExpenseService
+	interval time.Duration
+}
+
+// NewRecurringExpenseWorker creates a worker that checks for due runs at the given interval
+func NewRecurringExpenseWorker(service *ExpenseService, interval time.Duration) *RecurringExpenseWorker {
+	return &RecurringExpenseWorker{
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Start runs the check in the background until ctx is cancelled. The first check
+// runs straight away so a restart does not delay expenses by a whole interval.
+func (w *RecurringExpenseWorker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			generated, err := w.service.GenerateDueRecurringExpenses(ctx)
+			if err != nil {
+				log.Printf("Recurring expense run failed: %v", err)
+			} else if generated > 0 {
+				log.Printf("Generated %d recurring expense(s)", generated)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
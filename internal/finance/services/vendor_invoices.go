@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
+	"github.com/liquorpro/go-backend/pkg/shared/tax"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Vendor invoice statuses
+const (
+	VendorInvoicePending = "pending"
+	VendorInvoicePartial = "partial"
+	VendorInvoicePaid    = "paid"
+	VendorInvoiceOverdue = "overdue"
+)
+
+// VendorInvoiceRequest records a vendor's bill. GST is charged at the tax rate when
+// one is given; otherwise the tax amount is taken as billed.
+type VendorInvoiceRequest struct {
+	InvoiceNumber string    `json:"invoice_number" binding:"required,max=100"`
+	InvoiceDate   time.Time `json:"invoice_date"`
+	DueDate       time.Time `json:"due_date" binding:"required"`
+	SubTotal      float64   `json:"sub_total" binding:"required,gt=0"`
+	TaxRate       float64   `json:"tax_rate" binding:"min=0,max=100"`
+	TaxAmount     float64   `json:"tax_amount" binding:"min=0"`
+}
+
+type VendorInvoiceResponse struct {
+	ID                uuid.UUID `json:"id"`
+	VendorID          uuid.UUID `json:"vendor_id"`
+	InvoiceNumber     string    `json:"invoice_number"`
+	InvoiceDate       time.Time `json:"invoice_date"`
+	DueDate           time.Time `json:"due_date"`
+	SubTotal          float64   `json:"sub_total"`
+	TaxRate           float64   `json:"tax_rate"`
+	TaxAmount         float64   `json:"tax_amount"`
+	CGSTAmount        float64   `json:"cgst_amount"`
+	SGSTAmount        float64   `json:"sgst_amount"`
+	IGSTAmount        float64   `json:"igst_amount"`
+	InterState        bool      `json:"inter_state"`
+	GSTReviewRequired bool      `json:"gst_review_required"`
+	TotalAmount       float64   `json:"total_amount"`
+	PaidAmount        float64   `json:"paid_amount"`
+	DueAmount         float64   `json:"due_amount"`
+	Status            string    `json:"status"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// CreateVendorInvoice records a bill from a vendor. The whole total is due until
+// payments are recorded against it.
+func (s *VendorService) CreateVendorInvoice(ctx context.Context, vendorID uuid.UUID, req VendorInvoiceRequest, tenantID uuid.UUID) (*VendorInvoiceResponse, error) {
+	var vendor models.Vendor
+	if err := s.db.DB.WithContext(ctx).Where("id = ? AND tenant_id = ?", vendorID, tenantID).First(&vendor).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("vendor not found")
+		}
+		return nil, fmt.Errorf("failed to get vendor: %w", err)
+	}
+
+	invoiceDate := req.InvoiceDate
+	if invoiceDate.IsZero() {
+		invoiceDate = time.Now()
+	}
+	if req.DueDate.Format("2006-01-02") < invoiceDate.Format("2006-01-02") {
+		return nil, fmt.Errorf("due date cannot be before the invoice date")
+	}
+
+	// Bills from a vendor in another state carry IGST rather than CGST and SGST.
+	// Without both states the tenant's default applies and the bill is flagged.
+	interState, known := tax.IsInterState(s.settings.GetString(ctx, tenantID, settings.KeyGSTState), vendor.State)
+	if !known {
+		interState = s.settings.GetString(ctx, tenantID, settings.KeyGSTDefaultSupply) == settings.SupplyInterState
+	}
+	var gst tax.Breakdown
+	if req.TaxRate > 0 {
+		gst = tax.Exclusive(req.SubTotal, req.TaxRate, interState)
+	} else {
+		gst = tax.Split(req.SubTotal, req.TaxAmount, utils.RoundToTwoDecimals(req.TaxAmount/req.SubTotal*100), interState)
+	}
+
+	total := utils.RoundToTwoDecimals(req.SubTotal + gst.Total)
+	invoice := models.VendorInvoice{
+		TenantModel: models.TenantModel{
+			BaseModel: models.BaseModel{ID: uuid.New()},
+			TenantID:  tenantID,
+		},
+		InvoiceNumber: req.InvoiceNumber,
+		VendorID:      vendorID,
+		InvoiceDate:   invoiceDate,
+		DueDate:       req.DueDate,
+		SubTotal:      req.SubTotal,
+		TaxRate:       gst.Rate,
+		TaxAmount:     gst.Total,
+		CGSTAmount:    gst.CGST,
+		SGSTAmount:    gst.SGST,
+		IGSTAmount:    gst.IGST,
+		InterState:    interState,
+		TotalAmount:   total,
+		DueAmount:     total,
+
+		GSTReviewRequired: !known,
+	}
+	invoice.Status = vendorInvoiceStatus(&invoice, time.Now())
+
+	if err := s.db.DB.WithContext(ctx).Create(&invoice).Error; err != nil {
+		return nil, fmt.Errorf("failed to create vendor invoice: %w", err)
+	}
+
+	return buildVendorInvoiceResponse(&invoice), nil
+}
+
+// GetVendorInvoices returns a vendor's invoices, newest first
+func (s *VendorService) GetVendorInvoices(ctx context.Context, vendorID, tenantID uuid.UUID) ([]VendorInvoiceResponse, error) {
+	var invoices []models.VendorInvoice
+	if err := s.db.DB.WithContext(ctx).
+		Where("vendor_id = ? AND tenant_id = ?", vendorID, tenantID).
+		Order("invoice_date DESC").
+		Find(&invoices).Error; err != nil {
+		return nil, fmt.Errorf("failed to get vendor invoices: %w", err)
+	}
+
+	now := time.Now()
+	responses := make([]VendorInvoiceResponse, len(invoices))
+	for i := range invoices {
+		// Invoices fall overdue without any write, so the status is worked out on read
+		invoices[i].Status = vendorInvoiceStatus(&invoices[i], now)
+		responses[i] = *buildVendorInvoiceResponse(&invoices[i])
+	}
+	return responses, nil
+}
+
+// recordInvoicePayment applies a vendor payment to one of the vendor's invoices. The
+// invoice is locked, the payment is rejected if it is more than is still due, and the
+// paid and due amounts are recalculated from every payment against the invoice.
+func (s *VendorService) recordInvoicePayment(tx *gorm.DB, transaction *models.VendorTransaction) (*models.VendorInvoice, error) {
+	var invoice models.VendorInvoice
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND vendor_id = ? AND tenant_id = ?", *transaction.VendorInvoiceID, transaction.VendorID, transaction.TenantID).
+		First(&invoice).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("vendor invoice not found")
+		}
+		return nil, fmt.Errorf("failed to get vendor invoice: %w", err)
+	}
+
+	if invoice.DueAmount <= 0 {
+		return nil, fmt.Errorf("vendor invoice is already paid")
+	}
+	if utils.RoundToTwoDecimals(transaction.Amount) > utils.RoundToTwoDecimals(invoice.DueAmount) {
+		return nil, fmt.Errorf("payment of %.2f exceeds the %.2f due on the invoice", transaction.Amount, invoice.DueAmount)
+	}
+
+	payment := models.VendorInvoiceTransaction{
+		TenantModel: models.TenantModel{
+			BaseModel: models.BaseModel{ID: uuid.New()},
+			TenantID:  transaction.TenantID,
+		},
+		VendorInvoiceID: invoice.ID,
+		Amount:          transaction.Amount,
+		PaymentMethod:   transaction.PaymentMethod,
+		PaymentDate:     transaction.TransactionDate,
+		Reference:       transaction.ReferenceNo,
+		Notes:           transaction.Description,
+	}
+	if payment.PaymentMethod == "" {
+		payment.PaymentMethod = "other"
+	}
+	if err := tx.Create(&payment).Error; err != nil {
+		return nil, fmt.Errorf("failed to record invoice payment: %w", err)
+	}
+
+	var paid float64
+	if err := tx.Model(&models.VendorInvoiceTransaction{}).
+		Where("vendor_invoice_id = ? AND tenant_id = ?", invoice.ID, invoice.TenantID).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&paid).Error; err != nil {
+		return nil, fmt.Errorf("failed to total invoice payments: %w", err)
+	}
+
+	invoice.PaidAmount = utils.RoundToTwoDecimals(paid)
+	invoice.DueAmount = utils.RoundToTwoDecimals(invoice.TotalAmount - invoice.PaidAmount)
+	if invoice.DueAmount < 0 {
+		invoice.DueAmount = 0
+	}
+	invoice.Status = vendorInvoiceStatus(&invoice, transaction.TransactionDate)
+
+	if err := tx.Model(&invoice).Updates(map[string]interface{}{
+		"paid_amount": invoice.PaidAmount,
+		"due_amount":  invoice.DueAmount,
+		"status":      invoice.Status,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to update vendor invoice: %w", err)
+	}
+
+	return &invoice, nil
+}
+
+// vendorInvoiceStatus is paid once nothing is due, overdue when a balance is left
+// past the due date, partial when some has been paid and pending otherwise
+func vendorInvoiceStatus(invoice *models.VendorInvoice, now time.Time) string {
+	switch {
+	case invoice.DueAmount <= 0:
+		return VendorInvoicePaid
+	case now.After(invoice.DueDate):
+		return VendorInvoiceOverdue
+	case invoice.PaidAmount > 0:
+		return VendorInvoicePartial
+	default:
+		return VendorInvoicePending
+	}
+}
+
+func buildVendorInvoiceResponse(invoice *models.VendorInvoice) *VendorInvoiceResponse {
+	return &VendorInvoiceResponse{
+		ID:                invoice.ID,
+		VendorID:          invoice.VendorID,
+		InvoiceNumber:     invoice.InvoiceNumber,
+		InvoiceDate:       invoice.InvoiceDate,
+		DueDate:           invoice.DueDate,
+		SubTotal:          invoice.SubTotal,
+		TaxRate:           invoice.TaxRate,
+		TaxAmount:         invoice.TaxAmount,
+		CGSTAmount:        invoice.CGSTAmount,
+		SGSTAmount:        invoice.SGSTAmount,
+		IGSTAmount:        invoice.IGSTAmount,
+		InterState:        invoice.InterState,
+		GSTReviewRequired: invoice.GSTReviewRequired,
+		TotalAmount:       invoice.TotalAmount,
+		PaidAmount:        invoice.PaidAmount,
+		DueAmount:         invoice.DueAmount,
+		Status:            invoice.Status,
+		CreatedAt:         invoice.CreatedAt,
+	}
+}
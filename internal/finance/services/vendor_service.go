@@ -9,18 +9,21 @@ import (
 	"github.com/liquorpro/go-backend/pkg/shared/cache"
 	"github.com/liquorpro/go-backend/pkg/shared/database"
 	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
 	"gorm.io/gorm"
 )
 
 type VendorService struct {
-	db    *database.DB
-	cache *cache.Cache
+	db       *database.DB
+	cache    *cache.Cache
+	settings *settings.Service
 }
 
-func NewVendorService(db *database.DB, cache *cache.Cache) *VendorService {
+func NewVendorService(db *database.DB, cache *cache.Cache, settingsService *settings.Service) *VendorService {
 	return &VendorService{
-		db:    db,
-		cache: cache,
+		db:       db,
+		cache:    cache,
+		settings: settingsService,
 	}
 }
 
@@ -89,6 +92,7 @@ type VendorTransactionRequest struct {
 	Description     string    `json:"description" binding:"required"`
 	ReferenceNo     string    `json:"reference_no"`
 	PaymentMethod   string    `json:"payment_method"`
+	VendorInvoiceID *uuid.UUID `json:"vendor_invoice_id"`
 }
 
 type VendorTransactionResponse struct {
@@ -100,6 +104,8 @@ type VendorTransactionResponse struct {
 	Description     string    `json:"description"`
 	ReferenceNo     string    `json:"reference_no"`
 	PaymentMethod   string    `json:"payment_method"`
+	VendorInvoiceID *uuid.UUID `json:"vendor_invoice_id,omitempty"`
+	Invoice         *VendorInvoiceResponse `json:"invoice,omitempty"`
 	CreatedBy       uuid.UUID `json:"created_by"`
 	CreatedAt       time.Time `json:"created_at"`
 }
@@ -193,7 +199,7 @@ func (s *VendorService) GetVendors(ctx context.Context, tenantID uuid.UUID, incl
 	}
 
 	// Cache the result
-	s.cache.Set(ctx, cacheKey, responses, 5*time.Minute) // Cache for 5 minutes
+	s.cache.Set(ctx, cacheKey, responses, s.cache.TTLs().Vendors)
 
 	return responses, nil
 }
@@ -381,14 +387,38 @@ func (s *VendorService) CreateVendorTransaction(ctx context.Context, req VendorT
 		Description:     req.Description,
 		ReferenceNo:     req.ReferenceNo,
 		PaymentMethod:   req.PaymentMethod,
+		TransactionDate: time.Now(),
+		VendorInvoiceID: req.VendorInvoiceID,
 		CreatedBy:       userID,
 	}
 
-	if err := s.db.DB.Create(&transaction).Error; err != nil {
-		return nil, fmt.Errorf("failed to create transaction: %w", err)
+	// A payment against an invoice is recorded with the invoice's new balance
+	var invoice *models.VendorInvoice
+	if req.VendorInvoiceID != nil {
+		if req.TransactionType != "payment" {
+			return nil, fmt.Errorf("only payments can be recorded against an invoice")
+		}
+		if req.Amount <= 0 {
+			return nil, fmt.Errorf("payment amount must be positive")
+		}
 	}
 
-	return &VendorTransactionResponse{
+	err := s.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&transaction).Error; err != nil {
+			return fmt.Errorf("failed to create transaction: %w", err)
+		}
+		if transaction.VendorInvoiceID == nil {
+			return nil
+		}
+		var err error
+		invoice, err = s.recordInvoicePayment(tx, &transaction)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &VendorTransactionResponse{
 		ID:              transaction.ID,
 		VendorID:        transaction.VendorID,
 		VendorName:      vendor.Name,
@@ -397,9 +427,14 @@ func (s *VendorService) CreateVendorTransaction(ctx context.Context, req VendorT
 		Description:     transaction.Description,
 		ReferenceNo:     transaction.ReferenceNo,
 		PaymentMethod:   transaction.PaymentMethod,
+		VendorInvoiceID: transaction.VendorInvoiceID,
 		CreatedBy:       transaction.CreatedBy,
 		CreatedAt:       transaction.CreatedAt,
-	}, nil
+	}
+	if invoice != nil {
+		response.Invoice = buildVendorInvoiceResponse(invoice)
+	}
+	return response, nil
 }
 
 func (s *VendorService) GetVendorTransactions(ctx context.Context, vendorID, tenantID uuid.UUID, limit, offset int) ([]VendorTransactionResponse, int64, error) {
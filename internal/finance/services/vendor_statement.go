@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+	"gorm.io/gorm"
+)
+
+// Vendor statement entry types. Invoices add to what the tenant owes the vendor and
+// payments take from it.
+const (
+	VendorStatementInvoice = "invoice"
+	VendorStatementPayment = "payment"
+)
+
+// VendorStatementEntry is one invoice or payment on a vendor statement
+type VendorStatementEntry struct {
+	ID          uuid.UUID `json:"id"`
+	Date        time.Time `json:"date"`
+	EntryType   string    `json:"entry_type"`
+	Reference   string    `json:"reference"`
+	Description string    `json:"description"`
+	Debit       float64   `json:"debit"`  // invoiced
+	Credit      float64   `json:"credit"` // paid
+	Balance     float64   `json:"balance"`
+
+	// GST on invoice entries
+	TaxAmount         float64 `json:"tax_amount,omitempty"`
+	CGSTAmount        float64 `json:"cgst_amount,omitempty"`
+	SGSTAmount        float64 `json:"sgst_amount,omitempty"`
+	IGSTAmount        float64 `json:"igst_amount,omitempty"`
+	GSTReviewRequired bool    `json:"gst_review_required,omitempty"`
+}
+
+// VendorTaxSummary totals the GST on a statement's invoices
+type VendorTaxSummary struct {
+	CGST              float64 `json:"cgst"`
+	SGST              float64 `json:"sgst"`
+	IGST              float64 `json:"igst"`
+	Total             float64 `json:"total"`
+	InvoicesForReview int     `json:"invoices_for_review"`
+}
+
+// VendorAging splits a vendor's unpaid invoices by how many days past their due date
+// they are. Invoices not yet due fall in the 0-30 bucket.
+type VendorAging struct {
+	Days0To30  float64 `json:"days_0_30"`
+	Days31To60 float64 `json:"days_31_60"`
+	Days61To90 float64 `json:"days_61_90"`
+	Days90Plus float64 `json:"days_90_plus"`
+	Total      float64 `json:"total"`
+}
+
+// VendorStatement is a vendor's account over a date range
+type VendorStatement struct {
+	VendorID       uuid.UUID              `json:"vendor_id"`
+	VendorName     string                 `json:"vendor_name"`
+	StartDate      time.Time              `json:"start_date"`
+	EndDate        time.Time              `json:"end_date"`
+	OpeningBalance float64                `json:"opening_balance"`
+	TotalInvoiced  float64                `json:"total_invoiced"`
+	TotalPaid      float64                `json:"total_paid"`
+	ClosingBalance float64                `json:"closing_balance"`
+	Entries        []VendorStatementEntry `json:"entries"`
+	Tax            VendorTaxSummary       `json:"tax"`
+	Aging          VendorAging            `json:"aging"`
+}
+
+// GetVendorStatement returns a vendor's invoices and payments between startDate and
+// endDate, oldest first, with a running balance from the opening balance. The aging
+// breakdown is of invoices still unpaid, measured at endDate.
+func (s *VendorService) GetVendorStatement(ctx context.Context, vendorID, tenantID uuid.UUID, startDate, endDate time.Time) (*VendorStatement, error) {
+	db := s.db.DB.WithContext(ctx)
+
+	var vendor models.Vendor
+	if err := db.Where("id = ? AND tenant_id = ?", vendorID, tenantID).First(&vendor).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("vendor not found")
+		}
+		return nil, fmt.Errorf("failed to get vendor: %w", err)
+	}
+
+	var invoices []models.VendorInvoice
+	if err := db.Where("vendor_id = ? AND tenant_id = ? AND invoice_date <= ?", vendorID, tenantID, endDate).
+		Order("invoice_date ASC").
+		Find(&invoices).Error; err != nil {
+		return nil, fmt.Errorf("failed to get vendor invoices: %w", err)
+	}
+
+	var payments []models.VendorTransaction
+	if err := db.Where("vendor_id = ? AND tenant_id = ? AND transaction_type = ?", vendorID, tenantID, "payment").
+		Order("created_at ASC").
+		Find(&payments).Error; err != nil {
+		return nil, fmt.Errorf("failed to get vendor payments: %w", err)
+	}
+
+	var entries []VendorStatementEntry
+	for _, invoice := range invoices {
+		entries = append(entries, VendorStatementEntry{
+			ID:          invoice.ID,
+			Date:        invoice.InvoiceDate,
+			EntryType:   VendorStatementInvoice,
+			Reference:   invoice.InvoiceNumber,
+			Description: fmt.Sprintf("Invoice %s due %s", invoice.InvoiceNumber, invoice.DueDate.Format("2006-01-02")),
+			Debit:       invoice.TotalAmount,
+
+			TaxAmount:         invoice.TaxAmount,
+			CGSTAmount:        invoice.CGSTAmount,
+			SGSTAmount:        invoice.SGSTAmount,
+			IGSTAmount:        invoice.IGSTAmount,
+			GSTReviewRequired: invoice.GSTReviewRequired,
+		})
+	}
+	for _, payment := range payments {
+		// Older transactions were recorded without a transaction date
+		date := payment.TransactionDate
+		if date.IsZero() {
+			date = payment.CreatedAt
+		}
+		if date.After(endDate) {
+			continue
+		}
+		entries = append(entries, VendorStatementEntry{
+			ID:          payment.ID,
+			Date:        date,
+			EntryType:   VendorStatementPayment,
+			Reference:   payment.ReferenceNo,
+			Description: payment.Description,
+			Credit:      payment.Amount,
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Date.Before(entries[j].Date)
+	})
+
+	statement := &VendorStatement{
+		VendorID:   vendor.ID,
+		VendorName: vendor.Name,
+		StartDate:  startDate,
+		EndDate:    endDate,
+		Entries:    []VendorStatementEntry{},
+	}
+
+	balance := 0.0
+	for _, entry := range entries {
+		balance = utils.RoundToTwoDecimals(balance + entry.Debit - entry.Credit)
+		if entry.Date.Before(startDate) {
+			statement.OpeningBalance = balance
+			continue
+		}
+		entry.Balance = balance
+		statement.TotalInvoiced += entry.Debit
+		statement.TotalPaid += entry.Credit
+		statement.Tax.CGST += entry.CGSTAmount
+		statement.Tax.SGST += entry.SGSTAmount
+		statement.Tax.IGST += entry.IGSTAmount
+		statement.Tax.Total += entry.TaxAmount
+		if entry.GSTReviewRequired {
+			statement.Tax.InvoicesForReview++
+		}
+		statement.Entries = append(statement.Entries, entry)
+	}
+	statement.TotalInvoiced = utils.RoundToTwoDecimals(statement.TotalInvoiced)
+	statement.TotalPaid = utils.RoundToTwoDecimals(statement.TotalPaid)
+	statement.Tax.CGST = utils.RoundToTwoDecimals(statement.Tax.CGST)
+	statement.Tax.SGST = utils.RoundToTwoDecimals(statement.Tax.SGST)
+	statement.Tax.IGST = utils.RoundToTwoDecimals(statement.Tax.IGST)
+	statement.Tax.Total = utils.RoundToTwoDecimals(statement.Tax.Total)
+	statement.ClosingBalance = balance
+
+	asOf := endDate
+	if now := time.Now(); now.Before(asOf) {
+		asOf = now
+	}
+	for _, invoice := range invoices {
+		if invoice.DueAmount <= 0 || invoice.Status == "paid" {
+			continue
+		}
+		daysOverdue := int(asOf.Sub(invoice.DueDate).Hours() / 24)
+		switch {
+		case daysOverdue <= 30:
+			statement.Aging.Days0To30 += invoice.DueAmount
+		case daysOverdue <= 60:
+			statement.Aging.Days31To60 += invoice.DueAmount
+		case daysOverdue <= 90:
+			statement.Aging.Days61To90 += invoice.DueAmount
+		default:
+			statement.Aging.Days90Plus += invoice.DueAmount
+		}
+		statement.Aging.Total += invoice.DueAmount
+	}
+	statement.Aging.Days0To30 = utils.RoundToTwoDecimals(statement.Aging.Days0To30)
+	statement.Aging.Days31To60 = utils.RoundToTwoDecimals(statement.Aging.Days31To60)
+	statement.Aging.Days61To90 = utils.RoundToTwoDecimals(statement.Aging.Days61To90)
+	statement.Aging.Days90Plus = utils.RoundToTwoDecimals(statement.Aging.Days90Plus)
+	statement.Aging.Total = utils.RoundToTwoDecimals(statement.Aging.Total)
+
+	return statement, nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/liquorpro/go-backend/pkg/shared/cache"
 	"github.com/liquorpro/go-backend/pkg/shared/config"
 	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/logger"
 )
 
 type FrontendService struct {
@@ -47,6 +48,9 @@ func (s *FrontendService) makeAPIRequest(ctx context.Context, method, serviceURL
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	if requestID := logger.RequestIDFromContext(ctx); requestID != "" {
+		req.Header.Set(logger.RequestIDHeader, requestID)
+	}
 	if token != "" {
 		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 	}
@@ -313,6 +317,21 @@ func (s *FrontendService) GetMoneyCollections(ctx context.Context, token string,
 	return result, nil
 }
 
+func (s *FrontendService) GetDashboardSummary(ctx context.Context, token string) (map[string]interface{}, error) {
+	resp, err := s.makeAPIRequest(ctx, "GET", s.config.Services.Sales.URL, "/api/dashboard/summary", nil, token)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return result, nil
+}
+
 // Dashboard data aggregation
 func (s *FrontendService) GetDashboardData(ctx context.Context, token string) (map[string]interface{}, error) {
 	dashboardData := make(map[string]interface{})
@@ -323,6 +342,15 @@ func (s *FrontendService) GetDashboardData(ctx context.Context, token string) (m
 		dashboardData["recent_sales"] = salesData
 	}
 
+	// Get pending approval counts; the sales count only means something when the
+	// tenant holds daily sales for approval
+	summaryData, err := s.GetDashboardSummary(ctx, token)
+	if err == nil {
+		dashboardData["pending_sales"] = summaryData["pending_sales"]
+		dashboardData["pending_returns"] = summaryData["pending_returns"]
+		dashboardData["sales_approval_required"] = summaryData["sales_approval_required"]
+	}
+
 	// Get low stock items
 	stockData, err := s.GetStocks(ctx, token, map[string]string{"low_stock": "true", "limit": "10"})
 	if err == nil {
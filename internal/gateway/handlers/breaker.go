@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+)
+
+// Circuit breaker states
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// CircuitBreaker stops proxying to a downstream service after consecutive failures.
+// Once the cooldown has passed, a single probe request is let through: its success
+// closes the breaker and its failure opens it for another cooldown.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     string
+	failures  int
+	openedAt  time.Time
+	probing   bool
+}
+
+// BreakerStatus is a snapshot of a circuit breaker for health reporting
+type BreakerStatus struct {
+	State    string     `json:"state"`
+	Failures int        `json:"consecutive_failures"`
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+	RetryAt  *time.Time `json:"retry_at,omitempty"`
+}
+
+// NewCircuitBreaker creates a closed breaker that opens after threshold consecutive
+// failures. A threshold of zero or less never opens.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     BreakerClosed,
+	}
+}
+
+// Allow reports whether a request may be sent. When it may not, retryAfter is how
+// long until the next probe.
+func (b *CircuitBreaker) Allow() (allowed bool, retryAfter time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if wait := b.cooldown - time.Since(b.openedAt); wait > 0 {
+			return false, wait
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true, 0
+	case BreakerHalfOpen:
+		if b.probing {
+			return false, time.Second
+		}
+		b.probing = true
+		return true, 0
+	}
+	return true, 0
+}
+
+// Success records a successful request and closes the breaker
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure records a failed request, opening the breaker when the failed request
+// was a probe or the threshold is reached
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == BreakerHalfOpen || (b.threshold > 0 && b.failures >= b.threshold) {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+	b.probing = false
+}
+
+// Release records a request whose outcome says nothing about the service, such as
+// one the client abandoned. A probe released this way lets the next request probe.
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// Status returns the breaker's current state
+func (b *CircuitBreaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{State: b.state, Failures: b.failures}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		retryAt := openedAt.Add(b.cooldown)
+		status.OpenedAt = &openedAt
+		status.RetryAt = &retryAt
+	}
+	return status
+}
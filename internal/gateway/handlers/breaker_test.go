@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreakerReleasedProbeLetsNextRequestProbe(t *testing.T) {
+	b := NewCircuitBreaker(1, time.Nanosecond)
+	b.Failure()
+	time.Sleep(time.Millisecond)
+
+	allowed, _ := b.Allow()
+	assert.True(t, allowed, "probe after cooldown")
+	allowed, _ = b.Allow()
+	assert.False(t, allowed, "second request while probing")
+
+	b.Release()
+	allowed, _ = b.Allow()
+	assert.True(t, allowed, "next probe after release")
+	assert.Equal(t, BreakerHalfOpen, b.Status().State)
+}
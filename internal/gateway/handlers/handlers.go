@@ -2,27 +2,43 @@ package handlers
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/liquorpro/go-backend/pkg/shared/config"
+	"github.com/liquorpro/go-backend/pkg/shared/logger"
 )
 
+// proxiedServices are the downstream services the gateway proxies to
+var proxiedServices = []string{"auth", "sales", "inventory", "finance", "frontend"}
+
 // GatewayHandlers handles API gateway routing and service communication
 type GatewayHandlers struct {
 	config     *config.Config
 	httpClient *http.Client
+	breakers   map[string]*CircuitBreaker
 }
 
 // NewGatewayHandlers creates a new gateway handlers instance
 func NewGatewayHandlers(config *config.Config, httpClient *http.Client) *GatewayHandlers {
-	return &GatewayHandlers{
+	h := &GatewayHandlers{
 		config:     config,
 		httpClient: httpClient,
+		breakers:   make(map[string]*CircuitBreaker),
+	}
+	for _, name := range proxiedServices {
+		serviceConfig := h.getServiceConfig(name)
+		h.breakers[name] = NewCircuitBreaker(serviceConfig.FailureThreshold, time.Duration(serviceConfig.Cooldown)*time.Second)
 	}
+	return h
 }
 
 // ProxyRequest proxies requests to appropriate microservices
@@ -49,74 +65,163 @@ func (h *GatewayHandlers) ProxyRequest(serviceName string) gin.HandlerFunc {
 		}
 
 		// Read request body
-		var bodyReader io.Reader
+		var bodyBytes []byte
 		if c.Request.Body != nil {
-			bodyBytes, err := io.ReadAll(c.Request.Body)
+			var err error
+			bodyBytes, err = io.ReadAll(c.Request.Body)
 			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Request body is too large; this endpoint accepts at most %d bytes", tooLarge.Limit)})
+					return
+				}
 				c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
 				return
 			}
-			bodyReader = bytes.NewReader(bodyBytes)
-		}
-
-		// Create new request
-		req, err := http.NewRequestWithContext(c.Request.Context(), c.Request.Method, targetURL, bodyReader)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-			return
 		}
 
-		// Copy headers
+		// Build request headers
+		header := make(http.Header)
 		for key, values := range c.Request.Header {
 			for _, value := range values {
-				req.Header.Add(key, value)
+				header.Add(key, value)
 			}
 		}
 
 		// Add gateway headers
-		req.Header.Set("X-Gateway", "liquorpro-gateway")
-		req.Header.Set("X-Service", serviceName)
+		header.Set("X-Gateway", "liquorpro-gateway")
+		header.Set("X-Service", serviceName)
+		if requestID := c.GetString("request_id"); requestID != "" {
+			header.Set(logger.RequestIDHeader, requestID)
+		}
 
 		// Forward user context if available
 		if userID := c.GetString("user_id"); userID != "" {
-			req.Header.Set("X-User-ID", userID)
+			header.Set("X-User-ID", userID)
 		}
 		if tenantID := c.GetString("tenant_id"); tenantID != "" {
-			req.Header.Set("X-Tenant-ID", tenantID)
+			header.Set("X-Tenant-ID", tenantID)
 		}
 		if role := c.GetString("role"); role != "" {
-			req.Header.Set("X-User-Role", role)
+			header.Set("X-User-Role", role)
 		}
 
-		// Make request to service
-		resp, err := h.httpClient.Do(req)
-		if err != nil {
-			c.JSON(http.StatusBadGateway, gin.H{"error": "Service unavailable"})
-			return
+		// Make request to service; only GETs are safe to retry
+		serviceConfig := h.getServiceConfig(serviceName)
+		breaker := h.breakers[strings.ToLower(serviceName)]
+		attempts := 1
+		if c.Request.Method == http.MethodGet && serviceConfig.MaxRetries > 0 {
+			attempts += serviceConfig.MaxRetries
 		}
-		defer resp.Body.Close()
+		backoff := time.Duration(serviceConfig.RetryBackoff) * time.Millisecond
 
-		// Copy response headers
-		for key, values := range resp.Header {
-			for _, value := range values {
-				c.Header(key, value)
+		for attempt := 1; ; attempt++ {
+			if breaker != nil {
+				if allowed, retryAfter := breaker.Allow(); !allowed {
+					c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+					c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Service temporarily unavailable"})
+					return
+				}
+			}
+
+			resp, cancel, err := h.forward(c.Request.Context(), c.Request.Method, targetURL, bodyBytes, header, serviceConfig.Timeout)
+			failed := err != nil || isDownstreamFailure(resp.StatusCode)
+			// A client that went away says nothing about the service
+			if breaker != nil {
+				switch {
+				case c.Request.Context().Err() != nil:
+					breaker.Release()
+				case failed:
+					breaker.Failure()
+				default:
+					breaker.Success()
+				}
+			}
+
+			if failed && attempt < attempts && c.Request.Context().Err() == nil {
+				if resp != nil {
+					resp.Body.Close()
+				}
+				cancel()
+				time.Sleep(backoff << (attempt - 1))
+				continue
+			}
+
+			if err != nil {
+				cancel()
+				c.JSON(http.StatusBadGateway, gin.H{"error": "Service unavailable"})
+				return
 			}
+
+			// Copy response headers
+			for key, values := range resp.Header {
+				for _, value := range values {
+					c.Header(key, value)
+				}
+			}
+
+			// Copy response body
+			c.Status(resp.StatusCode)
+			io.Copy(c.Writer, resp.Body)
+			resp.Body.Close()
+			cancel()
+			return
 		}
+	}
+}
 
-		// Copy response body
-		c.Status(resp.StatusCode)
-		io.Copy(c.Writer, resp.Body)
+// forward sends one attempt of a proxied request, bounded by the service timeout.
+// The returned cancel func must be called once the response body has been read.
+func (h *GatewayHandlers) forward(ctx context.Context, method, targetURL string, body []byte, header http.Header, timeout int) (*http.Response, context.CancelFunc, error) {
+	cancel := context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
 	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, targetURL, bodyReader)
+	if err != nil {
+		cancel()
+		return nil, cancel, err
+	}
+	req.Header = header.Clone()
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, cancel, err
+	}
+	return resp, cancel, nil
+}
+
+// isDownstreamFailure reports whether a response status means the service itself
+// failed, as opposed to rejecting the request
+func isDownstreamFailure(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
 }
 
 // HealthCheck handles health check requests
 func (h *GatewayHandlers) HealthCheck(c *gin.Context) {
 	// Simple gateway health check - don't check other services to avoid circular issues
+	// Breaker states are local, so reporting them needs no calls downstream
+	status := "healthy"
+	breakers := make(map[string]BreakerStatus, len(h.breakers))
+	for name, breaker := range h.breakers {
+		breakers[name] = breaker.Status()
+		if breakers[name].State != BreakerClosed {
+			status = "degraded"
+		}
+	}
+
 	healthStatus := gin.H{
-		"status":    "healthy",
-		"service":   "gateway",
-		"timestamp": time.Now().UTC().Format(time.RFC3339),
-		"version":   h.config.App.Version,
+		"status":           status,
+		"service":          "gateway",
+		"timestamp":        time.Now().UTC().Format(time.RFC3339),
+		"version":          h.config.App.Version,
+		"circuit_breakers": breakers,
 	}
 
 	c.JSON(http.StatusOK, healthStatus)
@@ -163,6 +268,24 @@ func (h *GatewayHandlers) ServiceDiscovery(c *gin.Context) {
 	})
 }
 
+// getServiceConfig returns the configuration of a given service
+func (h *GatewayHandlers) getServiceConfig(serviceName string) config.ServiceConfig {
+	switch strings.ToLower(serviceName) {
+	case "auth":
+		return h.config.Services.Auth
+	case "sales":
+		return h.config.Services.Sales
+	case "inventory":
+		return h.config.Services.Inventory
+	case "finance":
+		return h.config.Services.Finance
+	case "frontend":
+		return h.config.Services.Frontend
+	default:
+		return config.ServiceConfig{}
+	}
+}
+
 // getServiceURL returns the URL for a given service name
 func (h *GatewayHandlers) getServiceURL(serviceName string) string {
 	switch strings.ToLower(serviceName) {
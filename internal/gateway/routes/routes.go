@@ -10,6 +10,12 @@ import (
 
 // SetupRoutes configures all gateway routes
 func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, gatewayHandlers *handlers.GatewayHandlers) {
+	// Counted per tenant, so it runs after authentication on protected groups
+	rateLimit := middleware.RateLimitMiddleware(cache, cfg.RateLimit)
+
+	// Oversized bodies are refused before they are read into memory and proxied
+	router.Use(middleware.BodySizeLimitMiddleware(cfg.RequestLimits))
+
 	// Gateway management endpoints
 	gateway := router.Group("/gateway")
 	{
@@ -20,6 +26,7 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, gat
 
 	// Authentication service routes (no auth required for login/register)
 	authPublic := router.Group("/api/auth")
+	authPublic.Use(rateLimit)
 	{
 		authPublic.POST("/login", gatewayHandlers.ProxyRequest("auth"))
 		authPublic.POST("/register", gatewayHandlers.ProxyRequest("auth"))
@@ -31,31 +38,80 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, gat
 	// Protected authentication routes
 	authProtected := router.Group("/api/auth")
 	authProtected.Use(middleware.AuthMiddleware(cfg.JWT, cache))
+	authProtected.Use(rateLimit)
 	{
 		authProtected.POST("/logout", gatewayHandlers.ProxyRequest("auth"))
 		authProtected.POST("/refresh", gatewayHandlers.ProxyRequest("auth"))
 		authProtected.GET("/profile", gatewayHandlers.ProxyRequest("auth"))
 		authProtected.PUT("/profile", gatewayHandlers.ProxyRequest("auth"))
 		authProtected.PUT("/change-password", gatewayHandlers.ProxyRequest("auth"))
+		authProtected.POST("/2fa/enroll", gatewayHandlers.ProxyRequest("auth"))
+		authProtected.POST("/2fa/verify", gatewayHandlers.ProxyRequest("auth"))
+		authProtected.POST("/2fa/disable", gatewayHandlers.ProxyRequest("auth"))
+	}
+
+	// Tenant settings routes (served by auth service)
+	tenantSettings := router.Group("/api/settings")
+	tenantSettings.Use(middleware.AuthMiddleware(cfg.JWT, cache))
+	tenantSettings.Use(middleware.TenantMiddleware())
+	tenantSettings.Use(rateLimit)
+	{
+		tenantSettings.GET("", gatewayHandlers.ProxyRequest("auth"))
+		tenantSettings.PUT("", gatewayHandlers.ProxyRequest("auth"))
+		tenantSettings.GET("/history", gatewayHandlers.ProxyRequest("auth"))
+		tenantSettings.GET("/approvers", gatewayHandlers.ProxyRequest("auth"))
+		tenantSettings.PUT("/approvers", gatewayHandlers.ProxyRequest("auth"))
+	}
+
+	// Tenant audit log (served by auth service)
+	auditLogs := router.Group("/api/audit-logs")
+	auditLogs.Use(middleware.AuthMiddleware(cfg.JWT, cache))
+	auditLogs.Use(middleware.TenantMiddleware())
+	auditLogs.Use(rateLimit)
+	{
+		auditLogs.GET("", gatewayHandlers.ProxyRequest("auth"))
+	}
+
+	// Tenant webhooks (served by auth service)
+	webhooks := router.Group("/api/webhooks")
+	webhooks.Use(middleware.AuthMiddleware(cfg.JWT, cache))
+	webhooks.Use(middleware.TenantMiddleware())
+	webhooks.Use(rateLimit)
+	{
+		webhooks.GET("", gatewayHandlers.ProxyRequest("auth"))
+		webhooks.POST("", gatewayHandlers.ProxyRequest("auth"))
+		webhooks.GET("/events", gatewayHandlers.ProxyRequest("auth"))
+		webhooks.GET("/deliveries", gatewayHandlers.ProxyRequest("auth"))
+		webhooks.POST("/deliveries/:id/retry", gatewayHandlers.ProxyRequest("auth"))
+		webhooks.PUT("/:id", gatewayHandlers.ProxyRequest("auth"))
+		webhooks.DELETE("/:id", gatewayHandlers.ProxyRequest("auth"))
 	}
 
 	// Sales service routes (protected)
 	sales := router.Group("/api/sales")
 	sales.Use(middleware.AuthMiddleware(cfg.JWT, cache))
 	sales.Use(middleware.TenantMiddleware())
+	sales.Use(rateLimit)
 	{
 		// Daily sales (critical for current workflow)
 		sales.GET("/daily-records", gatewayHandlers.ProxyRequest("sales"))
 		sales.POST("/daily-records", gatewayHandlers.ProxyRequest("sales"))
 		sales.GET("/daily-records/:id", gatewayHandlers.ProxyRequest("sales"))
 		sales.PUT("/daily-records/:id", gatewayHandlers.ProxyRequest("sales"))
+		sales.PATCH("/daily-records/:id/items/:itemId", gatewayHandlers.ProxyRequest("sales"))
 		sales.DELETE("/daily-records/:id", gatewayHandlers.ProxyRequest("sales"))
+		sales.POST("/daily-records/bulk-approve", gatewayHandlers.ProxyRequest("sales"))
 		sales.POST("/daily-records/:id/approve", gatewayHandlers.ProxyRequest("sales"))
 		sales.POST("/daily-records/:id/reject", gatewayHandlers.ProxyRequest("sales"))
+		sales.POST("/daily-records/:id/void", gatewayHandlers.ProxyRequest("sales"))
+		sales.POST("/daily-records/:id/returns", gatewayHandlers.ProxyRequest("sales"))
 
 		// Individual sales
 		sales.GET("/sales", gatewayHandlers.ProxyRequest("sales"))
 		sales.POST("/sales", gatewayHandlers.ProxyRequest("sales"))
+		sales.GET("/sales/commissions", gatewayHandlers.ProxyRequest("sales"))
+		sales.GET("/sales/commission-rules", gatewayHandlers.ProxyRequest("sales"))
+		sales.POST("/sales/commission-rules", gatewayHandlers.ProxyRequest("sales"))
 		sales.GET("/sales/:id", gatewayHandlers.ProxyRequest("sales"))
 		sales.PUT("/sales/:id", gatewayHandlers.ProxyRequest("sales"))
 		sales.DELETE("/sales/:id", gatewayHandlers.ProxyRequest("sales"))
@@ -77,6 +133,16 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, gat
 		sales.GET("/summaries", gatewayHandlers.ProxyRequest("sales"))
 		sales.GET("/dashboard", gatewayHandlers.ProxyRequest("sales"))
 		sales.GET("/uncollected", gatewayHandlers.ProxyRequest("sales"))
+		sales.GET("/reports/sales-by-category", gatewayHandlers.ProxyRequest("sales"))
+		sales.GET("/reports/sales-stock-reconciliation", gatewayHandlers.ProxyRequest("sales"))
+		sales.GET("/reports/margins", gatewayHandlers.ProxyRequest("sales"))
+
+		// Sales targets
+		sales.GET("/targets", gatewayHandlers.ProxyRequest("sales"))
+		sales.POST("/targets", gatewayHandlers.ProxyRequest("sales"))
+		sales.GET("/targets/achievement", gatewayHandlers.ProxyRequest("sales"))
+		sales.PUT("/targets/:id", gatewayHandlers.ProxyRequest("sales"))
+		sales.DELETE("/targets/:id", gatewayHandlers.ProxyRequest("sales"))
 
 		// OCR and image processing
 		sales.POST("/images/upload", gatewayHandlers.ProxyRequest("sales"))
@@ -93,13 +159,28 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, gat
 	inventory := router.Group("/api/inventory")
 	inventory.Use(middleware.AuthMiddleware(cfg.JWT, cache))
 	inventory.Use(middleware.TenantMiddleware())
+	inventory.Use(rateLimit)
 	{
 		// Products
 		inventory.GET("/products", gatewayHandlers.ProxyRequest("inventory"))
 		inventory.POST("/products", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.POST("/products/bulk-status", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.POST("/products/import", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.POST("/products/catalog/snapshot", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.GET("/products/catalog/snapshots", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.GET("/products/catalog/diff", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.POST("/products/catalog/snapshots/prune", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.DELETE("/products/catalog/snapshots/:id", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.GET("/products/barcode/:code", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.GET("/products/groups", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.GET("/products/groups/:id", gatewayHandlers.ProxyRequest("inventory"))
 		inventory.GET("/products/:id", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.GET("/products/:id/ledger", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.GET("/products/:id/stock-overview", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.GET("/products/:id/price-history", gatewayHandlers.ProxyRequest("inventory"))
 		inventory.PUT("/products/:id", gatewayHandlers.ProxyRequest("inventory"))
 		inventory.DELETE("/products/:id", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.POST("/products/:id/restore", gatewayHandlers.ProxyRequest("inventory"))
 
 		// Categories
 		inventory.GET("/categories", gatewayHandlers.ProxyRequest("inventory"))
@@ -107,6 +188,7 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, gat
 		inventory.GET("/categories/:id", gatewayHandlers.ProxyRequest("inventory"))
 		inventory.PUT("/categories/:id", gatewayHandlers.ProxyRequest("inventory"))
 		inventory.DELETE("/categories/:id", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.POST("/categories/:id/restore", gatewayHandlers.ProxyRequest("inventory"))
 
 		// Brands
 		inventory.GET("/brands", gatewayHandlers.ProxyRequest("inventory"))
@@ -114,6 +196,13 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, gat
 		inventory.GET("/brands/:id", gatewayHandlers.ProxyRequest("inventory"))
 		inventory.PUT("/brands/:id", gatewayHandlers.ProxyRequest("inventory"))
 		inventory.DELETE("/brands/:id", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.POST("/brands/:id/restore", gatewayHandlers.ProxyRequest("inventory"))
+
+		// Tax rates
+		inventory.GET("/tax-rates", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.POST("/tax-rates", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.PUT("/tax-rates/:id", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.DELETE("/tax-rates/:id", gatewayHandlers.ProxyRequest("inventory"))
 
 		// Brand pricing
 		inventory.GET("/brand-pricing", gatewayHandlers.ProxyRequest("inventory"))
@@ -131,6 +220,7 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, gat
 		// Stock purchases
 		inventory.GET("/purchases", gatewayHandlers.ProxyRequest("inventory"))
 		inventory.POST("/purchases", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.POST("/purchases/reorder/preview", gatewayHandlers.ProxyRequest("inventory"))
 		inventory.GET("/purchases/:id", gatewayHandlers.ProxyRequest("inventory"))
 		inventory.PUT("/purchases/:id", gatewayHandlers.ProxyRequest("inventory"))
 		inventory.POST("/purchases/:id/receive", gatewayHandlers.ProxyRequest("inventory"))
@@ -140,12 +230,41 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, gat
 		inventory.GET("/transfers", gatewayHandlers.ProxyRequest("inventory"))
 		inventory.GET("/transfers/:id", gatewayHandlers.ProxyRequest("inventory"))
 		inventory.POST("/transfers/:id/approve", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.POST("/stocks/transfers/import", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.GET("/stocks/transfers", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.GET("/stocks/transfers/:id", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.POST("/stocks/transfers/:id/approve", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.POST("/stocks/transfers/:id/reject", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.PUT("/stocks/:id/costing-method", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.POST("/stocks/reservations", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.GET("/stocks/reservations/:reference_id", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.POST("/stocks/reservations/:reference_id/release", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.GET("/stocks/valuation", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.GET("/stocks/reorder-suggestions", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.GET("/stocks/expiry-risk", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.GET("/stocks/write-offs", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.POST("/stocks/write-offs", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.GET("/stocks/write-offs/:id", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.POST("/stocks/write-offs/:id/approve", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.POST("/stocks/write-offs/:id/reject", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.GET("/stocks/counts", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.POST("/stocks/counts", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.GET("/stocks/counts/:id", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.POST("/stocks/counts/:id/items", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.POST("/stocks/counts/:id/finalize", gatewayHandlers.ProxyRequest("inventory"))
+
+		// Reports
+		inventory.GET("/reports/dead-stock", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.GET("/reports/write-offs", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.GET("/reports/purchase-planning", gatewayHandlers.ProxyRequest("inventory"))
+		inventory.GET("/reports/valuation", gatewayHandlers.ProxyRequest("inventory"))
 	}
 
 	// Finance service routes (protected)
 	finance := router.Group("/api/finance")
 	finance.Use(middleware.AuthMiddleware(cfg.JWT, cache))
 	finance.Use(middleware.TenantMiddleware())
+	finance.Use(rateLimit)
 	{
 		// Vendors
 		finance.GET("/vendors", gatewayHandlers.ProxyRequest("finance"))
@@ -153,20 +272,38 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, gat
 		finance.GET("/vendors/:id", gatewayHandlers.ProxyRequest("finance"))
 		finance.PUT("/vendors/:id", gatewayHandlers.ProxyRequest("finance"))
 		finance.DELETE("/vendors/:id", gatewayHandlers.ProxyRequest("finance"))
+		finance.GET("/vendors/:id/statement", gatewayHandlers.ProxyRequest("finance"))
+		finance.POST("/vendors/transactions", gatewayHandlers.ProxyRequest("finance"))
+		finance.GET("/vendors/:id/invoices", gatewayHandlers.ProxyRequest("finance"))
+		finance.POST("/vendors/:id/invoices", gatewayHandlers.ProxyRequest("finance"))
 
 		// Bank accounts
 		finance.GET("/bank-accounts", gatewayHandlers.ProxyRequest("finance"))
 		finance.POST("/bank-accounts", gatewayHandlers.ProxyRequest("finance"))
+		finance.POST("/bank-accounts/transfer", gatewayHandlers.ProxyRequest("finance"))
 		finance.GET("/bank-accounts/:id", gatewayHandlers.ProxyRequest("finance"))
 		finance.PUT("/bank-accounts/:id", gatewayHandlers.ProxyRequest("finance"))
 		finance.DELETE("/bank-accounts/:id", gatewayHandlers.ProxyRequest("finance"))
 
+		// Accounting exports
+		finance.GET("/exports/accounting", gatewayHandlers.ProxyRequest("finance"))
+		finance.GET("/exports/account-mappings", gatewayHandlers.ProxyRequest("finance"))
+		finance.PUT("/exports/account-mappings", gatewayHandlers.ProxyRequest("finance"))
+
 		// Expenses
 		finance.GET("/expenses", gatewayHandlers.ProxyRequest("finance"))
 		finance.POST("/expenses", gatewayHandlers.ProxyRequest("finance"))
 		finance.GET("/expenses/:id", gatewayHandlers.ProxyRequest("finance"))
 		finance.PUT("/expenses/:id", gatewayHandlers.ProxyRequest("finance"))
 		finance.POST("/expenses/:id/approve", gatewayHandlers.ProxyRequest("finance"))
+		finance.POST("/expenses/:id/reject", gatewayHandlers.ProxyRequest("finance"))
+		finance.GET("/expenses/pending-summary", gatewayHandlers.ProxyRequest("finance"))
+		finance.POST("/expenses/:id/attachments", gatewayHandlers.ProxyRequest("finance"))
+		finance.GET("/expenses/:id/attachments", gatewayHandlers.ProxyRequest("finance"))
+		finance.GET("/expenses/:id/attachments/:attachment_id", gatewayHandlers.ProxyRequest("finance"))
+		finance.GET("/expenses/recurring", gatewayHandlers.ProxyRequest("finance"))
+		finance.POST("/expenses/recurring", gatewayHandlers.ProxyRequest("finance"))
+		finance.POST("/expenses/recurring/:id/disable", gatewayHandlers.ProxyRequest("finance"))
 
 		// Executive finance
 		finance.GET("/executive-finance", gatewayHandlers.ProxyRequest("finance"))
@@ -180,11 +317,19 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, gat
 		finance.GET("/money-collection/:id", gatewayHandlers.ProxyRequest("finance"))
 		finance.POST("/money-collection/:id/approve", gatewayHandlers.ProxyRequest("finance"))
 		finance.POST("/money-collection/:id/reject", gatewayHandlers.ProxyRequest("finance"))
+		finance.GET("/assistant-manager/executives/:id/balance", gatewayHandlers.ProxyRequest("finance"))
+		finance.GET("/assistant-manager/ledger", gatewayHandlers.ProxyRequest("finance"))
 
 		// Bank deposits
 		finance.POST("/bank-deposits", gatewayHandlers.ProxyRequest("finance"))
 		finance.GET("/bank-deposits", gatewayHandlers.ProxyRequest("finance"))
 		finance.POST("/bank-deposits/:id/approve", gatewayHandlers.ProxyRequest("finance"))
+		finance.POST("/bank-deposits/reconcile", gatewayHandlers.ProxyRequest("finance"))
+
+		// Cash drawer reconciliation
+		finance.POST("/cash-drawers", gatewayHandlers.ProxyRequest("finance"))
+		finance.GET("/cash-drawers", gatewayHandlers.ProxyRequest("finance"))
+		finance.POST("/cash-drawers/:id/resolve", gatewayHandlers.ProxyRequest("finance"))
 
 		// Stock verification
 		finance.POST("/stock-verification", gatewayHandlers.ProxyRequest("finance"))
@@ -199,30 +344,37 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, gat
 		finance.GET("/reports/profit-loss", gatewayHandlers.ProxyRequest("finance"))
 		finance.GET("/reports/balance-sheet", gatewayHandlers.ProxyRequest("finance"))
 		finance.GET("/reports/cash-flow", gatewayHandlers.ProxyRequest("finance"))
+		finance.GET("/reports/break-even", gatewayHandlers.ProxyRequest("finance"))
+		finance.GET("/reports/collection-sheet", gatewayHandlers.ProxyRequest("finance"))
 	}
 
 	// Tenant and user management (admin routes)
 	admin := router.Group("/api/admin")
 	admin.Use(middleware.AuthMiddleware(cfg.JWT, cache))
 	admin.Use(middleware.RoleMiddleware("admin", "saas_admin"))
+	admin.Use(rateLimit)
 	{
-		// Tenant management
-		admin.GET("/tenants", gatewayHandlers.ProxyRequest("auth"))
-		admin.POST("/tenants", gatewayHandlers.ProxyRequest("auth"))
-		admin.GET("/tenants/:id", gatewayHandlers.ProxyRequest("auth"))
-		admin.PUT("/tenants/:id", gatewayHandlers.ProxyRequest("auth"))
-
 		// Shop management
 		admin.GET("/shops", gatewayHandlers.ProxyRequest("auth"))
 		admin.POST("/shops", gatewayHandlers.ProxyRequest("auth"))
 		admin.GET("/shops/:id", gatewayHandlers.ProxyRequest("auth"))
 		admin.PUT("/shops/:id", gatewayHandlers.ProxyRequest("auth"))
 
+		// Shop group management
+		admin.GET("/shop-groups", gatewayHandlers.ProxyRequest("auth"))
+		admin.POST("/shop-groups", gatewayHandlers.ProxyRequest("auth"))
+		admin.GET("/shop-groups/:id", gatewayHandlers.ProxyRequest("auth"))
+		admin.PUT("/shop-groups/:id", gatewayHandlers.ProxyRequest("auth"))
+		admin.PUT("/shop-groups/:id/shops", gatewayHandlers.ProxyRequest("auth"))
+		admin.DELETE("/shop-groups/:id", gatewayHandlers.ProxyRequest("auth"))
+
 		// User management
 		admin.GET("/users", gatewayHandlers.ProxyRequest("auth"))
 		admin.POST("/users", gatewayHandlers.ProxyRequest("auth"))
 		admin.GET("/users/:id", gatewayHandlers.ProxyRequest("auth"))
 		admin.PUT("/users/:id", gatewayHandlers.ProxyRequest("auth"))
+		admin.PUT("/users/:id/auto-approval", gatewayHandlers.ProxyRequest("auth"))
+		admin.PUT("/users/:id/shop-group", gatewayHandlers.ProxyRequest("auth"))
 		admin.DELETE("/users/:id", gatewayHandlers.ProxyRequest("auth"))
 
 		// Salesman management
@@ -237,6 +389,30 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, gat
 		admin.POST("/roles", gatewayHandlers.ProxyRequest("auth"))
 		admin.GET("/permissions", gatewayHandlers.ProxyRequest("auth"))
 		admin.POST("/permissions", gatewayHandlers.ProxyRequest("auth"))
+		admin.PUT("/permissions/:role", gatewayHandlers.ProxyRequest("auth"))
+		admin.DELETE("/permissions/:role", gatewayHandlers.ProxyRequest("auth"))
+	}
+
+	// SaaS admin routes (served by auth service)
+	saasAdmin := router.Group("/api/saas-admin")
+	saasAdmin.Use(middleware.AuthMiddleware(cfg.JWT, cache))
+	saasAdmin.Use(middleware.RoleMiddleware("saas_admin", "super_admin"))
+	saasAdmin.Use(rateLimit)
+	{
+		// Tenant management (super admin only)
+		tenants := saasAdmin.Group("/tenants")
+		tenants.Use(middleware.RoleMiddleware("super_admin"))
+		{
+			tenants.GET("", gatewayHandlers.ProxyRequest("auth"))
+			tenants.POST("", gatewayHandlers.ProxyRequest("auth"))
+			tenants.GET("/:id", gatewayHandlers.ProxyRequest("auth"))
+			tenants.PUT("/:id", gatewayHandlers.ProxyRequest("auth"))
+			tenants.DELETE("/:id", gatewayHandlers.ProxyRequest("auth"))
+		}
+
+		saasAdmin.GET("/all-users", gatewayHandlers.ProxyRequest("auth"))
+		saasAdmin.GET("/all-shops", gatewayHandlers.ProxyRequest("auth"))
+		saasAdmin.GET("/stats", gatewayHandlers.ProxyRequest("auth"))
 	}
 
 	// Catch-all for frontend SPA routing (handles all non-API routes)
@@ -1,12 +1,20 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/liquorpro/go-backend/internal/inventory/services"
+	"github.com/liquorpro/go-backend/pkg/shared/approval"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/usage"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
 )
 
 type InventoryHandlers struct {
@@ -14,6 +22,10 @@ type InventoryHandlers struct {
 	stockService    *services.StockService
 	purchaseService *services.PurchaseService
 	categoryService *services.CategoryService
+	reportService   *services.ReportService
+	writeOffService *services.WriteOffService
+	catalogService  *services.CatalogService
+	countService    *services.StockCountService
 }
 
 func NewInventoryHandlers(
@@ -21,12 +33,20 @@ func NewInventoryHandlers(
 	stockService *services.StockService,
 	purchaseService *services.PurchaseService,
 	categoryService *services.CategoryService,
+	reportService *services.ReportService,
+	writeOffService *services.WriteOffService,
+	catalogService *services.CatalogService,
+	countService *services.StockCountService,
 ) *InventoryHandlers {
 	return &InventoryHandlers{
 		productService:  productService,
 		stockService:    stockService,
 		purchaseService: purchaseService,
 		categoryService: categoryService,
+		reportService:   reportService,
+		writeOffService: writeOffService,
+		catalogService:  catalogService,
+		countService:    countService,
 	}
 }
 
@@ -72,6 +92,15 @@ func (h *InventoryHandlers) CreateProduct(c *gin.Context) {
 
 	product, err := h.productService.CreateProduct(c.Request.Context(), req, tenantUUID)
 	if err != nil {
+		if status, ok := usage.HTTPStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		var deleted *services.DeletedDuplicateError
+		if errors.As(err, &deleted) {
+			h.serviceError(c, err)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -92,24 +121,29 @@ func (h *InventoryHandlers) GetProducts(c *gin.Context) {
 		return
 	}
 
+	// deleted=true lists soft-deleted products so they can be restored
+	if c.Query("deleted") == "true" {
+		if c.GetString("role") != "admin" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can list deleted products"})
+			return
+		}
+		products, err := h.productService.GetDeletedProducts(c.Request.Context(), tenantUUID, c.Query("search"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		list := utils.PaginateSlice(products, utils.ParsePageRequest(c, 50, 100))
+		utils.RespondPaginated(c, list, gin.H{"products": list.Data, "total": list.TotalCount})
+		return
+	}
+
 	// Parse query parameters
 	categoryIDStr := c.Query("category_id")
 	brandIDStr := c.Query("brand_id")
 	search := c.Query("search")
 	includeInactive := c.Query("include_inactive") == "true"
 
-	limitStr := c.DefaultQuery("limit", "50")
-	offsetStr := c.DefaultQuery("offset", "0")
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 || limit > 100 {
-		limit = 50
-	}
-
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		offset = 0
-	}
+	page := utils.ParsePageRequest(c, 50, 100)
 
 	var categoryID, brandID *uuid.UUID
 	if categoryIDStr != "" {
@@ -125,8 +159,8 @@ func (h *InventoryHandlers) GetProducts(c *gin.Context) {
 
 	filters := services.ProductFilters{
 		Search:   search,
-		Page:     offset/limit + 1,
-		PageSize: limit,
+		Page:     page.Page,
+		PageSize: page.PageSize,
 	}
 	if categoryID != nil {
 		filters.CategoryID = *categoryID
@@ -145,14 +179,95 @@ func (h *InventoryHandlers) GetProducts(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"products": response.Products,
-		"total":    response.TotalCount,
-		"limit":    limit,
-		"offset":   offset,
+	list := utils.NewPaginatedResponse(response.Products, response.TotalCount, page)
+	utils.RespondPaginated(c, list, gin.H{
+		"products": list.Data,
+		"total":    list.TotalCount,
+		"limit":    page.PageSize,
+		"offset":   page.Offset,
+	})
+}
+
+// GetProductGroups lists products grouped by brand and name with all their sizes
+func (h *InventoryHandlers) GetProductGroups(c *gin.Context) {
+	tenantUUID, _, ok := h.tenantAndUser(c)
+	if !ok {
+		return
+	}
+
+	page := utils.ParsePageRequest(c, 50, 100)
+	filters := services.ProductFilters{
+		Search:   c.Query("search"),
+		Page:     page.Page,
+		PageSize: page.PageSize,
+	}
+	if parsed, err := uuid.Parse(c.Query("category_id")); err == nil {
+		filters.CategoryID = parsed
+	}
+	if parsed, err := uuid.Parse(c.Query("brand_id")); err == nil {
+		filters.BrandID = parsed
+	}
+	if c.Query("include_inactive") != "true" {
+		active := true
+		filters.IsActive = &active
+	}
+
+	response, err := h.productService.GetProductGroups(c.Request.Context(), tenantUUID, filters)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	list := utils.NewPaginatedResponse(response.Groups, response.TotalCount, page)
+	utils.RespondPaginated(c, list, gin.H{
+		"groups": list.Data,
+		"total":  list.TotalCount,
 	})
 }
 
+// GetProductGroup returns every size of a product group with combined stock and
+// the units sold between start_date and end_date (default: the last 30 days)
+func (h *InventoryHandlers) GetProductGroup(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product group ID"})
+		return
+	}
+
+	tenantUUID, _, ok := h.tenantAndUser(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1)
+	start := end.AddDate(0, 0, -30)
+	if startStr := c.Query("start_date"); startStr != "" {
+		parsed, err := time.Parse("2006-01-02", startStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must be YYYY-MM-DD"})
+			return
+		}
+		start = parsed
+	}
+	if endStr := c.Query("end_date"); endStr != "" {
+		parsed, err := time.Parse("2006-01-02", endStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must be YYYY-MM-DD"})
+			return
+		}
+		end = parsed.AddDate(0, 0, 1)
+	}
+
+	group, err := h.productService.GetProductGroup(c.Request.Context(), id, tenantUUID, start, end)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
 func (h *InventoryHandlers) GetProductByID(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -186,6 +301,159 @@ func (h *InventoryHandlers) GetProductByID(c *gin.Context) {
 	c.JSON(http.StatusOK, product)
 }
 
+// GetProductByBarcode returns the product matching a scanned barcode, with its stock
+// at shop_id when given
+func (h *InventoryHandlers) GetProductByBarcode(c *gin.Context) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+		return
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	var shopID *uuid.UUID
+	if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		parsed, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop ID"})
+			return
+		}
+		shopID = &parsed
+	}
+
+	product, err := h.productService.GetProductByBarcode(c.Request.Context(), c.Param("code"), tenantUUID, shopID)
+	if err != nil {
+		switch err.Error() {
+		case "product not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "barcode is required":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, product)
+}
+
+// GetProductStockOverview returns a product's stock consolidated across shops
+func (h *InventoryHandlers) GetProductStockOverview(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+		return
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	overview, err := h.stockService.GetProductStockOverview(c.Request.Context(), id, tenantUUID)
+	if err != nil {
+		if err.Error() == "product not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, overview)
+}
+
+// GetProductLedger returns a product's stock movements across all shops
+func (h *InventoryHandlers) GetProductLedger(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+		return
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	// end_date is inclusive
+	var dateRange services.DateRange
+	if startStr := c.Query("start_date"); startStr != "" {
+		parsed, err := time.Parse("2006-01-02", startStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date, expected YYYY-MM-DD"})
+			return
+		}
+		dateRange.Start = &parsed
+	}
+	if endStr := c.Query("end_date"); endStr != "" {
+		parsed, err := time.Parse("2006-01-02", endStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date, expected YYYY-MM-DD"})
+			return
+		}
+		end := parsed.AddDate(0, 0, 1)
+		dateRange.End = &end
+	}
+	if dateRange.Start != nil && dateRange.End != nil && !dateRange.End.After(*dateRange.Start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+		return
+	}
+
+	ledger, err := h.stockService.GetProductLedger(c.Request.Context(), id, tenantUUID, dateRange)
+	if err != nil {
+		if err.Error() == "product not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ledger)
+}
+
+// GetProductPriceHistory lists a product's price changes, newest first
+func (h *InventoryHandlers) GetProductPriceHistory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	tenantUUID, _, ok := h.tenantAndUser(c)
+	if !ok {
+		return
+	}
+
+	history, err := h.productService.GetPriceHistory(c.Request.Context(), id, tenantUUID)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"history": history})
+}
+
 func (h *InventoryHandlers) UpdateProduct(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -218,18 +486,23 @@ func (h *InventoryHandlers) UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	_, err = uuid.Parse(userID.(string))
+	userUUID, err := uuid.Parse(userID.(string))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
 		return
 	}
 
-	product, err := h.productService.UpdateProduct(c.Request.Context(), id, tenantUUID, req)
+	product, err := h.productService.UpdateProduct(c.Request.Context(), id, tenantUUID, userUUID, req)
 	if err != nil {
 		if err.Error() == "product not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		var deleted *services.DeletedDuplicateError
+		if errors.As(err, &deleted) {
+			h.serviceError(c, err)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -270,6 +543,60 @@ func (h *InventoryHandlers) DeleteProduct(c *gin.Context) {
 	c.JSON(http.StatusNoContent, nil)
 }
 
+// RestoreProduct brings back a deleted product
+func (h *InventoryHandlers) RestoreProduct(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	tenantUUID, _, ok := h.tenantAndUser(c)
+	if !ok {
+		return
+	}
+
+	product, err := h.productService.RestoreProduct(c.Request.Context(), id, tenantUUID)
+	if err != nil {
+		if status, ok := usage.HTTPStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, product)
+}
+
+func (h *InventoryHandlers) BulkSetProductStatus(c *gin.Context) {
+	var req services.BulkProductStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+		return
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	result, err := h.productService.BulkSetActive(c.Request.Context(), req.ProductIDs, *req.IsActive, tenantUUID, req.WarnOnStock)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 // Stock handlers
 func (h *InventoryHandlers) GetStocks(c *gin.Context) {
 	tenantID, exists := c.Get("tenant_id")
@@ -312,7 +639,9 @@ func (h *InventoryHandlers) GetStocks(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"stocks": stocks})
+	// Stock lists are loaded whole; without paging parameters they are returned whole
+	list := utils.PaginateSlice(stocks, utils.ParsePageRequest(c, 100, 500))
+	utils.RespondPaginated(c, list, gin.H{"stocks": list.Data})
 }
 
 func (h *InventoryHandlers) GetStockMovements(c *gin.Context) {
@@ -322,35 +651,70 @@ func (h *InventoryHandlers) GetStockMovements(c *gin.Context) {
 		return
 	}
 
-	_, err := uuid.Parse(tenantID.(string))
+	tenantUUID, err := uuid.Parse(tenantID.(string))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
 		return
 	}
 
 
-	limitStr := c.DefaultQuery("limit", "50")
-	offsetStr := c.DefaultQuery("offset", "0")
+	page := utils.ParsePageRequest(c, 50, 100)
 
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 || limit > 100 {
-		limit = 50
+	filters := services.StockMovementFilters{
+		MovementType: c.Query("movement_type"),
+	}
+	if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		shopID, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop ID"})
+			return
+		}
+		filters.ShopID = &shopID
+	}
+	if productIDStr := c.Query("product_id"); productIDStr != "" {
+		productID, err := uuid.Parse(productIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+			return
+		}
+		filters.ProductID = &productID
 	}
 
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		offset = 0
+	// end_date is inclusive
+	if startStr := c.Query("start_date"); startStr != "" {
+		parsed, err := time.Parse("2006-01-02", startStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date, expected YYYY-MM-DD"})
+			return
+		}
+		filters.DateRange.Start = &parsed
+	}
+	if endStr := c.Query("end_date"); endStr != "" {
+		parsed, err := time.Parse("2006-01-02", endStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date, expected YYYY-MM-DD"})
+			return
+		}
+		end := parsed.AddDate(0, 0, 1)
+		filters.DateRange.End = &end
+	}
+	if filters.DateRange.Start != nil && filters.DateRange.End != nil && !filters.DateRange.End.After(*filters.DateRange.Start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+		return
 	}
 
-	// For simplicity, return empty movements as this requires complex query implementation
-	movements := []*services.StockHistoryResponse{}
-	total := int64(0)
+	movements, total, err := h.stockService.GetAllStockMovements(c.Request.Context(), tenantUUID, filters, page.PageSize, page.Offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"movements": movements,
-		"total":     total,
-		"limit":     limit,
-		"offset":    offset,
+	list := utils.NewPaginatedResponse(movements, total, page)
+	utils.RespondPaginated(c, list, gin.H{
+		"movements": list.Data,
+		"total":     list.TotalCount,
+		"limit":     page.PageSize,
+		"offset":    page.Offset,
 	})
 }
 
@@ -428,178 +792,1199 @@ func (h *InventoryHandlers) TransferStock(c *gin.Context) {
 		return
 	}
 
-	err = h.stockService.CreateStockTransfer(c.Request.Context(), req, tenantUUID, userUUID)
+	transfer, err := h.stockService.CreateStockTransfer(c.Request.Context(), req, tenantUUID, userUUID)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	message := "Stock transferred successfully"
+	if transfer.Status == models.StatusPending {
+		message = "Stock transfer submitted for approval"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   message,
+		"reference": transfer.Reference,
+		"transfer":  transfer,
+	})
+}
+
+// maxTransferImportSize caps uploaded transfer CSV files
+const maxTransferImportSize = 5 << 20
+
+// GetStockTransfers lists stock transfers, optionally by shop and status
+func (h *InventoryHandlers) GetStockTransfers(c *gin.Context) {
+	tenantUUID, _, ok := h.tenantAndUser(c)
+	if !ok {
+		return
+	}
+
+	var shopID *uuid.UUID
+	if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		parsed, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop ID"})
+			return
+		}
+		shopID = &parsed
+	}
+
+	page := utils.ParsePageRequest(c, 50, 100)
+
+	transfers, total, err := h.stockService.GetStockTransfers(c.Request.Context(), tenantUUID, shopID, c.Query("status"), page.PageSize, page.Offset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Stock transferred successfully"})
+	list := utils.NewPaginatedResponse(transfers, total, page)
+	utils.RespondPaginated(c, list, gin.H{
+		"transfers": list.Data,
+		"total":     list.TotalCount,
+		"limit":     page.PageSize,
+		"offset":    page.Offset,
+	})
 }
 
-// Purchase handlers
-func (h *InventoryHandlers) CreatePurchase(c *gin.Context) {
-	var req services.PurchaseRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+// GetStockTransferByID returns a stock transfer with its items
+func (h *InventoryHandlers) GetStockTransferByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transfer ID"})
+		return
+	}
+
+	tenantUUID, _, ok := h.tenantAndUser(c)
+	if !ok {
+		return
+	}
+
+	transfer, err := h.stockService.GetStockTransferByID(c.Request.Context(), id, tenantUUID)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer)
+}
+
+// ApproveStockTransfer moves the stock of a pending transfer
+func (h *InventoryHandlers) ApproveStockTransfer(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transfer ID"})
+		return
+	}
+
+	tenantUUID, userUUID, ok := h.tenantAndUser(c)
+	if !ok {
+		return
+	}
+
+	transfer, err := h.stockService.ApproveStockTransfer(c.Request.Context(), id, tenantUUID, userUUID)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer)
+}
+
+// RejectStockTransfer cancels a pending transfer
+func (h *InventoryHandlers) RejectStockTransfer(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transfer ID"})
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantUUID, userUUID, ok := h.tenantAndUser(c)
+	if !ok {
+		return
+	}
+
+	transfer, err := h.stockService.RejectStockTransfer(c.Request.Context(), id, tenantUUID, userUUID, req.Reason)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer)
+}
+
+// UpdateStockCostingMethod sets the costing method of a stock record
+func (h *InventoryHandlers) UpdateStockCostingMethod(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid stock ID"})
+		return
+	}
+
+	var req struct {
+		CostingMethod string `json:"costing_method" binding:"required,oneof=fifo lifo average"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantUUID, _, ok := h.tenantAndUser(c)
+	if !ok {
+		return
+	}
+
+	stock, err := h.stockService.UpdateStockCostingMethod(c.Request.Context(), id, tenantUUID, req.CostingMethod)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stock)
+}
+
+// ImportStockTransfers creates transfers from a CSV of product, from_shop, to_shop,
+// quantity rows, sent either as a multipart "file" field or as the raw request body
+func (h *InventoryHandlers) ImportStockTransfers(c *gin.Context) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	opts := services.TransferImportOptions{
+		Notes:  c.Query("notes"),
+		DryRun: c.Query("dry_run") == "true",
+	}
+	if dateStr := c.Query("transfer_date"); dateStr != "" {
+		parsed, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transfer_date, expected YYYY-MM-DD"})
+			return
+		}
+		opts.TransferDate = parsed
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxTransferImportSize)
+	body := io.Reader(c.Request.Body)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "CSV file is required in the \"file\" field"})
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	result, err := h.stockService.ImportStockTransfers(c.Request.Context(), body, opts, tenantUUID, userUUID)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "failed to") {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(result.Errors) > 0 {
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// maxProductImportSize caps uploaded product CSV files
+const maxProductImportSize = 5 << 20
+
+// ImportProducts creates products from a CSV uploaded as a multipart "file" field
+// or sent as the raw request body. Rows fail individually, so the response lists
+// the outcome of every row.
+func (h *InventoryHandlers) ImportProducts(c *gin.Context) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+		return
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxProductImportSize)
+	body := io.Reader(c.Request.Body)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "CSV file is required in the \"file\" field"})
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	result, err := h.productService.BulkImportProducts(c.Request.Context(), tenantUUID, body)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "failed to") {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Purchase handlers
+func (h *InventoryHandlers) CreatePurchase(c *gin.Context) {
+	var req services.PurchaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	purchase, err := h.purchaseService.CreatePurchase(c.Request.Context(), req, tenantUUID, userUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, purchase)
+}
+
+// PreviewReorder prices suggested reorder quantities without creating purchases
+func (h *InventoryHandlers) PreviewReorder(c *gin.Context) {
+	var req services.ReorderPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+		return
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	preview, err := h.purchaseService.PreviewReorder(c.Request.Context(), req, tenantUUID)
+	if err != nil {
+		if strings.HasSuffix(err.Error(), "not found") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+func (h *InventoryHandlers) GetPurchases(c *gin.Context) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+		return
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	shopIDStr := c.Query("shop_id")
+	status := c.Query("status")
+
+	var shopID *uuid.UUID
+	if shopIDStr != "" {
+		if parsed, err := uuid.Parse(shopIDStr); err == nil {
+			shopID = &parsed
+		}
+	}
+
+	page := utils.ParsePageRequest(c, 50, 100)
+
+	purchases, total, err := h.purchaseService.GetPurchases(c.Request.Context(), tenantUUID, shopID, status, page.PageSize, page.Offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	list := utils.NewPaginatedResponse(purchases, total, page)
+	utils.RespondPaginated(c, list, gin.H{
+		"purchases": list.Data,
+		"total":     list.TotalCount,
+		"limit":     page.PageSize,
+		"offset":    page.Offset,
+	})
+}
+
+func (h *InventoryHandlers) GetPurchaseByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid purchase ID"})
+		return
+	}
+
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+		return
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	purchase, err := h.purchaseService.GetPurchaseByID(c.Request.Context(), id, tenantUUID)
+	if err != nil {
+		if err.Error() == "purchase not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, purchase)
+}
+
+func (h *InventoryHandlers) ReceivePurchase(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid purchase ID"})
+		return
+	}
+
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	err = h.purchaseService.ReceivePurchase(c.Request.Context(), id, tenantUUID, userUUID)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Purchase received successfully"})
+}
+
+// Write-off handlers
+func (h *InventoryHandlers) CreateWriteOff(c *gin.Context) {
+	var req services.WriteOffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantUUID, userUUID, ok := h.tenantAndUser(c)
+	if !ok {
+		return
+	}
+
+	writeOff, err := h.writeOffService.CreateWriteOff(c.Request.Context(), req, tenantUUID, userUUID)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, writeOff)
+}
+
+func (h *InventoryHandlers) GetWriteOffs(c *gin.Context) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+		return
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	var shopID *uuid.UUID
+	if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		parsed, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop ID"})
+			return
+		}
+		shopID = &parsed
+	}
+
+	page := utils.ParsePageRequest(c, 50, 100)
+
+	writeOffs, total, err := h.writeOffService.GetWriteOffs(c.Request.Context(), tenantUUID, shopID, c.Query("status"), page.PageSize, page.Offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	list := utils.NewPaginatedResponse(writeOffs, total, page)
+	utils.RespondPaginated(c, list, gin.H{
+		"write_offs": list.Data,
+		"total":      list.TotalCount,
+		"limit":      page.PageSize,
+		"offset":     page.Offset,
+	})
+}
+
+func (h *InventoryHandlers) GetWriteOffByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid write-off ID"})
+		return
+	}
+
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+		return
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	writeOff, err := h.writeOffService.GetWriteOffByID(c.Request.Context(), id, tenantUUID)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, writeOff)
+}
+
+func (h *InventoryHandlers) ApproveWriteOff(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid write-off ID"})
+		return
+	}
+
+	tenantUUID, userUUID, ok := h.tenantAndUser(c)
+	if !ok {
+		return
+	}
+
+	writeOff, err := h.writeOffService.ApproveWriteOff(c.Request.Context(), id, tenantUUID, userUUID)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, writeOff)
+}
+
+func (h *InventoryHandlers) RejectWriteOff(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid write-off ID"})
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantUUID, userUUID, ok := h.tenantAndUser(c)
+	if !ok {
+		return
+	}
+
+	writeOff, err := h.writeOffService.RejectWriteOff(c.Request.Context(), id, tenantUUID, userUUID, req.Reason)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, writeOff)
+}
+
+// Stock count handlers
+func (h *InventoryHandlers) CreateStockCount(c *gin.Context) {
+	var req services.StockCountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantUUID, userUUID, ok := h.tenantAndUser(c)
+	if !ok {
+		return
+	}
+
+	count, err := h.countService.CreateStockCount(c.Request.Context(), req, tenantUUID, userUUID)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, count)
+}
+
+func (h *InventoryHandlers) GetStockCounts(c *gin.Context) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+		return
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	var shopID *uuid.UUID
+	if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		parsed, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop ID"})
+			return
+		}
+		shopID = &parsed
+	}
+
+	counts, err := h.countService.GetStockCounts(c.Request.Context(), tenantUUID, shopID, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, counts)
+}
+
+// GetStockCount returns a stock count with its discrepancy report
+func (h *InventoryHandlers) GetStockCount(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid stock count ID"})
+		return
+	}
+
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+		return
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	count, err := h.countService.GetStockCount(c.Request.Context(), id, tenantUUID)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, count)
+}
+
+// AddStockCountItems saves counted quantities on a count in progress
+func (h *InventoryHandlers) AddStockCountItems(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid stock count ID"})
+		return
+	}
+
+	var req services.StockCountItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantUUID, _, ok := h.tenantAndUser(c)
+	if !ok {
+		return
+	}
+
+	count, err := h.countService.SaveStockCountItems(c.Request.Context(), id, tenantUUID, req)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, count)
+}
+
+// FinalizeStockCount adjusts stock to the counted quantities
+func (h *InventoryHandlers) FinalizeStockCount(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid stock count ID"})
+		return
+	}
+
+	tenantUUID, userUUID, ok := h.tenantAndUser(c)
+	if !ok {
+		return
+	}
+
+	count, err := h.countService.FinalizeStockCount(c.Request.Context(), id, tenantUUID, userUUID)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, count)
+}
+
+// GetWriteOffReport totals approved write-offs by reason; defaults to the current month
+func (h *InventoryHandlers) GetWriteOffReport(c *gin.Context) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+		return
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	var shopID *uuid.UUID
+	if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		parsed, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop ID"})
+			return
+		}
+		shopID = &parsed
+	}
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	end := start.AddDate(0, 1, 0)
+	if startStr := c.Query("start_date"); startStr != "" {
+		parsed, err := time.Parse("2006-01-02", startStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must be YYYY-MM-DD"})
+			return
+		}
+		start = parsed
+	}
+	if endStr := c.Query("end_date"); endStr != "" {
+		parsed, err := time.Parse("2006-01-02", endStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must be YYYY-MM-DD"})
+			return
+		}
+		end = parsed.AddDate(0, 0, 1)
+	}
+	if !end.After(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+		return
+	}
+
+	report, err := h.writeOffService.GetWriteOffReport(c.Request.Context(), tenantUUID, shopID, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetPurchasePlanning compares planned purchasing with purchases ordered and received;
+// defaults to the last 30 days
+func (h *InventoryHandlers) GetPurchasePlanning(c *gin.Context) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+		return
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	var shopID *uuid.UUID
+	if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		parsed, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop ID"})
+			return
+		}
+		shopID = &parsed
+	}
+
+	now := time.Now()
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1)
+	start := end.AddDate(0, 0, -30)
+	if startStr := c.Query("start_date"); startStr != "" {
+		parsed, err := time.Parse("2006-01-02", startStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must be YYYY-MM-DD"})
+			return
+		}
+		start = parsed
+	}
+	if endStr := c.Query("end_date"); endStr != "" {
+		parsed, err := time.Parse("2006-01-02", endStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must be YYYY-MM-DD"})
+			return
+		}
+		end = parsed.AddDate(0, 0, 1)
+	}
+
+	tolerance, err := strconv.ParseFloat(c.DefaultQuery("tolerance", "10"), 64)
+	if err != nil || tolerance < 0 || tolerance > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tolerance must be between 0 and 100"})
+		return
+	}
+
+	report, err := h.reportService.GetPurchasePlanning(c.Request.Context(), tenantUUID, shopID, start, end, tolerance)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "failed to") {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Stock reservation handlers
+
+// ReserveStock holds stock at a shop for a pending document
+func (h *InventoryHandlers) ReserveStock(c *gin.Context) {
+	var req services.StockReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantUUID, userUUID, ok := h.tenantAndUser(c)
+	if !ok {
+		return
+	}
+
+	reservations, err := h.stockService.ReserveStock(c.Request.Context(), req, tenantUUID, userUUID)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"reservations": reservations})
+}
+
+// ReleaseReservation releases the stock held for a document
+func (h *InventoryHandlers) ReleaseReservation(c *gin.Context) {
+	referenceID, err := uuid.Parse(c.Param("reference_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reference ID"})
+		return
+	}
+
+	tenantUUID, userUUID, ok := h.tenantAndUser(c)
+	if !ok {
+		return
+	}
+
+	reservations, err := h.stockService.ReleaseReservation(c.Request.Context(), referenceID, tenantUUID, userUUID)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reservations": reservations})
+}
+
+// GetReservations lists the reservations held for a document
+func (h *InventoryHandlers) GetReservations(c *gin.Context) {
+	referenceID, err := uuid.Parse(c.Param("reference_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reference ID"})
+		return
+	}
+
+	tenantUUID, _, ok := h.tenantAndUser(c)
+	if !ok {
+		return
+	}
+
+	reservations, err := h.stockService.GetReservations(c.Request.Context(), referenceID, tenantUUID)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reservations": reservations})
+}
+
+// tenantAndUser reads the tenant and user IDs set by the auth middleware, writing the
+// error response itself when either is missing or malformed
+func (h *InventoryHandlers) tenantAndUser(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return tenantUUID, userUUID, true
+}
+
+func (h *InventoryHandlers) serviceError(c *gin.Context, err error) {
+	var processed *approval.AlreadyProcessedError
+	var deleted *services.DeletedDuplicateError
+	switch {
+	case errors.As(err, &processed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "status": processed.Status})
+	case errors.As(err, &deleted):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "deleted_id": deleted.ID})
+	case strings.HasSuffix(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "failed to"):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+// Tax rate handlers
+
+// CreateTaxRate sets the GST rate of a category or product
+func (h *InventoryHandlers) CreateTaxRate(c *gin.Context) {
+	tenantUUID, userUUID, ok := h.tenantAndUser(c)
+	if !ok {
+		return
+	}
+
+	var req services.TaxRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rate, err := h.categoryService.CreateTaxRate(c.Request.Context(), req, tenantUUID, userUUID)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, rate)
+}
+
+// GetTaxRates lists the tenant's category and product tax rates
+func (h *InventoryHandlers) GetTaxRates(c *gin.Context) {
+	tenantUUID, _, ok := h.tenantAndUser(c)
+	if !ok {
 		return
 	}
 
-	tenantID, exists := c.Get("tenant_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+	rates, err := h.categoryService.GetTaxRates(c.Request.Context(), tenantUUID)
+	if err != nil {
+		h.serviceError(c, err)
 		return
 	}
 
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+	c.JSON(http.StatusOK, gin.H{"tax_rates": rates})
+}
+
+// UpdateTaxRate changes a tax rate
+func (h *InventoryHandlers) UpdateTaxRate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tax rate ID"})
 		return
 	}
 
-	tenantUUID, err := uuid.Parse(tenantID.(string))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+	tenantUUID, _, ok := h.tenantAndUser(c)
+	if !ok {
 		return
 	}
 
-	userUUID, err := uuid.Parse(userID.(string))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+	var req services.TaxRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	purchase, err := h.purchaseService.CreatePurchase(c.Request.Context(), req, tenantUUID, userUUID)
+	rate, err := h.categoryService.UpdateTaxRate(c.Request.Context(), id, req, tenantUUID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.serviceError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, purchase)
+	c.JSON(http.StatusOK, rate)
 }
 
-func (h *InventoryHandlers) GetPurchases(c *gin.Context) {
-	tenantID, exists := c.Get("tenant_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+// DeleteTaxRate removes a tax rate
+func (h *InventoryHandlers) DeleteTaxRate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tax rate ID"})
 		return
 	}
 
-	tenantUUID, err := uuid.Parse(tenantID.(string))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+	tenantUUID, _, ok := h.tenantAndUser(c)
+	if !ok {
 		return
 	}
 
-	shopIDStr := c.Query("shop_id")
-	status := c.Query("status")
-
-	var shopID *uuid.UUID
-	if shopIDStr != "" {
-		if parsed, err := uuid.Parse(shopIDStr); err == nil {
-			shopID = &parsed
-		}
+	if err := h.categoryService.DeleteTaxRate(c.Request.Context(), id, tenantUUID); err != nil {
+		h.serviceError(c, err)
+		return
 	}
 
-	limitStr := c.DefaultQuery("limit", "50")
-	offsetStr := c.DefaultQuery("offset", "0")
+	c.JSON(http.StatusOK, gin.H{"message": "Tax rate deleted"})
+}
+
+// Catalog snapshot handlers
 
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 || limit > 100 {
-		limit = 50
+// CreateCatalogSnapshot stores the current product catalog as a reviewable version
+func (h *InventoryHandlers) CreateCatalogSnapshot(c *gin.Context) {
+	tenantUUID, userUUID, ok := h.tenantAndUser(c)
+	if !ok {
+		return
 	}
 
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		offset = 0
+	var req services.CatalogSnapshotRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 	}
 
-	purchases, total, err := h.purchaseService.GetPurchases(c.Request.Context(), tenantUUID, shopID, status, limit, offset)
+	snapshot, err := h.catalogService.CreateSnapshot(c.Request.Context(), req, tenantUUID, userUUID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"purchases": purchases,
-		"total":     total,
-		"limit":     limit,
-		"offset":    offset,
-	})
+	c.JSON(http.StatusCreated, snapshot)
 }
 
-func (h *InventoryHandlers) GetPurchaseByID(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := uuid.Parse(idStr)
+// GetCatalogSnapshots lists catalog snapshots, newest first
+func (h *InventoryHandlers) GetCatalogSnapshots(c *gin.Context) {
+	tenantUUID, _, ok := h.tenantAndUser(c)
+	if !ok {
+		return
+	}
+
+	page := utils.ParsePageRequest(c, 50, 100)
+
+	snapshots, total, err := h.catalogService.GetSnapshots(c.Request.Context(), tenantUUID, page.PageSize, page.Offset)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid purchase ID"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	tenantID, exists := c.Get("tenant_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+	list := utils.NewPaginatedResponse(snapshots, total, page)
+	utils.RespondPaginated(c, list, gin.H{
+		"snapshots": list.Data,
+		"total":     list.TotalCount,
+		"limit":     page.PageSize,
+		"offset":    page.Offset,
+	})
+}
+
+// DiffCatalogSnapshots shows products added, removed or changed between two
+// snapshots; without `to` the snapshot is compared with the live catalog
+func (h *InventoryHandlers) DiffCatalogSnapshots(c *gin.Context) {
+	tenantUUID, _, ok := h.tenantAndUser(c)
+	if !ok {
 		return
 	}
 
-	tenantUUID, err := uuid.Parse(tenantID.(string))
+	fromID, err := uuid.Parse(c.Query("from"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a snapshot ID"})
 		return
 	}
 
-	purchase, err := h.purchaseService.GetPurchaseByID(c.Request.Context(), id, tenantUUID)
-	if err != nil {
-		if err.Error() == "purchase not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	var toID *uuid.UUID
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := uuid.Parse(toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a snapshot ID"})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		toID = &parsed
+	}
+
+	diff, err := h.catalogService.DiffSnapshots(c.Request.Context(), tenantUUID, fromID, toID)
+	if err != nil {
+		h.serviceError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, purchase)
+	c.JSON(http.StatusOK, diff)
 }
 
-func (h *InventoryHandlers) ReceivePurchase(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid purchase ID"})
+// DeleteCatalogSnapshot permanently deletes a catalog snapshot
+func (h *InventoryHandlers) DeleteCatalogSnapshot(c *gin.Context) {
+	tenantUUID, _, ok := h.tenantAndUser(c)
+	if !ok {
 		return
 	}
 
-	tenantID, exists := c.Get("tenant_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid snapshot ID"})
 		return
 	}
 
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+	if err := h.catalogService.DeleteSnapshot(c.Request.Context(), id, tenantUUID); err != nil {
+		h.serviceError(c, err)
 		return
 	}
 
-	tenantUUID, err := uuid.Parse(tenantID.(string))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+	c.JSON(http.StatusOK, gin.H{"message": "Catalog snapshot deleted successfully"})
+}
+
+// PruneCatalogSnapshots deletes old catalog snapshots
+func (h *InventoryHandlers) PruneCatalogSnapshots(c *gin.Context) {
+	tenantUUID, _, ok := h.tenantAndUser(c)
+	if !ok {
 		return
 	}
 
-	userUUID, err := uuid.Parse(userID.(string))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+	var req services.CatalogPruneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	err = h.purchaseService.ReceivePurchase(c.Request.Context(), id, tenantUUID, userUUID)
+	deleted, err := h.catalogService.PruneSnapshots(c.Request.Context(), tenantUUID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.serviceError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Purchase received successfully"})
+	c.JSON(http.StatusOK, gin.H{"deleted": deleted})
 }
 
 // Category handlers
@@ -656,6 +2041,22 @@ func (h *InventoryHandlers) GetCategories(c *gin.Context) {
 		return
 	}
 
+	// deleted=true lists soft-deleted categories so they can be restored
+	if c.Query("deleted") == "true" {
+		if c.GetString("role") != "admin" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can list deleted categories"})
+			return
+		}
+		categories, err := h.categoryService.GetDeletedCategories(c.Request.Context(), tenantUUID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		list := utils.PaginateSlice(categories, utils.ParsePageRequest(c, 50, 100))
+		utils.RespondPaginated(c, list, gin.H{"categories": list.Data})
+		return
+	}
+
 	includeInactive := c.Query("include_inactive") == "true"
 
 	categories, err := h.categoryService.GetCategories(c.Request.Context(), tenantUUID, includeInactive)
@@ -664,7 +2065,8 @@ func (h *InventoryHandlers) GetCategories(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"categories": categories})
+	list := utils.PaginateSlice(categories, utils.ParsePageRequest(c, 50, 100))
+	utils.RespondPaginated(c, list, gin.H{"categories": list.Data})
 }
 
 func (h *InventoryHandlers) GetCategoryByID(c *gin.Context) {
@@ -780,6 +2182,28 @@ func (h *InventoryHandlers) DeleteCategory(c *gin.Context) {
 	c.JSON(http.StatusNoContent, nil)
 }
 
+// RestoreCategory brings back a deleted category
+func (h *InventoryHandlers) RestoreCategory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	tenantUUID, _, ok := h.tenantAndUser(c)
+	if !ok {
+		return
+	}
+
+	category, err := h.categoryService.RestoreCategory(c.Request.Context(), id, tenantUUID)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
 // Brand handlers
 func (h *InventoryHandlers) CreateBrand(c *gin.Context) {
 	var req services.BrandRequest
@@ -834,6 +2258,22 @@ func (h *InventoryHandlers) GetBrands(c *gin.Context) {
 		return
 	}
 
+	// deleted=true lists soft-deleted brands so they can be restored
+	if c.Query("deleted") == "true" {
+		if c.GetString("role") != "admin" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can list deleted brands"})
+			return
+		}
+		brands, err := h.categoryService.GetDeletedBrands(c.Request.Context(), tenantUUID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		list := utils.PaginateSlice(brands, utils.ParsePageRequest(c, 50, 100))
+		utils.RespondPaginated(c, list, gin.H{"brands": list.Data})
+		return
+	}
+
 	includeInactive := c.Query("include_inactive") == "true"
 
 	brands, err := h.categoryService.GetBrands(c.Request.Context(), tenantUUID, includeInactive)
@@ -842,7 +2282,8 @@ func (h *InventoryHandlers) GetBrands(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"brands": brands})
+	list := utils.PaginateSlice(brands, utils.ParsePageRequest(c, 50, 100))
+	utils.RespondPaginated(c, list, gin.H{"brands": list.Data})
 }
 
 func (h *InventoryHandlers) GetBrandByID(c *gin.Context) {
@@ -956,4 +2397,187 @@ func (h *InventoryHandlers) DeleteBrand(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusNoContent, nil)
-}
\ No newline at end of file
+}
+
+// RestoreBrand brings back a deleted brand
+func (h *InventoryHandlers) RestoreBrand(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid brand ID"})
+		return
+	}
+
+	tenantUUID, _, ok := h.tenantAndUser(c)
+	if !ok {
+		return
+	}
+
+	brand, err := h.categoryService.RestoreBrand(c.Request.Context(), id, tenantUUID)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, brand)
+}
+
+// Report handlers
+// GetExpiryRisk lists unexpired batches expiring within the requested window
+func (h *InventoryHandlers) GetExpiryRisk(c *gin.Context) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+		return
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	var shopID *uuid.UUID
+	if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		parsed, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop ID"})
+			return
+		}
+		shopID = &parsed
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 || days > 365 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 365"})
+		return
+	}
+
+	report, err := h.reportService.GetExpiryRisk(c.Request.Context(), tenantUUID, shopID, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetReorderSuggestions suggests purchases for stock at or below its minimum level
+func (h *InventoryHandlers) GetReorderSuggestions(c *gin.Context) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+		return
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	var shopID *uuid.UUID
+	if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		parsed, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop ID"})
+			return
+		}
+		shopID = &parsed
+	}
+
+	suggestions, err := h.stockService.GenerateReorderSuggestions(c.Request.Context(), tenantUUID, shopID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestions)
+}
+
+// GetStockValuation values stock on hand, optionally as it stood at the end of an as_of date
+func (h *InventoryHandlers) GetStockValuation(c *gin.Context) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+		return
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	var shopID *uuid.UUID
+	if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		parsed, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop ID"})
+			return
+		}
+		shopID = &parsed
+	}
+
+	var asOf *time.Time
+	if asOfStr := c.Query("as_of"); asOfStr != "" {
+		parsed, err := time.Parse("2006-01-02", asOfStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "as_of must be a date in YYYY-MM-DD format"})
+			return
+		}
+		endOfDay := parsed.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		asOf = &endOfDay
+	}
+
+	report, err := h.stockService.GetStockValuation(c.Request.Context(), tenantUUID, shopID, asOf)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *InventoryHandlers) GetDeadStock(c *gin.Context) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant ID not found"})
+		return
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	var shopID *uuid.UUID
+	if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		parsed, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop ID"})
+			return
+		}
+		shopID = &parsed
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "90"))
+	if err != nil || days <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid days"})
+		return
+	}
+
+	unit := c.Query("unit")
+	if unit != "" && !services.ValidDisplayUnit(unit) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unit must be units or cases"})
+		return
+	}
+
+	report, err := h.reportService.GetDeadStock(c.Request.Context(), tenantUUID, shopID, days, unit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
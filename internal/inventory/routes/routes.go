@@ -3,13 +3,31 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/liquorpro/go-backend/internal/inventory/handlers"
+	"github.com/liquorpro/go-backend/pkg/shared/audit"
 	"github.com/liquorpro/go-backend/pkg/shared/cache"
 	"github.com/liquorpro/go-backend/pkg/shared/config"
 	"github.com/liquorpro/go-backend/pkg/shared/middleware"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
 )
 
+// auditTables maps the resources written through this service to their tables for
+// the write audit log
+var auditTables = map[string]string{
+	"products":     "products",
+	"snapshots":    "catalog_snapshots",
+	"categories":   "categories",
+	"brands":       "brands",
+	"tax-rates":    "tax_rates",
+	"stocks":       "stocks",
+	"transfers":    "stock_transfers",
+	"reservations": "stock_reservations",
+	"write-offs":   "stock_write_offs",
+	"counts":       "stock_counts",
+	"purchases":    "stock_purchases",
+}
+
 // SetupRoutes configures all inventory service routes
-func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, inventoryHandlers *handlers.InventoryHandlers) {
+func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, inventoryHandlers *handlers.InventoryHandlers, auditLogger *audit.Logger, shopScopes *scope.Resolver) {
 	// Health check
 	router.GET("/health", inventoryHandlers.Health)
 
@@ -17,31 +35,69 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, inv
 	api := router.Group("/api")
 	api.Use(middleware.AuthMiddleware(cfg.JWT, cache))
 	api.Use(middleware.TenantMiddleware())
+	api.Use(middleware.WriteAuditMiddleware(auditLogger, auditTables))
 
 	// Product Routes (Core inventory items)
 	products := api.Group("/products")
 	{
 		products.GET("", inventoryHandlers.GetProducts)
 		products.POST("", middleware.RoleMiddleware("manager", "admin"), inventoryHandlers.CreateProduct)
+		products.POST("/bulk-status", middleware.RoleMiddleware("manager", "admin"), inventoryHandlers.BulkSetProductStatus)
+		products.POST("/import", middleware.RoleMiddleware("manager", "admin"), inventoryHandlers.ImportProducts)
+		products.POST("/catalog/snapshot", middleware.RoleMiddleware("manager", "admin"), inventoryHandlers.CreateCatalogSnapshot)
+		products.GET("/catalog/snapshots", middleware.RoleMiddleware("manager", "admin"), inventoryHandlers.GetCatalogSnapshots)
+		products.GET("/catalog/diff", middleware.RoleMiddleware("manager", "admin"), inventoryHandlers.DiffCatalogSnapshots)
+		products.POST("/catalog/snapshots/prune", middleware.RoleMiddleware("admin"), inventoryHandlers.PruneCatalogSnapshots)
+		products.DELETE("/catalog/snapshots/:id", middleware.RoleMiddleware("admin"), inventoryHandlers.DeleteCatalogSnapshot)
+		products.GET("/barcode/:code", inventoryHandlers.GetProductByBarcode)
+		products.GET("/groups", inventoryHandlers.GetProductGroups)
+		products.GET("/groups/:id", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetProductGroup)
 		products.GET("/:id", inventoryHandlers.GetProductByID)
+		products.GET("/:id/ledger", inventoryHandlers.GetProductLedger)
+		products.GET("/:id/stock-overview", middleware.RoleMiddleware("manager", "admin"), middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetProductStockOverview)
+		products.GET("/:id/price-history", middleware.RoleMiddleware("manager", "admin"), inventoryHandlers.GetProductPriceHistory)
 		products.PUT("/:id", middleware.RoleMiddleware("manager", "admin"), inventoryHandlers.UpdateProduct)
 		products.DELETE("/:id", middleware.RoleMiddleware("admin"), inventoryHandlers.DeleteProduct)
+		products.POST("/:id/restore", middleware.RoleMiddleware("admin"), inventoryHandlers.RestoreProduct)
 	}
 
 	// Stock Management Routes (Critical for inventory tracking)
 	stocks := api.Group("/stocks")
 	{
-		stocks.GET("", inventoryHandlers.GetStocks)
+		stocks.GET("", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetStocks)
 		stocks.POST("/adjust", middleware.RoleMiddleware("manager", "admin"), inventoryHandlers.AdjustStock)
 		stocks.POST("/transfer", middleware.RoleMiddleware("manager", "admin"), inventoryHandlers.TransferStock)
-		stocks.GET("/movements", inventoryHandlers.GetStockMovements)
+		stocks.POST("/transfers/import", middleware.RoleMiddleware("manager", "admin"), inventoryHandlers.ImportStockTransfers)
+		stocks.GET("/transfers", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetStockTransfers)
+		stocks.GET("/transfers/:id", inventoryHandlers.GetStockTransferByID)
+		stocks.POST("/transfers/:id/approve", middleware.RoleMiddleware("manager", "admin"), inventoryHandlers.ApproveStockTransfer)
+		stocks.POST("/transfers/:id/reject", middleware.RoleMiddleware("manager", "admin"), inventoryHandlers.RejectStockTransfer)
+		stocks.PUT("/:id/costing-method", middleware.RoleMiddleware("manager", "admin"), inventoryHandlers.UpdateStockCostingMethod)
+		stocks.POST("/reservations", middleware.RoleMiddleware("salesman", "assistant_manager", "manager", "admin"), inventoryHandlers.ReserveStock)
+		stocks.GET("/reservations/:reference_id", inventoryHandlers.GetReservations)
+		stocks.POST("/reservations/:reference_id/release", middleware.RoleMiddleware("salesman", "assistant_manager", "manager", "admin"), inventoryHandlers.ReleaseReservation)
+		stocks.GET("/movements", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetStockMovements)
+		stocks.GET("/valuation", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetStockValuation)
+		stocks.GET("/reorder-suggestions", middleware.RoleMiddleware("manager", "admin"), middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetReorderSuggestions)
+		stocks.GET("/expiry-risk", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetExpiryRisk)
+		stocks.GET("/write-offs", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetWriteOffs)
+		stocks.POST("/write-offs", middleware.RoleMiddleware("assistant_manager", "manager", "admin"), inventoryHandlers.CreateWriteOff)
+		stocks.GET("/write-offs/:id", inventoryHandlers.GetWriteOffByID)
+		stocks.POST("/write-offs/:id/approve", middleware.RoleMiddleware("manager", "admin"), inventoryHandlers.ApproveWriteOff)
+		stocks.POST("/write-offs/:id/reject", middleware.RoleMiddleware("manager", "admin"), inventoryHandlers.RejectWriteOff)
+		stocks.GET("/counts", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetStockCounts)
+		stocks.POST("/counts", middleware.RoleMiddleware("assistant_manager", "manager", "admin"), inventoryHandlers.CreateStockCount)
+		stocks.GET("/counts/:id", inventoryHandlers.GetStockCount)
+		stocks.POST("/counts/:id/items", middleware.RoleMiddleware("assistant_manager", "manager", "admin"), inventoryHandlers.AddStockCountItems)
+		stocks.POST("/counts/:id/finalize", middleware.RoleMiddleware("manager", "admin"), inventoryHandlers.FinalizeStockCount)
 	}
 
 	// Purchase/Receiving Routes (Stock intake)
 	purchases := api.Group("/purchases")
 	{
-		purchases.GET("", inventoryHandlers.GetPurchases)
+		purchases.GET("", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetPurchases)
 		purchases.POST("", middleware.RoleMiddleware("manager", "admin"), inventoryHandlers.CreatePurchase)
+		purchases.POST("/reorder/preview", middleware.RoleMiddleware("manager", "admin"), inventoryHandlers.PreviewReorder)
 		purchases.GET("/:id", inventoryHandlers.GetPurchaseByID)
 		purchases.POST("/:id/receive", middleware.RoleMiddleware("manager", "admin"), inventoryHandlers.ReceivePurchase)
 	}
@@ -54,6 +110,7 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, inv
 		categories.GET("/:id", inventoryHandlers.GetCategoryByID)
 		categories.PUT("/:id", middleware.RoleMiddleware("manager", "admin"), inventoryHandlers.UpdateCategory)
 		categories.DELETE("/:id", middleware.RoleMiddleware("admin"), inventoryHandlers.DeleteCategory)
+		categories.POST("/:id/restore", middleware.RoleMiddleware("admin"), inventoryHandlers.RestoreCategory)
 	}
 
 	// Brand Management Routes
@@ -64,17 +121,28 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, inv
 		brands.GET("/:id", inventoryHandlers.GetBrandByID)
 		brands.PUT("/:id", middleware.RoleMiddleware("manager", "admin"), inventoryHandlers.UpdateBrand)
 		brands.DELETE("/:id", middleware.RoleMiddleware("admin"), inventoryHandlers.DeleteBrand)
+		brands.POST("/:id/restore", middleware.RoleMiddleware("admin"), inventoryHandlers.RestoreBrand)
+	}
+
+	// Tax Rate Routes (GST per category or product)
+	taxRates := api.Group("/tax-rates")
+	{
+		taxRates.GET("", inventoryHandlers.GetTaxRates)
+		taxRates.POST("", middleware.RoleMiddleware("manager", "admin"), inventoryHandlers.CreateTaxRate)
+		taxRates.PUT("/:id", middleware.RoleMiddleware("manager", "admin"), inventoryHandlers.UpdateTaxRate)
+		taxRates.DELETE("/:id", middleware.RoleMiddleware("admin"), inventoryHandlers.DeleteTaxRate)
 	}
 
 	// Reports Routes (Read-only analytics)
 	reports := api.Group("/reports")
 	{
-		reports.GET("/low-stock", inventoryHandlers.GetStocks) // Uses query param low_stock=true
-		reports.GET("/stock-movements", inventoryHandlers.GetStockMovements)
+		reports.GET("/low-stock", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetStocks) // Uses query param low_stock=true
+		reports.GET("/stock-movements", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetStockMovements)
+		reports.GET("/dead-stock", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetDeadStock)
+		reports.GET("/write-offs", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetWriteOffReport)
+		reports.GET("/purchase-planning", middleware.RoleMiddleware("manager", "admin"), middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetPurchasePlanning)
 		// TODO: Add more specialized reports
-		reports.GET("/valuation", func(c *gin.Context) {
-			c.JSON(501, gin.H{"message": "Inventory valuation report not implemented yet"})
-		})
+		reports.GET("/valuation", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetStockValuation)
 		reports.GET("/turnover", func(c *gin.Context) {
 			c.JSON(501, gin.H{"message": "Stock turnover report not implemented yet"})
 		})
@@ -85,7 +153,7 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, inv
 }
 
 // SetupProtectedRoutes sets up routes with gateway-style auth handling
-func SetupProtectedRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, inventoryHandlers *handlers.InventoryHandlers) {
+func SetupProtectedRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, inventoryHandlers *handlers.InventoryHandlers, auditLogger *audit.Logger, shopScopes *scope.Resolver) {
 	// Health check (no auth required)
 	router.GET("/health", inventoryHandlers.Health)
 
@@ -102,23 +170,61 @@ func SetupProtectedRoutes(router *gin.Engine, cfg *config.Config, cache *cache.C
 		}
 		c.Next()
 	})
+	router.Use(middleware.WriteAuditMiddleware(auditLogger, auditTables))
 
 	// Product Routes
 	router.GET("/products", inventoryHandlers.GetProducts)
 	router.POST("/products", inventoryHandlers.CreateProduct)
+	router.POST("/products/bulk-status", inventoryHandlers.BulkSetProductStatus)
+	router.POST("/products/import", inventoryHandlers.ImportProducts)
+	router.POST("/products/catalog/snapshot", inventoryHandlers.CreateCatalogSnapshot)
+	router.GET("/products/catalog/snapshots", inventoryHandlers.GetCatalogSnapshots)
+	router.GET("/products/catalog/diff", inventoryHandlers.DiffCatalogSnapshots)
+	router.POST("/products/catalog/snapshots/prune", inventoryHandlers.PruneCatalogSnapshots)
+	router.DELETE("/products/catalog/snapshots/:id", inventoryHandlers.DeleteCatalogSnapshot)
+	router.GET("/products/barcode/:code", inventoryHandlers.GetProductByBarcode)
+	router.GET("/products/groups", inventoryHandlers.GetProductGroups)
+	router.GET("/products/groups/:id", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetProductGroup)
 	router.GET("/products/:id", inventoryHandlers.GetProductByID)
+	router.GET("/products/:id/ledger", inventoryHandlers.GetProductLedger)
+	router.GET("/products/:id/stock-overview", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetProductStockOverview)
+	router.GET("/products/:id/price-history", inventoryHandlers.GetProductPriceHistory)
 	router.PUT("/products/:id", inventoryHandlers.UpdateProduct)
 	router.DELETE("/products/:id", inventoryHandlers.DeleteProduct)
+	router.POST("/products/:id/restore", inventoryHandlers.RestoreProduct)
 
 	// Stock Management Routes
-	router.GET("/stocks", inventoryHandlers.GetStocks)
+	router.GET("/stocks", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetStocks)
 	router.POST("/stocks/adjust", inventoryHandlers.AdjustStock)
 	router.POST("/stocks/transfer", inventoryHandlers.TransferStock)
-	router.GET("/stocks/movements", inventoryHandlers.GetStockMovements)
+	router.POST("/stocks/transfers/import", inventoryHandlers.ImportStockTransfers)
+	router.GET("/stocks/transfers", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetStockTransfers)
+	router.GET("/stocks/transfers/:id", inventoryHandlers.GetStockTransferByID)
+	router.POST("/stocks/transfers/:id/approve", inventoryHandlers.ApproveStockTransfer)
+	router.POST("/stocks/transfers/:id/reject", inventoryHandlers.RejectStockTransfer)
+	router.PUT("/stocks/:id/costing-method", inventoryHandlers.UpdateStockCostingMethod)
+	router.POST("/stocks/reservations", inventoryHandlers.ReserveStock)
+	router.GET("/stocks/reservations/:reference_id", inventoryHandlers.GetReservations)
+	router.POST("/stocks/reservations/:reference_id/release", inventoryHandlers.ReleaseReservation)
+	router.GET("/stocks/movements", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetStockMovements)
+	router.GET("/stocks/valuation", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetStockValuation)
+	router.GET("/stocks/reorder-suggestions", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetReorderSuggestions)
+	router.GET("/stocks/expiry-risk", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetExpiryRisk)
+	router.GET("/stocks/write-offs", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetWriteOffs)
+	router.POST("/stocks/write-offs", inventoryHandlers.CreateWriteOff)
+	router.GET("/stocks/write-offs/:id", inventoryHandlers.GetWriteOffByID)
+	router.POST("/stocks/write-offs/:id/approve", inventoryHandlers.ApproveWriteOff)
+	router.POST("/stocks/write-offs/:id/reject", inventoryHandlers.RejectWriteOff)
+	router.GET("/stocks/counts", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetStockCounts)
+	router.POST("/stocks/counts", inventoryHandlers.CreateStockCount)
+	router.GET("/stocks/counts/:id", inventoryHandlers.GetStockCount)
+	router.POST("/stocks/counts/:id/items", inventoryHandlers.AddStockCountItems)
+	router.POST("/stocks/counts/:id/finalize", inventoryHandlers.FinalizeStockCount)
 
 	// Purchase Routes
-	router.GET("/purchases", inventoryHandlers.GetPurchases)
+	router.GET("/purchases", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetPurchases)
 	router.POST("/purchases", inventoryHandlers.CreatePurchase)
+	router.POST("/purchases/reorder/preview", inventoryHandlers.PreviewReorder)
 	router.GET("/purchases/:id", inventoryHandlers.GetPurchaseByID)
 	router.POST("/purchases/:id/receive", inventoryHandlers.ReceivePurchase)
 
@@ -128,6 +234,7 @@ func SetupProtectedRoutes(router *gin.Engine, cfg *config.Config, cache *cache.C
 	router.GET("/categories/:id", inventoryHandlers.GetCategoryByID)
 	router.PUT("/categories/:id", inventoryHandlers.UpdateCategory)
 	router.DELETE("/categories/:id", inventoryHandlers.DeleteCategory)
+	router.POST("/categories/:id/restore", inventoryHandlers.RestoreCategory)
 
 	// Brand Routes
 	router.GET("/brands", inventoryHandlers.GetBrands)
@@ -135,8 +242,18 @@ func SetupProtectedRoutes(router *gin.Engine, cfg *config.Config, cache *cache.C
 	router.GET("/brands/:id", inventoryHandlers.GetBrandByID)
 	router.PUT("/brands/:id", inventoryHandlers.UpdateBrand)
 	router.DELETE("/brands/:id", inventoryHandlers.DeleteBrand)
+	router.POST("/brands/:id/restore", inventoryHandlers.RestoreBrand)
+
+	// Tax Rate Routes
+	router.GET("/tax-rates", inventoryHandlers.GetTaxRates)
+	router.POST("/tax-rates", inventoryHandlers.CreateTaxRate)
+	router.PUT("/tax-rates/:id", inventoryHandlers.UpdateTaxRate)
+	router.DELETE("/tax-rates/:id", inventoryHandlers.DeleteTaxRate)
 
 	// Reports Routes
-	router.GET("/reports/low-stock", inventoryHandlers.GetStocks)
-	router.GET("/reports/stock-movements", inventoryHandlers.GetStockMovements)
+	router.GET("/reports/low-stock", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetStocks)
+	router.GET("/reports/stock-movements", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetStockMovements)
+	router.GET("/reports/dead-stock", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetDeadStock)
+	router.GET("/reports/write-offs", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetWriteOffReport)
+	router.GET("/reports/purchase-planning", middleware.ShopScopeMiddleware(shopScopes), inventoryHandlers.GetPurchasePlanning)
 }
\ No newline at end of file
@@ -0,0 +1,430 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/cache"
+	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+	"gorm.io/gorm"
+)
+
+// CatalogService handles product catalog snapshots and the differences between them
+type CatalogService struct {
+	db       *database.DB
+	cache    *cache.Cache
+	settings *settings.Service
+}
+
+// NewCatalogService creates a new catalog service
+func NewCatalogService(db *database.DB, cache *cache.Cache, settingsService *settings.Service) *CatalogService {
+	return &CatalogService{
+		db:       db,
+		cache:    cache,
+		settings: settingsService,
+	}
+}
+
+// CatalogSnapshotRequest represents a request to snapshot the current catalog
+type CatalogSnapshotRequest struct {
+	Name  string `json:"name"`
+	Notes string `json:"notes"`
+}
+
+// CatalogPruneRequest selects snapshots to delete. Snapshots are deleted when they
+// fall outside the latest KeepLatest or are older than OlderThanDays.
+type CatalogPruneRequest struct {
+	KeepLatest    *int `json:"keep_latest" binding:"omitempty,gte=0"`
+	OlderThanDays *int `json:"older_than_days" binding:"omitempty,gt=0"`
+}
+
+// CatalogSnapshotResponse represents a catalog snapshot in responses
+type CatalogSnapshotResponse struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	Notes        string    `json:"notes"`
+	ProductCount int       `json:"product_count"`
+	CreatedByID  uuid.UUID `json:"created_by_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// CatalogDiffProduct is a product added to or removed from the catalog
+type CatalogDiffProduct struct {
+	ProductID    uuid.UUID `json:"product_id"`
+	Name         string    `json:"name"`
+	SKU          string    `json:"sku"`
+	BrandName    string    `json:"brand_name"`
+	CategoryName string    `json:"category_name"`
+	Size         string    `json:"size"`
+	CostPrice    float64   `json:"cost_price"`
+	SellingPrice float64   `json:"selling_price"`
+	MRP          float64   `json:"mrp"`
+	IsActive     bool      `json:"is_active"`
+}
+
+// CatalogPriceChange is a product whose cost, selling price or MRP changed
+type CatalogPriceChange struct {
+	ProductID             uuid.UUID `json:"product_id"`
+	Name                  string    `json:"name"`
+	SKU                   string    `json:"sku"`
+	OldCostPrice          float64   `json:"old_cost_price"`
+	NewCostPrice          float64   `json:"new_cost_price"`
+	OldSellingPrice       float64   `json:"old_selling_price"`
+	NewSellingPrice       float64   `json:"new_selling_price"`
+	SellingPriceChange    float64   `json:"selling_price_change"`
+	SellingPriceChangePct float64   `json:"selling_price_change_percent"`
+	OldMRP                float64   `json:"old_mrp"`
+	NewMRP                float64   `json:"new_mrp"`
+}
+
+// CatalogStatusChange is a product that was activated or deactivated
+type CatalogStatusChange struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Name      string    `json:"name"`
+	SKU       string    `json:"sku"`
+	WasActive bool      `json:"was_active"`
+	IsActive  bool      `json:"is_active"`
+}
+
+// CatalogDiff lists what changed in the catalog between two snapshots. A nil To
+// compares against the live catalog.
+type CatalogDiff struct {
+	From          *CatalogSnapshotResponse `json:"from"`
+	To            *CatalogSnapshotResponse `json:"to"`
+	Added         []CatalogDiffProduct     `json:"added"`
+	Removed       []CatalogDiffProduct     `json:"removed"`
+	PriceChanged  []CatalogPriceChange     `json:"price_changed"`
+	StatusChanged []CatalogStatusChange    `json:"status_changed"`
+	Unchanged     int                      `json:"unchanged"`
+}
+
+// CreateSnapshot stores the current state of every product in the tenant's catalog
+// and prunes snapshots beyond the tenant's catalog_snapshot_retention
+func (s *CatalogService) CreateSnapshot(ctx context.Context, req CatalogSnapshotRequest, tenantID, userID uuid.UUID) (*CatalogSnapshotResponse, error) {
+	items, err := s.liveCatalog(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := req.Name
+	if name == "" {
+		name = fmt.Sprintf("Catalog %s", time.Now().Format("2006-01-02 15:04"))
+	}
+
+	snapshot := models.CatalogSnapshot{
+		TenantModel:  models.TenantModel{TenantID: tenantID},
+		Name:         name,
+		Notes:        req.Notes,
+		ProductCount: len(items),
+		CreatedByID:  userID,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&snapshot).Error; err != nil {
+			return fmt.Errorf("failed to create catalog snapshot: %w", err)
+		}
+
+		for i := range items {
+			items[i].SnapshotID = snapshot.ID
+		}
+		if len(items) > 0 {
+			if err := tx.CreateInBatches(items, 500).Error; err != nil {
+				return fmt.Errorf("failed to store catalog snapshot items: %w", err)
+			}
+		}
+
+		if keep := s.settings.GetInt(ctx, tenantID, settings.KeyCatalogSnapshotRetention); keep > 0 {
+			if _, err := s.prune(tx, tenantID, &keep, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.mapSnapshotToResponse(&snapshot), nil
+}
+
+// GetSnapshots lists catalog snapshots, newest first
+func (s *CatalogService) GetSnapshots(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]*CatalogSnapshotResponse, int64, error) {
+	query := s.db.Model(&models.CatalogSnapshot{}).Where("tenant_id = ?", tenantID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count catalog snapshots: %w", err)
+	}
+
+	var snapshots []models.CatalogSnapshot
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&snapshots).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get catalog snapshots: %w", err)
+	}
+
+	responses := make([]*CatalogSnapshotResponse, len(snapshots))
+	for i := range snapshots {
+		responses[i] = s.mapSnapshotToResponse(&snapshots[i])
+	}
+
+	return responses, total, nil
+}
+
+// DeleteSnapshot permanently deletes a catalog snapshot
+func (s *CatalogService) DeleteSnapshot(ctx context.Context, id, tenantID uuid.UUID) error {
+	if _, err := s.findSnapshot(id, tenantID); err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return s.deleteSnapshots(tx, []uuid.UUID{id})
+	})
+}
+
+// PruneSnapshots deletes old catalog snapshots and returns how many were removed
+func (s *CatalogService) PruneSnapshots(ctx context.Context, tenantID uuid.UUID, req CatalogPruneRequest) (int64, error) {
+	if req.KeepLatest == nil && req.OlderThanDays == nil {
+		return 0, errors.New("keep_latest or older_than_days is required")
+	}
+
+	var olderThan *time.Time
+	if req.OlderThanDays != nil {
+		cutoff := time.Now().AddDate(0, 0, -*req.OlderThanDays)
+		olderThan = &cutoff
+	}
+
+	var deleted int64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		deleted, err = s.prune(tx, tenantID, req.KeepLatest, olderThan)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// DiffSnapshots compares two catalog snapshots. A nil toID compares the from
+// snapshot against the live catalog.
+func (s *CatalogService) DiffSnapshots(ctx context.Context, tenantID, fromID uuid.UUID, toID *uuid.UUID) (*CatalogDiff, error) {
+	from, err := s.findSnapshot(fromID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	fromItems, err := s.snapshotItems(from.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &CatalogDiff{
+		From:          s.mapSnapshotToResponse(from),
+		Added:         []CatalogDiffProduct{},
+		Removed:       []CatalogDiffProduct{},
+		PriceChanged:  []CatalogPriceChange{},
+		StatusChanged: []CatalogStatusChange{},
+	}
+
+	var toItems []models.CatalogSnapshotItem
+	if toID != nil {
+		to, err := s.findSnapshot(*toID, tenantID)
+		if err != nil {
+			return nil, err
+		}
+		if to.ID == from.ID {
+			return nil, errors.New("from and to must be different snapshots")
+		}
+		diff.To = s.mapSnapshotToResponse(to)
+		if toItems, err = s.snapshotItems(to.ID); err != nil {
+			return nil, err
+		}
+	} else {
+		if toItems, err = s.liveCatalog(ctx, tenantID); err != nil {
+			return nil, err
+		}
+	}
+
+	before := make(map[uuid.UUID]models.CatalogSnapshotItem, len(fromItems))
+	for _, item := range fromItems {
+		before[item.ProductID] = item
+	}
+
+	for _, after := range toItems {
+		prev, existed := before[after.ProductID]
+		if !existed {
+			diff.Added = append(diff.Added, mapDiffProduct(after))
+			continue
+		}
+		delete(before, after.ProductID)
+
+		changed := false
+		if prev.CostPrice != after.CostPrice || prev.SellingPrice != after.SellingPrice || prev.MRP != after.MRP {
+			change := CatalogPriceChange{
+				ProductID:          after.ProductID,
+				Name:               after.Name,
+				SKU:                after.SKU,
+				OldCostPrice:       prev.CostPrice,
+				NewCostPrice:       after.CostPrice,
+				OldSellingPrice:    prev.SellingPrice,
+				NewSellingPrice:    after.SellingPrice,
+				SellingPriceChange: utils.RoundToTwoDecimals(after.SellingPrice - prev.SellingPrice),
+				OldMRP:             prev.MRP,
+				NewMRP:             after.MRP,
+			}
+			if prev.SellingPrice > 0 {
+				change.SellingPriceChangePct = utils.RoundToTwoDecimals((after.SellingPrice - prev.SellingPrice) / prev.SellingPrice * 100)
+			}
+			diff.PriceChanged = append(diff.PriceChanged, change)
+			changed = true
+		}
+		if prev.IsActive != after.IsActive {
+			diff.StatusChanged = append(diff.StatusChanged, CatalogStatusChange{
+				ProductID: after.ProductID,
+				Name:      after.Name,
+				SKU:       after.SKU,
+				WasActive: prev.IsActive,
+				IsActive:  after.IsActive,
+			})
+			changed = true
+		}
+		if !changed {
+			diff.Unchanged++
+		}
+	}
+
+	// Whatever is left in the from snapshot no longer exists
+	for _, item := range fromItems {
+		if _, removed := before[item.ProductID]; removed {
+			diff.Removed = append(diff.Removed, mapDiffProduct(item))
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].Name < diff.Added[j].Name })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].Name < diff.Removed[j].Name })
+	sort.Slice(diff.PriceChanged, func(i, j int) bool { return diff.PriceChanged[i].Name < diff.PriceChanged[j].Name })
+	sort.Slice(diff.StatusChanged, func(i, j int) bool { return diff.StatusChanged[i].Name < diff.StatusChanged[j].Name })
+
+	return diff, nil
+}
+
+// liveCatalog captures the tenant's current products as snapshot items
+func (s *CatalogService) liveCatalog(ctx context.Context, tenantID uuid.UUID) ([]models.CatalogSnapshotItem, error) {
+	var products []models.Product
+	if err := s.db.Where("tenant_id = ?", tenantID).
+		Preload("Brand").Preload("Category").
+		Order("name ASC").
+		Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+
+	items := make([]models.CatalogSnapshotItem, len(products))
+	for i, product := range products {
+		items[i] = models.CatalogSnapshotItem{
+			ProductID:    product.ID,
+			Name:         product.Name,
+			SKU:          product.SKU,
+			Size:         product.Size,
+			CostPrice:    product.CostPrice,
+			SellingPrice: product.SellingPrice,
+			MRP:          product.MRP,
+			IsActive:     product.IsActive,
+		}
+		if product.Brand != nil {
+			items[i].BrandName = product.Brand.Name
+		}
+		if product.Category != nil {
+			items[i].CategoryName = product.Category.Name
+		}
+	}
+
+	return items, nil
+}
+
+func (s *CatalogService) findSnapshot(id, tenantID uuid.UUID) (*models.CatalogSnapshot, error) {
+	var snapshot models.CatalogSnapshot
+	if err := s.db.Where("id = ? AND tenant_id = ?", id, tenantID).First(&snapshot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("catalog snapshot not found")
+		}
+		return nil, fmt.Errorf("failed to get catalog snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+func (s *CatalogService) snapshotItems(snapshotID uuid.UUID) ([]models.CatalogSnapshotItem, error) {
+	var items []models.CatalogSnapshotItem
+	if err := s.db.Where("snapshot_id = ?", snapshotID).Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to get catalog snapshot items: %w", err)
+	}
+	return items, nil
+}
+
+// prune deletes snapshots outside the latest keepLatest or created before olderThan
+func (s *CatalogService) prune(tx *gorm.DB, tenantID uuid.UUID, keepLatest *int, olderThan *time.Time) (int64, error) {
+	var snapshots []models.CatalogSnapshot
+	if err := tx.Select("id", "created_at").
+		Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").
+		Find(&snapshots).Error; err != nil {
+		return 0, fmt.Errorf("failed to get catalog snapshots: %w", err)
+	}
+
+	var ids []uuid.UUID
+	for i, snapshot := range snapshots {
+		if (keepLatest != nil && i >= *keepLatest) || (olderThan != nil && snapshot.CreatedAt.Before(*olderThan)) {
+			ids = append(ids, snapshot.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	if err := s.deleteSnapshots(tx, ids); err != nil {
+		return 0, err
+	}
+	return int64(len(ids)), nil
+}
+
+// deleteSnapshots permanently removes snapshots and their items
+func (s *CatalogService) deleteSnapshots(tx *gorm.DB, ids []uuid.UUID) error {
+	if err := tx.Unscoped().Where("snapshot_id IN ?", ids).Delete(&models.CatalogSnapshotItem{}).Error; err != nil {
+		return fmt.Errorf("failed to delete catalog snapshot items: %w", err)
+	}
+	if err := tx.Unscoped().Where("id IN ?", ids).Delete(&models.CatalogSnapshot{}).Error; err != nil {
+		return fmt.Errorf("failed to delete catalog snapshots: %w", err)
+	}
+	return nil
+}
+
+func (s *CatalogService) mapSnapshotToResponse(snapshot *models.CatalogSnapshot) *CatalogSnapshotResponse {
+	return &CatalogSnapshotResponse{
+		ID:           snapshot.ID,
+		Name:         snapshot.Name,
+		Notes:        snapshot.Notes,
+		ProductCount: snapshot.ProductCount,
+		CreatedByID:  snapshot.CreatedByID,
+		CreatedAt:    snapshot.CreatedAt,
+	}
+}
+
+func mapDiffProduct(item models.CatalogSnapshotItem) CatalogDiffProduct {
+	return CatalogDiffProduct{
+		ProductID:    item.ProductID,
+		Name:         item.Name,
+		SKU:          item.SKU,
+		BrandName:    item.BrandName,
+		CategoryName: item.CategoryName,
+		Size:         item.Size,
+		CostPrice:    item.CostPrice,
+		SellingPrice: item.SellingPrice,
+		MRP:          item.MRP,
+		IsActive:     item.IsActive,
+	}
+}
@@ -144,7 +144,7 @@ func (s *CategoryService) GetCategories(ctx context.Context, tenantID uuid.UUID,
 	}
 
 	// Cache the result
-	s.cache.Set(ctx, cacheKey, rootCategories, 300) // Cache for 5 minutes
+	s.cache.Set(ctx, cacheKey, rootCategories, s.cache.TTLs().Categories)
 
 	return rootCategories, nil
 }
@@ -232,8 +232,7 @@ func (s *CategoryService) DeleteCategory(ctx context.Context, id, tenantID uuid.
 	}
 
 	// Clear cache
-	cacheKey := fmt.Sprintf("categories:tenant:%s", tenantID.String())
-	s.cache.Delete(ctx, cacheKey)
+	s.clearCategoryCache(ctx, tenantID)
 
 	return nil
 }
@@ -310,7 +309,7 @@ func (s *CategoryService) GetBrands(ctx context.Context, tenantID uuid.UUID, inc
 	}
 
 	// Cache the result
-	s.cache.Set(ctx, cacheKey, responses, 300) // Cache for 5 minutes
+	s.cache.Set(ctx, cacheKey, responses, s.cache.TTLs().Categories)
 
 	return responses, nil
 }
@@ -390,8 +389,7 @@ func (s *CategoryService) DeleteBrand(ctx context.Context, id, tenantID uuid.UUI
 	}
 
 	// Clear cache
-	cacheKey := fmt.Sprintf("brands:tenant:%s", tenantID.String())
-	s.cache.Delete(ctx, cacheKey)
+	s.clearBrandCache(ctx, tenantID)
 
 	return nil
 }
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquorpro/go-backend/pkg/shared/cache"
+	"github.com/liquorpro/go-backend/pkg/shared/cache/cachetest"
+	"github.com/liquorpro/go-backend/pkg/shared/database/dbtest"
+)
+
+func TestGetCategoriesCachesForCategoriesTTL(t *testing.T) {
+	c, recorder := cachetest.New(cache.TTLs{Categories: 7 * time.Minute})
+	s := NewCategoryService(dbtest.DryRun(t), c)
+	tenantID := uuid.New()
+
+	_, err := s.GetCategories(context.Background(), tenantID, false)
+	require.NoError(t, err)
+
+	ttl, _ := recorder.TTL("categories:tenant:" + tenantID.String() + ":inactive:false")
+	assert.Equal(t, 7*time.Minute, ttl)
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Stock costing. Every receipt into a stock record folds into its weighted
+// average cost and adds a cost layer (a StockBatch); every issue consumes
+// layers in the order of the stock's costing method and reports the per-unit
+// cost it went out at. Callers hold the stock row lock and apply the quantity
+// change themselves.
+
+// addCostLayer records quantity received at unitCost before the stock quantity
+// is increased. Batch details such as number, dates and supplier come from layer.
+func addCostLayer(tx *gorm.DB, stock *models.Stock, quantity int, unitCost float64, layer models.StockBatch) error {
+	if quantity <= 0 {
+		return nil
+	}
+
+	average := unitCost
+	if stock.Quantity > 0 {
+		average = (float64(stock.Quantity)*stock.AverageCost + float64(quantity)*unitCost) / float64(stock.Quantity+quantity)
+	}
+	stock.AverageCost = average
+	if err := tx.Model(stock).Update("average_cost", average).Error; err != nil {
+		return fmt.Errorf("failed to update average cost: %w", err)
+	}
+
+	layer.TenantModel = models.TenantModel{TenantID: stock.TenantID}
+	layer.StockID = stock.ID
+	layer.ProductID = stock.ProductID
+	layer.Quantity = quantity
+	layer.CostPrice = unitCost
+	if layer.PurchaseDate.IsZero() {
+		layer.PurchaseDate = time.Now()
+	}
+	if layer.BatchNumber == "" {
+		layer.BatchNumber = "LAYER-" + layer.PurchaseDate.Format("20060102150405")
+	}
+	if err := tx.Create(&layer).Error; err != nil {
+		return fmt.Errorf("failed to create cost layer: %w", err)
+	}
+	return nil
+}
+
+// consumeCostLayers takes quantity out of the stock's cost layers, oldest first
+// for FIFO and newest first for LIFO, and returns the per-unit cost of the
+// issue. Average-cost stock still draws layers down in FIFO order so they stay
+// in step with the quantity, but is costed at the average. Any quantity not
+// covered by layers, such as stock counted in before costing was tracked, is
+// costed at the average.
+func consumeCostLayers(tx *gorm.DB, stock *models.Stock, quantity int, fallbackCost float64) (float64, error) {
+	average := stock.AverageCost
+	if average == 0 {
+		average = fallbackCost
+	}
+	if quantity <= 0 {
+		return average, nil
+	}
+
+	order := "purchase_date ASC, created_at ASC"
+	if stock.CostingMethod == models.CostingLIFO {
+		order = "purchase_date DESC, created_at DESC"
+	}
+
+	var layers []models.StockBatch
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("stock_id = ? AND tenant_id = ? AND quantity > 0", stock.ID, stock.TenantID).
+		Order(order).
+		Find(&layers).Error; err != nil {
+		return 0, fmt.Errorf("failed to get cost layers: %w", err)
+	}
+
+	remaining := quantity
+	totalCost := 0.0
+	for i := range layers {
+		if remaining == 0 {
+			break
+		}
+		layer := &layers[i]
+		take := layer.Quantity
+		if take > remaining {
+			take = remaining
+		}
+		if err := tx.Model(layer).Update("quantity", layer.Quantity-take).Error; err != nil {
+			return 0, fmt.Errorf("failed to update cost layer: %w", err)
+		}
+		totalCost += float64(take) * layer.CostPrice
+		remaining -= take
+	}
+	totalCost += float64(remaining) * average
+
+	if stock.CostingMethod == models.CostingAverage {
+		return average, nil
+	}
+	return totalCost / float64(quantity), nil
+}
+
+// stockFallbackCost is the cost used for stock with no average yet: the
+// product's configured cost price
+func stockFallbackCost(tx *gorm.DB, stock *models.Stock) float64 {
+	if stock.AverageCost > 0 {
+		return stock.AverageCost
+	}
+	var product models.Product
+	if err := tx.Select("id", "cost_price").Where("id = ? AND tenant_id = ?", stock.ProductID, stock.TenantID).First(&product).Error; err != nil {
+		return 0
+	}
+	return product.CostPrice
+}
+
+// productCostingMethod is the costing method new stock of a product starts with
+func productCostingMethod(product *models.Product) string {
+	if product.CostingMethod == "" {
+		return models.CostingFIFO
+	}
+	return product.CostingMethod
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"gorm.io/gorm"
+)
+
+// barcodeCacheTTL is how long a barcode stays mapped to a product in the cache
+const barcodeCacheTTL = time.Hour
+
+// ShopStockLevel is a product's stock at one shop
+type ShopStockLevel struct {
+	ShopID            uuid.UUID `json:"shop_id"`
+	Quantity          int       `json:"quantity"`
+	ReservedQuantity  int       `json:"reserved_quantity"`
+	AvailableQuantity int       `json:"available_quantity"`
+}
+
+// BarcodeLookupResponse is the product matching a scanned barcode
+type BarcodeLookupResponse struct {
+	ProductResponse
+	ShopStock *ShopStockLevel `json:"shop_stock,omitempty"`
+}
+
+// GetProductByBarcode finds the product with an exact barcode match, including its
+// stock at shopID when set. The barcode to product mapping is cached since this is
+// called on every scan at the till.
+func (s *ProductService) GetProductByBarcode(ctx context.Context, barcode string, tenantID uuid.UUID, shopID *uuid.UUID) (*BarcodeLookupResponse, error) {
+	barcode = strings.TrimSpace(barcode)
+	if barcode == "" {
+		return nil, errors.New("barcode is required")
+	}
+
+	var product models.Product
+	cacheKey := barcodeCacheKey(tenantID, barcode)
+	var productID uuid.UUID
+	found := false
+	if err := s.cache.Get(ctx, cacheKey, &productID); err == nil {
+		err := s.db.WithContext(ctx).Preload("Category").Preload("Brand").
+			Where("id = ? AND tenant_id = ?", productID, tenantID).
+			First(&product).Error
+		// A product whose barcode changed since it was cached falls through to a fresh lookup
+		found = err == nil && product.Barcode == barcode
+	}
+
+	if !found {
+		err := s.db.WithContext(ctx).Preload("Category").Preload("Brand").
+			Where("tenant_id = ? AND barcode = ?", tenantID, barcode).
+			Order("is_active DESC, created_at ASC").
+			First(&product).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				s.cache.Delete(ctx, cacheKey)
+				return nil, errors.New("product not found")
+			}
+			return nil, fmt.Errorf("failed to get product: %w", err)
+		}
+		s.cache.Set(ctx, cacheKey, product.ID, barcodeCacheTTL)
+	}
+
+	var totalStock int
+	s.db.WithContext(ctx).Model(&models.Stock{}).
+		Where("product_id = ? AND tenant_id = ?", product.ID, tenantID).
+		Select("COALESCE(SUM(quantity), 0)").
+		Scan(&totalStock)
+
+	response := &BarcodeLookupResponse{ProductResponse: *s.mapProductToResponse(&product, totalStock)}
+	if shopID != nil {
+		level := &ShopStockLevel{ShopID: *shopID}
+		var stock models.Stock
+		err := s.db.WithContext(ctx).
+			Where("shop_id = ? AND product_id = ? AND tenant_id = ?", *shopID, product.ID, tenantID).
+			First(&stock).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to get stock: %w", err)
+		}
+		if err == nil {
+			level.Quantity = stock.Quantity
+			level.ReservedQuantity = stock.ReservedQuantity
+			level.AvailableQuantity = stock.Quantity - stock.ReservedQuantity
+		}
+		response.ShopStock = level
+	}
+
+	return response, nil
+}
+
+// clearBarcodeCache drops the cached product for a barcode
+func (s *ProductService) clearBarcodeCache(ctx context.Context, tenantID uuid.UUID, barcode string) {
+	if barcode = strings.TrimSpace(barcode); barcode != "" {
+		s.cache.Delete(ctx, barcodeCacheKey(tenantID, barcode))
+	}
+}
+
+func barcodeCacheKey(tenantID uuid.UUID, barcode string) string {
+	return fmt.Sprintf("product_barcode:%s:%s", tenantID.String(), barcode)
+}
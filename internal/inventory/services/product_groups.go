@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProductVariantResponse is one pack size within a product group
+type ProductVariantResponse struct {
+	ProductID    uuid.UUID `json:"product_id"`
+	SKU          string    `json:"sku"`
+	Size         string    `json:"size"`
+	CostPrice    float64   `json:"cost_price"`
+	SellingPrice float64   `json:"selling_price"`
+	MRP          float64   `json:"mrp"`
+	IsActive     bool      `json:"is_active"`
+	CurrentStock int       `json:"current_stock"`
+	UnitsSold    int       `json:"units_sold"`
+}
+
+// ProductGroupResponse is a product with all of its pack sizes. Stock and units
+// sold are totalled across the sizes.
+type ProductGroupResponse struct {
+	ID         uuid.UUID                 `json:"id"`
+	Name       string                    `json:"name"`
+	BrandID    uuid.UUID                 `json:"brand_id"`
+	BrandName  string                    `json:"brand_name"`
+	Sizes      []string                  `json:"sizes"`
+	TotalStock int                       `json:"total_stock"`
+	UnitsSold  int                       `json:"units_sold"`
+	SalesFrom  *time.Time                `json:"sales_from,omitempty"`
+	SalesTo    *time.Time                `json:"sales_to,omitempty"`
+	Variants   []*ProductVariantResponse `json:"variants"`
+}
+
+// ProductGroupListResponse represents a paginated grouped product listing
+type ProductGroupListResponse struct {
+	Groups     []*ProductGroupResponse `json:"groups"`
+	TotalCount int64                   `json:"total_count"`
+}
+
+// GetProductGroups lists products grouped by brand and name, with each group's
+// sizes and stock. Category, active and search filters apply to the sizes; groups
+// left with none are omitted.
+func (s *ProductService) GetProductGroups(ctx context.Context, tenantID uuid.UUID, filters ProductFilters) (*ProductGroupListResponse, error) {
+	productQuery := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("products.tenant_id = ?", tenantID)
+		if filters.CategoryID != uuid.Nil {
+			db = db.Where("products.category_id = ?", filters.CategoryID)
+		}
+		if filters.IsActive != nil {
+			db = db.Where("products.is_active = ?", *filters.IsActive)
+		}
+		if filters.Search != "" {
+			searchPattern := "%" + filters.Search + "%"
+			db = db.Where("products.name ILIKE ? OR products.sku ILIKE ? OR products.barcode ILIKE ?",
+				searchPattern, searchPattern, searchPattern)
+		}
+		return db
+	}
+
+	query := s.db.Model(&models.ProductGroup{}).
+		Where("product_groups.tenant_id = ?", tenantID).
+		Where("EXISTS (?)", productQuery(s.db.Model(&models.Product{}).Select("1").
+			Where("products.product_group_id = product_groups.id")))
+	if filters.BrandID != uuid.Nil {
+		query = query.Where("product_groups.brand_id = ?", filters.BrandID)
+	}
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, fmt.Errorf("failed to count product groups: %w", err)
+	}
+
+	var groups []models.ProductGroup
+	offset := (filters.Page - 1) * filters.PageSize
+	if err := query.Preload("Brand").
+		Preload("Products", func(db *gorm.DB) *gorm.DB {
+			return productQuery(db).Order("products.size ASC")
+		}).
+		Offset(offset).
+		Limit(filters.PageSize).
+		Order("product_groups.name ASC").
+		Find(&groups).Error; err != nil {
+		return nil, fmt.Errorf("failed to get product groups: %w", err)
+	}
+
+	var products []models.Product
+	for _, group := range groups {
+		products = append(products, group.Products...)
+	}
+	stockMap := s.getStockLevels(tenantID, products)
+
+	responses := make([]*ProductGroupResponse, len(groups))
+	for i := range groups {
+		responses[i] = buildProductGroupResponse(&groups[i], stockMap, nil)
+	}
+
+	return &ProductGroupListResponse{
+		Groups:     responses,
+		TotalCount: totalCount,
+	}, nil
+}
+
+// GetProductGroup returns every size of a product group with its stock and the
+// units sold in [start, end), net of returns. Stock and sales are limited to the
+// caller's shops.
+func (s *ProductService) GetProductGroup(ctx context.Context, groupID, tenantID uuid.UUID, start, end time.Time) (*ProductGroupResponse, error) {
+	if !end.After(start) {
+		return nil, errors.New("end date must be after start date")
+	}
+
+	var group models.ProductGroup
+	if err := s.db.Where("id = ? AND tenant_id = ?", groupID, tenantID).
+		Preload("Brand").
+		Preload("Products", func(db *gorm.DB) *gorm.DB {
+			return db.Order("size ASC")
+		}).
+		First(&group).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("product group not found")
+		}
+		return nil, fmt.Errorf("failed to get product group: %w", err)
+	}
+
+	productIDs := make([]uuid.UUID, len(group.Products))
+	for i, product := range group.Products {
+		productIDs[i] = product.ID
+	}
+
+	stockMap := make(map[uuid.UUID]int)
+	sold := make(map[uuid.UUID]int)
+	if len(productIDs) > 0 {
+		var stockLevels []struct {
+			ProductID uuid.UUID
+			Total     int
+		}
+		stockQuery := s.db.Model(&models.Stock{}).
+			Select("product_id, COALESCE(SUM(quantity), 0) AS total").
+			Where("product_id IN ? AND tenant_id = ?", productIDs, tenantID)
+		stockQuery = scope.FromContext(ctx).Apply(stockQuery, "shop_id")
+		if err := stockQuery.Group("product_id").Scan(&stockLevels).Error; err != nil {
+			return nil, fmt.Errorf("failed to get stock levels: %w", err)
+		}
+		for _, level := range stockLevels {
+			stockMap[level.ProductID] = level.Total
+		}
+
+		var sales []struct {
+			ProductID uuid.UUID
+			Quantity  int
+		}
+		salesQuery := s.db.Table("stock_histories h").
+			Select(`st.product_id, COALESCE(SUM(CASE WHEN h.movement_type = 'sale' THEN h.quantity ELSE -h.quantity END), 0) AS quantity`).
+			Joins("JOIN stocks st ON st.id = h.stock_id").
+			Where("h.tenant_id = ? AND st.product_id IN ? AND h.movement_type IN ? AND h.created_at >= ? AND h.created_at < ? AND h.deleted_at IS NULL",
+				tenantID, productIDs, []string{"sale", "return"}, start, end)
+		salesQuery = scope.FromContext(ctx).Apply(salesQuery, "st.shop_id")
+		if err := salesQuery.Group("st.product_id").Scan(&sales).Error; err != nil {
+			return nil, fmt.Errorf("failed to get units sold: %w", err)
+		}
+		for _, sale := range sales {
+			sold[sale.ProductID] = sale.Quantity
+		}
+	}
+
+	response := buildProductGroupResponse(&group, stockMap, sold)
+	response.SalesFrom = &start
+	response.SalesTo = &end
+	return response, nil
+}
+
+// productGroupID returns the group of the tenant's products with this brand and
+// name, creating it when the first size is added
+func productGroupID(tx *gorm.DB, tenantID, brandID uuid.UUID, name string) (*uuid.UUID, error) {
+	name = strings.TrimSpace(name)
+
+	var group models.ProductGroup
+	err := tx.Where("tenant_id = ? AND brand_id = ? AND LOWER(name) = LOWER(?)", tenantID, brandID, name).First(&group).Error
+	if err == nil {
+		return &group.ID, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get product group: %w", err)
+	}
+
+	group = models.ProductGroup{
+		TenantModel: models.TenantModel{
+			BaseModel: models.BaseModel{ID: uuid.New()},
+			TenantID:  tenantID,
+		},
+		BrandID: brandID,
+		Name:    name,
+	}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&group)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to create product group: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		// Another size of the same product created the group first
+		if err := tx.Where("tenant_id = ? AND brand_id = ? AND LOWER(name) = LOWER(?)", tenantID, brandID, name).First(&group).Error; err != nil {
+			return nil, fmt.Errorf("failed to get product group: %w", err)
+		}
+	}
+	return &group.ID, nil
+}
+
+func buildProductGroupResponse(group *models.ProductGroup, stockMap, sold map[uuid.UUID]int) *ProductGroupResponse {
+	response := &ProductGroupResponse{
+		ID:       group.ID,
+		Name:     group.Name,
+		BrandID:  group.BrandID,
+		Sizes:    make([]string, 0, len(group.Products)),
+		Variants: make([]*ProductVariantResponse, len(group.Products)),
+	}
+	if group.Brand != nil {
+		response.BrandName = group.Brand.Name
+	}
+
+	for i, product := range group.Products {
+		variant := &ProductVariantResponse{
+			ProductID:    product.ID,
+			SKU:          product.SKU,
+			Size:         product.Size,
+			CostPrice:    product.CostPrice,
+			SellingPrice: product.SellingPrice,
+			MRP:          product.MRP,
+			IsActive:     product.IsActive,
+			CurrentStock: stockMap[product.ID],
+			UnitsSold:    sold[product.ID],
+		}
+		response.Variants[i] = variant
+		response.Sizes = append(response.Sizes, product.Size)
+		response.TotalStock += variant.CurrentStock
+		response.UnitsSold += variant.UnitsSold
+	}
+	return response
+}
@@ -0,0 +1,281 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+)
+
+// maxProductImportRows caps the size of a single product import
+const maxProductImportRows = 5000
+
+var productImportColumns = []string{"name", "category", "brand", "size", "cost_price", "selling_price", "mrp"}
+
+// ProductImportResult reports the outcome of a product import
+type ProductImportResult struct {
+	TotalRows         int                      `json:"total_rows"`
+	Successful        int                      `json:"successful"`
+	Failed            int                      `json:"failed"`
+	CreatedCategories []string                 `json:"created_categories"`
+	CreatedBrands     []string                 `json:"created_brands"`
+	Rows              []ProductImportRowResult `json:"rows"`
+}
+
+// ProductImportRowResult is the outcome of one imported row
+type ProductImportRowResult struct {
+	Row       int        `json:"row"` // 1-based line number including the header
+	Name      string     `json:"name"`
+	SKU       string     `json:"sku"`
+	Success   bool       `json:"success"`
+	ProductID *uuid.UUID `json:"product_id,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// BulkImportProducts creates products from a CSV with name, category, brand, size,
+// cost_price, selling_price and mrp columns, plus optional sku, alcohol_content,
+// barcode, description and units_per_case. Categories and brands are matched by
+// name and created when missing. Each row succeeds or fails on its own; invalid
+// rows, including SKUs repeated in the file or already in use, are reported
+// without stopping the import.
+func (s *ProductService) BulkImportProducts(ctx context.Context, tenantID uuid.UUID, r io.Reader) (*ProductImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("CSV file is empty")
+		}
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	columns, err := csvColumnIndex(header, productImportColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	lookup, err := s.newProductImportLookup(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ProductImportResult{
+		CreatedCategories: []string{},
+		CreatedBrands:     []string{},
+		Rows:              []ProductImportRowResult{},
+	}
+	// Row that first used each SKU in the file
+	skuRows := make(map[string]int)
+
+	row := 1
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		row++
+		if err != nil {
+			result.TotalRows++
+			result.Failed++
+			result.Rows = append(result.Rows, ProductImportRowResult{Row: row, Error: err.Error()})
+			continue
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+
+		result.TotalRows++
+		if result.TotalRows > maxProductImportRows {
+			return nil, fmt.Errorf("CSV exceeds the limit of %d rows", maxProductImportRows)
+		}
+
+		field := func(name string) string {
+			idx, ok := columns[name]
+			if !ok || idx >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[idx])
+		}
+
+		rowResult := ProductImportRowResult{Row: row, Name: field("name"), SKU: field("sku")}
+		req, err := productImportRequest(field)
+		if err == nil && req.SKU != "" {
+			key := strings.ToUpper(req.SKU)
+			if first, ok := skuRows[key]; ok {
+				err = fmt.Errorf("duplicate SKU %s, already used on row %d", req.SKU, first)
+			} else if lookup.skus[key] {
+				err = errors.New("product with this SKU already exists")
+			} else {
+				skuRows[key] = row
+			}
+		}
+		if err == nil {
+			req.CategoryID, err = s.importCategory(lookup, result, tenantID, field("category"))
+		}
+		if err == nil {
+			req.BrandID, err = s.importBrand(lookup, result, tenantID, field("brand"))
+		}
+
+		var product *ProductResponse
+		if err == nil {
+			product, err = s.CreateProduct(ctx, *req, tenantID)
+		}
+		if err != nil {
+			rowResult.Error = err.Error()
+			result.Failed++
+			result.Rows = append(result.Rows, rowResult)
+			continue
+		}
+
+		lookup.skus[strings.ToUpper(product.SKU)] = true
+		rowResult.SKU = product.SKU
+		rowResult.Success = true
+		rowResult.ProductID = &product.ID
+		result.Successful++
+		result.Rows = append(result.Rows, rowResult)
+	}
+
+	if result.TotalRows == 0 {
+		return nil, errors.New("CSV file has no rows")
+	}
+	if len(result.CreatedCategories) > 0 {
+		s.cache.Delete(ctx, fmt.Sprintf("categories:tenant:%s", tenantID.String()))
+	}
+	if len(result.CreatedBrands) > 0 {
+		s.clearBrandCache(ctx, tenantID)
+	}
+
+	return result, nil
+}
+
+// productImportRequest validates the fields of one row; category and brand are
+// resolved separately
+func productImportRequest(field func(string) string) (*ProductRequest, error) {
+	req := &ProductRequest{
+		Name:        field("name"),
+		Size:        field("size"),
+		SKU:         field("sku"),
+		Barcode:     field("barcode"),
+		Description: field("description"),
+		IsActive:    true,
+	}
+	for _, required := range []string{"name", "category", "brand", "size"} {
+		if field(required) == "" {
+			return nil, fmt.Errorf("%s is required", required)
+		}
+	}
+
+	prices := []struct {
+		column string
+		value  *float64
+	}{
+		{"cost_price", &req.CostPrice},
+		{"selling_price", &req.SellingPrice},
+		{"mrp", &req.MRP},
+	}
+	for _, price := range prices {
+		value, err := strconv.ParseFloat(field(price.column), 64)
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf("%s must be a positive number", price.column)
+		}
+		*price.value = value
+	}
+
+	if value := field("alcohol_content"); value != "" {
+		alcohol, err := strconv.ParseFloat(value, 64)
+		if err != nil || alcohol < 0 || alcohol > 100 {
+			return nil, errors.New("alcohol_content must be a percentage between 0 and 100")
+		}
+		req.AlcoholContent = alcohol
+	}
+	if value := field("units_per_case"); value != "" {
+		units, err := strconv.Atoi(value)
+		if err != nil || units < 1 {
+			return nil, errors.New("units_per_case must be a positive whole number")
+		}
+		req.UnitsPerCase = units
+	}
+
+	return req, nil
+}
+
+// productImportLookup resolves category and brand names and tracks SKUs in use
+type productImportLookup struct {
+	categories map[string]uuid.UUID
+	brands     map[string]uuid.UUID
+	skus       map[string]bool
+}
+
+func (s *ProductService) newProductImportLookup(tenantID uuid.UUID) (*productImportLookup, error) {
+	var categories []models.Category
+	if err := s.db.Select("id", "name").Where("tenant_id = ?", tenantID).Find(&categories).Error; err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
+	}
+	var brands []models.Brand
+	if err := s.db.Select("id", "name").Where("tenant_id = ?", tenantID).Find(&brands).Error; err != nil {
+		return nil, fmt.Errorf("failed to load brands: %w", err)
+	}
+	var skus []string
+	if err := s.db.Model(&models.Product{}).Where("tenant_id = ? AND sku <> ''", tenantID).Pluck("sku", &skus).Error; err != nil {
+		return nil, fmt.Errorf("failed to load product SKUs: %w", err)
+	}
+
+	lookup := &productImportLookup{
+		categories: make(map[string]uuid.UUID, len(categories)),
+		brands:     make(map[string]uuid.UUID, len(brands)),
+		skus:       make(map[string]bool, len(skus)),
+	}
+	for _, category := range categories {
+		lookup.categories[strings.ToLower(category.Name)] = category.ID
+	}
+	for _, brand := range brands {
+		lookup.brands[strings.ToLower(brand.Name)] = brand.ID
+	}
+	for _, sku := range skus {
+		lookup.skus[strings.ToUpper(sku)] = true
+	}
+	return lookup, nil
+}
+
+// importCategory finds a category by name, creating it if the tenant has none by that name
+func (s *ProductService) importCategory(lookup *productImportLookup, result *ProductImportResult, tenantID uuid.UUID, name string) (uuid.UUID, error) {
+	if id, ok := lookup.categories[strings.ToLower(name)]; ok {
+		return id, nil
+	}
+	category := models.Category{
+		TenantModel: models.TenantModel{TenantID: tenantID},
+		Name:        name,
+		IsActive:    true,
+	}
+	if err := s.db.Create(&category).Error; err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create category %s: %w", name, err)
+	}
+	lookup.categories[strings.ToLower(name)] = category.ID
+	result.CreatedCategories = append(result.CreatedCategories, name)
+	return category.ID, nil
+}
+
+// importBrand finds a brand by name, creating it if the tenant has none by that name
+func (s *ProductService) importBrand(lookup *productImportLookup, result *ProductImportResult, tenantID uuid.UUID, name string) (uuid.UUID, error) {
+	if id, ok := lookup.brands[strings.ToLower(name)]; ok {
+		return id, nil
+	}
+	brand := models.Brand{
+		TenantModel: models.TenantModel{TenantID: tenantID},
+		Name:        name,
+		IsActive:    true,
+	}
+	if err := s.db.Create(&brand).Error; err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create brand %s: %w", name, err)
+	}
+	lookup.brands[strings.ToLower(name)] = brand.ID
+	result.CreatedBrands = append(result.CreatedBrands, name)
+	return brand.ID, nil
+}
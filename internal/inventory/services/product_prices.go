@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"gorm.io/gorm"
+)
+
+// Where a product price change came from
+const (
+	PriceSourceProductUpdate = "product_update"
+	PriceSourceBrandPricing  = "brand_pricing"
+)
+
+// ProductPriceHistoryResponse represents one price change in responses
+type ProductPriceHistoryResponse struct {
+	ID              uuid.UUID `json:"id"`
+	ProductID       uuid.UUID `json:"product_id"`
+	OldCostPrice    float64   `json:"old_cost_price"`
+	NewCostPrice    float64   `json:"new_cost_price"`
+	OldSellingPrice float64   `json:"old_selling_price"`
+	NewSellingPrice float64   `json:"new_selling_price"`
+	OldMRP          float64   `json:"old_mrp"`
+	NewMRP          float64   `json:"new_mrp"`
+	Source          string    `json:"source"`
+	ChangedByID     uuid.UUID `json:"changed_by_id"`
+	ChangedByName   string    `json:"changed_by_name"`
+	ChangedAt       time.Time `json:"changed_at"`
+}
+
+// recordPriceChange writes a price history row when the new prices differ from the
+// product's current ones. It runs before the product itself is updated.
+func recordPriceChange(tx *gorm.DB, product *models.Product, costPrice, sellingPrice, mrp float64, source string, userID uuid.UUID) error {
+	if product.CostPrice == costPrice && product.SellingPrice == sellingPrice && product.MRP == mrp {
+		return nil
+	}
+
+	history := models.ProductPriceHistory{
+		TenantModel:     models.TenantModel{TenantID: product.TenantID},
+		ProductID:       product.ID,
+		OldCostPrice:    product.CostPrice,
+		NewCostPrice:    costPrice,
+		OldSellingPrice: product.SellingPrice,
+		NewSellingPrice: sellingPrice,
+		OldMRP:          product.MRP,
+		NewMRP:          mrp,
+		Source:          source,
+		ChangedByID:     userID,
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return fmt.Errorf("failed to record price history: %w", err)
+	}
+	return nil
+}
+
+// GetPriceHistory returns a product's price changes, newest first
+func (s *ProductService) GetPriceHistory(ctx context.Context, productID, tenantID uuid.UUID) ([]*ProductPriceHistoryResponse, error) {
+	var product models.Product
+	if err := s.db.Select("id").Where("id = ? AND tenant_id = ?", productID, tenantID).First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("product not found")
+		}
+		return nil, fmt.Errorf("failed to find product: %w", err)
+	}
+
+	var history []models.ProductPriceHistory
+	if err := s.db.Where("product_id = ? AND tenant_id = ?", productID, tenantID).
+		Preload("ChangedBy").
+		Order("created_at DESC").
+		Find(&history).Error; err != nil {
+		return nil, fmt.Errorf("failed to get price history: %w", err)
+	}
+
+	responses := make([]*ProductPriceHistoryResponse, len(history))
+	for i, change := range history {
+		response := &ProductPriceHistoryResponse{
+			ID:              change.ID,
+			ProductID:       change.ProductID,
+			OldCostPrice:    change.OldCostPrice,
+			NewCostPrice:    change.NewCostPrice,
+			OldSellingPrice: change.OldSellingPrice,
+			NewSellingPrice: change.NewSellingPrice,
+			OldMRP:          change.OldMRP,
+			NewMRP:          change.NewMRP,
+			Source:          change.Source,
+			ChangedByID:     change.ChangedByID,
+			ChangedAt:       change.CreatedAt,
+		}
+		if change.ChangedBy != nil {
+			response.ChangedByName = change.ChangedBy.FirstName + " " + change.ChangedBy.LastName
+		}
+		responses[i] = response
+	}
+
+	return responses, nil
+}
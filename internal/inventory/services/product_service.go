@@ -4,27 +4,32 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/google/uuid"
 	"github.com/liquorpro/go-backend/pkg/shared/cache"
 	"github.com/liquorpro/go-backend/pkg/shared/database"
 	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/usage"
 	"github.com/liquorpro/go-backend/pkg/shared/utils"
 	"gorm.io/gorm"
 )
 
 // ProductService handles product management operations
 type ProductService struct {
-	db    *database.DB
-	cache *cache.Cache
+	db     *database.DB
+	cache  *cache.Cache
+	limits *usage.Enforcer
 }
 
 // NewProductService creates a new product service
 func NewProductService(db *database.DB, cache *cache.Cache) *ProductService {
 	return &ProductService{
-		db:    db,
-		cache: cache,
+		db:     db,
+		cache:  cache,
+		limits: usage.NewEnforcer(db.DB),
 	}
 }
 
@@ -42,6 +47,8 @@ type ProductRequest struct {
 	SellingPrice   float64 `json:"selling_price" binding:"required,gt=0"`
 	MRP            float64 `json:"mrp" binding:"required,gt=0"`
 	IsActive       bool    `json:"is_active"`
+	UnitsPerCase   int     `json:"units_per_case" binding:"omitempty,gte=1"`
+	CostingMethod  string  `json:"costing_method" binding:"omitempty,oneof=fifo lifo average"`
 }
 
 // ProductResponse represents product in responses
@@ -53,6 +60,7 @@ type ProductResponse struct {
 	BrandID        uuid.UUID `json:"brand_id"`
 	BrandName      string    `json:"brand_name"`
 	Size           string    `json:"size"`
+	ProductGroupID *uuid.UUID `json:"product_group_id"`
 	AlcoholContent float64   `json:"alcohol_content"`
 	Description    string    `json:"description"`
 	Barcode        string    `json:"barcode"`
@@ -61,11 +69,35 @@ type ProductResponse struct {
 	SellingPrice   float64   `json:"selling_price"`
 	MRP            float64   `json:"mrp"`
 	IsActive       bool      `json:"is_active"`
+	UnitsPerCase   int       `json:"units_per_case"`
+	CostingMethod  string    `json:"costing_method"`
 	CurrentStock   int       `json:"current_stock"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 }
 
+// BulkProductStatusRequest represents a bulk activate/deactivate request
+type BulkProductStatusRequest struct {
+	ProductIDs  []uuid.UUID `json:"product_ids" binding:"required,min=1,max=500"`
+	IsActive    *bool       `json:"is_active" binding:"required"`
+	WarnOnStock bool        `json:"warn_on_stock"`
+}
+
+// BulkProductStatusResult reports the outcome for one product in a bulk status update
+type BulkProductStatusResult struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Status    string    `json:"status"` // updated, unchanged, not_found
+	Warning   string    `json:"warning,omitempty"`
+}
+
+// BulkProductStatusResponse summarizes a bulk status update
+type BulkProductStatusResponse struct {
+	IsActive  bool                      `json:"is_active"`
+	Updated   int                       `json:"updated"`
+	Unchanged int                       `json:"unchanged"`
+	NotFound  int                       `json:"not_found"`
+	Results   []BulkProductStatusResult `json:"results"`
+}
 
 // BrandPricingRequest represents brand pricing request
 type BrandPricingRequest struct {
@@ -92,15 +124,23 @@ func (s *ProductService) CreateProduct(ctx context.Context, req ProductRequest,
 
 	// Check for duplicate SKU if provided
 	if req.SKU != "" {
-		var existing models.Product
-		if err := s.db.Where("sku = ? AND tenant_id = ?", req.SKU, tenantID).First(&existing).Error; err == nil {
-			return nil, errors.New("product with this SKU already exists")
+		if err := s.checkSKUAvailable(req.SKU, tenantID, uuid.Nil); err != nil {
+			return nil, err
 		}
 	} else {
 		// Generate SKU if not provided
 		req.SKU = s.generateSKU(brand.Name, req.Size)
 	}
 
+	if err := s.limits.Check(ctx, tenantID, usage.ResourceProducts); err != nil {
+		return nil, err
+	}
+
+	groupID, err := productGroupID(s.db.DB, tenantID, req.BrandID, req.Name)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create product
 	product := models.Product{
 		TenantModel:    models.TenantModel{TenantID: tenantID},
@@ -108,6 +148,7 @@ func (s *ProductService) CreateProduct(ctx context.Context, req ProductRequest,
 		CategoryID:     req.CategoryID,
 		BrandID:        req.BrandID,
 		Size:           req.Size,
+		ProductGroupID: groupID,
 		AlcoholContent: req.AlcoholContent,
 		Description:    req.Description,
 		Barcode:        req.Barcode,
@@ -116,6 +157,8 @@ func (s *ProductService) CreateProduct(ctx context.Context, req ProductRequest,
 		SellingPrice:   req.SellingPrice,
 		MRP:            req.MRP,
 		IsActive:       req.IsActive,
+		UnitsPerCase:   req.UnitsPerCase,
+		CostingMethod:  req.CostingMethod,
 		Category:       &category,
 		Brand:          &brand,
 	}
@@ -217,8 +260,9 @@ func (s *ProductService) GetProductByID(ctx context.Context, productID, tenantID
 	return s.mapProductToResponse(&product, totalStock), nil
 }
 
-// UpdateProduct updates product information
-func (s *ProductService) UpdateProduct(ctx context.Context, productID, tenantID uuid.UUID, req ProductRequest) (*ProductResponse, error) {
+// UpdateProduct updates product information, recording any price change in the
+// product's price history
+func (s *ProductService) UpdateProduct(ctx context.Context, productID, tenantID, userID uuid.UUID, req ProductRequest) (*ProductResponse, error) {
 	var product models.Product
 	
 	err := s.db.Where("id = ? AND tenant_id = ?", productID, tenantID).First(&product).Error
@@ -247,13 +291,13 @@ func (s *ProductService) UpdateProduct(ctx context.Context, productID, tenantID
 
 	// Check SKU uniqueness if changed
 	if req.SKU != "" && req.SKU != product.SKU {
-		var existing models.Product
-		if err := s.db.Where("sku = ? AND tenant_id = ? AND id != ?", req.SKU, tenantID, productID).First(&existing).Error; err == nil {
-			return nil, errors.New("product with this SKU already exists")
+		if err := s.checkSKUAvailable(req.SKU, tenantID, productID); err != nil {
+			return nil, err
 		}
 	}
 
 	// Update product
+	oldBarcode := product.Barcode
 	updates := map[string]interface{}{
 		"name":            req.Name,
 		"category_id":     req.CategoryID,
@@ -268,13 +312,39 @@ func (s *ProductService) UpdateProduct(ctx context.Context, productID, tenantID
 		"mrp":             req.MRP,
 		"is_active":       req.IsActive,
 	}
+	if req.UnitsPerCase > 0 {
+		updates["units_per_case"] = req.UnitsPerCase
+	}
+	if req.CostingMethod != "" {
+		updates["costing_method"] = req.CostingMethod
+	}
+
+	// A product renamed or moved to another brand joins that brand and name's group
+	if product.ProductGroupID == nil || req.BrandID != product.BrandID || !strings.EqualFold(strings.TrimSpace(req.Name), strings.TrimSpace(product.Name)) {
+		groupID, err := productGroupID(s.db.DB, tenantID, req.BrandID, req.Name)
+		if err != nil {
+			return nil, err
+		}
+		updates["product_group_id"] = groupID
+	}
 
-	if err := s.db.Model(&product).Updates(updates).Error; err != nil {
-		return nil, fmt.Errorf("failed to update product: %w", err)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := recordPriceChange(tx, &product, req.CostPrice, req.SellingPrice, req.MRP, PriceSourceProductUpdate, userID); err != nil {
+			return err
+		}
+		if err := tx.Model(&product).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update product: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Clear cache
 	s.clearProductCache(ctx, tenantID)
+	s.clearBarcodeCache(ctx, tenantID, oldBarcode)
+	s.clearBarcodeCache(ctx, tenantID, req.Barcode)
 
 	// Get updated product
 	return s.GetProductByID(ctx, productID, tenantID)
@@ -309,10 +379,101 @@ func (s *ProductService) DeleteProduct(ctx context.Context, productID, tenantID
 
 	// Clear cache
 	s.clearProductCache(ctx, tenantID)
+	s.clearBarcodeCache(ctx, tenantID, product.Barcode)
 
 	return nil
 }
 
+// BulkSetActive activates or deactivates many products in one transaction.
+// Unlike deletion, deactivation is allowed while stock remains; callers can ask
+// for a warning on products that still hold stock.
+func (s *ProductService) BulkSetActive(ctx context.Context, productIDs []uuid.UUID, isActive bool, tenantID uuid.UUID, warnOnStock bool) (*BulkProductStatusResponse, error) {
+	response := &BulkProductStatusResponse{
+		IsActive: isActive,
+		Results:  make([]BulkProductStatusResult, 0, len(productIDs)),
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var products []models.Product
+		if err := tx.Where("id IN ? AND tenant_id = ?", productIDs, tenantID).Find(&products).Error; err != nil {
+			return fmt.Errorf("failed to find products: %w", err)
+		}
+
+		productsByID := make(map[uuid.UUID]models.Product, len(products))
+		for _, product := range products {
+			productsByID[product.ID] = product
+		}
+
+		stockByProduct := make(map[uuid.UUID]int)
+		if !isActive && warnOnStock && len(products) > 0 {
+			var stockTotals []struct {
+				ProductID uuid.UUID
+				Quantity  int
+			}
+			err := tx.Model(&models.Stock{}).
+				Select("product_id, COALESCE(SUM(quantity), 0) as quantity").
+				Where("product_id IN ? AND tenant_id = ? AND quantity > 0", productIDs, tenantID).
+				Group("product_id").
+				Scan(&stockTotals).Error
+			if err != nil {
+				return fmt.Errorf("failed to check product stock: %w", err)
+			}
+			for _, total := range stockTotals {
+				stockByProduct[total.ProductID] = total.Quantity
+			}
+		}
+
+		var toUpdate []uuid.UUID
+		seen := make(map[uuid.UUID]bool, len(productIDs))
+		for _, id := range productIDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			result := BulkProductStatusResult{ProductID: id}
+			product, ok := productsByID[id]
+			switch {
+			case !ok:
+				result.Status = "not_found"
+				response.NotFound++
+			case product.IsActive == isActive:
+				result.Status = "unchanged"
+				response.Unchanged++
+			default:
+				result.Status = "updated"
+				response.Updated++
+				toUpdate = append(toUpdate, id)
+			}
+
+			if quantity := stockByProduct[id]; ok && quantity > 0 {
+				result.Warning = fmt.Sprintf("product still has %d units in stock", quantity)
+			}
+
+			response.Results = append(response.Results, result)
+		}
+
+		if len(toUpdate) > 0 {
+			if err := tx.Model(&models.Product{}).
+				Where("id IN ? AND tenant_id = ?", toUpdate, tenantID).
+				Update("is_active", isActive).Error; err != nil {
+				return fmt.Errorf("failed to update product status: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Updated > 0 {
+		s.clearProductCache(ctx, tenantID)
+	}
+
+	return response, nil
+}
+
 // Brand Management
 
 // CreateBrand creates a new brand
@@ -477,50 +638,66 @@ func (s *ProductService) DeleteBrand(ctx context.Context, brandID, tenantID uuid
 
 // Brand Pricing Management
 
-// CreateBrandPricing creates brand-size pricing
-func (s *ProductService) CreateBrandPricing(ctx context.Context, req BrandPricingRequest, tenantID uuid.UUID) error {
+// CreateBrandPricing sets the pricing for a brand and size and applies it to every
+// product of that brand and size, recording each product's price change
+func (s *ProductService) CreateBrandPricing(ctx context.Context, req BrandPricingRequest, tenantID, userID uuid.UUID) error {
 	// Verify brand exists
 	var brand models.Brand
 	if err := s.db.Where("id = ? AND tenant_id = ?", req.BrandID, tenantID).First(&brand).Error; err != nil {
 		return errors.New("brand not found")
 	}
 
-	// Check for duplicate
-	var existing models.BrandPricing
-	if err := s.db.Where("brand_id = ? AND size = ? AND tenant_id = ?", 
-		req.BrandID, req.Size, tenantID).First(&existing).Error; err == nil {
-		// Update existing
-		updates := map[string]interface{}{
-			"cost_price":    req.CostPrice,
-			"selling_price": req.SellingPrice,
-			"mrp":           req.MRP,
+	prices := map[string]interface{}{
+		"cost_price":    req.CostPrice,
+		"selling_price": req.SellingPrice,
+		"mrp":           req.MRP,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Update existing pricing or create new
+		var existing models.BrandPricing
+		err := tx.Where("brand_id = ? AND size = ? AND tenant_id = ?", req.BrandID, req.Size, tenantID).First(&existing).Error
+		if err == nil {
+			if err := tx.Model(&existing).Updates(prices).Error; err != nil {
+				return fmt.Errorf("failed to update brand pricing: %w", err)
+			}
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			pricing := models.BrandPricing{
+				TenantModel:  models.TenantModel{TenantID: tenantID},
+				BrandID:      req.BrandID,
+				Size:         req.Size,
+				CostPrice:    req.CostPrice,
+				SellingPrice: req.SellingPrice,
+				MRP:          req.MRP,
+			}
+			if err := tx.Create(&pricing).Error; err != nil {
+				return fmt.Errorf("failed to create brand pricing: %w", err)
+			}
+		} else {
+			return fmt.Errorf("failed to get brand pricing: %w", err)
 		}
-		return s.db.Model(&existing).Updates(updates).Error
-	}
 
-	// Create new pricing
-	pricing := models.BrandPricing{
-		TenantModel:  models.TenantModel{TenantID: tenantID},
-		BrandID:      req.BrandID,
-		Size:         req.Size,
-		CostPrice:    req.CostPrice,
-		SellingPrice: req.SellingPrice,
-		MRP:          req.MRP,
-	}
-
-	if err := s.db.Create(&pricing).Error; err != nil {
-		return fmt.Errorf("failed to create brand pricing: %w", err)
+		// Update all products with this brand and size
+		var products []models.Product
+		if err := tx.Where("brand_id = ? AND size = ? AND tenant_id = ?", req.BrandID, req.Size, tenantID).Find(&products).Error; err != nil {
+			return fmt.Errorf("failed to get products: %w", err)
+		}
+		for i := range products {
+			product := &products[i]
+			if err := recordPriceChange(tx, product, req.CostPrice, req.SellingPrice, req.MRP, PriceSourceBrandPricing, userID); err != nil {
+				return err
+			}
+			if err := tx.Model(product).Updates(prices).Error; err != nil {
+				return fmt.Errorf("failed to update product pricing: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	// Update all products with this brand and size
-	s.db.Model(&models.Product{}).
-		Where("brand_id = ? AND size = ? AND tenant_id = ?", req.BrandID, req.Size, tenantID).
-		Updates(map[string]interface{}{
-			"cost_price":    req.CostPrice,
-			"selling_price": req.SellingPrice,
-			"mrp":           req.MRP,
-		})
-
+	s.clearProductCache(ctx, tenantID)
 	return nil
 }
 
@@ -553,6 +730,7 @@ func (s *ProductService) mapProductToResponse(product *models.Product, currentSt
 		CategoryID:     product.CategoryID,
 		BrandID:        product.BrandID,
 		Size:           product.Size,
+		ProductGroupID: product.ProductGroupID,
 		AlcoholContent: product.AlcoholContent,
 		Description:    product.Description,
 		Barcode:        product.Barcode,
@@ -561,6 +739,8 @@ func (s *ProductService) mapProductToResponse(product *models.Product, currentSt
 		SellingPrice:   product.SellingPrice,
 		MRP:            product.MRP,
 		IsActive:       product.IsActive,
+		UnitsPerCase:   product.UnitsPerCase,
+		CostingMethod:  product.CostingMethod,
 		CurrentStock:   currentStock,
 		CreatedAt:      product.CreatedAt,
 		UpdatedAt:      product.UpdatedAt,
@@ -592,11 +772,26 @@ func (s *ProductService) mapBrandToResponse(brand *models.Brand, productCount in
 func (s *ProductService) generateSKU(brandName, size string) string {
 	timestamp := time.Now().Format("060102")
 	random, _ := utils.GenerateRandomString(4)
-	brandCode := brandName[:3]
-	if len(brandName) < 3 {
-		brandCode = brandName
+	return fmt.Sprintf("%s-%s-%s-%s", SKUBrandCode(brandName), size, timestamp, random)
+}
+
+// SKUBrandCode returns the brand part of a generated SKU: the first three letters
+// or digits of the brand name, uppercased. Names are read by rune so non-ASCII
+// brands are never cut mid-character; short names are padded with X.
+func SKUBrandCode(brandName string) string {
+	code := make([]rune, 0, 3)
+	for _, r := range brandName {
+		if len(code) == 3 {
+			break
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			code = append(code, unicode.ToUpper(r))
+		}
+	}
+	for len(code) < 3 {
+		code = append(code, 'X')
 	}
-	return fmt.Sprintf("%s-%s-%s-%s", brandCode, size, timestamp, random)
+	return string(code)
 }
 
 // getStockLevels gets stock levels for products
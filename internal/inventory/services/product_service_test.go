@@ -0,0 +1,49 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSKUBrandCode(t *testing.T) {
+	cases := []struct {
+		name  string
+		brand string
+		code  string
+	}{
+		{"ASCII Brand", "Royal Stag", "ROY"},
+		{"Lowercase Brand", "bacardi", "BAC"},
+		{"Leading Digits", "8PM", "8PM"},
+		{"Punctuation Stripped", "A&B's", "ABS"},
+		{"Single Character", "J", "JXX"},
+		{"Two Characters", "Xo", "XOX"},
+		{"Empty Name", "", "XXX"},
+		{"Only Symbols", "&-!", "XXX"},
+		{"Accented Latin", "Jägermeister", "JÄG"},
+		{"Accent In First Rune", "Émile", "ÉMI"},
+		{"CJK", "日本酒", "日本酒"},
+		{"Devanagari Marks Skipped", "ओल्ड मंक", "ओलड"},
+		{"Short Multibyte", "é", "ÉXX"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			code := SKUBrandCode(tc.brand)
+			assert.Equal(t, tc.code, code)
+			assert.True(t, utf8.ValidString(code))
+			assert.Equal(t, 3, utf8.RuneCountInString(code))
+		})
+	}
+}
+
+func TestGenerateSKUStartsWithBrandCode(t *testing.T) {
+	s := &ProductService{}
+	for _, brand := range []string{"Royal Stag", "Jägermeister", "日本酒", ""} {
+		sku := s.generateSKU(brand, "750ML")
+		assert.True(t, strings.HasPrefix(sku, SKUBrandCode(brand)+"-750ML-"), sku)
+		assert.True(t, utf8.ValidString(sku), sku)
+	}
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,7 +10,10 @@ import (
 	"github.com/liquorpro/go-backend/pkg/shared/cache"
 	"github.com/liquorpro/go-backend/pkg/shared/database"
 	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PurchaseService struct {
@@ -234,6 +238,7 @@ func (s *PurchaseService) GetPurchases(ctx context.Context, tenantID uuid.UUID,
 	if shopID != nil {
 		query = query.Where("shop_id = ?", *shopID)
 	}
+	query = scope.FromContext(ctx).Apply(query, "shop_id")
 	
 	if status != "" {
 		query = query.Where("status = ?", status)
@@ -289,125 +294,296 @@ func (s *PurchaseService) GetPurchaseByID(ctx context.Context, id, tenantID uuid
 	return s.buildPurchaseResponseFromModel(purchase), nil
 }
 
+// ReceivePurchase brings a pending purchase into stock at its shop. Each line is
+// folded into the stock's weighted average cost and kept as a cost layer so
+// later sales can be costed by the stock's costing method.
 func (s *PurchaseService) ReceivePurchase(ctx context.Context, id, tenantID, userID uuid.UUID) error {
-	tx := s.db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+	var purchase models.StockPurchase
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND tenant_id = ?", id, tenantID).
+			Preload("Items").
+			First(&purchase).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("purchase not found")
+			}
+			return fmt.Errorf("failed to get purchase: %w", err)
 		}
-	}()
-
-	if err := tx.Error; err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
 
-	// Get purchase with items
-	var purchase models.StockPurchase
-	if err := tx.Where("id = ? AND tenant_id = ?", id, tenantID).
-		Preload("Items").
-		First(&purchase).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("purchase not found: %w", err)
-	}
+		if purchase.Status != "pending" {
+			return errors.New("purchase is not in pending status")
+		}
 
-	if purchase.Status != "pending" {
-		tx.Rollback()
-		return fmt.Errorf("purchase is not in pending status")
-	}
+		now := time.Now()
+		for i := range purchase.Items {
+			item := &purchase.Items[i]
 
-	// Create or update stock for each item
-	for _, item := range purchase.Items {
-		// Check if stock exists for this product in this shop
-		var stock models.Stock
-		err := tx.Where("product_id = ? AND shop_id = ? AND tenant_id = ?", 
-			item.ProductID, purchase.VendorID, tenantID).First(&stock).Error
+			var product models.Product
+			if err := tx.Where("id = ? AND tenant_id = ?", item.ProductID, tenantID).First(&product).Error; err != nil {
+				return fmt.Errorf("product %s not found", item.ProductID)
+			}
 
-		if err == gorm.ErrRecordNotFound {
-			// Create new stock record
-			stock = models.Stock{
-				TenantModel: models.TenantModel{
-					BaseModel: models.BaseModel{ID: uuid.New()},
-					TenantID:  tenantID,
-				},
-				ProductID:      item.ProductID,
-				ShopID:         purchase.VendorID,
-				Quantity:       item.Quantity,
-				MinimumLevel:   0,
-				MaximumLevel:   0,
-				AverageCost:    item.UnitCost,
-				LastPurchaseDate: &time.Time{},
+			// Get or create the stock for this product at the purchasing shop
+			var stock models.Stock
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("product_id = ? AND shop_id = ? AND tenant_id = ?", item.ProductID, purchase.ShopID, tenantID).
+				First(&stock).Error
+			if err != nil {
+				if !errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("failed to get stock: %w", err)
+				}
+				stock = models.Stock{
+					TenantModel:   models.TenantModel{TenantID: tenantID},
+					ProductID:     item.ProductID,
+					ShopID:        purchase.ShopID,
+					CostingMethod: productCostingMethod(&product),
+				}
+				if err := tx.Create(&stock).Error; err != nil {
+					return fmt.Errorf("failed to create stock: %w", err)
+				}
 			}
-			*stock.LastPurchaseDate = time.Now()
 
-			if err := tx.Create(&stock).Error; err != nil {
-				tx.Rollback()
-				return fmt.Errorf("failed to create stock: %w", err)
+			batchNumber := item.BatchNumber
+			if batchNumber == "" {
+				batchNumber = purchase.PurchaseNumber
 			}
-		} else if err != nil {
-			tx.Rollback()
-			return fmt.Errorf("failed to check stock: %w", err)
-		} else {
-			// Update existing stock
-			newStock := stock.Quantity + item.Quantity
-			now := time.Now()
-			
-			updates := map[string]interface{}{
-				"quantity": newStock,
-				"average_cost": item.UnitCost,
-				"last_purchase_date": &now,
+			if err := addCostLayer(tx, &stock, item.Quantity, item.UnitCost, models.StockBatch{
+				BatchNumber:     batchNumber,
+				SellingPrice:    product.SellingPrice,
+				ManufactureDate: item.ManufactureDate,
+				ExpiryDate:      item.ExpiryDate,
+				PurchaseDate:    now,
+				SupplierID:      &purchase.VendorID,
+				StockPurchaseID: &purchase.ID,
+			}); err != nil {
+				return err
 			}
 
-			if err := tx.Model(&stock).Updates(updates).Error; err != nil {
-				tx.Rollback()
+			previousQuantity, err := addStockQuantity(tx, &stock, item.Quantity)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&stock).Updates(map[string]interface{}{
+				"last_purchase_price": item.UnitCost,
+				"last_purchase_date":  now,
+			}).Error; err != nil {
 				return fmt.Errorf("failed to update stock: %w", err)
 			}
-		}
 
-		// Create stock movement record
-		purchaseID := purchase.ID
-		movement := models.StockMovement{
-			TenantModel: models.TenantModel{
-				BaseModel: models.BaseModel{ID: uuid.New()},
-				TenantID:  tenantID,
-			},
-			StockID:      stock.ID,
-			MovementType: "purchase",
-			Quantity:     item.Quantity,
-			Reference:    "purchase",
-			ReferenceID:  &purchaseID,
-			Notes:        fmt.Sprintf("Stock received from purchase %s", purchase.PurchaseNumber),
+			history := models.StockHistory{
+				TenantModel:      models.TenantModel{TenantID: tenantID},
+				StockID:          stock.ID,
+				MovementType:     "purchase",
+				Quantity:         item.Quantity,
+				PreviousQuantity: previousQuantity,
+				NewQuantity:      previousQuantity + item.Quantity,
+				UnitCost:         item.UnitCost,
+				TotalCost:        item.UnitCost * float64(item.Quantity),
+				Reference:        purchase.PurchaseNumber,
+				ReferenceID:      &purchase.ID,
+				Notes:            fmt.Sprintf("Stock received from purchase %s", purchase.PurchaseNumber),
+				CreatedByID:      userID,
+			}
+			if err := tx.Create(&history).Error; err != nil {
+				return fmt.Errorf("failed to create stock history: %w", err)
+			}
 		}
 
-		if err := tx.Create(&movement).Error; err != nil {
-			tx.Rollback()
-			return fmt.Errorf("failed to create stock movement: %w", err)
+		if err := tx.Model(&purchase).Updates(map[string]interface{}{
+			"status":      "received",
+			"received_at": now,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update purchase status: %w", err)
 		}
-	}
-
-	// Update purchase status
-	if err := tx.Model(&purchase).Updates(map[string]interface{}{
-		"status": "received",
-		"received_at": time.Now(),
-		"received_by": userID,
-	}).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to update purchase status: %w", err)
-	}
-
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	// Clear cache
 	cacheKey := fmt.Sprintf("purchases:tenant:%s", tenantID.String())
 	s.cache.Delete(ctx, cacheKey)
-	
-	stockCacheKey := fmt.Sprintf("stocks:shop:%s:tenant:%s", purchase.VendorID.String(), tenantID.String())
-	s.cache.Delete(ctx, stockCacheKey)
+
+	for _, item := range purchase.Items {
+		s.cache.Delete(ctx, fmt.Sprintf(cache.StockKey, purchase.ShopID.String(), item.ProductID.String()))
+	}
+	s.cache.Delete(ctx, fmt.Sprintf("stock_levels:%s", tenantID.String()))
+	s.cache.Delete(ctx, fmt.Sprintf("low_stock:%s", tenantID.String()))
 
 	return nil
 }
 
+// reorderVelocityDays is the sales history used to estimate days of cover
+const reorderVelocityDays = 30
+
+type ReorderPreviewRequest struct {
+	Items []ReorderPreviewItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type ReorderPreviewItemRequest struct {
+	ShopID    uuid.UUID  `json:"shop_id" binding:"required"`
+	ProductID uuid.UUID  `json:"product_id" binding:"required"`
+	VendorID  *uuid.UUID `json:"vendor_id"`
+	Quantity  int        `json:"quantity" binding:"required,gt=0"`
+}
+
+type ReorderPreviewResponse struct {
+	Vendors     []*ReorderVendorPreview `json:"vendors"`
+	TotalItems  int                     `json:"total_items"`
+	TotalCost   float64                 `json:"total_cost"`
+	GeneratedAt time.Time               `json:"generated_at"`
+}
+
+type ReorderVendorPreview struct {
+	VendorID   *uuid.UUID            `json:"vendor_id"`
+	VendorName string                `json:"vendor_name"`
+	TotalItems int                   `json:"total_items"`
+	TotalCost  float64               `json:"total_cost"`
+	Items      []*ReorderItemPreview `json:"items"`
+}
+
+type ReorderItemPreview struct {
+	ShopID           uuid.UUID `json:"shop_id"`
+	ShopName         string    `json:"shop_name"`
+	ProductID        uuid.UUID `json:"product_id"`
+	ProductName      string    `json:"product_name"`
+	SKU              string    `json:"sku"`
+	Quantity         int       `json:"quantity"`
+	UnitCost         float64   `json:"unit_cost"`
+	CostSource       string    `json:"cost_source"` // last_purchase or product_cost
+	LineTotal        float64   `json:"line_total"`
+	CurrentStock     int       `json:"current_stock"`
+	ProjectedOnHand  int       `json:"projected_on_hand"`
+	AverageDailySale float64   `json:"average_daily_sale"`
+	DaysOfCover      *float64  `json:"days_of_cover"` // nil when the product has not sold recently
+}
+
+// PreviewReorder prices suggested reorder quantities and projects the resulting
+// stock position, grouped by vendor. Nothing is written.
+func (s *PurchaseService) PreviewReorder(ctx context.Context, req ReorderPreviewRequest, tenantID uuid.UUID) (*ReorderPreviewResponse, error) {
+	since := time.Now().AddDate(0, 0, -reorderVelocityDays)
+
+	vendors := make(map[uuid.UUID]*ReorderVendorPreview)
+	var unassigned *ReorderVendorPreview
+	order := make([]*ReorderVendorPreview, 0)
+	vendorNames := make(map[uuid.UUID]string)
+
+	response := &ReorderPreviewResponse{GeneratedAt: time.Now()}
+	for _, item := range req.Items {
+		var product models.Product
+		if err := s.db.Where("id = ? AND tenant_id = ?", item.ProductID, tenantID).First(&product).Error; err != nil {
+			return nil, fmt.Errorf("product %s not found", item.ProductID)
+		}
+
+		var shop models.Shop
+		if err := s.db.Where("id = ? AND tenant_id = ?", item.ShopID, tenantID).First(&shop).Error; err != nil {
+			return nil, fmt.Errorf("shop %s not found", item.ShopID)
+		}
+
+		// Current position; a missing stock row simply means nothing on hand
+		var stock models.Stock
+		currentStock := 0
+		err := s.db.Where("shop_id = ? AND product_id = ? AND tenant_id = ?", item.ShopID, item.ProductID, tenantID).First(&stock).Error
+		if err == nil {
+			currentStock = stock.Quantity
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to get stock: %w", err)
+		}
+
+		// Most recent received purchase of the product, preferring the requested vendor
+		lastPurchase := s.db.Model(&models.StockPurchaseItem{}).
+			Select("stock_purchase_items.unit_cost, stock_purchases.vendor_id").
+			Joins("JOIN stock_purchases ON stock_purchases.id = stock_purchase_items.stock_purchase_id").
+			Where("stock_purchase_items.product_id = ? AND stock_purchase_items.tenant_id = ? AND stock_purchases.status = ?", item.ProductID, tenantID, "received")
+		if item.VendorID != nil {
+			lastPurchase = lastPurchase.Where("stock_purchases.vendor_id = ?", *item.VendorID)
+		}
+		var last struct {
+			UnitCost float64
+			VendorID uuid.UUID
+		}
+		if err := lastPurchase.Order("stock_purchases.purchase_date DESC").Limit(1).Scan(&last).Error; err != nil {
+			return nil, fmt.Errorf("failed to get last purchase price: %w", err)
+		}
+
+		unitCost, costSource := product.CostPrice, "product_cost"
+		if last.UnitCost > 0 {
+			unitCost, costSource = last.UnitCost, "last_purchase"
+		}
+
+		vendorID := item.VendorID
+		if vendorID == nil && last.VendorID != uuid.Nil {
+			vendorID = &last.VendorID
+		}
+
+		var sold int64
+		if stock.ID != uuid.Nil {
+			err := s.db.Model(&models.StockHistory{}).
+				Where("stock_id = ? AND movement_type = ? AND created_at >= ?", stock.ID, "sale", since).
+				Select("COALESCE(SUM(quantity), 0)").
+				Scan(&sold).Error
+			if err != nil {
+				return nil, fmt.Errorf("failed to get sales velocity: %w", err)
+			}
+		}
+
+		preview := &ReorderItemPreview{
+			ShopID:           shop.ID,
+			ShopName:         shop.Name,
+			ProductID:        product.ID,
+			ProductName:      product.Name,
+			SKU:              product.SKU,
+			Quantity:         item.Quantity,
+			UnitCost:         unitCost,
+			CostSource:       costSource,
+			LineTotal:        utils.RoundToTwoDecimals(unitCost * float64(item.Quantity)),
+			CurrentStock:     currentStock,
+			ProjectedOnHand:  currentStock + item.Quantity,
+			AverageDailySale: utils.RoundToTwoDecimals(float64(sold) / reorderVelocityDays),
+		}
+		if sold > 0 {
+			cover := utils.RoundToTwoDecimals(float64(preview.ProjectedOnHand) * reorderVelocityDays / float64(sold))
+			preview.DaysOfCover = &cover
+		}
+
+		var group *ReorderVendorPreview
+		if vendorID == nil {
+			if unassigned == nil {
+				unassigned = &ReorderVendorPreview{VendorName: "Unassigned"}
+				order = append(order, unassigned)
+			}
+			group = unassigned
+		} else {
+			group = vendors[*vendorID]
+			if group == nil {
+				name, ok := vendorNames[*vendorID]
+				if !ok {
+					var vendor models.Vendor
+					if err := s.db.Where("id = ? AND tenant_id = ?", *vendorID, tenantID).First(&vendor).Error; err != nil {
+						return nil, fmt.Errorf("vendor %s not found", *vendorID)
+					}
+					name = vendor.Name
+					vendorNames[*vendorID] = name
+				}
+				id := *vendorID
+				group = &ReorderVendorPreview{VendorID: &id, VendorName: name}
+				vendors[id] = group
+				order = append(order, group)
+			}
+		}
+
+		group.Items = append(group.Items, preview)
+		group.TotalItems += preview.Quantity
+		group.TotalCost = utils.RoundToTwoDecimals(group.TotalCost + preview.LineTotal)
+		response.TotalItems += preview.Quantity
+		response.TotalCost = utils.RoundToTwoDecimals(response.TotalCost + preview.LineTotal)
+	}
+	response.Vendors = order
+
+	return response, nil
+}
+
 func (s *PurchaseService) generatePurchaseNumber(ctx context.Context, tenantID uuid.UUID) (string, error) {
 	year := time.Now().Year()
 	
@@ -0,0 +1,437 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/cache"
+	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+)
+
+// ReportService handles inventory analytics and reports
+type ReportService struct {
+	db       *database.DB
+	cache    *cache.Cache
+	settings *settings.Service
+}
+
+// NewReportService creates a new report service
+func NewReportService(db *database.DB, cache *cache.Cache, settingsService *settings.Service) *ReportService {
+	return &ReportService{
+		db:       db,
+		cache:    cache,
+		settings: settingsService,
+	}
+}
+
+// DeadStockItem represents a stock line with no sales in the report window
+type DeadStockItem struct {
+	StockID      uuid.UUID        `json:"stock_id"`
+	ShopID       uuid.UUID        `json:"shop_id"`
+	ShopName     string           `json:"shop_name"`
+	ProductID    uuid.UUID        `json:"product_id"`
+	ProductName  string           `json:"product_name"`
+	BrandName    string           `json:"brand_name"`
+	CategoryName string           `json:"category_name"`
+	Size         string           `json:"size"`
+	SKU          string           `json:"sku"`
+	Quantity     int              `json:"quantity"`
+	UnitsPerCase int              `json:"units_per_case"`
+	Display      *DisplayQuantity `json:"display" gorm:"-"`
+	UnitCost     float64          `json:"unit_cost"`
+	TiedUpValue  float64          `json:"tied_up_value"`
+	LastSaleDate *time.Time       `json:"last_sale_date"`
+}
+
+// DeadStockReport represents the dead stock report
+type DeadStockReport struct {
+	Days             int              `json:"days"`
+	Since            time.Time        `json:"since"`
+	TotalItems       int              `json:"total_items"`
+	TotalQuantity    int              `json:"total_quantity"`
+	DisplayUnit      string           `json:"display_unit"`
+	TotalDisplay     *DisplayQuantity `json:"total_display"`
+	TotalTiedUpValue float64          `json:"total_tied_up_value"`
+	Items            []*DeadStockItem `json:"items"`
+}
+
+// GetDeadStock returns stock on hand with no sale movements in the last N days,
+// sorted by the capital tied up in it
+func (s *ReportService) GetDeadStock(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, days int, unit string) (*DeadStockReport, error) {
+	if days <= 0 {
+		days = 90
+	}
+	since := time.Now().AddDate(0, 0, -days)
+
+	// Unit cost falls back to the product cost price when no average cost is tracked yet
+	query := s.db.Table("stocks st").
+		Select(`st.id AS stock_id, st.shop_id, sh.name AS shop_name, st.product_id,
+			p.name AS product_name, COALESCE(b.name, '') AS brand_name, COALESCE(c.name, '') AS category_name,
+			p.size, p.sku, st.quantity, COALESCE(NULLIF(p.units_per_case, 0), 1) AS units_per_case,
+			CASE WHEN st.average_cost > 0 THEN st.average_cost ELSE p.cost_price END AS unit_cost,
+			st.quantity * CASE WHEN st.average_cost > 0 THEN st.average_cost ELSE p.cost_price END AS tied_up_value,
+			(SELECT MAX(h.created_at) FROM stock_histories h
+				WHERE h.stock_id = st.id AND h.movement_type = 'sale' AND h.deleted_at IS NULL) AS last_sale_date`).
+		Joins("JOIN products p ON p.id = st.product_id").
+		Joins("JOIN shops sh ON sh.id = st.shop_id").
+		Joins("LEFT JOIN brands b ON b.id = p.brand_id").
+		Joins("LEFT JOIN categories c ON c.id = p.category_id").
+		Where("st.tenant_id = ? AND st.quantity > 0 AND st.deleted_at IS NULL", tenantID).
+		Where(`NOT EXISTS (SELECT 1 FROM stock_histories h
+			WHERE h.stock_id = st.id AND h.movement_type = 'sale' AND h.created_at >= ? AND h.deleted_at IS NULL)`, since)
+
+	if shopID != nil {
+		query = query.Where("st.shop_id = ?", *shopID)
+	}
+	query = scope.FromContext(ctx).Apply(query, "st.shop_id")
+
+	items := make([]*DeadStockItem, 0)
+	if err := query.Order("tied_up_value DESC").Scan(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to get dead stock: %w", err)
+	}
+
+	unit = s.displayUnit(ctx, tenantID, unit)
+	report := &DeadStockReport{
+		Days:        days,
+		Since:       since,
+		TotalItems:  len(items),
+		DisplayUnit: unit,
+		Items:       items,
+	}
+	total := &displayTotal{unit: unit}
+	for _, item := range items {
+		item.Display = toDisplayQuantity(item.Quantity, item.UnitsPerCase, unit)
+		total.add(item.Quantity, item.UnitsPerCase)
+		report.TotalQuantity += item.Quantity
+		report.TotalTiedUpValue += item.TiedUpValue
+	}
+	report.TotalDisplay = total.result()
+
+	return report, nil
+}
+
+// ExpiryRiskItem represents an unexpired batch that expires inside the report window
+type ExpiryRiskItem struct {
+	BatchID      uuid.UUID `json:"batch_id"`
+	BatchNumber  string    `json:"batch_number"`
+	StockID      uuid.UUID `json:"stock_id"`
+	ShopID       uuid.UUID `json:"shop_id"`
+	ShopName     string    `json:"shop_name"`
+	ProductID    uuid.UUID `json:"product_id"`
+	ProductName  string    `json:"product_name"`
+	SKU          string    `json:"sku"`
+	Quantity     int       `json:"quantity"`
+	UnitCost     float64   `json:"unit_cost"`
+	Value        float64   `json:"value"`
+	ExpiryDate   time.Time `json:"expiry_date"`
+	DaysToExpiry int       `json:"days_to_expiry" gorm:"-"`
+}
+
+// ExpiryRiskReport represents the expiry risk report
+type ExpiryRiskReport struct {
+	Days             int               `json:"days"`
+	Until            time.Time         `json:"until"`
+	TotalBatches     int               `json:"total_batches"`
+	TotalQuantity    int               `json:"total_quantity"`
+	TotalAtRiskValue float64           `json:"total_at_risk_value"`
+	Items            []*ExpiryRiskItem `json:"items"`
+}
+
+// GetExpiryRisk returns batches with stock left that expire within the next N days,
+// soonest first and then by value. Batches that have already expired are left to
+// the write-off report.
+func (s *ReportService) GetExpiryRisk(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, days int) (*ExpiryRiskReport, error) {
+	if days <= 0 {
+		days = 30
+	}
+	now := time.Now()
+	until := now.AddDate(0, 0, days)
+
+	query := s.db.Table("stock_batches sb").
+		Select(`sb.id AS batch_id, sb.batch_number, sb.stock_id, st.shop_id, sh.name AS shop_name,
+			sb.product_id, p.name AS product_name, p.sku, sb.quantity,
+			sb.cost_price AS unit_cost, sb.quantity * sb.cost_price AS value, sb.expiry_date`).
+		Joins("JOIN stocks st ON st.id = sb.stock_id").
+		Joins("JOIN shops sh ON sh.id = st.shop_id").
+		Joins("JOIN products p ON p.id = sb.product_id").
+		Where("sb.tenant_id = ? AND sb.quantity > 0 AND sb.deleted_at IS NULL", tenantID).
+		Where("sb.expiry_date IS NOT NULL AND sb.expiry_date >= ? AND sb.expiry_date <= ?", now, until)
+
+	if shopID != nil {
+		query = query.Where("st.shop_id = ?", *shopID)
+	}
+	query = scope.FromContext(ctx).Apply(query, "st.shop_id")
+
+	items := make([]*ExpiryRiskItem, 0)
+	if err := query.Order("sb.expiry_date ASC, value DESC").Scan(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to get expiry risk: %w", err)
+	}
+
+	report := &ExpiryRiskReport{
+		Days:         days,
+		Until:        until,
+		TotalBatches: len(items),
+		Items:        items,
+	}
+	for _, item := range items {
+		item.DaysToExpiry = int(item.ExpiryDate.Sub(now).Hours() / 24)
+		report.TotalQuantity += item.Quantity
+		report.TotalAtRiskValue += item.Value
+	}
+
+	return report, nil
+}
+
+// Purchase planning outcomes
+const (
+	PlanningUnderOrdered = "under_ordered"
+	PlanningOverOrdered  = "over_ordered"
+	PlanningOnPlan       = "on_plan"
+)
+
+// PurchasePlanningVendor summarises what was ordered from and delivered by one vendor
+type PurchasePlanningVendor struct {
+	VendorID         uuid.UUID `json:"vendor_id"`
+	VendorName       string    `json:"vendor_name"`
+	PurchaseCount    int64     `json:"purchase_count"`
+	OrderedQuantity  int64     `json:"ordered_quantity"`
+	ReceivedQuantity int64     `json:"received_quantity"`
+	OrderedValue     float64   `json:"ordered_value"`
+	FillRate         float64   `json:"fill_rate"` // percent of ordered quantity received
+}
+
+// PurchasePlanningProduct compares one product's replacement demand with what was
+// ordered and received
+type PurchasePlanningProduct struct {
+	ProductID        uuid.UUID                 `json:"product_id"`
+	ProductName      string                    `json:"product_name"`
+	SKU              string                    `json:"sku"`
+	PlannedQuantity  int64                     `json:"planned_quantity"`
+	OrderedQuantity  int64                     `json:"ordered_quantity"`
+	ReceivedQuantity int64                     `json:"received_quantity"`
+	Variance         int64                     `json:"variance"` // ordered minus planned
+	VariancePercent  *float64                  `json:"variance_percent"`
+	FillRate         float64                   `json:"fill_rate"`
+	Outcome          string                    `json:"outcome"`
+	Vendors          []*PurchasePlanningVendor `json:"vendors"`
+}
+
+// PurchasePlanningReport compares planned against actual purchasing over a period
+type PurchasePlanningReport struct {
+	StartDate        time.Time                  `json:"start_date"`
+	EndDate          time.Time                  `json:"end_date"`
+	TolerancePercent float64                    `json:"tolerance_percent"`
+	PlannedQuantity  int64                      `json:"planned_quantity"`
+	OrderedQuantity  int64                      `json:"ordered_quantity"`
+	ReceivedQuantity int64                      `json:"received_quantity"`
+	FillRate         float64                    `json:"fill_rate"`
+	UnderOrdered     int                        `json:"under_ordered"`
+	OverOrdered      int                        `json:"over_ordered"`
+	OnPlan           int                        `json:"on_plan"`
+	Products         []*PurchasePlanningProduct `json:"products"`
+	Vendors          []*PurchasePlanningVendor  `json:"vendors"`
+}
+
+// GetPurchasePlanning compares planned purchasing with purchases created in [start, end)
+// and how much of them has been received. Reorder suggestions are previews and are not
+// stored, so the planned quantity is the replacement demand the reorder preview works
+// from: units sold over the same period. Ordered quantities outside the tolerance band
+// around plan are flagged as under- or over-ordered.
+func (s *ReportService) GetPurchasePlanning(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, start, end time.Time, tolerancePercent float64) (*PurchasePlanningReport, error) {
+	if !end.After(start) {
+		return nil, errors.New("end date must be after start date")
+	}
+
+	demandQuery := s.db.Table("stock_histories h").
+		Select("st.product_id, COALESCE(SUM(h.quantity), 0) AS quantity").
+		Joins("JOIN stocks st ON st.id = h.stock_id").
+		Where("h.tenant_id = ? AND h.movement_type = ? AND h.created_at >= ? AND h.created_at < ? AND h.deleted_at IS NULL",
+			tenantID, "sale", start, end)
+	if shopID != nil {
+		demandQuery = demandQuery.Where("st.shop_id = ?", *shopID)
+	}
+	demandQuery = scope.FromContext(ctx).Apply(demandQuery, "st.shop_id")
+	var demand []struct {
+		ProductID uuid.UUID
+		Quantity  int64
+	}
+	if err := demandQuery.Group("st.product_id").Scan(&demand).Error; err != nil {
+		return nil, fmt.Errorf("failed to get sales demand: %w", err)
+	}
+
+	purchaseQuery := s.db.Table("stock_purchase_items pi").
+		Select(`pi.product_id, sp.vendor_id, v.name AS vendor_name,
+			COUNT(DISTINCT sp.id) AS purchase_count,
+			COALESCE(SUM(pi.quantity), 0) AS ordered_quantity,
+			COALESCE(SUM(CASE WHEN sp.status = 'received' THEN pi.quantity ELSE 0 END), 0) AS received_quantity,
+			COALESCE(SUM(pi.total_cost), 0) AS ordered_value`).
+		Joins("JOIN stock_purchases sp ON sp.id = pi.stock_purchase_id").
+		Joins("LEFT JOIN vendors v ON v.id = sp.vendor_id").
+		Where("pi.tenant_id = ? AND sp.status <> ? AND sp.purchase_date >= ? AND sp.purchase_date < ?", tenantID, "cancelled", start, end).
+		Where("pi.deleted_at IS NULL AND sp.deleted_at IS NULL")
+	if shopID != nil {
+		purchaseQuery = purchaseQuery.Where("sp.shop_id = ?", *shopID)
+	}
+	purchaseQuery = scope.FromContext(ctx).Apply(purchaseQuery, "sp.shop_id")
+	var purchased []struct {
+		ProductID uuid.UUID
+		PurchasePlanningVendor
+	}
+	if err := purchaseQuery.Group("pi.product_id, sp.vendor_id, v.name").Scan(&purchased).Error; err != nil {
+		return nil, fmt.Errorf("failed to get purchases: %w", err)
+	}
+
+	products := make(map[uuid.UUID]*PurchasePlanningProduct)
+	productFor := func(id uuid.UUID) *PurchasePlanningProduct {
+		if p, ok := products[id]; ok {
+			return p
+		}
+		p := &PurchasePlanningProduct{ProductID: id, Vendors: make([]*PurchasePlanningVendor, 0)}
+		products[id] = p
+		return p
+	}
+	for _, d := range demand {
+		productFor(d.ProductID).PlannedQuantity = d.Quantity
+	}
+
+	vendors := make(map[uuid.UUID]*PurchasePlanningVendor)
+	// Purchase counts are per product and vendor; count each purchase once per vendor
+	vendorPurchases := s.db.Table("stock_purchases sp").
+		Select("sp.vendor_id, COUNT(*) AS purchase_count").
+		Where("sp.tenant_id = ? AND sp.status <> ? AND sp.purchase_date >= ? AND sp.purchase_date < ? AND sp.deleted_at IS NULL", tenantID, "cancelled", start, end)
+	if shopID != nil {
+		vendorPurchases = vendorPurchases.Where("sp.shop_id = ?", *shopID)
+	}
+	vendorPurchases = scope.FromContext(ctx).Apply(vendorPurchases, "sp.shop_id")
+	var vendorCounts []struct {
+		VendorID      uuid.UUID
+		PurchaseCount int64
+	}
+	if err := vendorPurchases.Group("sp.vendor_id").Scan(&vendorCounts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count purchases: %w", err)
+	}
+
+	for _, row := range purchased {
+		line := row.PurchasePlanningVendor
+		line.FillRate = fillRate(line.ReceivedQuantity, line.OrderedQuantity)
+		product := productFor(row.ProductID)
+		product.OrderedQuantity += line.OrderedQuantity
+		product.ReceivedQuantity += line.ReceivedQuantity
+		product.Vendors = append(product.Vendors, &line)
+
+		vendor, ok := vendors[line.VendorID]
+		if !ok {
+			vendor = &PurchasePlanningVendor{VendorID: line.VendorID, VendorName: line.VendorName}
+			vendors[line.VendorID] = vendor
+		}
+		vendor.OrderedQuantity += line.OrderedQuantity
+		vendor.ReceivedQuantity += line.ReceivedQuantity
+		vendor.OrderedValue += line.OrderedValue
+	}
+	for _, count := range vendorCounts {
+		if vendor, ok := vendors[count.VendorID]; ok {
+			vendor.PurchaseCount = count.PurchaseCount
+		}
+	}
+
+	ids := make([]uuid.UUID, 0, len(products))
+	for id := range products {
+		ids = append(ids, id)
+	}
+	var catalog []models.Product
+	if len(ids) > 0 {
+		if err := s.db.Unscoped().Where("id IN ? AND tenant_id = ?", ids, tenantID).Find(&catalog).Error; err != nil {
+			return nil, fmt.Errorf("failed to get products: %w", err)
+		}
+	}
+	for _, p := range catalog {
+		products[p.ID].ProductName = p.Name
+		products[p.ID].SKU = p.SKU
+	}
+
+	report := &PurchasePlanningReport{
+		StartDate:        start,
+		EndDate:          end,
+		TolerancePercent: tolerancePercent,
+		Products:         make([]*PurchasePlanningProduct, 0, len(products)),
+		Vendors:          make([]*PurchasePlanningVendor, 0, len(vendors)),
+	}
+	for _, product := range products {
+		product.Variance = product.OrderedQuantity - product.PlannedQuantity
+		product.FillRate = fillRate(product.ReceivedQuantity, product.OrderedQuantity)
+		if product.PlannedQuantity > 0 {
+			pct := utils.RoundToTwoDecimals(float64(product.Variance) / float64(product.PlannedQuantity) * 100)
+			product.VariancePercent = &pct
+		}
+
+		band := float64(product.PlannedQuantity) * tolerancePercent / 100
+		switch {
+		case float64(product.Variance) < -band:
+			product.Outcome = PlanningUnderOrdered
+			report.UnderOrdered++
+		case float64(product.Variance) > band:
+			product.Outcome = PlanningOverOrdered
+			report.OverOrdered++
+		default:
+			product.Outcome = PlanningOnPlan
+			report.OnPlan++
+		}
+
+		report.PlannedQuantity += product.PlannedQuantity
+		report.OrderedQuantity += product.OrderedQuantity
+		report.ReceivedQuantity += product.ReceivedQuantity
+		report.Products = append(report.Products, product)
+	}
+	for _, vendor := range vendors {
+		vendor.FillRate = fillRate(vendor.ReceivedQuantity, vendor.OrderedQuantity)
+		vendor.OrderedValue = utils.RoundToTwoDecimals(vendor.OrderedValue)
+		report.Vendors = append(report.Vendors, vendor)
+	}
+	report.FillRate = fillRate(report.ReceivedQuantity, report.OrderedQuantity)
+
+	// Largest planning misses first
+	sort.Slice(report.Products, func(i, j int) bool {
+		return absInt64(report.Products[i].Variance) > absInt64(report.Products[j].Variance)
+	})
+	sort.Slice(report.Vendors, func(i, j int) bool {
+		return report.Vendors[i].OrderedValue > report.Vendors[j].OrderedValue
+	})
+
+	return report, nil
+}
+
+func fillRate(received, ordered int64) float64 {
+	if ordered == 0 {
+		return 0
+	}
+	return utils.RoundToTwoDecimals(float64(received) / float64(ordered) * 100)
+}
+
+func absInt64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// displayUnit resolves the unit a report presents quantities in, preferring an
+// explicit request over the tenant's stock_display_unit setting
+func (s *ReportService) displayUnit(ctx context.Context, tenantID uuid.UUID, requested string) string {
+	if ValidDisplayUnit(requested) {
+		return requested
+	}
+	if s.settings != nil {
+		if unit := s.settings.GetString(ctx, tenantID, settings.KeyStockDisplayUnit); ValidDisplayUnit(unit) {
+			return unit
+		}
+	}
+	return settings.StockDisplayUnits
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/usage"
+	"gorm.io/gorm"
+)
+
+// DeletedDuplicateError is returned when a new or renamed record clashes with a
+// soft-deleted one. The deleted record can be restored instead.
+type DeletedDuplicateError struct {
+	Entity string
+	Field  string
+	ID     uuid.UUID
+}
+
+func (e *DeletedDuplicateError) Error() string {
+	return fmt.Sprintf("a deleted %s with this %s exists; restore it instead", e.Entity, e.Field)
+}
+
+// DeletedProductResponse is a soft-deleted product
+type DeletedProductResponse struct {
+	ProductResponse
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// DeletedCategoryResponse is a soft-deleted category
+type DeletedCategoryResponse struct {
+	CategoryResponse
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// DeletedBrandResponse is a soft-deleted brand
+type DeletedBrandResponse struct {
+	BrandResponse
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// checkSKUAvailable reports whether sku is free for a product. Deleted products keep
+// their SKU, and the SKU column is unique, so a deleted match has to be restored
+// rather than reused.
+func (s *ProductService) checkSKUAvailable(sku string, tenantID, excludeID uuid.UUID) error {
+	var existing models.Product
+	query := s.db.Unscoped().Where("sku = ? AND tenant_id = ?", sku, tenantID)
+	if excludeID != uuid.Nil {
+		query = query.Where("id != ?", excludeID)
+	}
+	err := query.First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check existing product: %w", err)
+	}
+	if existing.DeletedAt.Valid {
+		return &DeletedDuplicateError{Entity: "product", Field: "SKU", ID: existing.ID}
+	}
+	return errors.New("product with this SKU already exists")
+}
+
+// RestoreProduct brings back a soft-deleted product. Its category and brand must
+// not be deleted themselves.
+func (s *ProductService) RestoreProduct(ctx context.Context, productID, tenantID uuid.UUID) (*ProductResponse, error) {
+	var product models.Product
+	if err := s.db.Unscoped().Where("id = ? AND tenant_id = ?", productID, tenantID).First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("product not found")
+		}
+		return nil, fmt.Errorf("failed to find product: %w", err)
+	}
+	if !product.DeletedAt.Valid {
+		return nil, errors.New("product is not deleted")
+	}
+
+	var category models.Category
+	if err := s.db.Where("id = ? AND tenant_id = ?", product.CategoryID, tenantID).First(&category).Error; err != nil {
+		return nil, errors.New("product's category is deleted; restore the category first")
+	}
+	var brand models.Brand
+	if err := s.db.Where("id = ? AND tenant_id = ?", product.BrandID, tenantID).First(&brand).Error; err != nil {
+		return nil, errors.New("product's brand is deleted; restore the brand first")
+	}
+
+	if err := s.limits.Check(ctx, tenantID, usage.ResourceProducts); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Unscoped().Model(&product).Update("deleted_at", nil).Error; err != nil {
+		return nil, fmt.Errorf("failed to restore product: %w", err)
+	}
+
+	s.clearProductCache(ctx, tenantID)
+	s.clearBarcodeCache(ctx, tenantID, product.Barcode)
+
+	return s.GetProductByID(ctx, productID, tenantID)
+}
+
+// GetDeletedProducts lists soft-deleted products, most recently deleted first
+func (s *ProductService) GetDeletedProducts(ctx context.Context, tenantID uuid.UUID, search string) ([]DeletedProductResponse, error) {
+	unscoped := func(db *gorm.DB) *gorm.DB { return db.Unscoped() }
+	query := s.db.Unscoped().
+		Preload("Category", unscoped).
+		Preload("Brand", unscoped).
+		Where("tenant_id = ? AND deleted_at IS NOT NULL", tenantID)
+	if search != "" {
+		searchPattern := "%" + search + "%"
+		query = query.Where("name ILIKE ? OR sku ILIKE ? OR barcode ILIKE ?",
+			searchPattern, searchPattern, searchPattern)
+	}
+
+	var products []models.Product
+	if err := query.Order("deleted_at DESC").Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("failed to get deleted products: %w", err)
+	}
+
+	responses := make([]DeletedProductResponse, len(products))
+	for i := range products {
+		responses[i] = DeletedProductResponse{
+			ProductResponse: *s.mapProductToResponse(&products[i], 0),
+			DeletedAt:       products[i].DeletedAt.Time,
+		}
+	}
+	return responses, nil
+}
+
+// RestoreCategory brings back a soft-deleted category, unless a live category has
+// since taken its name
+func (s *CategoryService) RestoreCategory(ctx context.Context, id, tenantID uuid.UUID) (*CategoryResponse, error) {
+	var category models.Category
+	if err := s.db.Unscoped().Where("id = ? AND tenant_id = ?", id, tenantID).First(&category).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("category not found")
+		}
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+	if !category.DeletedAt.Valid {
+		return nil, fmt.Errorf("category is not deleted")
+	}
+
+	var clashes int64
+	if err := s.db.Model(&models.Category{}).
+		Where("name = ? AND tenant_id = ? AND id != ?", category.Name, tenantID, id).
+		Count(&clashes).Error; err != nil {
+		return nil, fmt.Errorf("failed to check existing category: %w", err)
+	}
+	if clashes > 0 {
+		return nil, fmt.Errorf("category with this name already exists at this level")
+	}
+
+	if err := s.db.Unscoped().Model(&category).Update("deleted_at", nil).Error; err != nil {
+		return nil, fmt.Errorf("failed to restore category: %w", err)
+	}
+
+	s.clearCategoryCache(ctx, tenantID)
+
+	return s.GetCategoryByID(ctx, id, tenantID)
+}
+
+// GetDeletedCategories lists soft-deleted categories, most recently deleted first
+func (s *CategoryService) GetDeletedCategories(ctx context.Context, tenantID uuid.UUID) ([]DeletedCategoryResponse, error) {
+	var categories []models.Category
+	if err := s.db.Unscoped().
+		Where("tenant_id = ? AND deleted_at IS NOT NULL", tenantID).
+		Order("deleted_at DESC").
+		Find(&categories).Error; err != nil {
+		return nil, fmt.Errorf("failed to get deleted categories: %w", err)
+	}
+
+	responses := make([]DeletedCategoryResponse, len(categories))
+	for i, category := range categories {
+		responses[i] = DeletedCategoryResponse{
+			CategoryResponse: *s.buildCategoryResponse(category, "", 0),
+			DeletedAt:        category.DeletedAt.Time,
+		}
+	}
+	return responses, nil
+}
+
+// RestoreBrand brings back a soft-deleted brand, unless a live brand has since
+// taken its name
+func (s *CategoryService) RestoreBrand(ctx context.Context, id, tenantID uuid.UUID) (*BrandResponse, error) {
+	var brand models.Brand
+	if err := s.db.Unscoped().Where("id = ? AND tenant_id = ?", id, tenantID).First(&brand).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("brand not found")
+		}
+		return nil, fmt.Errorf("failed to get brand: %w", err)
+	}
+	if !brand.DeletedAt.Valid {
+		return nil, fmt.Errorf("brand is not deleted")
+	}
+
+	var clashes int64
+	if err := s.db.Model(&models.Brand{}).
+		Where("name = ? AND tenant_id = ? AND id != ?", brand.Name, tenantID, id).
+		Count(&clashes).Error; err != nil {
+		return nil, fmt.Errorf("failed to check existing brand: %w", err)
+	}
+	if clashes > 0 {
+		return nil, fmt.Errorf("brand with this name already exists")
+	}
+
+	if err := s.db.Unscoped().Model(&brand).Update("deleted_at", nil).Error; err != nil {
+		return nil, fmt.Errorf("failed to restore brand: %w", err)
+	}
+
+	s.clearBrandCache(ctx, tenantID)
+
+	return s.GetBrandByID(ctx, id, tenantID)
+}
+
+// GetDeletedBrands lists soft-deleted brands, most recently deleted first
+func (s *CategoryService) GetDeletedBrands(ctx context.Context, tenantID uuid.UUID) ([]DeletedBrandResponse, error) {
+	var brands []models.Brand
+	if err := s.db.Unscoped().
+		Where("tenant_id = ? AND deleted_at IS NOT NULL", tenantID).
+		Order("deleted_at DESC").
+		Find(&brands).Error; err != nil {
+		return nil, fmt.Errorf("failed to get deleted brands: %w", err)
+	}
+
+	responses := make([]DeletedBrandResponse, len(brands))
+	for i, brand := range brands {
+		responses[i] = DeletedBrandResponse{
+			BrandResponse: *s.buildBrandResponse(brand, 0),
+			DeletedAt:     brand.DeletedAt.Time,
+		}
+	}
+	return responses, nil
+}
+
+// clearCategoryCache clears the cached category lists, with and without inactive
+// categories
+func (s *CategoryService) clearCategoryCache(ctx context.Context, tenantID uuid.UUID) {
+	s.cache.Delete(ctx,
+		fmt.Sprintf("categories:tenant:%s", tenantID.String()),
+		fmt.Sprintf("categories:tenant:%s:inactive:%t", tenantID.String(), true),
+		fmt.Sprintf("categories:tenant:%s:inactive:%t", tenantID.String(), false),
+	)
+}
+
+// clearBrandCache clears the cached brand lists, with and without inactive brands
+func (s *CategoryService) clearBrandCache(ctx context.Context, tenantID uuid.UUID) {
+	s.cache.Delete(ctx,
+		fmt.Sprintf("brands:tenant:%s", tenantID.String()),
+		fmt.Sprintf("brands:tenant:%s:inactive:%t", tenantID.String(), true),
+		fmt.Sprintf("brands:tenant:%s:inactive:%t", tenantID.String(), false),
+	)
+}
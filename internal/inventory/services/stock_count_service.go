@@ -0,0 +1,485 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/cache"
+	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// stockCountReference is the stock history reference for stocktake adjustments
+const stockCountReference = "Stock count"
+
+// StockCountService handles physical stocktakes of a shop
+type StockCountService struct {
+	db     *database.DB
+	cache  *cache.Cache
+	stocks *StockService
+}
+
+// NewStockCountService creates a new stock count service
+func NewStockCountService(db *database.DB, cache *cache.Cache, stockService *StockService) *StockCountService {
+	return &StockCountService{
+		db:     db,
+		cache:  cache,
+		stocks: stockService,
+	}
+}
+
+// StockCountRequest starts a stocktake of a shop
+type StockCountRequest struct {
+	ShopID uuid.UUID `json:"shop_id" binding:"required"`
+	Notes  string    `json:"notes"`
+}
+
+// StockCountItemsRequest records counted quantities. Counting a product again
+// replaces its earlier count.
+type StockCountItemsRequest struct {
+	Items []StockCountItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type StockCountItemRequest struct {
+	ProductID       uuid.UUID `json:"product_id" binding:"required"`
+	CountedQuantity int       `json:"counted_quantity" binding:"min=0"`
+	Notes           string    `json:"notes"`
+}
+
+// StockCountLine compares the counted and system quantity of one product
+type StockCountLine struct {
+	ProductID       uuid.UUID `json:"product_id"`
+	ProductName     string    `json:"product_name"`
+	SKU             string    `json:"sku"`
+	SystemQuantity  int       `json:"system_quantity"`
+	CountedQuantity int       `json:"counted_quantity"`
+	Difference      int       `json:"difference"` // counted minus system
+	UnitCost        float64   `json:"unit_cost"`
+	ValueDifference float64   `json:"value_difference"`
+	Notes           string    `json:"notes"`
+}
+
+// StockCountReport is a stocktake with its discrepancies. While the count is in
+// progress the system quantities are live; once finalized they are the quantities
+// the count was reconciled against.
+type StockCountReport struct {
+	ID                uuid.UUID        `json:"id"`
+	ShopID            uuid.UUID        `json:"shop_id"`
+	ShopName          string           `json:"shop_name"`
+	Status            string           `json:"status"`
+	Notes             string           `json:"notes"`
+	ItemCount         int              `json:"item_count"`
+	DiscrepancyCount  int              `json:"discrepancy_count"`
+	ShrinkageQuantity int              `json:"shrinkage_quantity"`
+	ShrinkageValue    float64          `json:"shrinkage_value"`
+	SurplusQuantity   int              `json:"surplus_quantity"`
+	SurplusValue      float64          `json:"surplus_value"`
+	NetValue          float64          `json:"net_value"` // surplus less shrinkage
+	Lines             []StockCountLine `json:"lines"`
+	FinalizedAt       *time.Time       `json:"finalized_at"`
+	FinalizedByID     *uuid.UUID       `json:"finalized_by_id"`
+	CreatedByID       uuid.UUID        `json:"created_by_id"`
+	CreatedAt         time.Time        `json:"created_at"`
+}
+
+// StockCountSummary is a stocktake in listings
+type StockCountSummary struct {
+	ID             uuid.UUID  `json:"id"`
+	ShopID         uuid.UUID  `json:"shop_id"`
+	ShopName       string     `json:"shop_name"`
+	Status         string     `json:"status"`
+	Notes          string     `json:"notes"`
+	ShrinkageValue float64    `json:"shrinkage_value"`
+	SurplusValue   float64    `json:"surplus_value"`
+	FinalizedAt    *time.Time `json:"finalized_at"`
+	CreatedByID    uuid.UUID  `json:"created_by_id"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// CreateStockCount starts a stocktake. A shop can have only one count in progress.
+func (s *StockCountService) CreateStockCount(ctx context.Context, req StockCountRequest, tenantID, userID uuid.UUID) (*StockCountReport, error) {
+	var shop models.Shop
+	if err := s.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", req.ShopID, tenantID).First(&shop).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("shop not found")
+		}
+		return nil, fmt.Errorf("failed to get shop: %w", err)
+	}
+
+	var open int64
+	if err := s.db.WithContext(ctx).Model(&models.StockCount{}).
+		Where("tenant_id = ? AND shop_id = ? AND status = ?", tenantID, req.ShopID, models.StockCountInProgress).
+		Count(&open).Error; err != nil {
+		return nil, fmt.Errorf("failed to check open stock counts: %w", err)
+	}
+	if open > 0 {
+		return nil, errors.New("a stock count is already in progress for this shop")
+	}
+
+	count := models.StockCount{
+		TenantModel: models.TenantModel{
+			BaseModel: models.BaseModel{ID: uuid.New()},
+			TenantID:  tenantID,
+		},
+		ShopID:      req.ShopID,
+		Status:      models.StockCountInProgress,
+		Notes:       req.Notes,
+		CreatedByID: userID,
+	}
+	if err := s.db.WithContext(ctx).Create(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to create stock count: %w", err)
+	}
+
+	return s.GetStockCount(ctx, count.ID, tenantID)
+}
+
+// SaveStockCountItems records counted quantities on a count in progress
+func (s *StockCountService) SaveStockCountItems(ctx context.Context, countID, tenantID uuid.UUID, req StockCountItemsRequest) (*StockCountReport, error) {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		count, err := lockStockCount(tx, countID, tenantID)
+		if err != nil {
+			return err
+		}
+		if count.Status != models.StockCountInProgress {
+			return errors.New("stock count is already finalized")
+		}
+
+		productIDs := make([]uuid.UUID, len(req.Items))
+		for i, item := range req.Items {
+			productIDs[i] = item.ProductID
+		}
+		var found int64
+		if err := tx.Model(&models.Product{}).
+			Where("id IN ? AND tenant_id = ?", productIDs, tenantID).
+			Distinct("id").
+			Count(&found).Error; err != nil {
+			return fmt.Errorf("failed to get products: %w", err)
+		}
+		unique := make(map[uuid.UUID]bool, len(productIDs))
+		for _, id := range productIDs {
+			unique[id] = true
+		}
+		if int(found) != len(unique) {
+			return errors.New("product not found")
+		}
+
+		for _, item := range req.Items {
+			var existing models.StockCountItem
+			err := tx.Where("stock_count_id = ? AND product_id = ? AND tenant_id = ?", countID, item.ProductID, tenantID).
+				First(&existing).Error
+			switch {
+			case err == nil:
+				if err := tx.Model(&existing).Updates(map[string]interface{}{
+					"counted_quantity": item.CountedQuantity,
+					"notes":            item.Notes,
+				}).Error; err != nil {
+					return fmt.Errorf("failed to update stock count item: %w", err)
+				}
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				line := models.StockCountItem{
+					TenantModel:     models.TenantModel{TenantID: tenantID},
+					StockCountID:    countID,
+					ProductID:       item.ProductID,
+					CountedQuantity: item.CountedQuantity,
+					Notes:           item.Notes,
+				}
+				if err := tx.Create(&line).Error; err != nil {
+					return fmt.Errorf("failed to add stock count item: %w", err)
+				}
+			default:
+				return fmt.Errorf("failed to get stock count item: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetStockCount(ctx, countID, tenantID)
+}
+
+// FinalizeStockCount reconciles stock with a count. Each counted product's stock is
+// adjusted to the counted quantity with an adjustment history entry; products that
+// were not counted are left as they are.
+func (s *StockCountService) FinalizeStockCount(ctx context.Context, countID, tenantID, userID uuid.UUID) (*StockCountReport, error) {
+	var shopID uuid.UUID
+	var productIDs []uuid.UUID
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		count, err := lockStockCount(tx, countID, tenantID)
+		if err != nil {
+			return err
+		}
+		if count.Status != models.StockCountInProgress {
+			return errors.New("stock count is already finalized")
+		}
+		shopID = count.ShopID
+
+		var items []models.StockCountItem
+		if err := tx.Where("stock_count_id = ? AND tenant_id = ?", countID, tenantID).
+			Order("product_id").
+			Find(&items).Error; err != nil {
+			return fmt.Errorf("failed to get stock count items: %w", err)
+		}
+		if len(items) == 0 {
+			return errors.New("stock count has no counted items")
+		}
+
+		var shrinkage, surplus float64
+		for i := range items {
+			item := &items[i]
+			productIDs = append(productIDs, item.ProductID)
+
+			var stock models.Stock
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("shop_id = ? AND product_id = ? AND tenant_id = ?", count.ShopID, item.ProductID, tenantID).
+				First(&stock).Error
+			if err != nil {
+				if !errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("failed to get stock: %w", err)
+				}
+				var product models.Product
+				if err := tx.Where("id = ? AND tenant_id = ?", item.ProductID, tenantID).First(&product).Error; err != nil {
+					return fmt.Errorf("failed to get product: %w", err)
+				}
+				stock = models.Stock{
+					TenantModel:   models.TenantModel{TenantID: tenantID},
+					ShopID:        count.ShopID,
+					ProductID:     item.ProductID,
+					CostingMethod: productCostingMethod(&product),
+				}
+				if err := tx.Create(&stock).Error; err != nil {
+					return fmt.Errorf("failed to create stock record: %w", err)
+				}
+			}
+
+			// Discrepancies are valued at the average cost before the adjustment
+			valueCost := stockFallbackCost(tx, &stock)
+			previous := stock.Quantity
+			difference := item.CountedQuantity - previous
+
+			if err := tx.Model(item).Updates(map[string]interface{}{
+				"system_quantity": previous,
+				"unit_cost":       valueCost,
+			}).Error; err != nil {
+				return fmt.Errorf("failed to update stock count item: %w", err)
+			}
+			if difference == 0 {
+				continue
+			}
+
+			moved := difference
+			unitCost := valueCost
+			if difference > 0 {
+				if err := addCostLayer(tx, &stock, difference, valueCost, models.StockBatch{}); err != nil {
+					return err
+				}
+				surplus += float64(difference) * valueCost
+			} else {
+				moved = -difference
+				if unitCost, err = consumeCostLayers(tx, &stock, moved, valueCost); err != nil {
+					return err
+				}
+				shrinkage += float64(moved) * valueCost
+			}
+
+			if _, err := setStockQuantity(tx, &stock, item.CountedQuantity); err != nil {
+				return err
+			}
+
+			refID := countID
+			history := models.StockHistory{
+				TenantModel:      models.TenantModel{TenantID: tenantID},
+				StockID:          stock.ID,
+				MovementType:     "adjustment",
+				Quantity:         moved,
+				PreviousQuantity: previous,
+				NewQuantity:      item.CountedQuantity,
+				UnitCost:         unitCost,
+				TotalCost:        unitCost * float64(moved),
+				Reference:        stockCountReference,
+				ReferenceID:      &refID,
+				Notes:            item.Notes,
+				CreatedByID:      userID,
+			}
+			if err := tx.Create(&history).Error; err != nil {
+				return fmt.Errorf("failed to create stock history: %w", err)
+			}
+		}
+
+		now := time.Now()
+		if err := tx.Model(count).Updates(map[string]interface{}{
+			"status":          models.StockCountFinalized,
+			"finalized_at":    &now,
+			"finalized_by_id": &userID,
+			"shrinkage_value": utils.RoundToTwoDecimals(shrinkage),
+			"surplus_value":   utils.RoundToTwoDecimals(surplus),
+		}).Error; err != nil {
+			return fmt.Errorf("failed to finalize stock count: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, productID := range productIDs {
+		s.stocks.clearStockCache(ctx, tenantID, shopID, productID)
+	}
+
+	return s.GetStockCount(ctx, countID, tenantID)
+}
+
+// GetStockCount returns a stocktake with its discrepancy report
+func (s *StockCountService) GetStockCount(ctx context.Context, countID, tenantID uuid.UUID) (*StockCountReport, error) {
+	var count models.StockCount
+	if err := s.db.WithContext(ctx).
+		Preload("Shop").
+		Preload("Items.Product").
+		Where("id = ? AND tenant_id = ?", countID, tenantID).
+		First(&count).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("stock count not found")
+		}
+		return nil, fmt.Errorf("failed to get stock count: %w", err)
+	}
+
+	// While counting, compare with the live stock of the counted products
+	live := make(map[uuid.UUID]models.Stock)
+	if count.Status == models.StockCountInProgress && len(count.Items) > 0 {
+		productIDs := make([]uuid.UUID, len(count.Items))
+		for i, item := range count.Items {
+			productIDs[i] = item.ProductID
+		}
+		var stocks []models.Stock
+		if err := s.db.WithContext(ctx).
+			Where("shop_id = ? AND product_id IN ? AND tenant_id = ?", count.ShopID, productIDs, tenantID).
+			Find(&stocks).Error; err != nil {
+			return nil, fmt.Errorf("failed to get stock: %w", err)
+		}
+		for _, stock := range stocks {
+			live[stock.ProductID] = stock
+		}
+	}
+
+	report := &StockCountReport{
+		ID:            count.ID,
+		ShopID:        count.ShopID,
+		Status:        count.Status,
+		Notes:         count.Notes,
+		ItemCount:     len(count.Items),
+		Lines:         make([]StockCountLine, 0, len(count.Items)),
+		FinalizedAt:   count.FinalizedAt,
+		FinalizedByID: count.FinalizedByID,
+		CreatedByID:   count.CreatedByID,
+		CreatedAt:     count.CreatedAt,
+	}
+	if count.Shop != nil {
+		report.ShopName = count.Shop.Name
+	}
+
+	for _, item := range count.Items {
+		line := StockCountLine{
+			ProductID:       item.ProductID,
+			SystemQuantity:  item.SystemQuantity,
+			CountedQuantity: item.CountedQuantity,
+			UnitCost:        item.UnitCost,
+			Notes:           item.Notes,
+		}
+		if item.Product != nil {
+			line.ProductName = item.Product.Name
+			line.SKU = item.Product.SKU
+		}
+		if count.Status == models.StockCountInProgress {
+			stock := live[item.ProductID]
+			line.SystemQuantity = stock.Quantity
+			line.UnitCost = stock.AverageCost
+			if line.UnitCost <= 0 && item.Product != nil {
+				line.UnitCost = item.Product.CostPrice
+			}
+		}
+
+		line.Difference = line.CountedQuantity - line.SystemQuantity
+		line.ValueDifference = utils.RoundToTwoDecimals(float64(line.Difference) * line.UnitCost)
+		switch {
+		case line.Difference < 0:
+			report.DiscrepancyCount++
+			report.ShrinkageQuantity -= line.Difference
+			report.ShrinkageValue -= line.ValueDifference
+		case line.Difference > 0:
+			report.DiscrepancyCount++
+			report.SurplusQuantity += line.Difference
+			report.SurplusValue += line.ValueDifference
+		}
+		report.Lines = append(report.Lines, line)
+	}
+
+	report.ShrinkageValue = utils.RoundToTwoDecimals(report.ShrinkageValue)
+	report.SurplusValue = utils.RoundToTwoDecimals(report.SurplusValue)
+	report.NetValue = utils.RoundToTwoDecimals(report.SurplusValue - report.ShrinkageValue)
+
+	// Largest losses first
+	sort.SliceStable(report.Lines, func(i, j int) bool {
+		return report.Lines[i].ValueDifference < report.Lines[j].ValueDifference
+	})
+
+	return report, nil
+}
+
+// GetStockCounts lists stocktakes, newest first, limited to one shop when shopID is set
+func (s *StockCountService) GetStockCounts(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, status string) ([]StockCountSummary, error) {
+	query := s.db.WithContext(ctx).Preload("Shop").Where("tenant_id = ?", tenantID)
+	if shopID != nil {
+		query = query.Where("shop_id = ?", *shopID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query = scope.FromContext(ctx).Apply(query, "shop_id")
+
+	var counts []models.StockCount
+	if err := query.Order("created_at DESC").Find(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get stock counts: %w", err)
+	}
+
+	summaries := make([]StockCountSummary, len(counts))
+	for i, count := range counts {
+		summaries[i] = StockCountSummary{
+			ID:             count.ID,
+			ShopID:         count.ShopID,
+			Status:         count.Status,
+			Notes:          count.Notes,
+			ShrinkageValue: count.ShrinkageValue,
+			SurplusValue:   count.SurplusValue,
+			FinalizedAt:    count.FinalizedAt,
+			CreatedByID:    count.CreatedByID,
+			CreatedAt:      count.CreatedAt,
+		}
+		if count.Shop != nil {
+			summaries[i].ShopName = count.Shop.Name
+		}
+	}
+	return summaries, nil
+}
+
+// lockStockCount loads a stock count for update
+func lockStockCount(tx *gorm.DB, countID, tenantID uuid.UUID) (*models.StockCount, error) {
+	var count models.StockCount
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND tenant_id = ?", countID, tenantID).
+		First(&count).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("stock count not found")
+		}
+		return nil, fmt.Errorf("failed to get stock count: %w", err)
+	}
+	return &count, nil
+}
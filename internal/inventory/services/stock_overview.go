@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+	"gorm.io/gorm"
+)
+
+// ShopStockOverview is one shop's stock of a product
+type ShopStockOverview struct {
+	ShopID            uuid.UUID `json:"shop_id"`
+	ShopName          string    `json:"shop_name"`
+	Quantity          int       `json:"quantity"`
+	ReservedQuantity  int       `json:"reserved_quantity"`
+	AvailableQuantity int       `json:"available_quantity"`
+	MinimumLevel      int       `json:"minimum_level"`
+	MaximumLevel      int       `json:"maximum_level"`
+	BelowMinimum      bool      `json:"below_minimum"`
+	Shortfall         int       `json:"shortfall"` // units needed to reach the minimum level
+	Surplus           int       `json:"surplus"`   // units above the maximum level, when one is set
+	RetailValue       float64   `json:"retail_value"`
+}
+
+// ProductStockOverview consolidates a product's stock across every shop
+type ProductStockOverview struct {
+	ProductID              uuid.UUID           `json:"product_id"`
+	ProductName            string              `json:"product_name"`
+	SKU                    string              `json:"sku"`
+	SellingPrice           float64             `json:"selling_price"`
+	TotalQuantity          int                 `json:"total_quantity"`
+	TotalReservedQuantity  int                 `json:"total_reserved_quantity"`
+	TotalAvailableQuantity int                 `json:"total_available_quantity"`
+	TotalRetailValue       float64             `json:"total_retail_value"`
+	ShopCount              int                 `json:"shop_count"`
+	ShopsBelowMinimum      []uuid.UUID         `json:"shops_below_minimum"`
+	Shops                  []ShopStockOverview `json:"shops"`
+	GeneratedAt            time.Time           `json:"generated_at"`
+}
+
+// GetProductStockOverview totals a product's stock across the shops the caller can
+// see, flagging the shops at or below their minimum level (as the low stock report
+// does) and those holding more than their
+// maximum, to help decide inter-shop transfers
+func (s *StockService) GetProductStockOverview(ctx context.Context, productID, tenantID uuid.UUID) (*ProductStockOverview, error) {
+	var product models.Product
+	if err := s.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", productID, tenantID).First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("product not found")
+		}
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	var stocks []models.Stock
+	if err := s.db.WithContext(ctx).
+		Where("product_id = ? AND tenant_id = ?", productID, tenantID).
+		Scopes(scope.FromContext(ctx).Filter("shop_id")).
+		Preload("Shop").
+		Find(&stocks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get stock: %w", err)
+	}
+
+	overview := &ProductStockOverview{
+		ProductID:         product.ID,
+		ProductName:       product.Name,
+		SKU:               product.SKU,
+		SellingPrice:      product.SellingPrice,
+		ShopCount:         len(stocks),
+		ShopsBelowMinimum: []uuid.UUID{},
+		Shops:             make([]ShopStockOverview, 0, len(stocks)),
+		GeneratedAt:       time.Now(),
+	}
+
+	for _, stock := range stocks {
+		shop := ShopStockOverview{
+			ShopID:            stock.ShopID,
+			Quantity:          stock.Quantity,
+			ReservedQuantity:  stock.ReservedQuantity,
+			AvailableQuantity: stock.Quantity - stock.ReservedQuantity,
+			MinimumLevel:      stock.MinimumLevel,
+			MaximumLevel:      stock.MaximumLevel,
+			BelowMinimum:      stock.Quantity <= stock.MinimumLevel,
+			RetailValue:       utils.RoundToTwoDecimals(float64(stock.Quantity) * product.SellingPrice),
+		}
+		if stock.Shop != nil {
+			shop.ShopName = stock.Shop.Name
+		}
+		if shop.BelowMinimum {
+			shop.Shortfall = stock.MinimumLevel - stock.Quantity
+			overview.ShopsBelowMinimum = append(overview.ShopsBelowMinimum, stock.ShopID)
+		}
+		if stock.MaximumLevel > 0 && stock.Quantity > stock.MaximumLevel {
+			shop.Surplus = stock.Quantity - stock.MaximumLevel
+		}
+
+		overview.TotalQuantity += shop.Quantity
+		overview.TotalReservedQuantity += shop.ReservedQuantity
+		overview.TotalAvailableQuantity += shop.AvailableQuantity
+		overview.Shops = append(overview.Shops, shop)
+	}
+	overview.TotalRetailValue = utils.RoundToTwoDecimals(float64(overview.TotalQuantity) * product.SellingPrice)
+
+	// Shops most in need of stock first
+	sort.Slice(overview.Shops, func(i, j int) bool {
+		if overview.Shops[i].Shortfall != overview.Shops[j].Shortfall {
+			return overview.Shops[i].Shortfall > overview.Shops[j].Shortfall
+		}
+		return overview.Shops[i].ShopName < overview.Shops[j].ShopName
+	})
+
+	return overview, nil
+}
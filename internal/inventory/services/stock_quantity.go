@@ -0,0 +1,67 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Stock quantity updates. Callers lock the stock row before checking and
+// costing a movement, but the quantity itself is always changed relative to
+// the value in the database rather than written back from the loaded record,
+// and removals only apply while enough stock remains. A caller working from a
+// stale read fails instead of overwriting another transaction's movement or
+// taking stock below zero.
+
+// ErrInsufficientStock is returned when a removal would take stock below zero
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// addStockQuantity adds quantity to a stock record. stock.Quantity is refreshed
+// from the database and the quantity before the movement is returned.
+func addStockQuantity(tx *gorm.DB, stock *models.Stock, quantity int) (int, error) {
+	result := tx.Model(stock).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "quantity"}}}).
+		Update("quantity", gorm.Expr("quantity + ?", quantity))
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to update stock: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return 0, errors.New("stock not found")
+	}
+	return stock.Quantity - quantity, nil
+}
+
+// removeStockQuantity takes quantity out of a stock record, failing with
+// ErrInsufficientStock when less than quantity remains. stock.Quantity is
+// refreshed from the database and the quantity before the movement is returned.
+func removeStockQuantity(tx *gorm.DB, stock *models.Stock, quantity int) (int, error) {
+	result := tx.Model(stock).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "quantity"}}}).
+		Where("quantity >= ?", quantity).
+		Update("quantity", gorm.Expr("quantity - ?", quantity))
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to update stock: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return 0, ErrInsufficientStock
+	}
+	return stock.Quantity + quantity, nil
+}
+
+// setStockQuantity moves a stock record to quantity, as a relative change from
+// the locked quantity the caller read
+func setStockQuantity(tx *gorm.DB, stock *models.Stock, quantity int) (int, error) {
+	if quantity < 0 {
+		return 0, errors.New("quantity cannot be negative")
+	}
+	switch delta := quantity - stock.Quantity; {
+	case delta > 0:
+		return addStockQuantity(tx, stock, delta)
+	case delta < 0:
+		return removeStockQuantity(tx, stock, -delta)
+	}
+	return stock.Quantity, nil
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+)
+
+// ReorderSuggestionsResponse lists what to order for low stock, one group per shop
+// and vendor so each group can become a single purchase
+type ReorderSuggestionsResponse struct {
+	Groups        []*ReorderSuggestionGroup `json:"groups"`
+	TotalItems    int                       `json:"total_items"`
+	EstimatedCost float64                   `json:"estimated_cost"`
+	GeneratedAt   time.Time                 `json:"generated_at"`
+}
+
+// ReorderSuggestionGroup holds the suggestions for one shop from one vendor.
+// Purchase is ready to pass to CreatePurchase; it is nil when no vendor has supplied
+// the products yet, as a purchase needs one.
+type ReorderSuggestionGroup struct {
+	ShopID        uuid.UUID            `json:"shop_id"`
+	ShopName      string               `json:"shop_name"`
+	VendorID      *uuid.UUID           `json:"vendor_id"`
+	VendorName    string               `json:"vendor_name"`
+	TotalItems    int                  `json:"total_items"`
+	EstimatedCost float64              `json:"estimated_cost"`
+	Items         []*ReorderSuggestion `json:"items"`
+	Purchase      *PurchaseRequest     `json:"purchase,omitempty"`
+}
+
+// ReorderSuggestion is the quantity needed to bring one stock record back to its target level
+type ReorderSuggestion struct {
+	StockID           uuid.UUID `json:"stock_id"`
+	ProductID         uuid.UUID `json:"product_id"`
+	ProductName       string    `json:"product_name"`
+	SKU               string    `json:"sku"`
+	CurrentStock      int       `json:"current_stock"`
+	MinimumLevel      int       `json:"minimum_level"`
+	TargetLevel       int       `json:"target_level"`
+	OnOrder           int       `json:"on_order"` // already on pending purchases for the shop
+	SuggestedQuantity int       `json:"suggested_quantity"`
+	UnitCost          float64   `json:"unit_cost"`
+	CostSource        string    `json:"cost_source"` // last_purchase or product_cost
+	EstimatedCost     float64   `json:"estimated_cost"`
+}
+
+// GenerateReorderSuggestions suggests order quantities for stock at or below its
+// minimum level. Stock is filled up to its maximum level, or to the tenant's
+// reorder_target_percent of the minimum level when no maximum is set, less anything
+// already on pending purchases. Suggestions are grouped by the vendor that last
+// supplied each product and priced at the stock's last purchase price.
+func (s *StockService) GenerateReorderSuggestions(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID) (*ReorderSuggestionsResponse, error) {
+	query := s.db.Model(&models.Stock{}).
+		Where("tenant_id = ? AND minimum_level > 0 AND quantity <= minimum_level", tenantID).
+		Preload("Shop").
+		Preload("Product")
+
+	if shopID != nil {
+		query = query.Where("shop_id = ?", *shopID)
+	}
+	query = scope.FromContext(ctx).Apply(query, "shop_id")
+
+	var stocks []models.Stock
+	if err := query.Order("shop_id, product_id").Find(&stocks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get low stock items: %w", err)
+	}
+
+	targetPercent := 200
+	if s.settings != nil {
+		targetPercent = s.settings.GetInt(ctx, tenantID, settings.KeyReorderTargetPercent)
+	}
+
+	response := &ReorderSuggestionsResponse{
+		Groups:      make([]*ReorderSuggestionGroup, 0),
+		GeneratedAt: time.Now(),
+	}
+	type groupKey struct {
+		shopID   uuid.UUID
+		vendorID uuid.UUID
+	}
+	groups := make(map[groupKey]*ReorderSuggestionGroup)
+	vendorNames := make(map[uuid.UUID]string)
+
+	for i := range stocks {
+		stock := &stocks[i]
+		if stock.Product == nil {
+			continue
+		}
+
+		target := stock.MaximumLevel
+		if target <= stock.MinimumLevel {
+			target = stock.MinimumLevel * targetPercent / 100
+		}
+
+		var onOrder int64
+		if err := s.db.Model(&models.StockPurchaseItem{}).
+			Joins("JOIN stock_purchases ON stock_purchases.id = stock_purchase_items.stock_purchase_id").
+			Where("stock_purchase_items.product_id = ? AND stock_purchases.shop_id = ? AND stock_purchases.tenant_id = ? AND stock_purchases.status = ? AND stock_purchases.deleted_at IS NULL",
+				stock.ProductID, stock.ShopID, tenantID, "pending").
+			Select("COALESCE(SUM(stock_purchase_items.quantity), 0)").
+			Scan(&onOrder).Error; err != nil {
+			return nil, fmt.Errorf("failed to get quantity on order: %w", err)
+		}
+
+		quantity := target - stock.Quantity - int(onOrder)
+		if quantity <= 0 {
+			continue
+		}
+
+		// Preferred vendor is whoever last supplied the product to any shop
+		var last struct {
+			VendorID uuid.UUID
+		}
+		if err := s.db.Model(&models.StockPurchase{}).
+			Select("stock_purchases.vendor_id").
+			Joins("JOIN stock_purchase_items ON stock_purchase_items.stock_purchase_id = stock_purchases.id").
+			Where("stock_purchase_items.product_id = ? AND stock_purchases.tenant_id = ? AND stock_purchases.status = ?", stock.ProductID, tenantID, "received").
+			Order("stock_purchases.purchase_date DESC").
+			Limit(1).
+			Scan(&last).Error; err != nil {
+			return nil, fmt.Errorf("failed to get last purchase vendor: %w", err)
+		}
+
+		unitCost, costSource := stock.Product.CostPrice, "product_cost"
+		if stock.LastPurchasePrice > 0 {
+			unitCost, costSource = stock.LastPurchasePrice, "last_purchase"
+		}
+
+		suggestion := &ReorderSuggestion{
+			StockID:           stock.ID,
+			ProductID:         stock.ProductID,
+			ProductName:       stock.Product.Name,
+			SKU:               stock.Product.SKU,
+			CurrentStock:      stock.Quantity,
+			MinimumLevel:      stock.MinimumLevel,
+			TargetLevel:       target,
+			OnOrder:           int(onOrder),
+			SuggestedQuantity: quantity,
+			UnitCost:          unitCost,
+			CostSource:        costSource,
+			EstimatedCost:     utils.RoundToTwoDecimals(unitCost * float64(quantity)),
+		}
+
+		key := groupKey{shopID: stock.ShopID, vendorID: last.VendorID}
+		group := groups[key]
+		if group == nil {
+			group = &ReorderSuggestionGroup{ShopID: stock.ShopID, VendorName: "Unassigned"}
+			if stock.Shop != nil {
+				group.ShopName = stock.Shop.Name
+			}
+			if last.VendorID != uuid.Nil {
+				name, ok := vendorNames[last.VendorID]
+				if !ok {
+					var vendor models.Vendor
+					if err := s.db.Select("id", "name").Where("id = ? AND tenant_id = ?", last.VendorID, tenantID).First(&vendor).Error; err == nil {
+						name = vendor.Name
+					}
+					vendorNames[last.VendorID] = name
+				}
+				vendorID := last.VendorID
+				group.VendorID = &vendorID
+				group.VendorName = name
+				group.Purchase = &PurchaseRequest{
+					VendorID: vendorID,
+					ShopID:   stock.ShopID,
+					Notes:    "Created from reorder suggestions",
+				}
+			}
+			groups[key] = group
+			response.Groups = append(response.Groups, group)
+		}
+
+		group.Items = append(group.Items, suggestion)
+		group.TotalItems += quantity
+		group.EstimatedCost = utils.RoundToTwoDecimals(group.EstimatedCost + suggestion.EstimatedCost)
+		if group.Purchase != nil {
+			group.Purchase.Items = append(group.Purchase.Items, PurchaseItemRequest{
+				ProductID:  stock.ProductID,
+				Quantity:   float64(quantity),
+				UnitPrice:  unitCost,
+				TotalPrice: suggestion.EstimatedCost,
+			})
+			group.Purchase.TotalAmount = group.EstimatedCost
+		}
+		response.TotalItems += quantity
+		response.EstimatedCost = utils.RoundToTwoDecimals(response.EstimatedCost + suggestion.EstimatedCost)
+	}
+
+	return response, nil
+}
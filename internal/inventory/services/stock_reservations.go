@@ -0,0 +1,268 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Stock movement types for reservations. They change the reserved quantity only, so
+// the history rows record the unchanged on-hand quantity.
+const (
+	MovementReserve = "reserve"
+	MovementRelease = "release"
+)
+
+// StockReservationRequest holds stock at a shop for a pending document
+type StockReservationRequest struct {
+	ShopID      uuid.UUID                     `json:"shop_id" binding:"required"`
+	Reference   string                        `json:"reference"`
+	ReferenceID uuid.UUID                     `json:"reference_id" binding:"required"`
+	Items       []StockReservationItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type StockReservationItemRequest struct {
+	ProductID uuid.UUID `json:"product_id" binding:"required"`
+	Quantity  int       `json:"quantity" binding:"required,gt=0"`
+}
+
+type StockReservationResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	StockID     uuid.UUID  `json:"stock_id"`
+	ShopID      uuid.UUID  `json:"shop_id"`
+	ProductID   uuid.UUID  `json:"product_id"`
+	Quantity    int        `json:"quantity"`
+	Reference   string     `json:"reference"`
+	ReferenceID uuid.UUID  `json:"reference_id"`
+	Status      string     `json:"status"`
+	ReleasedAt  *time.Time `json:"released_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// ReserveStock holds stock for a pending document. Either every item is reserved or
+// none is; an item is refused when it asks for more than the shop has available
+// (on hand less what is already reserved).
+func (s *StockService) ReserveStock(ctx context.Context, req StockReservationRequest, tenantID, userID uuid.UUID) ([]StockReservationResponse, error) {
+	var reservations []models.StockReservation
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		reservations, err = s.ReserveStockTx(tx, req, tenantID, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, reservation := range reservations {
+		s.clearStockCache(ctx, tenantID, reservation.ShopID, reservation.ProductID)
+	}
+	return buildStockReservationResponses(reservations), nil
+}
+
+// ReserveStockTx reserves stock inside the caller's transaction so the hold commits
+// or rolls back with the document it is for. Callers should clear the stock cache
+// once they have committed.
+func (s *StockService) ReserveStockTx(tx *gorm.DB, req StockReservationRequest, tenantID, userID uuid.UUID) ([]models.StockReservation, error) {
+	var active int64
+	if err := tx.Model(&models.StockReservation{}).
+		Where("tenant_id = ? AND reference_id = ? AND status = ?", tenantID, req.ReferenceID, models.ReservationActive).
+		Count(&active).Error; err != nil {
+		return nil, fmt.Errorf("failed to check reservations: %w", err)
+	}
+	if active > 0 {
+		return nil, errors.New("stock is already reserved for this reference")
+	}
+
+	// Merge repeated products and lock stock rows in a fixed order so two
+	// reservations over the same products cannot deadlock
+	quantities := make(map[uuid.UUID]int)
+	for _, item := range req.Items {
+		quantities[item.ProductID] += item.Quantity
+	}
+	productIDs := make([]uuid.UUID, 0, len(quantities))
+	for productID := range quantities {
+		productIDs = append(productIDs, productID)
+	}
+	sort.Slice(productIDs, func(i, j int) bool {
+		return bytes.Compare(productIDs[i][:], productIDs[j][:]) < 0
+	})
+
+	reservations := make([]models.StockReservation, 0, len(productIDs))
+	for _, productID := range productIDs {
+		quantity := quantities[productID]
+
+		var stock models.Stock
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("shop_id = ? AND product_id = ? AND tenant_id = ?", req.ShopID, productID, tenantID).
+			First(&stock).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("no stock of %s at this shop", s.productName(tx, productID, tenantID))
+			}
+			return nil, fmt.Errorf("failed to get stock: %w", err)
+		}
+
+		available := stock.Quantity - stock.ReservedQuantity
+		if quantity > available {
+			return nil, fmt.Errorf("insufficient stock to reserve %s: %d available, %d requested",
+				s.productName(tx, productID, tenantID), available, quantity)
+		}
+
+		if err := tx.Model(&stock).Update("reserved_quantity", stock.ReservedQuantity+quantity).Error; err != nil {
+			return nil, fmt.Errorf("failed to update stock: %w", err)
+		}
+
+		reservation := models.StockReservation{
+			TenantModel: models.TenantModel{
+				BaseModel: models.BaseModel{ID: uuid.New()},
+				TenantID:  tenantID,
+			},
+			StockID:     stock.ID,
+			ShopID:      req.ShopID,
+			ProductID:   productID,
+			Quantity:    quantity,
+			Reference:   req.Reference,
+			ReferenceID: req.ReferenceID,
+			Status:      models.ReservationActive,
+			CreatedByID: userID,
+		}
+		if err := tx.Create(&reservation).Error; err != nil {
+			return nil, fmt.Errorf("failed to create reservation: %w", err)
+		}
+
+		if err := recordReservationMovement(tx, &stock, MovementReserve, quantity, stock.ReservedQuantity+quantity, req.Reference, req.ReferenceID, userID); err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, reservation)
+	}
+
+	return reservations, nil
+}
+
+// ReleaseReservation releases every active reservation held for a document, for use
+// when the document is approved or cancelled
+func (s *StockService) ReleaseReservation(ctx context.Context, referenceID, tenantID, userID uuid.UUID) ([]StockReservationResponse, error) {
+	var reservations []models.StockReservation
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		reservations, err = s.ReleaseReservationTx(tx, referenceID, tenantID, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, reservation := range reservations {
+		s.clearStockCache(ctx, tenantID, reservation.ShopID, reservation.ProductID)
+	}
+	return buildStockReservationResponses(reservations), nil
+}
+
+// ReleaseReservationTx releases a document's reservations inside the caller's
+// transaction. It returns an error when the document holds no active reservation.
+func (s *StockService) ReleaseReservationTx(tx *gorm.DB, referenceID, tenantID, userID uuid.UUID) ([]models.StockReservation, error) {
+	var reservations []models.StockReservation
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("tenant_id = ? AND reference_id = ? AND status = ?", tenantID, referenceID, models.ReservationActive).
+		Order("product_id").
+		Find(&reservations).Error; err != nil {
+		return nil, fmt.Errorf("failed to get reservations: %w", err)
+	}
+	if len(reservations) == 0 {
+		return nil, errors.New("reservation not found")
+	}
+
+	now := time.Now()
+	for i := range reservations {
+		reservation := &reservations[i]
+
+		var stock models.Stock
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND tenant_id = ?", reservation.StockID, tenantID).
+			First(&stock).Error; err != nil {
+			return nil, fmt.Errorf("failed to get stock: %w", err)
+		}
+
+		// Never let the reserved quantity go negative, even if it was changed by hand
+		reserved := stock.ReservedQuantity - reservation.Quantity
+		if reserved < 0 {
+			reserved = 0
+		}
+		if err := tx.Model(&stock).Update("reserved_quantity", reserved).Error; err != nil {
+			return nil, fmt.Errorf("failed to update stock: %w", err)
+		}
+
+		if err := tx.Model(reservation).Updates(map[string]interface{}{
+			"status":      models.ReservationReleased,
+			"released_at": &now,
+		}).Error; err != nil {
+			return nil, fmt.Errorf("failed to release reservation: %w", err)
+		}
+
+		if err := recordReservationMovement(tx, &stock, MovementRelease, reservation.Quantity, reserved, reservation.Reference, referenceID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return reservations, nil
+}
+
+// GetReservations lists the reservations held for a document
+func (s *StockService) GetReservations(ctx context.Context, referenceID, tenantID uuid.UUID) ([]StockReservationResponse, error) {
+	var reservations []models.StockReservation
+	if err := s.db.WithContext(ctx).
+		Where("tenant_id = ? AND reference_id = ?", tenantID, referenceID).
+		Order("created_at ASC").
+		Find(&reservations).Error; err != nil {
+		return nil, fmt.Errorf("failed to get reservations: %w", err)
+	}
+	return buildStockReservationResponses(reservations), nil
+}
+
+// recordReservationMovement writes the stock history row for a reserve or release
+func recordReservationMovement(tx *gorm.DB, stock *models.Stock, movementType string, quantity, reservedAfter int, reference string, referenceID, userID uuid.UUID) error {
+	refID := referenceID
+	history := models.StockHistory{
+		TenantModel:      models.TenantModel{TenantID: stock.TenantID},
+		StockID:          stock.ID,
+		MovementType:     movementType,
+		Quantity:         quantity,
+		PreviousQuantity: stock.Quantity,
+		NewQuantity:      stock.Quantity,
+		Reference:        reference,
+		ReferenceID:      &refID,
+		Notes:            fmt.Sprintf("Reserved quantity now %d", reservedAfter),
+		CreatedByID:      userID,
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return fmt.Errorf("failed to create stock history: %w", err)
+	}
+	return nil
+}
+
+func buildStockReservationResponses(reservations []models.StockReservation) []StockReservationResponse {
+	responses := make([]StockReservationResponse, len(reservations))
+	for i, reservation := range reservations {
+		responses[i] = StockReservationResponse{
+			ID:          reservation.ID,
+			StockID:     reservation.StockID,
+			ShopID:      reservation.ShopID,
+			ProductID:   reservation.ProductID,
+			Quantity:    reservation.Quantity,
+			Reference:   reservation.Reference,
+			ReferenceID: reservation.ReferenceID,
+			Status:      reservation.Status,
+			ReleasedAt:  reservation.ReleasedAt,
+			CreatedAt:   reservation.CreatedAt,
+		}
+	}
+	return responses
+}
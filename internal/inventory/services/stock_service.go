@@ -4,26 +4,39 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/liquorpro/go-backend/pkg/shared/cache"
+	"github.com/liquorpro/go-backend/pkg/shared/config"
 	"github.com/liquorpro/go-backend/pkg/shared/database"
 	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/notification"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
+	"github.com/liquorpro/go-backend/pkg/shared/webhook"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // StockService handles stock management operations
 type StockService struct {
-	db    *database.DB
-	cache *cache.Cache
+	db       *database.DB
+	cache    *cache.Cache
+	settings *settings.Service
+	notifier *notification.Service
+	config   config.InventoryConfig
 }
 
 // NewStockService creates a new stock service
-func NewStockService(db *database.DB, cache *cache.Cache) *StockService {
+func NewStockService(db *database.DB, cache *cache.Cache, settingsService *settings.Service, notifier *notification.Service, cfg config.InventoryConfig) *StockService {
 	return &StockService{
-		db:    db,
-		cache: cache,
+		db:       db,
+		cache:    cache,
+		settings: settingsService,
+		notifier: notifier,
+		config:   cfg,
 	}
 }
 
@@ -79,7 +92,9 @@ type StockResponse struct {
 type StockHistoryResponse struct {
 	ID               uuid.UUID `json:"id"`
 	StockID          uuid.UUID `json:"stock_id"`
+	ProductID        uuid.UUID `json:"product_id"`
 	ProductName      string    `json:"product_name"`
+	ShopID           uuid.UUID `json:"shop_id"`
 	ShopName         string    `json:"shop_name"`
 	MovementType     string    `json:"movement_type"`
 	Quantity         int       `json:"quantity"`
@@ -147,6 +162,7 @@ func (s *StockService) GetStockByProduct(ctx context.Context, productID, tenantI
 
 	var stocks []models.Stock
 	err := s.db.Where("product_id = ? AND tenant_id = ?", productID, tenantID).
+		Scopes(scope.FromContext(ctx).Filter("shop_id")).
 		Preload("Shop").
 		Preload("Product.Brand").
 		Preload("Product.Category").
@@ -197,7 +213,7 @@ func (s *StockService) AdjustStock(ctx context.Context, req StockAdjustmentReque
 	// Start transaction
 	err := s.db.Transaction(func(tx *gorm.DB) error {
 		// Get or create stock record
-		err := tx.Where("shop_id = ? AND product_id = ? AND tenant_id = ?", 
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("shop_id = ? AND product_id = ? AND tenant_id = ?", 
 			req.ShopID, req.ProductID, tenantID).First(&stock).Error
 		
 		if err != nil {
@@ -208,7 +224,7 @@ func (s *StockService) AdjustStock(ctx context.Context, req StockAdjustmentReque
 					ShopID:        req.ShopID,
 					ProductID:     req.ProductID,
 					Quantity:      0,
-					CostingMethod: models.CostingFIFO,
+					CostingMethod: productCostingMethod(&product),
 				}
 				if err := tx.Create(&stock).Error; err != nil {
 					return fmt.Errorf("failed to create stock record: %w", err)
@@ -236,10 +252,29 @@ func (s *StockService) AdjustStock(ctx context.Context, req StockAdjustmentReque
 			}
 		}
 
+		// Stock counted in joins at the current cost; stock counted out draws down the layers
+		unitCost := stock.AverageCost
+		if unitCost == 0 {
+			unitCost = product.CostPrice
+		}
+		moved := newQuantity - previousQuantity
+		if moved > 0 {
+			if err := addCostLayer(tx, &stock, moved, unitCost, models.StockBatch{}); err != nil {
+				return err
+			}
+		} else if moved < 0 {
+			moved = -moved
+			if unitCost, err = consumeCostLayers(tx, &stock, moved, unitCost); err != nil {
+				return err
+			}
+		}
+
 		// Update stock
-		stock.Quantity = newQuantity
-		if err := tx.Save(&stock).Error; err != nil {
-			return fmt.Errorf("failed to update stock: %w", err)
+		if _, err := setStockQuantity(tx, &stock, newQuantity); err != nil {
+			return err
+		}
+		if err := enqueueLowStock(tx, &stock, previousQuantity, newQuantity); err != nil {
+			return err
 		}
 
 		// Create stock history
@@ -250,6 +285,8 @@ func (s *StockService) AdjustStock(ctx context.Context, req StockAdjustmentReque
 			Quantity:         req.Quantity,
 			PreviousQuantity: previousQuantity,
 			NewQuantity:      newQuantity,
+			UnitCost:         unitCost,
+			TotalCost:        unitCost * float64(moved),
 			Reference:        req.Reason,
 			Notes:            req.Notes,
 			CreatedByID:      userID,
@@ -274,24 +311,52 @@ func (s *StockService) AdjustStock(ctx context.Context, req StockAdjustmentReque
 	return s.mapStockToResponse(&stock), nil
 }
 
-// CreateStockTransfer creates a transfer between shops
-func (s *StockService) CreateStockTransfer(ctx context.Context, req StockTransferRequest, tenantID, userID uuid.UUID) error {
+// CreateStockTransfer records a transfer between shops. Tenants that hold transfers
+// for approval get a pending transfer and no stock moves until ApproveStockTransfer;
+// otherwise stock moves immediately and the transfer is recorded as approved.
+func (s *StockService) CreateStockTransfer(ctx context.Context, req StockTransferRequest, tenantID, userID uuid.UUID) (*StockTransferResponse, error) {
 	// Verify shops exist and belong to tenant
 	var fromShop, toShop models.Shop
 	if err := s.db.Where("id = ? AND tenant_id = ?", req.FromShopID, tenantID).First(&fromShop).Error; err != nil {
-		return errors.New("source shop not found")
+		return nil, errors.New("source shop not found")
 	}
 	if err := s.db.Where("id = ? AND tenant_id = ?", req.ToShopID, tenantID).First(&toShop).Error; err != nil {
-		return errors.New("destination shop not found")
+		return nil, errors.New("destination shop not found")
 	}
 
 	if req.FromShopID == req.ToShopID {
-		return errors.New("cannot transfer to the same shop")
+		return nil, errors.New("cannot transfer to the same shop")
+	}
+
+	requireApproval := s.settings.GetBool(ctx, tenantID, settings.KeyTransferApprovalRequired)
+
+	transfer := models.StockTransfer{
+		TenantModel:  models.TenantModel{TenantID: tenantID},
+		FromShopID:   req.FromShopID,
+		FromShop:     &fromShop,
+		ToShopID:     req.ToShopID,
+		ToShop:       &toShop,
+		TransferDate: req.TransferDate,
+		Notes:        req.Notes,
+		Status:       models.StatusPending,
+		CreatedByID:  userID,
+	}
+	if !requireApproval {
+		now := time.Now()
+		transfer.Status = models.StatusApproved
+		transfer.ApprovedAt = &now
+		transfer.ApprovedByID = &userID
 	}
 
 	// Start transaction
-	return s.db.Transaction(func(tx *gorm.DB) error {
-		transferRef := fmt.Sprintf("TRANSFER-%s-%d", time.Now().Format("20060102"), time.Now().Unix()%10000)
+	summary := transferSummary{FromShop: fromShop, ToShop: toShop, ItemCount: len(req.Items)}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Number the transfer from the tenant's locked sequence so references never collide
+		seq, err := models.NextSequenceValue(tx, tenantID, models.SequenceStockTransfer)
+		if err != nil {
+			return err
+		}
+		transfer.Reference = s.formatTransferRef(tenantID, seq)
 
 		for _, item := range req.Items {
 			// Verify product exists
@@ -300,96 +365,117 @@ func (s *StockService) CreateStockTransfer(ctx context.Context, req StockTransfe
 				return fmt.Errorf("product %s not found", item.ProductID)
 			}
 
-			// Get source stock
-			var fromStock models.Stock
-			err := tx.Where("shop_id = ? AND product_id = ? AND tenant_id = ?", 
-				req.FromShopID, item.ProductID, tenantID).First(&fromStock).Error
-			
-			if err != nil {
-				return fmt.Errorf("stock not found for product %s in source shop", product.Name)
-			}
+			// Pending transfers are checked against current stock so that obviously short
+			// requests fail early; stock is checked again when the transfer is approved
+			if requireApproval {
+				var fromStock models.Stock
+				if err := tx.Where("shop_id = ? AND product_id = ? AND tenant_id = ?",
+					req.FromShopID, item.ProductID, tenantID).First(&fromStock).Error; err != nil {
+					return fmt.Errorf("stock not found for product %s in source shop", product.Name)
+				}
+				if availableQty := fromStock.Quantity - fromStock.ReservedQuantity; availableQty < item.Quantity {
+					return fmt.Errorf("insufficient stock for product %s (available: %d, requested: %d)",
+						product.Name, availableQty, item.Quantity)
+				}
 
-			// Check available quantity
-			availableQty := fromStock.Quantity - fromStock.ReservedQuantity
-			if availableQty < item.Quantity {
-				return fmt.Errorf("insufficient stock for product %s (available: %d, requested: %d)", 
-					product.Name, availableQty, item.Quantity)
+				// The inbound notice values the transfer at today's cost; the cost
+				// layers are only consumed on approval
+				unitCost := fromStock.AverageCost
+				if unitCost == 0 {
+					unitCost = product.CostPrice
+				}
+				summary.TotalQuantity += item.Quantity
+				summary.TotalCost += unitCost * float64(item.Quantity)
 			}
 
-			// Update source stock
-			fromStock.Quantity -= item.Quantity
-			if err := tx.Save(&fromStock).Error; err != nil {
-				return fmt.Errorf("failed to update source stock: %w", err)
-			}
+			transfer.Items = append(transfer.Items, models.StockTransferItem{
+				TenantModel: models.TenantModel{TenantID: tenantID},
+				ProductID:   item.ProductID,
+				Quantity:    item.Quantity,
+			})
+		}
 
-			// Create source history
-			fromHistory := models.StockHistory{
-				TenantModel:      models.TenantModel{TenantID: tenantID},
-				StockID:          fromStock.ID,
-				MovementType:     "transfer_out",
-				Quantity:         -item.Quantity,
-				PreviousQuantity: fromStock.Quantity + item.Quantity,
-				NewQuantity:      fromStock.Quantity,
-				Reference:        transferRef,
-				Notes:            fmt.Sprintf("Transfer to %s", toShop.Name),
-				CreatedByID:      userID,
-			}
-			if err := tx.Create(&fromHistory).Error; err != nil {
-				return fmt.Errorf("failed to create source history: %w", err)
-			}
+		if err := tx.Omit("FromShop", "ToShop").Create(&transfer).Error; err != nil {
+			return fmt.Errorf("failed to create stock transfer: %w", err)
+		}
 
-			// Get or create destination stock
-			var toStock models.Stock
-			err = tx.Where("shop_id = ? AND product_id = ? AND tenant_id = ?", 
-				req.ToShopID, item.ProductID, tenantID).First(&toStock).Error
-			
-			if err != nil {
-				if errors.Is(err, gorm.ErrRecordNotFound) {
-					// Create new stock record
-					toStock = models.Stock{
-						TenantModel:   models.TenantModel{TenantID: tenantID},
-						ShopID:        req.ToShopID,
-						ProductID:     item.ProductID,
-						Quantity:      0,
-						CostingMethod: fromStock.CostingMethod,
-						AverageCost:   fromStock.AverageCost,
-					}
-					if err := tx.Create(&toStock).Error; err != nil {
-						return fmt.Errorf("failed to create destination stock: %w", err)
-					}
-				} else {
-					return fmt.Errorf("failed to get destination stock: %w", err)
-				}
-			}
+		if requireApproval {
+			summary.Reference = transfer.Reference
+			return nil
+		}
+		summary, err = s.moveTransferStock(tx, &transfer, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
 
-			// Update destination stock
-			previousToQty := toStock.Quantity
-			toStock.Quantity += item.Quantity
-			if err := tx.Save(&toStock).Error; err != nil {
-				return fmt.Errorf("failed to update destination stock: %w", err)
-			}
+	// The destination shop hears about the stock as soon as the transfer exists, so
+	// it does not order the same stock while the transfer waits for approval
+	go s.notifyTransfer(tenantID, models.EmailEventTransferInbound, summary)
+	if !requireApproval {
+		s.clearTransferStockCache(ctx, &transfer)
+		go s.notifyTransfer(tenantID, models.EmailEventTransferCompleted, summary)
+	}
 
-			// Create destination history
-			toHistory := models.StockHistory{
-				TenantModel:      models.TenantModel{TenantID: tenantID},
-				StockID:          toStock.ID,
-				MovementType:     "transfer_in",
-				Quantity:         item.Quantity,
-				PreviousQuantity: previousToQty,
-				NewQuantity:      toStock.Quantity,
-				Reference:        transferRef,
-				Notes:            fmt.Sprintf("Transfer from %s", fromShop.Name),
-				CreatedByID:      userID,
-			}
-			if err := tx.Create(&toHistory).Error; err != nil {
-				return fmt.Errorf("failed to create destination history: %w", err)
-			}
-		}
+	return s.GetStockTransferByID(ctx, transfer.ID, tenantID)
+}
 
-		// TODO: Create transfer document for approval workflow if needed
+// formatTransferRef builds a transfer reference such as TRF-1A2B3C4D-000042
+func (s *StockService) formatTransferRef(tenantID uuid.UUID, seq int64) string {
+	prefix := s.config.TransferRefPrefix
+	if prefix == "" {
+		prefix = "TRF"
+	}
+	tenantCode := strings.ToUpper(strings.ReplaceAll(tenantID.String(), "-", "")[:8])
+	return fmt.Sprintf("%s-%s-%0*d", prefix, tenantCode, s.config.TransferRefPadding, seq)
+}
 
-		return nil
-	})
+// transferSummary holds the totals reported in transfer notifications
+type transferSummary struct {
+	Reference     string
+	FromShop      models.Shop
+	ToShop        models.Shop
+	ItemCount     int
+	TotalQuantity int
+	TotalCost     float64
+}
+
+// notifyTransfer emails shop managers about a transfer lifecycle event: the
+// destination shop's managers when stock is inbound, and both shops' managers
+// when the transfer completes
+func (s *StockService) notifyTransfer(tenantID uuid.UUID, event string, summary transferSummary) {
+	if s.notifier == nil {
+		return
+	}
+	ctx := context.Background()
+
+	shopIDs := []uuid.UUID{summary.ToShop.ID}
+	if event == models.EmailEventTransferCompleted {
+		shopIDs = append(shopIDs, summary.FromShop.ID)
+	}
+	recipients, err := s.notifier.GetShopManagerRecipients(ctx, tenantID, shopIDs...)
+	if err != nil {
+		fmt.Printf("Warning: Failed to get transfer notification recipients: %v\n", err)
+		return
+	}
+
+	var tenant models.Tenant
+	s.db.Select("name").Where("id = ?", tenantID).First(&tenant)
+
+	vars := map[string]interface{}{
+		"reference":      summary.Reference,
+		"from_shop":      summary.FromShop.Name,
+		"to_shop":        summary.ToShop.Name,
+		"item_count":     summary.ItemCount,
+		"total_quantity": summary.TotalQuantity,
+		"total_cost":     fmt.Sprintf("%.2f", summary.TotalCost),
+		"company_name":   tenant.Name,
+	}
+
+	if err := s.notifier.Send(ctx, tenantID, event, recipients, vars); err != nil {
+		fmt.Printf("Warning: Failed to send %s notification: %v\n", event, err)
+	}
 }
 
 // GetStockHistory returns stock movement history
@@ -420,6 +506,247 @@ func (s *StockService) GetStockHistory(ctx context.Context, stockID, tenantID uu
 	return responses, nil
 }
 
+// DateRange bounds a report period; End is exclusive and either bound may be open
+type DateRange struct {
+	Start *time.Time
+	End   *time.Time
+}
+
+// StockMovementFilters narrows the tenant-wide stock movement history
+type StockMovementFilters struct {
+	ShopID       *uuid.UUID
+	ProductID    *uuid.UUID
+	MovementType string
+	DateRange    DateRange
+}
+
+// GetAllStockMovements returns a page of stock movements across the tenant, newest
+// first, with the total number of matching movements
+func (s *StockService) GetAllStockMovements(ctx context.Context, tenantID uuid.UUID, filters StockMovementFilters, limit, offset int) ([]*StockHistoryResponse, int64, error) {
+	query := s.db.Model(&models.StockHistory{}).
+		Joins("JOIN stocks ON stocks.id = stock_histories.stock_id AND stocks.tenant_id = stock_histories.tenant_id").
+		Where("stock_histories.tenant_id = ?", tenantID)
+
+	if filters.ShopID != nil {
+		query = query.Where("stocks.shop_id = ?", *filters.ShopID)
+	}
+	if filters.ProductID != nil {
+		query = query.Where("stocks.product_id = ?", *filters.ProductID)
+	}
+	if filters.MovementType != "" {
+		query = query.Where("stock_histories.movement_type = ?", filters.MovementType)
+	}
+	if filters.DateRange.Start != nil {
+		query = query.Where("stock_histories.created_at >= ?", *filters.DateRange.Start)
+	}
+	if filters.DateRange.End != nil {
+		query = query.Where("stock_histories.created_at < ?", *filters.DateRange.End)
+	}
+	query = scope.FromContext(ctx).Apply(query, "stocks.shop_id")
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count stock movements: %w", err)
+	}
+
+	var histories []models.StockHistory
+	err := query.
+		Preload("Stock.Product").
+		Preload("Stock.Shop").
+		Preload("CreatedBy").
+		Order("stock_histories.created_at DESC, stock_histories.id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&histories).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get stock movements: %w", err)
+	}
+
+	responses := make([]*StockHistoryResponse, len(histories))
+	for i := range histories {
+		responses[i] = s.mapStockHistoryToResponse(&histories[i])
+	}
+
+	return responses, total, nil
+}
+
+// ProductLedger represents every stock movement of a product across all shops
+type ProductLedger struct {
+	ProductID       uuid.UUID             `json:"product_id"`
+	ProductName     string                `json:"product_name"`
+	SKU             string                `json:"sku"`
+	Start           *time.Time            `json:"start,omitempty"`
+	End             *time.Time            `json:"end,omitempty"`
+	OpeningQuantity int                   `json:"opening_quantity"`
+	TotalIn         int                   `json:"total_in"`
+	TotalOut        int                   `json:"total_out"`
+	ClosingQuantity int                   `json:"closing_quantity"`
+	Entries         []*ProductLedgerEntry `json:"entries"`
+}
+
+// ProductLedgerEntry represents one movement in a product ledger. Change is signed;
+// ShopQuantity is the shop's balance and RunningQuantity the product's total across
+// all shops after the movement.
+type ProductLedgerEntry struct {
+	ID              uuid.UUID       `json:"id"`
+	StockID         uuid.UUID       `json:"stock_id"`
+	ShopID          uuid.UUID       `json:"shop_id"`
+	ShopName        string          `json:"shop_name"`
+	MovementType    string          `json:"movement_type"`
+	Change          int             `json:"change"`
+	ShopQuantity    int             `json:"shop_quantity"`
+	RunningQuantity int             `json:"running_quantity"`
+	UnitCost        float64         `json:"unit_cost"`
+	Reference       string          `json:"reference"`
+	Notes           string          `json:"notes"`
+	CreatedByName   string          `json:"created_by_name"`
+	CreatedAt       time.Time       `json:"created_at"`
+	Transfer        *LedgerTransfer `json:"transfer,omitempty"`
+}
+
+// LedgerTransfer links the two sides of a transfer in a product ledger
+type LedgerTransfer struct {
+	PairedEntryID *uuid.UUID `json:"paired_entry_id"`
+	FromShopID    *uuid.UUID `json:"from_shop_id"`
+	FromShopName  string     `json:"from_shop_name"`
+	ToShopID      *uuid.UUID `json:"to_shop_id"`
+	ToShopName    string     `json:"to_shop_name"`
+}
+
+// GetProductLedger returns a product's movements across every shop in chronological
+// order with a running total. Transfers appear as paired out/in entries that leave
+// the running total unchanged.
+func (s *StockService) GetProductLedger(ctx context.Context, productID, tenantID uuid.UUID, dateRange DateRange) (*ProductLedger, error) {
+	var product models.Product
+	if err := s.db.Where("id = ? AND tenant_id = ?", productID, tenantID).First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("product not found")
+		}
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	var currentQuantity int64
+	err := s.db.Model(&models.Stock{}).
+		Where("product_id = ? AND tenant_id = ?", productID, tenantID).
+		Select("COALESCE(SUM(quantity), 0)").
+		Scan(&currentQuantity).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current stock: %w", err)
+	}
+
+	movements := func() *gorm.DB {
+		return s.db.Model(&models.StockHistory{}).
+			Joins("JOIN stocks ON stocks.id = stock_histories.stock_id").
+			Where("stocks.product_id = ? AND stock_histories.tenant_id = ?", productID, tenantID)
+	}
+
+	// Work back from the current level so the opening balance holds even when the
+	// range is open or history predates it
+	var changedSinceStart int64
+	since := movements()
+	if dateRange.Start != nil {
+		since = since.Where("stock_histories.created_at >= ?", *dateRange.Start)
+	}
+	if err := since.Select("COALESCE(SUM(stock_histories.new_quantity - stock_histories.previous_quantity), 0)").Scan(&changedSinceStart).Error; err != nil {
+		return nil, fmt.Errorf("failed to get stock movements: %w", err)
+	}
+
+	query := movements().
+		Preload("Stock.Shop").
+		Preload("CreatedBy")
+	if dateRange.Start != nil {
+		query = query.Where("stock_histories.created_at >= ?", *dateRange.Start)
+	}
+	if dateRange.End != nil {
+		query = query.Where("stock_histories.created_at < ?", *dateRange.End)
+	}
+
+	var histories []models.StockHistory
+	if err := query.Order("stock_histories.created_at ASC, stock_histories.id ASC").Find(&histories).Error; err != nil {
+		return nil, fmt.Errorf("failed to get product ledger: %w", err)
+	}
+
+	ledger := &ProductLedger{
+		ProductID:       product.ID,
+		ProductName:     product.Name,
+		SKU:             product.SKU,
+		Start:           dateRange.Start,
+		End:             dateRange.End,
+		OpeningQuantity: int(currentQuantity - changedSinceStart),
+		Entries:         make([]*ProductLedgerEntry, 0, len(histories)),
+	}
+
+	running := ledger.OpeningQuantity
+	pendingOut := make(map[string][]*ProductLedgerEntry)
+	pendingIn := make(map[string][]*ProductLedgerEntry)
+	for _, history := range histories {
+		change := history.NewQuantity - history.PreviousQuantity
+		running += change
+		if change > 0 {
+			ledger.TotalIn += change
+		} else {
+			ledger.TotalOut -= change
+		}
+
+		entry := &ProductLedgerEntry{
+			ID:              history.ID,
+			StockID:         history.StockID,
+			MovementType:    history.MovementType,
+			Change:          change,
+			ShopQuantity:    history.NewQuantity,
+			RunningQuantity: running,
+			UnitCost:        history.UnitCost,
+			Reference:       history.Reference,
+			Notes:           history.Notes,
+			CreatedAt:       history.CreatedAt,
+		}
+		if history.Stock != nil {
+			entry.ShopID = history.Stock.ShopID
+			if history.Stock.Shop != nil {
+				entry.ShopName = history.Stock.Shop.Name
+			}
+		}
+		if history.CreatedBy != nil {
+			entry.CreatedByName = history.CreatedBy.FirstName + " " + history.CreatedBy.LastName
+		}
+
+		switch history.MovementType {
+		case "transfer_out":
+			entry.Transfer = &LedgerTransfer{FromShopID: &entry.ShopID, FromShopName: entry.ShopName}
+			if queue := pendingIn[entry.Reference]; len(queue) > 0 {
+				pairTransferEntries(entry, queue[0])
+				pendingIn[entry.Reference] = queue[1:]
+			} else {
+				pendingOut[entry.Reference] = append(pendingOut[entry.Reference], entry)
+			}
+		case "transfer_in":
+			entry.Transfer = &LedgerTransfer{ToShopID: &entry.ShopID, ToShopName: entry.ShopName}
+			if queue := pendingOut[entry.Reference]; len(queue) > 0 {
+				pairTransferEntries(queue[0], entry)
+				pendingOut[entry.Reference] = queue[1:]
+			} else {
+				pendingIn[entry.Reference] = append(pendingIn[entry.Reference], entry)
+			}
+		}
+
+		ledger.Entries = append(ledger.Entries, entry)
+	}
+	ledger.ClosingQuantity = running
+
+	return ledger, nil
+}
+
+// pairTransferEntries cross-links the outgoing and incoming sides of a transfer
+func pairTransferEntries(out, in *ProductLedgerEntry) {
+	out.Transfer.PairedEntryID = &in.ID
+	out.Transfer.ToShopID = &in.ShopID
+	out.Transfer.ToShopName = in.ShopName
+
+	in.Transfer.PairedEntryID = &out.ID
+	in.Transfer.FromShopID = &out.ShopID
+	in.Transfer.FromShopName = out.ShopName
+}
+
 // GetLowStockItems returns items below minimum level
 func (s *StockService) GetLowStockItems(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID) ([]*StockResponse, error) {
 	query := s.db.Model(&models.Stock{}).
@@ -431,6 +758,7 @@ func (s *StockService) GetLowStockItems(ctx context.Context, tenantID uuid.UUID,
 	if shopID != nil {
 		query = query.Where("shop_id = ?", *shopID)
 	}
+	query = scope.FromContext(ctx).Apply(query, "shop_id")
 
 	var stocks []models.Stock
 	if err := query.Find(&stocks).Error; err != nil {
@@ -481,61 +809,166 @@ func (s *StockService) UpdateStockMinMax(ctx context.Context, stockID, tenantID
 	return nil
 }
 
+// UpdateStockCostingMethod changes how sales of a stock record are costed. Existing
+// cost layers and the average cost are kept, so the change applies from the next issue.
+func (s *StockService) UpdateStockCostingMethod(ctx context.Context, stockID, tenantID uuid.UUID, method string) (*StockResponse, error) {
+	switch method {
+	case models.CostingFIFO, models.CostingLIFO, models.CostingAverage:
+	default:
+		return nil, errors.New("costing method must be fifo, lifo or average")
+	}
+
+	var stock models.Stock
+	if err := s.db.Where("id = ? AND tenant_id = ?", stockID, tenantID).First(&stock).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("stock not found")
+		}
+		return nil, fmt.Errorf("failed to find stock: %w", err)
+	}
+
+	if err := s.db.Model(&stock).Update("costing_method", method).Error; err != nil {
+		return nil, fmt.Errorf("failed to update costing method: %w", err)
+	}
+
+	// Clear cache
+	s.clearStockCache(ctx, tenantID, stock.ShopID, stock.ProductID)
+
+	s.db.Preload("Shop").Preload("Product.Brand").Preload("Product.Category").First(&stock, stock.ID)
+	return s.mapStockToResponse(&stock), nil
+}
+
 // ProcessSale updates stock for a sale
 func (s *StockService) ProcessSale(ctx context.Context, saleID uuid.UUID, items []models.SaleItem, shopID, tenantID, userID uuid.UUID, reverse bool) error {
-	return s.db.Transaction(func(tx *gorm.DB) error {
-		for _, item := range items {
-			var stock models.Stock
-			err := tx.Where("shop_id = ? AND product_id = ? AND tenant_id = ?", 
-				shopID, item.ProductID, tenantID).First(&stock).Error
-			
-			if err != nil {
-				return fmt.Errorf("stock not found for product %s", item.ProductID)
-			}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		return s.ProcessSaleTx(tx, fmt.Sprintf("SALE-%s", saleID), saleID, items, shopID, tenantID, userID, reverse)
+	})
+	if err != nil {
+		return err
+	}
 
-			previousQty := stock.Quantity
-			var newQty int
-			var movementType string
+	s.ClearSaleStockCache(ctx, tenantID, shopID, items)
+	return nil
+}
 
-			if reverse {
-				// Return - add back to stock
-				newQty = stock.Quantity + item.Quantity
-				movementType = "return"
-			} else {
-				// Sale - remove from stock
-				if stock.Quantity < item.Quantity {
-					return fmt.Errorf("insufficient stock for product %s", item.ProductID)
-				}
-				newQty = stock.Quantity - item.Quantity
-				movementType = "sale"
+// ProcessSaleTx moves stock for sold (or, with reverse, returned) items inside the
+// caller's transaction, so the stock change commits or rolls back with the caller's
+// own writes. Stock rows are locked while they are checked and updated, and history
+// rows carry the cost the stock went out (or came back) at. Callers
+// should clear the stock cache with ClearSaleStockCache once they have committed.
+func (s *StockService) ProcessSaleTx(tx *gorm.DB, reference string, referenceID uuid.UUID, items []models.SaleItem, shopID, tenantID, userID uuid.UUID, reverse bool) error {
+	for _, item := range items {
+		var stock models.Stock
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("shop_id = ? AND product_id = ? AND tenant_id = ?", shopID, item.ProductID, tenantID).
+			First(&stock).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("no stock of %s at this shop", s.productName(tx, item.ProductID, tenantID))
 			}
+			return fmt.Errorf("failed to get stock: %w", err)
+		}
 
-			// Update stock
-			stock.Quantity = newQty
-			if err := tx.Save(&stock).Error; err != nil {
-				return fmt.Errorf("failed to update stock: %w", err)
-			}
+		previousQty := stock.Quantity
+		var newQty int
+		var movementType string
+		unitCost := stockFallbackCost(tx, &stock)
 
-			// Create history
-			history := models.StockHistory{
-				TenantModel:      models.TenantModel{TenantID: tenantID},
-				StockID:          stock.ID,
-				MovementType:     movementType,
-				Quantity:         item.Quantity,
-				PreviousQuantity: previousQty,
-				NewQuantity:      newQty,
-				UnitCost:         item.UnitPrice,
-				TotalCost:        item.TotalPrice,
-				Reference:        fmt.Sprintf("SALE-%s", saleID),
-				CreatedByID:      userID,
+		if reverse {
+			// Return - add back to stock at the current cost
+			if err := addCostLayer(tx, &stock, item.Quantity, unitCost, models.StockBatch{BatchNumber: reference}); err != nil {
+				return err
 			}
-
-			if err := tx.Create(&history).Error; err != nil {
-				return fmt.Errorf("failed to create stock history: %w", err)
+			newQty = previousQty + item.Quantity
+			movementType = "return"
+		} else {
+			// Sale - remove from stock, costed from the layers it consumes
+			if stock.Quantity < item.Quantity {
+				return fmt.Errorf("insufficient stock for %s: %d available, %d required",
+					s.productName(tx, item.ProductID, tenantID), stock.Quantity, item.Quantity)
+			}
+			unitCost, err = consumeCostLayers(tx, &stock, item.Quantity, unitCost)
+			if err != nil {
+				return err
 			}
+			newQty = stock.Quantity - item.Quantity
+			movementType = "sale"
+		}
+
+		// Update stock
+		if reverse {
+			_, err = addStockQuantity(tx, &stock, item.Quantity)
+		} else {
+			_, err = removeStockQuantity(tx, &stock, item.Quantity)
+		}
+		if errors.Is(err, ErrInsufficientStock) {
+			return fmt.Errorf("insufficient stock for %s: %d required",
+				s.productName(tx, item.ProductID, tenantID), item.Quantity)
+		}
+		if err != nil {
+			return err
+		}
+		if err := enqueueLowStock(tx, &stock, previousQty, newQty); err != nil {
+			return err
 		}
 
+		// Create history
+		refID := referenceID
+		history := models.StockHistory{
+			TenantModel:      models.TenantModel{TenantID: tenantID},
+			StockID:          stock.ID,
+			MovementType:     movementType,
+			Quantity:         item.Quantity,
+			PreviousQuantity: previousQty,
+			NewQuantity:      newQty,
+			UnitCost:         unitCost,
+			TotalCost:        unitCost * float64(item.Quantity),
+			Reference:        reference,
+			ReferenceID:      &refID,
+			CreatedByID:      userID,
+		}
+
+		if err := tx.Create(&history).Error; err != nil {
+			return fmt.Errorf("failed to create stock history: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ClearSaleStockCache clears cached stock for the items of a processed sale
+func (s *StockService) ClearSaleStockCache(ctx context.Context, tenantID, shopID uuid.UUID, items []models.SaleItem) {
+	for _, item := range items {
+		s.clearStockCache(ctx, tenantID, shopID, item.ProductID)
+	}
+}
+
+// productName names a product in error messages, falling back to its ID
+func (s *StockService) productName(tx *gorm.DB, productID, tenantID uuid.UUID) string {
+	var product models.Product
+	if err := tx.Select("id", "name").Where("id = ? AND tenant_id = ?", productID, tenantID).First(&product).Error; err != nil {
+		return productID.String()
+	}
+	return product.Name
+}
+
+// enqueueLowStock queues the stock.low webhook event when a movement takes stock
+// from above its minimum level to at or below it, in the movement's transaction
+func enqueueLowStock(tx *gorm.DB, stock *models.Stock, previousQty, newQty int) error {
+	if stock.MinimumLevel <= 0 || previousQty <= stock.MinimumLevel || newQty > stock.MinimumLevel {
 		return nil
+	}
+
+	var product models.Product
+	tx.Select("id", "name", "sku").Where("id = ? AND tenant_id = ?", stock.ProductID, stock.TenantID).First(&product)
+
+	return webhook.Enqueue(tx, stock.TenantID, webhook.EventStockLow, webhook.StockLowData{
+		StockID:      stock.ID,
+		ShopID:       stock.ShopID,
+		ProductID:    stock.ProductID,
+		ProductName:  product.Name,
+		SKU:          product.SKU,
+		Quantity:     newQty,
+		MinimumLevel: stock.MinimumLevel,
 	})
 }
 
@@ -605,6 +1038,8 @@ func (s *StockService) mapStockHistoryToResponse(history *models.StockHistory) *
 	}
 
 	if history.Stock != nil {
+		response.ProductID = history.Stock.ProductID
+		response.ShopID = history.Stock.ShopID
 		if history.Stock.Product != nil {
 			response.ProductName = history.Stock.Product.Name
 		}
@@ -0,0 +1,366 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/approval"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StockTransferResponse represents a stock transfer in responses
+type StockTransferResponse struct {
+	ID              uuid.UUID                    `json:"id"`
+	Reference       string                       `json:"reference"`
+	FromShopID      uuid.UUID                    `json:"from_shop_id"`
+	FromShopName    string                       `json:"from_shop_name"`
+	ToShopID        uuid.UUID                    `json:"to_shop_id"`
+	ToShopName      string                       `json:"to_shop_name"`
+	TransferDate    time.Time                    `json:"transfer_date"`
+	Notes           string                       `json:"notes"`
+	Status          string                       `json:"status"`
+	ApprovedAt      *time.Time                   `json:"approved_at"`
+	ApprovedByID    *uuid.UUID                   `json:"approved_by_id"`
+	RejectionReason string                       `json:"rejection_reason,omitempty"`
+	CreatedByID     uuid.UUID                    `json:"created_by_id"`
+	CreatedByName   string                       `json:"created_by_name"`
+	TotalQuantity   int                          `json:"total_quantity"`
+	Items           []*StockTransferItemResponse `json:"items"`
+	CreatedAt       time.Time                    `json:"created_at"`
+}
+
+// StockTransferItemResponse represents a transfer item in responses
+type StockTransferItemResponse struct {
+	ProductID   uuid.UUID `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	SKU         string    `json:"sku"`
+	Quantity    int       `json:"quantity"`
+	UnitCost    float64   `json:"unit_cost"`
+}
+
+// ApproveStockTransfer moves the stock of a pending transfer. The transfer and the
+// stock rows are locked and re-checked, so the transfer fails as a whole if the
+// source shop no longer has enough of any product.
+func (s *StockService) ApproveStockTransfer(ctx context.Context, id, tenantID, userID uuid.UUID) (*StockTransferResponse, error) {
+	var transfer models.StockTransfer
+	var summary transferSummary
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.lockStockTransfer(tx, &transfer, id, tenantID); err != nil {
+			return err
+		}
+		if err := approval.Decide("stock transfer", transfer.Status, models.StatusApproved); err != nil {
+			return err
+		}
+
+		var err error
+		summary, err = s.moveTransferStock(tx, &transfer, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&transfer).Updates(map[string]interface{}{
+			"status":         models.StatusApproved,
+			"approved_at":    time.Now(),
+			"approved_by_id": userID,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to approve stock transfer: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		if approval.Replay(ctx, s.settings, tenantID, err) {
+			return s.GetStockTransferByID(ctx, id, tenantID)
+		}
+		return nil, err
+	}
+
+	s.clearTransferStockCache(ctx, &transfer)
+
+	// The destination shop was told the stock is inbound when the transfer was created
+	go s.notifyTransfer(tenantID, models.EmailEventTransferCompleted, summary)
+
+	return s.GetStockTransferByID(ctx, id, tenantID)
+}
+
+// RejectStockTransfer cancels a pending transfer under the same lock as approval;
+// stock is left unchanged
+func (s *StockService) RejectStockTransfer(ctx context.Context, id, tenantID, userID uuid.UUID, reason string) (*StockTransferResponse, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var transfer models.StockTransfer
+		if err := s.lockStockTransfer(tx, &transfer, id, tenantID); err != nil {
+			return err
+		}
+		if err := approval.Decide("stock transfer", transfer.Status, models.StatusRejected); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&transfer).Updates(map[string]interface{}{
+			"status":           models.StatusRejected,
+			"approved_by_id":   userID,
+			"rejection_reason": reason,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to reject stock transfer: %w", err)
+		}
+		return nil
+	})
+	if err != nil && !approval.Replay(ctx, s.settings, tenantID, err) {
+		return nil, err
+	}
+
+	return s.GetStockTransferByID(ctx, id, tenantID)
+}
+
+// GetStockTransferByID returns a stock transfer by ID
+func (s *StockService) GetStockTransferByID(ctx context.Context, id, tenantID uuid.UUID) (*StockTransferResponse, error) {
+	var transfer models.StockTransfer
+	if err := s.db.Where("id = ? AND tenant_id = ?", id, tenantID).
+		Preload("FromShop").Preload("ToShop").Preload("CreatedBy").Preload("Items.Product").
+		First(&transfer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("stock transfer not found")
+		}
+		return nil, fmt.Errorf("failed to get stock transfer: %w", err)
+	}
+
+	return s.mapStockTransferToResponse(&transfer), nil
+}
+
+// GetStockTransfers lists stock transfers, newest first. A shop filter matches
+// transfers into or out of the shop.
+func (s *StockService) GetStockTransfers(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, status string, limit, offset int) ([]*StockTransferResponse, int64, error) {
+	query := s.db.Model(&models.StockTransfer{}).Where("tenant_id = ?", tenantID)
+	if shopID != nil {
+		query = query.Where("(from_shop_id = ? OR to_shop_id = ?)", *shopID, *shopID)
+	}
+	if shopScope := scope.FromContext(ctx); shopScope != nil && shopScope.Restricted {
+		query = query.Where("(from_shop_id IN ? OR to_shop_id IN ?)", shopScope.ShopIDs, shopScope.ShopIDs)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count stock transfers: %w", err)
+	}
+
+	var transfers []models.StockTransfer
+	if err := query.Preload("FromShop").Preload("ToShop").Preload("CreatedBy").Preload("Items.Product").
+		Order("created_at DESC").Limit(limit).Offset(offset).
+		Find(&transfers).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get stock transfers: %w", err)
+	}
+
+	responses := make([]*StockTransferResponse, len(transfers))
+	for i := range transfers {
+		responses[i] = s.mapStockTransferToResponse(&transfers[i])
+	}
+
+	return responses, total, nil
+}
+
+// lockStockTransfer loads a transfer with its items and shops, holding a row lock on
+// the transfer until the transaction ends
+func (s *StockService) lockStockTransfer(tx *gorm.DB, transfer *models.StockTransfer, id, tenantID uuid.UUID) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		Preload("FromShop").Preload("ToShop").Preload("Items").
+		First(transfer).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("stock transfer not found")
+		}
+		return fmt.Errorf("failed to find stock transfer: %w", err)
+	}
+	return nil
+}
+
+// moveTransferStock moves each item of a transfer from the source to the destination
+// shop, writing transfer_out and transfer_in history against the transfer
+func (s *StockService) moveTransferStock(tx *gorm.DB, transfer *models.StockTransfer, userID uuid.UUID) (transferSummary, error) {
+	summary := transferSummary{
+		Reference: transfer.Reference,
+		ItemCount: len(transfer.Items),
+	}
+	if transfer.FromShop != nil {
+		summary.FromShop = *transfer.FromShop
+	}
+	if transfer.ToShop != nil {
+		summary.ToShop = *transfer.ToShop
+	}
+
+	for i := range transfer.Items {
+		item := &transfer.Items[i]
+
+		var product models.Product
+		if err := tx.Where("id = ? AND tenant_id = ?", item.ProductID, transfer.TenantID).First(&product).Error; err != nil {
+			return summary, fmt.Errorf("product %s not found", item.ProductID)
+		}
+
+		// Get source stock
+		var fromStock models.Stock
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("shop_id = ? AND product_id = ? AND tenant_id = ?", transfer.FromShopID, item.ProductID, transfer.TenantID).
+			First(&fromStock).Error
+		if err != nil {
+			return summary, fmt.Errorf("stock not found for product %s in source shop", product.Name)
+		}
+
+		// Check available quantity
+		availableQty := fromStock.Quantity - fromStock.ReservedQuantity
+		if availableQty < item.Quantity {
+			return summary, fmt.Errorf("insufficient stock for product %s (available: %d, requested: %d)",
+				product.Name, availableQty, item.Quantity)
+		}
+
+		// Value the transfer at the cost of the source layers it draws down
+		unitCost := fromStock.AverageCost
+		if unitCost == 0 {
+			unitCost = product.CostPrice
+		}
+		unitCost, err = consumeCostLayers(tx, &fromStock, item.Quantity, unitCost)
+		if err != nil {
+			return summary, err
+		}
+		summary.TotalQuantity += item.Quantity
+		summary.TotalCost += unitCost * float64(item.Quantity)
+
+		if err := tx.Model(item).Update("unit_cost", unitCost).Error; err != nil {
+			return summary, fmt.Errorf("failed to update stock transfer item: %w", err)
+		}
+
+		// Update source stock
+		previousFromQty, err := removeStockQuantity(tx, &fromStock, item.Quantity)
+		if errors.Is(err, ErrInsufficientStock) {
+			return summary, fmt.Errorf("insufficient stock for product %s (requested: %d)", product.Name, item.Quantity)
+		}
+		if err != nil {
+			return summary, err
+		}
+
+		fromHistory := models.StockHistory{
+			TenantModel:      models.TenantModel{TenantID: transfer.TenantID},
+			StockID:          fromStock.ID,
+			MovementType:     "transfer_out",
+			Quantity:         -item.Quantity,
+			PreviousQuantity: previousFromQty,
+			NewQuantity:      previousFromQty - item.Quantity,
+			UnitCost:         unitCost,
+			TotalCost:        unitCost * float64(item.Quantity),
+			Reference:        transfer.Reference,
+			ReferenceID:      &transfer.ID,
+			Notes:            fmt.Sprintf("Transfer to %s", summary.ToShop.Name),
+			CreatedByID:      userID,
+		}
+		if err := tx.Create(&fromHistory).Error; err != nil {
+			return summary, fmt.Errorf("failed to create source history: %w", err)
+		}
+
+		// Get or create destination stock
+		var toStock models.Stock
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("shop_id = ? AND product_id = ? AND tenant_id = ?", transfer.ToShopID, item.ProductID, transfer.TenantID).
+			First(&toStock).Error
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return summary, fmt.Errorf("failed to get destination stock: %w", err)
+			}
+			toStock = models.Stock{
+				TenantModel:   models.TenantModel{TenantID: transfer.TenantID},
+				ShopID:        transfer.ToShopID,
+				ProductID:     item.ProductID,
+				Quantity:      0,
+				CostingMethod: productCostingMethod(&product),
+			}
+			if err := tx.Create(&toStock).Error; err != nil {
+				return summary, fmt.Errorf("failed to create destination stock: %w", err)
+			}
+		}
+
+		// Update destination stock, carrying the cost across as a new layer
+		if err := addCostLayer(tx, &toStock, item.Quantity, unitCost, models.StockBatch{BatchNumber: transfer.Reference}); err != nil {
+			return summary, err
+		}
+		previousToQty, err := addStockQuantity(tx, &toStock, item.Quantity)
+		if err != nil {
+			return summary, err
+		}
+
+		toHistory := models.StockHistory{
+			TenantModel:      models.TenantModel{TenantID: transfer.TenantID},
+			StockID:          toStock.ID,
+			MovementType:     "transfer_in",
+			Quantity:         item.Quantity,
+			PreviousQuantity: previousToQty,
+			NewQuantity:      previousToQty + item.Quantity,
+			UnitCost:         unitCost,
+			TotalCost:        unitCost * float64(item.Quantity),
+			Reference:        transfer.Reference,
+			ReferenceID:      &transfer.ID,
+			Notes:            fmt.Sprintf("Transfer from %s", summary.FromShop.Name),
+			CreatedByID:      userID,
+		}
+		if err := tx.Create(&toHistory).Error; err != nil {
+			return summary, fmt.Errorf("failed to create destination history: %w", err)
+		}
+	}
+
+	return summary, nil
+}
+
+// clearTransferStockCache clears cached stock at both shops of a transfer
+func (s *StockService) clearTransferStockCache(ctx context.Context, transfer *models.StockTransfer) {
+	for _, item := range transfer.Items {
+		s.clearStockCache(ctx, transfer.TenantID, transfer.FromShopID, item.ProductID)
+		s.clearStockCache(ctx, transfer.TenantID, transfer.ToShopID, item.ProductID)
+	}
+}
+
+func (s *StockService) mapStockTransferToResponse(transfer *models.StockTransfer) *StockTransferResponse {
+	response := &StockTransferResponse{
+		ID:              transfer.ID,
+		Reference:       transfer.Reference,
+		FromShopID:      transfer.FromShopID,
+		ToShopID:        transfer.ToShopID,
+		TransferDate:    transfer.TransferDate,
+		Notes:           transfer.Notes,
+		Status:          transfer.Status,
+		ApprovedAt:      transfer.ApprovedAt,
+		ApprovedByID:    transfer.ApprovedByID,
+		RejectionReason: transfer.RejectionReason,
+		CreatedByID:     transfer.CreatedByID,
+		Items:           make([]*StockTransferItemResponse, len(transfer.Items)),
+		CreatedAt:       transfer.CreatedAt,
+	}
+	if transfer.FromShop != nil {
+		response.FromShopName = transfer.FromShop.Name
+	}
+	if transfer.ToShop != nil {
+		response.ToShopName = transfer.ToShop.Name
+	}
+	if transfer.CreatedBy != nil {
+		response.CreatedByName = transfer.CreatedBy.FirstName + " " + transfer.CreatedBy.LastName
+	}
+
+	for i, item := range transfer.Items {
+		response.Items[i] = &StockTransferItemResponse{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			UnitCost:  item.UnitCost,
+		}
+		if item.Product != nil {
+			response.Items[i].ProductName = item.Product.Name
+			response.Items[i].SKU = item.Product.SKU
+		}
+		response.TotalQuantity += item.Quantity
+	}
+
+	return response
+}
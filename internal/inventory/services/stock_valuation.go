@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+)
+
+// StockValuationItem values one product's stock at cost and at selling price
+type StockValuationItem struct {
+	ProductID    uuid.UUID `json:"product_id"`
+	ProductName  string    `json:"product_name"`
+	SKU          string    `json:"sku"`
+	Size         string    `json:"size"`
+	BrandID      uuid.UUID `json:"brand_id"`
+	BrandName    string    `json:"brand_name"`
+	CategoryID   uuid.UUID `json:"category_id"`
+	CategoryName string    `json:"category_name"`
+	Quantity     int       `json:"quantity"`
+	UnitCost     float64   `json:"unit_cost"`
+	SellingPrice float64   `json:"selling_price"`
+	CostValue    float64   `json:"cost_value"`
+	RetailValue  float64   `json:"retail_value"`
+}
+
+// StockValuationSubtotal totals the valuation for one category or brand
+type StockValuationSubtotal struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Quantity    int       `json:"quantity"`
+	CostValue   float64   `json:"cost_value"`
+	RetailValue float64   `json:"retail_value"`
+}
+
+// StockValuationReport is the inventory valuation for a shop or the whole tenant
+type StockValuationReport struct {
+	ShopID           *uuid.UUID                `json:"shop_id,omitempty"`
+	AsOf             *time.Time                `json:"as_of,omitempty"`
+	GeneratedAt      time.Time                 `json:"generated_at"`
+	TotalQuantity    int                       `json:"total_quantity"`
+	TotalCostValue   float64                   `json:"total_cost_value"`
+	TotalRetailValue float64                   `json:"total_retail_value"`
+	Categories       []*StockValuationSubtotal `json:"categories"`
+	Brands           []*StockValuationSubtotal `json:"brands"`
+	Items            []*StockValuationItem     `json:"items"`
+}
+
+// stockValuationRow is one stock record before it is rolled up by product
+type stockValuationRow struct {
+	StockID      uuid.UUID
+	ProductID    uuid.UUID
+	ProductName  string
+	SKU          string
+	Size         string
+	BrandID      uuid.UUID
+	BrandName    string
+	CategoryID   uuid.UUID
+	CategoryName string
+	Quantity     int
+	UnitCost     float64
+	SellingPrice float64
+}
+
+// GetStockValuation values stock on hand per product at average cost and at selling
+// price, with category and brand subtotals. With asOf, quantities are rebuilt by
+// undoing every stock movement recorded after that time; values still use the
+// current average cost and selling price.
+func (s *StockService) GetStockValuation(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, asOf *time.Time) (*StockValuationReport, error) {
+	// Unit cost falls back to the product cost price when no average cost is tracked yet
+	query := s.db.Table("stocks st").
+		Select(`st.id AS stock_id, st.product_id, p.name AS product_name, p.sku, p.size,
+			p.brand_id, COALESCE(b.name, '') AS brand_name, p.category_id, COALESCE(c.name, '') AS category_name,
+			st.quantity, CASE WHEN st.average_cost > 0 THEN st.average_cost ELSE p.cost_price END AS unit_cost,
+			p.selling_price`).
+		Joins("JOIN products p ON p.id = st.product_id").
+		Joins("LEFT JOIN brands b ON b.id = p.brand_id").
+		Joins("LEFT JOIN categories c ON c.id = p.category_id").
+		Where("st.tenant_id = ? AND st.deleted_at IS NULL", tenantID)
+
+	if shopID != nil {
+		query = query.Where("st.shop_id = ?", *shopID)
+	}
+	query = scope.FromContext(ctx).Apply(query, "st.shop_id")
+
+	var rows []stockValuationRow
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get stock valuation: %w", err)
+	}
+
+	if asOf != nil {
+		var changes []struct {
+			StockID        uuid.UUID
+			QuantityChange int
+		}
+		if err := s.db.Table("stock_histories").
+			Select("stock_id, SUM(new_quantity - previous_quantity) AS quantity_change").
+			Where("tenant_id = ? AND created_at > ? AND deleted_at IS NULL", tenantID, *asOf).
+			Group("stock_id").
+			Scan(&changes).Error; err != nil {
+			return nil, fmt.Errorf("failed to replay stock history: %w", err)
+		}
+		changeByStock := make(map[uuid.UUID]int, len(changes))
+		for _, change := range changes {
+			changeByStock[change.StockID] = change.QuantityChange
+		}
+		for i := range rows {
+			rows[i].Quantity -= changeByStock[rows[i].StockID]
+		}
+	}
+
+	report := &StockValuationReport{
+		ShopID:      shopID,
+		AsOf:        asOf,
+		GeneratedAt: time.Now(),
+		Categories:  make([]*StockValuationSubtotal, 0),
+		Brands:      make([]*StockValuationSubtotal, 0),
+		Items:       make([]*StockValuationItem, 0),
+	}
+
+	products := make(map[uuid.UUID]*StockValuationItem)
+	categories := make(map[uuid.UUID]*StockValuationSubtotal)
+	brands := make(map[uuid.UUID]*StockValuationSubtotal)
+	for _, row := range rows {
+		if row.Quantity <= 0 {
+			continue
+		}
+		costValue := float64(row.Quantity) * row.UnitCost
+		retailValue := float64(row.Quantity) * row.SellingPrice
+
+		item, ok := products[row.ProductID]
+		if !ok {
+			item = &StockValuationItem{
+				ProductID:    row.ProductID,
+				ProductName:  row.ProductName,
+				SKU:          row.SKU,
+				Size:         row.Size,
+				BrandID:      row.BrandID,
+				BrandName:    row.BrandName,
+				CategoryID:   row.CategoryID,
+				CategoryName: row.CategoryName,
+				SellingPrice: row.SellingPrice,
+			}
+			products[row.ProductID] = item
+			report.Items = append(report.Items, item)
+		}
+		item.Quantity += row.Quantity
+		item.CostValue += costValue
+		item.RetailValue += retailValue
+
+		category, ok := categories[row.CategoryID]
+		if !ok {
+			category = &StockValuationSubtotal{ID: row.CategoryID, Name: row.CategoryName}
+			categories[row.CategoryID] = category
+			report.Categories = append(report.Categories, category)
+		}
+		category.Quantity += row.Quantity
+		category.CostValue += costValue
+		category.RetailValue += retailValue
+
+		brand, ok := brands[row.BrandID]
+		if !ok {
+			brand = &StockValuationSubtotal{ID: row.BrandID, Name: row.BrandName}
+			brands[row.BrandID] = brand
+			report.Brands = append(report.Brands, brand)
+		}
+		brand.Quantity += row.Quantity
+		brand.CostValue += costValue
+		brand.RetailValue += retailValue
+
+		report.TotalQuantity += row.Quantity
+		report.TotalCostValue += costValue
+		report.TotalRetailValue += retailValue
+	}
+
+	// A product held at several shops is costed at each shop's average; its unit
+	// cost is the blend of those
+	for _, item := range report.Items {
+		item.UnitCost = utils.RoundToTwoDecimals(item.CostValue / float64(item.Quantity))
+		item.CostValue = utils.RoundToTwoDecimals(item.CostValue)
+		item.RetailValue = utils.RoundToTwoDecimals(item.RetailValue)
+	}
+	for _, subtotals := range [][]*StockValuationSubtotal{report.Categories, report.Brands} {
+		for _, subtotal := range subtotals {
+			subtotal.CostValue = utils.RoundToTwoDecimals(subtotal.CostValue)
+			subtotal.RetailValue = utils.RoundToTwoDecimals(subtotal.RetailValue)
+		}
+		sort.Slice(subtotals, func(i, j int) bool { return subtotals[i].CostValue > subtotals[j].CostValue })
+	}
+	sort.Slice(report.Items, func(i, j int) bool { return report.Items[i].CostValue > report.Items[j].CostValue })
+	report.TotalCostValue = utils.RoundToTwoDecimals(report.TotalCostValue)
+	report.TotalRetailValue = utils.RoundToTwoDecimals(report.TotalRetailValue)
+
+	return report, nil
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"gorm.io/gorm"
+)
+
+// TaxRateRequest sets the GST rate of either a category or a single product
+type TaxRateRequest struct {
+	Name       string     `json:"name" binding:"required,max=100"`
+	CategoryID *uuid.UUID `json:"category_id"`
+	ProductID  *uuid.UUID `json:"product_id"`
+	Percentage float64    `json:"percentage" binding:"min=0,max=100"`
+	IsActive   *bool      `json:"is_active"`
+}
+
+// TaxRateResponse represents a tax rate in responses
+type TaxRateResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	Name         string     `json:"name"`
+	CategoryID   *uuid.UUID `json:"category_id"`
+	CategoryName string     `json:"category_name,omitempty"`
+	ProductID    *uuid.UUID `json:"product_id"`
+	ProductName  string     `json:"product_name,omitempty"`
+	Percentage   float64    `json:"percentage"`
+	IsActive     bool       `json:"is_active"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// CreateTaxRate adds a GST rate for a category or product. Each may have only one
+// active rate; a product's rate overrides its category's.
+func (s *CategoryService) CreateTaxRate(ctx context.Context, req TaxRateRequest, tenantID, userID uuid.UUID) (*TaxRateResponse, error) {
+	rate := models.TaxRate{
+		TenantModel: models.TenantModel{TenantID: tenantID},
+		Name:        req.Name,
+		CategoryID:  req.CategoryID,
+		ProductID:   req.ProductID,
+		Percentage:  req.Percentage,
+		IsActive:    true,
+		CreatedByID: userID,
+	}
+	if req.IsActive != nil {
+		rate.IsActive = *req.IsActive
+	}
+
+	if err := s.validateTaxRate(&rate); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(&rate).Error; err != nil {
+		return nil, fmt.Errorf("failed to create tax rate: %w", err)
+	}
+
+	return s.GetTaxRate(ctx, rate.ID, tenantID)
+}
+
+// GetTaxRates lists the tenant's tax rates, category rates first
+func (s *CategoryService) GetTaxRates(ctx context.Context, tenantID uuid.UUID) ([]*TaxRateResponse, error) {
+	var rates []models.TaxRate
+	if err := s.db.Where("tenant_id = ?", tenantID).
+		Preload("Category").
+		Preload("Product").
+		Order("product_id IS NOT NULL, name ASC").
+		Find(&rates).Error; err != nil {
+		return nil, fmt.Errorf("failed to get tax rates: %w", err)
+	}
+
+	responses := make([]*TaxRateResponse, len(rates))
+	for i := range rates {
+		responses[i] = buildTaxRateResponse(&rates[i])
+	}
+	return responses, nil
+}
+
+// GetTaxRate returns one tax rate
+func (s *CategoryService) GetTaxRate(ctx context.Context, id, tenantID uuid.UUID) (*TaxRateResponse, error) {
+	var rate models.TaxRate
+	if err := s.db.Where("id = ? AND tenant_id = ?", id, tenantID).
+		Preload("Category").
+		Preload("Product").
+		First(&rate).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("tax rate not found")
+		}
+		return nil, fmt.Errorf("failed to get tax rate: %w", err)
+	}
+	return buildTaxRateResponse(&rate), nil
+}
+
+// UpdateTaxRate changes a tax rate. Sales already recorded keep the tax they were
+// charged; only new sales use the new rate.
+func (s *CategoryService) UpdateTaxRate(ctx context.Context, id uuid.UUID, req TaxRateRequest, tenantID uuid.UUID) (*TaxRateResponse, error) {
+	var rate models.TaxRate
+	if err := s.db.Where("id = ? AND tenant_id = ?", id, tenantID).First(&rate).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("tax rate not found")
+		}
+		return nil, fmt.Errorf("failed to get tax rate: %w", err)
+	}
+
+	rate.Name = req.Name
+	rate.CategoryID = req.CategoryID
+	rate.ProductID = req.ProductID
+	rate.Percentage = req.Percentage
+	if req.IsActive != nil {
+		rate.IsActive = *req.IsActive
+	}
+
+	if err := s.validateTaxRate(&rate); err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(&rate).Select("name", "category_id", "product_id", "percentage", "is_active").Updates(&rate).Error; err != nil {
+		return nil, fmt.Errorf("failed to update tax rate: %w", err)
+	}
+
+	return s.GetTaxRate(ctx, rate.ID, tenantID)
+}
+
+// DeleteTaxRate removes a tax rate; its category or product is then untaxed unless
+// a category rate still applies
+func (s *CategoryService) DeleteTaxRate(ctx context.Context, id, tenantID uuid.UUID) error {
+	result := s.db.Where("id = ? AND tenant_id = ?", id, tenantID).Delete(&models.TaxRate{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete tax rate: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("tax rate not found")
+	}
+	return nil
+}
+
+// validateTaxRate checks a rate targets exactly one existing category or product
+// that has no other active rate
+func (s *CategoryService) validateTaxRate(rate *models.TaxRate) error {
+	if (rate.CategoryID == nil) == (rate.ProductID == nil) {
+		return errors.New("a tax rate must apply to either a category or a product")
+	}
+
+	target, column, targetID := "category", "category_id", rate.CategoryID
+	var err error
+	if rate.ProductID != nil {
+		target, column, targetID = "product", "product_id", rate.ProductID
+		err = s.db.Select("id").Where("id = ? AND tenant_id = ?", *targetID, rate.TenantID).First(&models.Product{}).Error
+	} else {
+		err = s.db.Select("id").Where("id = ? AND tenant_id = ?", *targetID, rate.TenantID).First(&models.Category{}).Error
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%s not found", target)
+		}
+		return fmt.Errorf("failed to get %s: %w", target, err)
+	}
+
+	if !rate.IsActive {
+		return nil
+	}
+	var count int64
+	query := s.db.Model(&models.TaxRate{}).
+		Where("tenant_id = ? AND "+column+" = ? AND is_active = ?", rate.TenantID, *targetID, true)
+	if rate.ID != uuid.Nil {
+		query = query.Where("id <> ?", rate.ID)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check existing tax rates: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("this %s already has an active tax rate", target)
+	}
+	return nil
+}
+
+func buildTaxRateResponse(rate *models.TaxRate) *TaxRateResponse {
+	response := &TaxRateResponse{
+		ID:         rate.ID,
+		Name:       rate.Name,
+		CategoryID: rate.CategoryID,
+		ProductID:  rate.ProductID,
+		Percentage: rate.Percentage,
+		IsActive:   rate.IsActive,
+		CreatedAt:  rate.CreatedAt,
+		UpdatedAt:  rate.UpdatedAt,
+	}
+	if rate.Category != nil {
+		response.CategoryName = rate.Category.Name
+	}
+	if rate.Product != nil {
+		response.ProductName = rate.Product.Name
+	}
+	return response
+}
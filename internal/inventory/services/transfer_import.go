@@ -0,0 +1,317 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+)
+
+// maxTransferImportRows caps the size of a single transfer import
+const maxTransferImportRows = 5000
+
+var transferImportColumns = []string{"product", "from_shop", "to_shop", "quantity"}
+
+// TransferImportOptions controls how an imported transfer file is applied
+type TransferImportOptions struct {
+	TransferDate time.Time
+	Notes        string
+	DryRun       bool // validate and group rows without moving stock
+}
+
+// TransferImportResult reports the outcome of a transfer import
+type TransferImportResult struct {
+	TotalRows  int                      `json:"total_rows"`
+	ValidRows  int                      `json:"valid_rows"`
+	DryRun     bool                     `json:"dry_run"`
+	Errors     []TransferImportRowError `json:"errors"`
+	Transfers  []*TransferImportGroup   `json:"transfers"`
+	Failed     int                      `json:"failed"`
+	Successful int                      `json:"successful"`
+}
+
+// TransferImportRowError describes a row that failed validation
+type TransferImportRowError struct {
+	Row   int    `json:"row"` // 1-based line number including the header
+	Error string `json:"error"`
+}
+
+// TransferImportGroup is one transfer created for a source/destination shop pair
+type TransferImportGroup struct {
+	FromShopID    uuid.UUID `json:"from_shop_id"`
+	FromShopName  string    `json:"from_shop_name"`
+	ToShopID      uuid.UUID `json:"to_shop_id"`
+	ToShopName    string    `json:"to_shop_name"`
+	Rows          []int     `json:"rows"`
+	ItemCount     int       `json:"item_count"`
+	TotalQuantity int       `json:"total_quantity"`
+	Reference     string    `json:"reference,omitempty"`
+	Status        string    `json:"status,omitempty"`
+	Error         string    `json:"error,omitempty"`
+
+	items   []StockTransferItemRequest
+	itemIdx map[uuid.UUID]int
+}
+
+// ImportStockTransfers reads a CSV of product, from_shop, to_shop, quantity rows and
+// creates one transfer per shop pair through CreateStockTransfer. Products match on
+// SKU or ID and shops on name or ID. If any row is invalid nothing is transferred and
+// the error report is returned; each shop pair then succeeds or fails on its own.
+func (s *StockService) ImportStockTransfers(ctx context.Context, r io.Reader, opts TransferImportOptions, tenantID, userID uuid.UUID) (*TransferImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("CSV file is empty")
+		}
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	columns, err := transferImportColumnIndex(header)
+	if err != nil {
+		return nil, err
+	}
+
+	lookup, err := s.newTransferImportLookup(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TransferImportResult{
+		DryRun:    opts.DryRun,
+		Errors:    []TransferImportRowError{},
+		Transfers: []*TransferImportGroup{},
+	}
+
+	type shopPair struct{ from, to uuid.UUID }
+	groups := make(map[shopPair]*TransferImportGroup)
+	// Quantity already claimed from each source shop's stock of a product by earlier rows
+	type stockKey struct{ shop, product uuid.UUID }
+	claimed := make(map[stockKey]int)
+
+	row := 1
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		row++
+		if err != nil {
+			result.TotalRows++
+			result.Errors = append(result.Errors, TransferImportRowError{Row: row, Error: err.Error()})
+			continue
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+
+		result.TotalRows++
+		if result.TotalRows > maxTransferImportRows {
+			return nil, fmt.Errorf("CSV exceeds the limit of %d rows", maxTransferImportRows)
+		}
+
+		field := func(name string) string {
+			idx := columns[name]
+			if idx >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[idx])
+		}
+
+		rowError := func(format string, args ...interface{}) {
+			result.Errors = append(result.Errors, TransferImportRowError{Row: row, Error: fmt.Sprintf(format, args...)})
+		}
+
+		quantity, err := strconv.Atoi(field("quantity"))
+		if err != nil || quantity <= 0 {
+			rowError("quantity must be a positive whole number")
+			continue
+		}
+		product, ok := lookup.product(field("product"))
+		if !ok {
+			rowError("product %q not found", field("product"))
+			continue
+		}
+		fromShop, ok := lookup.shop(field("from_shop"))
+		if !ok {
+			rowError("source shop %q not found", field("from_shop"))
+			continue
+		}
+		toShop, ok := lookup.shop(field("to_shop"))
+		if !ok {
+			rowError("destination shop %q not found", field("to_shop"))
+			continue
+		}
+		if fromShop.ID == toShop.ID {
+			rowError("source and destination shop are the same")
+			continue
+		}
+
+		// Check the cumulative quantity requested from the source shop
+		source := stockKey{shop: fromShop.ID, product: product.ID}
+		var stock models.Stock
+		available := 0
+		if err := s.db.Where("shop_id = ? AND product_id = ? AND tenant_id = ?", fromShop.ID, product.ID, tenantID).First(&stock).Error; err == nil {
+			available = stock.Quantity - stock.ReservedQuantity
+		}
+		if claimed[source]+quantity > available {
+			rowError("insufficient stock for %s in %s (available: %d, requested: %d)",
+				product.Name, fromShop.Name, available-claimed[source], quantity)
+			continue
+		}
+		claimed[source] += quantity
+
+		pair := shopPair{from: fromShop.ID, to: toShop.ID}
+		group, ok := groups[pair]
+		if !ok {
+			group = &TransferImportGroup{
+				FromShopID:   fromShop.ID,
+				FromShopName: fromShop.Name,
+				ToShopID:     toShop.ID,
+				ToShopName:   toShop.Name,
+				itemIdx:      make(map[uuid.UUID]int),
+			}
+			groups[pair] = group
+			result.Transfers = append(result.Transfers, group)
+		}
+		if idx, ok := group.itemIdx[product.ID]; ok {
+			group.items[idx].Quantity += quantity
+		} else {
+			group.itemIdx[product.ID] = len(group.items)
+			group.items = append(group.items, StockTransferItemRequest{ProductID: product.ID, Quantity: quantity})
+		}
+		group.Rows = append(group.Rows, row)
+		group.ItemCount = len(group.items)
+		group.TotalQuantity += quantity
+		result.ValidRows++
+	}
+
+	if result.TotalRows == 0 {
+		return nil, errors.New("CSV file has no rows")
+	}
+	if len(result.Errors) > 0 || opts.DryRun {
+		return result, nil
+	}
+
+	transferDate := opts.TransferDate
+	if transferDate.IsZero() {
+		transferDate = time.Now()
+	}
+	for _, group := range result.Transfers {
+		transfer, err := s.CreateStockTransfer(ctx, StockTransferRequest{
+			FromShopID:   group.FromShopID,
+			ToShopID:     group.ToShopID,
+			TransferDate: transferDate,
+			Notes:        opts.Notes,
+			Items:        group.items,
+		}, tenantID, userID)
+		if err != nil {
+			group.Error = err.Error()
+			result.Failed++
+			continue
+		}
+		group.Reference = transfer.Reference
+		group.Status = transfer.Status
+		result.Successful++
+	}
+
+	return result, nil
+}
+
+func transferImportColumnIndex(header []string) (map[string]int, error) {
+	return csvColumnIndex(header, transferImportColumns)
+}
+
+// csvColumnIndex maps lower-cased header names to their position and checks that
+// every required column is present
+func csvColumnIndex(header, required []string) (map[string]int, error) {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	var missing []string
+	for _, name := range required {
+		if _, ok := columns[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("CSV is missing columns: %s", strings.Join(missing, ", "))
+	}
+	return columns, nil
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// transferImportLookup resolves the product and shop references used in an import
+type transferImportLookup struct {
+	productsByID  map[uuid.UUID]*models.Product
+	productsBySKU map[string]*models.Product
+	shopsByID     map[uuid.UUID]*models.Shop
+	shopsByName   map[string]*models.Shop
+}
+
+func (s *StockService) newTransferImportLookup(tenantID uuid.UUID) (*transferImportLookup, error) {
+	var products []models.Product
+	if err := s.db.Select("id", "name", "sku").Where("tenant_id = ?", tenantID).Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("failed to load products: %w", err)
+	}
+	var shops []models.Shop
+	if err := s.db.Select("id", "name").Where("tenant_id = ?", tenantID).Find(&shops).Error; err != nil {
+		return nil, fmt.Errorf("failed to load shops: %w", err)
+	}
+
+	lookup := &transferImportLookup{
+		productsByID:  make(map[uuid.UUID]*models.Product, len(products)),
+		productsBySKU: make(map[string]*models.Product, len(products)),
+		shopsByID:     make(map[uuid.UUID]*models.Shop, len(shops)),
+		shopsByName:   make(map[string]*models.Shop, len(shops)),
+	}
+	for i := range products {
+		product := &products[i]
+		lookup.productsByID[product.ID] = product
+		if product.SKU != "" {
+			lookup.productsBySKU[strings.ToUpper(product.SKU)] = product
+		}
+	}
+	for i := range shops {
+		shop := &shops[i]
+		lookup.shopsByID[shop.ID] = shop
+		lookup.shopsByName[strings.ToLower(shop.Name)] = shop
+	}
+	return lookup, nil
+}
+
+func (l *transferImportLookup) product(ref string) (*models.Product, bool) {
+	if id, err := uuid.Parse(ref); err == nil {
+		product, ok := l.productsByID[id]
+		return product, ok
+	}
+	product, ok := l.productsBySKU[strings.ToUpper(ref)]
+	return product, ok
+}
+
+func (l *transferImportLookup) shop(ref string) (*models.Shop, bool) {
+	if id, err := uuid.Parse(ref); err == nil {
+		shop, ok := l.shopsByID[id]
+		return shop, ok
+	}
+	shop, ok := l.shopsByName[strings.ToLower(ref)]
+	return shop, ok
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+)
+
+// DisplayQuantity presents a base-unit stock quantity in the tenant's display unit.
+// Cases and LooseUnits are exact; Quantity is the rounded figure for presentation only.
+type DisplayQuantity struct {
+	Unit       string  `json:"unit"`
+	Quantity   float64 `json:"quantity"`
+	Cases      int     `json:"cases"`
+	LooseUnits int     `json:"loose_units"`
+}
+
+// ValidDisplayUnit reports whether unit is a supported stock display unit
+func ValidDisplayUnit(unit string) bool {
+	return unit == settings.StockDisplayUnits || unit == settings.StockDisplayCases
+}
+
+// toDisplayQuantity converts a quantity held in base units into the display unit
+func toDisplayQuantity(quantity, unitsPerCase int, unit string) *DisplayQuantity {
+	if unitsPerCase <= 0 {
+		unitsPerCase = 1
+	}
+
+	if unit != settings.StockDisplayCases {
+		return &DisplayQuantity{
+			Unit:       settings.StockDisplayUnits,
+			Quantity:   float64(quantity),
+			LooseUnits: quantity,
+		}
+	}
+
+	return &DisplayQuantity{
+		Unit:       settings.StockDisplayCases,
+		Quantity:   utils.RoundToTwoDecimals(float64(quantity) / float64(unitsPerCase)),
+		Cases:      quantity / unitsPerCase,
+		LooseUnits: quantity % unitsPerCase,
+	}
+}
+
+// displayTotal accumulates display quantities across products with different case
+// sizes. Whole cases and loose units are summed exactly so totals always agree with
+// the line items; only the presented Quantity is rounded, once, at the end.
+type displayTotal struct {
+	unit       string
+	cases      int
+	looseUnits int
+	fraction   float64
+}
+
+func (t *displayTotal) add(quantity, unitsPerCase int) {
+	if unitsPerCase <= 0 {
+		unitsPerCase = 1
+	}
+	if t.unit != settings.StockDisplayCases {
+		t.looseUnits += quantity
+		return
+	}
+	t.cases += quantity / unitsPerCase
+	t.looseUnits += quantity % unitsPerCase
+	t.fraction += float64(quantity%unitsPerCase) / float64(unitsPerCase)
+}
+
+func (t *displayTotal) result() *DisplayQuantity {
+	if t.unit != settings.StockDisplayCases {
+		return &DisplayQuantity{
+			Unit:       settings.StockDisplayUnits,
+			Quantity:   float64(t.looseUnits),
+			LooseUnits: t.looseUnits,
+		}
+	}
+	return &DisplayQuantity{
+		Unit:       settings.StockDisplayCases,
+		Quantity:   utils.RoundToTwoDecimals(float64(t.cases) + t.fraction),
+		Cases:      t.cases,
+		LooseUnits: t.looseUnits,
+	}
+}
@@ -0,0 +1,436 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/approval"
+	"github.com/liquorpro/go-backend/pkg/shared/cache"
+	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// writeOffPaymentMethod marks expenses that record a stock loss rather than money paid out
+const writeOffPaymentMethod = "write_off"
+
+// WriteOffService handles writing off expired or damaged stock
+type WriteOffService struct {
+	db       *database.DB
+	cache    *cache.Cache
+	settings *settings.Service
+	stocks   *StockService
+}
+
+// NewWriteOffService creates a new write-off service
+func NewWriteOffService(db *database.DB, cache *cache.Cache, settingsService *settings.Service, stockService *StockService) *WriteOffService {
+	return &WriteOffService{
+		db:       db,
+		cache:    cache,
+		settings: settingsService,
+		stocks:   stockService,
+	}
+}
+
+// WriteOffRequest represents a request to write off stock
+type WriteOffRequest struct {
+	ShopID       uuid.UUID  `json:"shop_id" binding:"required"`
+	ProductID    uuid.UUID  `json:"product_id" binding:"required"`
+	StockBatchID *uuid.UUID `json:"stock_batch_id"`
+	Quantity     int        `json:"quantity" binding:"required,gt=0"`
+	Reason       string     `json:"reason" binding:"required,oneof=expired damaged breakage theft other"`
+	Notes        string     `json:"notes"`
+}
+
+// WriteOffResponse represents a stock write-off in responses
+type WriteOffResponse struct {
+	ID              uuid.UUID  `json:"id"`
+	ShopID          uuid.UUID  `json:"shop_id"`
+	ShopName        string     `json:"shop_name"`
+	ProductID       uuid.UUID  `json:"product_id"`
+	ProductName     string     `json:"product_name"`
+	StockBatchID    *uuid.UUID `json:"stock_batch_id"`
+	BatchNumber     string     `json:"batch_number,omitempty"`
+	Quantity        int        `json:"quantity"`
+	UnitCost        float64    `json:"unit_cost"`
+	TotalCost       float64    `json:"total_cost"`
+	Reason          string     `json:"reason"`
+	Notes           string     `json:"notes"`
+	Status          string     `json:"status"`
+	ApprovedAt      *time.Time `json:"approved_at"`
+	ApprovedByID    *uuid.UUID `json:"approved_by_id"`
+	RejectionReason string     `json:"rejection_reason,omitempty"`
+	ExpenseID       *uuid.UUID `json:"expense_id"`
+	CreatedByID     uuid.UUID  `json:"created_by_id"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// WriteOffReasonTotal summarises approved write-offs for one reason
+type WriteOffReasonTotal struct {
+	Reason    string  `json:"reason"`
+	Count     int64   `json:"count"`
+	Quantity  int64   `json:"quantity"`
+	TotalCost float64 `json:"total_cost"`
+}
+
+// WriteOffReport represents approved write-offs grouped by reason
+type WriteOffReport struct {
+	StartDate     time.Time              `json:"start_date"`
+	EndDate       time.Time              `json:"end_date"`
+	TotalCount    int64                  `json:"total_count"`
+	TotalQuantity int64                  `json:"total_quantity"`
+	TotalCost     float64                `json:"total_cost"`
+	ByReason      []*WriteOffReasonTotal `json:"by_reason"`
+}
+
+// CreateWriteOff records a pending write-off. Stock is not touched until approval.
+func (s *WriteOffService) CreateWriteOff(ctx context.Context, req WriteOffRequest, tenantID, userID uuid.UUID) (*WriteOffResponse, error) {
+	var stock models.Stock
+	if err := s.db.Where("shop_id = ? AND product_id = ? AND tenant_id = ?", req.ShopID, req.ProductID, tenantID).
+		Preload("Product").
+		First(&stock).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("stock not found")
+		}
+		return nil, fmt.Errorf("failed to get stock: %w", err)
+	}
+
+	if req.Quantity > stock.Quantity {
+		return nil, errors.New("insufficient stock")
+	}
+
+	unitCost := stock.AverageCost
+	if unitCost <= 0 && stock.Product != nil {
+		unitCost = stock.Product.CostPrice
+	}
+
+	if req.StockBatchID != nil {
+		var batch models.StockBatch
+		if err := s.db.Where("id = ? AND stock_id = ? AND tenant_id = ?", *req.StockBatchID, stock.ID, tenantID).First(&batch).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("stock batch not found")
+			}
+			return nil, fmt.Errorf("failed to get stock batch: %w", err)
+		}
+		if req.Quantity > batch.Quantity {
+			return nil, errors.New("insufficient batch quantity")
+		}
+		unitCost = batch.CostPrice
+	}
+
+	writeOff := models.StockWriteOff{
+		TenantModel:  models.TenantModel{TenantID: tenantID},
+		ShopID:       req.ShopID,
+		ProductID:    req.ProductID,
+		StockID:      stock.ID,
+		StockBatchID: req.StockBatchID,
+		Quantity:     req.Quantity,
+		UnitCost:     unitCost,
+		TotalCost:    utils.RoundToTwoDecimals(unitCost * float64(req.Quantity)),
+		Reason:       req.Reason,
+		Notes:        req.Notes,
+		Status:       models.StatusPending,
+		CreatedByID:  userID,
+	}
+
+	if err := s.db.Create(&writeOff).Error; err != nil {
+		return nil, fmt.Errorf("failed to create write-off: %w", err)
+	}
+
+	return s.GetWriteOffByID(ctx, writeOff.ID, tenantID)
+}
+
+// ApproveWriteOff removes the written-off quantity from stock with a write_off movement
+// and books the loss as an approved expense, all in one transaction
+func (s *WriteOffService) ApproveWriteOff(ctx context.Context, id, tenantID, userID uuid.UUID) (*WriteOffResponse, error) {
+	categoryName := s.settings.GetString(ctx, tenantID, settings.KeyWriteOffExpenseCategory)
+
+	var writeOff models.StockWriteOff
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND tenant_id = ?", id, tenantID).
+			Preload("Product").
+			First(&writeOff).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("write-off not found")
+			}
+			return fmt.Errorf("failed to get write-off: %w", err)
+		}
+		if err := approval.Decide("write-off", writeOff.Status, models.StatusApproved); err != nil {
+			return err
+		}
+
+		var stock models.Stock
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND tenant_id = ?", writeOff.StockID, tenantID).
+			First(&stock).Error; err != nil {
+			return fmt.Errorf("failed to lock stock: %w", err)
+		}
+		if writeOff.Quantity > stock.Quantity {
+			return errors.New("insufficient stock")
+		}
+
+		if writeOff.StockBatchID != nil {
+			var batch models.StockBatch
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ? AND tenant_id = ?", *writeOff.StockBatchID, tenantID).
+				First(&batch).Error; err != nil {
+				return fmt.Errorf("failed to lock stock batch: %w", err)
+			}
+			if writeOff.Quantity > batch.Quantity {
+				return errors.New("insufficient batch quantity")
+			}
+			if err := tx.Model(&batch).Update("quantity", batch.Quantity-writeOff.Quantity).Error; err != nil {
+				return fmt.Errorf("failed to update stock batch: %w", err)
+			}
+		} else {
+			// Keep the cost layers in step with the stock quantity
+			if _, err := consumeCostLayers(tx, &stock, writeOff.Quantity, writeOff.UnitCost); err != nil {
+				return err
+			}
+		}
+
+		previousQuantity, err := removeStockQuantity(tx, &stock, writeOff.Quantity)
+		if err != nil {
+			return err
+		}
+
+		history := models.StockHistory{
+			TenantModel:      models.TenantModel{TenantID: tenantID},
+			StockID:          stock.ID,
+			MovementType:     "write_off",
+			Quantity:         writeOff.Quantity,
+			PreviousQuantity: previousQuantity,
+			NewQuantity:      previousQuantity - writeOff.Quantity,
+			UnitCost:         writeOff.UnitCost,
+			TotalCost:        writeOff.TotalCost,
+			Reference:        "write_off:" + writeOff.Reason,
+			ReferenceID:      &writeOff.ID,
+			Notes:            writeOff.Notes,
+			CreatedByID:      userID,
+		}
+		if err := tx.Create(&history).Error; err != nil {
+			return fmt.Errorf("failed to create stock history: %w", err)
+		}
+
+		category, err := s.lossCategory(tx, tenantID, userID, categoryName)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		productName := ""
+		if writeOff.Product != nil {
+			productName = writeOff.Product.Name
+		}
+		expense := models.Expense{
+			TenantModel:   models.TenantModel{TenantID: tenantID},
+			CategoryID:    &category.ID,
+			ShopID:        &writeOff.ShopID,
+			ExpenseDate:   now,
+			Description:   fmt.Sprintf("Stock write-off (%s): %d x %s", writeOff.Reason, writeOff.Quantity, productName),
+			Amount:        writeOff.TotalCost,
+			PaymentMethod: writeOffPaymentMethod,
+			Notes:         writeOff.Notes,
+			Status:        models.StatusApproved,
+			ApprovedAt:    &now,
+			ApprovedByID:  &userID,
+			CreatedByID:   userID,
+		}
+		if err := tx.Create(&expense).Error; err != nil {
+			return fmt.Errorf("failed to book write-off expense: %w", err)
+		}
+
+		if err := tx.Model(&writeOff).Updates(map[string]interface{}{
+			"status":         models.StatusApproved,
+			"approved_at":    now,
+			"approved_by_id": userID,
+			"expense_id":     expense.ID,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to approve write-off: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		if approval.Replay(ctx, s.settings, tenantID, err) {
+			return s.GetWriteOffByID(ctx, id, tenantID)
+		}
+		return nil, err
+	}
+
+	s.stocks.clearStockCache(ctx, tenantID, writeOff.ShopID, writeOff.ProductID)
+
+	return s.GetWriteOffByID(ctx, id, tenantID)
+}
+
+// RejectWriteOff rejects a pending write-off under the same lock as approval; stock
+// is left unchanged
+func (s *WriteOffService) RejectWriteOff(ctx context.Context, id, tenantID, userID uuid.UUID, reason string) (*WriteOffResponse, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var writeOff models.StockWriteOff
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND tenant_id = ?", id, tenantID).
+			First(&writeOff).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("write-off not found")
+			}
+			return fmt.Errorf("failed to get write-off: %w", err)
+		}
+		if err := approval.Decide("write-off", writeOff.Status, models.StatusRejected); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&writeOff).Updates(map[string]interface{}{
+			"status":           models.StatusRejected,
+			"approved_by_id":   userID,
+			"rejection_reason": reason,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to reject write-off: %w", err)
+		}
+		return nil
+	})
+	if err != nil && !approval.Replay(ctx, s.settings, tenantID, err) {
+		return nil, err
+	}
+
+	return s.GetWriteOffByID(ctx, id, tenantID)
+}
+
+// GetWriteOffByID returns a write-off by ID
+func (s *WriteOffService) GetWriteOffByID(ctx context.Context, id, tenantID uuid.UUID) (*WriteOffResponse, error) {
+	var writeOff models.StockWriteOff
+	if err := s.db.Where("id = ? AND tenant_id = ?", id, tenantID).
+		Preload("Shop").Preload("Product").Preload("StockBatch").
+		First(&writeOff).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("write-off not found")
+		}
+		return nil, fmt.Errorf("failed to get write-off: %w", err)
+	}
+
+	return s.mapWriteOffToResponse(&writeOff), nil
+}
+
+// GetWriteOffs lists write-offs, newest first
+func (s *WriteOffService) GetWriteOffs(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, status string, limit, offset int) ([]*WriteOffResponse, int64, error) {
+	query := s.db.Model(&models.StockWriteOff{}).Where("tenant_id = ?", tenantID)
+	if shopID != nil {
+		query = query.Where("shop_id = ?", *shopID)
+	}
+	query = scope.FromContext(ctx).Apply(query, "shop_id")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count write-offs: %w", err)
+	}
+
+	var writeOffs []models.StockWriteOff
+	if err := query.Preload("Shop").Preload("Product").Preload("StockBatch").
+		Order("created_at DESC").Limit(limit).Offset(offset).
+		Find(&writeOffs).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get write-offs: %w", err)
+	}
+
+	responses := make([]*WriteOffResponse, len(writeOffs))
+	for i := range writeOffs {
+		responses[i] = s.mapWriteOffToResponse(&writeOffs[i])
+	}
+
+	return responses, total, nil
+}
+
+// GetWriteOffReport totals approved write-offs between start and end by reason
+func (s *WriteOffService) GetWriteOffReport(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, start, end time.Time) (*WriteOffReport, error) {
+	query := s.db.Model(&models.StockWriteOff{}).
+		Select("reason, COUNT(*) AS count, COALESCE(SUM(quantity), 0) AS quantity, COALESCE(SUM(total_cost), 0) AS total_cost").
+		Where("tenant_id = ? AND status = ? AND approved_at >= ? AND approved_at < ?", tenantID, models.StatusApproved, start, end)
+	if shopID != nil {
+		query = query.Where("shop_id = ?", *shopID)
+	}
+	query = scope.FromContext(ctx).Apply(query, "shop_id")
+
+	byReason := make([]*WriteOffReasonTotal, 0)
+	if err := query.Group("reason").Order("total_cost DESC").Scan(&byReason).Error; err != nil {
+		return nil, fmt.Errorf("failed to get write-off report: %w", err)
+	}
+
+	report := &WriteOffReport{
+		StartDate: start,
+		EndDate:   end,
+		ByReason:  byReason,
+	}
+	for _, total := range byReason {
+		report.TotalCount += total.Count
+		report.TotalQuantity += total.Quantity
+		report.TotalCost += total.TotalCost
+	}
+	report.TotalCost = utils.RoundToTwoDecimals(report.TotalCost)
+
+	return report, nil
+}
+
+// lossCategory returns the tenant's write-off expense category, creating it if needed
+func (s *WriteOffService) lossCategory(tx *gorm.DB, tenantID, userID uuid.UUID, name string) (*models.ExpenseCategory, error) {
+	var category models.ExpenseCategory
+	err := tx.Where("tenant_id = ? AND LOWER(name) = LOWER(?)", tenantID, name).First(&category).Error
+	if err == nil {
+		return &category, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get write-off expense category: %w", err)
+	}
+
+	category = models.ExpenseCategory{
+		TenantModel: models.TenantModel{TenantID: tenantID},
+		Name:        name,
+		Description: "Stock written off as expired, damaged or lost",
+		IsActive:    true,
+		CreatedBy:   userID,
+	}
+	if err := tx.Create(&category).Error; err != nil {
+		return nil, fmt.Errorf("failed to create write-off expense category: %w", err)
+	}
+	return &category, nil
+}
+
+func (s *WriteOffService) mapWriteOffToResponse(writeOff *models.StockWriteOff) *WriteOffResponse {
+	response := &WriteOffResponse{
+		ID:              writeOff.ID,
+		ShopID:          writeOff.ShopID,
+		ProductID:       writeOff.ProductID,
+		StockBatchID:    writeOff.StockBatchID,
+		Quantity:        writeOff.Quantity,
+		UnitCost:        writeOff.UnitCost,
+		TotalCost:       writeOff.TotalCost,
+		Reason:          writeOff.Reason,
+		Notes:           writeOff.Notes,
+		Status:          writeOff.Status,
+		ApprovedAt:      writeOff.ApprovedAt,
+		ApprovedByID:    writeOff.ApprovedByID,
+		RejectionReason: writeOff.RejectionReason,
+		ExpenseID:       writeOff.ExpenseID,
+		CreatedByID:     writeOff.CreatedByID,
+		CreatedAt:       writeOff.CreatedAt,
+	}
+	if writeOff.Shop != nil {
+		response.ShopName = writeOff.Shop.Name
+	}
+	if writeOff.Product != nil {
+		response.ProductName = writeOff.Product.Name
+	}
+	if writeOff.StockBatch != nil {
+		response.BatchNumber = writeOff.StockBatch.BatchNumber
+	}
+	return response
+}
@@ -3,10 +3,12 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"github.com/liquorpro/go-backend/internal/saas/models"
 	"github.com/liquorpro/go-backend/internal/saas/services"
 )
 
@@ -107,6 +109,79 @@ func (h *AdminHandler) UpdateSubscriptionStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "subscription status updated successfully"})
 }
 
+func (h *AdminHandler) ExtendTrial(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subscription ID"})
+		return
+	}
+
+	adminUserID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid admin user ID format"})
+		return
+	}
+
+	var req models.ExtendTrialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := h.adminService.ExtendTrial(c.Request.Context(), subscriptionID, adminUserID, &req)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			status = http.StatusNotFound
+		case strings.HasPrefix(err.Error(), "failed to"):
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subscription": subscription})
+}
+
+func (h *AdminHandler) ConvertTrial(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subscription ID"})
+		return
+	}
+
+	adminUserID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid admin user ID format"})
+		return
+	}
+
+	var req models.ConvertTrialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, payment, err := h.adminService.ConvertTrial(c.Request.Context(), subscriptionID, adminUserID, &req)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			status = http.StatusNotFound
+		case strings.HasPrefix(err.Error(), "failed to"):
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"subscription": subscription,
+		"payment":      payment,
+	})
+}
+
 func (h *AdminHandler) GetAuditLogs(c *gin.Context) {
 	// Parse pagination parameters
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
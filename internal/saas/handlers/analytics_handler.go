@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -51,6 +52,8 @@ func (h *AnalyticsHandler) GetRevenue(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid end_date format (use YYYY-MM-DD)"})
 			return
 		}
+		// end_date is inclusive
+		endDate = endDate.AddDate(0, 0, 1).Add(-time.Second)
 	} else {
 		// Default to current month
 		now := time.Now()
@@ -58,7 +61,13 @@ func (h *AnalyticsHandler) GetRevenue(c *gin.Context) {
 		endDate = startDate.AddDate(0, 1, 0).Add(-time.Second)
 	}
 
-	analytics, err := h.analyticsService.GetRevenueAnalytics(c.Request.Context(), period, startDate, endDate)
+	groupBy := c.DefaultQuery("group_by", services.RevenueGroupDay)
+	if err := services.ValidateRevenueRange(groupBy, startDate, endDate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	analytics, err := h.analyticsService.GetRevenueAnalytics(c.Request.Context(), period, groupBy, startDate, endDate)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -89,6 +98,22 @@ func (h *AnalyticsHandler) GetTenantMetrics(c *gin.Context) {
 	c.JSON(http.StatusOK, metrics)
 }
 
+func (h *AnalyticsHandler) GetCohortRetention(c *gin.Context) {
+	months, err := strconv.Atoi(c.DefaultQuery("months", "12"))
+	if err != nil || months < 1 || months > services.MaxCohortMonths {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "months must be a number between 1 and " + strconv.Itoa(services.MaxCohortMonths)})
+		return
+	}
+
+	retention, err := h.analyticsService.GetCohortRetention(c.Request.Context(), months)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, retention)
+}
+
 func (h *AnalyticsHandler) GetRevenueChart(c *gin.Context) {
 	// Parse chart parameters
 	chartType := c.DefaultQuery("type", "daily") // daily, weekly, monthly
@@ -104,8 +129,8 @@ func (h *AnalyticsHandler) GetRevenueChart(c *gin.Context) {
 			period = "30"
 		}
 		days := 30 // default
-		if p, err := time.ParseDuration(period + "h"); err == nil {
-			days = int(p.Hours() / 24)
+		if p, err := strconv.Atoi(period); err == nil && p > 0 {
+			days = p
 		}
 		startDate = now.AddDate(0, 0, -days)
 		endDate = now
@@ -124,7 +149,18 @@ func (h *AnalyticsHandler) GetRevenueChart(c *gin.Context) {
 		return
 	}
 
-	analytics, err := h.analyticsService.GetRevenueAnalytics(c.Request.Context(), chartType, startDate, endDate)
+	// Each chart point covers one day, week or month
+	groupBy := map[string]string{
+		"daily":   services.RevenueGroupDay,
+		"weekly":  services.RevenueGroupWeek,
+		"monthly": services.RevenueGroupMonth,
+	}[chartType]
+	if err := services.ValidateRevenueRange(groupBy, startDate, endDate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	analytics, err := h.analyticsService.GetRevenueAnalytics(c.Request.Context(), chartType, groupBy, startDate, endDate)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -278,7 +314,7 @@ func (h *AnalyticsHandler) GetTopPerformers(c *gin.Context) {
 		startDate := now.AddDate(0, -1, 0) // Last month
 		endDate := now
 
-		analytics, err := h.analyticsService.GetRevenueAnalytics(c.Request.Context(), "monthly", startDate, endDate)
+		analytics, err := h.analyticsService.GetRevenueAnalytics(c.Request.Context(), "monthly", services.RevenueGroupDay, startDate, endDate)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -339,7 +375,7 @@ func (h *AnalyticsHandler) ExportAnalytics(c *gin.Context) {
 		now := time.Now()
 		startDate := now.AddDate(0, -1, 0)
 		endDate := now
-		data, err = h.analyticsService.GetRevenueAnalytics(c.Request.Context(), "monthly", startDate, endDate)
+		data, err = h.analyticsService.GetRevenueAnalytics(c.Request.Context(), "monthly", services.RevenueGroupDay, startDate, endDate)
 		filename = "revenue_analytics"
 	case "subscriptions":
 		data, err = h.analyticsService.GetSubscriptionMetrics(c.Request.Context(), "current")
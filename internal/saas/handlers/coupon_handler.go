@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/liquorpro/go-backend/internal/saas/models"
+	"github.com/liquorpro/go-backend/internal/saas/services"
+)
+
+type CouponHandler struct {
+	couponService *services.CouponService
+}
+
+func NewCouponHandler(couponService *services.CouponService) *CouponHandler {
+	return &CouponHandler{
+		couponService: couponService,
+	}
+}
+
+// Admin endpoints - require super admin authentication
+
+func (h *CouponHandler) GetCoupons(c *gin.Context) {
+	coupons, err := h.couponService.GetCoupons(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"coupons": coupons})
+}
+
+func (h *CouponHandler) CreateCoupon(c *gin.Context) {
+	var req models.CouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	coupon, err := h.couponService.CreateCoupon(c.Request.Context(), &req)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case strings.HasPrefix(err.Error(), "failed to"):
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, coupon)
+}
+
+func (h *CouponHandler) GetCoupon(c *gin.Context) {
+	couponID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid coupon ID"})
+		return
+	}
+
+	coupon, redemptions, err := h.couponService.GetCoupon(c.Request.Context(), couponID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"coupon":      coupon,
+		"redemptions": redemptions,
+	})
+}
+
+func (h *CouponHandler) UpdateCoupon(c *gin.Context) {
+	couponID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid coupon ID"})
+		return
+	}
+
+	var req models.CouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	coupon, err := h.couponService.UpdateCoupon(c.Request.Context(), couponID, &req)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			status = http.StatusNotFound
+		case strings.HasPrefix(err.Error(), "failed to"):
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, coupon)
+}
+
+func (h *CouponHandler) DeleteCoupon(c *gin.Context) {
+	couponID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid coupon ID"})
+		return
+	}
+
+	if err := h.couponService.DeleteCoupon(c.Request.Context(), couponID); err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "coupon deleted successfully"})
+}
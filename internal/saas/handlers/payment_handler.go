@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -14,11 +19,13 @@ import (
 
 type PaymentHandler struct {
 	paymentService *services.PaymentService
+	invoiceService *services.InvoiceService
 }
 
-func NewPaymentHandler(paymentService *services.PaymentService) *PaymentHandler {
+func NewPaymentHandler(paymentService *services.PaymentService, invoiceService *services.InvoiceService) *PaymentHandler {
 	return &PaymentHandler{
 		paymentService: paymentService,
+		invoiceService: invoiceService,
 	}
 }
 
@@ -31,7 +38,14 @@ func (h *PaymentHandler) CreatePayment(c *gin.Context) {
 
 	payment, err := h.paymentService.CreatePayment(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		status := http.StatusBadRequest
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			status = http.StatusNotFound
+		case strings.HasPrefix(err.Error(), "failed to"):
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -102,23 +116,30 @@ func (h *PaymentHandler) RefundPayment(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		Amount float64 `json:"amount" binding:"required,min=0"`
-		Reason string  `json:"reason"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
+	// An empty body refunds the full remaining amount
+	var req models.RefundPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	payment, err := h.paymentService.RefundPayment(c.Request.Context(), paymentID, req.Amount, req.Reason)
+	payment, refund, err := h.paymentService.RefundPayment(c.Request.Context(), paymentID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		status := http.StatusBadRequest
+		switch {
+		case strings.HasSuffix(err.Error(), "not found"):
+			status = http.StatusNotFound
+		case strings.HasPrefix(err.Error(), "failed to"):
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, payment)
+	c.JSON(http.StatusOK, gin.H{
+		"payment": payment,
+		"refund":  refund,
+	})
 }
 
 func (h *PaymentHandler) UpdatePaymentStatus(c *gin.Context) {
@@ -148,44 +169,63 @@ func (h *PaymentHandler) UpdatePaymentStatus(c *gin.Context) {
 }
 
 // Webhook handler - no authentication required
+// maxWebhookBodySize bounds the webhook payloads we are willing to read
+const maxWebhookBodySize = 1 << 20
+
 func (h *PaymentHandler) HandleRazorpayWebhook(c *gin.Context) {
-	// Read the entire request body
-	body, err := io.ReadAll(c.Request.Body)
+	// Read the raw body; the signature is computed over these exact bytes
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBodySize))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
 		return
 	}
 
-	// Get event type from headers
-	eventType := c.GetHeader("X-Razorpay-Event-Id")
-	if eventType == "" {
-		// Try to get from X-Razorpay-Event header
-		eventType = c.GetHeader("X-Razorpay-Event")
-	}
-
-	// Get signature for verification
 	signature := c.GetHeader("X-Razorpay-Signature")
 	if signature == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "missing signature"})
 		return
 	}
 
-	// TODO: Verify webhook signature before processing
-	// For now, we'll process all webhooks
-
-	// Parse event type from body if not in headers
-	if eventType == "" {
-		eventType = "payment.captured" // default for testing
+	if err := h.paymentService.VerifyWebhook(body, signature); err != nil {
+		if errors.Is(err, services.ErrWebhookSecretMissing) {
+			log.Printf("Razorpay webhook rejected: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Razorpay webhook rejected from %s: %v", c.ClientIP(), err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	// Process the webhook
-	err = h.paymentService.HandleWebhook(c.Request.Context(), eventType, body)
+	eventID := c.GetHeader("X-Razorpay-Event-Id")
+	event, err := h.paymentService.RecordWebhook(c.Request.Context(), eventID, body)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Printf("Razorpay webhook %s not recorded: %v", eventID, err)
+		status := http.StatusInternalServerError
+		if !strings.HasPrefix(err.Error(), "failed to") {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Razorpay expects a 200 OK response
+	// Already handled; acknowledge so Razorpay stops retrying
+	if event == nil {
+		log.Printf("Razorpay webhook %s already processed, skipping", eventID)
+		c.JSON(http.StatusOK, gin.H{"status": "duplicate"})
+		return
+	}
+
+	log.Printf("Razorpay webhook %s (%s) received", event.EventID, event.EventType)
+
+	// The event is stored, so it is processed after Razorpay gets its 200; a failed
+	// attempt is kept as failed and retried by the webhook retry worker
+	go func() {
+		if err := h.paymentService.ProcessWebhook(context.Background(), event); err != nil {
+			log.Printf("Razorpay webhook %s failed: %v", event.EventID, err)
+		}
+	}()
+
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
@@ -255,17 +295,24 @@ func (h *PaymentHandler) DownloadInvoice(c *gin.Context) {
 		return
 	}
 
-	invoice, err := h.paymentService.GetInvoice(c.Request.Context(), invoiceID)
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tenant ID"})
 		return
 	}
 
-	// TODO: Generate PDF invoice
-	// For now, return invoice data as JSON with appropriate headers
-	c.Header("Content-Type", "application/json")
-	c.Header("Content-Disposition", "attachment; filename=invoice_"+invoice.InvoiceNumber+".json")
-	c.JSON(http.StatusOK, invoice)
+	content, filename, err := h.invoiceService.RenderInvoicePDF(c.Request.Context(), invoiceID, tenantID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == "invoice not found" {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "application/pdf", content)
 }
 
 // Utility endpoints
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -76,7 +77,12 @@ func (h *PlanHandler) CreatePlan(c *gin.Context) {
 
 	plan, err := h.planService.CreatePlan(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		status := http.StatusBadRequest
+		switch {
+		case strings.HasPrefix(err.Error(), "failed to"):
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -114,7 +120,14 @@ func (h *PlanHandler) UpdatePlan(c *gin.Context) {
 
 	plan, err := h.planService.UpdatePlan(c.Request.Context(), planID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		status := http.StatusBadRequest
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			status = http.StatusNotFound
+		case strings.HasPrefix(err.Error(), "failed to"):
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -30,7 +32,14 @@ func (h *SubscriptionHandler) CreateSubscription(c *gin.Context) {
 
 	subscription, err := h.subscriptionService.CreateSubscription(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		status := http.StatusBadRequest
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			status = http.StatusNotFound
+		case strings.HasPrefix(err.Error(), "failed to"):
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -95,66 +104,71 @@ func (h *SubscriptionHandler) CancelSubscription(c *gin.Context) {
 		return
 	}
 
-	err = h.subscriptionService.CancelSubscription(c.Request.Context(), subscriptionID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	// The body is optional; without it the tenant's cancellation policy applies
+	var req models.CancelSubscriptionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "subscription cancelled successfully"})
-}
-
-func (h *SubscriptionHandler) UpgradeSubscription(c *gin.Context) {
-	subscriptionID, err := uuid.Parse(c.Param("id"))
+	result, err := h.subscriptionService.CancelSubscription(c.Request.Context(), subscriptionID, &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subscription ID"})
+		if strings.HasPrefix(err.Error(), "subscription is already") {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	var req struct {
-		NewPlanID uuid.UUID `json:"new_plan_id" binding:"required"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	message := "subscription cancelled successfully"
+	if result.Subscription.CancelAtPeriodEnd {
+		message = "subscription will be cancelled at the end of the current period"
 	}
 
-	subscription, err := h.subscriptionService.UpgradeSubscription(c.Request.Context(), subscriptionID, req.NewPlanID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":      message,
+		"cancellation": result,
+	})
+}
 
-	c.JSON(http.StatusOK, subscription)
+func (h *SubscriptionHandler) UpgradeSubscription(c *gin.Context) {
+	h.changePlan(c, h.subscriptionService.UpgradeSubscription)
 }
 
 func (h *SubscriptionHandler) DowngradeSubscription(c *gin.Context) {
-	// For now, downgrade works similar to upgrade but with validation
+	h.changePlan(c, h.subscriptionService.DowngradeSubscription)
+}
+
+func (h *SubscriptionHandler) changePlan(c *gin.Context, change func(context.Context, uuid.UUID, *models.ChangePlanRequest) (*models.PlanChangeResponse, error)) {
 	subscriptionID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subscription ID"})
 		return
 	}
 
-	var req struct {
-		NewPlanID uuid.UUID `json:"new_plan_id" binding:"required"`
-		Reason    string    `json:"reason"`
-	}
-
+	var req models.ChangePlanRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// TODO: Implement proper downgrade logic with plan validation
-	subscription, err := h.subscriptionService.UpgradeSubscription(c.Request.Context(), subscriptionID, req.NewPlanID)
+	result, err := change(c.Request.Context(), subscriptionID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		status := http.StatusBadRequest
+		switch {
+		case strings.HasSuffix(err.Error(), "not found"):
+			status = http.StatusNotFound
+		case strings.HasPrefix(err.Error(), "failed to"):
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, subscription)
+	c.JSON(http.StatusOK, result)
 }
 
 func (h *SubscriptionHandler) GetUsage(c *gin.Context) {
@@ -17,9 +17,9 @@ type PricingPlan struct {
 	Currency          string         `json:"currency" gorm:"not null;default:'INR'"`
 	BillingCycle      string         `json:"billing_cycle" gorm:"not null;default:'monthly'"` // monthly, yearly
 	TrialDays         int            `json:"trial_days" gorm:"default:60"`                    // 2 months = 60 days
-	MaxLocations      int            `json:"max_locations" gorm:"default:1"`                  // -1 for unlimited
-	MaxUsers          int            `json:"max_users" gorm:"default:10"`                     // -1 for unlimited
-	MaxProducts       int            `json:"max_products" gorm:"default:1000"`                // -1 for unlimited
+	MaxLocations      int            `json:"max_locations" gorm:"default:1"`                  // 0 for unlimited
+	MaxUsers          int            `json:"max_users" gorm:"default:10"`                     // 0 for unlimited
+	MaxProducts       int            `json:"max_products" gorm:"default:1000"`                // 0 for unlimited
 	Features          []string       `json:"features" gorm:"serializer:json"`
 	AIFeatures        []string       `json:"ai_features" gorm:"serializer:json"`
 	Popular           bool           `json:"popular" gorm:"default:false"`
@@ -41,12 +41,14 @@ type Subscription struct {
 	ID                   uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	TenantID             uuid.UUID       `json:"tenant_id" gorm:"type:uuid;not null;index"`
 	PlanID               uuid.UUID       `json:"plan_id" gorm:"type:uuid;not null"`
-	Status               string          `json:"status" gorm:"not null;default:'trial'"` // trial, active, suspended, cancelled, expired
+	Status               string          `json:"status" gorm:"not null;default:'trial'"` // trial, active, past_due, suspended, cancelled, expired
 	CurrentPeriodStart   time.Time       `json:"current_period_start"`
 	CurrentPeriodEnd     time.Time       `json:"current_period_end"`
 	TrialStart           *time.Time      `json:"trial_start"`
 	TrialEnd             *time.Time      `json:"trial_end"`
 	CancelledAt          *time.Time      `json:"cancelled_at"`
+	CancelAtPeriodEnd    bool            `json:"cancel_at_period_end" gorm:"default:false"`
+	CancellationEffectiveAt *time.Time   `json:"cancellation_effective_at"` // when access ends; churn is counted on this date
 	EndedAt              *time.Time      `json:"ended_at"`
 	RazorpayCustomerID   string          `json:"razorpay_customer_id"`
 	RazorpaySubscriptionID string        `json:"razorpay_subscription_id"`
@@ -55,6 +57,13 @@ type Subscription struct {
 	Amount               float64         `json:"amount" gorm:"not null"`
 	Currency             string          `json:"currency" gorm:"not null;default:'INR'"`
 	NextBillingDate     *time.Time      `json:"next_billing_date"`
+	PendingPlanID        *uuid.UUID      `json:"pending_plan_id" gorm:"type:uuid"` // plan a scheduled downgrade switches to
+	PendingPlanAt        *time.Time      `json:"pending_plan_at"`                  // when the scheduled downgrade takes effect
+	CouponID             *uuid.UUID      `json:"coupon_id" gorm:"type:uuid"`       // coupon redeemed when subscribing
+	Discount             float64         `json:"discount" gorm:"default:0"`        // coupon discount taken off Amount each period while it lasts
+	ConvertedAt          *time.Time      `json:"converted_at"`                     // when the trial became a paid subscription
+	ConvertedManually    bool            `json:"converted_manually" gorm:"default:false"` // converted by an admin rather than a gateway payment
+	PastDueAt            *time.Time      `json:"past_due_at"`                      // when every retry of a failed payment had failed; cancelled after the grace period
 	CreatedAt            time.Time       `json:"created_at"`
 	UpdatedAt            time.Time       `json:"updated_at"`
 	DeletedAt            gorm.DeletedAt  `json:"deleted_at" gorm:"index"`
@@ -66,6 +75,54 @@ type Subscription struct {
 	UsageRecords []UsageRecord  `json:"usage_records,omitempty" gorm:"foreignKey:SubscriptionID"`
 }
 
+// Plan change directions and states
+const (
+	PlanChangeUpgrade   = "upgrade"
+	PlanChangeDowngrade = "downgrade"
+
+	PlanChangeScheduled = "scheduled"
+	PlanChangeApplied   = "applied"
+	PlanChangeCancelled = "cancelled"
+)
+
+// PlanChange records a subscription moving to another plan. Mid-cycle changes carry
+// the proration: a credit for the unused part of the old plan and a charge for the
+// rest of the period on the new one.
+type PlanChange struct {
+	ID             uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SubscriptionID uuid.UUID      `json:"subscription_id" gorm:"type:uuid;not null;index"`
+	TenantID       uuid.UUID      `json:"tenant_id" gorm:"type:uuid;not null"`
+	FromPlanID     uuid.UUID      `json:"from_plan_id" gorm:"type:uuid;not null"`
+	ToPlanID       uuid.UUID      `json:"to_plan_id" gorm:"type:uuid;not null"`
+	Direction      string         `json:"direction" gorm:"not null"` // upgrade, downgrade
+	Status         string         `json:"status" gorm:"not null"`    // scheduled, applied, cancelled
+	OldAmount      float64        `json:"old_amount"`
+	NewAmount      float64        `json:"new_amount"`
+	MRRDelta       float64        `json:"mrr_delta"` // change in monthly recurring revenue
+	Credit         float64        `json:"credit"`
+	Charge         float64        `json:"charge"`
+	NetAmount      float64        `json:"net_amount"` // charge less credit; negative is owed to the tenant
+	InvoiceID      *uuid.UUID     `json:"invoice_id" gorm:"type:uuid"`
+	PaymentID      *uuid.UUID     `json:"payment_id" gorm:"type:uuid"`
+	Reason         string         `json:"reason"`
+	EffectiveAt    time.Time      `json:"effective_at" gorm:"index"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// Payment statuses set by refunds. A refund leaves the original payment partially
+// refunded or refunded and records the money returned as a separate payment with
+// status refund and a negative amount, so revenue sums net refunds out.
+const (
+	PaymentPartiallyRefunded = "partially_refunded"
+	PaymentRefunded          = "refunded"
+	PaymentRefund            = "refund"
+)
+
+// RevenuePaymentStatuses are the payment statuses that count towards revenue
+var RevenuePaymentStatuses = []string{"succeeded", PaymentPartiallyRefunded, PaymentRefunded, PaymentRefund}
+
 // Payment represents a payment transaction
 type Payment struct {
 	ID                   uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
@@ -73,7 +130,7 @@ type Payment struct {
 	InvoiceID            *uuid.UUID     `json:"invoice_id" gorm:"type:uuid"`
 	Amount               float64        `json:"amount" gorm:"not null"`
 	Currency             string         `json:"currency" gorm:"not null;default:'INR'"`
-	Status               string         `json:"status" gorm:"not null"` // pending, processing, succeeded, failed, cancelled, refunded
+	Status               string         `json:"status" gorm:"not null"` // pending, processing, succeeded, failed, cancelled, partially_refunded, refunded, refund
 	PaymentMethod        string         `json:"payment_method"`         // card, netbanking, wallet, upi
 	RazorpayPaymentID    string         `json:"razorpay_payment_id"`
 	RazorpayOrderID      string         `json:"razorpay_order_id"`
@@ -81,8 +138,14 @@ type Payment struct {
 	FailureReason        string         `json:"failure_reason"`
 	ProcessedAt          *time.Time     `json:"processed_at"`
 	RefundedAt           *time.Time     `json:"refunded_at"`
-	RefundAmount         float64        `json:"refund_amount" gorm:"default:0"`
+	RefundedAmount       float64        `json:"refunded_amount" gorm:"column:refund_amount;default:0"` // total refunded so far
 	RefundReason         string         `json:"refund_reason"`
+	RazorpayRefundID     string         `json:"razorpay_refund_id"`                                    // gateway ID of the latest refund
+	RefundOfID           *uuid.UUID     `json:"refund_of_id,omitempty" gorm:"type:uuid;index"`         // the refunded payment, on refund entries
+	FailedAt             *time.Time     `json:"failed_at,omitempty"`                                   // when the payment failed; retries are scheduled from here
+	RetryCount           int            `json:"retry_count" gorm:"default:0"`                          // dunning retries made for a failed payment
+	NextRetryAt          *time.Time     `json:"next_retry_at,omitempty" gorm:"index"`                  // when the next dunning retry is due; nil when none is scheduled
+	RetryOfID            *uuid.UUID     `json:"retry_of_id,omitempty" gorm:"type:uuid;index"`          // the failed payment, on dunning retries
 	Description          string         `json:"description"`
 	CreatedAt            time.Time      `json:"created_at"`
 	UpdatedAt            time.Time      `json:"updated_at"`
@@ -93,6 +156,29 @@ type Payment struct {
 	Invoice      *Invoice     `json:"invoice,omitempty" gorm:"foreignKey:InvoiceID"`
 }
 
+// Dunning attempt outcomes
+const (
+	DunningAttemptPending   = "pending"
+	DunningAttemptSucceeded = "succeeded"
+	DunningAttemptFailed    = "failed"
+)
+
+// DunningAttempt records one retry of a failed subscription payment
+type DunningAttempt struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	PaymentID      uuid.UUID  `json:"payment_id" gorm:"type:uuid;not null;index"` // the failed payment being retried
+	RetryPaymentID *uuid.UUID `json:"retry_payment_id" gorm:"type:uuid;index"`    // the charge made for this attempt, if the gateway took one
+	SubscriptionID uuid.UUID  `json:"subscription_id" gorm:"type:uuid;not null;index"`
+	TenantID       uuid.UUID  `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	Attempt        int        `json:"attempt" gorm:"not null"` // 1 for the first retry
+	Status         string     `json:"status" gorm:"not null"`  // pending, succeeded, failed
+	FailureReason  string     `json:"failure_reason"`
+	AttemptedAt    time.Time  `json:"attempted_at"`
+	CompletedAt    *time.Time `json:"completed_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
 // Invoice represents an invoice for a subscription
 type Invoice struct {
 	ID              uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
@@ -122,6 +208,53 @@ type Invoice struct {
 	Payments     []Payment    `json:"payments,omitempty" gorm:"foreignKey:InvoiceID"`
 }
 
+// Coupon discount types
+const (
+	CouponPercentage = "percentage"
+	CouponFixed      = "fixed"
+)
+
+// Coupon is a discount code for subscriptions. A percentage coupon takes Value
+// percent off each billed period; a fixed coupon takes Value off in its currency.
+// DurationCycles limits the discount to a subscription's first paid periods; zero
+// keeps it for as long as the subscription lasts.
+type Coupon struct {
+	ID              uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Code            string         `json:"code" gorm:"not null;uniqueIndex"` // stored upper case
+	Description     string         `json:"description"`
+	Type            string         `json:"type" gorm:"not null"` // percentage, fixed
+	Value           float64        `json:"value" gorm:"not null"`
+	Currency        string         `json:"currency" gorm:"not null;default:'INR'"` // fixed coupons only apply to subscriptions in this currency
+	MaxRedemptions  int            `json:"max_redemptions" gorm:"default:0"`       // 0 for unlimited
+	Redemptions     int            `json:"redemptions" gorm:"default:0"`
+	ExpiresAt       *time.Time     `json:"expires_at"`
+	PlanIDs         []uuid.UUID    `json:"plan_ids" gorm:"serializer:json"` // plans the coupon applies to; empty for every plan
+	DurationCycles  int            `json:"duration_cycles" gorm:"default:0"`
+	RazorpayOfferID string         `json:"razorpay_offer_id"` // gateway offer that discounts the recurring charges
+	Active          bool           `json:"active" gorm:"default:true"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// CouponRedemption records which subscription used which coupon, the discount it
+// was given and how many paid periods have had it
+type CouponRedemption struct {
+	ID             uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CouponID       uuid.UUID      `json:"coupon_id" gorm:"type:uuid;not null;index"`
+	SubscriptionID uuid.UUID      `json:"subscription_id" gorm:"type:uuid;not null;uniqueIndex"`
+	TenantID       uuid.UUID      `json:"tenant_id" gorm:"type:uuid;not null"`
+	Discount       float64        `json:"discount"` // per period
+	CyclesApplied  int            `json:"cycles_applied" gorm:"default:0"`
+	EndedAt        *time.Time     `json:"ended_at"` // when the discount stopped applying
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	// Relations
+	Coupon *Coupon `json:"coupon,omitempty" gorm:"foreignKey:CouponID"`
+}
+
 // UsageRecord tracks usage metrics for each subscription
 type UsageRecord struct {
 	ID             uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
@@ -200,6 +333,7 @@ type CreateSubscriptionRequest struct {
 	PlanID       uuid.UUID `json:"plan_id" binding:"required"`
 	BillingCycle string    `json:"billing_cycle" binding:"required,oneof=monthly yearly"`
 	AutoRenew    bool      `json:"auto_renew"`
+	CouponCode   string    `json:"coupon_code"`
 }
 
 type UpdateSubscriptionRequest struct {
@@ -207,6 +341,46 @@ type UpdateSubscriptionRequest struct {
 	Status    string `json:"status,omitempty" binding:"omitempty,oneof=active suspended cancelled"`
 }
 
+// Subscription cancellation modes
+const (
+	CancellationModeImmediate   = "immediate"
+	CancellationModeEndOfPeriod = "end_of_period"
+)
+
+type CancelSubscriptionRequest struct {
+	Mode          string `json:"mode" binding:"omitempty,oneof=immediate end_of_period"` // empty uses the tenant's cancellation policy
+	ProrateRefund *bool  `json:"prorate_refund"`                                          // immediate mode only; nil uses the tenant's policy
+	Reason        string `json:"reason"`
+}
+
+type RefundPaymentRequest struct {
+	Amount float64 `json:"amount" binding:"omitempty,gt=0"` // omitted refunds everything not yet refunded
+	Reason string  `json:"reason"`
+}
+
+type ExtendTrialRequest struct {
+	Days   int    `json:"days" binding:"required,min=1,max=90"`
+	Reason string `json:"reason" binding:"required"`
+}
+
+type ConvertTrialRequest struct {
+	Amount        float64 `json:"amount" binding:"omitempty,gt=0"` // omitted charges the subscription's amount
+	PaymentMethod string  `json:"payment_method"`                  // how the first payment was received; defaults to manual
+	Reference     string  `json:"reference"`                       // bank transfer, cheque or receipt reference
+	Reason        string  `json:"reason" binding:"required"`
+}
+
+type ChangePlanRequest struct {
+	NewPlanID   uuid.UUID `json:"new_plan_id" binding:"required"`
+	AtPeriodEnd bool      `json:"at_period_end"` // downgrades only; switch plans when the current period ends
+	Reason      string    `json:"reason"`
+}
+
+type PlanChangeResponse struct {
+	Subscription *SubscriptionResponse `json:"subscription"`
+	Change       *PlanChange           `json:"change"`
+}
+
 type CreatePlanRequest struct {
 	Name           string   `json:"name" binding:"required"`
 	DisplayName    string   `json:"display_name" binding:"required"`
@@ -215,9 +389,9 @@ type CreatePlanRequest struct {
 	Currency       string   `json:"currency"`
 	BillingCycle   string   `json:"billing_cycle" binding:"required,oneof=monthly yearly"`
 	TrialDays      int      `json:"trial_days"`
-	MaxLocations   int      `json:"max_locations"`
-	MaxUsers       int      `json:"max_users"`
-	MaxProducts    int      `json:"max_products"`
+	MaxLocations   int      `json:"max_locations" binding:"min=0"` // 0 for unlimited
+	MaxUsers       int      `json:"max_users" binding:"min=0"`     // 0 for unlimited
+	MaxProducts    int      `json:"max_products" binding:"min=0"`  // 0 for unlimited
 	Features       []string `json:"features"`
 	AIFeatures     []string `json:"ai_features"`
 	Popular        bool     `json:"popular"`
@@ -235,6 +409,20 @@ type CreatePaymentRequest struct {
 	Description    string    `json:"description"`
 }
 
+type CouponRequest struct {
+	Code            string      `json:"code" binding:"required,max=50"`
+	Description     string      `json:"description"`
+	Type            string      `json:"type" binding:"required,oneof=percentage fixed"`
+	Value           float64     `json:"value" binding:"required,gt=0"`
+	Currency        string      `json:"currency"`
+	MaxRedemptions  int         `json:"max_redemptions" binding:"min=0"` // 0 for unlimited
+	ExpiresAt       *time.Time  `json:"expires_at"`
+	PlanIDs         []uuid.UUID `json:"plan_ids"`
+	DurationCycles  int         `json:"duration_cycles" binding:"min=0"` // 0 for every period
+	RazorpayOfferID string      `json:"razorpay_offer_id"`
+	Active          *bool       `json:"active"`
+}
+
 type RazorpayWebhookPayload struct {
 	Event   string `json:"event"`
 	Payload struct {
@@ -264,20 +452,40 @@ type SubscriptionResponse struct {
 	Amount             float64        `json:"amount"`
 	Currency           string         `json:"currency"`
 	NextBillingDate    *time.Time     `json:"next_billing_date"`
+	PendingPlanID      *uuid.UUID     `json:"pending_plan_id,omitempty"`
+	PendingPlanAt      *time.Time     `json:"pending_plan_at,omitempty"`
+	CouponID           *uuid.UUID     `json:"coupon_id,omitempty"`
+	Discount           float64        `json:"discount"`
+	CancelAtPeriodEnd  bool           `json:"cancel_at_period_end"`
+	CancelledAt        *time.Time     `json:"cancelled_at"`
+	CancellationEffectiveAt *time.Time `json:"cancellation_effective_at"`
+	EndedAt            *time.Time     `json:"ended_at"`
 	Usage              *UsageRecord   `json:"usage,omitempty"`
 	CreatedAt          time.Time      `json:"created_at"`
 	UpdatedAt          time.Time      `json:"updated_at"`
 }
 
+type CancelSubscriptionResponse struct {
+	Subscription    *SubscriptionResponse `json:"subscription"`
+	Mode            string                `json:"mode"`
+	EffectiveAt     time.Time             `json:"effective_at"`
+	RefundAmount    float64               `json:"refund_amount"`
+	RefundPaymentID *uuid.UUID            `json:"refund_payment_id,omitempty"`
+}
+
 type DashboardMetrics struct {
 	TotalSubscriptions    int                    `json:"total_subscriptions"`
 	ActiveSubscriptions   int                    `json:"active_subscriptions"`
 	TrialSubscriptions    int                    `json:"trial_subscriptions"`
+	Currency              string                 `json:"currency"` // base currency every amount is converted to
 	TotalRevenue          float64                `json:"total_revenue"`
 	MonthlyRevenue        float64                `json:"monthly_revenue"`
+	RevenueByCurrency     map[string]float64     `json:"revenue_by_currency"` // unconverted, including currencies without a configured rate
 	TotalTenants          int                    `json:"total_tenants"`
 	NewTenants            int                    `json:"new_tenants"`
 	ChurnRate             float64                `json:"churn_rate"`
+	ExpansionMRR          float64                `json:"expansion_mrr"`   // MRR added by upgrades this month
+	ContractionMRR        float64                `json:"contraction_mrr"` // MRR lost to downgrades this month
 	PlanDistribution      map[string]int         `json:"plan_distribution"`
 	RevenueByPlan         map[string]float64     `json:"revenue_by_plan"`
 	MonthlyGrowth         map[string]float64     `json:"monthly_growth"`
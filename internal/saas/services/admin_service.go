@@ -109,12 +109,18 @@ func (s *AdminService) UpdateSubscriptionStatus(ctx context.Context, subscriptio
 	case "cancelled":
 		now := time.Now()
 		subscription.CancelledAt = &now
+		subscription.CancellationEffectiveAt = &now
+		subscription.EndedAt = &now
+		subscription.CancelAtPeriodEnd = false
 		subscription.AutoRenew = false
 	case "suspended":
 		// Keep existing fields
 	case "active":
-		// Clear cancelled date if reactivating
+		// Clear cancellation details if reactivating
 		subscription.CancelledAt = nil
+		subscription.CancellationEffectiveAt = nil
+		subscription.EndedAt = nil
+		subscription.CancelAtPeriodEnd = false
 	}
 
 	// Update subscription
@@ -10,22 +10,26 @@ import (
 
 	"github.com/liquorpro/go-backend/internal/saas/models"
 	"github.com/liquorpro/go-backend/pkg/shared/config"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
 )
 
 type AnalyticsService struct {
-	db     *gorm.DB
-	config *config.Config
+	db       *gorm.DB
+	config   *config.Config
+	currency *currencyConverter
 }
 
 func NewAnalyticsService(db *gorm.DB, cfg *config.Config) *AnalyticsService {
 	return &AnalyticsService{
-		db:     db,
-		config: cfg,
+		db:       db,
+		config:   cfg,
+		currency: newCurrencyConverter(cfg.Billing),
 	}
 }
 
 func (s *AnalyticsService) GetDashboardMetrics(ctx context.Context) (*models.DashboardMetrics, error) {
 	metrics := &models.DashboardMetrics{
+		Currency:         s.currency.base,
 		PlanDistribution: make(map[string]int),
 		RevenueByPlan:    make(map[string]float64),
 		MonthlyGrowth:    make(map[string]float64),
@@ -33,7 +37,7 @@ func (s *AnalyticsService) GetDashboardMetrics(ctx context.Context) (*models.Das
 
 	// Total subscriptions
 	var totalSubs int64
-	if err := s.db.Model(&models.Subscription{}).Count(&totalSubs); err != nil {
+	if err := s.db.Model(&models.Subscription{}).Count(&totalSubs).Error; err != nil {
 		return nil, fmt.Errorf("failed to get total subscriptions: %w", err)
 	}
 	metrics.TotalSubscriptions = int(totalSubs)
@@ -42,7 +46,7 @@ func (s *AnalyticsService) GetDashboardMetrics(ctx context.Context) (*models.Das
 	var activeSubs int64
 	if err := s.db.Model(&models.Subscription{}).
 		Where("status = 'active'").
-		Count(&activeSubs); err != nil {
+		Count(&activeSubs).Error; err != nil {
 		return nil, fmt.Errorf("failed to get active subscriptions: %w", err)
 	}
 	metrics.ActiveSubscriptions = int(activeSubs)
@@ -51,7 +55,7 @@ func (s *AnalyticsService) GetDashboardMetrics(ctx context.Context) (*models.Das
 	var trialSubs int64
 	if err := s.db.Model(&models.Subscription{}).
 		Where("status = 'trial'").
-		Count(&trialSubs); err != nil {
+		Count(&trialSubs).Error; err != nil {
 		return nil, fmt.Errorf("failed to get trial subscriptions: %w", err)
 	}
 	metrics.TrialSubscriptions = int(trialSubs)
@@ -59,19 +63,25 @@ func (s *AnalyticsService) GetDashboardMetrics(ctx context.Context) (*models.Das
 	// Total revenue
 	var totalRevenue float64
 	if err := s.db.Model(&models.Payment{}).
-		Where("status = 'succeeded'").
-		Select("COALESCE(SUM(amount), 0)").
+		Where("status IN ?", models.RevenuePaymentStatuses).
+		Select("COALESCE(SUM(" + s.paymentAmountSQL() + "), 0)").
 		Scan(&totalRevenue).Error; err != nil {
 		return nil, fmt.Errorf("failed to get total revenue: %w", err)
 	}
 	metrics.TotalRevenue = totalRevenue
 
+	revenueByCurrency, err := s.getRevenueByCurrency(s.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revenue by currency: %w", err)
+	}
+	metrics.RevenueByCurrency = revenueByCurrency
+
 	// Monthly revenue (current month)
 	currentMonth := time.Now().Truncate(24 * time.Hour).AddDate(0, 0, -time.Now().Day()+1)
 	var monthlyRevenue float64
 	if err := s.db.Model(&models.Payment{}).
-		Where("status = 'succeeded' AND created_at >= ?", currentMonth).
-		Select("COALESCE(SUM(amount), 0)").
+		Where("status IN ? AND created_at >= ?", models.RevenuePaymentStatuses, currentMonth).
+		Select("COALESCE(SUM(" + s.paymentAmountSQL() + "), 0)").
 		Scan(&monthlyRevenue).Error; err != nil {
 		return nil, fmt.Errorf("failed to get monthly revenue: %w", err)
 	}
@@ -81,7 +91,7 @@ func (s *AnalyticsService) GetDashboardMetrics(ctx context.Context) (*models.Das
 	var totalTenants int64
 	if err := s.db.Model(&models.Subscription{}).
 		Distinct("tenant_id").
-		Count(&totalTenants); err != nil {
+		Count(&totalTenants).Error; err != nil {
 		return nil, fmt.Errorf("failed to get total tenants: %w", err)
 	}
 	metrics.TotalTenants = int(totalTenants)
@@ -91,7 +101,7 @@ func (s *AnalyticsService) GetDashboardMetrics(ctx context.Context) (*models.Das
 	if err := s.db.Model(&models.Subscription{}).
 		Where("created_at >= ?", currentMonth).
 		Distinct("tenant_id").
-		Count(&newTenants); err != nil {
+		Count(&newTenants).Error; err != nil {
 		return nil, fmt.Errorf("failed to get new tenants: %w", err)
 	}
 	metrics.NewTenants = int(newTenants)
@@ -103,6 +113,15 @@ func (s *AnalyticsService) GetDashboardMetrics(ctx context.Context) (*models.Das
 	}
 	metrics.ChurnRate = churnRate
 
+	// MRR moved by plan changes that took effect this month; prorated credits and
+	// charges are already part of revenue as adjustment payments
+	expansion, contraction, err := s.getPlanChangeMRR(currentMonth, currentMonth.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plan change MRR: %w", err)
+	}
+	metrics.ExpansionMRR = expansion
+	metrics.ContractionMRR = contraction
+
 	// Plan distribution
 	planDist, err := s.getPlanDistribution()
 	if err != nil {
@@ -127,9 +146,49 @@ func (s *AnalyticsService) GetDashboardMetrics(ctx context.Context) (*models.Das
 	return metrics, nil
 }
 
-func (s *AnalyticsService) GetRevenueAnalytics(ctx context.Context, period string, startDate, endDate time.Time) (*RevenueAnalytics, error) {
+// Revenue breakdown granularities
+const (
+	RevenueGroupDay   = "day"
+	RevenueGroupWeek  = "week"
+	RevenueGroupMonth = "month"
+)
+
+// ValidateRevenueRange checks a revenue query's grouping and date range. The range
+// is capped per grouping so a breakdown stays a few hundred points at most.
+func ValidateRevenueRange(groupBy string, startDate, endDate time.Time) error {
+	var maxEnd time.Time
+	switch groupBy {
+	case RevenueGroupDay:
+		maxEnd = startDate.AddDate(1, 0, 0)
+	case RevenueGroupWeek:
+		maxEnd = startDate.AddDate(5, 0, 0)
+	case RevenueGroupMonth:
+		maxEnd = startDate.AddDate(10, 0, 0)
+	default:
+		return fmt.Errorf("group_by must be one of: %s, %s, %s", RevenueGroupDay, RevenueGroupWeek, RevenueGroupMonth)
+	}
+
+	if !endDate.After(startDate) {
+		return fmt.Errorf("end_date must be after start_date")
+	}
+	if endDate.After(maxEnd) {
+		return fmt.Errorf("date range is too long for group_by=%s", groupBy)
+	}
+	return nil
+}
+
+// GetRevenueAnalytics reports revenue between startDate and endDate, both
+// inclusive, broken down by groupBy and compared with the range of the same
+// length just before it
+func (s *AnalyticsService) GetRevenueAnalytics(ctx context.Context, period, groupBy string, startDate, endDate time.Time) (*RevenueAnalytics, error) {
+	if err := ValidateRevenueRange(groupBy, startDate, endDate); err != nil {
+		return nil, err
+	}
+
 	analytics := &RevenueAnalytics{
 		Period:           period,
+		Currency:         s.currency.base,
+		GroupBy:          groupBy,
 		StartDate:        startDate,
 		EndDate:          endDate,
 		DailyRevenue:     make([]DailyRevenue, 0),
@@ -139,19 +198,39 @@ func (s *AnalyticsService) GetRevenueAnalytics(ctx context.Context, period strin
 	}
 
 	// Total revenue in period
-	var totalRevenue float64
-	if err := s.db.Model(&models.Payment{}).
-		Where("status = 'succeeded' AND created_at BETWEEN ? AND ?", startDate, endDate).
-		Select("COALESCE(SUM(amount), 0)").
-		Scan(&totalRevenue).Error; err != nil {
+	totalRevenue, err := s.getRevenueBetween(startDate, endDate)
+	if err != nil {
 		return nil, fmt.Errorf("failed to get total revenue: %w", err)
 	}
 	analytics.TotalRevenue = totalRevenue
 
-	// Daily revenue breakdown
-	dailyRevenue, err := s.getDailyRevenue(startDate, endDate)
+	revenueByCurrency, err := s.getRevenueByCurrency(s.db.Where("created_at BETWEEN ? AND ?", startDate, endDate))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revenue by currency: %w", err)
+	}
+	analytics.RevenueByCurrency = revenueByCurrency
+
+	// The same length of time immediately before the range
+	previousEnd := startDate.Add(-time.Nanosecond)
+	previousStart := previousEnd.Add(-endDate.Sub(startDate))
+	previousRevenue, err := s.getRevenueBetween(previousStart, previousEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get previous period revenue: %w", err)
+	}
+	analytics.PreviousPeriod = RevenuePeriodComparison{
+		StartDate:    previousStart,
+		EndDate:      previousEnd,
+		TotalRevenue: previousRevenue,
+	}
+	if previousRevenue > 0 {
+		growth := (totalRevenue - previousRevenue) / previousRevenue * 100
+		analytics.PreviousPeriod.Growth = &growth
+	}
+
+	// Revenue breakdown by day, week or month
+	dailyRevenue, err := s.getRevenueBreakdown(groupBy, startDate, endDate)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get daily revenue: %w", err)
+		return nil, fmt.Errorf("failed to get revenue breakdown: %w", err)
 	}
 	analytics.DailyRevenue = dailyRevenue
 
@@ -182,6 +261,7 @@ func (s *AnalyticsService) GetRevenueAnalytics(ctx context.Context, period strin
 func (s *AnalyticsService) GetSubscriptionMetrics(ctx context.Context, period string) (*SubscriptionMetrics, error) {
 	metrics := &SubscriptionMetrics{
 		Period:                period,
+		Currency:              s.currency.base,
 		StatusDistribution:    make(map[string]int),
 		BillingCycleBreakdown: make(map[string]int),
 		PlanPopularity:        make([]PlanPopularity, 0),
@@ -189,7 +269,7 @@ func (s *AnalyticsService) GetSubscriptionMetrics(ctx context.Context, period st
 	}
 
 	// Total subscriptions
-	if err := s.db.Model(&models.Subscription{}).Count(&metrics.TotalSubscriptions); err != nil {
+	if err := s.db.Model(&models.Subscription{}).Count(&metrics.TotalSubscriptions).Error; err != nil {
 		return nil, fmt.Errorf("failed to get total subscriptions: %w", err)
 	}
 
@@ -225,12 +305,28 @@ func (s *AnalyticsService) GetSubscriptionMetrics(ctx context.Context, period st
 	var avgValue float64
 	if err := s.db.Model(&models.Subscription{}).
 		Where("status IN ?", []string{"active", "trial"}).
-		Select("COALESCE(AVG(amount), 0)").
+		Select("COALESCE(AVG(" + s.currency.amountSQL("amount", "currency") + "), 0)").
 		Scan(&avgValue).Error; err != nil {
 		return nil, fmt.Errorf("failed to get average subscription value: %w", err)
 	}
 	metrics.AverageSubscriptionValue = avgValue
 
+	// Recurring revenue
+	mrr, err := s.getMRR()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MRR: %w", err)
+	}
+	metrics.MRR = utils.RoundToTwoDecimals(mrr)
+	metrics.ARR = utils.RoundToTwoDecimals(mrr * 12)
+
+	currentMonth := time.Now().Truncate(24 * time.Hour).AddDate(0, 0, -time.Now().Day()+1)
+	movement, err := s.getMRRMovement(currentMonth, currentMonth.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MRR movement: %w", err)
+	}
+	metrics.MRRMovement = *movement
+	metrics.NetNewMRR = movement.NetNew
+
 	return metrics, nil
 }
 
@@ -242,7 +338,7 @@ func (s *AnalyticsService) GetTenantMetrics(ctx context.Context) (*TenantMetrics
 	// Total tenants
 	if err := s.db.Model(&models.Subscription{}).
 		Distinct("tenant_id").
-		Count(&metrics.TotalTenants); err != nil {
+		Count(&metrics.TotalTenants).Error; err != nil {
 		return nil, fmt.Errorf("failed to get total tenants: %w", err)
 	}
 
@@ -250,7 +346,7 @@ func (s *AnalyticsService) GetTenantMetrics(ctx context.Context) (*TenantMetrics
 	if err := s.db.Model(&models.Subscription{}).
 		Where("status IN ?", []string{"active", "trial"}).
 		Distinct("tenant_id").
-		Count(&metrics.ActiveTenants); err != nil {
+		Count(&metrics.ActiveTenants).Error; err != nil {
 		return nil, fmt.Errorf("failed to get active tenants: %w", err)
 	}
 
@@ -280,6 +376,84 @@ func (s *AnalyticsService) GetTenantMetrics(ctx context.Context) (*TenantMetrics
 	return metrics, nil
 }
 
+// MaxCohortMonths is the most signup months a cohort retention report covers
+const MaxCohortMonths = 36
+
+// GetCohortRetention groups tenants by the month they first subscribed, over the
+// last months months, and reports what share of each cohort was still a customer
+// in every month since. Month 0 is the signup month and always 100%; later months
+// only appear once they have started, so the rows form a triangle. A tenant has
+// churned once none of its subscriptions are live, from the date its last
+// cancellation took effect.
+func (s *AnalyticsService) GetCohortRetention(ctx context.Context, months int) (*CohortRetention, error) {
+	if months < 1 || months > MaxCohortMonths {
+		return nil, fmt.Errorf("months must be between 1 and %d", MaxCohortMonths)
+	}
+
+	now := time.Now()
+	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	firstCohort := currentMonth.AddDate(0, -(months - 1), 0)
+
+	var tenants []struct {
+		TenantID   uuid.UUID
+		SignedUpAt time.Time
+		Live       bool
+		ChurnedAt  *time.Time
+	}
+	if err := s.db.WithContext(ctx).Model(&models.Subscription{}).
+		Select("tenant_id, MIN(created_at) AS signed_up_at, "+
+			"BOOL_OR(status IN ? AND (cancellation_effective_at IS NULL OR cancellation_effective_at > ?)) AS live, "+
+			"MAX(COALESCE(cancellation_effective_at, cancelled_at, ended_at)) AS churned_at",
+			[]string{"active", "trial", "suspended"}, now).
+		Group("tenant_id").
+		Having("MIN(created_at) >= ?", firstCohort).
+		Scan(&tenants).Error; err != nil {
+		return nil, fmt.Errorf("failed to get tenant cohorts: %w", err)
+	}
+
+	// retained[i][k] counts cohort i's tenants still customers in its month k
+	sizes := make([]int, months)
+	retained := make([][]int, months)
+	for i := range retained {
+		retained[i] = make([]int, months-i)
+	}
+	for _, tenant := range tenants {
+		signedUp := tenant.SignedUpAt.In(now.Location())
+		cohort := (signedUp.Year()-firstCohort.Year())*12 + int(signedUp.Month()-firstCohort.Month())
+		if cohort < 0 || cohort >= months {
+			continue
+		}
+		sizes[cohort]++
+
+		cohortStart := firstCohort.AddDate(0, cohort, 0)
+		for k := range retained[cohort] {
+			if tenant.Live || tenant.ChurnedAt == nil || !tenant.ChurnedAt.Before(cohortStart.AddDate(0, k, 0)) {
+				retained[cohort][k]++
+			}
+		}
+	}
+
+	report := &CohortRetention{
+		Months:  months,
+		Cohorts: make([]CohortRow, months),
+	}
+	for i := range report.Cohorts {
+		row := CohortRow{
+			Cohort:    firstCohort.AddDate(0, i, 0).Format("2006-01"),
+			Tenants:   sizes[i],
+			Retention: make([]float64, len(retained[i])),
+		}
+		if sizes[i] > 0 {
+			for k, count := range retained[i] {
+				row.Retention[k] = utils.RoundToTwoDecimals(float64(count) / float64(sizes[i]) * 100)
+			}
+		}
+		report.Cohorts[i] = row
+	}
+
+	return report, nil
+}
+
 // Helper methods
 
 func (s *AnalyticsService) calculateChurnRate(currentMonth time.Time) (float64, error) {
@@ -291,10 +465,12 @@ func (s *AnalyticsService) calculateChurnRate(currentMonth time.Time) (float64,
 		return 0, err
 	}
 
-	// Cancelled this month
+	// Cancellations taking effect this month; end-of-period cancellations churn when
+	// access ends, not when they were requested
 	var cancelledThisMonth int64
 	if err := s.db.Model(&models.Subscription{}).
-		Where("status = 'cancelled' AND cancelled_at >= ? AND cancelled_at < ?", currentMonth, currentMonth.AddDate(0, 1, 0)).
+		Where("(status = 'cancelled' OR cancel_at_period_end = ?) AND COALESCE(cancellation_effective_at, cancelled_at) >= ? AND COALESCE(cancellation_effective_at, cancelled_at) < ?",
+			true, currentMonth, currentMonth.AddDate(0, 1, 0)).
 		Count(&cancelledThisMonth).Error; err != nil {
 		return 0, err
 	}
@@ -306,6 +482,109 @@ func (s *AnalyticsService) calculateChurnRate(currentMonth time.Time) (float64,
 	return float64(cancelledThisMonth) / float64(activeLastMonth) * 100, nil
 }
 
+// getPlanChangeMRR sums the MRR added by upgrades and lost to downgrades applied
+// between start and end
+func (s *AnalyticsService) getPlanChangeMRR(start, end time.Time) (expansion, contraction float64, err error) {
+	var result struct {
+		Expansion   float64
+		Contraction float64
+	}
+	// A plan change is in its subscription's currency
+	delta := s.currency.amountSQL("plan_changes.mrr_delta", "subscriptions.currency")
+	if err := s.db.Model(&models.PlanChange{}).
+		Select("COALESCE(SUM(CASE WHEN plan_changes.mrr_delta > 0 THEN "+delta+" ELSE 0 END), 0) AS expansion, "+
+			"COALESCE(SUM(CASE WHEN plan_changes.mrr_delta < 0 THEN -"+delta+" ELSE 0 END), 0) AS contraction").
+		Joins("JOIN subscriptions ON subscriptions.id = plan_changes.subscription_id").
+		Where("plan_changes.status = ? AND plan_changes.effective_at >= ? AND plan_changes.effective_at < ?", models.PlanChangeApplied, start, end).
+		Scan(&result).Error; err != nil {
+		return 0, 0, err
+	}
+	return result.Expansion, result.Contraction, nil
+}
+
+// monthlyAmountSQL normalises subscriptions.amount to a month, as monthlyAmount
+// does, in the base currency
+func (s *AnalyticsService) monthlyAmountSQL() string {
+	amount := s.currency.amountSQL("amount", "currency")
+	return "CASE WHEN billing_cycle = 'yearly' THEN " + amount + " / 12 ELSE " + amount + " END"
+}
+
+// paymentAmountSQL is payments.amount in the base currency
+func (s *AnalyticsService) paymentAmountSQL() string {
+	return s.currency.amountSQL("payments.amount", "payments.currency")
+}
+
+// getRevenueByCurrency totals the revenue of the payments query selects in each
+// payment currency, unconverted, including currencies without a configured rate
+func (s *AnalyticsService) getRevenueByCurrency(query *gorm.DB) (map[string]float64, error) {
+	var results []struct {
+		Currency string
+		Revenue  float64
+	}
+	if err := query.Model(&models.Payment{}).
+		Select("UPPER(currency) AS currency, COALESCE(SUM(amount), 0) AS revenue").
+		Where("status IN ?", models.RevenuePaymentStatuses).
+		Group("UPPER(currency)").
+		Scan(&results).Error; err != nil {
+		return nil, err
+	}
+
+	revenue := make(map[string]float64, len(results))
+	for _, result := range results {
+		revenue[result.Currency] = utils.RoundToTwoDecimals(result.Revenue)
+	}
+	return revenue, nil
+}
+
+// getMRR is the monthly recurring revenue of every active subscription. Trials
+// have not paid yet and add nothing.
+func (s *AnalyticsService) getMRR() (float64, error) {
+	var mrr float64
+	if err := s.db.Model(&models.Subscription{}).
+		Where("status = 'active'").
+		Select("COALESCE(SUM(" + s.monthlyAmountSQL() + "), 0)").
+		Scan(&mrr).Error; err != nil {
+		return 0, err
+	}
+	return mrr, nil
+}
+
+// getMRRMovement works out how MRR moved between start and end. New MRR comes from
+// subscriptions that started paying in the range, either straight away or when
+// their trial ended; churned MRR from cancellations that took effect in it. A
+// trial cancelled before it converted never counted toward MRR, so it is not churn.
+func (s *AnalyticsService) getMRRMovement(start, end time.Time) (*MRRMovement, error) {
+	movement := &MRRMovement{}
+
+	if err := s.db.Model(&models.Subscription{}).
+		Where("status = 'active' AND COALESCE(trial_end, created_at) >= ? AND COALESCE(trial_end, created_at) < ?", start, end).
+		Select("COALESCE(SUM(" + s.monthlyAmountSQL() + "), 0)").
+		Scan(&movement.New).Error; err != nil {
+		return nil, err
+	}
+
+	expansion, contraction, err := s.getPlanChangeMRR(start, end)
+	if err != nil {
+		return nil, err
+	}
+	movement.Expansion = expansion
+	movement.Contraction = contraction
+
+	if err := s.db.Model(&models.Subscription{}).
+		Where("(status = 'cancelled' OR cancel_at_period_end = ?) AND COALESCE(cancellation_effective_at, cancelled_at) >= ? AND COALESCE(cancellation_effective_at, cancelled_at) < ?",
+			true, start, end).
+		Where("trial_end IS NULL OR COALESCE(cancellation_effective_at, cancelled_at) > trial_end").
+		Select("COALESCE(SUM(" + s.monthlyAmountSQL() + "), 0)").
+		Scan(&movement.Churned).Error; err != nil {
+		return nil, err
+	}
+
+	movement.New = utils.RoundToTwoDecimals(movement.New)
+	movement.Churned = utils.RoundToTwoDecimals(movement.Churned)
+	movement.NetNew = utils.RoundToTwoDecimals(movement.New + movement.Expansion - movement.Contraction - movement.Churned)
+	return movement, nil
+}
+
 func (s *AnalyticsService) getPlanDistribution() (map[string]int, error) {
 	var results []struct {
 		PlanName string `json:"plan_name"`
@@ -338,10 +617,10 @@ func (s *AnalyticsService) getRevenueByPlan() (map[string]float64, error) {
 	}
 
 	err := s.db.Table("payments").
-		Select("pricing_plans.display_name as plan_name, COALESCE(SUM(payments.amount), 0) as revenue").
+		Select("pricing_plans.display_name as plan_name, COALESCE(SUM(" + s.paymentAmountSQL() + "), 0) as revenue").
 		Joins("JOIN subscriptions ON payments.subscription_id = subscriptions.id").
 		Joins("JOIN pricing_plans ON subscriptions.plan_id = pricing_plans.id").
-		Where("payments.status = 'succeeded'").
+		Where("payments.status IN ?", models.RevenuePaymentStatuses).
 		Group("pricing_plans.display_name").
 		Scan(&results).Error
 
@@ -387,15 +666,15 @@ func (s *AnalyticsService) getMonthlyGrowth() (map[string]float64, error) {
 	var currentMonthRevenue, lastMonthRevenue float64
 	
 	if err := s.db.Model(&models.Payment{}).
-		Where("status = 'succeeded' AND created_at >= ?", currentMonth).
-		Select("COALESCE(SUM(amount), 0)").
+		Where("status IN ? AND created_at >= ?", models.RevenuePaymentStatuses, currentMonth).
+		Select("COALESCE(SUM(" + s.paymentAmountSQL() + "), 0)").
 		Scan(&currentMonthRevenue).Error; err != nil {
 		return nil, err
 	}
 
 	if err := s.db.Model(&models.Payment{}).
-		Where("status = 'succeeded' AND created_at >= ? AND created_at < ?", lastMonth, currentMonth).
-		Select("COALESCE(SUM(amount), 0)").
+		Where("status IN ? AND created_at >= ? AND created_at < ?", models.RevenuePaymentStatuses, lastMonth, currentMonth).
+		Select("COALESCE(SUM(" + s.paymentAmountSQL() + "), 0)").
 		Scan(&lastMonthRevenue).Error; err != nil {
 		return nil, err
 	}
@@ -407,13 +686,25 @@ func (s *AnalyticsService) getMonthlyGrowth() (map[string]float64, error) {
 	return growth, nil
 }
 
-func (s *AnalyticsService) getDailyRevenue(startDate, endDate time.Time) ([]DailyRevenue, error) {
+// getRevenueBetween totals revenue between startDate and endDate, both inclusive
+func (s *AnalyticsService) getRevenueBetween(startDate, endDate time.Time) (float64, error) {
+	var revenue float64
+	err := s.db.Model(&models.Payment{}).
+		Where("status IN ? AND created_at BETWEEN ? AND ?", models.RevenuePaymentStatuses, startDate, endDate).
+		Select("COALESCE(SUM(" + s.paymentAmountSQL() + "), 0)").
+		Scan(&revenue).Error
+	return revenue, err
+}
+
+// getRevenueBreakdown totals revenue per day, week or month. Each point is dated by
+// the first day of its period; weeks start on Monday.
+func (s *AnalyticsService) getRevenueBreakdown(groupBy string, startDate, endDate time.Time) ([]DailyRevenue, error) {
 	var results []DailyRevenue
 
 	err := s.db.Table("payments").
-		Select("DATE(created_at) as date, COALESCE(SUM(amount), 0) as revenue, COUNT(*) as transactions").
-		Where("status = 'succeeded' AND created_at BETWEEN ? AND ?", startDate, endDate).
-		Group("DATE(created_at)").
+		Select("TO_CHAR(DATE_TRUNC(?, created_at), 'YYYY-MM-DD') as date, COALESCE(SUM("+s.paymentAmountSQL()+"), 0) as revenue, COUNT(*) as transactions", groupBy).
+		Where("status IN ? AND created_at BETWEEN ? AND ?", models.RevenuePaymentStatuses, startDate, endDate).
+		Group("date").
 		Order("date").
 		Scan(&results).Error
 
@@ -427,8 +718,8 @@ func (s *AnalyticsService) getPaymentMethodsBreakdown(startDate, endDate time.Ti
 	}
 
 	err := s.db.Table("payments").
-		Select("COALESCE(payment_method, 'unknown') as payment_method, COALESCE(SUM(amount), 0) as revenue").
-		Where("status = 'succeeded' AND created_at BETWEEN ? AND ?", startDate, endDate).
+		Select("COALESCE(payment_method, 'unknown') as payment_method, COALESCE(SUM(" + s.paymentAmountSQL() + "), 0) as revenue").
+		Where("status IN ? AND created_at BETWEEN ? AND ?", models.RevenuePaymentStatuses, startDate, endDate).
 		Group("payment_method").
 		Scan(&results).Error
 
@@ -448,10 +739,10 @@ func (s *AnalyticsService) getTopPlansByRevenue(startDate, endDate time.Time, li
 	var results []PlanRevenue
 
 	err := s.db.Table("payments").
-		Select("pricing_plans.display_name as plan_name, COALESCE(SUM(payments.amount), 0) as revenue, COUNT(payments.id) as transactions").
+		Select("pricing_plans.display_name as plan_name, COALESCE(SUM(" + s.paymentAmountSQL() + "), 0) as revenue, COUNT(payments.id) as transactions").
 		Joins("JOIN subscriptions ON payments.subscription_id = subscriptions.id").
 		Joins("JOIN pricing_plans ON subscriptions.plan_id = pricing_plans.id").
-		Where("payments.status = 'succeeded' AND payments.created_at BETWEEN ? AND ?", startDate, endDate).
+		Where("payments.status IN ? AND payments.created_at BETWEEN ? AND ?", models.RevenuePaymentStatuses, startDate, endDate).
 		Group("pricing_plans.id, pricing_plans.display_name").
 		Order("revenue DESC").
 		Limit(limit).
@@ -467,7 +758,7 @@ func (s *AnalyticsService) getRevenueByStatus(startDate, endDate time.Time) (map
 	}
 
 	err := s.db.Table("payments").
-		Select("status, COALESCE(SUM(amount), 0) as revenue").
+		Select("status, COALESCE(SUM(" + s.paymentAmountSQL() + "), 0) as revenue").
 		Where("created_at BETWEEN ? AND ?", startDate, endDate).
 		Group("status").
 		Scan(&results).Error
@@ -535,7 +826,7 @@ func (s *AnalyticsService) getPlanPopularity() ([]PlanPopularity, error) {
 	var results []PlanPopularity
 
 	err := s.db.Table("subscriptions").
-		Select("pricing_plans.display_name as plan_name, COUNT(subscriptions.id) as subscriptions, COALESCE(AVG(subscriptions.amount), 0) as average_revenue").
+		Select("pricing_plans.display_name as plan_name, COUNT(subscriptions.id) as subscriptions, COALESCE(AVG(" + s.currency.amountSQL("subscriptions.amount", "subscriptions.currency") + "), 0) as average_revenue").
 		Joins("JOIN pricing_plans ON subscriptions.plan_id = pricing_plans.id").
 		Where("subscriptions.status IN ?", []string{"active", "trial"}).
 		Group("pricing_plans.id, pricing_plans.display_name").
@@ -549,21 +840,30 @@ func (s *AnalyticsService) getConversionRates() (map[string]float64, error) {
 	rates := make(map[string]float64)
 	
 	// Trial to active conversion
-	var trialCount, activeFromTrialCount int64
+	var trialCount, activeFromTrialCount, manualCount int64
 	
 	if err := s.db.Model(&models.Subscription{}).Where("status = 'trial'").Count(&trialCount).Error; err != nil {
 		return nil, err
 	}
 	
-	// Simplified: count active subscriptions that had a trial period
+	// Trials that converted, by payment or by an admin. Subscriptions from before
+	// conversions were recorded count while they are active.
 	if err := s.db.Model(&models.Subscription{}).
-		Where("status = 'active' AND trial_start IS NOT NULL").
+		Where("trial_start IS NOT NULL AND (converted_at IS NOT NULL OR status = 'active')").
 		Count(&activeFromTrialCount).Error; err != nil {
 		return nil, err
 	}
 
+	if err := s.db.Model(&models.Subscription{}).
+		Where("trial_start IS NOT NULL AND converted_manually = ?", true).
+		Count(&manualCount).Error; err != nil {
+		return nil, err
+	}
+
 	if trialCount+activeFromTrialCount > 0 {
 		rates["trial_to_active"] = float64(activeFromTrialCount) / float64(trialCount+activeFromTrialCount) * 100
+		// The part of trial_to_active converted manually by admins
+		rates["trial_to_active_manual"] = float64(manualCount) / float64(trialCount+activeFromTrialCount) * 100
 	}
 
 	return rates, nil
@@ -630,15 +930,31 @@ func (s *AnalyticsService) getTopTenantsByUsage(limit int) ([]TopTenant, error)
 
 type RevenueAnalytics struct {
 	Period          string                 `json:"period"`
+	GroupBy         string                 `json:"group_by"`
 	StartDate       time.Time              `json:"start_date"`
 	EndDate         time.Time              `json:"end_date"`
+	Currency        string                 `json:"currency"` // base currency every amount is converted to
 	TotalRevenue    float64                `json:"total_revenue"`
+	// Revenue in each payment currency before conversion; a currency without a
+	// configured rate appears only here
+	RevenueByCurrency map[string]float64   `json:"revenue_by_currency"`
+	PreviousPeriod  RevenuePeriodComparison `json:"previous_period"`
+	// One point per group_by period; the name predates weekly and monthly grouping
 	DailyRevenue    []DailyRevenue         `json:"daily_revenue"`
 	PaymentMethods  map[string]float64     `json:"payment_methods"`
 	TopPlans        []PlanRevenue          `json:"top_plans"`
 	RevenueByStatus map[string]float64     `json:"revenue_by_status"`
 }
 
+// RevenuePeriodComparison is the revenue of the range just before a revenue
+// query's range. Growth is a percentage and is null when that revenue is zero.
+type RevenuePeriodComparison struct {
+	StartDate    time.Time `json:"start_date"`
+	EndDate      time.Time `json:"end_date"`
+	TotalRevenue float64   `json:"total_revenue"`
+	Growth       *float64  `json:"growth"`
+}
+
 type DailyRevenue struct {
 	Date         string  `json:"date"`
 	Revenue      float64 `json:"revenue"`
@@ -653,12 +969,41 @@ type PlanRevenue struct {
 
 type SubscriptionMetrics struct {
 	Period                    string                  `json:"period"`
+	Currency                  string                  `json:"currency"` // base currency every amount is converted to
 	TotalSubscriptions        int64                   `json:"total_subscriptions"`
 	StatusDistribution        map[string]int          `json:"status_distribution"`
 	BillingCycleBreakdown     map[string]int          `json:"billing_cycle_breakdown"`
 	PlanPopularity            []PlanPopularity        `json:"plan_popularity"`
 	ConversionRates           map[string]float64      `json:"conversion_rates"`
 	AverageSubscriptionValue  float64                 `json:"average_subscription_value"`
+	MRR                       float64                 `json:"mrr"`
+	ARR                       float64                 `json:"arr"`
+	NetNewMRR                 float64                 `json:"net_new_mrr"` // this month
+	MRRMovement               MRRMovement             `json:"mrr_movement"`
+}
+
+// CohortRetention is the share of each signup-month cohort of tenants still
+// subscribed in each month after signing up
+type CohortRetention struct {
+	Months  int         `json:"months"`
+	Cohorts []CohortRow `json:"cohorts"`
+}
+
+// CohortRow is one signup month. Retention[k] is the percentage of the cohort's
+// tenants still customers k months after the signup month.
+type CohortRow struct {
+	Cohort    string    `json:"cohort"` // YYYY-MM
+	Tenants   int       `json:"tenants"`
+	Retention []float64 `json:"retention"`
+}
+
+// MRRMovement breaks down how monthly recurring revenue changed over a period
+type MRRMovement struct {
+	New         float64 `json:"new"`
+	Expansion   float64 `json:"expansion"`
+	Contraction float64 `json:"contraction"`
+	Churned     float64 `json:"churned"`
+	NetNew      float64 `json:"net_new"` // new + expansion - contraction - churned
 }
 
 type PlanPopularity struct {
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/liquorpro/go-backend/internal/saas/models"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+)
+
+type CouponService struct {
+	db *gorm.DB
+}
+
+func NewCouponService(db *gorm.DB) *CouponService {
+	return &CouponService{db: db}
+}
+
+// CreateCoupon adds a discount code. Codes are case-insensitive and unique.
+func (s *CouponService) CreateCoupon(ctx context.Context, req *models.CouponRequest) (*models.Coupon, error) {
+	coupon := models.Coupon{
+		ID:     uuid.New(),
+		Active: true,
+	}
+	if err := applyCouponRequest(&coupon, req); err != nil {
+		return nil, err
+	}
+	if err := s.checkCodeFree(coupon.Code, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.WithContext(ctx).Create(&coupon).Error; err != nil {
+		return nil, fmt.Errorf("failed to create coupon: %w", err)
+	}
+	// Written explicitly so an inactive coupon is not replaced by the column default
+	if err := s.db.WithContext(ctx).Model(&coupon).Update("active", coupon.Active).Error; err != nil {
+		return nil, fmt.Errorf("failed to create coupon: %w", err)
+	}
+	return &coupon, nil
+}
+
+// GetCoupons lists every coupon, newest first
+func (s *CouponService) GetCoupons(ctx context.Context) ([]models.Coupon, error) {
+	var coupons []models.Coupon
+	if err := s.db.WithContext(ctx).Order("created_at DESC").Find(&coupons).Error; err != nil {
+		return nil, fmt.Errorf("failed to get coupons: %w", err)
+	}
+	return coupons, nil
+}
+
+// GetCoupon returns a coupon with the subscriptions that redeemed it
+func (s *CouponService) GetCoupon(ctx context.Context, id uuid.UUID) (*models.Coupon, []models.CouponRedemption, error) {
+	var coupon models.Coupon
+	if err := s.db.WithContext(ctx).First(&coupon, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errors.New("coupon not found")
+		}
+		return nil, nil, fmt.Errorf("failed to get coupon: %w", err)
+	}
+
+	var redemptions []models.CouponRedemption
+	if err := s.db.WithContext(ctx).Where("coupon_id = ?", id).
+		Order("created_at DESC").
+		Find(&redemptions).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to get coupon redemptions: %w", err)
+	}
+	return &coupon, redemptions, nil
+}
+
+// UpdateCoupon changes a coupon. Subscriptions that already redeemed it keep the
+// discount they were given.
+func (s *CouponService) UpdateCoupon(ctx context.Context, id uuid.UUID, req *models.CouponRequest) (*models.Coupon, error) {
+	var coupon models.Coupon
+	if err := s.db.WithContext(ctx).First(&coupon, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("coupon not found")
+		}
+		return nil, fmt.Errorf("failed to get coupon: %w", err)
+	}
+
+	if err := applyCouponRequest(&coupon, req); err != nil {
+		return nil, err
+	}
+	if coupon.MaxRedemptions > 0 && coupon.MaxRedemptions < coupon.Redemptions {
+		return nil, fmt.Errorf("coupon has already been redeemed %d times", coupon.Redemptions)
+	}
+	if err := s.checkCodeFree(coupon.Code, coupon.ID); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.WithContext(ctx).Model(&coupon).
+		Select("code", "description", "type", "value", "currency", "max_redemptions", "expires_at",
+			"plan_ids", "duration_cycles", "razorpay_offer_id", "active").
+		Updates(&coupon).Error; err != nil {
+		return nil, fmt.Errorf("failed to update coupon: %w", err)
+	}
+	return &coupon, nil
+}
+
+// DeleteCoupon removes a coupon so it can no longer be redeemed. Subscriptions
+// that already redeemed it keep their discount.
+func (s *CouponService) DeleteCoupon(ctx context.Context, id uuid.UUID) error {
+	result := s.db.WithContext(ctx).Delete(&models.Coupon{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete coupon: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("coupon not found")
+	}
+	return nil
+}
+
+func (s *CouponService) checkCodeFree(code string, id uuid.UUID) error {
+	var count int64
+	// Deleted coupons keep their codes
+	if err := s.db.Unscoped().Model(&models.Coupon{}).Where("code = ? AND id <> ?", code, id).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check coupon code: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("coupon code %s already exists", code)
+	}
+	return nil
+}
+
+// applyCouponRequest copies a create or update request onto a coupon
+func applyCouponRequest(coupon *models.Coupon, req *models.CouponRequest) error {
+	code := normalizeCouponCode(req.Code)
+	if code == "" {
+		return errors.New("coupon code is required")
+	}
+	if req.Type == models.CouponPercentage && req.Value > 100 {
+		return errors.New("a percentage coupon cannot take off more than 100%")
+	}
+	currency, err := NormalizeCurrency(req.Currency)
+	if err != nil {
+		return err
+	}
+
+	coupon.Code = code
+	coupon.Description = req.Description
+	coupon.Type = req.Type
+	coupon.Value = req.Value
+	coupon.Currency = currency
+	coupon.MaxRedemptions = req.MaxRedemptions
+	coupon.ExpiresAt = req.ExpiresAt
+	coupon.PlanIDs = req.PlanIDs
+	coupon.DurationCycles = req.DurationCycles
+	coupon.RazorpayOfferID = strings.TrimSpace(req.RazorpayOfferID)
+	if req.Active != nil {
+		coupon.Active = *req.Active
+	}
+	return nil
+}
+
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// redeemCoupon checks a coupon code can be used on a new subscription, takes its
+// discount off the subscription's amount and counts the redemption. The coupon row
+// is locked so concurrent sign-ups cannot exceed its redemption limit. The caller
+// records the redemption once the subscription exists.
+func redeemCoupon(tx *gorm.DB, code string, subscription *models.Subscription, plan *models.PricingPlan, now time.Time) (*models.Coupon, error) {
+	var coupon models.Coupon
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("code = ?", normalizeCouponCode(code)).First(&coupon).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("coupon code is not valid")
+		}
+		return nil, fmt.Errorf("failed to get coupon: %w", err)
+	}
+
+	switch {
+	case !coupon.Active:
+		return nil, errors.New("coupon code is not valid")
+	case coupon.ExpiresAt != nil && !now.Before(*coupon.ExpiresAt):
+		return nil, errors.New("coupon has expired")
+	case coupon.MaxRedemptions > 0 && coupon.Redemptions >= coupon.MaxRedemptions:
+		return nil, errors.New("coupon has reached its redemption limit")
+	case !couponAppliesTo(&coupon, plan):
+		return nil, fmt.Errorf("coupon does not apply to the %s plan", plan.DisplayName)
+	case coupon.Type == models.CouponFixed && coupon.Currency != subscription.Currency:
+		return nil, fmt.Errorf("coupon is in %s but the subscription is billed in %s", coupon.Currency, subscription.Currency)
+	}
+
+	discount := couponDiscount(&coupon, subscription.Amount)
+	subscription.Amount = utils.RoundAmount(subscription.Amount-discount, 2, utils.RoundHalfUp)
+	subscription.Discount = discount
+	subscription.CouponID = &coupon.ID
+
+	if err := tx.Model(&coupon).Update("redemptions", gorm.Expr("redemptions + 1")).Error; err != nil {
+		return nil, fmt.Errorf("failed to redeem coupon: %w", err)
+	}
+	return &coupon, nil
+}
+
+// couponAppliesTo reports whether a coupon can be used with a plan
+func couponAppliesTo(coupon *models.Coupon, plan *models.PricingPlan) bool {
+	if len(coupon.PlanIDs) == 0 {
+		return true
+	}
+	for _, planID := range coupon.PlanIDs {
+		if planID == plan.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// couponDiscount is what a coupon takes off a period's amount; it never takes the
+// amount below zero
+func couponDiscount(coupon *models.Coupon, amount float64) float64 {
+	discount := coupon.Value
+	if coupon.Type == models.CouponPercentage {
+		discount = math.Round(amount*coupon.Value) / 100
+	}
+	return utils.RoundAmount(math.Min(discount, amount), 2, utils.RoundHalfUp)
+}
+
+// couponDiscountOnPlan is what a subscription's coupon takes off each period on
+// another plan. The discount carries over when the coupon applies to the plan and
+// ends otherwise; a deleted coupon still honours the discount it already gave.
+func couponDiscountOnPlan(tx *gorm.DB, subscription *models.Subscription, plan *models.PricingPlan, listAmount float64) (float64, error) {
+	if subscription.CouponID == nil || subscription.Discount == 0 {
+		return 0, nil
+	}
+
+	var coupon models.Coupon
+	if err := tx.Unscoped().First(&coupon, *subscription.CouponID).Error; err != nil {
+		return 0, fmt.Errorf("failed to get coupon: %w", err)
+	}
+	if !couponAppliesTo(&coupon, plan) {
+		return 0, nil
+	}
+	return couponDiscount(&coupon, listAmount), nil
+}
+
+// setRedemptionDiscount records the discount a subscription now gets from its
+// coupon, ending the redemption when there is none left
+func setRedemptionDiscount(tx *gorm.DB, subscription *models.Subscription, discount float64, now time.Time) error {
+	if subscription.CouponID == nil {
+		return nil
+	}
+	updates := map[string]interface{}{"discount": discount}
+	if discount == 0 {
+		updates["ended_at"] = now
+	}
+	if err := tx.Model(&models.CouponRedemption{}).
+		Where("subscription_id = ? AND ended_at IS NULL", subscription.ID).
+		Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update coupon redemption: %w", err)
+	}
+	return nil
+}
+
+// countDiscountedPeriod records that a subscription has been billed one period with
+// its coupon discount. When the coupon's periods run out the discount ends and the
+// subscription goes back to its full amount.
+func countDiscountedPeriod(tx *gorm.DB, subscription *models.Subscription, now time.Time) error {
+	if subscription.CouponID == nil || subscription.Discount == 0 {
+		return nil
+	}
+
+	var redemption models.CouponRedemption
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Coupon", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).Where("subscription_id = ?", subscription.ID).First(&redemption).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get coupon redemption: %w", err)
+	}
+
+	redemption.CyclesApplied++
+	updates := map[string]interface{}{"cycles_applied": redemption.CyclesApplied}
+	ended := redemption.Coupon != nil && redemption.Coupon.DurationCycles > 0 &&
+		redemption.CyclesApplied >= redemption.Coupon.DurationCycles
+	if ended {
+		updates["ended_at"] = now
+	}
+	if err := tx.Model(&redemption).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update coupon redemption: %w", err)
+	}
+	if !ended {
+		return nil
+	}
+
+	subscription.Amount = utils.RoundAmount(subscription.Amount+subscription.Discount, 2, utils.RoundHalfUp)
+	subscription.Discount = 0
+	if err := tx.Model(subscription).Updates(map[string]interface{}{
+		"amount":   subscription.Amount,
+		"discount": 0,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to end coupon discount: %w", err)
+	}
+	return nil
+}
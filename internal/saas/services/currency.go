@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/liquorpro/go-backend/pkg/shared/config"
+)
+
+// DefaultCurrency is the currency of plans, subscriptions, payments and invoices
+// that do not name one
+const DefaultCurrency = "INR"
+
+// NormalizeCurrency returns code as an upper-case ISO 4217 code, or
+// DefaultCurrency when code is empty
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return DefaultCurrency, nil
+	}
+	if len(code) != 3 {
+		return "", errors.New("currency must be a three-letter ISO 4217 code")
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return "", errors.New("currency must be a three-letter ISO 4217 code")
+		}
+	}
+	return code, nil
+}
+
+// currencyConverter converts amounts to the base currency analytics report in,
+// using the configured rate table
+type currencyConverter struct {
+	base  string
+	rates map[string]float64 // base currency units per unit of each currency
+}
+
+func newCurrencyConverter(cfg config.BillingConfig) *currencyConverter {
+	base, err := NormalizeCurrency(cfg.BaseCurrency)
+	if err != nil {
+		base = DefaultCurrency
+	}
+
+	converter := &currencyConverter{base: base, rates: map[string]float64{base: 1}}
+	for code, rate := range cfg.CurrencyRates {
+		// Only validated codes reach the SQL built from the table
+		code, err := NormalizeCurrency(code)
+		if err != nil || rate <= 0 || code == base {
+			continue
+		}
+		converter.rates[code] = rate
+	}
+	return converter
+}
+
+// amountSQL is the SQL expression for amountColumn in the base currency. Amounts
+// in a currency without a rate convert to NULL, so SUM and AVG leave them out
+// rather than adding them at face value; they are still reported per currency.
+func (c *currencyConverter) amountSQL(amountColumn, currencyColumn string) string {
+	codes := make([]string, 0, len(c.rates))
+	for code := range c.rates {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "(CASE UPPER(%s)", currencyColumn)
+	for _, code := range codes {
+		fmt.Fprintf(&b, " WHEN '%s' THEN %s * %s", code, amountColumn, strconv.FormatFloat(c.rates[code], 'f', -1, 64))
+	}
+	b.WriteString(" END)")
+	return b.String()
+}
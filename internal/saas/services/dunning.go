@@ -0,0 +1,441 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/liquorpro/go-backend/internal/saas/models"
+	sharedmodels "github.com/liquorpro/go-backend/pkg/shared/models"
+)
+
+// Dunning chases failed subscription payments. A failed payment is retried on
+// the configured schedule of days after it failed, charging the customer's saved
+// payment method. The tenant's admins are emailed after each failure. Once every
+// retry has failed the subscription goes past due, keeping access for the grace
+// period, and is then cancelled. Any successful payment ends dunning for the
+// subscription.
+
+// startDunning records that a subscription payment failed and schedules what
+// happens next. A failed dunning retry moves on the payment it was retrying.
+func (s *PaymentService) startDunning(ctx context.Context, payment *models.Payment, reason string) error {
+	if payment.RefundOfID != nil {
+		return nil
+	}
+	now := time.Now()
+
+	if payment.RetryOfID != nil {
+		if err := s.db.WithContext(ctx).Model(&models.DunningAttempt{}).
+			Where("retry_payment_id = ? AND status = ?", payment.ID, models.DunningAttemptPending).
+			Updates(map[string]interface{}{
+				"status":         models.DunningAttemptFailed,
+				"failure_reason": reason,
+				"completed_at":   now,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to update dunning attempt: %w", err)
+		}
+		return s.scheduleNextRetry(ctx, *payment.RetryOfID, now)
+	}
+
+	if err := s.db.WithContext(ctx).Model(payment).Updates(map[string]interface{}{
+		"failed_at":   now,
+		"retry_count": 0,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to record payment failure: %w", err)
+	}
+	return s.scheduleNextRetry(ctx, payment.ID, now)
+}
+
+// scheduleNextRetry sets when a failed payment is next retried. With no retries
+// left its subscription goes past due. The tenant's admins are told either way.
+func (s *PaymentService) scheduleNextRetry(ctx context.Context, paymentID uuid.UUID, now time.Time) error {
+	var payment models.Payment
+	var subscription models.Subscription
+	var nextRetry *time.Time
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, paymentID).Error; err != nil {
+			return fmt.Errorf("failed to get payment: %w", err)
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Plan").
+			First(&subscription, payment.SubscriptionID).Error; err != nil {
+			return fmt.Errorf("failed to get subscription: %w", err)
+		}
+
+		// Nothing is chased for a subscription that has ended
+		if subscription.Status == "cancelled" || subscription.Status == "expired" {
+			return tx.Model(&payment).Update("next_retry_at", nil).Error
+		}
+
+		retryDays := s.config.Billing.DunningRetryDays
+		if payment.RetryCount < len(retryDays) {
+			failedAt := now
+			if payment.FailedAt != nil {
+				failedAt = *payment.FailedAt
+			}
+			next := failedAt.AddDate(0, 0, retryDays[payment.RetryCount])
+			if next.Before(now) {
+				next = now
+			}
+			nextRetry = &next
+			if err := tx.Model(&payment).Update("next_retry_at", next).Error; err != nil {
+				return fmt.Errorf("failed to schedule payment retry: %w", err)
+			}
+			return nil
+		}
+
+		if err := tx.Model(&payment).Update("next_retry_at", nil).Error; err != nil {
+			return fmt.Errorf("failed to end payment retries: %w", err)
+		}
+		if subscription.Status == "active" {
+			subscription.Status = "past_due"
+			subscription.PastDueAt = &now
+			if err := tx.Model(&subscription).Updates(map[string]interface{}{
+				"status":      subscription.Status,
+				"past_due_at": now,
+			}).Error; err != nil {
+				return fmt.Errorf("failed to mark subscription past due: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	vars := map[string]interface{}{
+		"amount":    formatAmount(payment.Currency, payment.Amount),
+		"plan_name": subscription.Plan.DisplayName,
+		"attempt":   payment.RetryCount,
+	}
+	if nextRetry != nil {
+		vars["next_retry"] = nextRetry.Format("02 Jan 2006")
+	} else {
+		vars["cancels_on"] = now.AddDate(0, 0, s.config.Billing.DunningGraceDays).Format("02 Jan 2006")
+	}
+	s.notifyTenantAdmins(ctx, subscription.TenantID, sharedmodels.EmailEventPaymentFailed, vars)
+	return nil
+}
+
+// endDunning stops chasing a subscription's failed payments once a payment for it
+// succeeds, and brings a past due subscription back to active
+func endDunning(tx *gorm.DB, subscription *models.Subscription, payment *models.Payment, now time.Time) error {
+	if payment.RetryOfID != nil {
+		if err := tx.Model(&models.DunningAttempt{}).
+			Where("retry_payment_id = ? AND status = ?", payment.ID, models.DunningAttemptPending).
+			Updates(map[string]interface{}{
+				"status":       models.DunningAttemptSucceeded,
+				"completed_at": now,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to update dunning attempt: %w", err)
+		}
+	}
+
+	if err := tx.Model(&models.Payment{}).
+		Where("subscription_id = ? AND status = ? AND next_retry_at IS NOT NULL", subscription.ID, "failed").
+		Update("next_retry_at", nil).Error; err != nil {
+		return fmt.Errorf("failed to end payment retries: %w", err)
+	}
+
+	if subscription.Status == "past_due" {
+		subscription.Status = "active"
+		subscription.PastDueAt = nil
+		if err := tx.Model(subscription).Updates(map[string]interface{}{
+			"status":      subscription.Status,
+			"past_due_at": nil,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to reactivate subscription: %w", err)
+		}
+	}
+	return nil
+}
+
+// RunDunning retries every failed payment that is due and cancels subscriptions
+// whose grace period has run out. It returns how many retries were made and how
+// many subscriptions were cancelled. A payment or subscription that fails is
+// logged and left for the next run, so it does not hold up other tenants.
+func (s *PaymentService) RunDunning(ctx context.Context) (int, int, error) {
+	now := time.Now()
+
+	var due []models.Payment
+	if err := s.db.WithContext(ctx).
+		Where("status = ? AND next_retry_at <= ? AND retry_of_id IS NULL", "failed", now).
+		Find(&due).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to get payments due a retry: %w", err)
+	}
+
+	retried := 0
+	for i := range due {
+		attempted, err := s.retryPayment(ctx, &due[i], now)
+		if err != nil {
+			log.Printf("Failed to retry payment %s: %v", due[i].ID, err)
+		}
+		if attempted {
+			retried++
+		}
+	}
+
+	graceEnd := now.AddDate(0, 0, -s.config.Billing.DunningGraceDays)
+	var pastDue []models.Subscription
+	if err := s.db.WithContext(ctx).Preload("Plan").
+		Where("status = ? AND past_due_at <= ?", "past_due", graceEnd).
+		Find(&pastDue).Error; err != nil {
+		return retried, 0, fmt.Errorf("failed to get past due subscriptions: %w", err)
+	}
+
+	cancelled := 0
+	for i := range pastDue {
+		ok, err := s.cancelPastDue(ctx, &pastDue[i], now)
+		if err != nil {
+			log.Printf("Failed to cancel past due subscription %s: %v", pastDue[i].ID, err)
+			continue
+		}
+		if ok {
+			cancelled++
+		}
+	}
+	return retried, cancelled, nil
+}
+
+// retryPayment makes the next dunning attempt on a failed payment. The attempt is
+// claimed under a row lock so two runs never retry the same payment at once. The
+// gateway reports the charge's outcome by webhook; a charge the gateway refuses
+// outright fails the attempt at once.
+func (s *PaymentService) retryPayment(ctx context.Context, payment *models.Payment, now time.Time) (bool, error) {
+	var subscription models.Subscription
+	var attempt models.DunningAttempt
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("status = ? AND next_retry_at <= ?", "failed", now).
+			First(payment, payment.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&subscription, payment.SubscriptionID).Error; err != nil {
+			return fmt.Errorf("failed to get subscription: %w", err)
+		}
+
+		payment.RetryCount++
+		if err := tx.Model(payment).Updates(map[string]interface{}{
+			"retry_count":   payment.RetryCount,
+			"next_retry_at": nil,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to claim payment retry: %w", err)
+		}
+
+		attempt = models.DunningAttempt{
+			ID:             uuid.New(),
+			PaymentID:      payment.ID,
+			SubscriptionID: subscription.ID,
+			TenantID:       subscription.TenantID,
+			Attempt:        payment.RetryCount,
+			Status:         models.DunningAttemptPending,
+			AttemptedAt:    now,
+		}
+		if err := tx.Create(&attempt).Error; err != nil {
+			return fmt.Errorf("failed to record dunning attempt: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		// Another run claimed the retry first
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	orderID, razorpayPaymentID, chargeErr := s.chargeSavedMethod(ctx, &subscription, payment)
+	if chargeErr != nil {
+		if err := s.db.WithContext(ctx).Model(&attempt).Updates(map[string]interface{}{
+			"status":         models.DunningAttemptFailed,
+			"failure_reason": chargeErr.Error(),
+			"completed_at":   time.Now(),
+		}).Error; err != nil {
+			return true, fmt.Errorf("failed to update dunning attempt: %w", err)
+		}
+		return true, s.scheduleNextRetry(ctx, payment.ID, time.Now())
+	}
+
+	retry := models.Payment{
+		ID:                uuid.New(),
+		SubscriptionID:    payment.SubscriptionID,
+		Amount:            payment.Amount,
+		Currency:          payment.Currency,
+		Status:            "pending",
+		RazorpayOrderID:   orderID,
+		RazorpayPaymentID: razorpayPaymentID,
+		RetryOfID:         &payment.ID,
+		Description:       fmt.Sprintf("Retry %d of failed payment %s", attempt.Attempt, payment.ID),
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&retry).Error; err != nil {
+			return fmt.Errorf("failed to create retry payment: %w", err)
+		}
+		if err := tx.Model(&attempt).Update("retry_payment_id", retry.ID).Error; err != nil {
+			return fmt.Errorf("failed to update dunning attempt: %w", err)
+		}
+		return nil
+	})
+	return true, err
+}
+
+// chargeSavedMethod charges a failed payment's amount to the customer's saved
+// payment method. It returns the gateway's order and payment IDs.
+func (s *PaymentService) chargeSavedMethod(ctx context.Context, subscription *models.Subscription, payment *models.Payment) (string, string, error) {
+	if subscription.RazorpayCustomerID == "" {
+		return "", "", errors.New("subscription has no saved payment method")
+	}
+	email, err := s.tenantAdminEmail(ctx, subscription.TenantID)
+	if err != nil {
+		return "", "", err
+	}
+	tokens, err := s.paymentClient.FetchTokens(subscription.RazorpayCustomerID)
+	if err != nil {
+		return "", "", err
+	}
+	if len(tokens) == 0 {
+		return "", "", errors.New("subscription has no saved payment method")
+	}
+
+	amountInPaise := int64(payment.Amount*100 + 0.5)
+	receipt := fmt.Sprintf("retry_%s_%d", payment.ID.String()[:8], payment.RetryCount)
+	orderID, err := s.paymentClient.CreateOrder(amountInPaise, payment.Currency, receipt)
+	if err != nil {
+		return "", "", err
+	}
+
+	razorpayPaymentID, err := s.paymentClient.CreateRecurringPayment(subscription.RazorpayCustomerID, tokens[0], orderID,
+		amountInPaise, payment.Currency, email, "Subscription payment retry")
+	if err != nil {
+		return orderID, "", err
+	}
+	return orderID, razorpayPaymentID, nil
+}
+
+// tenantAdminEmail is the email of the tenant's longest-standing active admin, who
+// the gateway contacts about the charge
+func (s *PaymentService) tenantAdminEmail(ctx context.Context, tenantID uuid.UUID) (string, error) {
+	var admin sharedmodels.User
+	if err := s.db.WithContext(ctx).Select("email").
+		Where("tenant_id = ? AND role = ? AND is_active = ?", tenantID, sharedmodels.RoleAdmin, true).
+		Order("created_at").
+		First(&admin).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", errors.New("tenant has no active admin to bill")
+		}
+		return "", fmt.Errorf("failed to get tenant admin: %w", err)
+	}
+	return admin.Email, nil
+}
+
+// cancelPastDue cancels a subscription whose grace period ended without payment
+func (s *PaymentService) cancelPastDue(ctx context.Context, subscription *models.Subscription, now time.Time) (bool, error) {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Subscription{}).
+			Where("id = ? AND status = ?", subscription.ID, "past_due").
+			Updates(map[string]interface{}{
+				"status":                    "cancelled",
+				"cancelled_at":              now,
+				"cancellation_effective_at": now,
+				"ended_at":                  now,
+				"cancel_at_period_end":      false,
+				"auto_renew":                false,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to cancel past due subscription: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		// Paid or changed since it was loaded
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if subscription.RazorpaySubscriptionID != "" {
+		if err := s.paymentClient.CancelSubscription(subscription.RazorpaySubscriptionID, false); err != nil {
+			log.Printf("Failed to cancel razorpay subscription %s: %v", subscription.RazorpaySubscriptionID, err)
+		}
+	}
+
+	s.notifyTenantAdmins(ctx, subscription.TenantID, sharedmodels.EmailEventSubscriptionCancelled, map[string]interface{}{
+		"amount":    formatAmount(subscription.Currency, subscription.Amount),
+		"plan_name": subscription.Plan.DisplayName,
+	})
+	return true, nil
+}
+
+// notifyTenantAdmins emails a tenant's admins about their subscription. Failures
+// are logged and never affect billing. A service working in a transaction holds
+// the email back until the transaction commits.
+func (s *PaymentService) notifyTenantAdmins(ctx context.Context, tenantID uuid.UUID, event string, vars map[string]interface{}) {
+	if s.notifier == nil {
+		return
+	}
+	if s.notices != nil {
+		*s.notices = append(*s.notices, tenantNotice{tenantID: tenantID, event: event, vars: vars})
+		return
+	}
+
+	var tenant sharedmodels.Tenant
+	if err := s.db.WithContext(ctx).Select("name").First(&tenant, tenantID).Error; err == nil {
+		vars["company_name"] = tenant.Name
+	}
+
+	recipients, err := s.notifier.GetRecipientsByRole(ctx, tenantID, sharedmodels.RoleAdmin)
+	if err == nil {
+		err = s.notifier.Send(ctx, tenantID, event, recipients, vars)
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to send %s notification: %v", event, err)
+	}
+}
+
+// DunningWorker retries failed subscription payments and cancels subscriptions
+// left past due
+type DunningWorker struct {
+	service  *PaymentService
+	interval time.Duration
+}
+
+// NewDunningWorker creates a worker that runs dunning at the given interval
+func NewDunningWorker(service *PaymentService, interval time.Duration) *DunningWorker {
+	return &DunningWorker{
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Start runs dunning in the background until ctx is cancelled
+func (w *DunningWorker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				retried, cancelled, err := w.service.RunDunning(ctx)
+				if err != nil {
+					log.Printf("Dunning run failed: %v", err)
+				} else if retried > 0 || cancelled > 0 {
+					log.Printf("Dunning retried %d payment(s) and cancelled %d subscription(s)", retried, cancelled)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/liquorpro/go-backend/internal/saas/models"
+	"github.com/liquorpro/go-backend/pkg/shared/pdf"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
+)
+
+type InvoiceService struct {
+	db       *gorm.DB
+	settings *settings.Service
+}
+
+func NewInvoiceService(db *gorm.DB, settingsService *settings.Service) *InvoiceService {
+	return &InvoiceService{
+		db:       db,
+		settings: settingsService,
+	}
+}
+
+// RenderInvoicePDF renders one of the tenant's invoices as a PDF and returns it with
+// a download filename. Payments already made against the invoice are listed, so
+// partly paid invoices show both the paid and the outstanding amount.
+func (s *InvoiceService) RenderInvoicePDF(ctx context.Context, invoiceID, tenantID uuid.UUID) ([]byte, string, error) {
+	var invoice models.Invoice
+	err := s.db.WithContext(ctx).
+		Preload("Subscription.Plan").
+		Preload("Payments", "status IN ?", []string{"succeeded", models.PaymentPartiallyRefunded, models.PaymentRefunded}).
+		Where("id = ? AND subscription_id IN (?)", invoiceID,
+			s.db.Model(&models.Subscription{}).Select("id").Where("tenant_id = ?", tenantID)).
+		First(&invoice).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", errors.New("invoice not found")
+		}
+		return nil, "", fmt.Errorf("failed to get invoice: %w", err)
+	}
+
+	var tenant struct {
+		Name   string
+		Domain string
+	}
+	if err := s.db.WithContext(ctx).Table("tenants").Select("name, domain").
+		Where("id = ?", tenantID).Scan(&tenant).Error; err != nil {
+		return nil, "", fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	var gstNumber string
+	if s.settings != nil {
+		gstNumber = s.settings.GetString(ctx, tenantID, settings.KeyGSTNumber)
+	}
+
+	currency := invoice.Currency
+	money := func(amount float64) string {
+		return fmt.Sprintf("%s %.2f", currency, amount)
+	}
+
+	doc := pdf.New("Invoice " + invoice.InvoiceNumber)
+
+	// Company header
+	if tenant.Name != "" {
+		doc.Heading(strings.ToUpper(tenant.Name))
+	}
+	if tenant.Domain != "" {
+		doc.Line("%s", tenant.Domain)
+	}
+	if gstNumber != "" {
+		doc.Line("GSTIN: %s", gstNumber)
+	}
+	doc.Blank()
+
+	title := "INVOICE"
+	if gstNumber != "" {
+		title = "TAX INVOICE"
+	}
+	doc.Heading(title)
+	doc.Line("%-16s %s", "Invoice number:", invoice.InvoiceNumber)
+	doc.Line("%-16s %s", "Invoice date:", invoice.CreatedAt.Format("02 Jan 2006"))
+	doc.Line("%-16s %s", "Due date:", invoice.DueDate.Format("02 Jan 2006"))
+	doc.Line("%-16s %s", "Status:", strings.ToUpper(invoice.Status))
+	doc.Blank()
+
+	if invoice.BillingName != "" || invoice.BillingEmail != "" || invoice.BillingAddress != "" {
+		doc.Heading("Bill to")
+		for _, value := range []string{invoice.BillingName, invoice.BillingEmail} {
+			if value != "" {
+				doc.Line("%s", value)
+			}
+		}
+		for _, addressLine := range strings.Split(invoice.BillingAddress, "\n") {
+			if addressLine = strings.TrimSpace(addressLine); addressLine != "" {
+				doc.Line("%s", addressLine)
+			}
+		}
+		doc.Blank()
+	}
+
+	// Line items; an invoice covers one subscription period
+	description := "Subscription"
+	if plan := invoice.Subscription.Plan; plan.DisplayName != "" {
+		description = fmt.Sprintf("%s subscription (%s)", plan.DisplayName, invoice.Subscription.BillingCycle)
+	}
+	doc.Heading(fmt.Sprintf("%-60s %34s", "Description", "Amount"))
+	doc.Rule()
+	doc.Line("%-60.60s %34s", description, money(invoice.Amount))
+	doc.Line("  Period %s to %s", invoice.PeriodStart.Format("02 Jan 2006"), invoice.PeriodEnd.Format("02 Jan 2006"))
+	doc.Rule()
+
+	// Totals and tax breakdown
+	taxable := invoice.Amount - invoice.Discount
+	doc.Line("%60s %34s", "Subtotal", money(invoice.Amount))
+	if invoice.Discount > 0 {
+		doc.Line("%60s %34s", "Discount", "-"+money(invoice.Discount))
+		doc.Line("%60s %34s", "Taxable amount", money(taxable))
+	}
+	if invoice.Tax > 0 {
+		label := "Tax"
+		if gstNumber != "" {
+			label = "GST"
+		}
+		if taxable > 0 {
+			label = fmt.Sprintf("%s @ %g%%", label, math.Round(invoice.Tax/taxable*10000)/100)
+		}
+		doc.Line("%60s %34s", label, money(invoice.Tax))
+		if gstNumber != "" {
+			half := math.Round(invoice.Tax*50) / 100
+			doc.Line("%60s %34s", "  CGST", money(half))
+			doc.Line("%60s %34s", "  SGST", money(invoice.Tax-half))
+		}
+	}
+	doc.Heading(fmt.Sprintf("%60s %34s", "Total", money(invoice.Total)))
+	doc.Blank()
+
+	// Payments received
+	paid := 0.0
+	if len(invoice.Payments) > 0 {
+		doc.Heading("Payments")
+		for _, payment := range invoice.Payments {
+			received := payment.Amount - payment.RefundedAmount
+			paid += received
+
+			date := payment.CreatedAt
+			if payment.ProcessedAt != nil {
+				date = *payment.ProcessedAt
+			}
+			method := payment.PaymentMethod
+			if method == "" {
+				method = "payment"
+			}
+			note := ""
+			if payment.RefundedAmount > 0 {
+				note = fmt.Sprintf(" (refunded %s)", money(payment.RefundedAmount))
+			}
+			doc.Line("%-12s %-20.20s %-28.28s %32s", date.Format("02 Jan 2006"), method, note, money(received))
+		}
+		doc.Rule()
+	}
+
+	due := math.Max(invoice.Total-paid, 0)
+	doc.Line("%60s %34s", "Amount paid", money(paid))
+	doc.Heading(fmt.Sprintf("%60s %34s", "Amount due", money(due)))
+
+	if invoice.Notes != "" {
+		doc.Blank()
+		doc.Heading("Notes")
+		for _, noteLine := range strings.Split(invoice.Notes, "\n") {
+			doc.Line("%s", noteLine)
+		}
+	}
+
+	return doc.Bytes(), fmt.Sprintf("invoice-%s.pdf", invoice.InvoiceNumber), nil
+}
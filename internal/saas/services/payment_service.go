@@ -2,29 +2,46 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/liquorpro/go-backend/internal/saas/models"
 	"github.com/liquorpro/go-backend/pkg/shared/config"
+	"github.com/liquorpro/go-backend/pkg/shared/notification"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
 )
 
 type PaymentService struct {
 	db            *gorm.DB
 	config        *config.Config
 	paymentClient *RazorpayClient
+	notifier      *notification.Service
+	notices       *[]tenantNotice // held back until the transaction commits
 }
 
-func NewPaymentService(db *gorm.DB, cfg *config.Config) *PaymentService {
+// tenantNotice is a notification to a tenant's admins
+type tenantNotice struct {
+	tenantID uuid.UUID
+	event    string
+	vars     map[string]interface{}
+}
+
+func NewPaymentService(db *gorm.DB, cfg *config.Config, notifier *notification.Service) *PaymentService {
 	paymentClient := NewRazorpayClient(cfg)
 	return &PaymentService{
 		db:            db,
 		config:        cfg,
 		paymentClient: paymentClient,
+		notifier:      notifier,
 	}
 }
 
@@ -35,11 +52,24 @@ func (s *PaymentService) CreatePayment(ctx context.Context, req *models.CreatePa
 		return nil, fmt.Errorf("subscription not found: %w", err)
 	}
 
+	// A payment is in its subscription's currency
+	currency := subscription.Currency
+	if req.Currency != "" {
+		normalized, err := NormalizeCurrency(req.Currency)
+		if err != nil {
+			return nil, err
+		}
+		if normalized != subscription.Currency {
+			return nil, fmt.Errorf("payment currency must match the subscription's currency (%s)", subscription.Currency)
+		}
+		currency = normalized
+	}
+
 	// Create Razorpay order
 	amountInPaise := int64(req.Amount * 100) // Convert to paise
 	receipt := fmt.Sprintf("payment_%s_%d", req.SubscriptionID.String()[:8], time.Now().Unix())
 	
-	orderID, err := s.paymentClient.CreateOrder(amountInPaise, req.Currency, receipt)
+	orderID, err := s.paymentClient.CreateOrder(amountInPaise, currency, receipt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create razorpay order: %w", err)
 	}
@@ -49,17 +79,13 @@ func (s *PaymentService) CreatePayment(ctx context.Context, req *models.CreatePa
 		ID:                uuid.New(),
 		SubscriptionID:    req.SubscriptionID,
 		Amount:            req.Amount,
-		Currency:          req.Currency,
+		Currency:          currency,
 		Status:            "pending",
 		PaymentMethod:     req.PaymentMethod,
 		RazorpayOrderID:   orderID,
 		Description:       req.Description,
 	}
 
-	if req.Currency == "" {
-		payment.Currency = "INR"
-	}
-
 	if err := s.db.Create(&payment).Error; err != nil {
 		return nil, fmt.Errorf("failed to create payment: %w", err)
 	}
@@ -67,6 +93,25 @@ func (s *PaymentService) CreatePayment(ctx context.Context, req *models.CreatePa
 	return &payment, nil
 }
 
+// withTx returns a copy of the service that works in tx. The notifications it
+// sends are held back, to be sent by sendNotices once tx has committed.
+func (s *PaymentService) withTx(tx *gorm.DB) *PaymentService {
+	return &PaymentService{
+		db:            tx,
+		config:        s.config,
+		paymentClient: s.paymentClient,
+		notifier:      s.notifier,
+		notices:       &[]tenantNotice{},
+	}
+}
+
+// sendNotices sends the notifications held back by a service from withTx
+func (s *PaymentService) sendNotices(ctx context.Context, txService *PaymentService) {
+	for _, notice := range *txService.notices {
+		s.notifyTenantAdmins(ctx, notice.tenantID, notice.event, notice.vars)
+	}
+}
+
 func (s *PaymentService) GetPayment(ctx context.Context, id uuid.UUID) (*models.Payment, error) {
 	var payment models.Payment
 	
@@ -127,126 +172,254 @@ func (s *PaymentService) UpdatePaymentStatus(ctx context.Context, paymentID uuid
 			return fmt.Errorf("failed to handle successful payment: %w", err)
 		}
 	}
+	if status == "failed" {
+		return s.startDunning(ctx, &payment, "Payment failed")
+	}
 
 	return nil
 }
 
-func (s *PaymentService) RefundPayment(ctx context.Context, paymentID uuid.UUID, amount float64, reason string) (*models.Payment, error) {
+// RefundPayment refunds part or all of a captured payment through Razorpay. A zero
+// amount refunds whatever has not been refunded yet. It returns the updated payment
+// and the refund entry recorded against it.
+func (s *PaymentService) RefundPayment(ctx context.Context, paymentID uuid.UUID, req *models.RefundPaymentRequest) (*models.Payment, *models.Payment, error) {
 	var payment models.Payment
-	
-	if err := s.db.First(&payment, paymentID).Error; err != nil {
-		return nil, fmt.Errorf("payment not found: %w", err)
-	}
+	var refund *models.Payment
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, paymentID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("payment not found")
+			}
+			return fmt.Errorf("failed to get payment: %w", err)
+		}
+
+		switch payment.Status {
+		case "succeeded", models.PaymentPartiallyRefunded:
+		case models.PaymentRefunded:
+			return errors.New("payment has already been fully refunded")
+		default:
+			return fmt.Errorf("cannot refund a %s payment", payment.Status)
+		}
+
+		refundable := utils.RoundToTwoDecimals(payment.Amount - payment.RefundedAmount)
+		amount := req.Amount
+		if amount == 0 {
+			amount = refundable
+		}
+		if amount > refundable {
+			return fmt.Errorf("refund amount %.2f exceeds the refundable amount %.2f", amount, refundable)
+		}
 
-	if payment.Status != "succeeded" {
-		return nil, fmt.Errorf("can only refund successful payments")
+		var err error
+		refund, err = refundPayment(tx, s.paymentClient, &payment, amount, req.Reason, time.Now())
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
+	return &payment, refund, nil
+}
+
+// refundPayment refunds amount of a captured payment with Razorpay, marks the payment
+// partially or fully refunded and records the refund as a negative payment entry so
+// revenue reflects it when the refund happened
+func refundPayment(tx *gorm.DB, client *RazorpayClient, payment *models.Payment, amount float64, reason string, now time.Time) (*models.Payment, error) {
 	if payment.RazorpayPaymentID == "" {
-		return nil, fmt.Errorf("no razorpay payment ID found")
+		return nil, errors.New("payment has no razorpay payment ID to refund")
+	}
+	amount = utils.RoundToTwoDecimals(amount)
+	if amount <= 0 {
+		return nil, errors.New("refund amount must be greater than zero")
 	}
 
-	// Process refund with Razorpay
-	amountInPaise := int64(amount * 100)
-	refundData, err := s.paymentClient.RefundPayment(payment.RazorpayPaymentID, amountInPaise)
+	refundData, err := client.RefundPayment(payment.RazorpayPaymentID, int64(math.Round(amount*100)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to process refund with razorpay: %w", err)
 	}
+	refundID, _ := refundData["id"].(string)
 
-	// Update payment record
-	payment.Status = "refunded"
-	payment.RefundAmount = amount
+	payment.RefundedAmount = utils.RoundToTwoDecimals(payment.RefundedAmount + amount)
+	payment.Status = models.PaymentPartiallyRefunded
+	if payment.RefundedAmount >= payment.Amount {
+		payment.Status = models.PaymentRefunded
+	}
 	payment.RefundReason = reason
-	now := time.Now()
 	payment.RefundedAt = &now
-
-	if refundID, ok := refundData["id"].(string); ok {
-		// Store refund ID in notes or create separate refund table if needed
-		payment.Description += fmt.Sprintf(" (Refund ID: %s)", refundID)
-	}
-
-	if err := s.db.Save(&payment).Error; err != nil {
+	payment.RazorpayRefundID = refundID
+	if err := tx.Save(payment).Error; err != nil {
 		return nil, fmt.Errorf("failed to update payment: %w", err)
 	}
 
-	return &payment, nil
+	refund := &models.Payment{
+		ID:                uuid.New(),
+		SubscriptionID:    payment.SubscriptionID,
+		InvoiceID:         payment.InvoiceID,
+		Amount:            -amount,
+		Currency:          payment.Currency,
+		Status:            models.PaymentRefund,
+		PaymentMethod:     payment.PaymentMethod,
+		RazorpayPaymentID: payment.RazorpayPaymentID,
+		RazorpayRefundID:  refundID,
+		ProcessedAt:       &now,
+		RefundReason:      reason,
+		RefundOfID:        &payment.ID,
+		Description:       fmt.Sprintf("Refund of payment %s", payment.ID),
+	}
+	if err := tx.Create(refund).Error; err != nil {
+		return nil, fmt.Errorf("failed to record refund: %w", err)
+	}
+
+	return refund, nil
 }
 
-func (s *PaymentService) HandleWebhook(ctx context.Context, eventType string, payload []byte) error {
-	// Parse webhook payload
+// ErrInvalidWebhookSignature is returned when a webhook's signature does not match its payload
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// ErrWebhookSecretMissing is returned when no webhook secret is configured to verify against
+var ErrWebhookSecretMissing = errors.New("webhook secret is not configured")
+
+// VerifyWebhook checks a Razorpay webhook signature against the configured secret
+func (s *PaymentService) VerifyWebhook(payload []byte, signature string) error {
+	if s.config == nil || s.config.Razorpay.WebhookSecret == "" {
+		return ErrWebhookSecretMissing
+	}
+	if !s.paymentClient.VerifyWebhookSignature(payload, signature, s.config.Razorpay.WebhookSecret) {
+		return ErrInvalidWebhookSignature
+	}
+	return nil
+}
+
+// WebhookProcessingTimeout is how long a webhook event may stay pending or
+// processing before it is taken to have been abandoned, e.g. by a restart, and is
+// processed again
+const WebhookProcessingTimeout = 10 * time.Minute
+
+// RecordWebhook stores a verified webhook under its Razorpay event ID, keeping the
+// raw payload for debugging. Without an event ID the payload hash stands in for it.
+// It returns nil when the event was already processed or is being processed, so
+// retries are acknowledged without being applied twice; failed and abandoned
+// events are returned again to be retried.
+func (s *PaymentService) RecordWebhook(ctx context.Context, eventID string, payload []byte) (*models.WebhookEvent, error) {
 	var webhookPayload models.RazorpayWebhookPayload
 	if err := json.Unmarshal(payload, &webhookPayload); err != nil {
-		return fmt.Errorf("failed to parse webhook payload: %w", err)
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	if eventID == "" {
+		sum := sha256.Sum256(payload)
+		eventID = "sha256:" + hex.EncodeToString(sum[:])
 	}
 
-	// Create webhook event record
 	webhookEvent := models.WebhookEvent{
 		ID:        uuid.New(),
 		Provider:  "razorpay",
-		EventType: eventType,
-		EventID:   fmt.Sprintf("evt_%d", time.Now().Unix()),
+		EventType: webhookPayload.Event,
+		EventID:   eventID,
 		Status:    "pending",
 		Payload:   string(payload),
 	}
 
-	if err := s.db.Create(&webhookEvent).Error; err != nil {
-		return fmt.Errorf("failed to create webhook event: %w", err)
+	result := s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "event_id"}}, DoNothing: true}).
+		Create(&webhookEvent)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to record webhook event: %w", result.Error)
+	}
+	if result.RowsAffected == 1 {
+		return &webhookEvent, nil
 	}
 
-	// Process webhook based on event type
-	switch eventType {
-	case "payment.captured":
-		err := s.handlePaymentCaptured(&webhookPayload)
-		if err != nil {
-			webhookEvent.Status = "failed"
-			webhookEvent.ErrorMessage = err.Error()
-		} else {
-			webhookEvent.Status = "processed"
-			now := time.Now()
-			webhookEvent.ProcessedAt = &now
+	// Seen before: only a failed or abandoned attempt is worth another go
+	if err := s.db.WithContext(ctx).Where("event_id = ?", eventID).First(&webhookEvent).Error; err != nil {
+		return nil, fmt.Errorf("failed to get webhook event: %w", err)
+	}
+	switch webhookEvent.Status {
+	case "failed":
+		return &webhookEvent, nil
+	case "pending", "processing":
+		if webhookEvent.UpdatedAt.Before(time.Now().Add(-WebhookProcessingTimeout)) {
+			return &webhookEvent, nil
 		}
+	}
+	return nil, nil
+}
 
-	case "payment.failed":
-		err := s.handlePaymentFailed(&webhookPayload)
-		if err != nil {
-			webhookEvent.Status = "failed"
-			webhookEvent.ErrorMessage = err.Error()
-		} else {
-			webhookEvent.Status = "processed"
-			now := time.Now()
-			webhookEvent.ProcessedAt = &now
-		}
+// ProcessWebhook applies a recorded webhook event. The event is claimed first so
+// that concurrent deliveries of the same event are only applied once. A claim
+// older than WebhookProcessingTimeout was abandoned and can be taken over. The
+// event is applied and marked processed in one transaction, so a failed attempt
+// leaves nothing behind for a retry to apply twice.
+func (s *PaymentService) ProcessWebhook(ctx context.Context, webhookEvent *models.WebhookEvent) error {
+	claim := s.db.WithContext(ctx).Model(&models.WebhookEvent{}).
+		Where("id = ?", webhookEvent.ID).
+		Where("(status IN ? OR (status = ? AND updated_at < ?))", []string{"pending", "failed"},
+			"processing", time.Now().Add(-WebhookProcessingTimeout)).
+		Updates(map[string]interface{}{"status": "processing", "retries": gorm.Expr("retries + 1")})
+	if claim.Error != nil {
+		return fmt.Errorf("failed to claim webhook event: %w", claim.Error)
+	}
+	if claim.RowsAffected == 0 {
+		return nil
+	}
 
-	case "subscription.charged":
-		err := s.handleSubscriptionCharged(&webhookPayload)
+	var webhookPayload models.RazorpayWebhookPayload
+	if err := json.Unmarshal([]byte(webhookEvent.Payload), &webhookPayload); err != nil {
+		return s.failWebhook(ctx, webhookEvent, err)
+	}
+
+	var txService *PaymentService
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txService = s.withTx(tx)
+
+		var err error
+		switch webhookEvent.EventType {
+		case "payment.captured":
+			err = txService.handlePaymentCaptured(ctx, &webhookPayload)
+		case "payment.failed":
+			err = txService.handlePaymentFailed(ctx, &webhookPayload)
+		case "subscription.charged":
+			err = txService.handleSubscriptionCharged(ctx, &webhookPayload)
+		default:
+			// Unknown event type, mark as processed but don't handle
+		}
 		if err != nil {
-			webhookEvent.Status = "failed"
-			webhookEvent.ErrorMessage = err.Error()
-		} else {
-			webhookEvent.Status = "processed"
-			now := time.Now()
-			webhookEvent.ProcessedAt = &now
+			return err
 		}
 
-	default:
-		// Unknown event type, mark as processed but don't handle
-		webhookEvent.Status = "processed"
-		now := time.Now()
-		webhookEvent.ProcessedAt = &now
-	}
-
-	// Update webhook event status
-	if err := s.db.Save(&webhookEvent).Error; err != nil {
-		return fmt.Errorf("failed to update webhook event: %w", err)
+		if err := tx.Model(&models.WebhookEvent{}).Where("id = ?", webhookEvent.ID).Updates(map[string]interface{}{
+			"status":        "processed",
+			"error_message": "",
+			"processed_at":  time.Now(),
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update webhook event: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return s.failWebhook(ctx, webhookEvent, err)
 	}
 
+	s.sendNotices(ctx, txService)
 	return nil
 }
 
-func (s *PaymentService) handlePaymentCaptured(payload *models.RazorpayWebhookPayload) error {
-	// Find payment by Razorpay payment ID
+// failWebhook marks a claimed webhook event failed, to be retried, and returns
+// the error it failed with
+func (s *PaymentService) failWebhook(ctx context.Context, webhookEvent *models.WebhookEvent, err error) error {
+	if saveErr := s.db.WithContext(ctx).Model(&models.WebhookEvent{}).Where("id = ?", webhookEvent.ID).Updates(map[string]interface{}{
+		"status":        "failed",
+		"error_message": err.Error(),
+	}).Error; saveErr != nil {
+		return fmt.Errorf("failed to update webhook event: %w", saveErr)
+	}
+	return err
+}
+
+func (s *PaymentService) handlePaymentCaptured(ctx context.Context, payload *models.RazorpayWebhookPayload) error {
+	// Find payment by Razorpay payment ID; its refund entries share the ID
 	var payment models.Payment
-	err := s.db.Where("razorpay_payment_id = ?", payload.Payload.Payment.ID).First(&payment).Error
+	err := s.db.Where("razorpay_payment_id = ? AND refund_of_id IS NULL", payload.Payload.Payment.ID).First(&payment).Error
 	if err != nil {
 		return fmt.Errorf("payment not found for razorpay ID %s: %w", payload.Payload.Payment.ID, err)
 	}
@@ -265,10 +438,10 @@ func (s *PaymentService) handlePaymentCaptured(payload *models.RazorpayWebhookPa
 	return s.handleSuccessfulPayment(&payment)
 }
 
-func (s *PaymentService) handlePaymentFailed(payload *models.RazorpayWebhookPayload) error {
-	// Find payment by Razorpay payment ID
+func (s *PaymentService) handlePaymentFailed(ctx context.Context, payload *models.RazorpayWebhookPayload) error {
+	// Find payment by Razorpay payment ID; its refund entries share the ID
 	var payment models.Payment
-	err := s.db.Where("razorpay_payment_id = ?", payload.Payload.Payment.ID).First(&payment).Error
+	err := s.db.Where("razorpay_payment_id = ? AND refund_of_id IS NULL", payload.Payload.Payment.ID).First(&payment).Error
 	if err != nil {
 		return fmt.Errorf("payment not found for razorpay ID %s: %w", payload.Payload.Payment.ID, err)
 	}
@@ -281,10 +454,11 @@ func (s *PaymentService) handlePaymentFailed(payload *models.RazorpayWebhookPayl
 		return fmt.Errorf("failed to update payment status: %w", err)
 	}
 
-	return nil
+	// Failed subscription payments are retried on the dunning schedule
+	return s.startDunning(ctx, &payment, payment.FailureReason)
 }
 
-func (s *PaymentService) handleSubscriptionCharged(payload *models.RazorpayWebhookPayload) error {
+func (s *PaymentService) handleSubscriptionCharged(ctx context.Context, payload *models.RazorpayWebhookPayload) error {
 	// Find subscription by Razorpay subscription ID
 	var subscription models.Subscription
 	err := s.db.Where("razorpay_subscription_id = ?", payload.Payload.Subscription.ID).First(&subscription).Error
@@ -292,13 +466,25 @@ func (s *PaymentService) handleSubscriptionCharged(payload *models.RazorpayWebho
 		return fmt.Errorf("subscription not found for razorpay ID %s: %w", payload.Payload.Subscription.ID, err)
 	}
 
+	// A charge already recorded, e.g. from its payment.captured event, is not
+	// credited again
+	var existing int64
+	if err := s.db.Model(&models.Payment{}).
+		Where("razorpay_payment_id = ? AND refund_of_id IS NULL", payload.Payload.Payment.ID).
+		Count(&existing).Error; err != nil {
+		return fmt.Errorf("failed to check for an existing payment: %w", err)
+	}
+	if existing > 0 {
+		return nil
+	}
+
 	// Create payment record for the charge
 	amountInRupees := float64(payload.Payload.Payment.Amount) / 100 // Convert from paise
 	payment := models.Payment{
 		ID:                uuid.New(),
 		SubscriptionID:    subscription.ID,
 		Amount:            amountInRupees,
-		Currency:          "INR",
+		Currency:          subscription.Currency,
 		Status:            "succeeded",
 		PaymentMethod:     payload.Payload.Payment.Method,
 		RazorpayPaymentID: payload.Payload.Payment.ID,
@@ -313,7 +499,12 @@ func (s *PaymentService) handleSubscriptionCharged(payload *models.RazorpayWebho
 	}
 
 	// Handle successful payment
-	return s.handleSuccessfulPayment(&payment)
+	if err := s.handleSuccessfulPayment(&payment); err != nil {
+		return err
+	}
+
+	// A renewal uses up one of the coupon's discounted periods
+	return countDiscountedPeriod(s.db, &subscription, now)
 }
 
 func (s *PaymentService) handleSuccessfulPayment(payment *models.Payment) error {
@@ -325,15 +516,22 @@ func (s *PaymentService) handleSuccessfulPayment(payment *models.Payment) error
 
 	// If subscription is in trial, activate it
 	if subscription.Status == "trial" {
+		now := time.Now()
 		subscription.Status = "active"
+		subscription.ConvertedAt = &now
 		if err := s.db.Save(&subscription).Error; err != nil {
 			return fmt.Errorf("failed to activate subscription: %w", err)
 		}
 	}
 
+	// A successful payment settles any failed payment still being chased
+	if err := endDunning(s.db, &subscription, payment, time.Now()); err != nil {
+		return err
+	}
+
 	// Create invoice if needed
 	if payment.InvoiceID == nil {
-		invoice, err := s.createInvoice(&subscription, payment)
+		invoice, err := createInvoice(s.db, &subscription, payment)
 		if err != nil {
 			return fmt.Errorf("failed to create invoice: %w", err)
 		}
@@ -346,7 +544,8 @@ func (s *PaymentService) handleSuccessfulPayment(payment *models.Payment) error
 	return nil
 }
 
-func (s *PaymentService) createInvoice(subscription *models.Subscription, payment *models.Payment) (*models.Invoice, error) {
+// createInvoice writes the paid invoice for a subscription payment
+func createInvoice(tx *gorm.DB, subscription *models.Subscription, payment *models.Payment) (*models.Invoice, error) {
 	invoiceNumber := fmt.Sprintf("INV-%s-%d", subscription.ID.String()[:8], time.Now().Unix())
 	
 	invoice := models.Invoice{
@@ -367,10 +566,16 @@ func (s *PaymentService) createInvoice(subscription *models.Subscription, paymen
 		Notes:          "Generated automatically on payment success",
 	}
 
+	// A charge for the subscription's discounted amount shows the coupon discount
+	if subscription.Discount > 0 && payment.Amount == subscription.Amount {
+		invoice.Amount = utils.RoundAmount(payment.Amount+subscription.Discount, 2, utils.RoundHalfUp)
+		invoice.Discount = subscription.Discount
+	}
+
 	now := time.Now()
 	invoice.PaidAt = &now
 
-	if err := s.db.Create(&invoice).Error; err != nil {
+	if err := tx.Create(&invoice).Error; err != nil {
 		return nil, fmt.Errorf("failed to create invoice: %w", err)
 	}
 
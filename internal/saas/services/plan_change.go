@@ -0,0 +1,379 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/liquorpro/go-backend/internal/saas/models"
+	"github.com/liquorpro/go-backend/pkg/shared/usage"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+)
+
+// UpgradeSubscription moves a subscription to a higher-priced plan straight away,
+// prorating the rest of the current period
+func (s *SubscriptionService) UpgradeSubscription(ctx context.Context, subID uuid.UUID, req *models.ChangePlanRequest) (*models.PlanChangeResponse, error) {
+	if req.AtPeriodEnd {
+		return nil, errors.New("upgrades take effect immediately")
+	}
+	return s.changePlan(ctx, subID, req, models.PlanChangeUpgrade)
+}
+
+// DowngradeSubscription moves a subscription to a lower-priced plan, either straight
+// away with proration or, with AtPeriodEnd, when the current period ends
+func (s *SubscriptionService) DowngradeSubscription(ctx context.Context, subID uuid.UUID, req *models.ChangePlanRequest) (*models.PlanChangeResponse, error) {
+	return s.changePlan(ctx, subID, req, models.PlanChangeDowngrade)
+}
+
+// changePlan switches plans in one transaction. An immediate change credits the
+// unused part of the current plan, charges the new plan for the same remaining time
+// and records the net on an adjustment invoice and payment: a pending payment when
+// the tenant owes money, a negative one when they are owed a credit. Trials have not
+// been paid for, so they switch without proration.
+func (s *SubscriptionService) changePlan(ctx context.Context, subID uuid.UUID, req *models.ChangePlanRequest, direction string) (*models.PlanChangeResponse, error) {
+	var subscription models.Subscription
+	var change models.PlanChange
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Plan").First(&subscription, subID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("subscription not found")
+			}
+			return fmt.Errorf("failed to get subscription: %w", err)
+		}
+		if subscription.Status != "active" && subscription.Status != "trial" {
+			return fmt.Errorf("cannot change the plan of a %s subscription", subscription.Status)
+		}
+		if subscription.CancelAtPeriodEnd {
+			return errors.New("subscription is scheduled for cancellation")
+		}
+
+		var newPlan models.PricingPlan
+		if err := tx.Where("id = ? AND active = ?", req.NewPlanID, true).First(&newPlan).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("plan not found")
+			}
+			return fmt.Errorf("failed to get plan: %w", err)
+		}
+		if newPlan.ID == subscription.PlanID {
+			return errors.New("subscription is already on this plan")
+		}
+		if direction == models.PlanChangeUpgrade && newPlan.Price <= subscription.Plan.Price {
+			return errors.New("new plan must be a higher tier")
+		}
+		if direction == models.PlanChangeDowngrade && newPlan.Price >= subscription.Plan.Price {
+			return errors.New("new plan must be a lower tier")
+		}
+		if direction == models.PlanChangeDowngrade {
+			if err := checkPlanFits(tx, subscription.TenantID, &newPlan); err != nil {
+				return err
+			}
+		}
+
+		// Any downgrade already scheduled is superseded
+		if err := tx.Model(&models.PlanChange{}).
+			Where("subscription_id = ? AND status = ?", subscription.ID, models.PlanChangeScheduled).
+			Update("status", models.PlanChangeCancelled).Error; err != nil {
+			return fmt.Errorf("failed to cancel scheduled plan change: %w", err)
+		}
+
+		now := time.Now()
+		// A coupon on the subscription carries over to the new plan when it applies there
+		listAmount := planAmount(&newPlan, subscription.BillingCycle)
+		newDiscount, err := couponDiscountOnPlan(tx, &subscription, &newPlan, listAmount)
+		if err != nil {
+			return err
+		}
+		newAmount := utils.RoundAmount(listAmount-newDiscount, 2, utils.RoundHalfUp)
+		change = models.PlanChange{
+			ID:             uuid.New(),
+			SubscriptionID: subscription.ID,
+			TenantID:       subscription.TenantID,
+			FromPlanID:     subscription.PlanID,
+			ToPlanID:       newPlan.ID,
+			Direction:      direction,
+			Status:         models.PlanChangeApplied,
+			OldAmount:      subscription.Amount,
+			NewAmount:      newAmount,
+			MRRDelta:       utils.RoundToTwoDecimals(monthlyAmount(newAmount, subscription.BillingCycle) - monthlyAmount(subscription.Amount, subscription.BillingCycle)),
+			Reason:         req.Reason,
+			EffectiveAt:    now,
+		}
+
+		if req.AtPeriodEnd && subscription.CurrentPeriodEnd.After(now) {
+			change.Status = models.PlanChangeScheduled
+			change.EffectiveAt = subscription.CurrentPeriodEnd
+			if err := tx.Create(&change).Error; err != nil {
+				return fmt.Errorf("failed to record plan change: %w", err)
+			}
+			subscription.PendingPlanID = &newPlan.ID
+			subscription.PendingPlanAt = &subscription.CurrentPeriodEnd
+			if err := tx.Omit("Plan").Save(&subscription).Error; err != nil {
+				return fmt.Errorf("failed to schedule plan change: %w", err)
+			}
+			return nil
+		}
+
+		if subscription.Status != "trial" {
+			fraction := remainingFraction(&subscription, now)
+			change.Credit = utils.RoundToTwoDecimals(subscription.Amount * fraction)
+			change.Charge = utils.RoundToTwoDecimals(newAmount * fraction)
+			change.NetAmount = utils.RoundToTwoDecimals(change.Charge - change.Credit)
+		}
+		if change.NetAmount != 0 {
+			invoice, payment, err := s.createProrationAdjustment(tx, &subscription, &newPlan, &change, now)
+			if err != nil {
+				return err
+			}
+			change.InvoiceID = &invoice.ID
+			change.PaymentID = &payment.ID
+		}
+		if err := tx.Create(&change).Error; err != nil {
+			return fmt.Errorf("failed to record plan change: %w", err)
+		}
+
+		if subscription.Discount > 0 {
+			if err := setRedemptionDiscount(tx, &subscription, newDiscount, now); err != nil {
+				return err
+			}
+		}
+		subscription.PlanID = newPlan.ID
+		subscription.Amount = newAmount
+		subscription.Discount = newDiscount
+		subscription.PendingPlanID = nil
+		subscription.PendingPlanAt = nil
+		if err := tx.Omit("Plan").Save(&subscription).Error; err != nil {
+			return fmt.Errorf("failed to update subscription: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Preload("Plan").First(&subscription, subscription.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to load updated subscription: %w", err)
+	}
+
+	return &models.PlanChangeResponse{
+		Subscription: s.toSubscriptionResponse(&subscription),
+		Change:       &change,
+	}, nil
+}
+
+// createProrationAdjustment writes the invoice and payment for a mid-cycle plan change
+func (s *SubscriptionService) createProrationAdjustment(tx *gorm.DB, subscription *models.Subscription, newPlan *models.PricingPlan, change *models.PlanChange, now time.Time) (*models.Invoice, *models.Payment, error) {
+	description := fmt.Sprintf("Proration for %s from %s to %s", change.Direction, subscription.Plan.DisplayName, newPlan.DisplayName)
+
+	invoice := models.Invoice{
+		ID:             uuid.New(),
+		SubscriptionID: subscription.ID,
+		InvoiceNumber:  fmt.Sprintf("ADJ-%s-%d", subscription.ID.String()[:8], now.Unix()),
+		Status:         "open",
+		Amount:         change.Charge,
+		Currency:       subscription.Currency,
+		Discount:       change.Credit,
+		Total:          change.NetAmount,
+		PeriodStart:    now,
+		PeriodEnd:      subscription.CurrentPeriodEnd,
+		DueDate:        now,
+		BillingName:    fmt.Sprintf("Tenant %s", subscription.TenantID),
+		BillingEmail:   fmt.Sprintf("billing@tenant-%s.liquorpro.com", subscription.TenantID),
+		Notes: fmt.Sprintf("%s: %s for the rest of the period on %s, less a credit of %s for the unused part of %s",
+			description, formatAmount(subscription.Currency, change.Charge), newPlan.DisplayName,
+			formatAmount(subscription.Currency, change.Credit), subscription.Plan.DisplayName),
+	}
+
+	payment := models.Payment{
+		ID:             uuid.New(),
+		SubscriptionID: subscription.ID,
+		InvoiceID:      &invoice.ID,
+		Amount:         change.NetAmount,
+		Currency:       subscription.Currency,
+		Status:         "pending",
+		Description:    description,
+	}
+
+	// A credit is settled at once; it reduces revenue rather than waiting on the gateway
+	if change.NetAmount < 0 {
+		invoice.Status = "paid"
+		invoice.PaidAt = &now
+		payment.Status = "succeeded"
+		payment.PaymentMethod = "credit"
+		payment.ProcessedAt = &now
+	}
+
+	if err := tx.Create(&invoice).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to create adjustment invoice: %w", err)
+	}
+	if err := tx.Create(&payment).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to create adjustment payment: %w", err)
+	}
+	return &invoice, &payment, nil
+}
+
+// applyScheduledPlanChange switches a subscription to its pending plan once the
+// scheduled downgrade has taken effect
+func (s *SubscriptionService) applyScheduledPlanChange(ctx context.Context, subscription *models.Subscription) error {
+	if subscription.PendingPlanID == nil || subscription.PendingPlanAt == nil || subscription.PendingPlanAt.After(time.Now()) {
+		return nil
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var newPlan models.PricingPlan
+		if err := tx.First(&newPlan, *subscription.PendingPlanID).Error; err != nil {
+			return fmt.Errorf("failed to get scheduled plan: %w", err)
+		}
+
+		if err := tx.Model(&models.PlanChange{}).
+			Where("subscription_id = ? AND to_plan_id = ? AND status = ?", subscription.ID, newPlan.ID, models.PlanChangeScheduled).
+			Update("status", models.PlanChangeApplied).Error; err != nil {
+			return fmt.Errorf("failed to apply scheduled plan change: %w", err)
+		}
+
+		listAmount := planAmount(&newPlan, subscription.BillingCycle)
+		discount, err := couponDiscountOnPlan(tx, subscription, &newPlan, listAmount)
+		if err != nil {
+			return err
+		}
+		if subscription.Discount > 0 {
+			if err := setRedemptionDiscount(tx, subscription, discount, time.Now()); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&models.Subscription{}).Where("id = ?", subscription.ID).Updates(map[string]interface{}{
+			"plan_id":         newPlan.ID,
+			"amount":          utils.RoundAmount(listAmount-discount, 2, utils.RoundHalfUp),
+			"discount":        discount,
+			"pending_plan_id": nil,
+			"pending_plan_at": nil,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to apply scheduled plan change: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Preload("Plan").First(subscription, subscription.ID).Error
+}
+
+// ApplyScheduledPlanChanges applies every scheduled downgrade that is due
+func (s *SubscriptionService) ApplyScheduledPlanChanges(ctx context.Context) (int, error) {
+	var subscriptions []models.Subscription
+	if err := s.db.WithContext(ctx).
+		Where("pending_plan_id IS NOT NULL AND pending_plan_at <= ? AND status IN ?", time.Now(), usage.LiveSubscriptionStatuses).
+		Find(&subscriptions).Error; err != nil {
+		return 0, fmt.Errorf("failed to get scheduled plan changes: %w", err)
+	}
+
+	applied := 0
+	for i := range subscriptions {
+		if err := s.applyScheduledPlanChange(ctx, &subscriptions[i]); err != nil {
+			return applied, err
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// PlanChangeWorker applies scheduled downgrades once their period has ended
+type PlanChangeWorker struct {
+	service  *SubscriptionService
+	interval time.Duration
+}
+
+// NewPlanChangeWorker creates a worker that checks for due plan changes at the given interval
+func NewPlanChangeWorker(service *SubscriptionService, interval time.Duration) *PlanChangeWorker {
+	return &PlanChangeWorker{
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Start runs the check in the background until ctx is cancelled
+func (w *PlanChangeWorker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				applied, err := w.service.ApplyScheduledPlanChanges(ctx)
+				if err != nil {
+					log.Printf("Scheduled plan changes failed: %v", err)
+				} else if applied > 0 {
+					log.Printf("Applied %d scheduled plan change(s)", applied)
+				}
+			}
+		}
+	}()
+}
+
+// checkPlanFits rejects a downgrade when the tenant already has more than the new
+// plan allows
+func checkPlanFits(db *gorm.DB, tenantID uuid.UUID, plan *models.PricingPlan) error {
+	counts, err := usage.CountAll(db, tenantID)
+	if err != nil {
+		return err
+	}
+	limits := []struct {
+		resource string
+		count    int64
+		limit    int
+	}{
+		{usage.ResourceLocations, counts.Locations, plan.MaxLocations},
+		{usage.ResourceUsers, counts.Users, plan.MaxUsers},
+		{usage.ResourceProducts, counts.Products, plan.MaxProducts},
+	}
+	for _, l := range limits {
+		if l.limit > 0 && l.count > int64(l.limit) {
+			return fmt.Errorf("tenant has %d %s but the %s plan allows %d", l.count, l.resource, plan.DisplayName, l.limit)
+		}
+	}
+	return nil
+}
+
+// planAmount is what a plan costs per billing cycle, after the yearly discount
+func planAmount(plan *models.PricingPlan, billingCycle string) float64 {
+	amount := plan.Price
+	if billingCycle == "yearly" {
+		amount -= amount * (plan.YearlyDiscount / 100)
+	}
+	return amount
+}
+
+// monthlyAmount normalises a per-cycle amount to a month
+func monthlyAmount(amount float64, billingCycle string) float64 {
+	if billingCycle == "yearly" {
+		return amount / 12
+	}
+	return amount
+}
+
+// remainingFraction is the share of the current period still to run
+func remainingFraction(subscription *models.Subscription, now time.Time) float64 {
+	period := subscription.CurrentPeriodEnd.Sub(subscription.CurrentPeriodStart)
+	remaining := subscription.CurrentPeriodEnd.Sub(now)
+	if period <= 0 || remaining <= 0 {
+		return 0
+	}
+	return math.Min(remaining.Seconds()/period.Seconds(), 1)
+}
+
+func formatAmount(currency string, amount float64) string {
+	return fmt.Sprintf("%s %.2f", currency, amount)
+}
@@ -33,7 +33,7 @@ func (s *PlanService) CreatePlan(ctx context.Context, req *models.CreatePlanRequ
 		DisplayName:    req.DisplayName,
 		Description:    req.Description,
 		Price:          req.Price,
-		Currency:       "INR",
+		Currency:       DefaultCurrency,
 		BillingCycle:   req.BillingCycle,
 		TrialDays:      req.TrialDays,
 		MaxLocations:   req.MaxLocations,
@@ -49,13 +49,26 @@ func (s *PlanService) CreatePlan(ctx context.Context, req *models.CreatePlanRequ
 	}
 
 	if req.Currency != "" {
-		plan.Currency = req.Currency
+		currency, err := NormalizeCurrency(req.Currency)
+		if err != nil {
+			return nil, err
+		}
+		plan.Currency = currency
 	}
 
-	// Create plan in database
+	// Create plan in database; limits are written explicitly so that zero (unlimited)
+	// is not replaced by the column defaults
 	if err := s.db.Create(&plan).Error; err != nil {
 		return nil, fmt.Errorf("failed to create plan: %w", err)
 	}
+	if err := s.db.Model(&plan).Updates(map[string]interface{}{
+		"max_locations": req.MaxLocations,
+		"max_users":     req.MaxUsers,
+		"max_products":  req.MaxProducts,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to set plan limits: %w", err)
+	}
+	plan.MaxLocations, plan.MaxUsers, plan.MaxProducts = req.MaxLocations, req.MaxUsers, req.MaxProducts
 
 	// TODO: Create corresponding Razorpay plan
 	// razorpayPlanID, err := s.createRazorpayPlan(&plan)
@@ -135,7 +148,11 @@ func (s *PlanService) UpdatePlan(ctx context.Context, id uuid.UUID, req *models.
 	plan.YearlyDiscount = req.YearlyDiscount
 
 	if req.Currency != "" {
-		plan.Currency = req.Currency
+		currency, err := NormalizeCurrency(req.Currency)
+		if err != nil {
+			return nil, err
+		}
+		plan.Currency = currency
 	}
 
 	if err := s.db.Save(&plan).Error; err != nil {
@@ -252,9 +269,9 @@ func (s *PlanService) InitializeDefaultPlans(ctx context.Context) error {
 			Currency:    "INR",
 			BillingCycle: "monthly",
 			TrialDays:   60, // 2 months free trial
-			MaxLocations: -1, // Unlimited
-			MaxUsers:    -1,  // Unlimited
-			MaxProducts: -1,  // Unlimited
+			MaxLocations: 0, // Unlimited
+			MaxUsers:    0,  // Unlimited
+			MaxProducts: 0,  // Unlimited
 			Features: []string{
 				"Enterprise inventory management",
 				"Unlimited locations & users",
@@ -283,9 +300,17 @@ func (s *PlanService) InitializeDefaultPlans(ctx context.Context) error {
 
 	// Create plans in database
 	for _, plan := range defaultPlans {
+		limits := map[string]interface{}{
+			"max_locations": plan.MaxLocations,
+			"max_users":     plan.MaxUsers,
+			"max_products":  plan.MaxProducts,
+		}
 		if err := s.db.Create(&plan).Error; err != nil {
 			return fmt.Errorf("failed to create default plan %s: %w", plan.Name, err)
 		}
+		if err := s.db.Model(&plan).Updates(limits).Error; err != nil {
+			return fmt.Errorf("failed to set default plan %s limits: %w", plan.Name, err)
+		}
 	}
 
 	return nil
@@ -320,8 +345,8 @@ func (s *PlanService) ValidatePlanLimits(ctx context.Context, planID uuid.UUID,
 		return fmt.Errorf("unknown resource type: %s", resourceType)
 	}
 
-	// -1 means unlimited
-	if limit != -1 && currentCount >= limit {
+	// Zero (or the older -1) means unlimited
+	if limit > 0 && currentCount >= limit {
 		return fmt.Errorf("plan limit exceeded: %s limit is %d, current count is %d", resourceType, limit, currentCount)
 	}
 
@@ -2,7 +2,10 @@ package services
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -93,7 +96,9 @@ func (r *RazorpayClient) CreateCustomer(name string) (string, error) {
 	return customer.ID, nil
 }
 
-func (r *RazorpayClient) CreateSubscription(customerID, planID string) (string, error) {
+// CreateSubscription subscribes a customer to a plan. A non-empty offerID applies a
+// gateway offer, such as a coupon's discount, to the recurring charges.
+func (r *RazorpayClient) CreateSubscription(customerID, planID, offerID string) (string, error) {
 	data := map[string]interface{}{
 		"plan_id":     planID,
 		"customer_id": customerID,
@@ -103,6 +108,9 @@ func (r *RazorpayClient) CreateSubscription(customerID, planID string) (string,
 			"created_by": "liquorpro_saas",
 		},
 	}
+	if offerID != "" {
+		data["offer_id"] = offerID
+	}
 
 	jsonData, err := json.Marshal(data)
 	if err != nil {
@@ -135,12 +143,27 @@ func (r *RazorpayClient) CreateSubscription(customerID, planID string) (string,
 	return subscription.ID, nil
 }
 
-func (r *RazorpayClient) CancelSubscription(subscriptionID string) error {
-	req, err := http.NewRequest("POST", r.baseURL+"/subscriptions/"+subscriptionID+"/cancel", nil)
+// CancelSubscription cancels a Razorpay subscription, either right away or once
+// the current billing cycle ends
+func (r *RazorpayClient) CancelSubscription(subscriptionID string, atCycleEnd bool) error {
+	data := map[string]interface{}{
+		"cancel_at_cycle_end": 0,
+	}
+	if atCycleEnd {
+		data["cancel_at_cycle_end"] = 1
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cancellation data: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", r.baseURL+"/subscriptions/"+subscriptionID+"/cancel", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
+	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Basic "+r.basicAuth())
 
 	resp, err := r.client.Do(req)
@@ -262,10 +285,107 @@ func (r *RazorpayClient) RefundPayment(paymentID string, amount int64) (map[stri
 	return refund, nil
 }
 
+// FetchTokens returns the IDs of the saved payment methods a customer has
+// authorised for recurring charges
+func (r *RazorpayClient) FetchTokens(customerID string) ([]string, error) {
+	req, err := http.NewRequest("GET", r.baseURL+"/customers/"+customerID+"/tokens", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Basic "+r.basicAuth())
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("razorpay token fetch failed with status: %d", resp.StatusCode)
+	}
+
+	var tokens struct {
+		Items []struct {
+			ID              string `json:"id"`
+			RecurringStatus string `json:"recurring_details_status"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	var ids []string
+	for _, token := range tokens.Items {
+		if token.RecurringStatus == "" || token.RecurringStatus == "confirmed" {
+			ids = append(ids, token.ID)
+		}
+	}
+	return ids, nil
+}
+
+// CreateRecurringPayment charges a customer's saved payment method for an order
+// without them being present. The outcome arrives by webhook; the returned ID is
+// the gateway's payment ID.
+func (r *RazorpayClient) CreateRecurringPayment(customerID, tokenID, orderID string, amount int64, currency, email, description string) (string, error) {
+	data := map[string]interface{}{
+		"email":       email,
+		"contact":     "+919000000000",
+		"amount":      amount, // amount in paise
+		"currency":    currency,
+		"order_id":    orderID,
+		"customer_id": customerID,
+		"token":       tokenID,
+		"recurring":   "1",
+		"description": description,
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal payment data: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", r.baseURL+"/payments/create/recurring", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Basic "+r.basicAuth())
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("razorpay recurring payment failed with status: %d", resp.StatusCode)
+	}
+
+	var payment struct {
+		PaymentID string `json:"razorpay_payment_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payment); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return payment.PaymentID, nil
+}
+
+// VerifyWebhookSignature checks the X-Razorpay-Signature header, a hex HMAC-SHA256
+// of the raw request body keyed with the webhook secret
 func (r *RazorpayClient) VerifyWebhookSignature(payload []byte, signature string, secret string) bool {
-	// Implement webhook signature verification
-	// This is a simplified version - in production, implement proper HMAC verification
-	return len(signature) > 0
+	if secret == "" || signature == "" {
+		return false
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hmac.Equal(mac.Sum(nil), expected)
 }
 
 func (r *RazorpayClient) basicAuth() string {
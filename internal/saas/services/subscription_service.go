@@ -10,20 +10,25 @@ import (
 
 	"github.com/liquorpro/go-backend/internal/saas/models"
 	"github.com/liquorpro/go-backend/pkg/shared/config"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
+	"github.com/liquorpro/go-backend/pkg/shared/usage"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
 )
 
 type SubscriptionService struct {
 	db            *gorm.DB
 	config        *config.Config
 	paymentClient *RazorpayClient
+	settings      *settings.Service
 }
 
-func NewSubscriptionService(db *gorm.DB, cfg *config.Config) *SubscriptionService {
+func NewSubscriptionService(db *gorm.DB, cfg *config.Config, settingsService *settings.Service) *SubscriptionService {
 	paymentClient := NewRazorpayClient(cfg)
 	return &SubscriptionService{
 		db:            db,
 		config:        cfg,
 		paymentClient: paymentClient,
+		settings:      settingsService,
 	}
 }
 
@@ -45,7 +50,7 @@ func (s *SubscriptionService) CreateSubscription(ctx context.Context, req *model
 
 	// Check if tenant already has active subscription
 	var existingSub models.Subscription
-	err := tx.Where("tenant_id = ? AND status IN ?", req.TenantID, []string{"active", "trial"}).First(&existingSub).Error
+	err := tx.Where("tenant_id = ? AND status IN ?", req.TenantID, usage.LiveSubscriptionStatuses).First(&existingSub).Error
 	if err == nil {
 		tx.Rollback()
 		return nil, fmt.Errorf("tenant already has an active subscription")
@@ -54,13 +59,7 @@ func (s *SubscriptionService) CreateSubscription(ctx context.Context, req *model
 	// Calculate subscription details
 	now := time.Now()
 	trialEnd := now.AddDate(0, 0, plan.TrialDays)
-	
-	var periodEnd time.Time
-	if req.BillingCycle == "yearly" {
-		periodEnd = trialEnd.AddDate(1, 0, 0)
-	} else {
-		periodEnd = trialEnd.AddDate(0, 1, 0)
-	}
+	periodEnd := billingPeriodEnd(trialEnd, req.BillingCycle)
 
 	// Create subscription
 	subscription := models.Subscription{
@@ -73,16 +72,20 @@ func (s *SubscriptionService) CreateSubscription(ctx context.Context, req *model
 		TrialStart:         &now,
 		TrialEnd:           &trialEnd,
 		BillingCycle:       req.BillingCycle,
-		Amount:             plan.Price,
+		Amount:             planAmount(&plan, req.BillingCycle), // after the yearly discount
 		Currency:           plan.Currency,
 		NextBillingDate:    &trialEnd,
 		AutoRenew:          req.AutoRenew,
 	}
 
-	// Apply yearly discount if applicable
-	if req.BillingCycle == "yearly" {
-		discountAmount := subscription.Amount * (plan.YearlyDiscount / 100)
-		subscription.Amount = subscription.Amount - discountAmount
+	// A coupon comes off the amount billed each period
+	var coupon *models.Coupon
+	if req.CouponCode != "" {
+		coupon, err = redeemCoupon(tx, req.CouponCode, &subscription, &plan, now)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
 	}
 
 	if err := tx.Create(&subscription).Error; err != nil {
@@ -90,9 +93,27 @@ func (s *SubscriptionService) CreateSubscription(ctx context.Context, req *model
 		return nil, fmt.Errorf("failed to create subscription: %w", err)
 	}
 
+	if coupon != nil {
+		redemption := models.CouponRedemption{
+			ID:             uuid.New(),
+			CouponID:       coupon.ID,
+			SubscriptionID: subscription.ID,
+			TenantID:       subscription.TenantID,
+			Discount:       subscription.Discount,
+		}
+		if err := tx.Create(&redemption).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to record coupon redemption: %w", err)
+		}
+	}
+
 	// Create Razorpay customer and subscription if not in trial
 	if plan.TrialDays == 0 {
-		razorpayCustomerID, razorpaySubID, err := s.createRazorpaySubscription(ctx, &subscription, &plan)
+		offerID := ""
+		if coupon != nil {
+			offerID = coupon.RazorpayOfferID
+		}
+		razorpayCustomerID, razorpaySubID, err := s.createRazorpaySubscription(ctx, &subscription, &plan, offerID)
 		if err != nil {
 			tx.Rollback()
 			return nil, fmt.Errorf("failed to create razorpay subscription: %w", err)
@@ -140,7 +161,7 @@ func (s *SubscriptionService) GetSubscription(ctx context.Context, tenantID uuid
 	var subscription models.Subscription
 	
 	err := s.db.Preload("Plan").
-		Where("tenant_id = ? AND status IN ?", tenantID, []string{"active", "trial", "suspended"}).
+		Where("tenant_id = ? AND status IN ?", tenantID, []string{"active", "trial", "past_due", "suspended"}).
 		First(&subscription).Error
 	
 	if err != nil {
@@ -150,6 +171,16 @@ func (s *SubscriptionService) GetSubscription(ctx context.Context, tenantID uuid
 		return nil, fmt.Errorf("failed to get subscription: %w", err)
 	}
 
+	if err := s.endScheduledCancellation(&subscription); err != nil {
+		return nil, err
+	}
+	if err := s.applyScheduledPlanChange(ctx, &subscription); err != nil {
+		return nil, err
+	}
+	if subscription.Status == "cancelled" {
+		return nil, fmt.Errorf("no active subscription found for tenant")
+	}
+
 	return s.toSubscriptionResponse(&subscription), nil
 }
 
@@ -170,6 +201,9 @@ func (s *SubscriptionService) UpdateSubscription(ctx context.Context, subID uuid
 		if req.Status == "cancelled" {
 			now := time.Now()
 			subscription.CancelledAt = &now
+			subscription.CancellationEffectiveAt = &now
+			subscription.EndedAt = &now
+			subscription.CancelAtPeriodEnd = false
 			subscription.AutoRenew = false
 		}
 	}
@@ -186,87 +220,152 @@ func (s *SubscriptionService) UpdateSubscription(ctx context.Context, subID uuid
 	return s.toSubscriptionResponse(&subscription), nil
 }
 
-func (s *SubscriptionService) CancelSubscription(ctx context.Context, subID uuid.UUID) error {
+// CancelSubscription cancels a subscription. Immediate cancellation ends access now
+// and can refund the unused part of the paid period; end-of-period cancellation keeps
+// access until CurrentPeriodEnd and stops renewal. Unset request fields fall back to
+// the tenant's cancellation policy.
+func (s *SubscriptionService) CancelSubscription(ctx context.Context, subID uuid.UUID, req *models.CancelSubscriptionRequest) (*models.CancelSubscriptionResponse, error) {
 	var subscription models.Subscription
 	
 	if err := s.db.First(&subscription, subID).Error; err != nil {
-		return fmt.Errorf("subscription not found: %w", err)
+		return nil, fmt.Errorf("subscription not found: %w", err)
 	}
 
-	// Cancel in Razorpay if applicable
-	if subscription.RazorpaySubscriptionID != "" {
-		if err := s.paymentClient.CancelSubscription(subscription.RazorpaySubscriptionID); err != nil {
-			return fmt.Errorf("failed to cancel razorpay subscription: %w", err)
-		}
+	if subscription.Status == "cancelled" || subscription.Status == "expired" {
+		return nil, fmt.Errorf("subscription is already %s", subscription.Status)
 	}
 
-	// Update subscription
-	now := time.Now()
-	subscription.Status = "cancelled"
-	subscription.CancelledAt = &now
-	subscription.AutoRenew = false
+	mode := req.Mode
+	if mode == "" {
+		mode = s.settings.GetString(ctx, subscription.TenantID, settings.KeyCancellationMode)
+	}
+	if mode == models.CancellationModeEndOfPeriod && subscription.CancelAtPeriodEnd {
+		return nil, fmt.Errorf("subscription is already scheduled for cancellation")
+	}
 
-	if err := s.db.Save(&subscription).Error; err != nil {
-		return fmt.Errorf("failed to cancel subscription: %w", err)
+	prorate := s.settings.GetBool(ctx, subscription.TenantID, settings.KeyCancellationProrate)
+	if req.ProrateRefund != nil {
+		prorate = *req.ProrateRefund
 	}
 
-	return nil
-}
+	now := time.Now()
+	effectiveAt := now
+	if mode == models.CancellationModeEndOfPeriod && subscription.CurrentPeriodEnd.After(now) {
+		effectiveAt = subscription.CurrentPeriodEnd
+	}
 
-func (s *SubscriptionService) UpgradeSubscription(ctx context.Context, subID uuid.UUID, newPlanID uuid.UUID) (*models.SubscriptionResponse, error) {
-	// Start transaction
-	tx := s.db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+	// Cancel in Razorpay if applicable
+	if subscription.RazorpaySubscriptionID != "" {
+		atCycleEnd := effectiveAt.After(now)
+		if err := s.paymentClient.CancelSubscription(subscription.RazorpaySubscriptionID, atCycleEnd); err != nil {
+			return nil, fmt.Errorf("failed to cancel razorpay subscription: %w", err)
 		}
-	}()
+	}
 
-	// Get current subscription
-	var subscription models.Subscription
-	if err := tx.Preload("Plan").First(&subscription, subID).Error; err != nil {
-		tx.Rollback()
-		return nil, fmt.Errorf("subscription not found: %w", err)
+	response := &models.CancelSubscriptionResponse{
+		Mode:        mode,
+		EffectiveAt: effectiveAt,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		subscription.CancelledAt = &now
+		subscription.CancellationEffectiveAt = &effectiveAt
+		subscription.AutoRenew = false
+		subscription.NextBillingDate = nil
+
+		if effectiveAt.After(now) {
+			subscription.CancelAtPeriodEnd = true
+		} else {
+			// Trials have not been paid for, so there is nothing to refund
+			if prorate && subscription.Status != "trial" {
+				payment, amount, err := s.refundUnusedPeriod(tx, &subscription, now, req.Reason)
+				if err != nil {
+					return err
+				}
+				if payment != nil {
+					response.RefundAmount = amount
+					response.RefundPaymentID = &payment.ID
+				}
+			}
+
+			subscription.Status = "cancelled"
+			subscription.CancelAtPeriodEnd = false
+			subscription.EndedAt = &now
+		}
+
+		if err := tx.Save(&subscription).Error; err != nil {
+			return fmt.Errorf("failed to cancel subscription: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Get new plan
-	var newPlan models.PricingPlan
-	if err := tx.First(&newPlan, newPlanID).Error; err != nil {
-		tx.Rollback()
-		return nil, fmt.Errorf("new plan not found: %w", err)
+	// Load plan for response
+	if err := s.db.Preload("Plan").First(&subscription, subscription.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to load subscription: %w", err)
 	}
+	response.Subscription = s.toSubscriptionResponse(&subscription)
 
-	// Validate upgrade (new plan should be higher tier)
-	if newPlan.Price <= subscription.Plan.Price {
-		tx.Rollback()
-		return nil, fmt.Errorf("new plan must be a higher tier")
+	return response, nil
+}
+
+// refundUnusedPeriod refunds the unused fraction of the current period against the
+// latest captured payment. It returns a nil payment when there is nothing to refund.
+func (s *SubscriptionService) refundUnusedPeriod(tx *gorm.DB, subscription *models.Subscription, now time.Time, reason string) (*models.Payment, float64, error) {
+	periodLength := subscription.CurrentPeriodEnd.Sub(subscription.CurrentPeriodStart)
+	remaining := subscription.CurrentPeriodEnd.Sub(now)
+	if periodLength <= 0 || remaining <= 0 {
+		return nil, 0, nil
 	}
 
-	// Update subscription
-	subscription.PlanID = newPlanID
-	subscription.Amount = newPlan.Price
-	
-	// Apply yearly discount if applicable
-	if subscription.BillingCycle == "yearly" {
-		discountAmount := subscription.Amount * (newPlan.YearlyDiscount / 100)
-		subscription.Amount = subscription.Amount - discountAmount
+	var payment models.Payment
+	err := tx.Where("subscription_id = ? AND status IN ? AND razorpay_payment_id <> ''", subscription.ID, []string{"succeeded", models.PaymentPartiallyRefunded}).
+		Order("processed_at DESC, created_at DESC").
+		First(&payment).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, 0, nil
+		}
+		return nil, 0, fmt.Errorf("failed to get payment for refund: %w", err)
 	}
 
-	if err := tx.Save(&subscription).Error; err != nil {
-		tx.Rollback()
-		return nil, fmt.Errorf("failed to update subscription: %w", err)
+	amount := utils.RoundToTwoDecimals(subscription.Amount * remaining.Seconds() / periodLength.Seconds())
+	if refundable := utils.RoundToTwoDecimals(payment.Amount - payment.RefundedAmount); amount > refundable {
+		amount = refundable
+	}
+	if amount <= 0 {
+		return nil, 0, nil
 	}
 
-	// TODO: Handle prorated billing with Razorpay
+	if reason == "" {
+		reason = "prorated refund on cancellation"
+	}
+	refund, err := refundPayment(tx, s.paymentClient, &payment, amount, reason, now)
+	if err != nil {
+		return nil, 0, err
+	}
 
-	tx.Commit()
+	return refund, amount, nil
+}
 
-	// Load updated subscription
-	if err := s.db.Preload("Plan").First(&subscription, subscription.ID).Error; err != nil {
-		return nil, fmt.Errorf("failed to load updated subscription: %w", err)
+// endScheduledCancellation moves a subscription whose end-of-period cancellation has
+// taken effect to the cancelled state
+func (s *SubscriptionService) endScheduledCancellation(subscription *models.Subscription) error {
+	if !subscription.CancelAtPeriodEnd || subscription.CancellationEffectiveAt == nil ||
+		subscription.CancellationEffectiveAt.After(time.Now()) {
+		return nil
 	}
 
-	return s.toSubscriptionResponse(&subscription), nil
+	subscription.Status = "cancelled"
+	subscription.CancelAtPeriodEnd = false
+	subscription.EndedAt = subscription.CancellationEffectiveAt
+
+	if err := s.db.Save(subscription).Error; err != nil {
+		return fmt.Errorf("failed to end cancelled subscription: %w", err)
+	}
+	return nil
 }
 
 func (s *SubscriptionService) GetUsage(ctx context.Context, subID uuid.UUID) (*models.UsageRecord, error) {
@@ -301,8 +400,8 @@ func (s *SubscriptionService) CheckLimits(ctx context.Context, tenantID uuid.UUI
 		return fmt.Errorf("unknown resource type: %s", resourceType)
 	}
 
-	// -1 means unlimited
-	if limit != -1 && currentCount >= limit {
+	// Zero (or the older -1) means unlimited
+	if limit > 0 && currentCount >= limit {
 		return fmt.Errorf("resource limit exceeded: %s limit is %d", resourceType, limit)
 	}
 
@@ -312,7 +411,7 @@ func (s *SubscriptionService) CheckLimits(ctx context.Context, tenantID uuid.UUI
 func (s *SubscriptionService) RecordUsage(ctx context.Context, tenantID uuid.UUID, metrics map[string]int) error {
 	// Get active subscription
 	var subscription models.Subscription
-	err := s.db.Where("tenant_id = ? AND status IN ?", tenantID, []string{"active", "trial"}).First(&subscription).Error
+	err := s.db.Where("tenant_id = ? AND status IN ?", tenantID, usage.LiveSubscriptionStatuses).First(&subscription).Error
 	if err != nil {
 		return fmt.Errorf("no active subscription found: %w", err)
 	}
@@ -359,7 +458,7 @@ func (s *SubscriptionService) RecordUsage(ctx context.Context, tenantID uuid.UUI
 
 // Helper methods
 
-func (s *SubscriptionService) createRazorpaySubscription(ctx context.Context, subscription *models.Subscription, plan *models.PricingPlan) (string, string, error) {
+func (s *SubscriptionService) createRazorpaySubscription(ctx context.Context, subscription *models.Subscription, plan *models.PricingPlan, offerID string) (string, string, error) {
 	// Create Razorpay customer
 	customerID, err := s.paymentClient.CreateCustomer(fmt.Sprintf("tenant-%s", subscription.TenantID))
 	if err != nil {
@@ -367,7 +466,7 @@ func (s *SubscriptionService) createRazorpaySubscription(ctx context.Context, su
 	}
 
 	// Create Razorpay subscription
-	subID, err := s.paymentClient.CreateSubscription(customerID, plan.RazorpayPlanID)
+	subID, err := s.paymentClient.CreateSubscription(customerID, plan.RazorpayPlanID, offerID)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to create razorpay subscription: %w", err)
 	}
@@ -389,6 +488,14 @@ func (s *SubscriptionService) toSubscriptionResponse(subscription *models.Subscr
 		Amount:             subscription.Amount,
 		Currency:           subscription.Currency,
 		NextBillingDate:    subscription.NextBillingDate,
+		PendingPlanID:      subscription.PendingPlanID,
+		PendingPlanAt:      subscription.PendingPlanAt,
+		CouponID:           subscription.CouponID,
+		Discount:           subscription.Discount,
+		CancelAtPeriodEnd:  subscription.CancelAtPeriodEnd,
+		CancelledAt:        subscription.CancelledAt,
+		CancellationEffectiveAt: subscription.CancellationEffectiveAt,
+		EndedAt:            subscription.EndedAt,
 		CreatedAt:          subscription.CreatedAt,
 		UpdatedAt:          subscription.UpdatedAt,
 	}
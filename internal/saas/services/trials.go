@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/liquorpro/go-backend/internal/saas/models"
+)
+
+// Trial management for super admins. Sales can give a prospect a few more days
+// of trial, or convert a trial to a paid subscription when the first payment is
+// taken outside the gateway. Both are recorded in the audit log with the admin
+// who made the change and why.
+
+// ExtendTrial pushes a trial's end back by req.Days. A trial that has already
+// run out is extended from now. The first billing date and period move with it.
+func (s *AdminService) ExtendTrial(ctx context.Context, subscriptionID uuid.UUID, adminUserID uuid.UUID, req *models.ExtendTrialRequest) (*models.Subscription, error) {
+	var subscription models.Subscription
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockTrialSubscription(tx, subscriptionID, &subscription, "extended"); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		oldTrialEnd := *subscription.TrialEnd
+		from := oldTrialEnd
+		if from.Before(now) {
+			from = now
+		}
+		trialEnd := from.AddDate(0, 0, req.Days)
+
+		subscription.TrialEnd = &trialEnd
+		subscription.CurrentPeriodEnd = subscription.CurrentPeriodEnd.Add(trialEnd.Sub(oldTrialEnd))
+		subscription.NextBillingDate = &trialEnd
+		if err := tx.Model(&subscription).Updates(map[string]interface{}{
+			"trial_end":          subscription.TrialEnd,
+			"current_period_end": subscription.CurrentPeriodEnd,
+			"next_billing_date":  subscription.NextBillingDate,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to extend trial: %w", err)
+		}
+
+		return recordSubscriptionAction(tx, &subscription, adminUserID, "extend_trial",
+			map[string]interface{}{"trial_end": oldTrialEnd},
+			map[string]interface{}{"trial_end": trialEnd, "days": req.Days, "reason": req.Reason})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// ConvertTrial makes a trial a paid subscription straight away, recording the
+// first payment as received. The first paid period starts now; the payment
+// defaults to the subscription's amount and gets an invoice like a gateway
+// payment would.
+func (s *AdminService) ConvertTrial(ctx context.Context, subscriptionID uuid.UUID, adminUserID uuid.UUID, req *models.ConvertTrialRequest) (*models.Subscription, *models.Payment, error) {
+	var subscription models.Subscription
+	var payment models.Payment
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockTrialSubscription(tx, subscriptionID, &subscription, "converted"); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		oldTrialEnd := *subscription.TrialEnd
+		periodEnd := billingPeriodEnd(now, subscription.BillingCycle)
+
+		// The trial ends at conversion, so new MRR is counted from today
+		subscription.Status = "active"
+		subscription.TrialEnd = &now
+		subscription.ConvertedAt = &now
+		subscription.ConvertedManually = true
+		subscription.CurrentPeriodStart = now
+		subscription.CurrentPeriodEnd = periodEnd
+		subscription.NextBillingDate = &periodEnd
+		if err := tx.Model(&subscription).Updates(map[string]interface{}{
+			"status":               subscription.Status,
+			"trial_end":            subscription.TrialEnd,
+			"converted_at":         subscription.ConvertedAt,
+			"converted_manually":   true,
+			"current_period_start": subscription.CurrentPeriodStart,
+			"current_period_end":   subscription.CurrentPeriodEnd,
+			"next_billing_date":    subscription.NextBillingDate,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to convert trial: %w", err)
+		}
+
+		paymentMethod := strings.TrimSpace(req.PaymentMethod)
+		if paymentMethod == "" {
+			paymentMethod = "manual"
+		}
+		description := "Trial conversion"
+		if reference := strings.TrimSpace(req.Reference); reference != "" {
+			description = fmt.Sprintf("Trial conversion, reference %s", reference)
+		}
+		amount := req.Amount
+		if amount == 0 {
+			amount = subscription.Amount
+		}
+
+		payment = models.Payment{
+			ID:             uuid.New(),
+			SubscriptionID: subscription.ID,
+			Amount:         amount,
+			Currency:       subscription.Currency,
+			Status:         "succeeded",
+			PaymentMethod:  paymentMethod,
+			ProcessedAt:    &now,
+			Description:    description,
+		}
+		if err := tx.Create(&payment).Error; err != nil {
+			return fmt.Errorf("failed to create payment: %w", err)
+		}
+
+		invoice, err := createInvoice(tx, &subscription, &payment)
+		if err != nil {
+			return err
+		}
+		payment.InvoiceID = &invoice.ID
+		if err := tx.Model(&payment).Update("invoice_id", invoice.ID).Error; err != nil {
+			return fmt.Errorf("failed to update payment with invoice ID: %w", err)
+		}
+
+		// The first payment is the first period billed with any coupon discount
+		if err := countDiscountedPeriod(tx, &subscription, now); err != nil {
+			return err
+		}
+
+		return recordSubscriptionAction(tx, &subscription, adminUserID, "convert_trial",
+			map[string]interface{}{"status": "trial", "trial_end": oldTrialEnd},
+			map[string]interface{}{"status": "active", "payment_id": payment.ID, "amount": amount, "reason": req.Reason})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &subscription, &payment, nil
+}
+
+// lockTrialSubscription loads a subscription for update and checks it is still
+// in its trial
+func lockTrialSubscription(tx *gorm.DB, subscriptionID uuid.UUID, subscription *models.Subscription, action string) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(subscription, subscriptionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("subscription not found")
+		}
+		return fmt.Errorf("failed to get subscription: %w", err)
+	}
+	if subscription.Status != "trial" {
+		return fmt.Errorf("only a trial can be %s; this subscription is %s", action, subscription.Status)
+	}
+	if subscription.TrialEnd == nil {
+		return errors.New("subscription has no trial period")
+	}
+	return nil
+}
+
+// recordSubscriptionAction writes the audit log entry for an admin's change to a
+// subscription
+func recordSubscriptionAction(tx *gorm.DB, subscription *models.Subscription, adminUserID uuid.UUID, action string, oldValues, newValues map[string]interface{}) error {
+	oldJSON, _ := json.Marshal(oldValues)
+	newJSON, _ := json.Marshal(newValues)
+
+	auditLog := models.AuditLog{
+		ID:          uuid.New(),
+		AdminUserID: &adminUserID,
+		TenantID:    &subscription.TenantID,
+		Action:      action,
+		Resource:    "subscription",
+		ResourceID:  subscription.ID.String(),
+		OldValues:   string(oldJSON),
+		NewValues:   string(newJSON),
+		IPAddress:   "unknown", // TODO: Get from context
+		UserAgent:   "admin-panel",
+	}
+	if err := tx.Create(&auditLog).Error; err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+	return nil
+}
+
+// billingPeriodEnd is when a billing period starting at start ends
+func billingPeriodEnd(start time.Time, billingCycle string) time.Time {
+	if billingCycle == "yearly" {
+		return start.AddDate(1, 0, 0)
+	}
+	return start.AddDate(0, 1, 0)
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/liquorpro/go-backend/internal/saas/models"
+	"github.com/liquorpro/go-backend/pkg/shared/usage"
+)
+
+// UsageSnapshotWorker records each subscribed tenant's location, user and product
+// counts once a day, so usage analytics reflect what tenants actually have
+type UsageSnapshotWorker struct {
+	service  *SubscriptionService
+	interval time.Duration
+}
+
+// NewUsageSnapshotWorker creates a worker that snapshots usage at the given interval
+func NewUsageSnapshotWorker(service *SubscriptionService, interval time.Duration) *UsageSnapshotWorker {
+	return &UsageSnapshotWorker{
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Start takes a snapshot right away and then at every interval until ctx is cancelled
+func (w *UsageSnapshotWorker) Start(ctx context.Context) {
+	go func() {
+		w.snapshot(ctx)
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				w.snapshot(ctx)
+			}
+		}
+	}()
+}
+
+func (w *UsageSnapshotWorker) snapshot(ctx context.Context) {
+	recorded, err := w.service.SnapshotUsage(ctx)
+	if err != nil {
+		log.Printf("Usage snapshot failed: %v", err)
+		return
+	}
+	log.Printf("Usage snapshot recorded %d subscription(s)", recorded)
+}
+
+// SnapshotUsage writes today's usage record for every trial or active subscription
+// from the tenant's current counts. Running it again the same day updates the
+// existing records.
+func (s *SubscriptionService) SnapshotUsage(ctx context.Context) (int, error) {
+	var subscriptions []models.Subscription
+	if err := s.db.WithContext(ctx).
+		Where("status IN ?", usage.LiveSubscriptionStatuses).
+		Find(&subscriptions).Error; err != nil {
+		return 0, fmt.Errorf("failed to get subscriptions: %w", err)
+	}
+
+	today := time.Now().Truncate(24 * time.Hour)
+	recorded := 0
+	for _, subscription := range subscriptions {
+		counts, err := usage.CountAll(s.db.WithContext(ctx), subscription.TenantID)
+		if err != nil {
+			log.Printf("Usage snapshot skipped tenant %s: %v", subscription.TenantID, err)
+			continue
+		}
+
+		var record models.UsageRecord
+		err = s.db.Where("subscription_id = ? AND record_date = ?", subscription.ID, today).First(&record).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			record = models.UsageRecord{
+				SubscriptionID: subscription.ID,
+				TenantID:       subscription.TenantID,
+				RecordDate:     today,
+			}
+		} else if err != nil {
+			return recorded, fmt.Errorf("failed to get usage record: %w", err)
+		}
+
+		record.Locations = int(counts.Locations)
+		record.Users = int(counts.Users)
+		record.Products = int(counts.Products)
+		if err := s.db.Save(&record).Error; err != nil {
+			return recorded, fmt.Errorf("failed to record usage: %w", err)
+		}
+		recorded++
+	}
+
+	return recorded, nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/liquorpro/go-backend/internal/saas/models"
+)
+
+// WebhookMaxRetries is how many times a webhook event is attempted before it is
+// left failed for someone to look into
+const WebhookMaxRetries = 10
+
+// RetryWebhooks processes again every webhook event whose last attempt failed and
+// every event abandoned mid-processing, and returns how many went through. The
+// gateway is told an event was received before it is processed, so it does not
+// deliver a failed event again. An event that fails again is logged and left for
+// the next run until it runs out of retries.
+func (s *PaymentService) RetryWebhooks(ctx context.Context) (int, error) {
+	cutoff := time.Now().Add(-WebhookProcessingTimeout)
+
+	var events []models.WebhookEvent
+	if err := s.db.WithContext(ctx).
+		Where("retries < ?", WebhookMaxRetries).
+		Where("(status = ? OR (status IN ? AND updated_at < ?))", "failed", []string{"pending", "processing"}, cutoff).
+		Order("created_at").
+		Find(&events).Error; err != nil {
+		return 0, fmt.Errorf("failed to get webhook events to retry: %w", err)
+	}
+
+	retried := 0
+	for i := range events {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := s.ProcessWebhook(ctx, &events[i]); err != nil {
+			log.Printf("Retry of Razorpay webhook %s failed: %v", events[i].EventID, err)
+			continue
+		}
+		retried++
+	}
+	return retried, nil
+}
+
+// WebhookRetryWorker periodically retries webhook events that were not processed
+type WebhookRetryWorker struct {
+	service  *PaymentService
+	interval time.Duration
+}
+
+// NewWebhookRetryWorker creates a worker that retries webhook events at the given interval
+func NewWebhookRetryWorker(service *PaymentService, interval time.Duration) *WebhookRetryWorker {
+	return &WebhookRetryWorker{
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Start runs the retries in the background until ctx is cancelled
+func (w *WebhookRetryWorker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				retried, err := w.service.RetryWebhooks(ctx)
+				if err != nil {
+					log.Printf("Webhook retry run failed: %v", err)
+				} else if retried > 0 {
+					log.Printf("Retried %d webhook event(s)", retried)
+				}
+			}
+		}
+	}()
+}
@@ -1,12 +1,18 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/liquorpro/go-backend/internal/sales/services"
+	"github.com/liquorpro/go-backend/pkg/shared/approval"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
 	"github.com/liquorpro/go-backend/pkg/shared/validators"
 )
 
@@ -16,6 +22,9 @@ type SalesHandlers struct {
 	salesService      *services.SalesService
 	returnsService    *services.ReturnsService
 	dashboardService  *services.DashboardService
+	reportService     *services.ReportService
+	commissionService *services.CommissionService
+	targetService     *services.TargetService
 }
 
 // NewSalesHandlers creates new sales handlers
@@ -24,12 +33,18 @@ func NewSalesHandlers(
 	salesService *services.SalesService,
 	returnsService *services.ReturnsService,
 	dashboardService *services.DashboardService,
+	reportService *services.ReportService,
+	commissionService *services.CommissionService,
+	targetService *services.TargetService,
 ) *SalesHandlers {
 	return &SalesHandlers{
 		dailySalesService: dailySalesService,
 		salesService:      salesService,
 		returnsService:    returnsService,
 		dashboardService:  dashboardService,
+		reportService:     reportService,
+		commissionService: commissionService,
+		targetService:     targetService,
 	}
 }
 
@@ -86,6 +101,10 @@ func (h *SalesHandlers) CreateDailySalesRecord(c *gin.Context) {
 
 	record, err := h.dailySalesService.CreateDailySalesRecord(c.Request.Context(), req, tenantID, createdByID)
 	if err != nil {
+		if errors.Is(err, utils.ErrTooManyItems) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -108,13 +127,9 @@ func (h *SalesHandlers) GetDailySalesRecords(c *gin.Context) {
 		return
 	}
 
-	// Set defaults
-	if filters.Page <= 0 {
-		filters.Page = 1
-	}
-	if filters.PageSize <= 0 || filters.PageSize > 100 {
-		filters.PageSize = 20
-	}
+	page := utils.ParsePageRequest(c, 20, 100)
+	filters.Page = page.Page
+	filters.PageSize = page.PageSize
 
 	records, err := h.dailySalesService.GetDailySalesRecords(c.Request.Context(), tenantID, filters)
 	if err != nil {
@@ -122,7 +137,8 @@ func (h *SalesHandlers) GetDailySalesRecords(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, records)
+	list := utils.NewPaginatedResponse(records.Records, records.TotalCount, page)
+	utils.RespondPaginated(c, list, gin.H{"records": list.Data})
 }
 
 // GetDailySalesRecordByID returns daily sales record by ID
@@ -180,10 +196,53 @@ func (h *SalesHandlers) UpdateDailySalesRecord(c *gin.Context) {
 
 	record, err := h.dailySalesService.UpdateDailySalesRecord(c.Request.Context(), recordID, tenantID, req)
 	if err != nil {
+		if errors.Is(err, utils.ErrTooManyItems) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+		h.decisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, record)
+}
+
+// UpdateDailySalesItem corrects a single item of a pending daily sales record
+func (h *SalesHandlers) UpdateDailySalesItem(c *gin.Context) {
+	tenantID, _, err := h.getTenantAndUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recordID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid record ID"})
+		return
+	}
+
+	itemID, err := uuid.Parse(c.Param("itemId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+		return
+	}
+
+	var req services.DailySalesItemUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	record, err := h.dailySalesService.UpdateDailySalesItem(c.Request.Context(), recordID, itemID, tenantID, req)
+	if err != nil {
+		if strings.HasSuffix(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		h.decisionError(c, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, record)
 }
 
@@ -201,15 +260,45 @@ func (h *SalesHandlers) ApproveDailySalesRecord(c *gin.Context) {
 		return
 	}
 
-	record, err := h.dailySalesService.ApproveDailySalesRecord(c.Request.Context(), recordID, tenantID, approvedByID)
-	if err != nil {
+	// The body is optional; without a version the record is approved as it stands
+	var req services.DailySalesApprovalRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	record, err := h.dailySalesService.ApproveDailySalesRecord(c.Request.Context(), recordID, tenantID, approvedByID, req.Version)
+	if err != nil {
+		h.decisionError(c, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, record)
 }
 
+// BulkApproveDailySalesRecords approves many pending daily sales records at once
+func (h *SalesHandlers) BulkApproveDailySalesRecords(c *gin.Context) {
+	tenantID, approvedByID, err := h.getTenantAndUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req services.BulkApproveDailySalesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.dailySalesService.BulkApprove(c.Request.Context(), req.RecordIDs, tenantID, approvedByID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 // RejectDailySalesRecord rejects a daily sales record
 func (h *SalesHandlers) RejectDailySalesRecord(c *gin.Context) {
 	tenantID, rejectedByID, err := h.getTenantAndUserID(c)
@@ -224,6 +313,37 @@ func (h *SalesHandlers) RejectDailySalesRecord(c *gin.Context) {
 		return
 	}
 
+	var req struct {
+		Reason  string `json:"reason" binding:"required"`
+		Version *int   `json:"version"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.dailySalesService.RejectDailySalesRecord(c.Request.Context(), recordID, tenantID, rejectedByID, req.Reason, req.Version); err != nil {
+		h.decisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Daily sales record rejected successfully"})
+}
+
+// VoidDailySalesRecord voids an approved daily sales record and restores its stock
+func (h *SalesHandlers) VoidDailySalesRecord(c *gin.Context) {
+	tenantID, voidedByID, err := h.getTenantAndUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recordID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid record ID"})
+		return
+	}
+
 	var req struct {
 		Reason string `json:"reason" binding:"required"`
 	}
@@ -232,12 +352,17 @@ func (h *SalesHandlers) RejectDailySalesRecord(c *gin.Context) {
 		return
 	}
 
-	if err := h.dailySalesService.RejectDailySalesRecord(c.Request.Context(), recordID, tenantID, rejectedByID, req.Reason); err != nil {
+	record, err := h.dailySalesService.VoidDailySalesRecord(c.Request.Context(), recordID, tenantID, voidedByID, req.Reason)
+	if err != nil {
+		if strings.HasSuffix(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Daily sales record rejected successfully"})
+	c.JSON(http.StatusOK, record)
 }
 
 // Individual Sales Endpoints
@@ -291,13 +416,9 @@ func (h *SalesHandlers) GetSales(c *gin.Context) {
 		return
 	}
 
-	// Set defaults
-	if filters.Page <= 0 {
-		filters.Page = 1
-	}
-	if filters.PageSize <= 0 || filters.PageSize > 100 {
-		filters.PageSize = 20
-	}
+	page := utils.ParsePageRequest(c, 20, 100)
+	filters.Page = page.Page
+	filters.PageSize = page.PageSize
 
 	sales, err := h.salesService.GetSales(c.Request.Context(), tenantID, filters)
 	if err != nil {
@@ -305,7 +426,8 @@ func (h *SalesHandlers) GetSales(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, sales)
+	list := utils.NewPaginatedResponse(sales.Sales, sales.TotalCount, page)
+	utils.RespondPaginated(c, list, gin.H{"sales": list.Data})
 }
 
 // GetSaleByID returns sale by ID
@@ -347,7 +469,7 @@ func (h *SalesHandlers) ApproveSale(c *gin.Context) {
 
 	sale, err := h.salesService.ApproveSale(c.Request.Context(), saleID, tenantID, approvedByID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		h.decisionError(c, err)
 		return
 	}
 
@@ -377,7 +499,7 @@ func (h *SalesHandlers) RejectSale(c *gin.Context) {
 	}
 
 	if err := h.salesService.RejectSale(c.Request.Context(), saleID, tenantID, rejectedByID, req.Reason); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		h.decisionError(c, err)
 		return
 	}
 
@@ -467,6 +589,39 @@ func (h *SalesHandlers) CreateSaleReturn(c *gin.Context) {
 	c.JSON(http.StatusCreated, saleReturn)
 }
 
+// CreateDailySalesReturn creates a return against an approved daily sales record
+func (h *SalesHandlers) CreateDailySalesReturn(c *gin.Context) {
+	tenantID, createdByID, err := h.getTenantAndUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recordID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid record ID"})
+		return
+	}
+
+	var req services.DailySalesReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	saleReturn, err := h.returnsService.CreateDailySalesReturn(c.Request.Context(), recordID, req, tenantID, createdByID)
+	if err != nil {
+		if strings.HasSuffix(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, saleReturn)
+}
+
 // GetSaleReturns returns paginated list of returns
 func (h *SalesHandlers) GetSaleReturns(c *gin.Context) {
 	tenantID, _, err := h.getTenantAndUserID(c)
@@ -482,13 +637,9 @@ func (h *SalesHandlers) GetSaleReturns(c *gin.Context) {
 		return
 	}
 
-	// Set defaults
-	if filters.Page <= 0 {
-		filters.Page = 1
-	}
-	if filters.PageSize <= 0 || filters.PageSize > 100 {
-		filters.PageSize = 20
-	}
+	page := utils.ParsePageRequest(c, 20, 100)
+	filters.Page = page.Page
+	filters.PageSize = page.PageSize
 
 	returns, err := h.returnsService.GetSaleReturns(c.Request.Context(), tenantID, filters)
 	if err != nil {
@@ -496,7 +647,8 @@ func (h *SalesHandlers) GetSaleReturns(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, returns)
+	list := utils.NewPaginatedResponse(returns.Returns, returns.TotalCount, page)
+	utils.RespondPaginated(c, list, gin.H{"returns": list.Data})
 }
 
 // GetSaleReturnByID returns sale return by ID
@@ -538,7 +690,7 @@ func (h *SalesHandlers) ApproveSaleReturn(c *gin.Context) {
 
 	saleReturn, err := h.returnsService.ApproveSaleReturn(c.Request.Context(), returnID, tenantID, approvedByID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		h.decisionError(c, err)
 		return
 	}
 
@@ -568,7 +720,7 @@ func (h *SalesHandlers) RejectSaleReturn(c *gin.Context) {
 	}
 
 	if err := h.returnsService.RejectSaleReturn(c.Request.Context(), returnID, tenantID, rejectedByID, req.Reason); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		h.decisionError(c, err)
 		return
 	}
 
@@ -625,6 +777,418 @@ func (h *SalesHandlers) GetDashboardSummary(c *gin.Context) {
 	c.JSON(http.StatusOK, summary)
 }
 
+// GetSalesByCategory returns approved sales grouped by category or brand
+func (h *SalesHandlers) GetSalesByCategory(c *gin.Context) {
+	tenantID, _, err := h.getTenantAndUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var shopID *uuid.UUID
+	if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		parsed, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop ID"})
+			return
+		}
+		shopID = &parsed
+	}
+
+	// Default to the current month; end_date is inclusive
+	now := time.Now()
+	startDate := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	endDate := startDate.AddDate(0, 1, 0)
+	if startStr := c.Query("start_date"); startStr != "" {
+		parsed, err := time.Parse("2006-01-02", startStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date, expected YYYY-MM-DD"})
+			return
+		}
+		startDate = parsed
+	}
+	if endStr := c.Query("end_date"); endStr != "" {
+		parsed, err := time.Parse("2006-01-02", endStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date, expected YYYY-MM-DD"})
+			return
+		}
+		endDate = parsed.AddDate(0, 0, 1)
+	}
+
+	report, err := h.reportService.GetSalesByCategory(c.Request.Context(), tenantID, shopID, startDate, endDate, c.DefaultQuery("group_by", services.GroupByCategory))
+	if err != nil {
+		if err.Error() == "group_by must be category or brand" || err.Error() == "end date must be after start date" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetSalesStockReconciliation compares a day's approved sales with the stock depleted by sales
+func (h *SalesHandlers) GetSalesStockReconciliation(c *gin.Context) {
+	tenantID, _, err := h.getTenantAndUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var shopID *uuid.UUID
+	if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		parsed, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop ID"})
+			return
+		}
+		shopID = &parsed
+	}
+
+	date := time.Now()
+	if dateStr := c.Query("date"); dateStr != "" {
+		parsed, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date, expected YYYY-MM-DD"})
+			return
+		}
+		date = parsed
+	}
+
+	report, err := h.reportService.GetSalesStockReconciliation(c.Request.Context(), tenantID, shopID, date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetMarginReport returns revenue, cost of goods sold and gross margin by product,
+// category and shop
+func (h *SalesHandlers) GetMarginReport(c *gin.Context) {
+	tenantID, _, err := h.getTenantAndUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var shopID *uuid.UUID
+	if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		parsed, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop ID"})
+			return
+		}
+		shopID = &parsed
+	}
+
+	// Default to the current month; end_date is inclusive
+	now := time.Now()
+	startDate := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	endDate := startDate.AddDate(0, 1, 0)
+	if startStr := c.Query("start_date"); startStr != "" {
+		parsed, err := time.Parse("2006-01-02", startStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date, expected YYYY-MM-DD"})
+			return
+		}
+		startDate = parsed
+	}
+	if endStr := c.Query("end_date"); endStr != "" {
+		parsed, err := time.Parse("2006-01-02", endStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date, expected YYYY-MM-DD"})
+			return
+		}
+		endDate = parsed.AddDate(0, 0, 1)
+	}
+
+	report, err := h.dashboardService.GetMarginReport(c.Request.Context(), tenantID, shopID, startDate, endDate)
+	if err != nil {
+		if err.Error() == "end date must be after start date" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Commission Endpoints
+
+// CreateCommissionRule sets the commission rule for a salesman or shop
+func (h *SalesHandlers) CreateCommissionRule(c *gin.Context) {
+	tenantID, userID, err := h.getTenantAndUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req services.CommissionRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.commissionService.CreateCommissionRule(c.Request.Context(), req, tenantID, userID)
+	if err != nil {
+		switch {
+		case strings.HasSuffix(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "failed to"):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// GetCommissionRules returns the active commission rules
+func (h *SalesHandlers) GetCommissionRules(c *gin.Context) {
+	tenantID, _, err := h.getTenantAndUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rules, err := h.commissionService.GetCommissionRules(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+// GetCommissions returns salesman commissions for a period
+func (h *SalesHandlers) GetCommissions(c *gin.Context) {
+	tenantID, _, err := h.getTenantAndUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var salesmanID, shopID *uuid.UUID
+	if salesmanIDStr := c.Query("salesman_id"); salesmanIDStr != "" {
+		parsed, err := uuid.Parse(salesmanIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid salesman ID"})
+			return
+		}
+		salesmanID = &parsed
+	}
+	if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		parsed, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop ID"})
+			return
+		}
+		shopID = &parsed
+	}
+
+	// Default to the current month; end is inclusive
+	now := time.Now()
+	startDate := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	endDate := startDate.AddDate(0, 1, 0)
+	if startStr := c.Query("start"); startStr != "" {
+		parsed, err := time.Parse("2006-01-02", startStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start, expected YYYY-MM-DD"})
+			return
+		}
+		startDate = parsed
+	}
+	if endStr := c.Query("end"); endStr != "" {
+		parsed, err := time.Parse("2006-01-02", endStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end, expected YYYY-MM-DD"})
+			return
+		}
+		endDate = parsed.AddDate(0, 0, 1)
+	}
+
+	report, err := h.commissionService.CalculateCommissions(c.Request.Context(), tenantID, salesmanID, shopID, startDate, endDate)
+	if err != nil {
+		if err.Error() == "end date must be after start date" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Sales Target Endpoints
+
+// CreateSalesTarget sets a monthly target for a shop or salesman
+func (h *SalesHandlers) CreateSalesTarget(c *gin.Context) {
+	tenantID, userID, err := h.getTenantAndUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req services.SalesTargetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	target, err := h.targetService.CreateSalesTarget(c.Request.Context(), req, tenantID, userID)
+	if err != nil {
+		h.targetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, target)
+}
+
+// GetSalesTargets lists the targets for a month
+func (h *SalesHandlers) GetSalesTargets(c *gin.Context) {
+	tenantID, _, err := h.getTenantAndUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	periodStart, shopID, ok := h.targetFilters(c)
+	if !ok {
+		return
+	}
+
+	targets, err := h.targetService.GetSalesTargets(c.Request.Context(), tenantID, periodStart, shopID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"targets": targets, "period": periodStart.Format("2006-01")})
+}
+
+// UpdateSalesTarget changes a target's amount or notes
+func (h *SalesHandlers) UpdateSalesTarget(c *gin.Context) {
+	tenantID, _, err := h.getTenantAndUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target ID"})
+		return
+	}
+
+	var req services.SalesTargetUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	target, err := h.targetService.UpdateSalesTarget(c.Request.Context(), targetID, tenantID, req)
+	if err != nil {
+		h.targetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, target)
+}
+
+// DeleteSalesTarget removes a target
+func (h *SalesHandlers) DeleteSalesTarget(c *gin.Context) {
+	tenantID, _, err := h.getTenantAndUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target ID"})
+		return
+	}
+
+	if err := h.targetService.DeleteSalesTarget(c.Request.Context(), targetID, tenantID); err != nil {
+		h.targetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Sales target deleted successfully"})
+}
+
+// GetTargetAchievement compares a month's targets with approved sales
+func (h *SalesHandlers) GetTargetAchievement(c *gin.Context) {
+	tenantID, _, err := h.getTenantAndUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	periodStart, shopID, ok := h.targetFilters(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.targetService.GetTargetAchievement(c.Request.Context(), tenantID, periodStart, shopID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// targetFilters reads the period (YYYY-MM, default this month) and shop_id query
+// parameters, answering 400 itself when either is invalid
+func (h *SalesHandlers) targetFilters(c *gin.Context) (time.Time, *uuid.UUID, bool) {
+	now := time.Now()
+	periodStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if period := c.Query("period"); period != "" {
+		parsed, err := services.ParseTargetPeriod(period)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return time.Time{}, nil, false
+		}
+		periodStart = parsed
+	}
+
+	var shopID *uuid.UUID
+	if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		parsed, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop ID"})
+			return time.Time{}, nil, false
+		}
+		shopID = &parsed
+	}
+
+	return periodStart, shopID, true
+}
+
+// targetError maps a sales target service error to a status code
+func (h *SalesHandlers) targetError(c *gin.Context, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err.Error() == "a target already exists for this period":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "failed to"):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
 // Helper methods
 
 
@@ -647,4 +1211,20 @@ func (h *SalesHandlers) getTenantAndUserID(c *gin.Context) (tenantID, userID uui
 	}
 
 	return tenantID, userID, nil
-}
\ No newline at end of file
+}
+
+// decisionError answers a failed edit, approve or reject, reporting a record another
+// request already decided or modified as a conflict
+func (h *SalesHandlers) decisionError(c *gin.Context, err error) {
+	var processed *approval.AlreadyProcessedError
+	if errors.As(err, &processed) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "status": processed.Status})
+		return
+	}
+	var modified *approval.ModifiedError
+	if errors.As(err, &modified) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
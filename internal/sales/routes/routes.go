@@ -3,13 +3,27 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/liquorpro/go-backend/internal/sales/handlers"
+	"github.com/liquorpro/go-backend/pkg/shared/audit"
 	"github.com/liquorpro/go-backend/pkg/shared/cache"
 	"github.com/liquorpro/go-backend/pkg/shared/config"
 	"github.com/liquorpro/go-backend/pkg/shared/middleware"
+	"github.com/liquorpro/go-backend/pkg/shared/permissions"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
 )
 
+// auditTables maps the resources written through this service to their tables for
+// the write audit log
+var auditTables = map[string]string{
+	"daily-records":    "daily_sales_records",
+	"sales":            "sales",
+	"returns":          "sale_returns",
+	"commission-rules": "commission_rules",
+	"targets":          "sales_targets",
+}
+
 // SetupRoutes configures all sales service routes
-func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, salesHandlers *handlers.SalesHandlers) {
+func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, salesHandlers *handlers.SalesHandlers, settingsService *settings.Service, permissionService *permissions.Service, readAuditor *audit.ReadAuditor, auditLogger *audit.Logger, shopScopes *scope.Resolver) {
 	// Health check
 	router.GET("/health", salesHandlers.Health)
 
@@ -17,55 +31,83 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, sal
 	api := router.Group("/api")
 	api.Use(middleware.AuthMiddleware(cfg.JWT, cache))
 	api.Use(middleware.TenantMiddleware())
+	api.Use(middleware.WriteAuditMiddleware(auditLogger, auditTables))
 
 	// Daily Sales Routes (Critical for bulk entry workflow)
 	dailySales := api.Group("/daily-records")
 	{
-		dailySales.GET("", salesHandlers.GetDailySalesRecords)
-		dailySales.POST("", middleware.RoleMiddleware("salesman", "manager", "admin"), salesHandlers.CreateDailySalesRecord)
+		dailySales.GET("", middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetDailySalesRecords)
+		dailySales.POST("", middleware.PermissionMiddleware(permissionService, permissions.SalesCreate), middleware.IdempotencyMiddleware(cache), salesHandlers.CreateDailySalesRecord)
 		dailySales.GET("/:id", salesHandlers.GetDailySalesRecordByID)
-		dailySales.PUT("/:id", middleware.RoleMiddleware("salesman", "manager", "admin"), salesHandlers.UpdateDailySalesRecord)
-		dailySales.POST("/:id/approve", middleware.RoleMiddleware("manager", "admin"), salesHandlers.ApproveDailySalesRecord)
-		dailySales.POST("/:id/reject", middleware.RoleMiddleware("manager", "admin"), salesHandlers.RejectDailySalesRecord)
+		dailySales.PUT("/:id", middleware.PermissionMiddleware(permissionService, permissions.SalesCreate), salesHandlers.UpdateDailySalesRecord)
+		dailySales.PATCH("/:id/items/:itemId", middleware.PermissionMiddleware(permissionService, permissions.SalesCreate), salesHandlers.UpdateDailySalesItem)
+		dailySales.POST("/bulk-approve", middleware.PermissionMiddleware(permissionService, permissions.SalesApprove), middleware.ApproverMiddleware(settingsService, settings.ApprovalSales), middleware.ShopScopeMiddleware(shopScopes), salesHandlers.BulkApproveDailySalesRecords)
+		dailySales.POST("/:id/approve", middleware.PermissionMiddleware(permissionService, permissions.SalesApprove), middleware.ApproverMiddleware(settingsService, settings.ApprovalSales), salesHandlers.ApproveDailySalesRecord)
+		dailySales.POST("/:id/reject", middleware.PermissionMiddleware(permissionService, permissions.SalesApprove), middleware.ApproverMiddleware(settingsService, settings.ApprovalSales), salesHandlers.RejectDailySalesRecord)
+		dailySales.POST("/:id/void", middleware.RoleMiddleware("manager", "admin"), middleware.PermissionMiddleware(permissionService, permissions.SalesApprove), middleware.ApproverMiddleware(settingsService, settings.ApprovalSales), salesHandlers.VoidDailySalesRecord)
+		dailySales.POST("/:id/returns", middleware.PermissionMiddleware(permissionService, permissions.SalesCreate), salesHandlers.CreateDailySalesReturn)
 	}
 
 	// Individual Sales Routes
 	sales := api.Group("/sales")
 	{
-		sales.GET("", salesHandlers.GetSales)
-		sales.POST("", middleware.RoleMiddleware("salesman", "manager", "admin"), salesHandlers.CreateSale)
+		sales.GET("", middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetSales)
+		sales.POST("", middleware.PermissionMiddleware(permissionService, permissions.SalesCreate), salesHandlers.CreateSale)
+		sales.GET("/commissions", middleware.RoleMiddleware("manager", "admin"), middleware.ReadAuditMiddleware(readAuditor, settings.ReadAuditCommissions), middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetCommissions)
+		sales.GET("/commission-rules", middleware.RoleMiddleware("manager", "admin"), salesHandlers.GetCommissionRules)
+		sales.POST("/commission-rules", middleware.RoleMiddleware("admin"), salesHandlers.CreateCommissionRule)
 		sales.GET("/:id", salesHandlers.GetSaleByID)
-		sales.POST("/:id/approve", middleware.RoleMiddleware("manager", "admin"), salesHandlers.ApproveSale)
-		sales.POST("/:id/reject", middleware.RoleMiddleware("manager", "admin"), salesHandlers.RejectSale)
+		sales.POST("/:id/approve", middleware.PermissionMiddleware(permissionService, permissions.SalesApprove), middleware.ApproverMiddleware(settingsService, settings.ApprovalSales), salesHandlers.ApproveSale)
+		sales.POST("/:id/reject", middleware.PermissionMiddleware(permissionService, permissions.SalesApprove), middleware.ApproverMiddleware(settingsService, settings.ApprovalSales), salesHandlers.RejectSale)
 	}
 
 	// Sale Returns Routes
 	returns := api.Group("/returns")
 	{
-		returns.GET("", salesHandlers.GetSaleReturns)
-		returns.POST("", middleware.RoleMiddleware("salesman", "manager", "admin"), salesHandlers.CreateSaleReturn)
+		returns.GET("", middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetSaleReturns)
+		returns.POST("", middleware.PermissionMiddleware(permissionService, permissions.SalesCreate), salesHandlers.CreateSaleReturn)
 		returns.GET("/:id", salesHandlers.GetSaleReturnByID)
-		returns.POST("/:id/approve", middleware.RoleMiddleware("manager", "admin"), salesHandlers.ApproveSaleReturn)
-		returns.POST("/:id/reject", middleware.RoleMiddleware("manager", "admin"), salesHandlers.RejectSaleReturn)
+		returns.POST("/:id/approve", middleware.PermissionMiddleware(permissionService, permissions.SalesApprove), middleware.ApproverMiddleware(settingsService, settings.ApprovalSales), salesHandlers.ApproveSaleReturn)
+		returns.POST("/:id/reject", middleware.PermissionMiddleware(permissionService, permissions.SalesApprove), middleware.ApproverMiddleware(settingsService, settings.ApprovalSales), salesHandlers.RejectSaleReturn)
 	}
 
 	// Pending Items (for approval workflows)
 	pending := api.Group("/pending")
 	{
-		pending.GET("/sales", middleware.RoleMiddleware("manager", "admin"), salesHandlers.GetPendingSales)
-		pending.GET("/returns", middleware.RoleMiddleware("manager", "admin"), salesHandlers.GetPendingReturns)
+		pending.GET("/sales", middleware.RoleMiddleware("manager", "admin"), middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetPendingSales)
+		pending.GET("/returns", middleware.RoleMiddleware("manager", "admin"), middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetPendingReturns)
 	}
 
 	// Financial Reports
 	financial := api.Group("/financial")
 	{
-		financial.GET("/uncollected", middleware.RoleMiddleware("executive", "manager", "admin"), salesHandlers.GetUncollectedSales)
+		financial.GET("/uncollected", middleware.RoleMiddleware("executive", "manager", "admin"), middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetUncollectedSales)
 	}
 
 	// Dashboard and Summary Routes
 	dashboard := api.Group("/dashboard")
 	{
-		dashboard.GET("/summary", salesHandlers.GetDashboardSummary)
+		dashboard.GET("/summary", middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetDashboardSummary)
+	}
+
+	// Monthly sales targets
+	targets := api.Group("/targets")
+	targets.Use(middleware.RoleMiddleware("manager", "admin"))
+	{
+		targets.GET("", middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetSalesTargets)
+		targets.POST("", salesHandlers.CreateSalesTarget)
+		targets.GET("/achievement", middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetTargetAchievement)
+		targets.PUT("/:id", salesHandlers.UpdateSalesTarget)
+		targets.DELETE("/:id", salesHandlers.DeleteSalesTarget)
+	}
+
+	// Sales Reports
+	reports := api.Group("/reports")
+	reports.Use(middleware.RoleMiddleware("manager", "admin"))
+	{
+		reports.GET("/sales-by-category", middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetSalesByCategory)
+		reports.GET("/sales-stock-reconciliation", middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetSalesStockReconciliation)
+		reports.GET("/margins", middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetMarginReport)
 	}
 
 	// OCR and Image Processing Routes (Placeholder for future implementation)
@@ -101,7 +143,7 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, sal
 }
 
 // SetupProtectedRoutes sets up routes with gateway-style auth handling
-func SetupProtectedRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, salesHandlers *handlers.SalesHandlers) {
+func SetupProtectedRoutes(router *gin.Engine, cfg *config.Config, cache *cache.Cache, salesHandlers *handlers.SalesHandlers, settingsService *settings.Service, permissionService *permissions.Service, readAuditor *audit.ReadAuditor, auditLogger *audit.Logger, shopScopes *scope.Resolver) {
 	// Health check (no auth required)
 	router.GET("/health", salesHandlers.Health)
 
@@ -118,36 +160,56 @@ func SetupProtectedRoutes(router *gin.Engine, cfg *config.Config, cache *cache.C
 		}
 		c.Next()
 	})
+	router.Use(middleware.WriteAuditMiddleware(auditLogger, auditTables))
 
 	// Daily Sales Routes (Critical bulk entry endpoints)
-	router.GET("/daily-records", salesHandlers.GetDailySalesRecords)
-	router.POST("/daily-records", salesHandlers.CreateDailySalesRecord)
+	router.GET("/daily-records", middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetDailySalesRecords)
+	router.POST("/daily-records", middleware.PermissionMiddleware(permissionService, permissions.SalesCreate), middleware.IdempotencyMiddleware(cache), salesHandlers.CreateDailySalesRecord)
 	router.GET("/daily-records/:id", salesHandlers.GetDailySalesRecordByID)
-	router.PUT("/daily-records/:id", salesHandlers.UpdateDailySalesRecord)
-	router.POST("/daily-records/:id/approve", salesHandlers.ApproveDailySalesRecord)
-	router.POST("/daily-records/:id/reject", salesHandlers.RejectDailySalesRecord)
+	router.PUT("/daily-records/:id", middleware.PermissionMiddleware(permissionService, permissions.SalesCreate), salesHandlers.UpdateDailySalesRecord)
+	router.PATCH("/daily-records/:id/items/:itemId", middleware.PermissionMiddleware(permissionService, permissions.SalesCreate), salesHandlers.UpdateDailySalesItem)
+	router.POST("/daily-records/bulk-approve", middleware.PermissionMiddleware(permissionService, permissions.SalesApprove), middleware.ApproverMiddleware(settingsService, settings.ApprovalSales), middleware.ShopScopeMiddleware(shopScopes), salesHandlers.BulkApproveDailySalesRecords)
+	router.POST("/daily-records/:id/approve", middleware.PermissionMiddleware(permissionService, permissions.SalesApprove), middleware.ApproverMiddleware(settingsService, settings.ApprovalSales), salesHandlers.ApproveDailySalesRecord)
+	router.POST("/daily-records/:id/reject", middleware.PermissionMiddleware(permissionService, permissions.SalesApprove), middleware.ApproverMiddleware(settingsService, settings.ApprovalSales), salesHandlers.RejectDailySalesRecord)
+	router.POST("/daily-records/:id/void", middleware.RoleMiddleware("manager", "admin"), middleware.PermissionMiddleware(permissionService, permissions.SalesApprove), middleware.ApproverMiddleware(settingsService, settings.ApprovalSales), salesHandlers.VoidDailySalesRecord)
+	router.POST("/daily-records/:id/returns", middleware.PermissionMiddleware(permissionService, permissions.SalesCreate), salesHandlers.CreateDailySalesReturn)
 
 	// Individual Sales Routes
-	router.GET("/sales", salesHandlers.GetSales)
-	router.POST("/sales", salesHandlers.CreateSale)
+	router.GET("/sales", middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetSales)
+	router.POST("/sales", middleware.PermissionMiddleware(permissionService, permissions.SalesCreate), salesHandlers.CreateSale)
+	router.GET("/sales/commissions", middleware.RoleMiddleware("manager", "admin"), middleware.ReadAuditMiddleware(readAuditor, settings.ReadAuditCommissions), middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetCommissions)
+	router.GET("/sales/commission-rules", middleware.RoleMiddleware("manager", "admin"), salesHandlers.GetCommissionRules)
+	router.POST("/sales/commission-rules", middleware.RoleMiddleware("admin"), salesHandlers.CreateCommissionRule)
 	router.GET("/sales/:id", salesHandlers.GetSaleByID)
-	router.POST("/sales/:id/approve", salesHandlers.ApproveSale)
-	router.POST("/sales/:id/reject", salesHandlers.RejectSale)
+	router.POST("/sales/:id/approve", middleware.PermissionMiddleware(permissionService, permissions.SalesApprove), middleware.ApproverMiddleware(settingsService, settings.ApprovalSales), salesHandlers.ApproveSale)
+	router.POST("/sales/:id/reject", middleware.PermissionMiddleware(permissionService, permissions.SalesApprove), middleware.ApproverMiddleware(settingsService, settings.ApprovalSales), salesHandlers.RejectSale)
 
 	// Sale Returns Routes
-	router.GET("/returns", salesHandlers.GetSaleReturns)
-	router.POST("/returns", salesHandlers.CreateSaleReturn)
+	router.GET("/returns", middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetSaleReturns)
+	router.POST("/returns", middleware.PermissionMiddleware(permissionService, permissions.SalesCreate), salesHandlers.CreateSaleReturn)
 	router.GET("/returns/:id", salesHandlers.GetSaleReturnByID)
-	router.POST("/returns/:id/approve", salesHandlers.ApproveSaleReturn)
-	router.POST("/returns/:id/reject", salesHandlers.RejectSaleReturn)
+	router.POST("/returns/:id/approve", middleware.PermissionMiddleware(permissionService, permissions.SalesApprove), middleware.ApproverMiddleware(settingsService, settings.ApprovalSales), salesHandlers.ApproveSaleReturn)
+	router.POST("/returns/:id/reject", middleware.PermissionMiddleware(permissionService, permissions.SalesApprove), middleware.ApproverMiddleware(settingsService, settings.ApprovalSales), salesHandlers.RejectSaleReturn)
 
 	// Pending and Financial Routes
-	router.GET("/pending/sales", salesHandlers.GetPendingSales)
-	router.GET("/pending/returns", salesHandlers.GetPendingReturns)
-	router.GET("/uncollected", salesHandlers.GetUncollectedSales)
+	router.GET("/pending/sales", middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetPendingSales)
+	router.GET("/pending/returns", middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetPendingReturns)
+	router.GET("/uncollected", middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetUncollectedSales)
 
 	// Dashboard
-	router.GET("/dashboard/summary", salesHandlers.GetDashboardSummary)
+	router.GET("/dashboard/summary", middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetDashboardSummary)
+
+	// Sales targets
+	router.GET("/targets", middleware.RoleMiddleware("manager", "admin"), middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetSalesTargets)
+	router.POST("/targets", middleware.RoleMiddleware("manager", "admin"), salesHandlers.CreateSalesTarget)
+	router.GET("/targets/achievement", middleware.RoleMiddleware("manager", "admin"), middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetTargetAchievement)
+	router.PUT("/targets/:id", middleware.RoleMiddleware("manager", "admin"), salesHandlers.UpdateSalesTarget)
+	router.DELETE("/targets/:id", middleware.RoleMiddleware("manager", "admin"), salesHandlers.DeleteSalesTarget)
+
+	// Reports
+	router.GET("/reports/sales-by-category", middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetSalesByCategory)
+	router.GET("/reports/sales-stock-reconciliation", middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetSalesStockReconciliation)
+	router.GET("/reports/margins", middleware.ShopScopeMiddleware(shopScopes), salesHandlers.GetMarginReport)
 
 	// OCR Placeholder Routes
 	router.POST("/ocr/upload", func(c *gin.Context) {
@@ -0,0 +1,459 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/cache"
+	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+	"gorm.io/gorm"
+)
+
+// CommissionService handles commission rules and salesman commission calculation
+type CommissionService struct {
+	db    *database.DB
+	cache *cache.Cache
+}
+
+// NewCommissionService creates a new commission service
+func NewCommissionService(db *database.DB, cache *cache.Cache) *CommissionService {
+	return &CommissionService{
+		db:    db,
+		cache: cache,
+	}
+}
+
+type CommissionTierRequest struct {
+	MinAmount float64 `json:"min_amount" binding:"min=0"`
+	Rate      float64 `json:"rate" binding:"min=0,max=100"`
+}
+
+type CommissionCategoryRateRequest struct {
+	CategoryID uuid.UUID `json:"category_id" binding:"required"`
+	Rate       float64   `json:"rate" binding:"min=0,max=100"`
+}
+
+type CommissionRuleRequest struct {
+	SalesmanID    *uuid.UUID                      `json:"salesman_id"`
+	ShopID        *uuid.UUID                      `json:"shop_id"`
+	RuleType      string                          `json:"rule_type" binding:"required,oneof=flat tiered category"`
+	Rate          float64                         `json:"rate" binding:"min=0,max=100"`
+	Tiers         []CommissionTierRequest         `json:"tiers"`
+	CategoryRates []CommissionCategoryRateRequest `json:"category_rates"`
+}
+
+// CommissionLine is one part of a salesman's commission: a tier of a tiered rule,
+// a category of a category rule, or the whole amount for a flat rule
+type CommissionLine struct {
+	CategoryID   *uuid.UUID `json:"category_id,omitempty"`
+	CategoryName string     `json:"category_name,omitempty"`
+	MinAmount    *float64   `json:"min_amount,omitempty"`
+	SalesAmount  float64    `json:"sales_amount"`
+	ReturnAmount float64    `json:"return_amount"`
+	Amount       float64    `json:"amount"` // commissionable amount the rate applies to
+	Rate         float64    `json:"rate"`
+	Commission   float64    `json:"commission"`
+}
+
+// SalesmanCommission is one salesman's commission for a period
+type SalesmanCommission struct {
+	SalesmanID           uuid.UUID        `json:"salesman_id"`
+	SalesmanName         string           `json:"salesman_name"`
+	ShopID               uuid.UUID        `json:"shop_id"`
+	RuleID               *uuid.UUID       `json:"rule_id"`
+	RuleType             string           `json:"rule_type"`
+	SalesAmount          float64          `json:"sales_amount"`
+	ReturnAmount         float64          `json:"return_amount"`
+	CommissionableAmount float64          `json:"commissionable_amount"`
+	Commission           float64          `json:"commission"`
+	Breakdown            []CommissionLine `json:"breakdown"`
+}
+
+// CommissionReport lists salesman commissions for a period
+type CommissionReport struct {
+	StartDate       time.Time            `json:"start_date"`
+	EndDate         time.Time            `json:"end_date"`
+	TotalCommission float64              `json:"total_commission"`
+	Salesmen        []SalesmanCommission `json:"salesmen"`
+	GeneratedAt     time.Time            `json:"generated_at"`
+}
+
+// commissionAmount is an approved sales or returns total for one salesman and category
+type commissionAmount struct {
+	SalesmanID uuid.UUID
+	CategoryID uuid.UUID
+	Amount     float64
+}
+
+// CreateCommissionRule sets the commission rule for a salesman or a shop, replacing
+// the rule that was active for it before
+func (s *CommissionService) CreateCommissionRule(ctx context.Context, req CommissionRuleRequest, tenantID, userID uuid.UUID) (*models.CommissionRule, error) {
+	if (req.SalesmanID == nil) == (req.ShopID == nil) {
+		return nil, fmt.Errorf("set exactly one of salesman_id or shop_id")
+	}
+	switch req.RuleType {
+	case models.CommissionTiered:
+		if len(req.Tiers) == 0 {
+			return nil, fmt.Errorf("a tiered rule needs at least one tier")
+		}
+		seen := make(map[float64]bool, len(req.Tiers))
+		for _, tier := range req.Tiers {
+			if seen[tier.MinAmount] {
+				return nil, fmt.Errorf("tiers must have different min_amount values")
+			}
+			seen[tier.MinAmount] = true
+		}
+	case models.CommissionCategory:
+		if len(req.CategoryRates) == 0 && req.Rate == 0 {
+			return nil, fmt.Errorf("a category rule needs category rates or a fallback rate")
+		}
+	}
+
+	db := s.db.DB.WithContext(ctx)
+	if req.SalesmanID != nil {
+		var count int64
+		if err := db.Model(&models.Salesman{}).Where("id = ? AND tenant_id = ?", *req.SalesmanID, tenantID).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to get salesman: %w", err)
+		}
+		if count == 0 {
+			return nil, fmt.Errorf("salesman not found")
+		}
+	} else {
+		var count int64
+		if err := db.Model(&models.Shop{}).Where("id = ? AND tenant_id = ?", *req.ShopID, tenantID).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to get shop: %w", err)
+		}
+		if count == 0 {
+			return nil, fmt.Errorf("shop not found")
+		}
+	}
+
+	rule := models.CommissionRule{
+		TenantModel: models.TenantModel{
+			BaseModel: models.BaseModel{ID: uuid.New()},
+			TenantID:  tenantID,
+		},
+		SalesmanID:  req.SalesmanID,
+		ShopID:      req.ShopID,
+		RuleType:    req.RuleType,
+		Rate:        req.Rate,
+		IsActive:    true,
+		CreatedByID: userID,
+	}
+	if req.RuleType == models.CommissionTiered {
+		for _, tier := range req.Tiers {
+			rule.Tiers = append(rule.Tiers, models.CommissionTier{
+				TenantModel: models.TenantModel{TenantID: tenantID},
+				MinAmount:   tier.MinAmount,
+				Rate:        tier.Rate,
+			})
+		}
+	}
+	if req.RuleType == models.CommissionCategory {
+		for _, categoryRate := range req.CategoryRates {
+			rule.CategoryRates = append(rule.CategoryRates, models.CommissionCategoryRate{
+				TenantModel: models.TenantModel{TenantID: tenantID},
+				CategoryID:  categoryRate.CategoryID,
+				Rate:        categoryRate.Rate,
+			})
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		previous := tx.Model(&models.CommissionRule{}).Where("tenant_id = ? AND is_active = ?", tenantID, true)
+		if req.SalesmanID != nil {
+			previous = previous.Where("salesman_id = ?", *req.SalesmanID)
+		} else {
+			previous = previous.Where("shop_id = ? AND salesman_id IS NULL", *req.ShopID)
+		}
+		if err := previous.Update("is_active", false).Error; err != nil {
+			return fmt.Errorf("failed to replace commission rule: %w", err)
+		}
+		if err := tx.Create(&rule).Error; err != nil {
+			return fmt.Errorf("failed to create commission rule: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+// GetCommissionRules returns the tenant's active commission rules
+func (s *CommissionService) GetCommissionRules(ctx context.Context, tenantID uuid.UUID) ([]models.CommissionRule, error) {
+	var rules []models.CommissionRule
+	if err := s.db.DB.WithContext(ctx).
+		Preload("Tiers").
+		Preload("CategoryRates").
+		Where("tenant_id = ? AND is_active = ?", tenantID, true).
+		Order("created_at DESC").
+		Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to get commission rules: %w", err)
+	}
+	return rules, nil
+}
+
+// CalculateCommissions works out each salesman's commission between start and end
+// (end exclusive) from approved daily sales. Approved returns against a salesman's
+// daily sales reduce the commissionable amount in the period the return was made.
+// Salesmen are limited to one when salesmanID is set and to one shop when shopID is set.
+func (s *CommissionService) CalculateCommissions(ctx context.Context, tenantID uuid.UUID, salesmanID, shopID *uuid.UUID, start, end time.Time) (*CommissionReport, error) {
+	if !end.After(start) {
+		return nil, fmt.Errorf("end date must be after start date")
+	}
+
+	filter := func(query *gorm.DB) *gorm.DB {
+		query = query.Where("daily_sales_records.salesman_id IS NOT NULL AND daily_sales_records.deleted_at IS NULL")
+		if salesmanID != nil {
+			query = query.Where("daily_sales_records.salesman_id = ?", *salesmanID)
+		}
+		if shopID != nil {
+			query = query.Where("daily_sales_records.shop_id = ?", *shopID)
+		}
+		return scope.FromContext(ctx).Apply(query, "daily_sales_records.shop_id")
+	}
+
+	var sales []commissionAmount
+	if err := filter(s.db.DB.WithContext(ctx).Model(&models.DailySalesItem{}).
+		Select("daily_sales_records.salesman_id, products.category_id, SUM(daily_sales_items.total_amount) as amount").
+		Joins("JOIN daily_sales_records ON daily_sales_items.daily_sales_record_id = daily_sales_records.id").
+		Joins("JOIN products ON daily_sales_items.product_id = products.id").
+		Where("daily_sales_items.tenant_id = ? AND daily_sales_records.status = ? AND daily_sales_records.record_date >= ? AND daily_sales_records.record_date < ?",
+			tenantID, models.StatusApproved, start, end)).
+		Group("daily_sales_records.salesman_id, products.category_id").
+		Scan(&sales).Error; err != nil {
+		return nil, fmt.Errorf("failed to total salesman sales: %w", err)
+	}
+
+	var returns []commissionAmount
+	if err := filter(s.db.DB.WithContext(ctx).Model(&models.SaleReturnItem{}).
+		Select("daily_sales_records.salesman_id, products.category_id, SUM(sale_return_items.total_amount) as amount").
+		Joins("JOIN sale_returns ON sale_return_items.sale_return_id = sale_returns.id").
+		Joins("JOIN daily_sales_items ON sale_return_items.daily_sales_item_id = daily_sales_items.id").
+		Joins("JOIN daily_sales_records ON daily_sales_items.daily_sales_record_id = daily_sales_records.id").
+		Joins("JOIN products ON daily_sales_items.product_id = products.id").
+		Where("sale_return_items.tenant_id = ? AND sale_returns.status = ? AND sale_returns.return_date >= ? AND sale_returns.return_date < ?",
+			tenantID, models.StatusApproved, start, end).
+		Where("sale_returns.deleted_at IS NULL")).
+		Group("daily_sales_records.salesman_id, products.category_id").
+		Scan(&returns).Error; err != nil {
+		return nil, fmt.Errorf("failed to total salesman returns: %w", err)
+	}
+
+	type categoryTotals struct {
+		sales   float64
+		returns float64
+	}
+	totals := make(map[uuid.UUID]map[uuid.UUID]*categoryTotals)
+	categoryIDs := make(map[uuid.UUID]bool)
+	add := func(row commissionAmount, isReturn bool) {
+		if totals[row.SalesmanID] == nil {
+			totals[row.SalesmanID] = make(map[uuid.UUID]*categoryTotals)
+		}
+		category := totals[row.SalesmanID][row.CategoryID]
+		if category == nil {
+			category = &categoryTotals{}
+			totals[row.SalesmanID][row.CategoryID] = category
+		}
+		if isReturn {
+			category.returns += row.Amount
+		} else {
+			category.sales += row.Amount
+		}
+		categoryIDs[row.CategoryID] = true
+	}
+	for _, row := range sales {
+		add(row, false)
+	}
+	for _, row := range returns {
+		add(row, true)
+	}
+
+	report := &CommissionReport{
+		StartDate:   start,
+		EndDate:     end,
+		Salesmen:    []SalesmanCommission{},
+		GeneratedAt: time.Now(),
+	}
+	if len(totals) == 0 {
+		return report, nil
+	}
+
+	salesmanIDs := make([]uuid.UUID, 0, len(totals))
+	for id := range totals {
+		salesmanIDs = append(salesmanIDs, id)
+	}
+	var salesmen []models.Salesman
+	if err := s.db.DB.WithContext(ctx).
+		Where("id IN ? AND tenant_id = ?", salesmanIDs, tenantID).
+		Find(&salesmen).Error; err != nil {
+		return nil, fmt.Errorf("failed to get salesmen: %w", err)
+	}
+	shopIDs := make([]uuid.UUID, 0, len(salesmen))
+	for _, salesman := range salesmen {
+		shopIDs = append(shopIDs, salesman.ShopID)
+	}
+
+	var rules []models.CommissionRule
+	if err := s.db.DB.WithContext(ctx).
+		Preload("Tiers").
+		Preload("CategoryRates").
+		Where("tenant_id = ? AND is_active = ?", tenantID, true).
+		Where("salesman_id IN ? OR (salesman_id IS NULL AND shop_id IN ?)", salesmanIDs, shopIDs).
+		Order("created_at DESC").
+		Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to get commission rules: %w", err)
+	}
+	salesmanRules := make(map[uuid.UUID]*models.CommissionRule)
+	shopRules := make(map[uuid.UUID]*models.CommissionRule)
+	for i := range rules {
+		rule := &rules[i]
+		if rule.SalesmanID != nil {
+			if salesmanRules[*rule.SalesmanID] == nil {
+				salesmanRules[*rule.SalesmanID] = rule
+			}
+		} else if rule.ShopID != nil && shopRules[*rule.ShopID] == nil {
+			shopRules[*rule.ShopID] = rule
+		}
+	}
+
+	ids := make([]uuid.UUID, 0, len(categoryIDs))
+	for id := range categoryIDs {
+		ids = append(ids, id)
+	}
+	var categories []models.Category
+	if err := s.db.DB.WithContext(ctx).Where("id IN ?", ids).Find(&categories).Error; err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	categoryNames := make(map[uuid.UUID]string, len(categories))
+	for _, category := range categories {
+		categoryNames[category.ID] = category.Name
+	}
+
+	for _, salesman := range salesmen {
+		commission := SalesmanCommission{
+			SalesmanID:   salesman.ID,
+			SalesmanName: salesman.Name,
+			ShopID:       salesman.ShopID,
+			Breakdown:    []CommissionLine{},
+		}
+
+		var lines []CommissionLine
+		for categoryID, category := range totals[salesman.ID] {
+			id := categoryID
+			lines = append(lines, CommissionLine{
+				CategoryID:   &id,
+				CategoryName: categoryNames[categoryID],
+				SalesAmount:  category.sales,
+				ReturnAmount: category.returns,
+				Amount:       category.sales - category.returns,
+			})
+			commission.SalesAmount += category.sales
+			commission.ReturnAmount += category.returns
+		}
+		sort.Slice(lines, func(i, j int) bool { return lines[i].CategoryName < lines[j].CategoryName })
+
+		commission.CommissionableAmount = commission.SalesAmount - commission.ReturnAmount
+		if commission.CommissionableAmount < 0 {
+			commission.CommissionableAmount = 0
+		}
+
+		rule := salesmanRules[salesman.ID]
+		if rule == nil {
+			rule = shopRules[salesman.ShopID]
+		}
+		if rule != nil {
+			ruleID := rule.ID
+			commission.RuleID = &ruleID
+			commission.RuleType = rule.RuleType
+			commission.Breakdown = applyCommissionRule(rule, commission, lines)
+			for _, line := range commission.Breakdown {
+				commission.Commission += line.Commission
+			}
+			// Returns can outweigh a category's sales, but never leave the salesman owing
+			if commission.Commission < 0 {
+				commission.Commission = 0
+			}
+		}
+
+		commission.SalesAmount = utils.RoundToTwoDecimals(commission.SalesAmount)
+		commission.ReturnAmount = utils.RoundToTwoDecimals(commission.ReturnAmount)
+		commission.CommissionableAmount = utils.RoundToTwoDecimals(commission.CommissionableAmount)
+		commission.Commission = utils.RoundToTwoDecimals(commission.Commission)
+		report.TotalCommission += commission.Commission
+		report.Salesmen = append(report.Salesmen, commission)
+	}
+
+	sort.Slice(report.Salesmen, func(i, j int) bool {
+		return report.Salesmen[i].Commission > report.Salesmen[j].Commission
+	})
+	report.TotalCommission = utils.RoundToTwoDecimals(report.TotalCommission)
+
+	return report, nil
+}
+
+// applyCommissionRule splits a salesman's commission into breakdown lines. Flat
+// rules pay one rate on the whole commissionable amount; tiered rules pay each
+// tier's rate on the part of the amount that falls in that tier; category rules pay
+// each category's rate on that category's sales less its returns.
+func applyCommissionRule(rule *models.CommissionRule, commission SalesmanCommission, categories []CommissionLine) []CommissionLine {
+	lines := []CommissionLine{}
+	switch rule.RuleType {
+	case models.CommissionFlat:
+		lines = append(lines, CommissionLine{
+			SalesAmount:  utils.RoundToTwoDecimals(commission.SalesAmount),
+			ReturnAmount: utils.RoundToTwoDecimals(commission.ReturnAmount),
+			Amount:       utils.RoundToTwoDecimals(commission.CommissionableAmount),
+			Rate:         rule.Rate,
+			Commission:   utils.RoundToTwoDecimals(commission.CommissionableAmount * rule.Rate / 100),
+		})
+
+	case models.CommissionTiered:
+		tiers := append([]models.CommissionTier(nil), rule.Tiers...)
+		sort.Slice(tiers, func(i, j int) bool { return tiers[i].MinAmount < tiers[j].MinAmount })
+		for i, tier := range tiers {
+			if commission.CommissionableAmount <= tier.MinAmount {
+				break
+			}
+			upper := commission.CommissionableAmount
+			if i+1 < len(tiers) && tiers[i+1].MinAmount < upper {
+				upper = tiers[i+1].MinAmount
+			}
+			minAmount := tier.MinAmount
+			amount := upper - tier.MinAmount
+			lines = append(lines, CommissionLine{
+				MinAmount:  &minAmount,
+				Amount:     utils.RoundToTwoDecimals(amount),
+				Rate:       tier.Rate,
+				Commission: utils.RoundToTwoDecimals(amount * tier.Rate / 100),
+			})
+		}
+
+	case models.CommissionCategory:
+		rates := make(map[uuid.UUID]float64, len(rule.CategoryRates))
+		for _, categoryRate := range rule.CategoryRates {
+			rates[categoryRate.CategoryID] = categoryRate.Rate
+		}
+		for _, line := range categories {
+			rate, ok := rates[*line.CategoryID]
+			if !ok {
+				rate = rule.Rate
+			}
+			line.Rate = rate
+			line.Commission = utils.RoundToTwoDecimals(line.Amount * rate / 100)
+			line.SalesAmount = utils.RoundToTwoDecimals(line.SalesAmount)
+			line.ReturnAmount = utils.RoundToTwoDecimals(line.ReturnAmount)
+			line.Amount = utils.RoundToTwoDecimals(line.Amount)
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
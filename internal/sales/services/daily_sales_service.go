@@ -7,24 +7,40 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	inventory "github.com/liquorpro/go-backend/internal/inventory/services"
+	"github.com/liquorpro/go-backend/pkg/shared/approval"
 	"github.com/liquorpro/go-backend/pkg/shared/cache"
+	"github.com/liquorpro/go-backend/pkg/shared/config"
 	"github.com/liquorpro/go-backend/pkg/shared/database"
 	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/money"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
 	"github.com/liquorpro/go-backend/pkg/shared/utils"
+	"github.com/liquorpro/go-backend/pkg/shared/webhook"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // DailySalesService handles daily sales operations - the critical bulk entry workflow
 type DailySalesService struct {
-	db    *database.DB
-	cache *cache.Cache
+	db           *database.DB
+	cache        *cache.Cache
+	autoApprover *approval.AutoApprover
+	settings     *settings.Service
+	stocks       *inventory.StockService
+	limits       config.RequestLimitConfig
 }
 
 // NewDailySalesService creates a new daily sales service
-func NewDailySalesService(db *database.DB, cache *cache.Cache) *DailySalesService {
+func NewDailySalesService(db *database.DB, cache *cache.Cache, autoApprover *approval.AutoApprover, settingsService *settings.Service, stockService *inventory.StockService, limits config.RequestLimitConfig) *DailySalesService {
 	return &DailySalesService{
-		db:    db,
-		cache: cache,
+		db:           db,
+		cache:        cache,
+		autoApprover: autoApprover,
+		settings:     settingsService,
+		stocks:       stockService,
+		limits:       limits,
 	}
 }
 
@@ -33,25 +49,32 @@ type DailySalesRecordRequest struct {
 	RecordDate       time.Time              `json:"record_date" binding:"required"`
 	ShopID           uuid.UUID              `json:"shop_id" binding:"required"`
 	SalesmanID       *uuid.UUID             `json:"salesman_id"`
-	TotalSalesAmount float64                `json:"total_sales_amount" binding:"required,gt=0"`
-	TotalCashAmount  float64                `json:"total_cash_amount"`
-	TotalCardAmount  float64                `json:"total_card_amount"`
-	TotalUpiAmount   float64                `json:"total_upi_amount"`
-	TotalCreditAmount float64               `json:"total_credit_amount"`
+	TotalSalesAmount money.Money            `json:"total_sales_amount" binding:"required,gt=0"`
+	TotalCashAmount  money.Money            `json:"total_cash_amount"`
+	TotalCardAmount  money.Money            `json:"total_card_amount"`
+	TotalUpiAmount   money.Money            `json:"total_upi_amount"`
+	TotalCreditAmount money.Money           `json:"total_credit_amount"`
 	Notes            string                 `json:"notes"`
 	Items            []DailySalesItemRequest `json:"items" binding:"required,min=1"`
+	Version          *int                   `json:"version"` // on update, the version last read; a newer record is a conflict
+}
+
+// DailySalesApprovalRequest optionally names the version of a record the approver
+// reviewed, so approving a record edited since is refused
+type DailySalesApprovalRequest struct {
+	Version *int `json:"version"`
 }
 
 // DailySalesItemRequest represents individual product sales within daily record
 type DailySalesItemRequest struct {
 	ProductID     uuid.UUID `json:"product_id" binding:"required"`
 	Quantity      int       `json:"quantity" binding:"required,gt=0"`
-	UnitPrice     float64   `json:"unit_price" binding:"required,gt=0"`
-	TotalAmount   float64   `json:"total_amount" binding:"required,gt=0"`
-	CashAmount    float64   `json:"cash_amount"`
-	CardAmount    float64   `json:"card_amount"`
-	UpiAmount     float64   `json:"upi_amount"`
-	CreditAmount  float64   `json:"credit_amount"`
+	UnitPrice     money.Money `json:"unit_price" binding:"required,gt=0"`
+	TotalAmount   money.Money `json:"total_amount" binding:"required,gt=0"`
+	CashAmount    money.Money `json:"cash_amount"`
+	CardAmount    money.Money `json:"card_amount"`
+	UpiAmount     money.Money `json:"upi_amount"`
+	CreditAmount  money.Money `json:"credit_amount"`
 }
 
 // DailySalesRecordResponse represents daily sales record in responses
@@ -62,14 +85,19 @@ type DailySalesRecordResponse struct {
 	ShopName          string                  `json:"shop_name"`
 	SalesmanID        *uuid.UUID              `json:"salesman_id"`
 	SalesmanName      string                  `json:"salesman_name"`
-	TotalSalesAmount  float64                 `json:"total_sales_amount"`
-	TotalCashAmount   float64                 `json:"total_cash_amount"`
-	TotalCardAmount   float64                 `json:"total_card_amount"`
-	TotalUpiAmount    float64                 `json:"total_upi_amount"`
-	TotalCreditAmount float64                 `json:"total_credit_amount"`
+	TotalSalesAmount  money.Money             `json:"total_sales_amount"`
+	TotalCashAmount   money.Money             `json:"total_cash_amount"`
+	TotalCardAmount   money.Money             `json:"total_card_amount"`
+	TotalUpiAmount    money.Money             `json:"total_upi_amount"`
+	TotalCreditAmount money.Money             `json:"total_credit_amount"`
 	Status            string                  `json:"status"`
 	ApprovedAt        *time.Time              `json:"approved_at"`
 	ApprovedByName    string                  `json:"approved_by_name"`
+	AutoApproved      bool                    `json:"auto_approved"`
+	Version           int                     `json:"version"`
+	VoidedAt          *time.Time              `json:"voided_at,omitempty"`
+	VoidedByName      string                  `json:"voided_by_name,omitempty"`
+	VoidReason        string                  `json:"void_reason,omitempty"`
 	CreatedByName     string                  `json:"created_by_name"`
 	Notes             string                  `json:"notes"`
 	CreatedAt         time.Time               `json:"created_at"`
@@ -87,19 +115,23 @@ type DailySalesItemResponse struct {
 	CategoryName  string    `json:"category_name"`
 	Size          string    `json:"size"`
 	Quantity      int       `json:"quantity"`
-	UnitPrice     float64   `json:"unit_price"`
-	TotalAmount   float64   `json:"total_amount"`
-	CashAmount    float64   `json:"cash_amount"`
-	CardAmount    float64   `json:"card_amount"`
-	UpiAmount     float64   `json:"upi_amount"`
-	CreditAmount  float64   `json:"credit_amount"`
+	UnitPrice     money.Money `json:"unit_price"`
+	TotalAmount   money.Money `json:"total_amount"`
+	CashAmount    money.Money `json:"cash_amount"`
+	CardAmount    money.Money `json:"card_amount"`
+	UpiAmount     money.Money `json:"upi_amount"`
+	CreditAmount  money.Money `json:"credit_amount"`
 }
 
 // CreateDailySalesRecord creates a new daily sales record with bulk items
 func (s *DailySalesService) CreateDailySalesRecord(ctx context.Context, req DailySalesRecordRequest, tenantID, createdByID uuid.UUID) (*DailySalesRecordResponse, error) {
+	if err := s.checkItemCount(len(req.Items)); err != nil {
+		return nil, err
+	}
+
 	// Validate payment amounts sum up correctly
 	totalPaymentAmount := req.TotalCashAmount + req.TotalCardAmount + req.TotalUpiAmount + req.TotalCreditAmount
-	if utils.AbsFloat(totalPaymentAmount-req.TotalSalesAmount) > 0.01 {
+	if totalPaymentAmount != req.TotalSalesAmount {
 		return nil, errors.New("total payment amounts do not match total sales amount")
 	}
 
@@ -125,6 +157,12 @@ func (s *DailySalesService) CreateDailySalesRecord(ctx context.Context, req Dail
 		}
 	}
 
+	// Tenants that do not review sales approve every record as it is created, and
+	// records submitted by trusted executives below their limit skip manual approval
+	approvalRequired := s.settings.GetBool(ctx, tenantID, settings.KeySalesApprovalRequired)
+	autoApprove := approvalRequired && s.autoApprover.Eligible(ctx, tenantID, createdByID, req.TotalSalesAmount.Float64())
+	validateStock := s.settings.GetBool(ctx, tenantID, settings.KeyValidateStockOnSale)
+
 	// Start transaction for atomic creation
 	var record *models.DailySalesRecord
 	err := s.db.Transaction(func(tx *gorm.DB) error {
@@ -143,23 +181,42 @@ func (s *DailySalesService) CreateDailySalesRecord(ctx context.Context, req Dail
 			CreatedByID:       createdByID,
 			Notes:             req.Notes,
 		}
+		if autoApprove {
+			now := time.Now()
+			record.Status = models.StatusApproved
+			record.ApprovedAt = &now
+			record.AutoApproved = true
+		} else if !approvalRequired {
+			now := time.Now()
+			record.Status = models.StatusApproved
+			record.ApprovedAt = &now
+			record.ApprovedByID = &createdByID
+		}
 
 		if err := tx.Create(&record).Error; err != nil {
 			return fmt.Errorf("failed to create daily sales record: %w", err)
 		}
 
 		// Create daily sales items
-		totalItemsAmount := 0.0
+		var totalItemsAmount money.Money
+		requested := make(map[uuid.UUID]int, len(req.Items))
+		productNames := make(map[uuid.UUID]string, len(req.Items))
+		productOrder := make([]uuid.UUID, 0, len(req.Items))
 		for _, itemReq := range req.Items {
 			// Verify product exists
 			var product models.Product
 			if err := tx.Where("id = ? AND tenant_id = ?", itemReq.ProductID, tenantID).First(&product).Error; err != nil {
 				return fmt.Errorf("product %s not found", itemReq.ProductID)
 			}
+			if _, ok := requested[product.ID]; !ok {
+				productOrder = append(productOrder, product.ID)
+				productNames[product.ID] = product.Name
+			}
+			requested[product.ID] += itemReq.Quantity
 
 			// Validate item payment amounts
 			itemPaymentTotal := itemReq.CashAmount + itemReq.CardAmount + itemReq.UpiAmount + itemReq.CreditAmount
-			if utils.AbsFloat(itemPaymentTotal-itemReq.TotalAmount) > 0.01 {
+			if itemPaymentTotal != itemReq.TotalAmount {
 				return fmt.Errorf("payment amounts for product %s do not match total amount", product.Name)
 			}
 
@@ -185,10 +242,30 @@ func (s *DailySalesService) CreateDailySalesRecord(ctx context.Context, req Dail
 		}
 
 		// Verify total items amount matches record total
-		if utils.AbsFloat(totalItemsAmount-req.TotalSalesAmount) > 0.01 {
+		if totalItemsAmount != req.TotalSalesAmount {
 			return errors.New("total items amount does not match record total sales amount")
 		}
 
+		if validateStock {
+			for _, productID := range productOrder {
+				if err := checkStockAvailable(tx, req.ShopID, tenantID, productID, productNames[productID], requested[productID]); err != nil {
+					return err
+				}
+			}
+		}
+
+		if record.Status == models.StatusApproved {
+			if err := s.deductStock(tx, record, createdByID, false); err != nil {
+				return err
+			}
+			if err := enqueueDailySalesApproved(tx, record); err != nil {
+				return err
+			}
+		}
+		if record.AutoApproved {
+			return approval.RecordAutoApproval(tx, tenantID, createdByID, approval.EntityDailySalesRecord, record.ID, record.TotalSalesAmount.Float64())
+		}
+
 		return nil
 	})
 
@@ -198,6 +275,9 @@ func (s *DailySalesService) CreateDailySalesRecord(ctx context.Context, req Dail
 
 	// Clear cache for pending sales
 	s.clearDailySalesCache(ctx, tenantID, req.ShopID)
+	if record.Status == models.StatusApproved {
+		s.clearStockCache(ctx, record)
+	}
 
 	// Load and return complete record
 	return s.GetDailySalesRecordByID(ctx, record.ID, tenantID)
@@ -221,6 +301,7 @@ func (s *DailySalesService) GetDailySalesRecords(ctx context.Context, tenantID u
 	if filters.ShopID != uuid.Nil {
 		query = query.Where("shop_id = ?", filters.ShopID)
 	}
+	query = scope.FromContext(ctx).Apply(query, "shop_id")
 	if filters.SalesmanID != uuid.Nil {
 		query = query.Where("salesman_id = ?", filters.SalesmanID)
 	}
@@ -274,6 +355,7 @@ func (s *DailySalesService) GetDailySalesRecordByID(ctx context.Context, recordI
 		Preload("Salesman").
 		Preload("CreatedBy").
 		Preload("ApprovedBy").
+		Preload("VoidedBy").
 		Preload("Items.Product.Brand").
 		Preload("Items.Product.Category").
 		First(&record).Error
@@ -290,6 +372,10 @@ func (s *DailySalesService) GetDailySalesRecordByID(ctx context.Context, recordI
 
 // UpdateDailySalesRecord updates existing daily sales record
 func (s *DailySalesService) UpdateDailySalesRecord(ctx context.Context, recordID, tenantID uuid.UUID, req DailySalesRecordRequest) (*DailySalesRecordResponse, error) {
+	if err := s.checkItemCount(len(req.Items)); err != nil {
+		return nil, err
+	}
+
 	var record models.DailySalesRecord
 	
 	err := s.db.Where("id = ? AND tenant_id = ?", recordID, tenantID).First(&record).Error
@@ -304,21 +390,19 @@ func (s *DailySalesService) UpdateDailySalesRecord(ctx context.Context, recordID
 	if record.Status != models.StatusPending {
 		return nil, errors.New("only pending records can be updated")
 	}
+	if err := approval.CheckVersion("daily sales record", req.Version, record.Version); err != nil {
+		return nil, err
+	}
 
 	// Validate payment amounts
 	totalPaymentAmount := req.TotalCashAmount + req.TotalCardAmount + req.TotalUpiAmount + req.TotalCreditAmount
-	if utils.AbsFloat(totalPaymentAmount-req.TotalSalesAmount) > 0.01 {
+	if totalPaymentAmount != req.TotalSalesAmount {
 		return nil, errors.New("total payment amounts do not match total sales amount")
 	}
 
 	// Start transaction for atomic update
 	err = s.db.Transaction(func(tx *gorm.DB) error {
-		// Delete existing items
-		if err := tx.Where("daily_sales_record_id = ?", recordID).Delete(&models.DailySalesItem{}).Error; err != nil {
-			return fmt.Errorf("failed to delete existing items: %w", err)
-		}
-
-		// Update record
+		// Update record, unless it was edited or decided since it was read
 		updates := map[string]interface{}{
 			"total_sales_amount":  req.TotalSalesAmount,
 			"total_cash_amount":   req.TotalCashAmount,
@@ -328,12 +412,17 @@ func (s *DailySalesService) UpdateDailySalesRecord(ctx context.Context, recordID
 			"notes":               req.Notes,
 		}
 
-		if err := tx.Model(&record).Updates(updates).Error; err != nil {
-			return fmt.Errorf("failed to update daily sales record: %w", err)
+		if err := approval.UpdateVersioned(tx, "daily sales record", &record, &record.Version, updates); err != nil {
+			return err
+		}
+
+		// Delete existing items
+		if err := tx.Where("daily_sales_record_id = ?", recordID).Delete(&models.DailySalesItem{}).Error; err != nil {
+			return fmt.Errorf("failed to delete existing items: %w", err)
 		}
 
 		// Create new items
-		totalItemsAmount := 0.0
+		var totalItemsAmount money.Money
 		for _, itemReq := range req.Items {
 			// Verify product exists
 			var product models.Product
@@ -343,7 +432,7 @@ func (s *DailySalesService) UpdateDailySalesRecord(ctx context.Context, recordID
 
 			// Validate item payment amounts
 			itemPaymentTotal := itemReq.CashAmount + itemReq.CardAmount + itemReq.UpiAmount + itemReq.CreditAmount
-			if utils.AbsFloat(itemPaymentTotal-itemReq.TotalAmount) > 0.01 {
+			if itemPaymentTotal != itemReq.TotalAmount {
 				return fmt.Errorf("payment amounts for product %s do not match total amount", product.Name)
 			}
 
@@ -369,7 +458,7 @@ func (s *DailySalesService) UpdateDailySalesRecord(ctx context.Context, recordID
 		}
 
 		// Verify total items amount matches record total
-		if utils.AbsFloat(totalItemsAmount-req.TotalSalesAmount) > 0.01 {
+		if totalItemsAmount != req.TotalSalesAmount {
 			return errors.New("total items amount does not match record total sales amount")
 		}
 
@@ -387,21 +476,272 @@ func (s *DailySalesService) UpdateDailySalesRecord(ctx context.Context, recordID
 	return s.GetDailySalesRecordByID(ctx, recordID, tenantID)
 }
 
-// ApproveDailySalesRecord approves a daily sales record
-func (s *DailySalesService) ApproveDailySalesRecord(ctx context.Context, recordID, tenantID, approvedByID uuid.UUID) (*DailySalesRecordResponse, error) {
+// DailySalesItemUpdateRequest corrects a single line item; omitted fields are left
+// unchanged. When quantity or unit price changes without a total, the total is
+// recomputed from them.
+type DailySalesItemUpdateRequest struct {
+	Version      *int     `json:"version"` // the record version last read; a newer record is a conflict
+	Quantity     *int     `json:"quantity"`
+	UnitPrice    *money.Money `json:"unit_price"`
+	TotalAmount  *money.Money `json:"total_amount"`
+	CashAmount   *money.Money `json:"cash_amount"`
+	CardAmount   *money.Money `json:"card_amount"`
+	UpiAmount    *money.Money `json:"upi_amount"`
+	CreditAmount *money.Money `json:"credit_amount"`
+}
+
+// UpdateDailySalesItem corrects one item of a pending daily sales record and
+// recomputes the record's totals and payment split from its items
+func (s *DailySalesService) UpdateDailySalesItem(ctx context.Context, recordID, itemID, tenantID uuid.UUID, req DailySalesItemUpdateRequest) (*DailySalesRecordResponse, error) {
 	var record models.DailySalesRecord
-	
-	err := s.db.Where("id = ? AND tenant_id = ?", recordID, tenantID).First(&record).Error
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the record so an approval cannot land between the edit and the new totals
+		if err := s.lockDailySalesRecord(tx, &record, recordID, tenantID); err != nil {
+			return err
+		}
+		if record.Status != models.StatusPending {
+			return errors.New("only pending records can be updated")
+		}
+		if err := approval.CheckVersion("daily sales record", req.Version, record.Version); err != nil {
+			return err
+		}
+
+		var item models.DailySalesItem
+		if err := tx.Where("id = ? AND daily_sales_record_id = ? AND tenant_id = ?", itemID, recordID, tenantID).
+			First(&item).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("daily sales item not found")
+			}
+			return fmt.Errorf("failed to find daily sales item: %w", err)
+		}
+
+		if req.Quantity != nil {
+			item.Quantity = *req.Quantity
+		}
+		if req.UnitPrice != nil {
+			item.UnitPrice = *req.UnitPrice
+		}
+		if req.TotalAmount != nil {
+			item.TotalAmount = *req.TotalAmount
+		} else if req.Quantity != nil || req.UnitPrice != nil {
+			item.TotalAmount = item.UnitPrice * money.Money(item.Quantity)
+		}
+		if req.CashAmount != nil {
+			item.CashAmount = *req.CashAmount
+		}
+		if req.CardAmount != nil {
+			item.CardAmount = *req.CardAmount
+		}
+		if req.UpiAmount != nil {
+			item.UpiAmount = *req.UpiAmount
+		}
+		if req.CreditAmount != nil {
+			item.CreditAmount = *req.CreditAmount
+		}
+
+		if item.Quantity <= 0 {
+			return errors.New("quantity must be greater than zero")
+		}
+		if item.UnitPrice <= 0 || item.TotalAmount <= 0 {
+			return errors.New("unit price and total amount must be greater than zero")
+		}
+		if item.CashAmount < 0 || item.CardAmount < 0 || item.UpiAmount < 0 || item.CreditAmount < 0 {
+			return errors.New("payment amounts cannot be negative")
+		}
+
+		// Validate item payment amounts
+		itemPaymentTotal := item.CashAmount + item.CardAmount + item.UpiAmount + item.CreditAmount
+		if itemPaymentTotal != item.TotalAmount {
+			return errors.New("item payment amounts do not match total amount")
+		}
+
+		if err := tx.Model(&item).Updates(map[string]interface{}{
+			"quantity":      item.Quantity,
+			"unit_price":    item.UnitPrice,
+			"total_amount":  item.TotalAmount,
+			"cash_amount":   item.CashAmount,
+			"card_amount":   item.CardAmount,
+			"upi_amount":    item.UpiAmount,
+			"credit_amount": item.CreditAmount,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update daily sales item: %w", err)
+		}
+
+		// Recompute record totals from its items
+		var totals struct {
+			TotalSalesAmount  money.Money
+			TotalCashAmount   money.Money
+			TotalCardAmount   money.Money
+			TotalUpiAmount    money.Money
+			TotalCreditAmount money.Money
+		}
+		if err := tx.Model(&models.DailySalesItem{}).
+			Select(`COALESCE(SUM(total_amount), 0) as total_sales_amount,
+				COALESCE(SUM(cash_amount), 0) as total_cash_amount,
+				COALESCE(SUM(card_amount), 0) as total_card_amount,
+				COALESCE(SUM(upi_amount), 0) as total_upi_amount,
+				COALESCE(SUM(credit_amount), 0) as total_credit_amount`).
+			Where("daily_sales_record_id = ? AND tenant_id = ?", recordID, tenantID).
+			Scan(&totals).Error; err != nil {
+			return fmt.Errorf("failed to compute daily sales totals: %w", err)
+		}
+
+		return approval.UpdateVersioned(tx, "daily sales record", &record, &record.Version, map[string]interface{}{
+			"total_sales_amount":  totals.TotalSalesAmount,
+			"total_cash_amount":   totals.TotalCashAmount,
+			"total_card_amount":   totals.TotalCardAmount,
+			"total_upi_amount":    totals.TotalUpiAmount,
+			"total_credit_amount": totals.TotalCreditAmount,
+		})
+	})
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("daily sales record not found")
+		return nil, err
+	}
+
+	// Clear cache
+	s.clearDailySalesCache(ctx, tenantID, record.ShopID)
+
+	// Return updated record
+	return s.GetDailySalesRecordByID(ctx, recordID, tenantID)
+}
+
+// ApproveDailySalesRecord approves a daily sales record. The record is locked and its
+// status re-checked inside the transaction, so concurrent decisions take effect once.
+// When a version is given, a record edited since the approver read it is refused.
+// The sold quantities are deducted from the shop's stock in the same transaction, so
+// approval fails as a whole if any product is short.
+func (s *DailySalesService) ApproveDailySalesRecord(ctx context.Context, recordID, tenantID, approvedByID uuid.UUID, version *int) (*DailySalesRecordResponse, error) {
+	var record models.DailySalesRecord
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.lockDailySalesRecord(tx, &record, recordID, tenantID); err != nil {
+			return err
 		}
-		return nil, fmt.Errorf("failed to find daily sales record: %w", err)
+		return s.approveLocked(tx, &record, approvedByID, version)
+	})
+	if err != nil {
+		if approval.Replay(ctx, s.settings, tenantID, err) {
+			return s.GetDailySalesRecordByID(ctx, recordID, tenantID)
+		}
+		return nil, err
 	}
 
-	// Only pending records can be approved
-	if record.Status != models.StatusPending {
-		return nil, errors.New("only pending records can be approved")
+	// Clear cache
+	s.clearDailySalesCache(ctx, tenantID, record.ShopID)
+	s.clearStockCache(ctx, &record)
+
+	// Return updated record
+	return s.GetDailySalesRecordByID(ctx, recordID, tenantID)
+}
+
+// BulkApproveDailySalesRequest approves many daily sales records at once
+type BulkApproveDailySalesRequest struct {
+	RecordIDs []uuid.UUID `json:"record_ids" binding:"required,min=1,max=200"`
+}
+
+// Bulk approval outcomes for one record
+const (
+	BulkApprovalApproved = "approved"
+	BulkApprovalSkipped  = "skipped"
+	BulkApprovalFailed   = "failed"
+)
+
+// BulkApproveResult reports the outcome for one record in a bulk approval
+type BulkApproveResult struct {
+	RecordID uuid.UUID `json:"record_id"`
+	Status   string    `json:"status"` // approved, skipped, failed
+	Reason   string    `json:"reason,omitempty"`
+}
+
+// BulkApproveResponse summarizes a bulk approval
+type BulkApproveResponse struct {
+	Approved int                 `json:"approved"`
+	Skipped  int                 `json:"skipped"`
+	Failed   int                 `json:"failed"`
+	Results  []BulkApproveResult `json:"results"`
+}
+
+// BulkApprove approves many daily sales records in one transaction, deducting each
+// record's stock as a single approval does. Every record is approved under its own
+// savepoint, so one that fails, for example on insufficient stock, is rolled back
+// and reported without holding up the rest. Records that are no longer pending
+// are skipped, and records outside the caller's shops are reported as not found.
+func (s *DailySalesService) BulkApprove(ctx context.Context, recordIDs []uuid.UUID, tenantID, approverID uuid.UUID) (*BulkApproveResponse, error) {
+	response := &BulkApproveResponse{
+		Results: make([]BulkApproveResult, 0, len(recordIDs)),
+	}
+	shopScope := scope.FromContext(ctx)
+	var approved []models.DailySalesRecord
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		seen := make(map[uuid.UUID]bool, len(recordIDs))
+		for _, recordID := range recordIDs {
+			if seen[recordID] {
+				continue
+			}
+			seen[recordID] = true
+
+			var record models.DailySalesRecord
+			err := tx.Transaction(func(tx *gorm.DB) error {
+				if err := s.lockDailySalesRecord(tx, &record, recordID, tenantID); err != nil {
+					return err
+				}
+				if !shopScope.Allows(record.ShopID) {
+					return errors.New("daily sales record not found")
+				}
+				return s.approveLocked(tx, &record, approverID, nil)
+			})
+
+			result := BulkApproveResult{RecordID: recordID}
+			var processed *approval.AlreadyProcessedError
+			switch {
+			case err == nil:
+				result.Status = BulkApprovalApproved
+				response.Approved++
+				approved = append(approved, record)
+			case errors.As(err, &processed):
+				result.Status = BulkApprovalSkipped
+				result.Reason = err.Error()
+				response.Skipped++
+			default:
+				result.Status = BulkApprovalFailed
+				result.Reason = err.Error()
+				response.Failed++
+			}
+			response.Results = append(response.Results, result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Clear cache
+	clearedShops := make(map[uuid.UUID]bool)
+	for i := range approved {
+		if !clearedShops[approved[i].ShopID] {
+			clearedShops[approved[i].ShopID] = true
+			s.clearDailySalesCache(ctx, tenantID, approved[i].ShopID)
+		}
+		s.clearStockCache(ctx, &approved[i])
+	}
+
+	return response, nil
+}
+
+// approveLocked approves a pending record, row-locked by the caller, and takes its
+// items out of stock. A non-nil version must match the record's.
+func (s *DailySalesService) approveLocked(tx *gorm.DB, record *models.DailySalesRecord, approvedByID uuid.UUID, version *int) error {
+	if err := approval.Decide("daily sales record", record.Status, models.StatusApproved); err != nil {
+		return err
+	}
+	if err := approval.CheckVersion("daily sales record", version, record.Version); err != nil {
+		return err
+	}
+
+	if err := s.deductStock(tx, record, approvedByID, false); err != nil {
+		return err
 	}
 
 	// Update record status
@@ -412,50 +752,206 @@ func (s *DailySalesService) ApproveDailySalesRecord(ctx context.Context, recordI
 		"approved_by_id": approvedByID,
 	}
 
-	if err := s.db.Model(&record).Updates(updates).Error; err != nil {
-		return nil, fmt.Errorf("failed to approve daily sales record: %w", err)
+	if err := approval.UpdateVersioned(tx, "daily sales record", record, &record.Version, updates); err != nil {
+		return err
+	}
+	record.Status = models.StatusApproved
+	record.ApprovedAt = &now
+	record.ApprovedByID = &approvedByID
+	return enqueueDailySalesApproved(tx, record)
+}
+
+// RejectDailySalesRecord rejects a daily sales record under the same lock and
+// version check as approval
+func (s *DailySalesService) RejectDailySalesRecord(ctx context.Context, recordID, tenantID, rejectedByID uuid.UUID, reason string, version *int) error {
+	var record models.DailySalesRecord
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.lockDailySalesRecord(tx, &record, recordID, tenantID); err != nil {
+			return err
+		}
+		if err := approval.Decide("daily sales record", record.Status, models.StatusRejected); err != nil {
+			return err
+		}
+		if err := approval.CheckVersion("daily sales record", version, record.Version); err != nil {
+			return err
+		}
+
+		// Update record status
+		now := time.Now()
+		updates := map[string]interface{}{
+			"status":         models.StatusRejected,
+			"approved_at":    now,
+			"approved_by_id": rejectedByID,
+			"notes":          record.Notes + " | Rejection reason: " + reason,
+		}
+
+		return approval.UpdateVersioned(tx, "daily sales record", &record, &record.Version, updates)
+	})
+	if err != nil {
+		if approval.Replay(ctx, s.settings, tenantID, err) {
+			return nil
+		}
+		return err
 	}
 
 	// Clear cache
 	s.clearDailySalesCache(ctx, tenantID, record.ShopID)
 
-	// Return updated record
-	return s.GetDailySalesRecordByID(ctx, recordID, tenantID)
+	return nil
 }
 
-// RejectDailySalesRecord rejects a daily sales record
-func (s *DailySalesService) RejectDailySalesRecord(ctx context.Context, recordID, tenantID, rejectedByID uuid.UUID, reason string) error {
+// VoidDailySalesRecord cancels an approved daily sales record, puts its quantities
+// back into the shop's stock and records who voided it and why. A record with
+// pending or approved returns must have them settled first, since returned goods
+// are already back in stock. Voided records drop out of revenue and analytics.
+func (s *DailySalesService) VoidDailySalesRecord(ctx context.Context, recordID, tenantID, voidedByID uuid.UUID, reason string) (*DailySalesRecordResponse, error) {
 	var record models.DailySalesRecord
-	
-	err := s.db.Where("id = ? AND tenant_id = ?", recordID, tenantID).First(&record).Error
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.lockDailySalesRecord(tx, &record, recordID, tenantID); err != nil {
+			return err
+		}
+		if record.Status != models.StatusApproved {
+			return errors.New("only approved daily sales records can be voided")
+		}
+
+		// Returned goods are already back in stock, so voiding would restock them twice
+		var returns int64
+		if err := tx.Model(&models.SaleReturn{}).
+			Where("daily_sales_record_id = ? AND tenant_id = ? AND status IN ?",
+				record.ID, tenantID, []string{models.StatusPending, models.StatusApproved}).
+			Count(&returns).Error; err != nil {
+			return fmt.Errorf("failed to check daily sales returns: %w", err)
+		}
+		if returns > 0 {
+			return errors.New("daily sales records with returns cannot be voided")
+		}
+
+		if err := s.deductStock(tx, &record, voidedByID, true); err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{
+			"status":       models.StatusVoided,
+			"voided_at":    time.Now(),
+			"voided_by_id": voidedByID,
+			"void_reason":  reason,
+		}
+
+		return approval.UpdateVersioned(tx, "daily sales record", &record, &record.Version, updates)
+	})
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("daily sales record not found")
+		return nil, err
+	}
+
+	// Clear cache
+	s.clearDailySalesCache(ctx, tenantID, record.ShopID)
+	s.clearStockCache(ctx, &record)
+
+	return s.GetDailySalesRecordByID(ctx, recordID, tenantID)
+}
+
+// enqueueDailySalesApproved queues the sale.approved webhook event for a record in
+// the transaction that approved it
+func enqueueDailySalesApproved(tx *gorm.DB, record *models.DailySalesRecord) error {
+	data := webhook.SaleApprovedData{
+		Source:       webhook.SaleSourceDailySalesRecord,
+		ID:           record.ID,
+		ShopID:       record.ShopID,
+		Amount:       record.TotalSalesAmount.Float64(),
+		ApprovedByID: record.ApprovedByID,
+		AutoApproved: record.AutoApproved,
+	}
+	if record.ApprovedAt != nil {
+		data.ApprovedAt = *record.ApprovedAt
+	}
+	return webhook.Enqueue(tx, record.TenantID, webhook.EventSaleApproved, data)
+}
+
+// deductStock takes a record's items out of its shop's stock, or puts them back
+// when reverse is set, inside the caller's transaction
+func (s *DailySalesService) deductStock(tx *gorm.DB, record *models.DailySalesRecord, userID uuid.UUID, reverse bool) error {
+	items, err := s.stockItems(tx, record)
+	if err != nil {
+		return err
+	}
+
+	reference := fmt.Sprintf("DAILY-%s", record.ID)
+	return s.stocks.ProcessSaleTx(tx, reference, record.ID, items, record.ShopID, record.TenantID, userID, reverse)
+}
+
+// stockItems converts a record's items into the sale items the stock service moves
+func (s *DailySalesService) stockItems(db *gorm.DB, record *models.DailySalesRecord) ([]models.SaleItem, error) {
+	var items []models.DailySalesItem
+	if err := db.Where("daily_sales_record_id = ? AND tenant_id = ?", record.ID, record.TenantID).Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to get daily sales items: %w", err)
+	}
+
+	saleItems := make([]models.SaleItem, len(items))
+	for i, item := range items {
+		saleItems[i] = models.SaleItem{
+			ProductID:  item.ProductID,
+			Quantity:   item.Quantity,
+			UnitPrice:  item.UnitPrice.Float64(),
+			TotalPrice: item.TotalAmount.Float64(),
 		}
-		return fmt.Errorf("failed to find daily sales record: %w", err)
 	}
+	return saleItems, nil
+}
 
-	// Only pending records can be rejected
-	if record.Status != models.StatusPending {
-		return errors.New("only pending records can be rejected")
+// clearStockCache drops cached stock levels for a record's products after its
+// stock movements have committed
+func (s *DailySalesService) clearStockCache(ctx context.Context, record *models.DailySalesRecord) {
+	items, err := s.stockItems(s.db.DB, record)
+	if err != nil {
+		return
 	}
+	s.stocks.ClearSaleStockCache(ctx, record.TenantID, record.ShopID, items)
+}
 
-	// Update record status
-	now := time.Now()
-	updates := map[string]interface{}{
-		"status":         models.StatusRejected,
-		"approved_at":    now,
-		"approved_by_id": rejectedByID,
-		"notes":          record.Notes + " | Rejection reason: " + reason,
+// checkItemCount refuses a record with more items than the configured maximum
+func (s *DailySalesService) checkItemCount(count int) error {
+	if s.limits.MaxItems > 0 && count > s.limits.MaxItems {
+		return fmt.Errorf("%w: a daily sales record can have at most %d items, got %d", utils.ErrTooManyItems, s.limits.MaxItems, count)
 	}
+	return nil
+}
 
-	if err := s.db.Model(&record).Updates(updates).Error; err != nil {
-		return fmt.Errorf("failed to reject daily sales record: %w", err)
+// checkStockAvailable refuses a sale of more units than the shop has available,
+// leaving out units reserved for other documents. The stock row stays locked until
+// the transaction ends so a concurrent sale cannot claim the same units.
+func checkStockAvailable(tx *gorm.DB, shopID, tenantID, productID uuid.UUID, productName string, quantity int) error {
+	var stock models.Stock
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("shop_id = ? AND product_id = ? AND tenant_id = ?", shopID, productID, tenantID).
+		First(&stock).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to get stock: %w", err)
 	}
 
-	// Clear cache
-	s.clearDailySalesCache(ctx, tenantID, record.ShopID)
+	available := stock.Quantity - stock.ReservedQuantity
+	if available < 0 {
+		available = 0
+	}
+	if quantity > available {
+		return fmt.Errorf("insufficient stock for %s: %d available, %d required, short by %d",
+			productName, available, quantity, quantity-available)
+	}
+	return nil
+}
 
+// lockDailySalesRecord loads a record with a row lock held until the transaction ends
+func (s *DailySalesService) lockDailySalesRecord(tx *gorm.DB, record *models.DailySalesRecord, recordID, tenantID uuid.UUID) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND tenant_id = ?", recordID, tenantID).
+		First(record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("daily sales record not found")
+		}
+		return fmt.Errorf("failed to find daily sales record: %w", err)
+	}
 	return nil
 }
 
@@ -495,6 +991,10 @@ func (s *DailySalesService) mapDailySalesRecordToResponse(record *models.DailySa
 		TotalCreditAmount: record.TotalCreditAmount,
 		Status:            record.Status,
 		ApprovedAt:        record.ApprovedAt,
+		AutoApproved:      record.AutoApproved,
+		Version:           record.Version,
+		VoidedAt:          record.VoidedAt,
+		VoidReason:        record.VoidReason,
 		Notes:             record.Notes,
 		CreatedAt:         record.CreatedAt,
 		UpdatedAt:         record.UpdatedAt,
@@ -521,6 +1021,11 @@ func (s *DailySalesService) mapDailySalesRecordToResponse(record *models.DailySa
 		response.ApprovedByName = record.ApprovedBy.FirstName + " " + record.ApprovedBy.LastName
 	}
 
+	// Add voided by info
+	if record.VoidedBy != nil {
+		response.VoidedByName = record.VoidedBy.FirstName + " " + record.VoidedBy.LastName
+	}
+
 	// Add items
 	if len(record.Items) > 0 {
 		response.Items = make([]DailySalesItemResponse, len(record.Items))
@@ -567,4 +1072,6 @@ func (s *DailySalesService) clearDailySalesCache(ctx context.Context, tenantID,
 	for _, key := range cacheKeys {
 		s.cache.Delete(ctx, key)
 	}
+
+	s.cache.InvalidateDashboard(ctx, tenantID.String())
 }
\ No newline at end of file
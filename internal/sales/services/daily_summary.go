@@ -0,0 +1,280 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/cache"
+	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/notification"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+)
+
+// dailySummaryTopProducts is how many products the daily summary lists
+const dailySummaryTopProducts = 5
+
+// DailySalesSummary is one day's sales totals for a tenant
+type DailySalesSummary struct {
+	Date         time.Time           `json:"date"`
+	Sales        DailySalesStats     `json:"sales"`
+	Returns      DailyReturnsStats   `json:"returns"`
+	TotalRevenue float64             `json:"total_revenue"`
+	CashAmount   float64             `json:"cash_amount"`
+	CardAmount   float64             `json:"card_amount"`
+	UpiAmount    float64             `json:"upi_amount"`
+	CreditAmount float64             `json:"credit_amount"`
+	Shops        []ShopSummary       `json:"shops"`
+	TopProducts  []TopProductSummary `json:"top_products"`
+
+	// Approvals still outstanding when the summary was built
+	PendingSales         int     `json:"pending_sales"`
+	PendingReturns       int     `json:"pending_returns"`
+	PendingExpenses      int     `json:"pending_expenses"`
+	PendingExpenseAmount float64 `json:"pending_expense_amount"`
+}
+
+// GetDailySummary totals the calendar day containing day, in day's location. It
+// builds on the same queries as the dashboard, so the numbers match what the
+// dashboard showed for that day.
+func (s *DashboardService) GetDailySummary(ctx context.Context, tenantID uuid.UUID, day time.Time) (*DailySalesSummary, error) {
+	start := utils.StartOfDay(day)
+	end := start.AddDate(0, 0, 1)
+
+	// The dashboard helpers fill a dashboard summary; only the day's parts are kept
+	dashboard := &DashboardSummaryResponse{}
+	if err := s.getTodaysSalesStats(ctx, tenantID, nil, start, end, dashboard); err != nil {
+		return nil, fmt.Errorf("failed to get sales stats: %w", err)
+	}
+	if err := s.getTodaysReturnsStats(ctx, tenantID, nil, start, end, dashboard); err != nil {
+		return nil, fmt.Errorf("failed to get returns stats: %w", err)
+	}
+	if err := s.getPendingApprovalsCount(ctx, tenantID, nil, dashboard); err != nil {
+		return nil, fmt.Errorf("failed to get pending approvals: %w", err)
+	}
+	if err := s.getShopSummaries(ctx, tenantID, start, end, dashboard); err != nil {
+		return nil, fmt.Errorf("failed to get shop summaries: %w", err)
+	}
+
+	totals, err := s.paymentTotals(ctx, tenantID, nil, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment totals: %w", err)
+	}
+	topProducts, err := s.topProducts(ctx, tenantID, nil, start, end, dailySummaryTopProducts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top products: %w", err)
+	}
+
+	return &DailySalesSummary{
+		Date:                 start,
+		Sales:                dashboard.TodaySales,
+		Returns:              dashboard.TodayReturns,
+		TotalRevenue:         totals.TotalRevenue,
+		CashAmount:           totals.CashAmount,
+		CardAmount:           totals.CardAmount,
+		UpiAmount:            totals.UpiAmount,
+		CreditAmount:         totals.CreditAmount,
+		Shops:                dashboard.ShopSummaries,
+		TopProducts:          topProducts,
+		PendingSales:         dashboard.PendingSales,
+		PendingReturns:       dashboard.PendingReturns,
+		PendingExpenses:      dashboard.PendingExpenses,
+		PendingExpenseAmount: dashboard.PendingExpenseAmount,
+	}, nil
+}
+
+// DailySummaryMailer emails each tenant's configured recipients a summary of the
+// previous day's sales once their send time has passed
+type DailySummaryMailer struct {
+	db              *database.DB
+	cache           *cache.Cache
+	dashboard       *DashboardService
+	settings        *settings.Service
+	notifier        *notification.Service
+	defaultLocation *time.Location
+}
+
+// NewDailySummaryMailer creates a new daily summary mailer. defaultTimezone is used
+// for tenants whose timezone setting cannot be loaded.
+func NewDailySummaryMailer(db *database.DB, cache *cache.Cache, dashboard *DashboardService, settingsService *settings.Service, notifier *notification.Service, defaultTimezone string) *DailySummaryMailer {
+	location, err := time.LoadLocation(defaultTimezone)
+	if err != nil {
+		location = time.UTC
+	}
+	return &DailySummaryMailer{
+		db:              db,
+		cache:           cache,
+		dashboard:       dashboard,
+		settings:        settingsService,
+		notifier:        notifier,
+		defaultLocation: location,
+	}
+}
+
+// SendDueSummaries sends yesterday's summary to every active tenant whose send
+// time has passed and who has not had it yet, and returns how many were sent. A
+// tenant that fails is logged and skipped; it is retried on the next run.
+func (m *DailySummaryMailer) SendDueSummaries(ctx context.Context) (int, error) {
+	var tenants []models.Tenant
+	if err := m.db.WithContext(ctx).Select("id", "name").Where("is_active = ?", true).Find(&tenants).Error; err != nil {
+		return 0, fmt.Errorf("failed to get tenants: %w", err)
+	}
+
+	now := time.Now()
+	sent := 0
+	for _, tenant := range tenants {
+		if ctx.Err() != nil {
+			break
+		}
+		ok, err := m.sendTenantSummary(ctx, tenant, now)
+		if err != nil {
+			log.Printf("Daily sales summary for tenant %s failed: %v", tenant.ID, err)
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// sendTenantSummary sends one tenant's summary if it is due and reports whether it
+// was sent. A panic is recovered so it cannot stop the other tenants' summaries.
+func (m *DailySummaryMailer) sendTenantSummary(ctx context.Context, tenant models.Tenant, now time.Time) (sent bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			sent, err = false, fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	recipients := m.settings.GetList(ctx, tenant.ID, settings.KeyDailySummaryRecipients)
+	if len(recipients) == 0 {
+		return false, nil
+	}
+
+	location, err := time.LoadLocation(m.settings.GetString(ctx, tenant.ID, settings.KeyTimezone))
+	if err != nil {
+		location = m.defaultLocation
+	}
+	sendAt, err := time.Parse(settings.DailySummaryTimeLayout, m.settings.GetString(ctx, tenant.ID, settings.KeyDailySummarySendTime))
+	if err != nil {
+		return false, fmt.Errorf("invalid send time: %w", err)
+	}
+
+	local := now.In(location)
+	today := utils.StartOfDay(local)
+	if local.Before(today.Add(time.Duration(sendAt.Hour())*time.Hour + time.Duration(sendAt.Minute())*time.Minute)) {
+		return false, nil
+	}
+	yesterday := today.AddDate(0, 0, -1)
+
+	// Claim the day so a restart or a second instance does not send it again. The
+	// claim is released on failure so the next run retries.
+	key := fmt.Sprintf("daily_summary:%s:%s", tenant.ID.String(), yesterday.Format("2006-01-02"))
+	claimed, err := m.cache.Lock(ctx, key, 48*time.Hour)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim summary: %w", err)
+	}
+	if !claimed {
+		return false, nil
+	}
+
+	if err := m.send(ctx, tenant, yesterday, recipients); err != nil {
+		m.cache.Unlock(ctx, key)
+		return false, err
+	}
+	return true, nil
+}
+
+// send builds a tenant's summary for day and emails it to the recipients
+func (m *DailySummaryMailer) send(ctx context.Context, tenant models.Tenant, day time.Time, recipients []string) error {
+	summary, err := m.dashboard.GetDailySummary(ctx, tenant.ID, day)
+	if err != nil {
+		return err
+	}
+
+	shops := make([]map[string]interface{}, len(summary.Shops))
+	for i, shop := range summary.Shops {
+		shops[i] = map[string]interface{}{
+			"shop_name":    shop.ShopName,
+			"total_sales":  shop.TotalSales,
+			"total_amount": fmt.Sprintf("%.2f", shop.TotalAmount),
+		}
+	}
+	topProducts := make([]map[string]interface{}, len(summary.TopProducts))
+	for i, product := range summary.TopProducts {
+		topProducts[i] = map[string]interface{}{
+			"product_name": product.ProductName,
+			"quantity":     product.TotalQuantity,
+			"amount":       fmt.Sprintf("%.2f", product.TotalAmount),
+		}
+	}
+
+	vars := map[string]interface{}{
+		"date":             day.Format("Mon, 02 Jan 2006"),
+		"sales_count":      summary.Sales.TotalSales,
+		"sales_amount":     fmt.Sprintf("%.2f", summary.Sales.TotalAmount),
+		"approved_amount":  fmt.Sprintf("%.2f", summary.Sales.ApprovedAmount),
+		"returns_count":    summary.Returns.TotalReturns,
+		"returns_amount":   fmt.Sprintf("%.2f", summary.Returns.TotalAmount),
+		"total_revenue":    fmt.Sprintf("%.2f", summary.TotalRevenue),
+		"cash_amount":      fmt.Sprintf("%.2f", summary.CashAmount),
+		"card_amount":      fmt.Sprintf("%.2f", summary.CardAmount),
+		"upi_amount":       fmt.Sprintf("%.2f", summary.UpiAmount),
+		"credit_amount":    fmt.Sprintf("%.2f", summary.CreditAmount),
+		"shops":            shops,
+		"top_products":     topProducts,
+		"pending_sales":    summary.PendingSales,
+		"pending_returns":  summary.PendingReturns,
+		"pending_expenses": summary.PendingExpenses,
+		"company_name":     tenant.Name,
+	}
+
+	if err := m.notifier.Send(ctx, tenant.ID, models.EmailEventDailySalesSummary, recipients, vars); err != nil {
+		return fmt.Errorf("failed to send summary: %w", err)
+	}
+	return nil
+}
+
+// DailySummaryWorker periodically sends daily sales summaries that have come due
+type DailySummaryWorker struct {
+	mailer   *DailySummaryMailer
+	interval time.Duration
+}
+
+// NewDailySummaryWorker creates a worker that checks for due summaries at the given interval
+func NewDailySummaryWorker(mailer *DailySummaryMailer, interval time.Duration) *DailySummaryWorker {
+	return &DailySummaryWorker{
+		mailer:   mailer,
+		interval: interval,
+	}
+}
+
+// Start runs the check in the background until ctx is cancelled. The first check
+// runs straight away so a restart after the send time still sends the summary.
+func (w *DailySummaryWorker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			sent, err := w.mailer.SendDueSummaries(ctx)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("Daily sales summary run failed: %v", err)
+			} else if sent > 0 {
+				log.Printf("Sent %d daily sales summary email(s)", sent)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
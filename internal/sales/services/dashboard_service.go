@@ -9,20 +9,25 @@ import (
 	"github.com/liquorpro/go-backend/pkg/shared/cache"
 	"github.com/liquorpro/go-backend/pkg/shared/database"
 	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
 	"github.com/liquorpro/go-backend/pkg/shared/utils"
+	"gorm.io/gorm"
 )
 
 // DashboardService handles dashboard and reporting operations
 type DashboardService struct {
-	db    *database.DB
-	cache *cache.Cache
+	db       *database.DB
+	cache    *cache.Cache
+	settings *settings.Service
 }
 
 // NewDashboardService creates a new dashboard service
-func NewDashboardService(db *database.DB, cache *cache.Cache) *DashboardService {
+func NewDashboardService(db *database.DB, cache *cache.Cache, settingsService *settings.Service) *DashboardService {
 	return &DashboardService{
-		db:    db,
-		cache: cache,
+		db:       db,
+		cache:    cache,
+		settings: settingsService,
 	}
 }
 
@@ -35,6 +40,18 @@ type DashboardSummaryResponse struct {
 	// Pending approvals
 	PendingSales     int                `json:"pending_sales"`
 	PendingReturns   int                `json:"pending_returns"`
+	PendingExpenses  int                `json:"pending_expenses"`
+	PendingExpenseAmount float64 `json:"pending_expense_amount"`
+	// False when the tenant approves daily sales records as they are created
+	SalesApprovalRequired bool `json:"sales_approval_required"`
+	
+	// Over or short cash drawer counts nobody has resolved yet; the amount is
+	// their net difference, negative when cash is missing
+	CashDiscrepancies      int     `json:"cash_discrepancies"`
+	CashDiscrepancyAmount  float64 `json:"cash_discrepancy_amount"`
+	
+	// Progress against this month's sales targets, when any are set
+	SalesTarget      *TargetAchievement `json:"sales_target,omitempty"`
 	
 	// Financial summary
 	TotalRevenue     float64            `json:"total_revenue"`
@@ -111,16 +128,21 @@ type RecentSaleActivity struct {
 
 // GetDashboardSummary returns dashboard summary for a tenant
 func (s *DashboardService) GetDashboardSummary(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID) (*DashboardSummaryResponse, error) {
-	// Try to get from cache first
-	cacheKey := fmt.Sprintf("dashboard_summary:%s", tenantID.String())
+	// Try to get from cache first. The key carries the tenant's dashboard version,
+	// which sales, returns and finance writes bump to expire stale summaries.
+	version := s.cache.DashboardVersion(ctx, tenantID.String())
+	cacheKey := fmt.Sprintf("dashboard_summary:%s:v%d", tenantID.String(), version)
 	if shopID != nil {
-		cacheKey = fmt.Sprintf("dashboard_summary:%s:%s", tenantID.String(), shopID.String())
+		cacheKey = fmt.Sprintf("dashboard_summary:%s:v%d:%s", tenantID.String(), version, shopID.String())
+	}
+	if shopScope := scope.FromContext(ctx); shopScope != nil && shopScope.Restricted {
+		cacheKey = fmt.Sprintf("%s:scope:%s", cacheKey, shopScope.Key())
 	}
 
 	var cached DashboardSummaryResponse
 	if err := s.cache.Get(ctx, cacheKey, &cached); err == nil {
-		// Return cached data if less than 5 minutes old
-		if time.Since(cached.GeneratedAt) < 5*time.Minute {
+		// Return cached data while it is within the dashboard TTL
+		if time.Since(cached.GeneratedAt) < s.cache.TTLs().Dashboard {
 			return &cached, nil
 		}
 	}
@@ -134,57 +156,68 @@ func (s *DashboardService) GetDashboardSummary(ctx context.Context, tenantID uui
 	tomorrow := today.AddDate(0, 0, 1)
 
 	// Get today's sales stats
-	if err := s.getTodaysSalesStats(tenantID, shopID, today, tomorrow, summary); err != nil {
+	if err := s.getTodaysSalesStats(ctx, tenantID, shopID, today, tomorrow, summary); err != nil {
 		return nil, fmt.Errorf("failed to get today's sales stats: %w", err)
 	}
 
 	// Get today's returns stats
-	if err := s.getTodaysReturnsStats(tenantID, shopID, today, tomorrow, summary); err != nil {
+	if err := s.getTodaysReturnsStats(ctx, tenantID, shopID, today, tomorrow, summary); err != nil {
 		return nil, fmt.Errorf("failed to get today's returns stats: %w", err)
 	}
 
 	// Get pending approvals count
-	if err := s.getPendingApprovalsCount(tenantID, shopID, summary); err != nil {
+	if err := s.getPendingApprovalsCount(ctx, tenantID, shopID, summary); err != nil {
 		return nil, fmt.Errorf("failed to get pending approvals: %w", err)
 	}
 
+	// Get unresolved cash drawer discrepancies
+	if err := s.getCashDiscrepancies(ctx, tenantID, shopID, summary); err != nil {
+		return nil, fmt.Errorf("failed to get cash discrepancies: %w", err)
+	}
+
 	// Get financial summary (this month)
-	if err := s.getFinancialSummary(tenantID, shopID, summary); err != nil {
+	if err := s.getFinancialSummary(ctx, tenantID, shopID, summary); err != nil {
 		return nil, fmt.Errorf("failed to get financial summary: %w", err)
 	}
 
+	// Get target vs actual (this month)
+	if err := s.getTargetSummary(ctx, tenantID, shopID, summary); err != nil {
+		return nil, fmt.Errorf("failed to get sales targets: %w", err)
+	}
+
 	// Get shop-wise breakdown
 	if shopID == nil { // Only for tenant-wide view
-		if err := s.getShopSummaries(tenantID, today, tomorrow, summary); err != nil {
+		if err := s.getShopSummaries(ctx, tenantID, today, tomorrow, summary); err != nil {
 			return nil, fmt.Errorf("failed to get shop summaries: %w", err)
 		}
 	}
 
 	// Get top products (this month)
-	if err := s.getTopProducts(tenantID, shopID, summary); err != nil {
+	if err := s.getTopProducts(ctx, tenantID, shopID, summary); err != nil {
 		return nil, fmt.Errorf("failed to get top products: %w", err)
 	}
 
 	// Get recent activities
-	if err := s.getRecentActivities(tenantID, shopID, summary); err != nil {
+	if err := s.getRecentActivities(ctx, tenantID, shopID, summary); err != nil {
 		return nil, fmt.Errorf("failed to get recent activities: %w", err)
 	}
 
-	// Cache the result for 5 minutes
-	s.cache.Set(ctx, cacheKey, summary, 5*time.Minute)
+	// Cache the result
+	s.cache.Set(ctx, cacheKey, summary, s.cache.TTLs().Dashboard)
 
 	return summary, nil
 }
 
 // getTodaysSalesStats gets today's sales statistics
-func (s *DashboardService) getTodaysSalesStats(tenantID uuid.UUID, shopID *uuid.UUID, today, tomorrow time.Time, summary *DashboardSummaryResponse) error {
+func (s *DashboardService) getTodaysSalesStats(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, today, tomorrow time.Time, summary *DashboardSummaryResponse) error {
 	// Daily sales records stats
 	dailySalesQuery := s.db.Model(&models.DailySalesRecord{}).
-		Where("tenant_id = ? AND record_date >= ? AND record_date < ?", tenantID, today, tomorrow)
+		Where("tenant_id = ? AND record_date >= ? AND record_date < ? AND status <> ?", tenantID, today, tomorrow, models.StatusVoided)
 	
 	if shopID != nil {
 		dailySalesQuery = dailySalesQuery.Where("shop_id = ?", *shopID)
 	}
+	dailySalesQuery = scope.FromContext(ctx).Apply(dailySalesQuery, "shop_id")
 
 	var dailySalesStats struct {
 		TotalRecords    int64   `gorm:"column:total_records"`
@@ -216,6 +249,7 @@ func (s *DashboardService) getTodaysSalesStats(tenantID uuid.UUID, shopID *uuid.
 	if shopID != nil {
 		individualSalesQuery = individualSalesQuery.Where("shop_id = ?", *shopID)
 	}
+	individualSalesQuery = scope.FromContext(ctx).Apply(individualSalesQuery, "shop_id")
 
 	var individualSalesStats struct {
 		TotalSales      int64   `gorm:"column:total_sales"`
@@ -240,12 +274,18 @@ func (s *DashboardService) getTodaysSalesStats(tenantID uuid.UUID, shopID *uuid.
 		return err
 	}
 
+	// Approved returns come off the revenue of the records they were raised against
+	returnedAmount, err := s.approvedDailyReturns(ctx, tenantID, shopID, today, tomorrow)
+	if err != nil {
+		return err
+	}
+
 	// Combine stats
 	summary.TodaySales = DailySalesStats{
 		TotalSales:     int(dailySalesStats.TotalRecords + individualSalesStats.TotalSales),
-		TotalAmount:    dailySalesStats.TotalAmount + individualSalesStats.TotalAmount,
+		TotalAmount:    dailySalesStats.TotalAmount - returnedAmount + individualSalesStats.TotalAmount,
 		ApprovedSales:  int(dailySalesStats.ApprovedRecords + individualSalesStats.ApprovedSales),
-		ApprovedAmount: dailySalesStats.ApprovedAmount + individualSalesStats.ApprovedAmount,
+		ApprovedAmount: dailySalesStats.ApprovedAmount - returnedAmount + individualSalesStats.ApprovedAmount,
 		PendingSales:   int(dailySalesStats.PendingRecords + individualSalesStats.PendingSales),
 		PendingAmount:  dailySalesStats.PendingAmount + individualSalesStats.PendingAmount,
 	}
@@ -254,13 +294,17 @@ func (s *DashboardService) getTodaysSalesStats(tenantID uuid.UUID, shopID *uuid.
 }
 
 // getTodaysReturnsStats gets today's returns statistics
-func (s *DashboardService) getTodaysReturnsStats(tenantID uuid.UUID, shopID *uuid.UUID, today, tomorrow time.Time, summary *DashboardSummaryResponse) error {
+func (s *DashboardService) getTodaysReturnsStats(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, today, tomorrow time.Time, summary *DashboardSummaryResponse) error {
 	query := s.db.Model(&models.SaleReturn{}).
 		Where("tenant_id = ? AND return_date >= ? AND return_date < ?", tenantID, today, tomorrow)
 
 	if shopID != nil {
-		query = query.Joins("JOIN sales ON sale_returns.sale_id = sales.id").
-			Where("sales.shop_id = ?", *shopID)
+		query = filterReturnsByShop(s.db.DB, query, func(shops *gorm.DB) *gorm.DB {
+			return shops.Where("shop_id = ?", *shopID)
+		})
+	}
+	if shopScope := scope.FromContext(ctx); shopScope != nil && shopScope.Restricted {
+		query = filterReturnsByShop(s.db.DB, query, shopScope.Filter("shop_id"))
 	}
 
 	var returnsStats struct {
@@ -299,7 +343,7 @@ func (s *DashboardService) getTodaysReturnsStats(tenantID uuid.UUID, shopID *uui
 }
 
 // getPendingApprovalsCount gets count of pending approvals
-func (s *DashboardService) getPendingApprovalsCount(tenantID uuid.UUID, shopID *uuid.UUID, summary *DashboardSummaryResponse) error {
+func (s *DashboardService) getPendingApprovalsCount(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, summary *DashboardSummaryResponse) error {
 	// Count pending daily sales records
 	dailySalesQuery := s.db.Model(&models.DailySalesRecord{}).
 		Where("tenant_id = ? AND status = ?", tenantID, models.StatusPending)
@@ -307,6 +351,7 @@ func (s *DashboardService) getPendingApprovalsCount(tenantID uuid.UUID, shopID *
 	if shopID != nil {
 		dailySalesQuery = dailySalesQuery.Where("shop_id = ?", *shopID)
 	}
+	dailySalesQuery = scope.FromContext(ctx).Apply(dailySalesQuery, "shop_id")
 
 	var pendingDailySales int64
 	if err := dailySalesQuery.Count(&pendingDailySales).Error; err != nil {
@@ -320,6 +365,7 @@ func (s *DashboardService) getPendingApprovalsCount(tenantID uuid.UUID, shopID *
 	if shopID != nil {
 		salesQuery = salesQuery.Where("shop_id = ?", *shopID)
 	}
+	salesQuery = scope.FromContext(ctx).Apply(salesQuery, "shop_id")
 
 	var pendingSales int64
 	if err := salesQuery.Count(&pendingSales).Error; err != nil {
@@ -331,8 +377,9 @@ func (s *DashboardService) getPendingApprovalsCount(tenantID uuid.UUID, shopID *
 		Where("tenant_id = ? AND status = ?", tenantID, models.StatusPending)
 
 	if shopID != nil {
-		returnsQuery = returnsQuery.Joins("JOIN sales ON sale_returns.sale_id = sales.id").
-			Where("sales.shop_id = ?", *shopID)
+		returnsQuery = filterReturnsByShop(s.db.DB, returnsQuery, func(shops *gorm.DB) *gorm.DB {
+			return shops.Where("shop_id = ?", *shopID)
+		})
 	}
 
 	var pendingReturns int64
@@ -340,26 +387,100 @@ func (s *DashboardService) getPendingApprovalsCount(tenantID uuid.UUID, shopID *
 		return err
 	}
 
+	// Expenses above the tenant's approval threshold
+	expensesQuery := s.db.Model(&models.Expense{}).
+		Where("tenant_id = ? AND status = ?", tenantID, models.StatusPending)
+
+	if shopID != nil {
+		expensesQuery = expensesQuery.Where("shop_id = ?", *shopID)
+	}
+	expensesQuery = scope.FromContext(ctx).Apply(expensesQuery, "shop_id")
+
+	var pendingExpenses struct {
+		Count  int64   `gorm:"column:count"`
+		Amount float64 `gorm:"column:amount"`
+	}
+	if err := expensesQuery.Select("COUNT(*) as count, COALESCE(SUM(amount), 0) as amount").Scan(&pendingExpenses).Error; err != nil {
+		return err
+	}
+
 	summary.PendingSales = int(pendingDailySales + pendingSales)
 	summary.PendingReturns = int(pendingReturns)
+	summary.PendingExpenses = int(pendingExpenses.Count)
+	summary.PendingExpenseAmount = pendingExpenses.Amount
+	// Records created while approval was required stay pending until decided, so
+	// they are still counted after the tenant turns approval off
+	summary.SalesApprovalRequired = s.settings.GetBool(ctx, tenantID, settings.KeySalesApprovalRequired)
+
+	return nil
+}
+
+// getCashDiscrepancies counts the over and short cash drawers still unresolved
+func (s *DashboardService) getCashDiscrepancies(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, summary *DashboardSummaryResponse) error {
+	query := s.db.Model(&models.CashDrawer{}).
+		Where("tenant_id = ? AND status <> ? AND resolved_at IS NULL", tenantID, "balanced")
+
+	if shopID != nil {
+		query = query.Where("shop_id = ?", *shopID)
+	}
+	query = scope.FromContext(ctx).Apply(query, "shop_id")
+
+	var discrepancies struct {
+		Count  int64   `gorm:"column:count"`
+		Amount float64 `gorm:"column:amount"`
+	}
+	if err := query.Select("COUNT(*) as count, COALESCE(SUM(difference), 0) as amount").Scan(&discrepancies).Error; err != nil {
+		return err
+	}
+
+	summary.CashDiscrepancies = int(discrepancies.Count)
+	summary.CashDiscrepancyAmount = utils.RoundToTwoDecimals(discrepancies.Amount)
 
 	return nil
 }
 
 // getFinancialSummary gets financial summary for current month
-func (s *DashboardService) getFinancialSummary(tenantID uuid.UUID, shopID *uuid.UUID, summary *DashboardSummaryResponse) error {
+func (s *DashboardService) getFinancialSummary(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, summary *DashboardSummaryResponse) error {
 	now := time.Now()
 	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
 	monthEnd := monthStart.AddDate(0, 1, 0)
 
+	totals, err := s.paymentTotals(ctx, tenantID, shopID, monthStart, monthEnd)
+	if err != nil {
+		return err
+	}
+
+	summary.TotalRevenue = totals.TotalRevenue
+	summary.TotalDue = totals.TotalDue
+	summary.CashAmount = totals.CashAmount
+	summary.CardAmount = totals.CardAmount
+	summary.UpiAmount = totals.UpiAmount
+	summary.CreditAmount = totals.CreditAmount
+
+	return nil
+}
+
+// paymentTotals holds approved revenue by payment method over a period
+type paymentTotals struct {
+	TotalRevenue float64
+	TotalDue     float64
+	CashAmount   float64
+	CardAmount   float64
+	UpiAmount    float64
+	CreditAmount float64
+}
+
+// paymentTotals totals approved revenue and its payment split between from and to
+func (s *DashboardService) paymentTotals(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, from, to time.Time) (*paymentTotals, error) {
 	// Get financial summary from daily sales records
 	dailySalesQuery := s.db.Model(&models.DailySalesRecord{}).
 		Where("tenant_id = ? AND status = ? AND record_date >= ? AND record_date < ?", 
-			tenantID, models.StatusApproved, monthStart, monthEnd)
+			tenantID, models.StatusApproved, from, to)
 	
 	if shopID != nil {
 		dailySalesQuery = dailySalesQuery.Where("shop_id = ?", *shopID)
 	}
+	dailySalesQuery = scope.FromContext(ctx).Apply(dailySalesQuery, "shop_id")
 
 	var dailyFinancial struct {
 		TotalRevenue  float64 `gorm:"column:total_revenue"`
@@ -378,17 +499,18 @@ func (s *DashboardService) getFinancialSummary(tenantID uuid.UUID, shopID *uuid.
 	`).Scan(&dailyFinancial).Error
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Get due amount from individual sales
 	salesQuery := s.db.Model(&models.Sale{}).
 		Where("tenant_id = ? AND status = ? AND sale_date >= ? AND sale_date < ?", 
-			tenantID, models.StatusApproved, monthStart, monthEnd)
+			tenantID, models.StatusApproved, from, to)
 	
 	if shopID != nil {
 		salesQuery = salesQuery.Where("shop_id = ?", *shopID)
 	}
+	salesQuery = scope.FromContext(ctx).Apply(salesQuery, "shop_id")
 
 	var salesFinancial struct {
 		TotalRevenue  float64 `gorm:"column:total_revenue"`
@@ -401,25 +523,60 @@ func (s *DashboardService) getFinancialSummary(tenantID uuid.UUID, shopID *uuid.
 	`).Scan(&salesFinancial).Error
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	summary.TotalRevenue = dailyFinancial.TotalRevenue + salesFinancial.TotalRevenue
-	summary.TotalDue = salesFinancial.TotalDue + dailyFinancial.CreditAmount
-	summary.CashAmount = dailyFinancial.CashAmount
-	summary.CardAmount = dailyFinancial.CardAmount
-	summary.UpiAmount = dailyFinancial.UpiAmount
-	summary.CreditAmount = dailyFinancial.CreditAmount
+	returnedAmount, err := s.approvedDailyReturns(ctx, tenantID, shopID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &paymentTotals{
+		TotalRevenue: dailyFinancial.TotalRevenue - returnedAmount + salesFinancial.TotalRevenue,
+		TotalDue:     salesFinancial.TotalDue + dailyFinancial.CreditAmount,
+		CashAmount:   dailyFinancial.CashAmount,
+		CardAmount:   dailyFinancial.CardAmount,
+		UpiAmount:    dailyFinancial.UpiAmount,
+		CreditAmount: dailyFinancial.CreditAmount,
+	}, nil
+}
+
+// getTargetSummary compares this month's sales targets with approved sales so far
+func (s *DashboardService) getTargetSummary(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, summary *DashboardSummaryResponse) error {
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	report, err := monthTargetAchievement(ctx, s.db.DB, tenantID, monthStart, shopID)
+	if err != nil {
+		return err
+	}
+	if len(report.Shops) > 0 {
+		summary.SalesTarget = &report.TargetAchievement
+	}
 
 	return nil
 }
 
+// approvedDailyReturns totals the approved returns against the approved daily sales
+// records of a period, which reduce those records' effective revenue
+func (s *DashboardService) approvedDailyReturns(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, from, to time.Time) (float64, error) {
+	records := s.approvedRecordIDs(ctx, tenantID, shopID, from, to)
+
+	var returnedAmount float64
+	err := s.db.Model(&models.SaleReturn{}).
+		Select("COALESCE(SUM(return_amount), 0)").
+		Where("tenant_id = ? AND status = ? AND daily_sales_record_id IN (?)", tenantID, models.StatusApproved, records).
+		Scan(&returnedAmount).Error
+
+	return returnedAmount, err
+}
+
 // getShopSummaries gets shop-wise summaries
-func (s *DashboardService) getShopSummaries(tenantID uuid.UUID, today, tomorrow time.Time, summary *DashboardSummaryResponse) error {
+func (s *DashboardService) getShopSummaries(ctx context.Context, tenantID uuid.UUID, today, tomorrow time.Time, summary *DashboardSummaryResponse) error {
 	// Get shop summaries from daily sales records
 	var shopSummaries []ShopSummary
 	
-	err := s.db.Model(&models.DailySalesRecord{}).
+	query := s.db.Model(&models.DailySalesRecord{}).
 		Select(`
 			shops.id as shop_id,
 			shops.name as shop_name,
@@ -429,9 +586,11 @@ func (s *DashboardService) getShopSummaries(tenantID uuid.UUID, today, tomorrow
 			COALESCE(SUM(CASE WHEN daily_sales_records.status = ? THEN daily_sales_records.total_sales_amount END), 0) as pending_amount
 		`, models.StatusPending, models.StatusPending).
 		Joins("JOIN shops ON daily_sales_records.shop_id = shops.id").
-		Where("daily_sales_records.tenant_id = ? AND daily_sales_records.record_date >= ? AND daily_sales_records.record_date < ?", 
-			tenantID, today, tomorrow).
-		Group("shops.id, shops.name").
+		Where("daily_sales_records.tenant_id = ? AND daily_sales_records.record_date >= ? AND daily_sales_records.record_date < ? AND daily_sales_records.status <> ?", 
+			tenantID, today, tomorrow, models.StatusVoided)
+	query = scope.FromContext(ctx).Apply(query, "daily_sales_records.shop_id")
+
+	err := query.Group("shops.id, shops.name").
 		Scan(&shopSummaries).Error
 
 	if err != nil {
@@ -443,11 +602,23 @@ func (s *DashboardService) getShopSummaries(tenantID uuid.UUID, today, tomorrow
 }
 
 // getTopProducts gets top-selling products for current month
-func (s *DashboardService) getTopProducts(tenantID uuid.UUID, shopID *uuid.UUID, summary *DashboardSummaryResponse) error {
+func (s *DashboardService) getTopProducts(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, summary *DashboardSummaryResponse) error {
 	now := time.Now()
 	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
 	monthEnd := monthStart.AddDate(0, 1, 0)
 
+	topProducts, err := s.topProducts(ctx, tenantID, shopID, monthStart, monthEnd, 10)
+	if err != nil {
+		return err
+	}
+
+	summary.TopProducts = topProducts
+	return nil
+}
+
+// topProducts returns the best-selling products by quantity on approved daily sales
+// records between from and to
+func (s *DashboardService) topProducts(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, from, to time.Time, limit int) ([]TopProductSummary, error) {
 	query := s.db.Model(&models.DailySalesItem{}).
 		Select(`
 			products.id as product_id,
@@ -462,28 +633,24 @@ func (s *DashboardService) getTopProducts(tenantID uuid.UUID, shopID *uuid.UUID,
 		Joins("LEFT JOIN brands ON products.brand_id = brands.id").
 		Joins("LEFT JOIN categories ON products.category_id = categories.id").
 		Where("daily_sales_items.tenant_id = ? AND daily_sales_records.status = ? AND daily_sales_records.record_date >= ? AND daily_sales_records.record_date < ?", 
-			tenantID, models.StatusApproved, monthStart, monthEnd)
+			tenantID, models.StatusApproved, from, to)
 
 	if shopID != nil {
 		query = query.Where("daily_sales_records.shop_id = ?", *shopID)
 	}
+	query = scope.FromContext(ctx).Apply(query, "daily_sales_records.shop_id")
 
 	var topProducts []TopProductSummary
 	err := query.Group("products.id, products.name, brands.name, categories.name").
 		Order("total_quantity DESC").
-		Limit(10).
+		Limit(limit).
 		Scan(&topProducts).Error
 
-	if err != nil {
-		return err
-	}
-
-	summary.TopProducts = topProducts
-	return nil
+	return topProducts, err
 }
 
 // getRecentActivities gets recent sale activities
-func (s *DashboardService) getRecentActivities(tenantID uuid.UUID, shopID *uuid.UUID, summary *DashboardSummaryResponse) error {
+func (s *DashboardService) getRecentActivities(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, summary *DashboardSummaryResponse) error {
 	var activities []RecentSaleActivity
 
 	// Get recent daily sales records
@@ -505,6 +672,7 @@ func (s *DashboardService) getRecentActivities(tenantID uuid.UUID, shopID *uuid.
 	if shopID != nil {
 		dailySalesQuery = dailySalesQuery.Where("daily_sales_records.shop_id = ?", *shopID)
 	}
+	dailySalesQuery = scope.FromContext(ctx).Apply(dailySalesQuery, "daily_sales_records.shop_id")
 
 	var dailyActivities []RecentSaleActivity
 	if err := dailySalesQuery.Order("daily_sales_records.created_at DESC").Limit(5).Scan(&dailyActivities).Error; err != nil {
@@ -532,6 +700,7 @@ func (s *DashboardService) getRecentActivities(tenantID uuid.UUID, shopID *uuid.
 	if shopID != nil {
 		salesQuery = salesQuery.Where("sales.shop_id = ?", *shopID)
 	}
+	salesQuery = scope.FromContext(ctx).Apply(salesQuery, "sales.shop_id")
 
 	var salesActivities []RecentSaleActivity
 	if err := salesQuery.Order("sales.created_at DESC").Limit(5).Scan(&salesActivities).Error; err != nil {
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/liquorpro/go-backend/pkg/shared/cache"
+	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+)
+
+// DashboardWarmer pre-populates each active tenant's dashboard summary shortly
+// before peak hours, so the managers who open it first at peak are served from
+// cache instead of all waiting on the same queries
+type DashboardWarmer struct {
+	db              *database.DB
+	cache           *cache.Cache
+	dashboard       *DashboardService
+	settings        *settings.Service
+	warmTimes       []time.Time // time of day only
+	defaultLocation *time.Location
+}
+
+// NewDashboardWarmer creates a dashboard warmer for the given HH:MM times of day,
+// in each tenant's timezone. Times that do not parse are logged and skipped.
+// defaultTimezone is used for tenants whose timezone setting cannot be loaded.
+func NewDashboardWarmer(db *database.DB, cache *cache.Cache, dashboard *DashboardService, settingsService *settings.Service, warmTimes []string, defaultTimezone string) *DashboardWarmer {
+	location, err := time.LoadLocation(defaultTimezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	warmer := &DashboardWarmer{
+		db:              db,
+		cache:           cache,
+		dashboard:       dashboard,
+		settings:        settingsService,
+		defaultLocation: location,
+	}
+	for _, value := range warmTimes {
+		at, err := time.Parse(settings.DailySummaryTimeLayout, value)
+		if err != nil {
+			log.Printf("Ignoring dashboard warm time %q: %v", value, err)
+			continue
+		}
+		warmer.warmTimes = append(warmer.warmTimes, at)
+	}
+	return warmer
+}
+
+// WarmDueDashboards warms the tenant-wide dashboard of every active tenant that
+// has reached one of its warm times and has not been warmed for it yet, and
+// returns how many were warmed. A warm time is skipped once the dashboard TTL has
+// passed since it, as the summary would already have expired. A tenant that
+// fails is logged and retried on the next run.
+func (w *DashboardWarmer) WarmDueDashboards(ctx context.Context) (int, error) {
+	if len(w.warmTimes) == 0 {
+		return 0, nil
+	}
+
+	var tenants []models.Tenant
+	if err := w.db.WithContext(ctx).Select("id").Where("is_active = ?", true).Find(&tenants).Error; err != nil {
+		return 0, fmt.Errorf("failed to get tenants: %w", err)
+	}
+
+	now := time.Now()
+	ttl := w.cache.TTLs().Dashboard
+	warmed := 0
+	for _, tenant := range tenants {
+		if ctx.Err() != nil {
+			break
+		}
+
+		location, err := time.LoadLocation(w.settings.GetString(ctx, tenant.ID, settings.KeyTimezone))
+		if err != nil {
+			location = w.defaultLocation
+		}
+		local := now.In(location)
+		today := utils.StartOfDay(local)
+
+		for _, warmTime := range w.warmTimes {
+			at := today.Add(time.Duration(warmTime.Hour())*time.Hour + time.Duration(warmTime.Minute())*time.Minute)
+			if local.Before(at) || !local.Before(at.Add(ttl)) {
+				continue
+			}
+
+			// Claim the warm time so other instances do not repeat it
+			key := fmt.Sprintf("dashboard_warm:%s:%s", tenant.ID.String(), at.Format("2006-01-02T15:04"))
+			claimed, err := w.cache.Lock(ctx, key, 48*time.Hour)
+			if err != nil {
+				return warmed, fmt.Errorf("failed to claim dashboard warm-up: %w", err)
+			}
+			if !claimed {
+				continue
+			}
+
+			if _, err := w.dashboard.GetDashboardSummary(ctx, tenant.ID, nil); err != nil {
+				w.cache.Unlock(ctx, key)
+				log.Printf("Dashboard warm-up for tenant %s failed: %v", tenant.ID, err)
+				continue
+			}
+			warmed++
+		}
+	}
+	return warmed, nil
+}
+
+// DashboardWarmWorker periodically warms dashboards whose warm time has come
+type DashboardWarmWorker struct {
+	warmer   *DashboardWarmer
+	interval time.Duration
+}
+
+// NewDashboardWarmWorker creates a worker that checks for due warm-ups at the given interval
+func NewDashboardWarmWorker(warmer *DashboardWarmer, interval time.Duration) *DashboardWarmWorker {
+	return &DashboardWarmWorker{
+		warmer:   warmer,
+		interval: interval,
+	}
+}
+
+// Start runs the check in the background until ctx is cancelled
+func (w *DashboardWarmWorker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			warmed, err := w.warmer.WarmDueDashboards(ctx)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("Dashboard warm-up run failed: %v", err)
+			} else if warmed > 0 {
+				log.Printf("Warmed %d dashboard(s)", warmed)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+	"gorm.io/gorm"
+)
+
+// MarginLine is the revenue, cost and gross margin of one product, category or shop
+type MarginLine struct {
+	ID            *uuid.UUID `json:"id"`
+	Name          string     `json:"name"`
+	SKU           string     `json:"sku,omitempty"`
+	Quantity      int        `json:"quantity"` // units sold less units returned
+	Revenue       float64    `json:"revenue"`
+	CostOfGoods   float64    `json:"cost_of_goods"`
+	GrossMargin   float64    `json:"gross_margin"`
+	MarginPercent float64    `json:"margin_percent"` // gross margin as a percent of revenue
+}
+
+// MarginReport breaks a period's gross margin down by product, category and shop
+type MarginReport struct {
+	ShopID        *uuid.UUID   `json:"shop_id,omitempty"`
+	StartDate     time.Time    `json:"start_date"`
+	EndDate       time.Time    `json:"end_date"`
+	Revenue       float64      `json:"revenue"`
+	CostOfGoods   float64      `json:"cost_of_goods"`
+	GrossMargin   float64      `json:"gross_margin"`
+	MarginPercent float64      `json:"margin_percent"`
+	Products      []MarginLine `json:"products"`
+	Categories    []MarginLine `json:"categories"`
+	Shops         []MarginLine `json:"shops"`
+	GeneratedAt   time.Time    `json:"generated_at"`
+}
+
+// marginQuantity is a quantity and amount of one product at one shop
+type marginQuantity struct {
+	ShopID    uuid.UUID
+	ProductID uuid.UUID
+	Quantity  int
+	Amount    float64
+}
+
+// marginProduct names a product sold in the period
+type marginProduct struct {
+	ShopID       uuid.UUID
+	ShopName     string
+	ProductID    uuid.UUID
+	ProductName  string
+	SKU          string
+	CategoryID   *uuid.UUID
+	CategoryName string
+	Quantity     int
+	Amount       float64
+}
+
+// GetMarginReport works out revenue, cost of goods sold and gross margin for the
+// approved daily sales records between start and end (end exclusive). Revenue is
+// what the records' items sold for; cost is what the stock movements recorded when
+// the goods left stock, under each stock's costing method. Approved returns against
+// those records take their revenue back out, along with the cost recorded when
+// their goods went back into stock.
+func (s *DashboardService) GetMarginReport(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, start, end time.Time) (*MarginReport, error) {
+	if !end.After(start) {
+		return nil, errors.New("end date must be after start date")
+	}
+
+	records := s.approvedRecordIDs(ctx, tenantID, shopID, start, end)
+	returns := s.db.Model(&models.SaleReturn{}).
+		Select("id").
+		Where("tenant_id = ? AND status = ? AND daily_sales_record_id IN (?)", tenantID, models.StatusApproved, records)
+
+	var sold []marginProduct
+	err := s.db.WithContext(ctx).Model(&models.DailySalesItem{}).
+		Select(`
+			daily_sales_records.shop_id, shops.name as shop_name,
+			products.id as product_id, products.name as product_name, products.sku,
+			products.category_id, COALESCE(categories.name, 'Uncategorized') as category_name,
+			SUM(daily_sales_items.quantity) as quantity,
+			SUM(daily_sales_items.total_amount) as amount
+		`).
+		Joins("JOIN daily_sales_records ON daily_sales_items.daily_sales_record_id = daily_sales_records.id").
+		Joins("JOIN shops ON daily_sales_records.shop_id = shops.id").
+		Joins("JOIN products ON daily_sales_items.product_id = products.id").
+		Joins("LEFT JOIN categories ON products.category_id = categories.id").
+		Where("daily_sales_items.daily_sales_record_id IN (?)", records).
+		Group("daily_sales_records.shop_id, shops.name, products.id, products.name, products.sku, products.category_id, categories.name").
+		Scan(&sold).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sold items: %w", err)
+	}
+
+	var returned []marginQuantity
+	err = s.db.WithContext(ctx).Model(&models.SaleReturnItem{}).
+		Select(`
+			daily_sales_records.shop_id, daily_sales_items.product_id,
+			SUM(sale_return_items.quantity) as quantity,
+			SUM(sale_return_items.total_amount) as amount
+		`).
+		Joins("JOIN daily_sales_items ON sale_return_items.daily_sales_item_id = daily_sales_items.id").
+		Joins("JOIN daily_sales_records ON daily_sales_items.daily_sales_record_id = daily_sales_records.id").
+		Where("sale_return_items.sale_return_id IN (?)", returns).
+		Group("daily_sales_records.shop_id, daily_sales_items.product_id").
+		Scan(&returned).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get returned items: %w", err)
+	}
+
+	soldCost, err := s.movementCost(ctx, tenantID, "sale", records)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cost of goods sold: %w", err)
+	}
+	returnedCost, err := s.movementCost(ctx, tenantID, "return", returns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cost of returns: %w", err)
+	}
+
+	type lineKey struct {
+		shopID    uuid.UUID
+		productID uuid.UUID
+	}
+	lines := make(map[lineKey]*marginProduct, len(sold))
+	costs := make(map[lineKey]float64, len(sold))
+	for i := range sold {
+		line := &sold[i]
+		lines[lineKey{shopID: line.ShopID, productID: line.ProductID}] = line
+	}
+	for _, q := range returned {
+		if line, ok := lines[lineKey{shopID: q.ShopID, productID: q.ProductID}]; ok {
+			line.Quantity -= q.Quantity
+			line.Amount -= q.Amount
+		}
+	}
+	for _, q := range soldCost {
+		costs[lineKey{shopID: q.ShopID, productID: q.ProductID}] += q.Amount
+	}
+	for _, q := range returnedCost {
+		costs[lineKey{shopID: q.ShopID, productID: q.ProductID}] -= q.Amount
+	}
+
+	report := &MarginReport{
+		ShopID:      shopID,
+		StartDate:   start,
+		EndDate:     end,
+		GeneratedAt: time.Now(),
+	}
+	products := make(map[uuid.UUID]*MarginLine)
+	categories := make(map[string]*MarginLine)
+	shops := make(map[uuid.UUID]*MarginLine)
+	for key, line := range lines {
+		cost := costs[key]
+
+		product, ok := products[line.ProductID]
+		if !ok {
+			productID := line.ProductID
+			product = &MarginLine{ID: &productID, Name: line.ProductName, SKU: line.SKU}
+			products[line.ProductID] = product
+		}
+		addMargin(product, line.Quantity, line.Amount, cost)
+
+		categoryKey := ""
+		if line.CategoryID != nil {
+			categoryKey = line.CategoryID.String()
+		}
+		category, ok := categories[categoryKey]
+		if !ok {
+			category = &MarginLine{ID: line.CategoryID, Name: line.CategoryName}
+			categories[categoryKey] = category
+		}
+		addMargin(category, line.Quantity, line.Amount, cost)
+
+		shop, ok := shops[line.ShopID]
+		if !ok {
+			shopID := line.ShopID
+			shop = &MarginLine{ID: &shopID, Name: line.ShopName}
+			shops[line.ShopID] = shop
+		}
+		addMargin(shop, line.Quantity, line.Amount, cost)
+
+		report.Revenue += line.Amount
+		report.CostOfGoods += cost
+	}
+
+	report.Revenue = utils.RoundToTwoDecimals(report.Revenue)
+	report.CostOfGoods = utils.RoundToTwoDecimals(report.CostOfGoods)
+	report.GrossMargin = utils.RoundToTwoDecimals(report.Revenue - report.CostOfGoods)
+	report.MarginPercent = marginPercent(report.GrossMargin, report.Revenue)
+	report.Products = sortedMarginLines(products)
+	report.Categories = sortedMarginLines(categories)
+	report.Shops = sortedMarginLines(shops)
+
+	return report, nil
+}
+
+// approvedRecordIDs selects the IDs of the approved daily sales records between from
+// and to that the caller may see
+func (s *DashboardService) approvedRecordIDs(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, from, to time.Time) *gorm.DB {
+	records := s.db.Model(&models.DailySalesRecord{}).
+		Select("id").
+		Where("tenant_id = ? AND status = ? AND record_date >= ? AND record_date < ?",
+			tenantID, models.StatusApproved, from, to)
+
+	if shopID != nil {
+		records = records.Where("shop_id = ?", *shopID)
+	}
+	return scope.FromContext(ctx).Apply(records, "shop_id")
+}
+
+// movementCost totals the cost recorded on stock movements of one type made for the
+// given documents, by shop and product
+func (s *DashboardService) movementCost(ctx context.Context, tenantID uuid.UUID, movementType string, referenceIDs *gorm.DB) ([]marginQuantity, error) {
+	var costs []marginQuantity
+	err := s.db.WithContext(ctx).Model(&models.StockHistory{}).
+		Select("stocks.shop_id, stocks.product_id, SUM(stock_histories.quantity) as quantity, SUM(stock_histories.total_cost) as amount").
+		Joins("JOIN stocks ON stock_histories.stock_id = stocks.id").
+		Where("stock_histories.tenant_id = ? AND stock_histories.movement_type = ? AND stock_histories.reference_id IN (?)",
+			tenantID, movementType, referenceIDs).
+		Group("stocks.shop_id, stocks.product_id").
+		Scan(&costs).Error
+	return costs, err
+}
+
+// addMargin adds a product line's figures to a report line
+func addMargin(line *MarginLine, quantity int, revenue, cost float64) {
+	line.Quantity += quantity
+	line.Revenue += revenue
+	line.CostOfGoods += cost
+}
+
+// marginPercent is gross margin as a percent of revenue, or zero without revenue
+func marginPercent(margin, revenue float64) float64 {
+	if revenue == 0 {
+		return 0
+	}
+	return utils.RoundToTwoDecimals(margin / revenue * 100)
+}
+
+// sortedMarginLines rounds the lines, works out their margins and orders them by
+// gross margin, most profitable first
+func sortedMarginLines[K comparable](lines map[K]*MarginLine) []MarginLine {
+	sorted := make([]MarginLine, 0, len(lines))
+	for _, line := range lines {
+		line.Revenue = utils.RoundToTwoDecimals(line.Revenue)
+		line.CostOfGoods = utils.RoundToTwoDecimals(line.CostOfGoods)
+		line.GrossMargin = utils.RoundToTwoDecimals(line.Revenue - line.CostOfGoods)
+		line.MarginPercent = marginPercent(line.GrossMargin, line.Revenue)
+		sorted = append(sorted, *line)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].GrossMargin != sorted[j].GrossMargin {
+			return sorted[i].GrossMargin > sorted[j].GrossMargin
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
@@ -0,0 +1,295 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/cache"
+	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+)
+
+// Sales report groupings
+const (
+	GroupByCategory = "category"
+	GroupByBrand    = "brand"
+)
+
+// ReportService handles sales analytics reports
+type ReportService struct {
+	db    *database.DB
+	cache *cache.Cache
+}
+
+// NewReportService creates a new report service
+func NewReportService(db *database.DB, cache *cache.Cache) *ReportService {
+	return &ReportService{
+		db:    db,
+		cache: cache,
+	}
+}
+
+// SalesGroupSummary represents sales for one category or brand
+type SalesGroupSummary struct {
+	GroupID       *uuid.UUID `json:"group_id"`
+	GroupName     string     `json:"group_name"`
+	ProductCount  int        `json:"product_count"`
+	TotalQuantity int        `json:"total_quantity"`
+	TotalRevenue  float64    `json:"total_revenue"`
+	QuantityShare float64    `json:"quantity_share"` // percent of all units sold
+	RevenueShare  float64    `json:"revenue_share"`  // percent of total revenue
+}
+
+// SalesByCategoryReport represents the grouped sales report
+type SalesByCategoryReport struct {
+	GroupBy       string              `json:"group_by"`
+	ShopID        *uuid.UUID          `json:"shop_id,omitempty"`
+	StartDate     time.Time           `json:"start_date"`
+	EndDate       time.Time           `json:"end_date"`
+	TotalQuantity int                 `json:"total_quantity"`
+	TotalRevenue  float64             `json:"total_revenue"`
+	Groups        []SalesGroupSummary `json:"groups"`
+	GeneratedAt   time.Time           `json:"generated_at"`
+}
+
+// GetSalesByCategory aggregates approved daily sales items between start and end
+// (end exclusive) by product category or brand
+func (s *ReportService) GetSalesByCategory(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, start, end time.Time, groupBy string) (*SalesByCategoryReport, error) {
+	var groupTable, groupColumn, ungrouped string
+	switch groupBy {
+	case "", GroupByCategory:
+		groupBy = GroupByCategory
+		groupTable, groupColumn, ungrouped = "categories", "products.category_id", "Uncategorized"
+	case GroupByBrand:
+		groupTable, groupColumn, ungrouped = "brands", "products.brand_id", "Unbranded"
+	default:
+		return nil, errors.New("group_by must be category or brand")
+	}
+
+	if !end.After(start) {
+		return nil, errors.New("end date must be after start date")
+	}
+
+	query := s.db.WithContext(ctx).Model(&models.DailySalesItem{}).
+		Select(fmt.Sprintf(`
+			%[1]s as group_id,
+			COALESCE(%[2]s.name, '%[3]s') as group_name,
+			COUNT(DISTINCT products.id) as product_count,
+			SUM(daily_sales_items.quantity) as total_quantity,
+			SUM(daily_sales_items.total_amount) as total_revenue
+		`, groupColumn, groupTable, ungrouped)).
+		Joins("JOIN daily_sales_records ON daily_sales_items.daily_sales_record_id = daily_sales_records.id").
+		Joins("JOIN products ON daily_sales_items.product_id = products.id").
+		Joins(fmt.Sprintf("LEFT JOIN %[1]s ON %[2]s = %[1]s.id", groupTable, groupColumn)).
+		Where("daily_sales_items.tenant_id = ? AND daily_sales_records.status = ? AND daily_sales_records.record_date >= ? AND daily_sales_records.record_date < ?",
+			tenantID, models.StatusApproved, start, end).
+		Where("daily_sales_records.deleted_at IS NULL")
+
+	if shopID != nil {
+		query = query.Where("daily_sales_records.shop_id = ?", *shopID)
+	}
+	query = scope.FromContext(ctx).Apply(query, "daily_sales_records.shop_id")
+
+	var groups []SalesGroupSummary
+	err := query.Group(fmt.Sprintf("%s, %s.name", groupColumn, groupTable)).
+		Order("total_revenue DESC").
+		Scan(&groups).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sales by %s: %w", groupBy, err)
+	}
+
+	report := &SalesByCategoryReport{
+		GroupBy:     groupBy,
+		ShopID:      shopID,
+		StartDate:   start,
+		EndDate:     end,
+		Groups:      groups,
+		GeneratedAt: time.Now(),
+	}
+	if report.Groups == nil {
+		report.Groups = []SalesGroupSummary{}
+	}
+
+	for _, group := range report.Groups {
+		report.TotalQuantity += group.TotalQuantity
+		report.TotalRevenue += group.TotalRevenue
+	}
+	report.TotalRevenue = utils.RoundToTwoDecimals(report.TotalRevenue)
+
+	for i := range report.Groups {
+		group := &report.Groups[i]
+		group.TotalRevenue = utils.RoundToTwoDecimals(group.TotalRevenue)
+		if report.TotalQuantity > 0 {
+			group.QuantityShare = utils.RoundToTwoDecimals(float64(group.TotalQuantity) * 100 / float64(report.TotalQuantity))
+		}
+		if report.TotalRevenue > 0 {
+			group.RevenueShare = utils.RoundToTwoDecimals(group.TotalRevenue * 100 / report.TotalRevenue)
+		}
+	}
+
+	return report, nil
+}
+
+// Reconciliation line statuses
+const (
+	ReconciliationMatched       = "matched"
+	ReconciliationUnderDepleted = "under_depleted" // sold more than left stock
+	ReconciliationOverDepleted  = "over_depleted"  // stock left without an approved sale
+)
+
+// SalesStockReconciliationLine compares sold and depleted quantities for one product in one shop
+type SalesStockReconciliationLine struct {
+	ShopID           uuid.UUID `json:"shop_id"`
+	ShopName         string    `json:"shop_name"`
+	ProductID        uuid.UUID `json:"product_id"`
+	ProductName      string    `json:"product_name"`
+	SKU              string    `json:"sku"`
+	SoldQuantity     int       `json:"sold_quantity"`
+	DepletedQuantity int       `json:"depleted_quantity"`
+	Difference       int       `json:"difference"` // depleted minus sold
+	Status           string    `json:"status"`
+}
+
+// SalesStockReconciliationReport represents the sales vs stock depletion control report
+type SalesStockReconciliationReport struct {
+	Date                  time.Time                      `json:"date"`
+	ShopID                *uuid.UUID                     `json:"shop_id,omitempty"`
+	TotalSoldQuantity     int                            `json:"total_sold_quantity"`
+	TotalDepletedQuantity int                            `json:"total_depleted_quantity"`
+	DiscrepancyCount      int                            `json:"discrepancy_count"`
+	Lines                 []SalesStockReconciliationLine `json:"lines"`
+	GeneratedAt           time.Time                      `json:"generated_at"`
+}
+
+type reconciliationQuantity struct {
+	ShopID      uuid.UUID
+	ShopName    string
+	ProductID   uuid.UUID
+	ProductName string
+	SKU         string
+	Quantity    int
+}
+
+// GetSalesStockReconciliation compares approved daily sales quantities for a day with
+// the sale movements recorded against stock that day, flagging products where they differ
+func (s *ReportService) GetSalesStockReconciliation(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID, date time.Time) (*SalesStockReconciliationReport, error) {
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	end := start.AddDate(0, 0, 1)
+
+	soldQuery := s.db.WithContext(ctx).Model(&models.DailySalesItem{}).
+		Select(`daily_sales_records.shop_id, shops.name as shop_name,
+			daily_sales_items.product_id, products.name as product_name, products.sku,
+			SUM(daily_sales_items.quantity) as quantity`).
+		Joins("JOIN daily_sales_records ON daily_sales_items.daily_sales_record_id = daily_sales_records.id").
+		Joins("JOIN shops ON daily_sales_records.shop_id = shops.id").
+		Joins("JOIN products ON daily_sales_items.product_id = products.id").
+		Where("daily_sales_items.tenant_id = ? AND daily_sales_records.status = ? AND daily_sales_records.record_date >= ? AND daily_sales_records.record_date < ?",
+			tenantID, models.StatusApproved, start, end).
+		Where("daily_sales_records.deleted_at IS NULL")
+	if shopID != nil {
+		soldQuery = soldQuery.Where("daily_sales_records.shop_id = ?", *shopID)
+	}
+	soldQuery = scope.FromContext(ctx).Apply(soldQuery, "daily_sales_records.shop_id")
+
+	var sold []reconciliationQuantity
+	err := soldQuery.Group("daily_sales_records.shop_id, shops.name, daily_sales_items.product_id, products.name, products.sku").
+		Scan(&sold).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sold quantities: %w", err)
+	}
+
+	depletedQuery := s.db.WithContext(ctx).Model(&models.StockHistory{}).
+		Select(`stocks.shop_id, shops.name as shop_name,
+			stocks.product_id, products.name as product_name, products.sku,
+			SUM(stock_histories.quantity) as quantity`).
+		Joins("JOIN stocks ON stock_histories.stock_id = stocks.id").
+		Joins("JOIN shops ON stocks.shop_id = shops.id").
+		Joins("JOIN products ON stocks.product_id = products.id").
+		Where("stock_histories.tenant_id = ? AND stock_histories.movement_type = ? AND stock_histories.created_at >= ? AND stock_histories.created_at < ?",
+			tenantID, "sale", start, end)
+	if shopID != nil {
+		depletedQuery = depletedQuery.Where("stocks.shop_id = ?", *shopID)
+	}
+	depletedQuery = scope.FromContext(ctx).Apply(depletedQuery, "stocks.shop_id")
+
+	var depleted []reconciliationQuantity
+	err = depletedQuery.Group("stocks.shop_id, shops.name, stocks.product_id, products.name, products.sku").
+		Scan(&depleted).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get depleted quantities: %w", err)
+	}
+
+	type lineKey struct {
+		shopID    uuid.UUID
+		productID uuid.UUID
+	}
+	lines := make(map[lineKey]*SalesStockReconciliationLine)
+	order := make([]lineKey, 0, len(sold)+len(depleted))
+	lineFor := func(q reconciliationQuantity) *SalesStockReconciliationLine {
+		key := lineKey{shopID: q.ShopID, productID: q.ProductID}
+		line, ok := lines[key]
+		if !ok {
+			line = &SalesStockReconciliationLine{
+				ShopID:      q.ShopID,
+				ShopName:    q.ShopName,
+				ProductID:   q.ProductID,
+				ProductName: q.ProductName,
+				SKU:         q.SKU,
+			}
+			lines[key] = line
+			order = append(order, key)
+		}
+		return line
+	}
+	for _, q := range sold {
+		lineFor(q).SoldQuantity += q.Quantity
+	}
+	for _, q := range depleted {
+		lineFor(q).DepletedQuantity += q.Quantity
+	}
+
+	report := &SalesStockReconciliationReport{
+		Date:        start,
+		ShopID:      shopID,
+		Lines:       make([]SalesStockReconciliationLine, 0, len(order)),
+		GeneratedAt: time.Now(),
+	}
+	for _, key := range order {
+		line := lines[key]
+		line.Difference = line.DepletedQuantity - line.SoldQuantity
+		switch {
+		case line.Difference == 0:
+			line.Status = ReconciliationMatched
+		case line.Difference < 0:
+			line.Status = ReconciliationUnderDepleted
+		default:
+			line.Status = ReconciliationOverDepleted
+		}
+		if line.Difference != 0 {
+			report.DiscrepancyCount++
+		}
+		report.TotalSoldQuantity += line.SoldQuantity
+		report.TotalDepletedQuantity += line.DepletedQuantity
+		report.Lines = append(report.Lines, *line)
+	}
+
+	// Discrepancies first, largest first
+	sort.SliceStable(report.Lines, func(i, j int) bool {
+		return absInt(report.Lines[i].Difference) > absInt(report.Lines[j].Difference)
+	})
+
+	return report, nil
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -7,24 +7,34 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	inventory "github.com/liquorpro/go-backend/internal/inventory/services"
+	"github.com/liquorpro/go-backend/pkg/shared/approval"
 	"github.com/liquorpro/go-backend/pkg/shared/cache"
 	"github.com/liquorpro/go-backend/pkg/shared/database"
 	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/money"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
 	"github.com/liquorpro/go-backend/pkg/shared/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ReturnsService handles sale return operations
 type ReturnsService struct {
-	db    *database.DB
-	cache *cache.Cache
+	db       *database.DB
+	cache    *cache.Cache
+	settings *settings.Service
+	stocks   *inventory.StockService
 }
 
 // NewReturnsService creates a new returns service
-func NewReturnsService(db *database.DB, cache *cache.Cache) *ReturnsService {
+func NewReturnsService(db *database.DB, cache *cache.Cache, settingsService *settings.Service, stockService *inventory.StockService) *ReturnsService {
 	return &ReturnsService{
-		db:    db,
-		cache: cache,
+		db:       db,
+		cache:    cache,
+		settings: settingsService,
+		stocks:   stockService,
 	}
 }
 
@@ -45,12 +55,28 @@ type SaleReturnItemRequest struct {
 	Reason      string    `json:"reason"`
 }
 
+// DailySalesReturnRequest represents a return against a daily sales record
+type DailySalesReturnRequest struct {
+	ReturnDate time.Time                     `json:"return_date" binding:"required"`
+	Reason     string                        `json:"reason" binding:"required"`
+	Notes      string                        `json:"notes"`
+	Items      []DailySalesReturnItemRequest `json:"items" binding:"required,min=1"`
+}
+
+// DailySalesReturnItemRequest represents a product returned from a daily sales record
+type DailySalesReturnItemRequest struct {
+	ProductID uuid.UUID `json:"product_id" binding:"required"`
+	Quantity  int       `json:"quantity" binding:"required,gt=0"`
+	Reason    string    `json:"reason"`
+}
+
 // SaleReturnResponse represents sale return in responses
 type SaleReturnResponse struct {
 	ID            uuid.UUID                `json:"id"`
 	ReturnNumber  string                   `json:"return_number"`
-	SaleID        uuid.UUID                `json:"sale_id"`
+	SaleID        *uuid.UUID               `json:"sale_id"`
 	SaleNumber    string                   `json:"sale_number"`
+	DailySalesRecordID *uuid.UUID          `json:"daily_sales_record_id"`
 	ReturnDate    time.Time                `json:"return_date"`
 	ReturnAmount  float64                  `json:"return_amount"`
 	Reason        string                   `json:"reason"`
@@ -68,7 +94,8 @@ type SaleReturnResponse struct {
 // SaleReturnItemResponse represents return item in responses
 type SaleReturnItemResponse struct {
 	ID           uuid.UUID `json:"id"`
-	SaleItemID   uuid.UUID `json:"sale_item_id"`
+	SaleItemID   *uuid.UUID `json:"sale_item_id"`
+	DailySalesItemID *uuid.UUID `json:"daily_sales_item_id"`
 	ProductID    uuid.UUID `json:"product_id"`
 	ProductName  string    `json:"product_name"`
 	BrandName    string    `json:"brand_name"`
@@ -99,6 +126,9 @@ func (s *ReturnsService) CreateSaleReturn(ctx context.Context, req SaleReturnReq
 	if sale.Status != models.StatusApproved {
 		return nil, errors.New("can only return items from approved sales")
 	}
+	if err := s.checkReturnWindow(ctx, tenantID, sale.SaleDate); err != nil {
+		return nil, err
+	}
 
 	// Create map of sale items for validation
 	saleItemsMap := make(map[uuid.UUID]*models.SaleItem)
@@ -131,7 +161,7 @@ func (s *ReturnsService) CreateSaleReturn(ctx context.Context, req SaleReturnReq
 		saleReturn = &models.SaleReturn{
 			TenantModel:  models.TenantModel{TenantID: tenantID},
 			ReturnNumber: utils.GenerateReturnNumber(),
-			SaleID:       req.SaleID,
+			SaleID:       &sale.ID,
 			ReturnDate:   req.ReturnDate,
 			ReturnAmount: totalReturnAmount,
 			Reason:       req.Reason,
@@ -147,11 +177,12 @@ func (s *ReturnsService) CreateSaleReturn(ctx context.Context, req SaleReturnReq
 		// Create return items
 		for _, itemReq := range req.Items {
 			totalAmount := float64(itemReq.Quantity) * itemReq.UnitPrice
+			saleItemID := itemReq.SaleItemID
 
 			returnItem := models.SaleReturnItem{
 				TenantModel:  models.TenantModel{TenantID: tenantID},
 				SaleReturnID: saleReturn.ID,
-				SaleItemID:   itemReq.SaleItemID,
+				SaleItemID:   &saleItemID,
 				Quantity:     itemReq.Quantity,
 				UnitPrice:    itemReq.UnitPrice,
 				TotalAmount:  totalAmount,
@@ -177,6 +208,160 @@ func (s *ReturnsService) CreateSaleReturn(ctx context.Context, req SaleReturnReq
 	return s.GetSaleReturnByID(ctx, saleReturn.ID, tenantID)
 }
 
+// checkReturnWindow rejects a return made later than the tenant's return window
+// allows after the day of the sale
+func (s *ReturnsService) checkReturnWindow(ctx context.Context, tenantID uuid.UUID, soldOn time.Time) error {
+	days := s.settings.GetInt(ctx, tenantID, settings.KeyReturnWindowDays)
+	if !time.Now().Before(utils.StartOfDay(soldOn).AddDate(0, 0, days+1)) {
+		return fmt.Errorf("returns are only accepted within %d days of the sale", days)
+	}
+	return nil
+}
+
+// CreateDailySalesReturn creates a pending return of products sold in an approved daily
+// sales record. Quantities are checked against what the record sold, less whatever
+// earlier pending or approved returns have already taken back.
+func (s *ReturnsService) CreateDailySalesReturn(ctx context.Context, recordID uuid.UUID, req DailySalesReturnRequest, tenantID, createdByID uuid.UUID) (*SaleReturnResponse, error) {
+	var record models.DailySalesRecord
+	var saleReturn *models.SaleReturn
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the record so concurrent returns cannot both take back the same goods
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND tenant_id = ?", recordID, tenantID).
+			First(&record).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("daily sales record not found")
+			}
+			return fmt.Errorf("failed to find daily sales record: %w", err)
+		}
+
+		// Only approved records can have returns
+		if record.Status != models.StatusApproved {
+			return errors.New("can only return items from approved daily sales records")
+		}
+		if err := s.checkReturnWindow(ctx, tenantID, record.RecordDate); err != nil {
+			return err
+		}
+
+		var items []models.DailySalesItem
+		if err := tx.Where("daily_sales_record_id = ? AND tenant_id = ?", record.ID, tenantID).
+			Preload("Product").
+			Find(&items).Error; err != nil {
+			return fmt.Errorf("failed to get daily sales items: %w", err)
+		}
+
+		// Total up what was sold per product
+		soldItems := make(map[uuid.UUID]*models.DailySalesItem)
+		soldQuantity := make(map[uuid.UUID]int)
+		soldAmount := make(map[uuid.UUID]money.Money)
+		for i := range items {
+			item := &items[i]
+			if _, exists := soldItems[item.ProductID]; !exists {
+				soldItems[item.ProductID] = item
+			}
+			soldQuantity[item.ProductID] += item.Quantity
+			soldAmount[item.ProductID] += item.TotalAmount
+		}
+
+		// Quantities already taken back by returns that still stand
+		var returned []struct {
+			ProductID uuid.UUID
+			Quantity  int
+		}
+		err = tx.Model(&models.SaleReturnItem{}).
+			Select("daily_sales_items.product_id, COALESCE(SUM(sale_return_items.quantity), 0) as quantity").
+			Joins("JOIN sale_returns ON sale_returns.id = sale_return_items.sale_return_id").
+			Joins("JOIN daily_sales_items ON daily_sales_items.id = sale_return_items.daily_sales_item_id").
+			Where("sale_returns.daily_sales_record_id = ? AND sale_returns.status IN ?",
+				record.ID, []string{models.StatusPending, models.StatusApproved}).
+			Group("daily_sales_items.product_id").
+			Scan(&returned).Error
+		if err != nil {
+			return fmt.Errorf("failed to get previous returns: %w", err)
+		}
+
+		available := make(map[uuid.UUID]int, len(soldQuantity))
+		for productID, quantity := range soldQuantity {
+			available[productID] = quantity
+		}
+		for _, r := range returned {
+			available[r.ProductID] -= r.Quantity
+		}
+
+		// Validate return items and calculate total
+		returnItems := make([]models.SaleReturnItem, 0, len(req.Items))
+		var totalReturnAmount float64
+		for _, itemReq := range req.Items {
+			soldItem, exists := soldItems[itemReq.ProductID]
+			if !exists {
+				return fmt.Errorf("product %s was not sold in this daily sales record", itemReq.ProductID)
+			}
+
+			productName := itemReq.ProductID.String()
+			if soldItem.Product != nil {
+				productName = soldItem.Product.Name
+			}
+			if itemReq.Quantity > available[itemReq.ProductID] {
+				return fmt.Errorf("return quantity (%d) exceeds the returnable quantity (%d) for %s",
+					itemReq.Quantity, available[itemReq.ProductID], productName)
+			}
+			available[itemReq.ProductID] -= itemReq.Quantity
+
+			// Refund at the average price the product sold for in the record
+			unitPrice := soldAmount[itemReq.ProductID].Float64() / float64(soldQuantity[itemReq.ProductID])
+			totalAmount := float64(itemReq.Quantity) * unitPrice
+			dailySalesItemID := soldItem.ID
+
+			returnItems = append(returnItems, models.SaleReturnItem{
+				TenantModel:      models.TenantModel{TenantID: tenantID},
+				DailySalesItemID: &dailySalesItemID,
+				Quantity:         itemReq.Quantity,
+				UnitPrice:        unitPrice,
+				TotalAmount:      totalAmount,
+				Reason:           itemReq.Reason,
+			})
+			totalReturnAmount += totalAmount
+		}
+
+		saleReturn = &models.SaleReturn{
+			TenantModel:        models.TenantModel{TenantID: tenantID},
+			ReturnNumber:       utils.GenerateReturnNumber(),
+			DailySalesRecordID: &record.ID,
+			ReturnDate:         req.ReturnDate,
+			ReturnAmount:       totalReturnAmount,
+			Reason:             req.Reason,
+			Status:             models.StatusPending,
+			CreatedByID:        createdByID,
+			Notes:              req.Notes,
+		}
+
+		if err := tx.Create(&saleReturn).Error; err != nil {
+			return fmt.Errorf("failed to create sale return: %w", err)
+		}
+
+		for i := range returnItems {
+			returnItems[i].SaleReturnID = saleReturn.ID
+			if err := tx.Create(&returnItems[i]).Error; err != nil {
+				return fmt.Errorf("failed to create return item: %w", err)
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Clear cache
+	s.clearReturnsCache(ctx, tenantID, record.ShopID)
+
+	// Return created return
+	return s.GetSaleReturnByID(ctx, saleReturn.ID, tenantID)
+}
+
 // GetSaleReturns returns paginated list of sale returns
 func (s *ReturnsService) GetSaleReturns(ctx context.Context, tenantID uuid.UUID, filters ReturnsFilters) (*ReturnsListResponse, error) {
 	var returns []models.SaleReturn
@@ -184,20 +369,22 @@ func (s *ReturnsService) GetSaleReturns(ctx context.Context, tenantID uuid.UUID,
 
 	query := s.db.Model(&models.SaleReturn{}).
 		Where("tenant_id = ?", tenantID).
-		Preload("Sale.Shop").
-		Preload("Sale.Salesman").
-		Preload("CreatedBy").
-		Preload("ApprovedBy").
-		Preload("Items.SaleItem.Product.Brand").
-		Preload("Items.SaleItem.Product.Category")
+		Scopes(preloadSaleReturn)
 
 	// Apply filters
 	if filters.SaleID != uuid.Nil {
 		query = query.Where("sale_id = ?", filters.SaleID)
 	}
+	if filters.DailySalesRecordID != uuid.Nil {
+		query = query.Where("daily_sales_record_id = ?", filters.DailySalesRecordID)
+	}
 	if filters.ShopID != uuid.Nil {
-		query = query.Joins("JOIN sales ON sale_returns.sale_id = sales.id").
-			Where("sales.shop_id = ?", filters.ShopID)
+		query = filterReturnsByShop(s.db.DB, query, func(shops *gorm.DB) *gorm.DB {
+			return shops.Where("shop_id = ?", filters.ShopID)
+		})
+	}
+	if shopScope := scope.FromContext(ctx); shopScope != nil && shopScope.Restricted {
+		query = filterReturnsByShop(s.db.DB, query, shopScope.Filter("shop_id"))
 	}
 	if filters.Status != "" {
 		query = query.Where("status = ?", filters.Status)
@@ -245,12 +432,7 @@ func (s *ReturnsService) GetSaleReturnByID(ctx context.Context, returnID, tenant
 	var saleReturn models.SaleReturn
 	
 	err := s.db.Where("id = ? AND tenant_id = ?", returnID, tenantID).
-		Preload("Sale.Shop").
-		Preload("Sale.Salesman").
-		Preload("CreatedBy").
-		Preload("ApprovedBy").
-		Preload("Items.SaleItem.Product.Brand").
-		Preload("Items.SaleItem.Product.Category").
+		Scopes(preloadSaleReturn).
 		First(&saleReturn).Error
 	
 	if err != nil {
@@ -263,28 +445,20 @@ func (s *ReturnsService) GetSaleReturnByID(ctx context.Context, returnID, tenant
 	return s.mapSaleReturnToResponse(&saleReturn), nil
 }
 
-// ApproveSaleReturn approves a sale return
+// ApproveSaleReturn approves a sale return. The return is locked and its status
+// re-checked inside the transaction, so concurrent decisions take effect once.
 func (s *ReturnsService) ApproveSaleReturn(ctx context.Context, returnID, tenantID, approvedByID uuid.UUID) (*SaleReturnResponse, error) {
 	var saleReturn models.SaleReturn
-	
-	err := s.db.Where("id = ? AND tenant_id = ?", returnID, tenantID).
-		Preload("Sale").
-		First(&saleReturn).Error
-	
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("sale return not found")
-		}
-		return nil, fmt.Errorf("failed to find sale return: %w", err)
-	}
-
-	// Only pending returns can be approved
-	if saleReturn.Status != models.StatusPending {
-		return nil, errors.New("only pending returns can be approved")
-	}
 
 	// Start transaction for approval
-	err = s.db.Transaction(func(tx *gorm.DB) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.lockSaleReturn(tx, &saleReturn, returnID, tenantID); err != nil {
+			return err
+		}
+		if err := approval.Decide("sale return", saleReturn.Status, models.StatusApproved); err != nil {
+			return err
+		}
+
 		// Update return status
 		now := time.Now()
 		updates := map[string]interface{}{
@@ -297,7 +471,13 @@ func (s *ReturnsService) ApproveSaleReturn(ctx context.Context, returnID, tenant
 			return fmt.Errorf("failed to approve return: %w", err)
 		}
 
-		// TODO: Update stock quantities (add returned items back to stock)
+		// Goods returned from a daily sales record go back into the shop's stock
+		if saleReturn.DailySalesRecord != nil {
+			if err := s.restockDailySalesReturn(tx, &saleReturn, approvedByID); err != nil {
+				return err
+			}
+		}
+
 		// TODO: Process refund if needed
 		// TODO: Update sale's due amount if partial refund
 
@@ -305,68 +485,91 @@ func (s *ReturnsService) ApproveSaleReturn(ctx context.Context, returnID, tenant
 	})
 
 	if err != nil {
+		if approval.Replay(ctx, s.settings, tenantID, err) {
+			return s.GetSaleReturnByID(ctx, returnID, tenantID)
+		}
 		return nil, err
 	}
 
 	// Clear cache
-	s.clearReturnsCache(ctx, tenantID, saleReturn.Sale.ShopID)
+	s.clearReturnsCache(ctx, tenantID, returnShopID(&saleReturn))
+	if saleReturn.DailySalesRecord != nil {
+		s.stocks.ClearSaleStockCache(ctx, tenantID, saleReturn.DailySalesRecord.ShopID, dailySalesReturnStockItems(&saleReturn))
+	}
 
 	// Return updated return
 	return s.GetSaleReturnByID(ctx, returnID, tenantID)
 }
 
-// RejectSaleReturn rejects a sale return
+// RejectSaleReturn rejects a sale return under the same lock as approval
 func (s *ReturnsService) RejectSaleReturn(ctx context.Context, returnID, tenantID, rejectedByID uuid.UUID, reason string) error {
 	var saleReturn models.SaleReturn
-	
-	err := s.db.Where("id = ? AND tenant_id = ?", returnID, tenantID).
-		Preload("Sale").
-		First(&saleReturn).Error
-	
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("sale return not found")
-		}
-		return fmt.Errorf("failed to find sale return: %w", err)
-	}
 
-	// Only pending returns can be rejected
-	if saleReturn.Status != models.StatusPending {
-		return errors.New("only pending returns can be rejected")
-	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.lockSaleReturn(tx, &saleReturn, returnID, tenantID); err != nil {
+			return err
+		}
+		if err := approval.Decide("sale return", saleReturn.Status, models.StatusRejected); err != nil {
+			return err
+		}
 
-	// Update return status
-	now := time.Now()
-	updates := map[string]interface{}{
-		"status":         models.StatusRejected,
-		"approved_at":    now,
-		"approved_by_id": rejectedByID,
-		"notes":          saleReturn.Notes + " | Rejection reason: " + reason,
-	}
+		// Update return status
+		now := time.Now()
+		updates := map[string]interface{}{
+			"status":         models.StatusRejected,
+			"approved_at":    now,
+			"approved_by_id": rejectedByID,
+			"notes":          saleReturn.Notes + " | Rejection reason: " + reason,
+		}
 
-	if err := s.db.Model(&saleReturn).Updates(updates).Error; err != nil {
-		return fmt.Errorf("failed to reject return: %w", err)
+		if err := tx.Model(&saleReturn).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to reject return: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		if approval.Replay(ctx, s.settings, tenantID, err) {
+			return nil
+		}
+		return err
 	}
 
 	// Clear cache
-	s.clearReturnsCache(ctx, tenantID, saleReturn.Sale.ShopID)
+	s.clearReturnsCache(ctx, tenantID, returnShopID(&saleReturn))
 
 	return nil
 }
 
+// lockSaleReturn loads a sale return with a row lock held until the transaction ends
+func (s *ReturnsService) lockSaleReturn(tx *gorm.DB, saleReturn *models.SaleReturn, returnID, tenantID uuid.UUID) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND tenant_id = ?", returnID, tenantID).
+		Preload("Sale").
+		Preload("DailySalesRecord").
+		Preload("Items.DailySalesItem").
+		First(saleReturn).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("sale return not found")
+		}
+		return fmt.Errorf("failed to find sale return: %w", err)
+	}
+	return nil
+}
+
 // GetPendingReturns returns pending returns requiring approval
 func (s *ReturnsService) GetPendingReturns(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID) ([]*SaleReturnResponse, error) {
 	query := s.db.Model(&models.SaleReturn{}).
 		Where("tenant_id = ? AND status = ?", tenantID, models.StatusPending).
-		Preload("Sale.Shop").
-		Preload("Sale.Salesman").
-		Preload("CreatedBy").
-		Preload("Items.SaleItem.Product.Brand").
-		Preload("Items.SaleItem.Product.Category")
+		Scopes(preloadSaleReturn)
 
 	if shopID != nil {
-		query = query.Joins("JOIN sales ON sale_returns.sale_id = sales.id").
-			Where("sales.shop_id = ?", *shopID)
+		query = filterReturnsByShop(s.db.DB, query, func(shops *gorm.DB) *gorm.DB {
+			return shops.Where("shop_id = ?", *shopID)
+		})
+	}
+	if shopScope := scope.FromContext(ctx); shopScope != nil && shopScope.Restricted {
+		query = filterReturnsByShop(s.db.DB, query, shopScope.Filter("shop_id"))
 	}
 
 	var returns []models.SaleReturn
@@ -387,6 +590,7 @@ func (s *ReturnsService) GetPendingReturns(ctx context.Context, tenantID uuid.UU
 // ReturnsFilters represents filters for returns
 type ReturnsFilters struct {
 	SaleID    uuid.UUID `form:"sale_id"`
+	DailySalesRecordID uuid.UUID `form:"daily_sales_record_id"`
 	ShopID    uuid.UUID `form:"shop_id"`
 	Status    string    `form:"status"`
 	StartDate time.Time `form:"start_date" time_format:"2006-01-02"`
@@ -410,6 +614,7 @@ func (s *ReturnsService) mapSaleReturnToResponse(saleReturn *models.SaleReturn)
 		ID:           saleReturn.ID,
 		ReturnNumber: saleReturn.ReturnNumber,
 		SaleID:       saleReturn.SaleID,
+		DailySalesRecordID: saleReturn.DailySalesRecordID,
 		ReturnDate:   saleReturn.ReturnDate,
 		ReturnAmount: saleReturn.ReturnAmount,
 		Reason:       saleReturn.Reason,
@@ -443,24 +648,31 @@ func (s *ReturnsService) mapSaleReturnToResponse(saleReturn *models.SaleReturn)
 			response.Items[i] = SaleReturnItemResponse{
 				ID:          item.ID,
 				SaleItemID:  item.SaleItemID,
+				DailySalesItemID: item.DailySalesItemID,
 				Quantity:    item.Quantity,
 				UnitPrice:   item.UnitPrice,
 				TotalAmount: item.TotalAmount,
 				Reason:      item.Reason,
 			}
 
-			// Add product info from sale item
-			if item.SaleItem != nil && item.SaleItem.Product != nil {
-				response.Items[i].ProductID = item.SaleItem.Product.ID
-				response.Items[i].ProductName = item.SaleItem.Product.Name
-				response.Items[i].Size = item.SaleItem.Product.Size
+			// Add product info from the sale or daily sales item
+			var product *models.Product
+			if item.SaleItem != nil {
+				product = item.SaleItem.Product
+			} else if item.DailySalesItem != nil {
+				product = item.DailySalesItem.Product
+			}
+			if product != nil {
+				response.Items[i].ProductID = product.ID
+				response.Items[i].ProductName = product.Name
+				response.Items[i].Size = product.Size
 				
-				if item.SaleItem.Product.Brand != nil {
-					response.Items[i].BrandName = item.SaleItem.Product.Brand.Name
+				if product.Brand != nil {
+					response.Items[i].BrandName = product.Brand.Name
 				}
 				
-				if item.SaleItem.Product.Category != nil {
-					response.Items[i].CategoryName = item.SaleItem.Product.Category.Name
+				if product.Category != nil {
+					response.Items[i].CategoryName = product.Category.Name
 				}
 			}
 		}
@@ -469,6 +681,64 @@ func (s *ReturnsService) mapSaleReturnToResponse(saleReturn *models.SaleReturn)
 	return response
 }
 
+// restockDailySalesReturn puts the goods of an approved daily sales return back
+// into the record's shop stock inside the approval transaction
+func (s *ReturnsService) restockDailySalesReturn(tx *gorm.DB, saleReturn *models.SaleReturn, userID uuid.UUID) error {
+	reference := fmt.Sprintf("RETURN-%s", saleReturn.ReturnNumber)
+	return s.stocks.ProcessSaleTx(tx, reference, saleReturn.ID, dailySalesReturnStockItems(saleReturn),
+		saleReturn.DailySalesRecord.ShopID, saleReturn.TenantID, userID, true)
+}
+
+// dailySalesReturnStockItems converts a daily sales return's items into the sale
+// items the stock service moves
+func dailySalesReturnStockItems(saleReturn *models.SaleReturn) []models.SaleItem {
+	items := make([]models.SaleItem, 0, len(saleReturn.Items))
+	for _, item := range saleReturn.Items {
+		if item.DailySalesItem == nil {
+			continue
+		}
+		items = append(items, models.SaleItem{
+			ProductID:  item.DailySalesItem.ProductID,
+			Quantity:   item.Quantity,
+			UnitPrice:  item.UnitPrice,
+			TotalPrice: item.TotalAmount,
+		})
+	}
+	return items
+}
+
+// returnShopID returns the shop a return was raised at
+func returnShopID(saleReturn *models.SaleReturn) uuid.UUID {
+	if saleReturn.Sale != nil {
+		return saleReturn.Sale.ShopID
+	}
+	if saleReturn.DailySalesRecord != nil {
+		return saleReturn.DailySalesRecord.ShopID
+	}
+	return uuid.Nil
+}
+
+// preloadSaleReturn loads what mapSaleReturnToResponse needs for either kind of return
+func preloadSaleReturn(db *gorm.DB) *gorm.DB {
+	return db.Preload("Sale.Shop").
+		Preload("Sale.Salesman").
+		Preload("DailySalesRecord.Shop").
+		Preload("CreatedBy").
+		Preload("ApprovedBy").
+		Preload("Items.SaleItem.Product.Brand").
+		Preload("Items.SaleItem.Product.Category").
+		Preload("Items.DailySalesItem.Product.Brand").
+		Preload("Items.DailySalesItem.Product.Category")
+}
+
+// filterReturnsByShop limits a sale return query to returns raised at the shops
+// selected by shops, whether against an individual sale or a daily sales record
+func filterReturnsByShop(db *gorm.DB, query *gorm.DB, shops func(*gorm.DB) *gorm.DB) *gorm.DB {
+	return query.Where("(sale_returns.sale_id IN (?) OR sale_returns.daily_sales_record_id IN (?))",
+		shops(db.Model(&models.Sale{}).Select("id")),
+		shops(db.Model(&models.DailySalesRecord{}).Select("id")))
+}
+
 // clearReturnsCache clears related cache entries
 func (s *ReturnsService) clearReturnsCache(ctx context.Context, tenantID, shopID uuid.UUID) {
 	cacheKeys := []string{
@@ -480,4 +750,6 @@ func (s *ReturnsService) clearReturnsCache(ctx context.Context, tenantID, shopID
 	for _, key := range cacheKeys {
 		s.cache.Delete(ctx, key)
 	}
+
+	s.cache.InvalidateDashboard(ctx, tenantID.String())
 }
\ No newline at end of file
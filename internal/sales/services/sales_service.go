@@ -7,24 +7,32 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/approval"
 	"github.com/liquorpro/go-backend/pkg/shared/cache"
 	"github.com/liquorpro/go-backend/pkg/shared/database"
 	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
+	"github.com/liquorpro/go-backend/pkg/shared/tax"
 	"github.com/liquorpro/go-backend/pkg/shared/utils"
+	"github.com/liquorpro/go-backend/pkg/shared/webhook"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // SalesService handles individual sale transactions
 type SalesService struct {
-	db    *database.DB
-	cache *cache.Cache
+	db       *database.DB
+	cache    *cache.Cache
+	settings *settings.Service
 }
 
 // NewSalesService creates a new sales service
-func NewSalesService(db *database.DB, cache *cache.Cache) *SalesService {
+func NewSalesService(db *database.DB, cache *cache.Cache, settingsService *settings.Service) *SalesService {
 	return &SalesService{
-		db:    db,
-		cache: cache,
+		db:       db,
+		cache:    cache,
+		settings: settingsService,
 	}
 }
 
@@ -65,6 +73,8 @@ type SaleResponse struct {
 	SubTotal      float64           `json:"sub_total"`
 	DiscountAmount float64          `json:"discount_amount"`
 	TaxAmount     float64           `json:"tax_amount"`
+	CGSTAmount    float64           `json:"cgst_amount"`
+	SGSTAmount    float64           `json:"sgst_amount"`
 	TotalAmount   float64           `json:"total_amount"`
 	PaidAmount    float64           `json:"paid_amount"`
 	DueAmount     float64           `json:"due_amount"`
@@ -94,6 +104,11 @@ type SaleItemResponse struct {
 	DiscountAmount float64   `json:"discount_amount"`
 	DiscountReason string    `json:"discount_reason"`
 	TotalPrice     float64   `json:"total_price"`
+	TaxRate        float64   `json:"tax_rate"`
+	TaxableAmount  float64   `json:"taxable_amount"`
+	CGSTAmount     float64   `json:"cgst_amount"`
+	SGSTAmount     float64   `json:"sgst_amount"`
+	TaxAmount      float64   `json:"tax_amount"`
 }
 
 // CreateSale creates a new individual sale
@@ -124,6 +139,7 @@ func (s *SalesService) CreateSale(ctx context.Context, req SaleRequest, tenantID
 	err := s.db.Transaction(func(tx *gorm.DB) error {
 		// Calculate totals
 		var subTotal, totalDiscount float64
+		productIDs := make([]uuid.UUID, 0, len(req.Items))
 		for _, itemReq := range req.Items {
 			// Verify product exists
 			var product models.Product
@@ -134,6 +150,20 @@ func (s *SalesService) CreateSale(ctx context.Context, req SaleRequest, tenantID
 			itemTotal := float64(itemReq.Quantity) * itemReq.UnitPrice
 			subTotal += itemTotal
 			totalDiscount += itemReq.DiscountAmount
+			productIDs = append(productIDs, itemReq.ProductID)
+		}
+
+		// Shelf prices include GST, so each line's tax is extracted from its total
+		rates, err := tax.ProductRates(tx, tenantID, productIDs)
+		if err != nil {
+			return err
+		}
+		itemTaxes := make([]tax.Breakdown, len(req.Items))
+		var saleTax tax.Breakdown
+		for i, itemReq := range req.Items {
+			totalPrice := (float64(itemReq.Quantity) * itemReq.UnitPrice) - itemReq.DiscountAmount
+			itemTaxes[i] = tax.Inclusive(totalPrice, rates[itemReq.ProductID], false)
+			saleTax.Add(itemTaxes[i])
 		}
 
 		totalAmount := subTotal - totalDiscount
@@ -162,6 +192,9 @@ func (s *SalesService) CreateSale(ctx context.Context, req SaleRequest, tenantID
 			CustomerPhone:  req.CustomerPhone,
 			SubTotal:       subTotal,
 			DiscountAmount: totalDiscount,
+			TaxAmount:      saleTax.Total,
+			CGSTAmount:     saleTax.CGST,
+			SGSTAmount:     saleTax.SGST,
 			TotalAmount:    totalAmount,
 			PaidAmount:     req.PaidAmount,
 			DueAmount:      dueAmount,
@@ -177,7 +210,7 @@ func (s *SalesService) CreateSale(ctx context.Context, req SaleRequest, tenantID
 		}
 
 		// Create sale items
-		for _, itemReq := range req.Items {
+		for i, itemReq := range req.Items {
 			totalPrice := (float64(itemReq.Quantity) * itemReq.UnitPrice) - itemReq.DiscountAmount
 
 			item := models.SaleItem{
@@ -189,6 +222,11 @@ func (s *SalesService) CreateSale(ctx context.Context, req SaleRequest, tenantID
 				DiscountAmount: itemReq.DiscountAmount,
 				DiscountReason: itemReq.DiscountReason,
 				TotalPrice:     totalPrice,
+				TaxRate:        itemTaxes[i].Rate,
+				TaxableAmount:  itemTaxes[i].TaxableAmount,
+				CGSTAmount:     itemTaxes[i].CGST,
+				SGSTAmount:     itemTaxes[i].SGST,
+				TaxAmount:      itemTaxes[i].Total,
 			}
 
 			if err := tx.Create(&item).Error; err != nil {
@@ -244,6 +282,7 @@ func (s *SalesService) GetSales(ctx context.Context, tenantID uuid.UUID, filters
 	if filters.ShopID != uuid.Nil {
 		query = query.Where("shop_id = ?", filters.ShopID)
 	}
+	query = scope.FromContext(ctx).Apply(query, "shop_id")
 	if filters.SalesmanID != uuid.Nil {
 		query = query.Where("salesman_id = ?", filters.SalesmanID)
 	}
@@ -315,33 +354,44 @@ func (s *SalesService) GetSaleByID(ctx context.Context, saleID, tenantID uuid.UU
 	return s.mapSaleToResponse(&sale), nil
 }
 
-// ApproveSale approves a sale
+// ApproveSale approves a sale. The sale is locked and its status re-checked inside
+// the transaction, so concurrent decisions take effect once.
 func (s *SalesService) ApproveSale(ctx context.Context, saleID, tenantID, approvedByID uuid.UUID) (*SaleResponse, error) {
 	var sale models.Sale
-	
-	err := s.db.Where("id = ? AND tenant_id = ?", saleID, tenantID).First(&sale).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("sale not found")
-		}
-		return nil, fmt.Errorf("failed to find sale: %w", err)
-	}
 
-	// Only pending sales can be approved
-	if sale.Status != models.StatusPending {
-		return nil, errors.New("only pending sales can be approved")
-	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.lockSale(tx, &sale, saleID, tenantID); err != nil {
+			return err
+		}
+		if err := approval.Decide("sale", sale.Status, models.StatusApproved); err != nil {
+			return err
+		}
 
-	// Update sale status
-	now := time.Now()
-	updates := map[string]interface{}{
-		"status":         models.StatusApproved,
-		"approved_at":    now,
-		"approved_by_id": approvedByID,
-	}
+		// Update sale status
+		now := time.Now()
+		updates := map[string]interface{}{
+			"status":         models.StatusApproved,
+			"approved_at":    now,
+			"approved_by_id": approvedByID,
+		}
 
-	if err := s.db.Model(&sale).Updates(updates).Error; err != nil {
-		return nil, fmt.Errorf("failed to approve sale: %w", err)
+		if err := tx.Model(&sale).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to approve sale: %w", err)
+		}
+		return webhook.Enqueue(tx, tenantID, webhook.EventSaleApproved, webhook.SaleApprovedData{
+			Source:       webhook.SaleSourceSale,
+			ID:           sale.ID,
+			ShopID:       sale.ShopID,
+			Amount:       sale.TotalAmount,
+			ApprovedByID: &approvedByID,
+			ApprovedAt:   now,
+		})
+	})
+	if err != nil {
+		if approval.Replay(ctx, s.settings, tenantID, err) {
+			return s.GetSaleByID(ctx, saleID, tenantID)
+		}
+		return nil, err
 	}
 
 	// Clear cache
@@ -351,34 +401,37 @@ func (s *SalesService) ApproveSale(ctx context.Context, saleID, tenantID, approv
 	return s.GetSaleByID(ctx, saleID, tenantID)
 }
 
-// RejectSale rejects a sale
+// RejectSale rejects a sale under the same lock as approval
 func (s *SalesService) RejectSale(ctx context.Context, saleID, tenantID, rejectedByID uuid.UUID, reason string) error {
 	var sale models.Sale
-	
-	err := s.db.Where("id = ? AND tenant_id = ?", saleID, tenantID).First(&sale).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("sale not found")
-		}
-		return fmt.Errorf("failed to find sale: %w", err)
-	}
 
-	// Only pending sales can be rejected
-	if sale.Status != models.StatusPending {
-		return errors.New("only pending sales can be rejected")
-	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.lockSale(tx, &sale, saleID, tenantID); err != nil {
+			return err
+		}
+		if err := approval.Decide("sale", sale.Status, models.StatusRejected); err != nil {
+			return err
+		}
 
-	// Update sale status
-	now := time.Now()
-	updates := map[string]interface{}{
-		"status":         models.StatusRejected,
-		"approved_at":    now,
-		"approved_by_id": rejectedByID,
-		"notes":          sale.Notes + " | Rejection reason: " + reason,
-	}
+		// Update sale status
+		now := time.Now()
+		updates := map[string]interface{}{
+			"status":         models.StatusRejected,
+			"approved_at":    now,
+			"approved_by_id": rejectedByID,
+			"notes":          sale.Notes + " | Rejection reason: " + reason,
+		}
 
-	if err := s.db.Model(&sale).Updates(updates).Error; err != nil {
-		return fmt.Errorf("failed to reject sale: %w", err)
+		if err := tx.Model(&sale).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to reject sale: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		if approval.Replay(ctx, s.settings, tenantID, err) {
+			return nil
+		}
+		return err
 	}
 
 	// Clear cache
@@ -387,6 +440,20 @@ func (s *SalesService) RejectSale(ctx context.Context, saleID, tenantID, rejecte
 	return nil
 }
 
+// lockSale loads a sale with a row lock held until the transaction ends
+func (s *SalesService) lockSale(tx *gorm.DB, sale *models.Sale, saleID, tenantID uuid.UUID) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND tenant_id = ?", saleID, tenantID).
+		First(sale).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("sale not found")
+		}
+		return fmt.Errorf("failed to find sale: %w", err)
+	}
+	return nil
+}
+
 // GetPendingSales returns pending sales requiring approval
 func (s *SalesService) GetPendingSales(ctx context.Context, tenantID uuid.UUID, shopID *uuid.UUID) ([]*SaleResponse, error) {
 	query := s.db.Model(&models.Sale{}).
@@ -400,6 +467,7 @@ func (s *SalesService) GetPendingSales(ctx context.Context, tenantID uuid.UUID,
 	if shopID != nil {
 		query = query.Where("shop_id = ?", *shopID)
 	}
+	query = scope.FromContext(ctx).Apply(query, "shop_id")
 
 	var sales []models.Sale
 	if err := query.Order("created_at ASC").Find(&sales).Error; err != nil {
@@ -427,6 +495,7 @@ func (s *SalesService) GetUncollectedSales(ctx context.Context, tenantID uuid.UU
 	if shopID != nil {
 		query = query.Where("shop_id = ?", *shopID)
 	}
+	query = scope.FromContext(ctx).Apply(query, "shop_id")
 
 	var sales []models.Sale
 	if err := query.Order("sale_date ASC").Find(&sales).Error; err != nil {
@@ -477,6 +546,8 @@ func (s *SalesService) mapSaleToResponse(sale *models.Sale) *SaleResponse {
 		SubTotal:      sale.SubTotal,
 		DiscountAmount: sale.DiscountAmount,
 		TaxAmount:     sale.TaxAmount,
+		CGSTAmount:    sale.CGSTAmount,
+		SGSTAmount:    sale.SGSTAmount,
 		TotalAmount:   sale.TotalAmount,
 		PaidAmount:    sale.PaidAmount,
 		DueAmount:     sale.DueAmount,
@@ -522,6 +593,11 @@ func (s *SalesService) mapSaleToResponse(sale *models.Sale) *SaleResponse {
 				DiscountAmount: item.DiscountAmount,
 				DiscountReason: item.DiscountReason,
 				TotalPrice:     item.TotalPrice,
+				TaxRate:        item.TaxRate,
+				TaxableAmount:  item.TaxableAmount,
+				CGSTAmount:     item.CGSTAmount,
+				SGSTAmount:     item.SGSTAmount,
+				TaxAmount:      item.TaxAmount,
 			}
 
 			// Add product info
@@ -554,4 +630,6 @@ func (s *SalesService) clearSalesCache(ctx context.Context, tenantID, shopID uui
 	for _, key := range cacheKeys {
 		s.cache.Delete(ctx, key)
 	}
+
+	s.cache.InvalidateDashboard(ctx, tenantID.String())
 }
\ No newline at end of file
@@ -0,0 +1,437 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/cache"
+	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+	"gorm.io/gorm"
+)
+
+// TargetService handles monthly sales targets and achievement tracking
+type TargetService struct {
+	db    *database.DB
+	cache *cache.Cache
+}
+
+// NewTargetService creates a new target service
+func NewTargetService(db *database.DB, cache *cache.Cache) *TargetService {
+	return &TargetService{
+		db:    db,
+		cache: cache,
+	}
+}
+
+type SalesTargetRequest struct {
+	ShopID       uuid.UUID  `json:"shop_id" binding:"required"`
+	SalesmanID   *uuid.UUID `json:"salesman_id"`
+	Period       string     `json:"period" binding:"required"` // YYYY-MM
+	TargetAmount float64    `json:"target_amount" binding:"required,gt=0"`
+	Notes        string     `json:"notes"`
+}
+
+type SalesTargetUpdateRequest struct {
+	TargetAmount float64 `json:"target_amount" binding:"required,gt=0"`
+	Notes        *string `json:"notes"`
+}
+
+type SalesTargetResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	ShopID       uuid.UUID  `json:"shop_id"`
+	ShopName     string     `json:"shop_name,omitempty"`
+	SalesmanID   *uuid.UUID `json:"salesman_id"`
+	SalesmanName string     `json:"salesman_name,omitempty"`
+	Period       string     `json:"period"`
+	TargetAmount float64    `json:"target_amount"`
+	Notes        string     `json:"notes"`
+	CreatedByID  uuid.UUID  `json:"created_by_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TargetAchievement compares a target with the approved sales made against it
+type TargetAchievement struct {
+	TargetAmount       float64 `json:"target_amount"`
+	ActualAmount       float64 `json:"actual_amount"`
+	AchievementPercent float64 `json:"achievement_percent"`
+	RemainingAmount    float64 `json:"remaining_amount"`
+}
+
+// SalesmanTargetAchievement is one salesman's progress against their target
+type SalesmanTargetAchievement struct {
+	TargetAchievement
+	TargetID     uuid.UUID `json:"target_id"`
+	SalesmanID   uuid.UUID `json:"salesman_id"`
+	SalesmanName string    `json:"salesman_name"`
+}
+
+// ShopTargetAchievement is one shop's progress. The shop's own target is used when
+// it has one; otherwise the shop's target is the sum of its salesmen's targets.
+type ShopTargetAchievement struct {
+	TargetAchievement
+	ShopID   uuid.UUID                   `json:"shop_id"`
+	ShopName string                      `json:"shop_name"`
+	TargetID *uuid.UUID                  `json:"target_id"` // nil when summed from salesman targets
+	Salesmen []SalesmanTargetAchievement `json:"salesmen"`
+}
+
+// TargetAchievementReport lists target progress for a month
+type TargetAchievementReport struct {
+	Period string `json:"period"`
+	TargetAchievement
+	Shops       []ShopTargetAchievement `json:"shops"`
+	GeneratedAt time.Time               `json:"generated_at"`
+}
+
+// ParseTargetPeriod parses a YYYY-MM period into the first day of that month
+func ParseTargetPeriod(period string) (time.Time, error) {
+	start, err := time.Parse("2006-01", period)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("period must be in YYYY-MM format")
+	}
+	return start, nil
+}
+
+// CreateSalesTarget sets a shop or salesman target for a month. Each shop and each
+// salesman can have one target per month.
+func (s *TargetService) CreateSalesTarget(ctx context.Context, req SalesTargetRequest, tenantID, userID uuid.UUID) (*SalesTargetResponse, error) {
+	periodStart, err := ParseTargetPeriod(req.Period)
+	if err != nil {
+		return nil, err
+	}
+
+	db := s.db.DB.WithContext(ctx)
+	var shop models.Shop
+	if err := db.Where("id = ? AND tenant_id = ?", req.ShopID, tenantID).First(&shop).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("shop not found")
+		}
+		return nil, fmt.Errorf("failed to get shop: %w", err)
+	}
+	if req.SalesmanID != nil {
+		var salesman models.Salesman
+		if err := db.Where("id = ? AND tenant_id = ?", *req.SalesmanID, tenantID).First(&salesman).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("salesman not found")
+			}
+			return nil, fmt.Errorf("failed to get salesman: %w", err)
+		}
+		if salesman.ShopID != req.ShopID {
+			return nil, fmt.Errorf("salesman does not belong to this shop")
+		}
+	}
+
+	existing := db.Model(&models.SalesTarget{}).
+		Where("tenant_id = ? AND shop_id = ? AND period_start = ?", tenantID, req.ShopID, periodStart)
+	if req.SalesmanID != nil {
+		existing = existing.Where("salesman_id = ?", *req.SalesmanID)
+	} else {
+		existing = existing.Where("salesman_id IS NULL")
+	}
+	var count int64
+	if err := existing.Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to check existing targets: %w", err)
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("a target already exists for this period")
+	}
+
+	target := models.SalesTarget{
+		TenantModel: models.TenantModel{
+			BaseModel: models.BaseModel{ID: uuid.New()},
+			TenantID:  tenantID,
+		},
+		ShopID:       req.ShopID,
+		SalesmanID:   req.SalesmanID,
+		PeriodStart:  periodStart,
+		TargetAmount: utils.RoundToTwoDecimals(req.TargetAmount),
+		Notes:        req.Notes,
+		CreatedByID:  userID,
+	}
+	if err := db.Create(&target).Error; err != nil {
+		return nil, fmt.Errorf("failed to create sales target: %w", err)
+	}
+
+	s.cache.InvalidateDashboard(ctx, tenantID.String())
+
+	return s.GetSalesTargetByID(ctx, target.ID, tenantID)
+}
+
+// GetSalesTargets lists the targets for a month, limited to one shop when shopID is set
+func (s *TargetService) GetSalesTargets(ctx context.Context, tenantID uuid.UUID, periodStart time.Time, shopID *uuid.UUID) ([]SalesTargetResponse, error) {
+	query := s.db.DB.WithContext(ctx).
+		Preload("Shop").
+		Preload("Salesman").
+		Where("tenant_id = ? AND period_start = ?", tenantID, periodStart)
+	if shopID != nil {
+		query = query.Where("shop_id = ?", *shopID)
+	}
+	query = scope.FromContext(ctx).Apply(query, "shop_id")
+
+	var targets []models.SalesTarget
+	if err := query.Order("shop_id, salesman_id NULLS FIRST").Find(&targets).Error; err != nil {
+		return nil, fmt.Errorf("failed to get sales targets: %w", err)
+	}
+
+	responses := make([]SalesTargetResponse, len(targets))
+	for i := range targets {
+		responses[i] = *buildSalesTargetResponse(&targets[i])
+	}
+	return responses, nil
+}
+
+// GetSalesTargetByID returns a single target
+func (s *TargetService) GetSalesTargetByID(ctx context.Context, id, tenantID uuid.UUID) (*SalesTargetResponse, error) {
+	var target models.SalesTarget
+	if err := s.db.DB.WithContext(ctx).
+		Preload("Shop").
+		Preload("Salesman").
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		First(&target).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("sales target not found")
+		}
+		return nil, fmt.Errorf("failed to get sales target: %w", err)
+	}
+	return buildSalesTargetResponse(&target), nil
+}
+
+// UpdateSalesTarget changes a target's amount and notes
+func (s *TargetService) UpdateSalesTarget(ctx context.Context, id, tenantID uuid.UUID, req SalesTargetUpdateRequest) (*SalesTargetResponse, error) {
+	updates := map[string]interface{}{
+		"target_amount": utils.RoundToTwoDecimals(req.TargetAmount),
+	}
+	if req.Notes != nil {
+		updates["notes"] = *req.Notes
+	}
+
+	result := s.db.DB.WithContext(ctx).Model(&models.SalesTarget{}).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		Updates(updates)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to update sales target: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("sales target not found")
+	}
+
+	s.cache.InvalidateDashboard(ctx, tenantID.String())
+
+	return s.GetSalesTargetByID(ctx, id, tenantID)
+}
+
+// DeleteSalesTarget removes a target
+func (s *TargetService) DeleteSalesTarget(ctx context.Context, id, tenantID uuid.UUID) error {
+	result := s.db.DB.WithContext(ctx).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		Delete(&models.SalesTarget{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete sales target: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("sales target not found")
+	}
+
+	s.cache.InvalidateDashboard(ctx, tenantID.String())
+	return nil
+}
+
+// GetTargetAchievement compares each target for a month with the approved sales made
+// in that month, limited to one shop when shopID is set
+func (s *TargetService) GetTargetAchievement(ctx context.Context, tenantID uuid.UUID, periodStart time.Time, shopID *uuid.UUID) (*TargetAchievementReport, error) {
+	return monthTargetAchievement(ctx, s.db.DB.WithContext(ctx), tenantID, periodStart, shopID)
+}
+
+// monthTargetAchievement builds the target report for the month starting at
+// periodStart. Sales are counted the way the dashboard counts revenue: approved daily
+// sales records less the approved returns raised against them, plus approved
+// individual sales.
+func monthTargetAchievement(ctx context.Context, db *gorm.DB, tenantID uuid.UUID, periodStart time.Time, shopID *uuid.UUID) (*TargetAchievementReport, error) {
+	periodEnd := periodStart.AddDate(0, 1, 0)
+	shopScope := scope.FromContext(ctx)
+
+	targetsQuery := db.Preload("Shop").Preload("Salesman").
+		Where("tenant_id = ? AND period_start = ?", tenantID, periodStart)
+	if shopID != nil {
+		targetsQuery = targetsQuery.Where("shop_id = ?", *shopID)
+	}
+	targetsQuery = shopScope.Apply(targetsQuery, "shop_id")
+
+	var targets []models.SalesTarget
+	if err := targetsQuery.Find(&targets).Error; err != nil {
+		return nil, fmt.Errorf("failed to get sales targets: %w", err)
+	}
+
+	report := &TargetAchievementReport{
+		Period:      periodStart.Format("2006-01"),
+		Shops:       []ShopTargetAchievement{},
+		GeneratedAt: time.Now(),
+	}
+	if len(targets) == 0 {
+		return report, nil
+	}
+
+	shopIDs := make([]uuid.UUID, 0, len(targets))
+	seen := make(map[uuid.UUID]bool)
+	for _, target := range targets {
+		if !seen[target.ShopID] {
+			seen[target.ShopID] = true
+			shopIDs = append(shopIDs, target.ShopID)
+		}
+	}
+
+	// Approved sales per shop and salesman; uuid.Nil collects sales without a salesman
+	type salesTotal struct {
+		ShopID     uuid.UUID
+		SalesmanID *uuid.UUID
+		Amount     float64
+	}
+	actuals := make(map[uuid.UUID]map[uuid.UUID]float64)
+	add := func(rows []salesTotal, sign float64) {
+		for _, row := range rows {
+			salesmanID := uuid.Nil
+			if row.SalesmanID != nil {
+				salesmanID = *row.SalesmanID
+			}
+			if actuals[row.ShopID] == nil {
+				actuals[row.ShopID] = make(map[uuid.UUID]float64)
+			}
+			actuals[row.ShopID][salesmanID] += sign * row.Amount
+		}
+	}
+
+	var daily []salesTotal
+	if err := db.Model(&models.DailySalesRecord{}).
+		Select("shop_id, salesman_id, SUM(total_sales_amount) as amount").
+		Where("tenant_id = ? AND status = ? AND record_date >= ? AND record_date < ? AND shop_id IN ?",
+			tenantID, models.StatusApproved, periodStart, periodEnd, shopIDs).
+		Group("shop_id, salesman_id").
+		Scan(&daily).Error; err != nil {
+		return nil, fmt.Errorf("failed to total daily sales: %w", err)
+	}
+	add(daily, 1)
+
+	var returned []salesTotal
+	if err := db.Model(&models.SaleReturn{}).
+		Select("daily_sales_records.shop_id, daily_sales_records.salesman_id, SUM(sale_returns.return_amount) as amount").
+		Joins("JOIN daily_sales_records ON sale_returns.daily_sales_record_id = daily_sales_records.id").
+		Where("sale_returns.tenant_id = ? AND sale_returns.status = ? AND daily_sales_records.status = ?",
+			tenantID, models.StatusApproved, models.StatusApproved).
+		Where("daily_sales_records.record_date >= ? AND daily_sales_records.record_date < ? AND daily_sales_records.shop_id IN ?",
+			periodStart, periodEnd, shopIDs).
+		Where("daily_sales_records.deleted_at IS NULL").
+		Group("daily_sales_records.shop_id, daily_sales_records.salesman_id").
+		Scan(&returned).Error; err != nil {
+		return nil, fmt.Errorf("failed to total returns: %w", err)
+	}
+	add(returned, -1)
+
+	var individual []salesTotal
+	if err := db.Model(&models.Sale{}).
+		Select("shop_id, salesman_id, SUM(total_amount) as amount").
+		Where("tenant_id = ? AND status = ? AND sale_date >= ? AND sale_date < ? AND shop_id IN ?",
+			tenantID, models.StatusApproved, periodStart, periodEnd, shopIDs).
+		Group("shop_id, salesman_id").
+		Scan(&individual).Error; err != nil {
+		return nil, fmt.Errorf("failed to total individual sales: %w", err)
+	}
+	add(individual, 1)
+
+	shops := make(map[uuid.UUID]*ShopTargetAchievement)
+	for _, target := range targets {
+		shop := shops[target.ShopID]
+		if shop == nil {
+			shop = &ShopTargetAchievement{ShopID: target.ShopID, Salesmen: []SalesmanTargetAchievement{}}
+			if target.Shop != nil {
+				shop.ShopName = target.Shop.Name
+			}
+			for _, amount := range actuals[target.ShopID] {
+				shop.ActualAmount += amount
+			}
+			shops[target.ShopID] = shop
+		}
+
+		if target.SalesmanID == nil {
+			targetID := target.ID
+			shop.TargetID = &targetID
+			shop.TargetAmount = target.TargetAmount
+			continue
+		}
+
+		salesman := SalesmanTargetAchievement{
+			TargetID:   target.ID,
+			SalesmanID: *target.SalesmanID,
+		}
+		if target.Salesman != nil {
+			salesman.SalesmanName = target.Salesman.Name
+		}
+		salesman.TargetAmount = target.TargetAmount
+		salesman.ActualAmount = actuals[target.ShopID][*target.SalesmanID]
+		salesman.measure()
+		shop.Salesmen = append(shop.Salesmen, salesman)
+	}
+
+	for _, shop := range shops {
+		// Without a shop-level target the salesmen's targets stand in for the shop's
+		if shop.TargetID == nil {
+			for _, salesman := range shop.Salesmen {
+				shop.TargetAmount += salesman.TargetAmount
+			}
+		}
+		shop.measure()
+		sort.Slice(shop.Salesmen, func(i, j int) bool {
+			return shop.Salesmen[i].SalesmanName < shop.Salesmen[j].SalesmanName
+		})
+
+		report.TargetAmount += shop.TargetAmount
+		report.ActualAmount += shop.ActualAmount
+		report.Shops = append(report.Shops, *shop)
+	}
+	sort.Slice(report.Shops, func(i, j int) bool { return report.Shops[i].ShopName < report.Shops[j].ShopName })
+	report.measure()
+
+	return report, nil
+}
+
+// measure rounds the amounts and works out the achievement and what is left to sell
+func (a *TargetAchievement) measure() {
+	a.TargetAmount = utils.RoundToTwoDecimals(a.TargetAmount)
+	a.ActualAmount = utils.RoundToTwoDecimals(a.ActualAmount)
+	a.AchievementPercent = 0
+	if a.TargetAmount > 0 {
+		a.AchievementPercent = utils.RoundToTwoDecimals(a.ActualAmount * 100 / a.TargetAmount)
+	}
+	a.RemainingAmount = utils.RoundToTwoDecimals(a.TargetAmount - a.ActualAmount)
+	if a.RemainingAmount < 0 {
+		a.RemainingAmount = 0
+	}
+}
+
+func buildSalesTargetResponse(target *models.SalesTarget) *SalesTargetResponse {
+	response := &SalesTargetResponse{
+		ID:           target.ID,
+		ShopID:       target.ShopID,
+		SalesmanID:   target.SalesmanID,
+		Period:       target.PeriodStart.Format("2006-01"),
+		TargetAmount: target.TargetAmount,
+		Notes:        target.Notes,
+		CreatedByID:  target.CreatedByID,
+		CreatedAt:    target.CreatedAt,
+		UpdatedAt:    target.UpdatedAt,
+	}
+	if target.Shop != nil {
+		response.ShopName = target.Shop.Name
+	}
+	if target.Salesman != nil {
+		response.SalesmanName = target.Salesman.Name
+	}
+	return response
+}
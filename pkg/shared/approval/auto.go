@@ -0,0 +1,73 @@
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
+	"gorm.io/gorm"
+)
+
+// Entity types recorded in auto-approval audit entries
+const (
+	EntityMoneyCollection  = "money_collection"
+	EntityDailySalesRecord = "daily_sales_record"
+)
+
+// AutoApprover decides whether work submitted by a trusted executive can skip manual approval
+type AutoApprover struct {
+	db       *database.DB
+	settings *settings.Service
+}
+
+// NewAutoApprover creates a new auto-approver
+func NewAutoApprover(db *database.DB, settingsService *settings.Service) *AutoApprover {
+	return &AutoApprover{
+		db:       db,
+		settings: settingsService,
+	}
+}
+
+// Eligible reports whether an amount submitted for the given user can be auto-approved.
+// The tenant must have opted in, and the user must be active, trusted and have a
+// limit at or above the amount. Any lookup failure falls back to manual approval.
+func (a *AutoApprover) Eligible(ctx context.Context, tenantID, userID uuid.UUID, amount float64) bool {
+	if a == nil || !a.settings.GetBool(ctx, tenantID, settings.KeyAutoApprovalEnabled) {
+		return false
+	}
+
+	var user models.User
+	err := a.db.WithContext(ctx).
+		Select("id", "is_active", "is_trusted", "auto_approval_limit").
+		Where("id = ? AND tenant_id = ?", userID, tenantID).
+		First(&user).Error
+	if err != nil {
+		return false
+	}
+
+	return user.IsActive && user.IsTrusted && user.AutoApprovalLimit > 0 && amount <= user.AutoApprovalLimit
+}
+
+// RecordAutoApproval writes the audit entry for an auto-approved entity inside the caller's transaction
+func RecordAutoApproval(tx *gorm.DB, tenantID, trustedUserID uuid.UUID, entityType string, entityID uuid.UUID, amount float64) error {
+	details, _ := json.Marshal(map[string]interface{}{
+		"trusted_user_id": trustedUserID,
+		"amount":          amount,
+	})
+
+	entry := models.AuditLog{
+		TenantModel: models.TenantModel{TenantID: tenantID},
+		Action:      models.AuditActionAutoApprove,
+		EntityType:  entityType,
+		EntityID:    entityID,
+		Details:     string(details),
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		return fmt.Errorf("failed to record auto-approval: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
+)
+
+// AlreadyProcessedError is returned when an approve or reject reaches a record
+// that another request has already decided
+type AlreadyProcessedError struct {
+	Entity string
+	Status string
+	Repeat bool // the earlier decision is the one being attempted again
+}
+
+func (e *AlreadyProcessedError) Error() string {
+	return fmt.Sprintf("%s has already been processed (status: %s)", e.Entity, e.Status)
+}
+
+// Decide checks the status of a row-locked record before moving it from pending
+// to target. It must be called inside the transaction holding the lock so that
+// concurrent decisions on the same record are serialised.
+func Decide(entity, current, target string) error {
+	if current == models.StatusPending {
+		return nil
+	}
+	return &AlreadyProcessedError{Entity: entity, Status: current, Repeat: current == target}
+}
+
+// Replay reports whether err is a repeat of an earlier identical decision that
+// the tenant answers idempotently instead of with a conflict
+func Replay(ctx context.Context, settingsService *settings.Service, tenantID uuid.UUID, err error) bool {
+	var processed *AlreadyProcessedError
+	if !errors.As(err, &processed) || !processed.Repeat || settingsService == nil {
+		return false
+	}
+	return settingsService.GetString(ctx, tenantID, settings.KeyRepeatDecisionMode) == settings.RepeatDecisionReplay
+}
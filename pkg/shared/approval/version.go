@@ -0,0 +1,43 @@
+package approval
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ModifiedError is returned when a write to a versioned record was based on a
+// version that another request has since replaced
+type ModifiedError struct {
+	Entity  string
+	Version int // the record's version when the write was refused, if known
+}
+
+func (e *ModifiedError) Error() string {
+	return fmt.Sprintf("%s was modified by another request; reload it and try again", e.Entity)
+}
+
+// CheckVersion compares the version a client last read, when it sent one, with
+// the record's current version
+func CheckVersion(entity string, expected *int, current int) error {
+	if expected != nil && *expected != current {
+		return &ModifiedError{Entity: entity, Version: current}
+	}
+	return nil
+}
+
+// UpdateVersioned applies updates to model only while its row is still at
+// *version, and moves it to the next version. A row changed since it was read
+// is left alone and a ModifiedError is returned.
+func UpdateVersioned(tx *gorm.DB, entity string, model interface{}, version *int, updates map[string]interface{}) error {
+	updates["version"] = gorm.Expr("version + 1")
+	result := tx.Model(model).Where("version = ?", *version).Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update %s: %w", entity, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return &ModifiedError{Entity: entity}
+	}
+	*version++
+	return nil
+}
@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
+)
+
+// ReadAccess describes a read of sensitive data
+type ReadAccess struct {
+	TenantID uuid.UUID
+	UserID   *uuid.UUID
+	Resource string    // one of the settings.ReadAudit* resources
+	EntityID uuid.UUID // uuid.Nil for listings and reports
+	Method   string
+	Path     string
+	Query    string
+	ClientIP string
+}
+
+// ReadAuditor records reads of sensitive data for tenants that have opted in
+type ReadAuditor struct {
+	db       *database.DB
+	settings *settings.Service
+}
+
+// NewReadAuditor creates a new read auditor
+func NewReadAuditor(db *database.DB, settingsService *settings.Service) *ReadAuditor {
+	return &ReadAuditor{
+		db:       db,
+		settings: settingsService,
+	}
+}
+
+// Enabled reports whether the tenant audits reads of the resource
+func (a *ReadAuditor) Enabled(ctx context.Context, tenantID uuid.UUID, resource string) bool {
+	if a == nil {
+		return false
+	}
+	for _, configured := range a.settings.GetList(ctx, tenantID, settings.KeyReadAuditResources) {
+		if configured == resource {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordRead writes a view entry to the audit log
+func (a *ReadAuditor) RecordRead(ctx context.Context, access ReadAccess) error {
+	details, _ := json.Marshal(map[string]interface{}{
+		"method":    access.Method,
+		"path":      access.Path,
+		"query":     access.Query,
+		"client_ip": access.ClientIP,
+	})
+
+	entry := models.AuditLog{
+		TenantModel: models.TenantModel{TenantID: access.TenantID},
+		UserID:      access.UserID,
+		Action:      models.AuditActionView,
+		EntityType:  access.Resource,
+		EntityID:    access.EntityID,
+		Details:     string(details),
+	}
+	if err := a.db.WithContext(ctx).Create(&entry).Error; err != nil {
+		return fmt.Errorf("failed to record read access: %w", err)
+	}
+	return nil
+}
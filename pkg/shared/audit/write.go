@@ -0,0 +1,286 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+)
+
+// FieldChange is the before and after value of one changed column
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// WriteEntry describes a create, update or delete made through the API
+type WriteEntry struct {
+	TenantID   uuid.UUID
+	UserID     *uuid.UUID
+	Action     string
+	EntityType string
+	EntityID   uuid.UUID // uuid.Nil for bulk and unaddressed writes
+	Changes    map[string]FieldChange
+	Method     string
+	Path       string
+	ClientIP   string
+}
+
+// AuditLogFilter narrows an audit log search
+type AuditLogFilter struct {
+	EntityType string
+	EntityID   *uuid.UUID
+	UserID     *uuid.UUID
+	Action     string
+	Start      *time.Time
+	End        *time.Time // exclusive
+}
+
+// AuditLogResponse is an audit log entry with its details decoded
+type AuditLogResponse struct {
+	ID         uuid.UUID       `json:"id"`
+	UserID     *uuid.UUID      `json:"user_id"`
+	UserName   string          `json:"user_name,omitempty"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   uuid.UUID       `json:"entity_id"`
+	Details    json.RawMessage `json:"details"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// Logger records writes to tenant data in the audit log and searches the log
+type Logger struct {
+	db *database.DB
+}
+
+// NewLogger creates a new audit logger
+func NewLogger(db *database.DB) *Logger {
+	return &Logger{db: db}
+}
+
+// Snapshot reads the current row of an entity as a column map. It returns nil when
+// the row does not exist. Soft-deleted rows are included so deletions show up as a
+// change to deleted_at.
+func (l *Logger) Snapshot(ctx context.Context, table string, tenantID, id uuid.UUID) map[string]interface{} {
+	if l == nil || table == "" || id == uuid.Nil {
+		return nil
+	}
+	row := map[string]interface{}{}
+	if err := l.db.WithContext(ctx).Table(table).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		Take(&row).Error; err != nil {
+		return nil
+	}
+	for column, value := range row {
+		row[column] = normalizeValue(column, value)
+	}
+	return row
+}
+
+// RecordWrite writes a create, update or delete entry to the audit log
+func (l *Logger) RecordWrite(ctx context.Context, entry WriteEntry) error {
+	details, _ := json.Marshal(map[string]interface{}{
+		"method":    entry.Method,
+		"path":      entry.Path,
+		"client_ip": entry.ClientIP,
+		"changes":   entry.Changes,
+	})
+
+	log := models.AuditLog{
+		TenantModel: models.TenantModel{TenantID: entry.TenantID},
+		UserID:      entry.UserID,
+		Action:      entry.Action,
+		EntityType:  entry.EntityType,
+		EntityID:    entry.EntityID,
+		Details:     string(details),
+	}
+	if err := l.db.WithContext(ctx).Create(&log).Error; err != nil {
+		return fmt.Errorf("failed to record write: %w", err)
+	}
+	return nil
+}
+
+// GetAuditLogs searches a tenant's audit log, newest first
+func (l *Logger) GetAuditLogs(ctx context.Context, tenantID uuid.UUID, filter AuditLogFilter, limit, offset int) ([]AuditLogResponse, int64, error) {
+	query := l.db.WithContext(ctx).Model(&models.AuditLog{}).Where("tenant_id = ?", tenantID)
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != nil {
+		query = query.Where("entity_id = ?", *filter.EntityID)
+	}
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.Start != nil {
+		query = query.Where("created_at >= ?", *filter.Start)
+	}
+	if filter.End != nil {
+		query = query.Where("created_at < ?", *filter.End)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+
+	var logs []models.AuditLog
+	if err := query.Preload("User").Order("created_at DESC").Limit(limit).Offset(offset).Find(&logs).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get audit logs: %w", err)
+	}
+
+	responses := make([]AuditLogResponse, len(logs))
+	for i, log := range logs {
+		responses[i] = AuditLogResponse{
+			ID:         log.ID,
+			UserID:     log.UserID,
+			Action:     log.Action,
+			EntityType: log.EntityType,
+			EntityID:   log.EntityID,
+			CreatedAt:  log.CreatedAt,
+		}
+		if json.Valid([]byte(log.Details)) {
+			responses[i].Details = json.RawMessage(log.Details)
+		}
+		if log.User != nil {
+			responses[i].UserName = log.User.FullName()
+		}
+	}
+	return responses, total, nil
+}
+
+// Diff lists the columns whose values differ between two snapshots. A nil before
+// snapshot is a create and lists every column that was set.
+func Diff(before, after map[string]interface{}) map[string]FieldChange {
+	changes := make(map[string]FieldChange)
+	for column, value := range after {
+		if ignoredColumn(column, before == nil) {
+			continue
+		}
+		old, existed := before[column]
+		if before == nil && value == nil {
+			continue
+		}
+		if existed && sameValue(old, value) {
+			continue
+		}
+		changes[column] = FieldChange{Old: old, New: value}
+	}
+	for column, old := range before {
+		if _, ok := after[column]; ok || ignoredColumn(column, false) {
+			continue
+		}
+		changes[column] = FieldChange{Old: old, New: nil}
+	}
+	return changes
+}
+
+// ResolveWrite works out the audited entity and action of a write request from its
+// method and route template. tables maps resource path segments (or, where a segment
+// is ambiguous, "parent/segment" pairs) to their tables; the entity is the last such
+// resource before the :id parameter, or in the whole path when there is none. A POST
+// below the entity is named by the remaining path (for example approve or restore);
+// otherwise the method decides the action.
+func ResolveWrite(method, route string, tables map[string]string) (entityType, table, action string) {
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(route, "/api"), "/"), "/")
+
+	end := len(segments)
+	for i, segment := range segments {
+		if segment == ":id" {
+			end = i
+			break
+		}
+	}
+
+	entityAt := -1
+	for i := end - 1; i >= 0 && entityAt < 0; i-- {
+		if i > 0 {
+			if mapped, ok := tables[segments[i-1]+"/"+segments[i]]; ok {
+				entityAt, table = i, mapped
+				break
+			}
+		}
+		if mapped, ok := tables[segments[i]]; ok {
+			entityAt, table = i, mapped
+		}
+	}
+	if entityAt < 0 {
+		// Not a mapped resource: audit it under its first path segment without a diff
+		entityAt = 0
+		entityType = segments[0]
+	} else {
+		entityType = table
+	}
+
+	var rest []string
+	for _, segment := range segments[entityAt+1:] {
+		if segment != "" && !strings.HasPrefix(segment, ":") {
+			rest = append(rest, segment)
+		}
+	}
+
+	switch {
+	case method == "DELETE":
+		action = models.AuditActionDelete
+	case method == "PUT" || method == "PATCH":
+		action = models.AuditActionUpdate
+	case len(rest) > 0:
+		action = strings.Join(rest, "/")
+	case end < len(segments):
+		action = models.AuditActionUpdate
+	default:
+		action = models.AuditActionCreate
+	}
+	return entityType, table, action
+}
+
+// ignoredColumn reports whether a column is left out of diffs. Bookkeeping columns
+// change on every write, and on create the key columns say nothing new.
+func ignoredColumn(column string, creating bool) bool {
+	switch column {
+	case "updated_at":
+		return true
+	case "id", "tenant_id", "created_at", "deleted_at":
+		return creating
+	}
+	return false
+}
+
+// normalizeValue makes driver values comparable and readable in JSON, and keeps
+// secrets out of the audit log
+func normalizeValue(column string, value interface{}) interface{} {
+	lower := strings.ToLower(column)
+	if strings.Contains(lower, "password") || strings.Contains(lower, "secret") || strings.Contains(lower, "token") {
+		if value == nil {
+			return nil
+		}
+		return "[redacted]"
+	}
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case [16]byte:
+		return uuid.UUID(v).String()
+	case time.Time:
+		return v.UTC()
+	}
+	return value
+}
+
+func sameValue(a, b interface{}) bool {
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			return ta.Equal(tb)
+		}
+	}
+	return reflect.DeepEqual(a, b) || fmt.Sprint(a) == fmt.Sprint(b)
+}
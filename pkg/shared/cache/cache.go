@@ -4,14 +4,29 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/rand"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+
+	"github.com/liquorpro/go-backend/pkg/shared/config"
 )
 
 // Cache wraps Redis client
 type Cache struct {
 	client *redis.Client
+	ttls   TTLs
+
+	// hits and misses count Get lookups, for the cache metrics
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// Stats holds how many Get lookups found their key and how many missed
+type Stats struct {
+	Hits   int64
+	Misses int64
 }
 
 // Config holds cache configuration
@@ -20,6 +35,50 @@ type Config struct {
 	Port     int
 	Password string
 	DB       int
+	TTLs     TTLs // unset TTLs use DefaultTTLs
+}
+
+// TTLs are how long each kind of cached read is kept
+type TTLs struct {
+	Dashboard      time.Duration
+	Categories     time.Duration
+	Vendors        time.Duration
+	ExpenseSummary time.Duration
+}
+
+// DefaultTTLs apply to any TTL that is not configured
+var DefaultTTLs = TTLs{
+	Dashboard:      5 * time.Minute,
+	Categories:     5 * time.Minute,
+	Vendors:        5 * time.Minute,
+	ExpenseSummary: 10 * time.Minute,
+}
+
+// NewTTLs converts the configured TTLs from seconds. Values that are not
+// positive keep their defaults.
+func NewTTLs(cfg config.CacheConfig) TTLs {
+	return TTLs{
+		Dashboard:      time.Duration(cfg.DashboardTTL) * time.Second,
+		Categories:     time.Duration(cfg.CategoryTTL) * time.Second,
+		Vendors:        time.Duration(cfg.VendorTTL) * time.Second,
+		ExpenseSummary: time.Duration(cfg.ExpenseSummaryTTL) * time.Second,
+	}.withDefaults()
+}
+
+func (t TTLs) withDefaults() TTLs {
+	if t.Dashboard <= 0 {
+		t.Dashboard = DefaultTTLs.Dashboard
+	}
+	if t.Categories <= 0 {
+		t.Categories = DefaultTTLs.Categories
+	}
+	if t.Vendors <= 0 {
+		t.Vendors = DefaultTTLs.Vendors
+	}
+	if t.ExpenseSummary <= 0 {
+		t.ExpenseSummary = DefaultTTLs.ExpenseSummary
+	}
+	return t
 }
 
 // NewCache creates a new Redis cache client
@@ -36,7 +95,17 @@ func NewCache(config Config) (*Cache, error) {
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
-	return &Cache{client: client}, nil
+	return &Cache{client: client, ttls: config.TTLs.withDefaults()}, nil
+}
+
+// NewCacheWithClient wraps an existing Redis client without checking the connection
+func NewCacheWithClient(client *redis.Client, ttls TTLs) *Cache {
+	return &Cache{client: client, ttls: ttls.withDefaults()}
+}
+
+// TTLs returns how long each kind of cached read is kept
+func (c *Cache) TTLs() TTLs {
+	return c.ttls
 }
 
 // Set stores a key-value pair with expiration
@@ -54,10 +123,12 @@ func (c *Cache) Get(ctx context.Context, key string, dest interface{}) error {
 	result := c.client.Get(ctx, key)
 	if err := result.Err(); err != nil {
 		if err == redis.Nil {
+			c.misses.Add(1)
 			return ErrCacheMiss
 		}
 		return fmt.Errorf("failed to get cache key %s: %w", key, err)
 	}
+	c.hits.Add(1)
 
 	data, err := result.Bytes()
 	if err != nil {
@@ -71,6 +142,32 @@ func (c *Cache) Get(ctx context.Context, key string, dest interface{}) error {
 	return nil
 }
 
+// Take retrieves a value by key and deletes it in one step, so that only one caller
+// can ever read it
+func (c *Cache) Take(ctx context.Context, key string, dest interface{}) error {
+	data, err := c.client.GetDel(ctx, key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return ErrCacheMiss
+		}
+		return fmt.Errorf("failed to take cache key %s: %w", key, err)
+	}
+
+	if err := json.Unmarshal(data, dest); err != nil {
+		return fmt.Errorf("failed to unmarshal cache data: %w", err)
+	}
+
+	return nil
+}
+
+// Stats returns the Get hit and miss counts since the cache was created
+func (c *Cache) Stats() Stats {
+	return Stats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+	}
+}
+
 // Delete removes a key from cache
 func (c *Cache) Delete(ctx context.Context, keys ...string) error {
 	return c.client.Del(ctx, keys...).Err()
@@ -185,6 +282,54 @@ func (c *Cache) Unlock(ctx context.Context, key string) error {
 	return c.client.Del(ctx, "lock:"+key).Err()
 }
 
+// slidingWindowScript admits a request when fewer than limit requests were admitted
+// in the trailing window. It returns {1, 0} when admitted, or {0, ms} with the time
+// until the oldest request in the window expires.
+var slidingWindowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+if redis.call('ZCARD', KEYS[1]) < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[4])
+	redis.call('PEXPIRE', KEYS[1], window)
+	return {1, 0}
+end
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+return {0, tonumber(oldest[2]) + window - now}
+`)
+
+// Allow records a request against a sliding window rate limit and reports whether
+// it is within limit requests per window. When it is not, retryAfter is how long
+// until a request would be admitted.
+func (c *Cache) Allow(ctx context.Context, key string, limit int, window time.Duration) (allowed bool, retryAfter time.Duration, err error) {
+	now := time.Now()
+	member := fmt.Sprintf("%d-%d", now.UnixNano(), rand.Int63())
+	result, err := slidingWindowScript.Run(ctx, c.client, []string{"ratelimit:" + key},
+		now.UnixMilli(), window.Milliseconds(), limit, member).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	if len(result) != 2 {
+		return false, 0, fmt.Errorf("unexpected rate limit result: %v", result)
+	}
+	return result[0] == 1, time.Duration(result[1]) * time.Millisecond, nil
+}
+
+// DashboardVersion returns the tenant's current dashboard cache generation. Cached
+// dashboards embed it in their key, so InvalidateDashboard expires every variant
+// (per shop and per shop scope) at once.
+func (c *Cache) DashboardVersion(ctx context.Context, tenantID string) int64 {
+	var version int64
+	c.Get(ctx, fmt.Sprintf(DashboardVersionKey, tenantID), &version)
+	return version
+}
+
+// InvalidateDashboard drops all cached dashboards for the tenant
+func (c *Cache) InvalidateDashboard(ctx context.Context, tenantID string) {
+	c.Increment(ctx, fmt.Sprintf(DashboardVersionKey, tenantID))
+}
+
 // Custom errors
 var (
 	ErrCacheMiss = fmt.Errorf("cache miss")
@@ -198,10 +343,14 @@ const (
 	StockKey          = "stock:%s:%s" // shop:product
 	DailySalesKey     = "daily_sales:%s:%s" // shop:date
 	PendingApprovalsKey = "pending_approvals:%s" // user_id
+	PasswordResetKey     = "password_reset:%s"      // token
+	PasswordResetUserKey = "password_reset:user:%s" // user_id
+	DashboardVersionKey  = "dashboard_summary:%s:version" // tenant_id
 	
 	// Cache durations
 	DefaultTTL       = 1 * time.Hour
 	SessionTTL       = 24 * time.Hour
 	ShortTTL         = 15 * time.Minute
 	LongTTL          = 24 * time.Hour
+	PasswordResetTTL = 30 * time.Minute
 )
\ No newline at end of file
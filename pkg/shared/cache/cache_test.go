@@ -0,0 +1,35 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liquorpro/go-backend/pkg/shared/config"
+)
+
+// The configured TTLs are seconds. A bare number of seconds passed to Redis as a
+// time.Duration is nanoseconds, which Redis rounds up to a millisecond, so the
+// services cache through these durations and never through the raw config.
+func TestNewTTLsConvertsSeconds(t *testing.T) {
+	cases := []struct {
+		name string
+		cfg  config.CacheConfig
+		ttls TTLs
+	}{
+		{"Default Seconds", config.CacheConfig{DashboardTTL: 300, CategoryTTL: 300, VendorTTL: 300, ExpenseSummaryTTL: 600},
+			TTLs{Dashboard: 5 * time.Minute, Categories: 5 * time.Minute, Vendors: 5 * time.Minute, ExpenseSummary: 10 * time.Minute}},
+		{"Custom Seconds", config.CacheConfig{DashboardTTL: 60, CategoryTTL: 3600, VendorTTL: 900, ExpenseSummaryTTL: 1800},
+			TTLs{Dashboard: time.Minute, Categories: time.Hour, Vendors: 15 * time.Minute, ExpenseSummary: 30 * time.Minute}},
+		{"Unset Uses Defaults", config.CacheConfig{}, DefaultTTLs},
+		{"Negative Uses Defaults", config.CacheConfig{DashboardTTL: -1, ExpenseSummaryTTL: -600, CategoryTTL: 120},
+			TTLs{Dashboard: 5 * time.Minute, Categories: 2 * time.Minute, Vendors: 5 * time.Minute, ExpenseSummary: 10 * time.Minute}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.ttls, NewTTLs(tc.cfg))
+		})
+	}
+}
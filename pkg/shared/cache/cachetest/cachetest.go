@@ -0,0 +1,110 @@
+// Package cachetest provides a cache for tests that records what is written to
+// it instead of talking to Redis. Every lookup misses.
+package cachetest
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/liquorpro/go-backend/pkg/shared/cache"
+)
+
+// Recorder remembers the expiration of every key written through its cache
+type Recorder struct {
+	mu   sync.Mutex
+	ttls map[string]time.Duration
+}
+
+// New returns a cache using the given TTLs and the recorder of its writes
+func New(ttls cache.TTLs) (*cache.Cache, *Recorder) {
+	recorder := &Recorder{ttls: make(map[string]time.Duration)}
+	client := redis.NewClient(&redis.Options{Addr: "cachetest:0"})
+	client.AddHook(recorder)
+	return cache.NewCacheWithClient(client, ttls), recorder
+}
+
+// TTL returns the expiration the key was last written with. Keys written without
+// one report zero.
+func (r *Recorder) TTL(key string) (time.Duration, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ttl, ok := r.ttls[key]
+	return ttl, ok
+}
+
+// TTLWithPrefix returns the expiration of the one key written that starts with
+// prefix, for keys that embed values such as dates
+func (r *Recorder) TTLWithPrefix(prefix string) (time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var found []string
+	for key := range r.ttls {
+		if strings.HasPrefix(key, prefix) {
+			found = append(found, key)
+		}
+	}
+	if len(found) != 1 {
+		return 0, fmt.Errorf("%d keys written with prefix %s: %v", len(found), prefix, found)
+	}
+	return r.ttls[found[0]], nil
+}
+
+// DialHook is part of redis.Hook; the recorder never dials
+func (r *Recorder) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook answers every command itself: SETs are recorded, GETs miss and
+// everything else succeeds with a zero result
+func (r *Recorder) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		switch cmd.Name() {
+		case "set":
+			r.recordSet(cmd.Args())
+		case "get", "getdel":
+			cmd.SetErr(redis.Nil)
+			return redis.Nil
+		}
+		return nil
+	}
+}
+
+// ProcessPipelineHook is part of redis.Hook; pipelines succeed without effect
+func (r *Recorder) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		return nil
+	}
+}
+
+// recordSet reads the key and expiration from SET key value [EX seconds | PX milliseconds]
+func (r *Recorder) recordSet(args []interface{}) {
+	if len(args) < 3 {
+		return
+	}
+	key := fmt.Sprint(args[1])
+
+	var ttl time.Duration
+	for i := 3; i+1 < len(args); i++ {
+		n, err := strconv.ParseInt(fmt.Sprint(args[i+1]), 10, 64)
+		if err != nil {
+			continue
+		}
+		switch strings.ToLower(fmt.Sprint(args[i])) {
+		case "ex":
+			ttl = time.Duration(n) * time.Second
+		case "px":
+			ttl = time.Duration(n) * time.Millisecond
+		}
+	}
+
+	r.mu.Lock()
+	r.ttls[key] = ttl
+	r.mu.Unlock()
+}
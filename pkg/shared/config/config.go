@@ -12,9 +12,18 @@ type Config struct {
 	Server   ServerConfig   `mapstructure:"server"`
 	Database DatabaseConfig `mapstructure:"database"`
 	Redis    RedisConfig    `mapstructure:"redis"`
+	Cache    CacheConfig    `mapstructure:"cache"`
 	JWT      JWTConfig      `mapstructure:"jwt"`
 	App      AppConfig      `mapstructure:"app"`
 	Services ServicesConfig `mapstructure:"services"`
+	Email    EmailConfig    `mapstructure:"email"`
+	Finance   FinanceConfig   `mapstructure:"finance"`
+	Inventory InventoryConfig `mapstructure:"inventory"`
+	Razorpay  RazorpayConfig  `mapstructure:"razorpay"`
+	Billing   BillingConfig   `mapstructure:"billing"`
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	RequestLimits RequestLimitConfig `mapstructure:"request_limits"`
+	Storage   StorageConfig   `mapstructure:"storage"`
 }
 
 // ServerConfig holds server configuration
@@ -46,12 +55,89 @@ type RedisConfig struct {
 	DB       int    `mapstructure:"db"`
 }
 
+// CacheConfig holds how long cached reads are kept, in seconds, and when tenant
+// dashboards are warmed ahead of peak hours
+type CacheConfig struct {
+	DashboardTTL      int      `mapstructure:"dashboard_ttl"`       // seconds a dashboard summary is served from cache
+	CategoryTTL       int      `mapstructure:"category_ttl"`        // seconds product and expense category lists are cached
+	VendorTTL         int      `mapstructure:"vendor_ttl"`          // seconds vendor lists are cached
+	ExpenseSummaryTTL int      `mapstructure:"expense_summary_ttl"` // seconds expense summaries are cached
+	WarmDashboards    bool     `mapstructure:"warm_dashboards"`     // pre-populate active tenants' dashboards before peak hours
+	WarmTimes         []string `mapstructure:"warm_times"`          // HH:MM in each tenant's timezone to warm dashboards at
+}
+
 // JWTConfig holds JWT configuration
 type JWTConfig struct {
 	Secret          string `mapstructure:"secret"`
 	ExpirationHours int    `mapstructure:"expiration_hours"`
 	RefreshHours    int    `mapstructure:"refresh_hours"`
 	Issuer          string `mapstructure:"issuer"`
+	TOTPKey         string `mapstructure:"totp_key"` // encrypts stored TOTP secrets; falls back to Secret when empty
+}
+
+// EmailConfig holds outgoing mail (SMTP) configuration
+type EmailConfig struct {
+	Host     string `mapstructure:"host"` // empty host logs emails instead of sending
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+	FromName string `mapstructure:"from_name"`
+}
+
+// FinanceConfig holds finance calculation settings
+type FinanceConfig struct {
+	RoundingPlaces           int     `mapstructure:"rounding_places"`
+	RoundingMode             string  `mapstructure:"rounding_mode"`              // half_up, down, up
+	NetAmountTolerance       float64 `mapstructure:"net_amount_tolerance"`       // max allowed client/server net difference
+	OverdueSweepInterval     int     `mapstructure:"overdue_sweep_interval"`     // seconds between overdue collection sweeps; 0 disables
+	RecurringExpenseInterval int     `mapstructure:"recurring_expense_interval"` // seconds between recurring expense runs; 0 disables
+}
+
+// InventoryConfig holds inventory document settings
+type InventoryConfig struct {
+	TransferRefPrefix  string `mapstructure:"transfer_ref_prefix"`
+	TransferRefPadding int    `mapstructure:"transfer_ref_padding"` // zero-padded width of the sequence number
+}
+
+// RazorpayConfig holds payment gateway settings
+type RazorpayConfig struct {
+	WebhookSecret string `mapstructure:"webhook_secret"` // signs webhook payloads; webhooks are rejected while empty
+}
+
+// BillingConfig holds the currencies SaaS billing reports in and how failed
+// subscription payments are chased. Plans, subscriptions, payments and invoices
+// each carry their own currency; analytics convert them to the base currency with
+// the rate table.
+type BillingConfig struct {
+	BaseCurrency     string             `mapstructure:"base_currency"`      // ISO 4217 code totals are reported in
+	CurrencyRates    map[string]float64 `mapstructure:"currency_rates"`     // base currency units per unit of each currency; amounts in a currency without a rate are reported per currency only
+	DunningRetryDays []int              `mapstructure:"dunning_retry_days"` // days after a payment fails to retry it; empty disables retries
+	DunningGraceDays int                `mapstructure:"dunning_grace_days"` // days a subscription stays past due after the last retry before it is cancelled
+	DunningInterval  int                `mapstructure:"dunning_interval"`   // seconds between dunning runs; 0 disables
+}
+
+// RateLimitConfig holds gateway rate limiting settings. Requests are counted per
+// tenant, or per client IP before login.
+type RateLimitConfig struct {
+	Enabled           bool           `mapstructure:"enabled"`
+	RequestsPerMinute int            `mapstructure:"requests_per_minute"`
+	Routes            map[string]int `mapstructure:"routes"` // requests per minute by path prefix; the longest matching prefix wins
+}
+
+// RequestLimitConfig holds limits on the size of client submissions. Body sizes are
+// enforced at the gateway; item counts by the services that accept the documents.
+type RequestLimitConfig struct {
+	MaxBodySize int64            `mapstructure:"max_body_size"` // bytes allowed per request body; 0 disables
+	Routes      map[string]int64 `mapstructure:"routes"`        // body bytes by path prefix; a "*" segment matches any one segment and the longest match wins
+	MaxItems    int              `mapstructure:"max_items"`     // line items allowed per document, such as a daily sales record; 0 disables
+}
+
+// StorageConfig holds where uploaded files, such as expense receipts, are kept
+type StorageConfig struct {
+	Driver        string `mapstructure:"driver"`          // local
+	LocalPath     string `mapstructure:"local_path"`      // root directory for the local driver
+	MaxUploadSize int64  `mapstructure:"max_upload_size"` // bytes allowed per uploaded file
 }
 
 // AppConfig holds application configuration
@@ -61,6 +147,8 @@ type AppConfig struct {
 	Environment string `mapstructure:"environment"`
 	Debug       bool   `mapstructure:"debug"`
 	LogLevel    string `mapstructure:"log_level"`
+
+	PasswordResetURL string `mapstructure:"password_reset_url"` // frontend page that accepts ?token=
 }
 
 // ServicesConfig holds microservices configuration
@@ -78,6 +166,13 @@ type ServiceConfig struct {
 	Host string `mapstructure:"host"`
 	Port int    `mapstructure:"port"`
 	URL  string `mapstructure:"url"`
+
+	// Gateway proxying to the service
+	Timeout          int `mapstructure:"timeout"`           // seconds per proxied request attempt
+	FailureThreshold int `mapstructure:"failure_threshold"` // consecutive failures that open the circuit breaker
+	Cooldown         int `mapstructure:"cooldown"`          // seconds the breaker stays open before a probe request
+	MaxRetries       int `mapstructure:"max_retries"`       // retries of failed GET requests
+	RetryBackoff     int `mapstructure:"retry_backoff"`     // milliseconds before the first retry, doubling after each
 }
 
 // LoadConfig loads configuration from file and environment variables
@@ -135,11 +230,87 @@ func setDefaults() {
 	viper.SetDefault("redis.password", "")
 	viper.SetDefault("redis.db", 0)
 
+	// Cache defaults
+	viper.SetDefault("cache.dashboard_ttl", 300)
+	viper.SetDefault("cache.category_ttl", 300)
+	viper.SetDefault("cache.vendor_ttl", 300)
+	viper.SetDefault("cache.expense_summary_ttl", 600)
+	viper.SetDefault("cache.warm_dashboards", false)
+	viper.SetDefault("cache.warm_times", []string{"17:55"})
+
 	// JWT defaults
 	viper.SetDefault("jwt.secret", "your-secret-key")
 	viper.SetDefault("jwt.expiration_hours", 24)
 	viper.SetDefault("jwt.refresh_hours", 168) // 7 days
 	viper.SetDefault("jwt.issuer", "liquorpro")
+	viper.SetDefault("jwt.totp_key", "")
+	viper.BindEnv("jwt.totp_key", "TOTP_ENCRYPTION_KEY")
+
+	// Email defaults
+	viper.SetDefault("email.host", "")
+	viper.SetDefault("email.port", 587)
+	viper.SetDefault("email.username", "")
+	viper.SetDefault("email.password", "")
+	viper.SetDefault("email.from", "no-reply@liquorpro.com")
+	viper.SetDefault("email.from_name", "LiquorPro")
+	viper.BindEnv("email.host", "EMAIL_HOST", "SMTP_HOST")
+	viper.BindEnv("email.port", "EMAIL_PORT", "SMTP_PORT")
+	viper.BindEnv("email.username", "EMAIL_USERNAME", "SMTP_USER")
+	viper.BindEnv("email.password", "EMAIL_PASSWORD", "SMTP_PASS")
+
+	// Finance defaults
+	viper.SetDefault("finance.rounding_places", 2)
+	viper.SetDefault("finance.rounding_mode", "half_up")
+	viper.SetDefault("finance.net_amount_tolerance", 1.0)
+	viper.SetDefault("finance.overdue_sweep_interval", 60)
+	viper.SetDefault("finance.recurring_expense_interval", 3600)
+
+	// Inventory defaults
+	viper.SetDefault("inventory.transfer_ref_prefix", "TRF")
+	viper.SetDefault("inventory.transfer_ref_padding", 6)
+
+	// Razorpay defaults
+	viper.SetDefault("razorpay.webhook_secret", "")
+	viper.BindEnv("razorpay.webhook_secret", "RAZORPAY_WEBHOOK_SECRET")
+
+	// Billing defaults
+	viper.SetDefault("billing.base_currency", "INR")
+	viper.SetDefault("billing.currency_rates", map[string]float64{})
+	viper.BindEnv("billing.base_currency", "BILLING_BASE_CURRENCY")
+	viper.SetDefault("billing.dunning_retry_days", []int{1, 3, 5, 7})
+	viper.SetDefault("billing.dunning_grace_days", 7)
+	viper.SetDefault("billing.dunning_interval", 3600)
+
+	// Rate limit defaults
+	viper.SetDefault("rate_limit.enabled", true)
+	viper.SetDefault("rate_limit.requests_per_minute", 600)
+	viper.SetDefault("rate_limit.routes", map[string]int{
+		"/api/auth/login":           20,
+		"/api/auth/forgot-password": 5,
+		"/api/auth/reset-password":  10,
+		"/api/sales/dashboard":      60,
+		"/api/sales/reports":        30,
+		"/api/inventory/reports":    30,
+		"/api/finance/dashboard":    60,
+		"/api/finance/reports":      30,
+	})
+
+	// Request limit defaults; uploads and imports get room for their files
+	viper.SetDefault("request_limits.max_body_size", 1<<20)
+	viper.SetDefault("request_limits.routes", map[string]int64{
+		"/api/inventory/products/import":         6 << 20,
+		"/api/inventory/stocks/transfers/import": 6 << 20,
+		"/api/finance/expenses/*/attachments":    11 << 20,
+		"/api/sales/images/upload":               11 << 20,
+	})
+	viper.SetDefault("request_limits.max_items", 500)
+
+	// Storage defaults
+	viper.SetDefault("storage.driver", "local")
+	viper.SetDefault("storage.local_path", "./data/uploads")
+	viper.SetDefault("storage.max_upload_size", 10<<20)
+	viper.BindEnv("storage.driver", "STORAGE_DRIVER")
+	viper.BindEnv("storage.local_path", "STORAGE_LOCAL_PATH")
 
 	// App defaults
 	viper.SetDefault("app.name", "LiquorPro")
@@ -147,6 +318,8 @@ func setDefaults() {
 	viper.SetDefault("app.environment", "development")
 	viper.SetDefault("app.debug", true)
 	viper.SetDefault("app.log_level", "info")
+	viper.SetDefault("app.password_reset_url", "http://localhost:8095/reset-password")
+	viper.BindEnv("app.password_reset_url", "PASSWORD_RESET_URL")
 
 	// Services defaults
 	viper.SetDefault("services.gateway.host", "localhost")
@@ -161,6 +334,13 @@ func setDefaults() {
 	viper.SetDefault("services.finance.port", 8094)
 	viper.SetDefault("services.frontend.host", "localhost")
 	viper.SetDefault("services.frontend.port", 8095)
+	for _, service := range []string{"auth", "sales", "inventory", "finance", "frontend"} {
+		viper.SetDefault("services."+service+".timeout", 30)
+		viper.SetDefault("services."+service+".failure_threshold", 5)
+		viper.SetDefault("services."+service+".cooldown", 30)
+		viper.SetDefault("services."+service+".max_retries", 2)
+		viper.SetDefault("services."+service+".retry_backoff", 200)
+	}
 }
 
 // GetDatabaseConnectionString returns the database connection string
@@ -67,6 +67,10 @@ func (db *DB) Migrate() error {
 		return fmt.Errorf("failed to create indexes: %w", err)
 	}
 
+	if err := models.BackfillProductGroups(db.DB); err != nil {
+		return fmt.Errorf("failed to backfill product groups: %w", err)
+	}
+
 	log.Println("Database migrations completed successfully")
 	return nil
 }
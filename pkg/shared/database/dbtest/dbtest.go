@@ -0,0 +1,28 @@
+// Package dbtest provides databases for tests that need no running server
+package dbtest
+
+import (
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/liquorpro/go-backend/pkg/shared/database"
+)
+
+// DryRun returns a database that builds its queries without running them, so
+// every query finds nothing. It suits tests of what a service does with an empty
+// result, such as what it caches.
+func DryRun(t testing.TB) *database.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=dry-run"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("failed to open dry run database: %v", err)
+	}
+	return &database.DB{DB: db}
+}
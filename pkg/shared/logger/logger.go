@@ -18,6 +18,14 @@ var (
 	Sugar *zap.SugaredLogger
 )
 
+// RequestIDHeader carries a request's ID between the gateway and the services, so
+// every service a request passes through logs it under the same ID
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey holds the request ID in a request's context.Context. It is the
+// key WithContext has always read.
+const requestIDKey = "request_id"
+
 // Config holds logger configuration
 type Config struct {
 	Level       string `json:"level"`
@@ -35,7 +43,10 @@ func Initialize(cfg Config) error {
 		config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 	} else {
 		config = zap.NewDevelopmentConfig()
-		config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		// JSON in every environment, so request logs can be searched by correlation ID
+		config.Encoding = "json"
+		config.EncoderConfig.TimeKey = "timestamp"
+		config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 	}
 	
 	// Set log level
@@ -81,8 +92,8 @@ func WithContext(ctx context.Context) *zap.Logger {
 	logger := Logger
 	
 	// Add common context fields
-	if requestID := ctx.Value("request_id"); requestID != nil {
-		logger = logger.With(zap.String("request_id", requestID.(string)))
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		logger = logger.With(zap.String("request_id", requestID))
 	}
 	
 	if userID := ctx.Value("user_id"); userID != nil {
@@ -96,6 +107,17 @@ func WithContext(ctx context.Context) *zap.Logger {
 	return logger
 }
 
+// ContextWithRequestID returns a copy of ctx carrying the request ID
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, or "" if none
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
 // WithGinContext returns a logger with Gin context fields
 func WithGinContext(c *gin.Context) *zap.Logger {
 	if Logger == nil {
@@ -138,8 +160,6 @@ func LogRequest(c *gin.Context) {
 	
 	// Log after request is processed
 	latency := time.Since(start)
-	clientIP := c.ClientIP()
-	method := c.Request.Method
 	statusCode := c.Writer.Status()
 	errorMessage := c.Errors.ByType(gin.ErrorTypePrivate).String()
 	
@@ -150,11 +170,18 @@ func LogRequest(c *gin.Context) {
 	logger := WithGinContext(c)
 	
 	fields := []zap.Field{
+		zap.String("uri", path),
 		zap.Int("status", statusCode),
 		zap.Duration("latency", latency),
 		zap.String("user_agent", c.Request.UserAgent()),
 	}
 	
+	// The request ID is shared by every service the request passed through, so it
+	// correlates this entry with the gateway's and other services' entries
+	if requestID := c.GetString("request_id"); requestID != "" {
+		fields = append(fields, zap.String("correlation_id", requestID))
+	}
+	
 	if errorMessage != "" {
 		fields = append(fields, zap.String("error", errorMessage))
 	}
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/audit"
+)
+
+// maxAuditedResponse caps how much of a create response is kept to find the new ID
+const maxAuditedResponse = 64 << 10
+
+// WriteAuditMiddleware records successful create, update and delete requests in the
+// tenant's audit log, with the fields they changed. tables maps the resource path
+// segments of the routes it wraps to their tables (see audit.ResolveWrite).
+func WriteAuditMiddleware(logger *audit.Logger, tables map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			c.Next()
+			return
+		}
+
+		tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+		if err != nil || logger == nil {
+			c.Next()
+			return
+		}
+
+		entityType, table, action := audit.ResolveWrite(c.Request.Method, c.FullPath(), tables)
+		entityID, _ := uuid.Parse(c.Param("id"))
+		before := logger.Snapshot(c.Request.Context(), table, tenantID, entityID)
+
+		// Creates are only addressable through the ID in their response
+		var recorder *auditResponseRecorder
+		if entityID == uuid.Nil {
+			recorder = &auditResponseRecorder{ResponseWriter: c.Writer}
+			c.Writer = recorder
+		}
+
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusBadRequest || IsIdempotentReplay(c) {
+			return
+		}
+		if recorder != nil {
+			entityID = recorder.createdID()
+		}
+
+		entry := audit.WriteEntry{
+			TenantID:   tenantID,
+			Action:     action,
+			EntityType: entityType,
+			EntityID:   entityID,
+			Method:     c.Request.Method,
+			Path:       c.Request.URL.Path,
+			ClientIP:   c.ClientIP(),
+		}
+		if userID, err := uuid.Parse(c.GetString("user_id")); err == nil {
+			entry.UserID = &userID
+		}
+		if after := logger.Snapshot(c.Request.Context(), table, tenantID, entityID); before != nil || after != nil {
+			entry.Changes = audit.Diff(before, after)
+		}
+
+		if err := logger.RecordWrite(c.Request.Context(), entry); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+}
+
+// auditResponseRecorder keeps the start of a response body so the ID of a created
+// entity can be read from it
+type auditResponseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *auditResponseRecorder) Write(data []byte) (int, error) {
+	w.keep(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *auditResponseRecorder) WriteString(s string) (int, error) {
+	w.keep([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *auditResponseRecorder) keep(data []byte) {
+	if room := maxAuditedResponse - w.body.Len(); room > 0 {
+		if len(data) > room {
+			data = data[:room]
+		}
+		w.body.Write(data)
+	}
+}
+
+// createdID reads the top-level "id" of a JSON response
+func (w *auditResponseRecorder) createdID() uuid.UUID {
+	var response struct {
+		ID uuid.UUID `json:"id"`
+	}
+	if err := json.Unmarshal(w.body.Bytes(), &response); err != nil {
+		return uuid.Nil
+	}
+	return response.ID
+}
@@ -7,8 +7,13 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/audit"
 	"github.com/liquorpro/go-backend/pkg/shared/cache"
 	"github.com/liquorpro/go-backend/pkg/shared/config"
+	"github.com/liquorpro/go-backend/pkg/shared/permissions"
+	"github.com/liquorpro/go-backend/pkg/shared/scope"
+	"github.com/liquorpro/go-backend/pkg/shared/settings"
 	"github.com/liquorpro/go-backend/pkg/shared/utils"
 )
 
@@ -113,7 +118,8 @@ func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 		}
 
 		for _, role := range allowedRoles {
-			if userRole == role {
+			// Regional managers hold manager permissions within their shop group
+			if userRole == role || (userRole == "regional_manager" && role == "manager") {
 				c.Next()
 				return
 			}
@@ -124,32 +130,138 @@ func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 	}
 }
 
-// PermissionMiddleware checks specific permissions
-func PermissionMiddleware(requiredPermission string) gin.HandlerFunc {
+// ShopScopeMiddleware resolves the shops the caller may see and stores the scope on
+// the request context for services to apply to their queries (see scope.FromContext).
+// A shop_id query parameter outside the scope is rejected.
+func ShopScopeMiddleware(resolver *scope.Resolver) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		permissions, exists := c.Get("permissions")
-		if !exists {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Permissions not found"})
+		tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Tenant ID required"})
+			c.Abort()
+			return
+		}
+		userID, err := uuid.Parse(c.GetString("user_id"))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
 			c.Abort()
 			return
 		}
 
-		permList, ok := permissions.([]interface{})
-		if !ok {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid permissions format"})
+		shopScope, err := resolver.Resolve(c.Request.Context(), tenantID, userID, c.GetString("role"))
+		if err != nil {
+			if strings.HasPrefix(err.Error(), "failed to") {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			} else {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			}
 			c.Abort()
 			return
 		}
 
-		for _, perm := range permList {
-			if permStr, ok := perm.(string); ok && permStr == requiredPermission {
-				c.Next()
+		if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+			if shopID, err := uuid.Parse(shopIDStr); err == nil && !shopScope.Allows(shopID) {
+				c.JSON(http.StatusForbidden, gin.H{"error": scope.ErrShopNotAllowed.Error()})
+				c.Abort()
 				return
 			}
 		}
 
-		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
-		c.Abort()
+		c.Set("shop_scope", shopScope)
+		c.Request = c.Request.WithContext(scope.WithShopScope(c.Request.Context(), shopScope))
+		c.Next()
+	}
+}
+
+// PermissionMiddleware checks that the caller's role holds a permission in the
+// tenant's role-permission matrix (see permissions.Catalog)
+func PermissionMiddleware(permissionService *permissions.Service, requiredPermission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userRole := c.GetString("role")
+		if userRole == "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Role not found"})
+			c.Abort()
+			return
+		}
+
+		tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Tenant ID required"})
+			c.Abort()
+			return
+		}
+
+		if !permissionService.Has(c.Request.Context(), tenantID, userRole, requiredPermission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ApproverMiddleware checks the caller's role against the tenant's configured
+// approvers for an entity type (see settings.ApprovalEntities)
+func ApproverMiddleware(settingsService *settings.Service, entity string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userRole := c.GetString("role")
+		if userRole == "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Role not found"})
+			c.Abort()
+			return
+		}
+
+		tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Tenant ID required"})
+			c.Abort()
+			return
+		}
+
+		if !settingsService.CanApprove(c.Request.Context(), tenantID, entity, userRole) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ReadAuditMiddleware records successful reads of a sensitive resource in the audit
+// log when the tenant has enabled read auditing for it
+func ReadAuditMiddleware(auditor *audit.ReadAuditor, resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+
+		tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+		if err != nil || !auditor.Enabled(c.Request.Context(), tenantID, resource) {
+			return
+		}
+
+		access := audit.ReadAccess{
+			TenantID: tenantID,
+			Resource: resource,
+			Method:   c.Request.Method,
+			Path:     c.Request.URL.Path,
+			Query:    c.Request.URL.RawQuery,
+			ClientIP: c.ClientIP(),
+		}
+		if userID, err := uuid.Parse(c.GetString("user_id")); err == nil {
+			access.UserID = &userID
+		}
+		if entityID, err := uuid.Parse(c.Param("id")); err == nil {
+			access.EntityID = entityID
+		}
+
+		if err := auditor.RecordRead(c.Request.Context(), access); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
 	}
 }
 
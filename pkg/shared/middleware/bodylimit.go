@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liquorpro/go-backend/pkg/shared/config"
+)
+
+// BodySizeLimitMiddleware refuses request bodies larger than the limit configured
+// for the path with 413 Request Entity Too Large. A path matching one of the
+// configured route patterns gets that route's limit instead of the default; a
+// limit of zero or less lets any size through. Bodies of unknown length are read
+// up to the limit, so nothing larger is ever held in memory.
+func BodySizeLimitMiddleware(cfg config.RequestLimitConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := bodyLimitFor(cfg, c.Request.URL.Path)
+		if limit <= 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > limit {
+			abortTooLarge(c, limit)
+			return
+		}
+
+		if c.Request.ContentLength < 0 {
+			body, err := io.ReadAll(io.LimitReader(c.Request.Body, limit+1))
+			c.Request.Body.Close()
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+				c.Abort()
+				return
+			}
+			if int64(len(body)) > limit {
+				abortTooLarge(c, limit)
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			c.Request.ContentLength = int64(len(body))
+		} else {
+			// Guards against a body longer than its declared length
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		}
+
+		c.Next()
+	}
+}
+
+// bodyLimitFor returns the body size limit that applies to a path. The longest
+// matching route pattern wins.
+func bodyLimitFor(cfg config.RequestLimitConfig, path string) int64 {
+	limit := cfg.MaxBodySize
+	longest := 0
+	for pattern, routeLimit := range cfg.Routes {
+		if len(pattern) > longest && matchesRoutePattern(path, pattern) {
+			limit, longest = routeLimit, len(pattern)
+		}
+	}
+	return limit
+}
+
+// matchesRoutePattern reports whether path is pattern or lies below it. A "*"
+// segment in the pattern matches any single path segment, such as an ID.
+func matchesRoutePattern(path, pattern string) bool {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(pathParts) < len(patternParts) {
+		return false
+	}
+	for i, part := range patternParts {
+		if part != "*" && part != pathParts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func abortTooLarge(c *gin.Context, limit int64) {
+	c.Header("Connection", "close")
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error":          fmt.Sprintf("Request body is too large; this endpoint accepts at most %s", formatBytes(limit)),
+		"max_body_bytes": limit,
+	})
+	c.Abort()
+}
+
+// formatBytes renders a byte count for error messages
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%d MB", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("%d KB", n>>10)
+	default:
+		return fmt.Sprintf("%d bytes", n)
+	}
+}
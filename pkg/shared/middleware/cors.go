@@ -23,10 +23,14 @@ func CORSMiddleware() gin.HandlerFunc {
 		"Authorization",
 		"X-Request-ID",
 		"X-Tenant-ID",
+		"Idempotency-Key",
+		"X-API-Version",
 	}
 	config.ExposeHeaders = []string{
 		"X-Request-ID",
 		"X-Total-Count",
+		"Idempotent-Replayed",
+		"X-API-Version",
 	}
 	config.AllowCredentials = true
 	
@@ -46,10 +50,14 @@ func ProductionCORSMiddleware(allowedOrigins []string) gin.HandlerFunc {
 		"Authorization",
 		"X-Request-ID",
 		"X-Tenant-ID",
+		"Idempotency-Key",
+		"X-API-Version",
 	}
 	config.ExposeHeaders = []string{
 		"X-Request-ID",
 		"X-Total-Count",
+		"Idempotent-Replayed",
+		"X-API-Version",
 	}
 	config.AllowCredentials = true
 	
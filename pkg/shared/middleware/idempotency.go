@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liquorpro/go-backend/pkg/shared/cache"
+)
+
+const (
+	// IdempotencyKeyHeader carries the client's key for a create request
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotencyWindow is how long a key's result is kept for replay
+	IdempotencyWindow = 24 * time.Hour
+
+	maxIdempotencyKeyLength = 255
+	// idempotencyLockTimeout bounds how long a key stays claimed by a request that
+	// never finishes
+	idempotencyLockTimeout = time.Minute
+	// idempotentReplayKey marks a replayed request in the gin context
+	idempotentReplayKey = "idempotent_replay"
+)
+
+// idempotentResponse is the stored result of the first request made with a key
+type idempotentResponse struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+	RequestHash string `json:"request_hash"`
+}
+
+// IdempotencyMiddleware makes a create endpoint safe to retry. When a request
+// carries an Idempotency-Key header, its successful response is kept in Redis for
+// IdempotencyWindow and a repeat of the key within the tenant gets that response
+// back instead of creating a second record. Failed requests are not kept, so they
+// can be retried with the same key. It should run after the tenant is known and
+// after permission checks. Requests without the header, and all requests when
+// Redis is unavailable, pass straight through.
+func IdempotencyMiddleware(cacheClient *cache.Cache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idempotencyKey := c.GetHeader(IdempotencyKeyHeader)
+		if idempotencyKey == "" {
+			c.Next()
+			return
+		}
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength)})
+			c.Abort()
+			return
+		}
+
+		tenantID := c.GetString("tenant_id")
+		if tenantID == "" {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		requestHash := hex.EncodeToString(sum[:])
+
+		ctx := c.Request.Context()
+		key := fmt.Sprintf("idempotency:%s:%s %s:%s", tenantID, c.Request.Method, c.FullPath(), idempotencyKey)
+
+		if replayIdempotent(c, cacheClient, key, requestHash) {
+			return
+		}
+
+		// Claim the key so a concurrent repeat cannot create a second record while
+		// the first is still in flight
+		claimed, err := cacheClient.Lock(ctx, key, idempotencyLockTimeout)
+		if err != nil {
+			log.Printf("Idempotency lock failed for %s: %v", key, err)
+			c.Next()
+			return
+		}
+		if !claimed {
+			// The first request may have finished between the lookup and the claim
+			if replayIdempotent(c, cacheClient, key, requestHash) {
+				return
+			}
+			c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+			c.Abort()
+			return
+		}
+		defer cacheClient.Unlock(ctx, key)
+
+		recorder := &idempotencyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if status < http.StatusOK || status >= http.StatusMultipleChoices {
+			return
+		}
+		stored := idempotentResponse{
+			Status:      status,
+			ContentType: c.Writer.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+			RequestHash: requestHash,
+		}
+		if err := cacheClient.Set(ctx, key, stored, IdempotencyWindow); err != nil {
+			log.Printf("Failed to store idempotent response for %s: %v", key, err)
+		}
+	}
+}
+
+// IsIdempotentReplay reports whether the response to this request was replayed from
+// an earlier request with the same Idempotency-Key
+func IsIdempotentReplay(c *gin.Context) bool {
+	return c.GetBool(idempotentReplayKey)
+}
+
+// replayIdempotent writes the stored response for key, if there is one, and reports
+// whether the request has been answered
+func replayIdempotent(c *gin.Context, cacheClient *cache.Cache, key, requestHash string) bool {
+	var stored idempotentResponse
+	if err := cacheClient.Get(c.Request.Context(), key, &stored); err != nil {
+		if !errors.Is(err, cache.ErrCacheMiss) {
+			log.Printf("Idempotency lookup failed for %s: %v", key, err)
+		}
+		return false
+	}
+
+	if stored.RequestHash != requestHash {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+		c.Abort()
+		return true
+	}
+
+	c.Set(idempotentReplayKey, true)
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(stored.Status, stored.ContentType, stored.Body)
+	c.Abort()
+	return true
+}
+
+// idempotencyRecorder keeps a copy of the response body so it can be replayed
+type idempotencyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
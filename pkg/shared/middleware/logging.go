@@ -1,9 +1,8 @@
 package middleware
 
 import (
-	"time"
-
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/liquorpro/go-backend/pkg/shared/logger"
 )
 
@@ -12,31 +11,40 @@ func LoggingMiddleware() gin.HandlerFunc {
 	return logger.LogRequest
 }
 
-// RequestIDMiddleware adds unique request IDs
+// maxRequestIDLength bounds a client-supplied request ID
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware gives each request an ID, keeping the X-Request-ID the
+// caller sent (the gateway forwards its own to the services) or generating one.
+// The ID is echoed in the response and stored in both the gin context and the
+// request's context, so logs and outgoing calls can carry it.
 func RequestIDMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		requestID := c.GetHeader("X-Request-ID")
-		if requestID == "" {
-			// Generate a simple request ID (you might want to use UUID here)
-			requestID = generateRequestID()
+		requestID := c.GetHeader(logger.RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.New().String()
 		}
-		
-		c.Header("X-Request-ID", requestID)
+
+		c.Header(logger.RequestIDHeader, requestID)
 		c.Set("request_id", requestID)
-		
+		c.Request = c.Request.WithContext(logger.ContextWithRequestID(c.Request.Context(), requestID))
+
 		c.Next()
 	}
 }
 
-func generateRequestID() string {
-	return time.Now().Format("20060102150405") + "-" + randomString(6)
-}
-
-func randomString(n int) string {
-	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	result := make([]byte, n)
-	for i := range result {
-		result[i] = letters[time.Now().UnixNano()%int64(len(letters))]
+// validRequestID reports whether a client-supplied request ID is safe to log: not
+// empty, not too long and made only of letters, digits, '-', '_' and '.'
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range requestID {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
 	}
-	return string(result)
-}
\ No newline at end of file
+	return true
+}
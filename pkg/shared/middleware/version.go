@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+)
+
+// APIVersionMiddleware reads the API version a client asks for in the
+// X-API-Version header and answers with the version the service serves, so
+// clients can tell which response shapes they will get
+func APIVersionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		utils.SetRequestedAPIVersion(c, utils.ParseAPIVersion(c.GetHeader(utils.APIVersionHeader)))
+		c.Header(utils.APIVersionHeader, strconv.Itoa(utils.APIVersion))
+		c.Next()
+	}
+}
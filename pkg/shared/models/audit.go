@@ -0,0 +1,23 @@
+package models
+
+import "github.com/google/uuid"
+
+// Audit actions
+const (
+	AuditActionAutoApprove = "auto_approve"
+	AuditActionView        = "view"
+	AuditActionCreate      = "create"
+	AuditActionUpdate      = "update"
+	AuditActionDelete      = "delete"
+)
+
+// AuditLog records a significant action taken on a tenant's data
+type AuditLog struct {
+	TenantModel
+	UserID     *uuid.UUID `json:"user_id" gorm:"type:uuid;index"` // nil for system actions
+	User       *User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Action     string     `json:"action" gorm:"not null;index"`
+	EntityType string     `json:"entity_type" gorm:"not null"`
+	EntityID   uuid.UUID  `json:"entity_id" gorm:"type:uuid;not null"`
+	Details    string     `json:"details" gorm:"type:text"` // JSON-encoded context
+}
@@ -36,6 +36,7 @@ const (
 	StatusRejected = "rejected"
 	StatusActive   = "active"
 	StatusInactive = "inactive"
+	StatusVoided   = "voided"
 )
 
 // User roles
@@ -46,6 +47,8 @@ const (
 	RoleSalesman         = "salesman"
 	RoleAssistantManager = "assistant_manager"
 	RoleSaasAdmin        = "saas_admin"
+	RoleSuperAdmin       = "super_admin"
+	RoleRegionalManager  = "regional_manager"
 )
 
 // Payment methods
@@ -88,11 +88,20 @@ type VendorInvoice struct {
 	DueDate         time.Time `json:"due_date" gorm:"not null"`
 	
 	SubTotal        float64 `json:"sub_total" gorm:"not null"`
+	TaxRate         float64 `json:"tax_rate" gorm:"default:0"`
 	TaxAmount       float64 `json:"tax_amount" gorm:"default:0"`
+	CGSTAmount      float64 `json:"cgst_amount" gorm:"default:0"`
+	SGSTAmount      float64 `json:"sgst_amount" gorm:"default:0"`
+	IGSTAmount      float64 `json:"igst_amount" gorm:"default:0"`
 	TotalAmount     float64 `json:"total_amount" gorm:"not null"`
 	PaidAmount      float64 `json:"paid_amount" gorm:"default:0"`
 	DueAmount       float64 `json:"due_amount" gorm:"not null"`
 	
+	// Whether the bill came from another state. When either state was unknown the
+	// tenant's default treatment was used and the invoice is flagged for review.
+	InterState        bool `json:"inter_state" gorm:"default:false"`
+	GSTReviewRequired bool `json:"gst_review_required" gorm:"default:false"`
+	
 	Status          string `json:"status" gorm:"default:'pending'"` // pending, partial, paid, overdue
 	
 	// Relationships
@@ -214,18 +223,75 @@ type Expense struct {
 	ReceiptNo       string `json:"receipt_no"`
 	BillNumber      string `json:"bill_number"`
 	VendorName      string `json:"vendor_name"`
+
+	// Set when the expense was generated from a recurring expense
+	RecurringExpenseID *uuid.UUID `json:"recurring_expense_id" gorm:"type:uuid;index"`
 	
 	// Status and approval
 	Status          string     `json:"status" gorm:"default:'pending'"` // pending, approved, rejected
 	ApprovedAt      *time.Time `json:"approved_at"`
 	ApprovedByID    *uuid.UUID `json:"approved_by_id" gorm:"type:uuid"`
 	ApprovedBy      *User      `json:"approved_by,omitempty" gorm:"foreignKey:ApprovedByID"`
+	AutoApproved    bool       `json:"auto_approved" gorm:"default:false"` // at or below the tenant's approval threshold
+	RejectionReason string     `json:"rejection_reason"`
 	
 	// Created by
 	CreatedBy       *User     `json:"created_by,omitempty" gorm:"foreignKey:CreatedByID"`
 	CreatedByID     uuid.UUID `json:"created_by_id" gorm:"type:uuid;not null"`
 }
 
+// ExpenseAttachment is a receipt or bill file kept with an expense
+type ExpenseAttachment struct {
+	TenantModel
+	ExpenseID    uuid.UUID `json:"expense_id" gorm:"type:uuid;not null;index"`
+	Expense      *Expense  `json:"expense,omitempty" gorm:"foreignKey:ExpenseID"`
+	FileName     string    `json:"file_name" gorm:"not null"`
+	ContentType  string    `json:"content_type" gorm:"not null"`
+	Size         int64     `json:"size" gorm:"not null"`
+	StorageKey   string    `json:"-" gorm:"not null"`
+
+	// Uploaded by
+	UploadedByID uuid.UUID `json:"uploaded_by_id" gorm:"type:uuid;not null"`
+	UploadedBy   *User     `json:"uploaded_by,omitempty" gorm:"foreignKey:UploadedByID"`
+}
+
+// Recurring expense frequencies
+const (
+	RecurringDaily   = "daily"
+	RecurringWeekly  = "weekly"
+	RecurringMonthly = "monthly"
+)
+
+// RecurringExpense is a template for an expense paid on a schedule, such as rent,
+// salaries or licence fees. Each time NextRunDate comes round a pending Expense is
+// created from it and the schedule moves on.
+type RecurringExpense struct {
+	TenantModel
+	CategoryID      *uuid.UUID       `json:"category_id" gorm:"type:uuid"`
+	Category        *ExpenseCategory `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	ShopID          *uuid.UUID       `json:"shop_id" gorm:"type:uuid"`
+	Shop            *Shop            `json:"shop,omitempty" gorm:"foreignKey:ShopID"`
+	VendorID        *uuid.UUID       `json:"vendor_id" gorm:"type:uuid"`
+	Vendor          *Vendor          `json:"vendor,omitempty" gorm:"foreignKey:VendorID"`
+
+	Description     string  `json:"description" gorm:"not null"`
+	Amount          float64 `json:"amount" gorm:"not null"`
+	PaymentMethod   string  `json:"payment_method" gorm:"not null"`
+	Notes           string  `json:"notes"`
+
+	// Schedule
+	Frequency       string     `json:"frequency" gorm:"not null"` // daily, weekly, monthly
+	StartDate       time.Time  `json:"start_date" gorm:"not null"`
+	NextRunDate     time.Time  `json:"next_run_date" gorm:"not null;index"`
+	EndDate         *time.Time `json:"end_date"`
+	LastRunDate     *time.Time `json:"last_run_date"`
+	IsActive        bool       `json:"is_active" gorm:"default:true"`
+
+	// Created by
+	CreatedBy       *User     `json:"created_by,omitempty" gorm:"foreignKey:CreatedByID"`
+	CreatedByID     uuid.UUID `json:"created_by_id" gorm:"type:uuid;not null"`
+}
+
 // Assistant Manager Financial Models
 
 // MoneyCollection represents money collection by assistant managers (15-minute approval deadline)
@@ -247,7 +313,7 @@ type MoneyCollection struct {
 	// 15-minute approval deadline
 	CollectedAt        time.Time  `json:"collected_at" gorm:"not null"`
 	SubmittedAt        time.Time  `json:"submitted_at" gorm:"not null"`
-	DeadlineAt         time.Time  `json:"deadline_at" gorm:"not null"` // submitted_at + 15 minutes
+	DeadlineAt         time.Time  `json:"deadline_at" gorm:"not null"` // submitted_at + the tenant's approval deadline (default 15 minutes)
 	ApprovalDeadline   time.Time  `json:"approval_deadline" gorm:"not null"` // submitted_at + 15 minutes
 	
 	// Status and approval
@@ -256,6 +322,8 @@ type MoneyCollection struct {
 	ApprovedByID       *uuid.UUID `json:"approved_by_id" gorm:"type:uuid"`
 	ApprovedBy         *User      `json:"approved_by,omitempty" gorm:"foreignKey:ApprovedByID"`
 	ApprovedByUser     *User      `json:"approved_by_user,omitempty" gorm:"foreignKey:ApprovedByID"`
+	AutoApproved       bool       `json:"auto_approved" gorm:"default:false"`
+	Version            int        `json:"version" gorm:"not null;default:1"` // bumped on every change; stale approvals are refused
 	
 	// Created by
 	CreatedBy          uuid.UUID  `json:"created_by" gorm:"type:uuid;not null"`
@@ -263,6 +331,10 @@ type MoneyCollection struct {
 	
 	RejectionReason    string `json:"rejection_reason"`
 	
+	// Set when a collection above the executive's outstanding balance was accepted anyway
+	BalanceOverridden  bool   `json:"balance_overridden" gorm:"default:false"`
+	OverrideReason     string `json:"override_reason"`
+	
 	// Relationships
 	BankDeposits       []BankDeposit       `json:"bank_deposits,omitempty" gorm:"foreignKey:MoneyCollectionID"`
 	StockVerifications []StockVerification `json:"stock_verifications,omitempty" gorm:"foreignKey:MoneyCollectionID"`
@@ -287,11 +359,45 @@ type BankDeposit struct {
 	ApprovedByID       *uuid.UUID `json:"approved_by_id" gorm:"type:uuid"`
 	ApprovedBy         *User      `json:"approved_by,omitempty" gorm:"foreignKey:ApprovedByID"`
 	
+	// Reconciliation against the depositing assistant manager's collections
+	ReconciliationStatus string     `json:"reconciliation_status" gorm:"default:'unreconciled'"` // unreconciled, matched, excess
+	ReconciledAmount     float64    `json:"reconciled_amount" gorm:"default:0"`                  // part of the deposit matched to collections
+	ReconciledAt         *time.Time `json:"reconciled_at"`
+	
 	// Created by
 	CreatedByID        uuid.UUID `json:"created_by_id" gorm:"type:uuid;not null"`
 	CreatedBy          *User     `json:"created_by,omitempty" gorm:"foreignKey:CreatedByID"`
 }
 
+// CashDrawer is a shop's end-of-day cash count, reconciled against the cash its
+// approved sales and cash expenses say should be in the drawer
+type CashDrawer struct {
+	TenantModel
+	ShopID             uuid.UUID `json:"shop_id" gorm:"type:uuid;not null;index"`
+	Shop               *Shop     `json:"shop,omitempty" gorm:"foreignKey:ShopID"`
+	DrawerDate         time.Time `json:"drawer_date" gorm:"not null;index"` // start of the day counted
+	
+	// Expected cash is cash sales less cash expenses; the difference is counted
+	// less expected, so positive is over and negative is short
+	CashSales          float64 `json:"cash_sales" gorm:"not null"`
+	CashExpenses       float64 `json:"cash_expenses" gorm:"not null"`
+	ExpectedAmount     float64 `json:"expected_amount" gorm:"not null"`
+	CountedAmount      float64 `json:"counted_amount" gorm:"not null"`
+	Difference         float64 `json:"difference" gorm:"not null"`
+	Status             string  `json:"status" gorm:"not null"` // balanced, over, short
+	Notes              string  `json:"notes"`
+	
+	// Resolution of an over or short drawer
+	ResolvedAt         *time.Time `json:"resolved_at"`
+	ResolvedByID       *uuid.UUID `json:"resolved_by_id" gorm:"type:uuid"`
+	ResolvedBy         *User      `json:"resolved_by,omitempty" gorm:"foreignKey:ResolvedByID"`
+	ResolutionNotes    string     `json:"resolution_notes"`
+	
+	// Counted by
+	CountedByID        uuid.UUID `json:"counted_by_id" gorm:"type:uuid;not null"`
+	CountedBy          *User     `json:"counted_by,omitempty" gorm:"foreignKey:CountedByID"`
+}
+
 // StockVerification represents stock verification by assistant managers
 type StockVerification struct {
 	TenantModel
@@ -358,6 +464,15 @@ type AssistantManagerLedger struct {
 	CreatedBy          *User     `json:"created_by,omitempty" gorm:"foreignKey:CreatedByID"`
 }
 
+// AccountMapping maps one of our sources (payment method, expense category, ...) to a
+// ledger name in the tenant's external accounting software
+type AccountMapping struct {
+	TenantModel
+	MappingType string `json:"mapping_type" gorm:"not null"` // sales, purchases, payment_method, expense_category, vendor, vendor_adjustment
+	SourceKey   string `json:"source_key"`                   // payment method, category ID or vendor ID; empty for single-account types
+	AccountName string `json:"account_name" gorm:"not null"`
+}
+
 // AssistantManagerMoneyCollection alias for MoneyCollection (for backward compatibility)
 type AssistantManagerMoneyCollection = MoneyCollection
 
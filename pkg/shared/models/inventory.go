@@ -38,11 +38,14 @@ type Product struct {
 	BrandID      uuid.UUID `json:"brand_id" gorm:"type:uuid;not null"`
 	Brand        *Brand    `json:"brand,omitempty" gorm:"foreignKey:BrandID"`
 	Size         string    `json:"size"` // e.g., "750ml", "1L"
+	ProductGroupID *uuid.UUID `json:"product_group_id" gorm:"type:uuid;index"` // the other pack sizes of this product
 	AlcoholContent float64 `json:"alcohol_content"`
 	Description  string    `json:"description"`
 	Barcode      string    `json:"barcode"`
 	SKU          string    `json:"sku" gorm:"unique"`
 	IsActive     bool      `json:"is_active" gorm:"default:true"`
+	UnitsPerCase int       `json:"units_per_case" gorm:"default:1"` // bottles per case; stock is always held in units
+	CostingMethod string   `json:"costing_method" gorm:"default:'fifo'"` // default for new stock records: fifo, lifo, average
 	
 	// Pricing
 	CostPrice   float64 `json:"cost_price"`
@@ -68,6 +71,17 @@ type BrandPricing struct {
 	MRP          float64   `json:"mrp"`
 }
 
+// ProductGroup links the pack sizes of one product, e.g. the 180ml, 375ml and 750ml
+// bottles of a whisky. Products share a group when they have the same brand and
+// name, so each size in a group lines up with the brand's pricing for that size.
+type ProductGroup struct {
+	TenantModel
+	BrandID  uuid.UUID `json:"brand_id" gorm:"type:uuid;not null"`
+	Brand    *Brand    `json:"brand,omitempty" gorm:"foreignKey:BrandID"`
+	Name     string    `json:"name" gorm:"not null"`
+	Products []Product `json:"products,omitempty" gorm:"foreignKey:ProductGroupID"`
+}
+
 // Stock represents current inventory levels per shop
 type Stock struct {
 	TenantModel
@@ -141,6 +155,65 @@ type StockHistory struct {
 	CreatedBy   *User     `json:"created_by,omitempty" gorm:"foreignKey:CreatedByID"`
 }
 
+// Stock reservation statuses
+const (
+	ReservationActive   = "active"
+	ReservationReleased = "released"
+)
+
+// StockReservation holds units of a shop's stock for a pending document, such as a
+// sale or transfer awaiting approval, so no other document can claim them. The held
+// units are counted in the stock's ReservedQuantity until the reservation is released.
+type StockReservation struct {
+	TenantModel
+	StockID     uuid.UUID  `json:"stock_id" gorm:"type:uuid;not null;index"`
+	Stock       *Stock     `json:"stock,omitempty" gorm:"foreignKey:StockID"`
+	ShopID      uuid.UUID  `json:"shop_id" gorm:"type:uuid;not null"`
+	ProductID   uuid.UUID  `json:"product_id" gorm:"type:uuid;not null"`
+	Quantity    int        `json:"quantity" gorm:"not null"`
+	Reference   string     `json:"reference"`
+	ReferenceID uuid.UUID  `json:"reference_id" gorm:"type:uuid;not null;index"`
+	Status      string     `json:"status" gorm:"not null;default:'active'"` // active, released
+	ReleasedAt  *time.Time `json:"released_at"`
+	CreatedByID uuid.UUID  `json:"created_by_id" gorm:"type:uuid;not null"`
+}
+
+// Stock count statuses
+const (
+	StockCountInProgress = "in_progress"
+	StockCountFinalized  = "finalized"
+)
+
+// StockCount is a physical stocktake of a shop. Counted quantities can be saved over
+// several sittings; finalizing the count adjusts stock to match what was counted.
+type StockCount struct {
+	TenantModel
+	ShopID         uuid.UUID        `json:"shop_id" gorm:"type:uuid;not null;index"`
+	Shop           *Shop            `json:"shop,omitempty" gorm:"foreignKey:ShopID"`
+	Status         string           `json:"status" gorm:"not null;default:'in_progress'"` // in_progress, finalized
+	Notes          string           `json:"notes"`
+	FinalizedAt    *time.Time       `json:"finalized_at"`
+	FinalizedByID  *uuid.UUID       `json:"finalized_by_id" gorm:"type:uuid"`
+	ShrinkageValue float64          `json:"shrinkage_value" gorm:"default:0"` // cost of units found missing, set on finalization
+	SurplusValue   float64          `json:"surplus_value" gorm:"default:0"`   // cost of units found over, set on finalization
+	CreatedByID    uuid.UUID        `json:"created_by_id" gorm:"type:uuid;not null"`
+	CreatedBy      *User            `json:"created_by,omitempty" gorm:"foreignKey:CreatedByID"`
+	Items          []StockCountItem `json:"items,omitempty" gorm:"foreignKey:StockCountID"`
+}
+
+// StockCountItem is the counted quantity of one product. The system quantity and
+// unit cost are fixed when the count is finalized.
+type StockCountItem struct {
+	TenantModel
+	StockCountID    uuid.UUID `json:"stock_count_id" gorm:"type:uuid;not null;index"`
+	ProductID       uuid.UUID `json:"product_id" gorm:"type:uuid;not null"`
+	Product         *Product  `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	CountedQuantity int       `json:"counted_quantity" gorm:"not null"`
+	SystemQuantity  int       `json:"system_quantity" gorm:"default:0"`
+	UnitCost        float64   `json:"unit_cost" gorm:"default:0"`
+	Notes           string    `json:"notes"`
+}
+
 // StockPurchase represents purchase orders/receipts
 type StockPurchase struct {
 	TenantModel
@@ -212,4 +285,140 @@ type StockMovement struct {
 	Reference    string    `json:"reference"`
 	ReferenceID  *uuid.UUID `json:"reference_id" gorm:"type:uuid"`
 	Notes        string    `json:"notes"`
-}
\ No newline at end of file
+}
+// Write-off reasons
+const (
+	WriteOffExpired  = "expired"
+	WriteOffDamaged  = "damaged"
+	WriteOffBreakage = "breakage"
+	WriteOffTheft    = "theft"
+	WriteOffOther    = "other"
+)
+
+// StockWriteOff records stock to be removed as a loss. Stock is only removed and the
+// loss booked as an expense once the write-off is approved.
+type StockWriteOff struct {
+	TenantModel
+	ShopID       uuid.UUID   `json:"shop_id" gorm:"type:uuid;not null"`
+	Shop         *Shop       `json:"shop,omitempty" gorm:"foreignKey:ShopID"`
+	ProductID    uuid.UUID   `json:"product_id" gorm:"type:uuid;not null"`
+	Product      *Product    `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	StockID      uuid.UUID   `json:"stock_id" gorm:"type:uuid;not null"`
+	StockBatchID *uuid.UUID  `json:"stock_batch_id" gorm:"type:uuid"`
+	StockBatch   *StockBatch `json:"stock_batch,omitempty" gorm:"foreignKey:StockBatchID"`
+
+	Quantity  int     `json:"quantity" gorm:"not null"`
+	UnitCost  float64 `json:"unit_cost"`
+	TotalCost float64 `json:"total_cost"`
+	Reason    string  `json:"reason" gorm:"not null"` // expired, damaged, breakage, theft, other
+	Notes     string  `json:"notes"`
+
+	// Status and approval
+	Status          string     `json:"status" gorm:"default:'pending'"` // pending, approved, rejected
+	ApprovedAt      *time.Time `json:"approved_at"`
+	ApprovedByID    *uuid.UUID `json:"approved_by_id" gorm:"type:uuid"`
+	ApprovedBy      *User      `json:"approved_by,omitempty" gorm:"foreignKey:ApprovedByID"`
+	RejectionReason string     `json:"rejection_reason"`
+
+	// Expense booked for the loss on approval
+	ExpenseID *uuid.UUID `json:"expense_id" gorm:"type:uuid"`
+
+	// Created by
+	CreatedByID uuid.UUID `json:"created_by_id" gorm:"type:uuid;not null"`
+	CreatedBy   *User     `json:"created_by,omitempty" gorm:"foreignKey:CreatedByID"`
+}
+
+// StockTransfer is a request to move stock between two shops. Stock only moves
+// once the transfer is approved.
+type StockTransfer struct {
+	TenantModel
+	Reference    string    `json:"reference" gorm:"unique;not null"`
+	FromShopID   uuid.UUID `json:"from_shop_id" gorm:"type:uuid;not null"`
+	FromShop     *Shop     `json:"from_shop,omitempty" gorm:"foreignKey:FromShopID"`
+	ToShopID     uuid.UUID `json:"to_shop_id" gorm:"type:uuid;not null"`
+	ToShop       *Shop     `json:"to_shop,omitempty" gorm:"foreignKey:ToShopID"`
+	TransferDate time.Time `json:"transfer_date" gorm:"not null"`
+	Notes        string    `json:"notes"`
+
+	// Status and approval
+	Status          string     `json:"status" gorm:"default:'pending'"` // pending, approved, rejected
+	ApprovedAt      *time.Time `json:"approved_at"`
+	ApprovedByID    *uuid.UUID `json:"approved_by_id" gorm:"type:uuid"`
+	ApprovedBy      *User      `json:"approved_by,omitempty" gorm:"foreignKey:ApprovedByID"`
+	RejectionReason string     `json:"rejection_reason"`
+
+	// Requested by
+	CreatedByID uuid.UUID `json:"created_by_id" gorm:"type:uuid;not null"`
+	CreatedBy   *User     `json:"created_by,omitempty" gorm:"foreignKey:CreatedByID"`
+
+	Items []StockTransferItem `json:"items,omitempty" gorm:"foreignKey:StockTransferID"`
+}
+
+// StockTransferItem is a product and quantity on a stock transfer
+type StockTransferItem struct {
+	TenantModel
+	StockTransferID uuid.UUID `json:"stock_transfer_id" gorm:"type:uuid;not null;index"`
+	ProductID       uuid.UUID `json:"product_id" gorm:"type:uuid;not null"`
+	Product         *Product  `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	Quantity        int       `json:"quantity" gorm:"not null"`
+	UnitCost        float64   `json:"unit_cost"` // source cost, set when stock moves
+}
+
+// TaxRate is the GST percentage charged on a category's products or on one product.
+// A product's own rate overrides its category's.
+type TaxRate struct {
+	TenantModel
+	Name        string     `json:"name" gorm:"not null"` // e.g. "GST 18%"
+	CategoryID  *uuid.UUID `json:"category_id" gorm:"type:uuid;index"`
+	Category    *Category  `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	ProductID   *uuid.UUID `json:"product_id" gorm:"type:uuid;index"`
+	Product     *Product   `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	Percentage  float64    `json:"percentage" gorm:"not null"`
+	IsActive    bool       `json:"is_active" gorm:"default:true"`
+	CreatedByID uuid.UUID  `json:"created_by_id" gorm:"type:uuid;not null"`
+	CreatedBy   *User      `json:"created_by,omitempty" gorm:"foreignKey:CreatedByID"`
+}
+
+// ProductPriceHistory records a change to a product's prices
+type ProductPriceHistory struct {
+	TenantModel
+	ProductID       uuid.UUID `json:"product_id" gorm:"type:uuid;not null"`
+	Product         *Product  `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	OldCostPrice    float64   `json:"old_cost_price"`
+	NewCostPrice    float64   `json:"new_cost_price"`
+	OldSellingPrice float64   `json:"old_selling_price"`
+	NewSellingPrice float64   `json:"new_selling_price"`
+	OldMRP          float64   `json:"old_mrp"`
+	NewMRP          float64   `json:"new_mrp"`
+	Source          string    `json:"source"` // product_update, brand_pricing
+	ChangedByID     uuid.UUID `json:"changed_by_id" gorm:"type:uuid;not null"`
+	ChangedBy       *User     `json:"changed_by,omitempty" gorm:"foreignKey:ChangedByID"`
+}
+
+// CatalogSnapshot is a point-in-time copy of a tenant's product catalog, used to
+// review what changed between two versions
+type CatalogSnapshot struct {
+	TenantModel
+	Name         string                `json:"name" gorm:"not null"`
+	Notes        string                `json:"notes"`
+	ProductCount int                   `json:"product_count"`
+	CreatedByID  uuid.UUID             `json:"created_by_id" gorm:"type:uuid;not null"`
+	CreatedBy    *User                 `json:"created_by,omitempty" gorm:"foreignKey:CreatedByID"`
+	Items        []CatalogSnapshotItem `json:"items,omitempty" gorm:"foreignKey:SnapshotID"`
+}
+
+// CatalogSnapshotItem is a product as it stood when the snapshot was taken
+type CatalogSnapshotItem struct {
+	BaseModel
+	SnapshotID   uuid.UUID `json:"snapshot_id" gorm:"type:uuid;not null;index"`
+	ProductID    uuid.UUID `json:"product_id" gorm:"type:uuid;not null"`
+	Name         string    `json:"name"`
+	SKU          string    `json:"sku"`
+	BrandName    string    `json:"brand_name"`
+	CategoryName string    `json:"category_name"`
+	Size         string    `json:"size"`
+	CostPrice    float64   `json:"cost_price"`
+	SellingPrice float64   `json:"selling_price"`
+	MRP          float64   `json:"mrp"`
+	IsActive     bool      `json:"is_active"`
+}
@@ -16,20 +16,33 @@ func AllModels() []interface{} {
 		&User{},
 		&TenantRole{},
 		&TenantPermission{},
+		&RolePermission{},
 		&UserSession{},
 		&Salesman{},
+		&ShopGroup{},
 		
 		// Inventory models
 		&Category{},
 		&Brand{},
+		&ProductGroup{},
 		&Product{},
 		&BrandPricing{},
 		&Stock{},
 		&StockBatch{},
 		&StockHistory{},
+		&StockReservation{},
+		&StockCount{},
+		&StockCountItem{},
 		&StockPurchase{},
 		&StockPurchaseItem{},
 		&StockPurchasePayment{},
+		&StockWriteOff{},
+		&StockTransfer{},
+		&StockTransferItem{},
+		&ProductPriceHistory{},
+		&TaxRate{},
+		&CatalogSnapshot{},
+		&CatalogSnapshotItem{},
 		
 		// Sales models
 		&Sale{},
@@ -41,6 +54,10 @@ func AllModels() []interface{} {
 		&DailySalesItem{},
 		&SaleFinanceLog{},
 		&DailySaleSummary{},
+		&CommissionRule{},
+		&CommissionTier{},
+		&CommissionCategoryRate{},
+		&SalesTarget{},
 		
 		// Finance models
 		&Vendor{},
@@ -53,6 +70,9 @@ func AllModels() []interface{} {
 		&CashDeposit{},
 		&ExecutiveFinance{},
 		&Expense{},
+		&ExpenseAttachment{},
+		&RecurringExpense{},
+		&AccountMapping{},
 		
 		// Assistant Manager models
 		&MoneyCollection{},
@@ -60,6 +80,23 @@ func AllModels() []interface{} {
 		&StockVerification{},
 		&StockVerificationItem{},
 		&AssistantManagerLedger{},
+		&AssistantManagerExpense{},
+		&CashDrawer{},
+
+		// Notification models
+		&EmailTemplate{},
+		&TenantWebhook{},
+		&WebhookDelivery{},
+
+		// Settings models
+		&TenantSetting{},
+		&TenantSettingAudit{},
+		
+		// Sequence models
+		&DocumentSequence{},
+		
+		// Audit models
+		&AuditLog{},
 	}
 }
 
@@ -77,6 +114,9 @@ func CreateIndexes(db *gorm.DB) error {
 	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_tenant_users ON users(tenant_id)").Error; err != nil {
 		return err
 	}
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_users_shop_group ON users(shop_group_id)").Error; err != nil {
+		return err
+	}
 	
 	// Sales performance indexes
 	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_sales_date ON sales(sale_date)").Error; err != nil {
@@ -88,6 +128,9 @@ func CreateIndexes(db *gorm.DB) error {
 	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_daily_sales_date ON daily_sales_records(record_date)").Error; err != nil {
 		return err
 	}
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_sale_returns_daily_record ON sale_returns(daily_sales_record_id)").Error; err != nil {
+		return err
+	}
 	
 	// Stock management indexes
 	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_stock_shop_product ON stocks(shop_id, product_id)").Error; err != nil {
@@ -96,8 +139,52 @@ func CreateIndexes(db *gorm.DB) error {
 	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_stock_history_stock ON stock_histories(stock_id)").Error; err != nil {
 		return err
 	}
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_stock_write_offs_status ON stock_write_offs(tenant_id, status)").Error; err != nil {
+		return err
+	}
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_stock_transfers_status ON stock_transfers(tenant_id, status)").Error; err != nil {
+		return err
+	}
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_catalog_snapshots_created ON catalog_snapshots(tenant_id, created_at)").Error; err != nil {
+		return err
+	}
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_product_price_histories_product ON product_price_histories(product_id, created_at)").Error; err != nil {
+		return err
+	}
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_products_barcode ON products(tenant_id, barcode) WHERE barcode <> ''").Error; err != nil {
+		return err
+	}
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_product_groups_brand_name ON product_groups(tenant_id, brand_id, LOWER(name)) WHERE deleted_at IS NULL").Error; err != nil {
+		return err
+	}
+	
+	// Sequence indexes
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_document_sequences_name ON document_sequences(tenant_id, name)").Error; err != nil {
+		return err
+	}
+	
+	// Audit indexes
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_audit_logs_entity ON audit_logs(entity_type, entity_id)").Error; err != nil {
+		return err
+	}
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_audit_logs_tenant_created ON audit_logs(tenant_id, created_at DESC)").Error; err != nil {
+		return err
+	}
+	
+	// Webhook indexes
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending'").Error; err != nil {
+		return err
+	}
+	
+	// Settings indexes
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_tenant_settings_key ON tenant_settings(tenant_id, key)").Error; err != nil {
+		return err
+	}
 	
 	// Finance indexes
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_account_mappings_source ON account_mappings(tenant_id, mapping_type, source_key)").Error; err != nil {
+		return err
+	}
 	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_money_collection_deadline ON money_collections(approval_deadline)").Error; err != nil {
 		return err
 	}
@@ -108,6 +195,25 @@ func CreateIndexes(db *gorm.DB) error {
 	return nil
 }
 
+// BackfillProductGroups groups products created before product groups existed by
+// brand and name. It only touches ungrouped products, so it is safe to run on
+// every start.
+func BackfillProductGroups(db *gorm.DB) error {
+	if err := db.Exec(`INSERT INTO product_groups (id, created_at, updated_at, tenant_id, brand_id, name)
+		SELECT gen_random_uuid(), NOW(), NOW(), p.tenant_id, p.brand_id, MIN(TRIM(p.name))
+		FROM products p
+		WHERE p.product_group_id IS NULL AND p.deleted_at IS NULL
+		GROUP BY p.tenant_id, p.brand_id, LOWER(TRIM(p.name))
+		ON CONFLICT DO NOTHING`).Error; err != nil {
+		return err
+	}
+	return db.Exec(`UPDATE products p SET product_group_id = g.id
+		FROM product_groups g
+		WHERE p.product_group_id IS NULL AND p.deleted_at IS NULL
+			AND g.tenant_id = p.tenant_id AND g.brand_id = p.brand_id
+			AND LOWER(g.name) = LOWER(TRIM(p.name)) AND g.deleted_at IS NULL`).Error
+}
+
 // DatabaseConfig holds database configuration
 type DatabaseConfig struct {
 	Host     string
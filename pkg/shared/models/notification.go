@@ -0,0 +1,29 @@
+package models
+
+// EmailTemplate represents a tenant-customised transactional email template
+type EmailTemplate struct {
+	TenantModel
+	Event    string `json:"event" gorm:"not null;index"` // see EmailEvent* constants
+	Subject  string `json:"subject" gorm:"not null"`
+	Body     string `json:"body" gorm:"type:text;not null"`
+	IsHTML   bool   `json:"is_html" gorm:"default:false"`
+	IsActive bool   `json:"is_active" gorm:"default:true"`
+}
+
+// Email template events
+const (
+	EmailEventWelcome       = "welcome"
+	EmailEventPasswordReset = "password_reset"
+	EmailEventLowStock      = "low_stock"
+	EmailEventInvoice       = "invoice"
+	EmailEventApproval      = "approval"
+
+	EmailEventTransferInbound   = "transfer_inbound"
+	EmailEventTransferCompleted = "transfer_completed"
+
+	EmailEventCollectionOverdue = "collection_overdue"
+	EmailEventDailySalesSummary = "daily_sales_summary"
+
+	EmailEventPaymentFailed         = "payment_failed"
+	EmailEventSubscriptionCancelled = "subscription_cancelled"
+)
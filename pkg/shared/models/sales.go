@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/money"
 )
 
 // Sale represents individual sale transactions
@@ -22,7 +23,9 @@ type Sale struct {
 	// Financial details
 	SubTotal     float64 `json:"sub_total" gorm:"not null"`
 	DiscountAmount float64 `json:"discount_amount" gorm:"default:0"`
-	TaxAmount    float64 `json:"tax_amount" gorm:"default:0"`
+	TaxAmount    float64 `json:"tax_amount" gorm:"default:0"` // GST included in the total
+	CGSTAmount   float64 `json:"cgst_amount" gorm:"default:0"`
+	SGSTAmount   float64 `json:"sgst_amount" gorm:"default:0"`
 	TotalAmount  float64 `json:"total_amount" gorm:"not null"`
 	PaidAmount   float64 `json:"paid_amount" gorm:"default:0"`
 	DueAmount    float64 `json:"due_amount" gorm:"default:0"`
@@ -67,6 +70,14 @@ type SaleItem struct {
 	DiscountReason string  `json:"discount_reason"`
 	TotalPrice     float64 `json:"total_price" gorm:"not null"`
 	
+	// GST included in the total price. Counter sales are always within the shop's
+	// state, so the tax splits into CGST and SGST.
+	TaxRate        float64 `json:"tax_rate" gorm:"default:0"`
+	TaxableAmount  float64 `json:"taxable_amount" gorm:"default:0"`
+	CGSTAmount     float64 `json:"cgst_amount" gorm:"default:0"`
+	SGSTAmount     float64 `json:"sgst_amount" gorm:"default:0"`
+	TaxAmount      float64 `json:"tax_amount" gorm:"default:0"`
+	
 	// Batch tracking
 	StockBatchID *uuid.UUID  `json:"stock_batch_id" gorm:"type:uuid"`
 	StockBatch   *StockBatch `json:"stock_batch,omitempty" gorm:"foreignKey:StockBatchID"`
@@ -96,8 +107,12 @@ type SalePayment struct {
 type SaleReturn struct {
 	TenantModel
 	ReturnNumber string    `json:"return_number" gorm:"unique;not null"`
-	SaleID       uuid.UUID `json:"sale_id" gorm:"type:uuid;not null"`
-	Sale         *Sale     `json:"sale,omitempty" gorm:"foreignKey:SaleID"`
+	
+	// A return is raised against either an individual sale or a daily sales record
+	SaleID             *uuid.UUID        `json:"sale_id" gorm:"type:uuid"`
+	Sale               *Sale             `json:"sale,omitempty" gorm:"foreignKey:SaleID"`
+	DailySalesRecordID *uuid.UUID        `json:"daily_sales_record_id" gorm:"type:uuid"`
+	DailySalesRecord   *DailySalesRecord `json:"daily_sales_record,omitempty" gorm:"foreignKey:DailySalesRecordID"`
 	
 	ReturnDate   time.Time `json:"return_date" gorm:"not null"`
 	ReturnAmount float64   `json:"return_amount" gorm:"not null"`
@@ -124,8 +139,10 @@ type SaleReturnItem struct {
 	TenantModel
 	SaleReturnID uuid.UUID     `json:"sale_return_id" gorm:"type:uuid;not null"`
 	SaleReturn   *SaleReturn   `json:"sale_return,omitempty" gorm:"foreignKey:SaleReturnID"`
-	SaleItemID   uuid.UUID     `json:"sale_item_id" gorm:"type:uuid;not null"`
+	SaleItemID   *uuid.UUID    `json:"sale_item_id" gorm:"type:uuid"`
 	SaleItem     *SaleItem     `json:"sale_item,omitempty" gorm:"foreignKey:SaleItemID"`
+	DailySalesItemID *uuid.UUID      `json:"daily_sales_item_id" gorm:"type:uuid"`
+	DailySalesItem   *DailySalesItem `json:"daily_sales_item,omitempty" gorm:"foreignKey:DailySalesItemID"`
 	
 	Quantity     int     `json:"quantity" gorm:"not null"`
 	UnitPrice    float64 `json:"unit_price" gorm:"not null"`
@@ -142,18 +159,26 @@ type DailySalesRecord struct {
 	SalesmanID  *uuid.UUID `json:"salesman_id" gorm:"type:uuid"`
 	Salesman    *Salesman `json:"salesman,omitempty" gorm:"foreignKey:SalesmanID"`
 	
-	// Financial totals
-	TotalSalesAmount  float64 `json:"total_sales_amount" gorm:"not null"`
-	TotalCashAmount   float64 `json:"total_cash_amount" gorm:"default:0"`
-	TotalCardAmount   float64 `json:"total_card_amount" gorm:"default:0"`
-	TotalUpiAmount    float64 `json:"total_upi_amount" gorm:"default:0"`
-	TotalCreditAmount float64 `json:"total_credit_amount" gorm:"default:0"`
+	// Financial totals, in paise so they add up exactly
+	TotalSalesAmount  money.Money `json:"total_sales_amount" gorm:"not null"`
+	TotalCashAmount   money.Money `json:"total_cash_amount" gorm:"default:0"`
+	TotalCardAmount   money.Money `json:"total_card_amount" gorm:"default:0"`
+	TotalUpiAmount    money.Money `json:"total_upi_amount" gorm:"default:0"`
+	TotalCreditAmount money.Money `json:"total_credit_amount" gorm:"default:0"`
 	
 	// Status and approval
-	Status       string     `json:"status" gorm:"default:'pending'"` // pending, approved, rejected
+	Status       string     `json:"status" gorm:"default:'pending'"` // pending, approved, rejected, voided
 	ApprovedAt   *time.Time `json:"approved_at"`
 	ApprovedByID *uuid.UUID `json:"approved_by_id" gorm:"type:uuid"`
 	ApprovedBy   *User      `json:"approved_by,omitempty" gorm:"foreignKey:ApprovedByID"`
+	AutoApproved bool       `json:"auto_approved" gorm:"default:false"`
+	Version      int        `json:"version" gorm:"not null;default:1"` // bumped on every change; stale edits and approvals are refused
+	
+	// Set when an approved record is voided and its stock put back
+	VoidedAt     *time.Time `json:"voided_at"`
+	VoidedByID   *uuid.UUID `json:"voided_by_id" gorm:"type:uuid"`
+	VoidedBy     *User      `json:"voided_by,omitempty" gorm:"foreignKey:VoidedByID"`
+	VoidReason   string     `json:"void_reason"`
 	
 	// Created by
 	CreatedByID  uuid.UUID `json:"created_by_id" gorm:"type:uuid;not null"`
@@ -173,15 +198,15 @@ type DailySalesItem struct {
 	ProductID          uuid.UUID          `json:"product_id" gorm:"type:uuid;not null"`
 	Product            *Product           `json:"product,omitempty" gorm:"foreignKey:ProductID"`
 	
-	Quantity           int     `json:"quantity" gorm:"not null"`
-	UnitPrice          float64 `json:"unit_price" gorm:"not null"`
-	TotalAmount        float64 `json:"total_amount" gorm:"not null"`
+	Quantity           int         `json:"quantity" gorm:"not null"`
+	UnitPrice          money.Money `json:"unit_price" gorm:"not null"`
+	TotalAmount        money.Money `json:"total_amount" gorm:"not null"`
 	
 	// Payment breakdown for this item
-	CashAmount         float64 `json:"cash_amount" gorm:"default:0"`
-	CardAmount         float64 `json:"card_amount" gorm:"default:0"`
-	UpiAmount          float64 `json:"upi_amount" gorm:"default:0"`
-	CreditAmount       float64 `json:"credit_amount" gorm:"default:0"`
+	CashAmount         money.Money `json:"cash_amount" gorm:"default:0"`
+	CardAmount         money.Money `json:"card_amount" gorm:"default:0"`
+	UpiAmount          money.Money `json:"upi_amount" gorm:"default:0"`
+	CreditAmount       money.Money `json:"credit_amount" gorm:"default:0"`
 }
 
 // SaleFinanceLog tracks financial transactions related to sales
@@ -224,4 +249,62 @@ type DailySaleSummary struct {
 	
 	IsGenerated     bool      `json:"is_generated" gorm:"default:false"`
 	GeneratedAt     *time.Time `json:"generated_at"`
-}
\ No newline at end of file
+}
+
+// Commission rule types
+const (
+	CommissionFlat     = "flat"     // one rate on the whole commissionable amount
+	CommissionTiered   = "tiered"   // rates that step up with the salesman's sales volume
+	CommissionCategory = "category" // a rate per product category
+)
+
+// CommissionRule sets how commission is worked out for one salesman, or for every
+// salesman in a shop. A salesman's own rule takes precedence over the shop's.
+type CommissionRule struct {
+	TenantModel
+	SalesmanID *uuid.UUID `json:"salesman_id" gorm:"type:uuid;index"`
+	Salesman   *Salesman  `json:"salesman,omitempty" gorm:"foreignKey:SalesmanID"`
+	ShopID     *uuid.UUID `json:"shop_id" gorm:"type:uuid;index"`
+	Shop       *Shop      `json:"shop,omitempty" gorm:"foreignKey:ShopID"`
+	RuleType   string     `json:"rule_type" gorm:"not null"` // flat, tiered, category
+	// Rate is the percentage for flat rules, and the fallback for categories
+	// without their own rate
+	Rate          float64                  `json:"rate" gorm:"default:0"`
+	IsActive      bool                     `json:"is_active" gorm:"default:true"`
+	CreatedByID   uuid.UUID                `json:"created_by_id" gorm:"type:uuid;not null"`
+	CreatedBy     *User                    `json:"created_by,omitempty" gorm:"foreignKey:CreatedByID"`
+	Tiers         []CommissionTier         `json:"tiers,omitempty" gorm:"foreignKey:CommissionRuleID"`
+	CategoryRates []CommissionCategoryRate `json:"category_rates,omitempty" gorm:"foreignKey:CommissionRuleID"`
+}
+
+// CommissionTier is one step of a tiered rule: sales above MinAmount earn Rate percent
+type CommissionTier struct {
+	TenantModel
+	CommissionRuleID uuid.UUID `json:"commission_rule_id" gorm:"type:uuid;not null;index"`
+	MinAmount        float64   `json:"min_amount" gorm:"not null"`
+	Rate             float64   `json:"rate" gorm:"not null"`
+}
+
+// CommissionCategoryRate is the percentage a category-based rule pays for one category
+type CommissionCategoryRate struct {
+	TenantModel
+	CommissionRuleID uuid.UUID `json:"commission_rule_id" gorm:"type:uuid;not null;index"`
+	CategoryID       uuid.UUID `json:"category_id" gorm:"type:uuid;not null"`
+	Category         *Category `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	Rate             float64   `json:"rate" gorm:"not null"`
+}
+
+// SalesTarget is a monthly sales goal for a shop, or for one salesman in a shop
+// when SalesmanID is set
+type SalesTarget struct {
+	TenantModel
+	ShopID       uuid.UUID  `json:"shop_id" gorm:"type:uuid;not null;index"`
+	Shop         *Shop      `json:"shop,omitempty" gorm:"foreignKey:ShopID"`
+	SalesmanID   *uuid.UUID `json:"salesman_id" gorm:"type:uuid;index"`
+	Salesman     *Salesman  `json:"salesman,omitempty" gorm:"foreignKey:SalesmanID"`
+	PeriodStart  time.Time  `json:"period_start" gorm:"type:date;not null;index"` // first day of the month
+	TargetAmount float64    `json:"target_amount" gorm:"not null"`
+	Notes        string     `json:"notes"`
+	CreatedByID  uuid.UUID  `json:"created_by_id" gorm:"type:uuid;not null"`
+	CreatedBy    *User      `json:"created_by,omitempty" gorm:"foreignKey:CreatedByID"`
+}
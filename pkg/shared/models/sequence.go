@@ -0,0 +1,54 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Document sequence names
+const (
+	SequenceStockTransfer = "stock_transfer"
+)
+
+// DocumentSequence is a per-tenant counter used to number documents
+type DocumentSequence struct {
+	TenantModel
+	Name      string `json:"name" gorm:"not null"`
+	LastValue int64  `json:"last_value" gorm:"not null;default:0"`
+}
+
+// NextSequenceValue returns the next value of a tenant's named sequence.
+// It must run inside the caller's transaction: the sequence row stays locked
+// until that transaction ends, so concurrent callers never get the same value
+// and a rolled back document does not consume a number.
+func NextSequenceValue(tx *gorm.DB, tenantID uuid.UUID, name string) (int64, error) {
+	seed := DocumentSequence{
+		TenantModel: TenantModel{TenantID: tenantID},
+		Name:        name,
+	}
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "name"}},
+		DoNothing: true,
+	}).Create(&seed).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to initialize sequence %s: %w", name, err)
+	}
+
+	var sequence DocumentSequence
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("tenant_id = ? AND name = ?", tenantID, name).
+		First(&sequence).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock sequence %s: %w", name, err)
+	}
+
+	sequence.LastValue++
+	if err := tx.Model(&sequence).Update("last_value", sequence.LastValue).Error; err != nil {
+		return 0, fmt.Errorf("failed to advance sequence %s: %w", name, err)
+	}
+
+	return sequence.LastValue, nil
+}
@@ -0,0 +1,20 @@
+package models
+
+import "github.com/google/uuid"
+
+// TenantSetting stores one typed per-tenant setting; Value holds the JSON-encoded value
+type TenantSetting struct {
+	TenantModel
+	Key   string `json:"key" gorm:"not null;index"`
+	Value string `json:"value" gorm:"type:text;not null"`
+}
+
+// TenantSettingAudit records every change made to a tenant setting
+type TenantSettingAudit struct {
+	TenantModel
+	Key         string    `json:"key" gorm:"not null;index"`
+	OldValue    string    `json:"old_value" gorm:"type:text"`
+	NewValue    string    `json:"new_value" gorm:"type:text;not null"`
+	ChangedByID uuid.UUID `json:"changed_by_id" gorm:"type:uuid;not null"`
+	ChangedBy   *User     `json:"changed_by,omitempty" gorm:"foreignKey:ChangedByID"`
+}
@@ -32,6 +32,9 @@ type Shop struct {
 	Longitude      float64 `json:"longitude"`
 	IsActive       bool    `json:"is_active" gorm:"default:true"`
 	
+	// Manager responsible for the shop, who receives its stock notifications
+	ManagerID      *uuid.UUID `json:"manager_id" gorm:"type:uuid"`
+	
 	// Relationships
 	Stocks      []Stock      `json:"stocks,omitempty" gorm:"foreignKey:ShopID"`
 	Sales       []Sale       `json:"sales,omitempty" gorm:"foreignKey:ShopID"`
@@ -57,4 +60,16 @@ type Salesman struct {
 	// Relationships
 	Sales      []Sale             `json:"sales,omitempty" gorm:"foreignKey:SalesmanID"`
 	DailySales []DailySalesRecord `json:"daily_sales,omitempty" gorm:"foreignKey:SalesmanID"`
+}
+
+// ShopGroup is a named set of shops overseen by regional managers
+type ShopGroup struct {
+	TenantModel
+	Name        string `json:"name" gorm:"not null"`
+	Description string `json:"description"`
+	IsActive    bool   `json:"is_active" gorm:"default:true"`
+	
+	// Relationships
+	Shops    []Shop `json:"shops,omitempty" gorm:"many2many:shop_group_shops;"`
+	Managers []User `json:"managers,omitempty" gorm:"foreignKey:ShopGroupID"`
 }
\ No newline at end of file
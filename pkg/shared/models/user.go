@@ -23,6 +23,19 @@ type User struct {
 	// Profile fields
 	ProfileImage string `json:"profile_image"`
 	
+	// Auto-approval (executives trusted to skip manual approval below a limit)
+	IsTrusted         bool    `json:"is_trusted" gorm:"default:false"`
+	AutoApprovalLimit float64 `json:"auto_approval_limit" gorm:"default:0"`
+	
+	// Shop group overseen by a regional manager
+	ShopGroupID *uuid.UUID `json:"shop_group_id" gorm:"type:uuid"`
+	
+	// Two-factor authentication (TOTP) for admins and managers
+	TOTPEnabled     bool       `json:"totp_enabled" gorm:"default:false"`
+	TOTPSecret      string     `json:"-"` // encrypted; pending until the enrollment is verified
+	TOTPBackupCodes string     `json:"-"` // JSON array of SHA-256 hashes of unused backup codes
+	TOTPEnabledAt   *time.Time `json:"totp_enabled_at"`
+	
 	// Relationships
 	TenantRoles       []TenantRole       `json:"tenant_roles,omitempty" gorm:"foreignKey:UserID"`
 	TenantPermissions []TenantPermission `json:"tenant_permissions,omitempty" gorm:"foreignKey:UserID"`
@@ -70,6 +83,15 @@ type TenantPermission struct {
 	Action     string    `json:"action"`
 }
 
+// RolePermission overrides a role's default permission for one tenant. Granted false
+// takes a default permission away; true adds one the role does not have by default.
+type RolePermission struct {
+	TenantModel
+	Role       string `json:"role" gorm:"not null;index"`
+	Permission string `json:"permission" gorm:"not null"`
+	Granted    bool   `json:"granted"`
+}
+
 // UserSession represents active user sessions
 type UserSession struct {
 	BaseModel
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// TenantWebhook is an endpoint a tenant has registered to be notified of events.
+// Payloads are signed with the secret so the receiver can verify them.
+type TenantWebhook struct {
+	TenantModel
+	URL         string    `json:"url" gorm:"not null"`
+	Secret      string    `json:"-" gorm:"not null"`
+	Events      []string  `json:"events" gorm:"serializer:json"` // see webhook.Event* constants
+	Description string    `json:"description"`
+	IsActive    bool      `json:"is_active" gorm:"default:true"`
+	CreatedByID uuid.UUID `json:"created_by_id" gorm:"type:uuid;not null"`
+}
+
+// WebhookDelivery is one event sent to one webhook. It is written in the same
+// transaction as the change that raised the event and delivered afterwards, with
+// retries until it succeeds or runs out of attempts.
+type WebhookDelivery struct {
+	TenantModel
+	WebhookID      uuid.UUID      `json:"webhook_id" gorm:"type:uuid;not null;index"`
+	Webhook        *TenantWebhook `json:"webhook,omitempty" gorm:"foreignKey:WebhookID"`
+	EventID        uuid.UUID      `json:"event_id" gorm:"type:uuid;not null;index"`
+	EventType      string         `json:"event_type" gorm:"not null"`
+	Payload        string         `json:"payload" gorm:"type:text;not null"` // JSON body as sent
+	Status         string         `json:"status" gorm:"not null;default:'pending'"`
+	Attempts       int            `json:"attempts" gorm:"default:0"`
+	LastStatusCode int            `json:"last_status_code"`
+	LastError      string         `json:"last_error" gorm:"type:text"`
+	NextAttemptAt  *time.Time     `json:"next_attempt_at"`
+	DeliveredAt    *time.Time     `json:"delivered_at"`
+}
@@ -0,0 +1,155 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in minor units: paise, or cents in other currencies. Sums
+// and comparisons of Money are exact. It is written to JSON and the database as
+// a decimal with two places, so columns and API payloads keep their shape.
+type Money int64
+
+// FromFloat converts a float amount to Money, rounding half away from zero
+func FromFloat(amount float64) Money {
+	return Money(math.Round(amount * 100))
+}
+
+// FromMinor returns an amount of minor units as Money
+func FromMinor(units int64) Money {
+	return Money(units)
+}
+
+// Parse reads a decimal amount such as "1234.5" exactly. Digits beyond the
+// second decimal place are rounded half away from zero.
+func Parse(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	r.Mul(r, big.NewRat(100, 1))
+
+	// Round the quotient half away from zero
+	num, den := new(big.Int).Set(r.Num()), r.Denom()
+	negative := num.Sign() < 0
+	num.Abs(num)
+	quotient, remainder := new(big.Int).QuoRem(num, den, new(big.Int))
+	if remainder.Mul(remainder, big.NewInt(2)).Cmp(den) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	if !quotient.IsInt64() {
+		return 0, fmt.Errorf("amount %q is out of range", s)
+	}
+	units := quotient.Int64()
+	if negative {
+		units = -units
+	}
+	return Money(units), nil
+}
+
+// Minor returns the amount in minor units
+func (m Money) Minor() int64 {
+	return int64(m)
+}
+
+// Float64 returns the amount in major units, for reports and calculations that
+// are still done in floats
+func (m Money) Float64() float64 {
+	return float64(m) / 100
+}
+
+// Abs returns the amount without its sign
+func (m Money) Abs() Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// String formats the amount with two decimal places, such as "-12.05"
+func (m Money) String() string {
+	units := int64(m)
+	sign := ""
+	if units < 0 {
+		sign = "-"
+	}
+	magnitude := uint64(units)
+	if units < 0 {
+		magnitude = uint64(-units)
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, magnitude/100, magnitude%100)
+}
+
+// Sum adds amounts exactly
+func Sum(amounts ...Money) Money {
+	var total Money
+	for _, amount := range amounts {
+		total += amount
+	}
+	return total
+}
+
+// MarshalJSON writes the amount as a JSON number with two decimal places
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON reads an amount from a JSON number or numeric string
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	amount, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*m = amount
+	return nil
+}
+
+// Scan reads the amount from a numeric or floating point column
+func (m *Money) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*m = 0
+	case []byte:
+		amount, err := Parse(string(v))
+		if err != nil {
+			return err
+		}
+		*m = amount
+	case string:
+		amount, err := Parse(v)
+		if err != nil {
+			return err
+		}
+		*m = amount
+	case float64:
+		*m = FromFloat(v)
+	case int64:
+		*m = Money(v * 100)
+	default:
+		return errors.New("unsupported type for money amount")
+	}
+	return nil
+}
+
+// Value writes the amount as an exact decimal
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// GormDataType keeps Money columns the decimal columns float amounts used
+func (Money) GormDataType() string {
+	return "decimal"
+}
@@ -0,0 +1,108 @@
+package monitoring
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liquorpro/go-backend/pkg/shared/cache"
+	"gorm.io/gorm"
+)
+
+// dependencyTimeout bounds each readiness check so a hung dependency reports
+// unhealthy instead of stalling the probe
+const dependencyTimeout = 2 * time.Second
+
+// DependencyStatus is the outcome of checking one dependency
+type DependencyStatus struct {
+	Status    string `json:"status"` // up, down
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// HealthChecker serves a service's liveness and readiness probes. Liveness only
+// says the process is serving requests; readiness also checks that the database
+// and Redis are reachable, so an instance that lost either stops receiving
+// traffic without being restarted.
+type HealthChecker struct {
+	service string
+	db      *gorm.DB
+	cache   *cache.Cache
+}
+
+// NewHealthChecker creates a health checker. A nil db or cache is not checked.
+func NewHealthChecker(service string, db *gorm.DB, cache *cache.Cache) *HealthChecker {
+	return &HealthChecker{
+		service: service,
+		db:      db,
+		cache:   cache,
+	}
+}
+
+// Register adds GET /health/live and GET /health/ready to the router
+func (h *HealthChecker) Register(router gin.IRouter) {
+	router.GET("/health/live", h.Liveness)
+	router.GET("/health/ready", h.Readiness)
+}
+
+// Liveness reports that the process is up. It checks no dependencies, so a
+// database outage never gets healthy instances restarted.
+func (h *HealthChecker) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "alive",
+		"service": h.service,
+	})
+}
+
+// Readiness checks every dependency and answers 503 if any is down
+func (h *HealthChecker) Readiness(c *gin.Context) {
+	checks := h.Check(c.Request.Context())
+
+	status, code := "ready", http.StatusOK
+	for _, check := range checks {
+		if check.Status != "up" {
+			status, code = "not_ready", http.StatusServiceUnavailable
+			break
+		}
+	}
+
+	c.JSON(code, gin.H{
+		"status":       status,
+		"service":      h.service,
+		"dependencies": checks,
+		"timestamp":    time.Now().UTC(),
+	})
+}
+
+// Check pings the database and Redis and reports each by name
+func (h *HealthChecker) Check(ctx context.Context) map[string]DependencyStatus {
+	checks := make(map[string]DependencyStatus, 2)
+	if h.db != nil {
+		checks["database"] = checkDependency(ctx, func(ctx context.Context) error {
+			return h.db.WithContext(ctx).Exec("SELECT 1").Error
+		})
+	}
+	if h.cache != nil {
+		checks["redis"] = checkDependency(ctx, h.cache.Health)
+	}
+	return checks
+}
+
+// checkDependency runs one check under dependencyTimeout and times it
+func checkDependency(ctx context.Context, check func(context.Context) error) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, dependencyTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	status := DependencyStatus{
+		Status:    "up",
+		LatencyMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		status.Status = "down"
+		status.Error = err.Error()
+	}
+	return status
+}
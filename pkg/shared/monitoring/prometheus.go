@@ -0,0 +1,217 @@
+package monitoring
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liquorpro/go-backend/pkg/shared/cache"
+	"gorm.io/gorm"
+)
+
+// prometheusContentType is the Prometheus text exposition format
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// latencyBuckets are the upper bounds, in seconds, of the request latency histogram
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// requestKey identifies one series of the request metrics
+type requestKey struct {
+	method string
+	route  string
+	status string
+}
+
+// requestStats is the count and latency histogram of one request series
+type requestStats struct {
+	count   uint64
+	sum     float64
+	buckets []uint64 // per bucket, not cumulative
+}
+
+// Prometheus collects a service's request, database pool and cache metrics and
+// serves them in the Prometheus text format. Requests are labelled with the route
+// pattern rather than the raw path, so IDs in URLs don't create a series each.
+type Prometheus struct {
+	service string
+	db      *gorm.DB
+	cache   *cache.Cache
+
+	inFlight atomic.Int64
+
+	mu       sync.Mutex
+	requests map[requestKey]*requestStats
+}
+
+// NewPrometheus creates a metrics collector. A nil db or cache is left out of the
+// exposition.
+func NewPrometheus(service string, db *gorm.DB, cache *cache.Cache) *Prometheus {
+	return &Prometheus{
+		service:  service,
+		db:       db,
+		cache:    cache,
+		requests: make(map[requestKey]*requestStats),
+	}
+}
+
+// Register adds the metrics middleware and GET /metrics to the router. The
+// middleware only sees routes registered after it, so call this before setting
+// up the service's routes.
+func (p *Prometheus) Register(router *gin.Engine) {
+	router.Use(p.Middleware())
+	router.GET("/metrics", p.Handler)
+}
+
+// Middleware counts and times every request and tracks how many are in flight
+func (p *Prometheus) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p.inFlight.Add(1)
+		defer p.inFlight.Add(-1)
+
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		p.observe(requestKey{
+			method: c.Request.Method,
+			route:  route,
+			status: strconv.Itoa(c.Writer.Status()),
+		}, time.Since(start).Seconds())
+	}
+}
+
+// InFlight returns the number of requests being served, so shutdown can report
+// how many it is waiting to drain
+func (p *Prometheus) InFlight() int64 {
+	return p.inFlight.Load()
+}
+
+// observe records one finished request
+func (p *Prometheus) observe(key requestKey, seconds float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats, ok := p.requests[key]
+	if !ok {
+		stats = &requestStats{buckets: make([]uint64, len(latencyBuckets))}
+		p.requests[key] = stats
+	}
+	stats.count++
+	stats.sum += seconds
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			stats.buckets[i]++
+			break
+		}
+	}
+}
+
+// Handler writes the current metrics in the Prometheus text format
+func (p *Prometheus) Handler(c *gin.Context) {
+	var b strings.Builder
+	service := fmt.Sprintf(`service="%s"`, escapeLabel(p.service))
+
+	writeHeader(&b, "http_requests_in_flight", "gauge", "Requests currently being served.")
+	fmt.Fprintf(&b, "http_requests_in_flight{%s} %d\n", service, p.inFlight.Load())
+
+	p.writeRequests(&b, service)
+
+	if p.db != nil {
+		if sqlDB, err := p.db.DB(); err == nil {
+			stats := sqlDB.Stats()
+			writeHeader(&b, "db_connections_open", "gauge", "Open database connections, in use and idle.")
+			fmt.Fprintf(&b, "db_connections_open{%s} %d\n", service, stats.OpenConnections)
+			writeHeader(&b, "db_connections_in_use", "gauge", "Database connections currently in use.")
+			fmt.Fprintf(&b, "db_connections_in_use{%s} %d\n", service, stats.InUse)
+			writeHeader(&b, "db_connections_idle", "gauge", "Idle database connections.")
+			fmt.Fprintf(&b, "db_connections_idle{%s} %d\n", service, stats.Idle)
+			writeHeader(&b, "db_connections_max_open", "gauge", "Maximum open database connections, 0 for unlimited.")
+			fmt.Fprintf(&b, "db_connections_max_open{%s} %d\n", service, stats.MaxOpenConnections)
+			writeHeader(&b, "db_connections_wait_total", "counter", "Times a request waited for a free database connection.")
+			fmt.Fprintf(&b, "db_connections_wait_total{%s} %d\n", service, stats.WaitCount)
+			writeHeader(&b, "db_connections_wait_seconds_total", "counter", "Time spent waiting for a free database connection.")
+			fmt.Fprintf(&b, "db_connections_wait_seconds_total{%s} %s\n", service, formatFloat(stats.WaitDuration.Seconds()))
+		}
+	}
+
+	if p.cache != nil {
+		stats := p.cache.Stats()
+		writeHeader(&b, "cache_hits_total", "counter", "Cache lookups that found their key.")
+		fmt.Fprintf(&b, "cache_hits_total{%s} %d\n", service, stats.Hits)
+		writeHeader(&b, "cache_misses_total", "counter", "Cache lookups that missed.")
+		fmt.Fprintf(&b, "cache_misses_total{%s} %d\n", service, stats.Misses)
+	}
+
+	c.Data(http.StatusOK, prometheusContentType, []byte(b.String()))
+}
+
+// writeRequests writes the request counter and latency histogram, series sorted
+// so scrapes are stable
+func (p *Prometheus) writeRequests(b *strings.Builder, service string) {
+	p.mu.Lock()
+	keys := make([]requestKey, 0, len(p.requests))
+	snapshot := make(map[requestKey]requestStats, len(p.requests))
+	for key, stats := range p.requests {
+		keys = append(keys, key)
+		copied := *stats
+		copied.buckets = append([]uint64(nil), stats.buckets...)
+		snapshot[key] = copied
+	}
+	p.mu.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].status < keys[j].status
+	})
+
+	writeHeader(b, "http_requests_total", "counter", "Requests served, by route and status.")
+	for _, key := range keys {
+		fmt.Fprintf(b, "http_requests_total{%s} %d\n", requestLabels(service, key), snapshot[key].count)
+	}
+
+	writeHeader(b, "http_request_duration_seconds", "histogram", "Request latency, by route and status.")
+	for _, key := range keys {
+		stats := snapshot[key]
+		labels := requestLabels(service, key)
+		var cumulative uint64
+		for i, bound := range latencyBuckets {
+			cumulative += stats.buckets[i]
+			fmt.Fprintf(b, "http_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels, formatFloat(bound), cumulative)
+		}
+		fmt.Fprintf(b, "http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, stats.count)
+		fmt.Fprintf(b, "http_request_duration_seconds_sum{%s} %s\n", labels, formatFloat(stats.sum))
+		fmt.Fprintf(b, "http_request_duration_seconds_count{%s} %d\n", labels, stats.count)
+	}
+}
+
+func requestLabels(service string, key requestKey) string {
+	return fmt.Sprintf(`%s,method="%s",route="%s",status="%s"`,
+		service, escapeLabel(key.method), escapeLabel(key.route), key.status)
+}
+
+func writeHeader(b *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// escapeLabel escapes a label value as the text format requires
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
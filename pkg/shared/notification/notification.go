@@ -0,0 +1,275 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"mime"
+	"net/smtp"
+	"strings"
+	"text/template"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/config"
+	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"gorm.io/gorm"
+)
+
+// Message is a rendered email ready to be delivered
+type Message struct {
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	Body    string   `json:"body"`
+	IsHTML  bool     `json:"is_html"`
+}
+
+// Sender delivers rendered messages
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// SMTPSender delivers messages through an SMTP relay
+type SMTPSender struct {
+	config config.EmailConfig
+}
+
+// NewSMTPSender creates a new SMTP sender
+func NewSMTPSender(cfg config.EmailConfig) *SMTPSender {
+	return &SMTPSender{config: cfg}
+}
+
+// Send delivers the message over SMTP
+func (s *SMTPSender) Send(ctx context.Context, msg *Message) error {
+	if len(msg.To) == 0 {
+		return errors.New("no recipients")
+	}
+
+	from := s.config.From
+	if s.config.FromName != "" {
+		from = fmt.Sprintf("%s <%s>", mime.QEncoding.Encode("UTF-8", headerValue(s.config.FromName)), s.config.From)
+	}
+
+	contentType := "text/plain"
+	if msg.IsHTML {
+		contentType = "text/html"
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(msg.To, ", "))
+	// The subject is rendered from tenant templates and user data, so it is kept to
+	// one line and encoded; a line break would let it add headers of its own
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", headerValue(msg.Subject)))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: %s; charset=UTF-8\r\n\r\n", contentType)
+	buf.WriteString(msg.Body)
+
+	var auth smtp.Auth
+	if s.config.Username != "" {
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+	}
+
+	addr := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
+	if err := smtp.SendMail(addr, auth, s.config.From, msg.To, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+// LogSender writes messages to the log instead of delivering them (development)
+type LogSender struct{}
+
+// Send logs the message
+func (LogSender) Send(ctx context.Context, msg *Message) error {
+	log.Printf("Email to %s: %s", strings.Join(msg.To, ", "), msg.Subject)
+	return nil
+}
+
+// NewSender returns an SMTP sender when a host is configured, otherwise a log sender
+func NewSender(cfg config.EmailConfig) Sender {
+	if cfg.Host == "" {
+		return LogSender{}
+	}
+	return NewSMTPSender(cfg)
+}
+
+// Service renders tenant email templates and sends them
+type Service struct {
+	db     *database.DB
+	sender Sender
+}
+
+// NewService creates a new notification service
+func NewService(db *database.DB, sender Sender) *Service {
+	return &Service{
+		db:     db,
+		sender: sender,
+	}
+}
+
+// GetTemplate returns the tenant's active template for an event, falling back to the built-in default
+func (s *Service) GetTemplate(ctx context.Context, tenantID uuid.UUID, event string) (*Template, error) {
+	defaultTemplate, ok := DefaultTemplates[event]
+	if !ok {
+		return nil, fmt.Errorf("unknown email event: %s", event)
+	}
+
+	var custom models.EmailTemplate
+	err := s.db.WithContext(ctx).
+		Where("tenant_id = ? AND event = ? AND is_active = ?", tenantID, event, true).
+		Order("updated_at DESC").
+		First(&custom).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &defaultTemplate, nil
+		}
+		return nil, fmt.Errorf("failed to get email template: %w", err)
+	}
+
+	return &Template{
+		Event:     custom.Event,
+		Subject:   custom.Subject,
+		Body:      custom.Body,
+		IsHTML:    custom.IsHTML,
+		Variables: defaultTemplate.Variables,
+	}, nil
+}
+
+// Render renders the tenant's template for an event with the given variables
+func (s *Service) Render(ctx context.Context, tenantID uuid.UUID, event string, vars map[string]interface{}) (*Message, error) {
+	tmpl, err := s.GetTemplate(ctx, tenantID, event)
+	if err != nil {
+		return nil, err
+	}
+	return RenderTemplate(tmpl, vars)
+}
+
+// Send renders the tenant's template for an event and delivers it to the recipients
+func (s *Service) Send(ctx context.Context, tenantID uuid.UUID, event string, to []string, vars map[string]interface{}) error {
+	msg, err := s.Render(ctx, tenantID, event, vars)
+	if err != nil {
+		return err
+	}
+	if len(to) == 0 {
+		return nil
+	}
+	msg.To = to
+	return s.sender.Send(ctx, msg)
+}
+
+// GetRecipientsByRole returns the emails of the tenant's active users with any of the given roles
+func (s *Service) GetRecipientsByRole(ctx context.Context, tenantID uuid.UUID, roles ...string) ([]string, error) {
+	var emails []string
+	err := s.db.WithContext(ctx).Model(&models.User{}).
+		Where("tenant_id = ? AND role IN ? AND is_active = ?", tenantID, roles, true).
+		Pluck("email", &emails).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recipients: %w", err)
+	}
+	return emails, nil
+}
+
+// GetShopManagerRecipients returns the emails of the active managers of the given
+// shops: each shop's own manager and the regional managers of the active shop
+// groups it belongs to
+func (s *Service) GetShopManagerRecipients(ctx context.Context, tenantID uuid.UUID, shopIDs ...uuid.UUID) ([]string, error) {
+	if len(shopIDs) == 0 {
+		return nil, nil
+	}
+
+	managers := s.db.WithContext(ctx).Table("shops").
+		Select("manager_id").
+		Where("tenant_id = ? AND id IN ? AND manager_id IS NOT NULL AND deleted_at IS NULL", tenantID, shopIDs)
+	groups := s.db.WithContext(ctx).Table("shop_group_shops").
+		Select("shop_group_shops.shop_group_id").
+		Joins("JOIN shop_groups ON shop_groups.id = shop_group_shops.shop_group_id").
+		Where("shop_groups.tenant_id = ? AND shop_groups.is_active = ? AND shop_groups.deleted_at IS NULL", tenantID, true).
+		Where("shop_group_shops.shop_id IN ?", shopIDs)
+
+	var emails []string
+	err := s.db.WithContext(ctx).Model(&models.User{}).
+		Distinct("email").
+		Where("tenant_id = ? AND is_active = ?", tenantID, true).
+		Where(s.db.Where("id IN (?)", managers).
+			Or("role = ? AND shop_group_id IN (?)", models.RoleRegionalManager, groups)).
+		Pluck("email", &emails).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shop manager recipients: %w", err)
+	}
+	return emails, nil
+}
+
+// RenderTemplate substitutes variables into a template's subject and body.
+// Missing variables render as empty strings.
+func RenderTemplate(tmpl *Template, vars map[string]interface{}) (*Message, error) {
+	if vars == nil {
+		vars = map[string]interface{}{}
+	}
+
+	subject, err := renderText("subject", tmpl.Subject, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	var body string
+	if tmpl.IsHTML {
+		body, err = renderHTML("body", tmpl.Body, vars)
+	} else {
+		body, err = renderText("body", tmpl.Body, vars)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		Subject: headerValue(subject),
+		Body:    body,
+		IsHTML:  tmpl.IsHTML,
+	}, nil
+}
+
+// ValidateSubject rejects a subject template that spans more than one line
+func ValidateSubject(subject string) error {
+	if strings.ContainsAny(subject, "\r\n") {
+		return errors.New("subject must be a single line")
+	}
+	return nil
+}
+
+// headerValue folds a value onto one line for use in a mail header
+func headerValue(value string) string {
+	return strings.TrimSpace(strings.Join(strings.FieldsFunc(value, func(r rune) bool {
+		return r == '\r' || r == '\n'
+	}), " "))
+}
+
+func renderText(name, text string, vars map[string]interface{}) (string, error) {
+	t, err := template.New(name).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	return strings.ReplaceAll(buf.String(), "<no value>", ""), nil
+}
+
+func renderHTML(name, text string, vars map[string]interface{}) (string, error) {
+	t, err := htmltemplate.New(name).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
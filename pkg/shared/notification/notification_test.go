@@ -0,0 +1,37 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderTemplateKeepsSubjectOnOneLine(t *testing.T) {
+	cases := []struct {
+		name    string
+		subject string
+		vars    map[string]interface{}
+		want    string
+	}{
+		{"Plain", "Welcome to {{.company_name}}", map[string]interface{}{"company_name": "Acme"}, "Welcome to Acme"},
+		{"CRLF In Variable", "Welcome {{.first_name}}", map[string]interface{}{"first_name": "Eve\r\nBcc: victim@example.com"}, "Welcome Eve Bcc: victim@example.com"},
+		{"Bare LF In Variable", "Hi {{.first_name}}", map[string]interface{}{"first_name": "Eve\nBcc: victim@example.com"}, "Hi Eve Bcc: victim@example.com"},
+		{"Trailing Newline", "Hi {{.first_name}}\n", map[string]interface{}{"first_name": "Eve"}, "Hi Eve"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			msg, err := RenderTemplate(&Template{Subject: tc.subject, Body: "body"}, tc.vars)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, msg.Subject)
+			assert.NotContains(t, msg.Subject, "\r")
+			assert.NotContains(t, msg.Subject, "\n")
+		})
+	}
+}
+
+func TestValidateSubject(t *testing.T) {
+	assert.NoError(t, ValidateSubject("Order {{.reference}} shipped"))
+	assert.Error(t, ValidateSubject("Order shipped\r\nBcc: victim@example.com"))
+	assert.Error(t, ValidateSubject("Order shipped\nBcc: victim@example.com"))
+}
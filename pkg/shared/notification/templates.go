@@ -0,0 +1,214 @@
+package notification
+
+import "github.com/liquorpro/go-backend/pkg/shared/models"
+
+// Template is a subject/body pair rendered with Go template syntax, e.g. {{.user_name}}
+type Template struct {
+	Event     string   `json:"event"`
+	Subject   string   `json:"subject"`
+	Body      string   `json:"body"`
+	IsHTML    bool     `json:"is_html"`
+	Variables []string `json:"variables"`
+}
+
+// Events lists the supported template events in display order
+var Events = []string{
+	models.EmailEventWelcome,
+	models.EmailEventPasswordReset,
+	models.EmailEventLowStock,
+	models.EmailEventInvoice,
+	models.EmailEventApproval,
+	models.EmailEventTransferInbound,
+	models.EmailEventTransferCompleted,
+	models.EmailEventCollectionOverdue,
+	models.EmailEventDailySalesSummary,
+	models.EmailEventPaymentFailed,
+	models.EmailEventSubscriptionCancelled,
+}
+
+// DefaultTemplates are used whenever a tenant has not customised an event
+var DefaultTemplates = map[string]Template{
+	models.EmailEventWelcome: {
+		Event:   models.EmailEventWelcome,
+		Subject: "Welcome to {{.company_name}}",
+		Body: `Hello {{.user_name}},
+
+Your account on {{.company_name}} has been created.
+Username: {{.username}}
+
+You can sign in at any time to start managing your shops.
+
+Regards,
+{{.company_name}}`,
+		Variables: []string{"user_name", "username", "company_name"},
+	},
+	models.EmailEventPasswordReset: {
+		Event:   models.EmailEventPasswordReset,
+		Subject: "Reset your {{.company_name}} password",
+		Body: `Hello {{.user_name}},
+
+We received a request to reset your password. Use the link below within {{.expires_in}}:
+
+{{.reset_link}}
+
+If you did not request this, you can ignore this email.
+
+Regards,
+{{.company_name}}`,
+		Variables: []string{"user_name", "company_name", "reset_link", "expires_in"},
+	},
+	models.EmailEventLowStock: {
+		Event:   models.EmailEventLowStock,
+		Subject: "Low stock alert: {{.product_name}} at {{.shop_name}}",
+		Body: `Hello,
+
+{{.product_name}} at {{.shop_name}} is running low.
+Current quantity: {{.quantity}}
+Minimum level: {{.minimum_level}}
+
+Please arrange a reorder.
+
+Regards,
+{{.company_name}}`,
+		Variables: []string{"product_name", "shop_name", "quantity", "minimum_level", "company_name"},
+	},
+	models.EmailEventInvoice: {
+		Event:   models.EmailEventInvoice,
+		Subject: "Invoice {{.invoice_number}} from {{.company_name}}",
+		Body: `Hello {{.customer_name}},
+
+Please find the details of invoice {{.invoice_number}} below.
+Amount: {{.amount}}
+Due date: {{.due_date}}
+
+Regards,
+{{.company_name}}`,
+		Variables: []string{"customer_name", "invoice_number", "amount", "due_date", "company_name"},
+	},
+	models.EmailEventApproval: {
+		Event:   models.EmailEventApproval,
+		Subject: "{{.record_type}} {{.reference}} was {{.status}}",
+		Body: `Hello {{.user_name}},
+
+{{.record_type}} {{.reference}} was {{.status}} by {{.approver_name}}.
+{{if .notes}}
+Notes: {{.notes}}
+{{end}}
+Regards,
+{{.company_name}}`,
+		Variables: []string{"user_name", "record_type", "reference", "status", "approver_name", "notes", "company_name"},
+	},
+	models.EmailEventTransferInbound: {
+		Event:   models.EmailEventTransferInbound,
+		Subject: "Inbound stock transfer {{.reference}} from {{.from_shop}}",
+		Body: `Hello,
+
+Stock transfer {{.reference}} from {{.from_shop}} to {{.to_shop}} has been created.
+Items: {{.item_count}} ({{.total_quantity}} units)
+Total cost: {{.total_cost}}
+
+Please account for this stock before placing new orders.
+
+Regards,
+{{.company_name}}`,
+		Variables: []string{"reference", "from_shop", "to_shop", "item_count", "total_quantity", "total_cost", "company_name"},
+	},
+	models.EmailEventTransferCompleted: {
+		Event:   models.EmailEventTransferCompleted,
+		Subject: "Stock transfer {{.reference}} completed",
+		Body: `Hello,
+
+Stock transfer {{.reference}} from {{.from_shop}} to {{.to_shop}} is complete.
+Items: {{.item_count}} ({{.total_quantity}} units)
+Transferred value: {{.total_cost}}
+
+Regards,
+{{.company_name}}`,
+		Variables: []string{"reference", "from_shop", "to_shop", "item_count", "total_quantity", "total_cost", "company_name"},
+	},
+	models.EmailEventCollectionOverdue: {
+		Event:   models.EmailEventCollectionOverdue,
+		Subject: "{{.count}} money collection(s) overdue",
+		Body: `Hello {{.manager_name}},
+
+These money collections were not approved before their deadline and are now overdue:
+{{range .collections}}
+- {{.executive_name}} at {{.shop_name}}: {{.amount}}, overdue by {{.overdue_for}}{{end}}
+
+Total overdue: {{.total_amount}}
+
+This cash is unaccounted for until the collections are approved.
+
+Regards,
+{{.company_name}}`,
+		// collections is a list; each entry has executive_name, shop_name, amount and overdue_for
+		Variables: []string{"manager_name", "count", "collections", "total_amount", "company_name"},
+	},
+	models.EmailEventDailySalesSummary: {
+		Event:   models.EmailEventDailySalesSummary,
+		Subject: "{{.company_name}} sales summary for {{.date}}",
+		Body: `Hello,
+
+Here are the numbers for {{.date}}.
+
+Sales: {{.sales_count}} for {{.sales_amount}} ({{.approved_amount}} approved)
+Returns: {{.returns_count}} for {{.returns_amount}}
+Approved revenue: {{.total_revenue}}
+
+Cash: {{.cash_amount}}
+Card: {{.card_amount}}
+UPI: {{.upi_amount}}
+Credit: {{.credit_amount}}
+{{if .shops}}
+By shop:{{range .shops}}
+- {{.shop_name}}: {{.total_sales}} record(s), {{.total_amount}}{{end}}
+{{end}}{{if .top_products}}
+Top products:{{range .top_products}}
+- {{.product_name}}: {{.quantity}} sold, {{.amount}}{{end}}
+{{end}}
+Waiting for approval: {{.pending_sales}} sale(s), {{.pending_returns}} return(s), {{.pending_expenses}} expense(s)
+
+Regards,
+{{.company_name}}`,
+		// shops and top_products are lists; shop entries have shop_name, total_sales and
+		// total_amount, product entries have product_name, quantity and amount
+		Variables: []string{"date", "sales_count", "sales_amount", "approved_amount", "returns_count", "returns_amount",
+			"total_revenue", "cash_amount", "card_amount", "upi_amount", "credit_amount", "shops", "top_products",
+			"pending_sales", "pending_returns", "pending_expenses", "company_name"},
+	},
+	models.EmailEventPaymentFailed: {
+		Event:   models.EmailEventPaymentFailed,
+		Subject: "Payment of {{.amount}} for your {{.plan_name}} subscription failed",
+		Body: `Hello,
+
+We could not collect {{.amount}} for your {{.plan_name}} subscription.
+{{if .next_retry}}
+We will try again on {{.next_retry}}. Please make sure your payment method is up to date.
+{{else}}
+All retries have failed and your subscription is now past due. It will be cancelled on {{.cancels_on}} unless the payment is made.
+{{end}}
+Regards,
+{{.company_name}}`,
+		// next_retry is empty once no retries are left, when cancels_on is set instead
+		Variables: []string{"amount", "plan_name", "attempt", "next_retry", "cancels_on", "company_name"},
+	},
+	models.EmailEventSubscriptionCancelled: {
+		Event:   models.EmailEventSubscriptionCancelled,
+		Subject: "Your {{.plan_name}} subscription has been cancelled",
+		Body: `Hello,
+
+Your {{.plan_name}} subscription has been cancelled because {{.amount}} could not be collected.
+
+Subscribe again at any time to restore access.
+
+Regards,
+{{.company_name}}`,
+		Variables: []string{"amount", "plan_name", "company_name"},
+	},
+}
+
+// IsValidEvent reports whether the event has a built-in default template
+func IsValidEvent(event string) bool {
+	_, ok := DefaultTemplates[event]
+	return ok
+}
@@ -0,0 +1,176 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Page layout for A4 portrait in points, using the built-in Courier fonts so no
+// font has to be embedded
+const (
+	pageWidth    = 595
+	pageHeight   = 842
+	margin       = 40
+	fontSize     = 9
+	leading      = 12
+	linesPerPage = (pageHeight - 2*margin) / leading
+
+	// Columns is the number of monospaced characters that fit on a line
+	Columns = 95
+)
+
+type line struct {
+	text string
+	bold bool
+}
+
+// Document is a minimal text-only PDF for printable reports. Lines are laid out
+// top to bottom and flow onto new pages, each numbered in the footer.
+type Document struct {
+	title string
+	lines []line
+}
+
+// New creates an empty document with the given title
+func New(title string) *Document {
+	return &Document{title: title}
+}
+
+// Heading adds a bold line
+func (d *Document) Heading(text string) {
+	d.lines = append(d.lines, line{text: text, bold: true})
+}
+
+// Line adds a formatted line of text
+func (d *Document) Line(format string, args ...interface{}) {
+	d.lines = append(d.lines, line{text: fmt.Sprintf(format, args...)})
+}
+
+// Rule adds a horizontal rule across the full width
+func (d *Document) Rule() {
+	d.lines = append(d.lines, line{text: strings.Repeat("-", Columns)})
+}
+
+// Blank adds an empty line
+func (d *Document) Blank() {
+	d.lines = append(d.lines, line{})
+}
+
+// PageBreak starts a new page unless the current one is empty
+func (d *Document) PageBreak() {
+	for len(d.lines)%linesPerPage != 0 {
+		d.Blank()
+	}
+}
+
+// Bytes renders the document
+func (d *Document) Bytes() []byte {
+	pages := d.pages()
+
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects 1-5 are fixed; each page then takes a page and a content object
+	const firstPage = 6
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title (%s) /Producer (LiquorPro) /CreationDate (D:%s) >>",
+		escape(d.title), time.Now().UTC().Format("20060102150405Z")))
+
+	for i, page := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, firstPage+2*i+1))
+		content := d.content(page, i+1, len(pages))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.Bytes()
+}
+
+// pages splits the lines into pages; an empty document still has one page
+func (d *Document) pages() [][]line {
+	var pages [][]line
+	for start := 0; start < len(d.lines); start += linesPerPage {
+		end := start + linesPerPage
+		if end > len(d.lines) {
+			end = len(d.lines)
+		}
+		pages = append(pages, d.lines[start:end])
+	}
+	if len(pages) == 0 {
+		pages = append(pages, nil)
+	}
+	return pages
+}
+
+// content builds the content stream for one page
+func (d *Document) content(lines []line, number, total int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "BT\n%d TL\n%d %d Td\n", leading, margin, pageHeight-margin)
+
+	bold := false
+	fmt.Fprintf(&b, "/F1 %d Tf\n", fontSize)
+	for _, l := range lines {
+		if l.bold != bold {
+			bold = l.bold
+			font := "F1"
+			if bold {
+				font = "F2"
+			}
+			fmt.Fprintf(&b, "/%s %d Tf\n", font, fontSize)
+		}
+		fmt.Fprintf(&b, "(%s) Tj T*\n", escape(truncate(l.text)))
+	}
+	b.WriteString("ET\n")
+
+	footer := fmt.Sprintf("Page %d of %d", number, total)
+	fmt.Fprintf(&b, "BT\n/F1 %d Tf\n%d %d Td\n(%s) Tj\nET", fontSize, margin, margin/2, escape(footer))
+
+	return b.String()
+}
+
+func truncate(text string) string {
+	if len(text) > Columns {
+		return text[:Columns]
+	}
+	return text
+}
+
+// escape makes text safe inside a PDF string literal. Characters outside
+// printable ASCII are replaced, as the standard fonts cannot show them.
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,268 @@
+package permissions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/cache"
+	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"gorm.io/gorm"
+)
+
+const overridesCacheKey = "role_permissions:%s" // tenant_id
+
+// Permission codes checked by PermissionMiddleware
+const (
+	SalesCreate        = "sales.create"
+	SalesApprove       = "sales.approve"
+	CollectionsRecord  = "collections.record"
+	CollectionsApprove = "collections.approve"
+	ExpensesCreate     = "expenses.create"
+	ExpensesApprove    = "expenses.approve"
+	DepositsCreate     = "deposits.create"
+	DepositsApprove    = "deposits.approve"
+)
+
+// Permission describes one grantable action
+type Permission struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+// Catalog lists every permission in display order
+var Catalog = []Permission{
+	{Code: SalesCreate, Description: "Record sales, daily sales records and returns"},
+	{Code: SalesApprove, Description: "Approve, reject and void sales and returns"},
+	{Code: CollectionsRecord, Description: "Record money collected from executives"},
+	{Code: CollectionsApprove, Description: "Approve and reject money collections"},
+	{Code: ExpensesCreate, Description: "Record expenses"},
+	{Code: ExpensesApprove, Description: "Approve and reject expenses"},
+	{Code: DepositsCreate, Description: "Record bank deposits of collected cash"},
+	{Code: DepositsApprove, Description: "Approve bank deposits"},
+}
+
+// Roles are the tenant roles whose permissions can be customised. Admins always hold
+// every permission so a tenant cannot lock itself out.
+var Roles = []string{
+	models.RoleManager,
+	models.RoleRegionalManager,
+	models.RoleAssistantManager,
+	models.RoleExecutive,
+	models.RoleSalesman,
+}
+
+// approverRoles may hold the approval permissions by default. Who actually approves
+// is still narrowed by the tenant's approver settings (see settings.CanApprove).
+var approverRoles = []string{models.RoleManager, models.RoleRegionalManager, models.RoleAssistantManager, models.RoleExecutive, models.RoleSalesman}
+
+// defaults reproduce the role checks the routes made before permissions existed
+var defaults = map[string][]string{
+	SalesCreate:        {models.RoleSalesman, models.RoleManager, models.RoleRegionalManager},
+	SalesApprove:       approverRoles,
+	CollectionsRecord:  {models.RoleAssistantManager, models.RoleManager, models.RoleRegionalManager},
+	CollectionsApprove: approverRoles,
+	ExpensesCreate:     {models.RoleSalesman, models.RoleManager, models.RoleRegionalManager},
+	ExpensesApprove:    approverRoles,
+	DepositsCreate:     {models.RoleAssistantManager, models.RoleManager, models.RoleRegionalManager},
+	DepositsApprove:    {models.RoleManager, models.RoleRegionalManager},
+}
+
+// IsValid reports whether code is a known permission
+func IsValid(code string) bool {
+	_, ok := defaults[code]
+	return ok
+}
+
+// DefaultPermissions returns the permissions a role holds unless a tenant changes them
+func DefaultPermissions(role string) []string {
+	granted := []string{}
+	for _, permission := range Catalog {
+		if role == models.RoleAdmin || containsString(defaults[permission.Code], role) {
+			granted = append(granted, permission.Code)
+		}
+	}
+	return granted
+}
+
+// RolePermissionsRequest replaces the permissions held by a role
+type RolePermissionsRequest struct {
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+// Service resolves role permissions per tenant
+type Service struct {
+	db    *database.DB
+	cache *cache.Cache
+}
+
+// NewService creates a new permissions service
+func NewService(db *database.DB, cache *cache.Cache) *Service {
+	return &Service{
+		db:    db,
+		cache: cache,
+	}
+}
+
+// Has reports whether role holds permission in the tenant
+func (s *Service) Has(ctx context.Context, tenantID uuid.UUID, role, permission string) bool {
+	if role == models.RoleAdmin {
+		return true
+	}
+	return containsString(s.RolePermissions(ctx, tenantID, role), permission)
+}
+
+// RolePermissions returns the role's permissions in the tenant. When the tenant's
+// overrides cannot be read the defaults apply.
+func (s *Service) RolePermissions(ctx context.Context, tenantID uuid.UUID, role string) []string {
+	granted := DefaultPermissions(role)
+	if role == models.RoleAdmin {
+		return granted
+	}
+
+	overrides, err := s.overrides(ctx, tenantID)
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		return granted
+	}
+
+	resolved := []string{}
+	for _, permission := range Catalog {
+		has := containsString(granted, permission.Code)
+		if override, ok := overrides[role][permission.Code]; ok {
+			has = override
+		}
+		if has {
+			resolved = append(resolved, permission.Code)
+		}
+	}
+	return resolved
+}
+
+// Matrix returns the permissions of every customisable role in the tenant
+func (s *Service) Matrix(ctx context.Context, tenantID uuid.UUID) map[string][]string {
+	matrix := make(map[string][]string, len(Roles)+1)
+	matrix[models.RoleAdmin] = DefaultPermissions(models.RoleAdmin)
+	for _, role := range Roles {
+		matrix[role] = s.RolePermissions(ctx, tenantID, role)
+	}
+	return matrix
+}
+
+// SetRolePermissions replaces the permissions a role holds in the tenant. Only the
+// differences from the defaults are stored, so later changes to the defaults still
+// reach roles the tenant has not customised in that respect.
+func (s *Service) SetRolePermissions(ctx context.Context, tenantID uuid.UUID, role string, granted []string) ([]string, error) {
+	if err := validateRole(role); err != nil {
+		return nil, err
+	}
+	for _, code := range granted {
+		if !IsValid(code) {
+			return nil, fmt.Errorf("unknown permission: %s", code)
+		}
+	}
+
+	defaultGrants := DefaultPermissions(role)
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("tenant_id = ? AND role = ?", tenantID, role).
+			Delete(&models.RolePermission{}).Error; err != nil {
+			return fmt.Errorf("failed to clear role permissions: %w", err)
+		}
+
+		for _, permission := range Catalog {
+			want := containsString(granted, permission.Code)
+			if want == containsString(defaultGrants, permission.Code) {
+				continue
+			}
+			override := models.RolePermission{
+				TenantModel: models.TenantModel{TenantID: tenantID},
+				Role:        role,
+				Permission:  permission.Code,
+				Granted:     want,
+			}
+			if err := tx.Create(&override).Error; err != nil {
+				return fmt.Errorf("failed to save role permission: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidate(ctx, tenantID)
+	return s.RolePermissions(ctx, tenantID, role), nil
+}
+
+// ResetRolePermissions restores a role's default permissions in the tenant
+func (s *Service) ResetRolePermissions(ctx context.Context, tenantID uuid.UUID, role string) ([]string, error) {
+	if err := validateRole(role); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.WithContext(ctx).Unscoped().Where("tenant_id = ? AND role = ?", tenantID, role).
+		Delete(&models.RolePermission{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to reset role permissions: %w", err)
+	}
+
+	s.invalidate(ctx, tenantID)
+	return DefaultPermissions(role), nil
+}
+
+// overrides returns the tenant's stored overrides by role and permission
+func (s *Service) overrides(ctx context.Context, tenantID uuid.UUID) (map[string]map[string]bool, error) {
+	cacheKey := fmt.Sprintf(overridesCacheKey, tenantID.String())
+
+	var cached map[string]map[string]bool
+	if s.cache != nil {
+		if err := s.cache.Get(ctx, cacheKey, &cached); err == nil {
+			return cached, nil
+		}
+	}
+
+	var rows []models.RolePermission
+	if err := s.db.WithContext(ctx).Where("tenant_id = ?", tenantID).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get role permissions: %w", err)
+	}
+
+	overrides := make(map[string]map[string]bool)
+	for _, row := range rows {
+		if overrides[row.Role] == nil {
+			overrides[row.Role] = make(map[string]bool)
+		}
+		overrides[row.Role][row.Permission] = row.Granted
+	}
+
+	if s.cache != nil {
+		s.cache.Set(ctx, cacheKey, overrides, cache.DefaultTTL)
+	}
+
+	return overrides, nil
+}
+
+func (s *Service) invalidate(ctx context.Context, tenantID uuid.UUID) {
+	if s.cache != nil {
+		s.cache.Delete(ctx, fmt.Sprintf(overridesCacheKey, tenantID.String()))
+	}
+}
+
+func validateRole(role string) error {
+	if role == models.RoleAdmin {
+		return errors.New("admin permissions cannot be changed")
+	}
+	if !containsString(Roles, role) {
+		return fmt.Errorf("unknown role: %s", role)
+	}
+	return nil
+}
+
+func containsString(items []string, value string) bool {
+	for _, item := range items {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package scope
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"gorm.io/gorm"
+)
+
+// ErrShopNotAllowed is returned when a caller asks for a shop outside their scope
+var ErrShopNotAllowed = errors.New("access to this shop is not allowed")
+
+type contextKey struct{}
+
+// ShopScope is the set of shops a caller may see. An unrestricted scope covers
+// every shop in the tenant.
+type ShopScope struct {
+	Restricted bool
+	ShopIDs    []uuid.UUID
+}
+
+// Allows reports whether the shop is inside the scope
+func (s *ShopScope) Allows(shopID uuid.UUID) bool {
+	if s == nil || !s.Restricted {
+		return true
+	}
+	for _, id := range s.ShopIDs {
+		if id == shopID {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply limits a query to the scope's shops using the given shop_id column.
+// A restricted scope without shops matches nothing.
+func (s *ShopScope) Apply(query *gorm.DB, column string) *gorm.DB {
+	if s == nil || !s.Restricted {
+		return query
+	}
+	if len(s.ShopIDs) == 0 {
+		return query.Where("1 = 0")
+	}
+	return query.Where(column+" IN ?", s.ShopIDs)
+}
+
+// Filter returns Apply as a gorm scope for use in chained queries
+func (s *ShopScope) Filter(column string) func(*gorm.DB) *gorm.DB {
+	return func(query *gorm.DB) *gorm.DB {
+		return s.Apply(query, column)
+	}
+}
+
+// Key identifies the scope's shops, e.g. for cache keys
+func (s *ShopScope) Key() string {
+	if s == nil || !s.Restricted {
+		return "all"
+	}
+	ids := make([]string, len(s.ShopIDs))
+	for i, id := range s.ShopIDs {
+		ids[i] = id.String()
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ",")
+}
+
+// Narrow restricts the scope to a single requested shop, failing when the shop
+// is outside the scope. A nil request leaves the scope unchanged.
+func (s *ShopScope) Narrow(requested *uuid.UUID) (*ShopScope, error) {
+	if requested == nil {
+		return s, nil
+	}
+	if !s.Allows(*requested) {
+		return nil, ErrShopNotAllowed
+	}
+	return &ShopScope{Restricted: true, ShopIDs: []uuid.UUID{*requested}}, nil
+}
+
+// WithShopScope returns a context carrying the caller's shop scope
+func WithShopScope(ctx context.Context, scope *ShopScope) context.Context {
+	return context.WithValue(ctx, contextKey{}, scope)
+}
+
+// FromContext returns the shop scope stored in the context, or nil (unrestricted)
+// when none was set
+func FromContext(ctx context.Context) *ShopScope {
+	if ctx == nil {
+		return nil
+	}
+	scope, _ := ctx.Value(contextKey{}).(*ShopScope)
+	return scope
+}
+
+// Resolver works out which shops a user may see from their role
+type Resolver struct {
+	db *database.DB
+}
+
+// NewResolver creates a new shop scope resolver
+func NewResolver(db *database.DB) *Resolver {
+	return &Resolver{db: db}
+}
+
+// Resolve returns the shop scope for a user. Salesmen see the shop they are
+// assigned to and regional managers the active shops in their shop group; every
+// other role sees the whole tenant.
+func (r *Resolver) Resolve(ctx context.Context, tenantID, userID uuid.UUID, role string) (*ShopScope, error) {
+	switch role {
+	case models.RoleSalesman:
+		var salesman models.Salesman
+		err := r.db.WithContext(ctx).
+			Where("user_id = ? AND tenant_id = ? AND is_active = ?", userID, tenantID, true).
+			First(&salesman).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("salesman is not assigned to a shop")
+			}
+			return nil, fmt.Errorf("failed to get salesman shop: %w", err)
+		}
+		return &ShopScope{Restricted: true, ShopIDs: []uuid.UUID{salesman.ShopID}}, nil
+
+	case models.RoleRegionalManager:
+		var user models.User
+		err := r.db.WithContext(ctx).
+			Select("id", "shop_group_id").
+			Where("id = ? AND tenant_id = ?", userID, tenantID).
+			First(&user).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("user not found")
+			}
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		if user.ShopGroupID == nil {
+			return nil, fmt.Errorf("regional manager is not assigned to a shop group")
+		}
+
+		shopIDs, err := r.GroupShopIDs(ctx, tenantID, *user.ShopGroupID)
+		if err != nil {
+			return nil, err
+		}
+		return &ShopScope{Restricted: true, ShopIDs: shopIDs}, nil
+	}
+
+	return &ShopScope{}, nil
+}
+
+// GroupShopIDs returns the active shops in an active shop group
+func (r *Resolver) GroupShopIDs(ctx context.Context, tenantID, groupID uuid.UUID) ([]uuid.UUID, error) {
+	var shopIDs []uuid.UUID
+	err := r.db.WithContext(ctx).Table("shop_group_shops").
+		Joins("JOIN shop_groups ON shop_groups.id = shop_group_shops.shop_group_id").
+		Joins("JOIN shops ON shops.id = shop_group_shops.shop_id").
+		Where("shop_groups.id = ? AND shop_groups.tenant_id = ? AND shop_groups.is_active = ? AND shop_groups.deleted_at IS NULL",
+			groupID, tenantID, true).
+		Where("shops.is_active = ? AND shops.deleted_at IS NULL", true).
+		Pluck("shop_group_shops.shop_id", &shopIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shop group shops: %w", err)
+	}
+	return shopIDs, nil
+}
@@ -0,0 +1,109 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Approvable entity types
+const (
+	ApprovalExpenses    = "expenses"
+	ApprovalCollections = "collections"
+	ApprovalSales       = "sales"
+	ApprovalDeposits    = "deposits"
+)
+
+var approverKeys = map[string]string{
+	ApprovalExpenses:    KeyApproverRolesExpenses,
+	ApprovalCollections: KeyApproverRolesCollections,
+	ApprovalSales:       KeyApproverRolesSales,
+	ApprovalDeposits:    KeyApproverRolesDeposits,
+}
+
+// ApprovalEntities returns the entity types whose approvers are configurable
+func ApprovalEntities() []string {
+	return []string{ApprovalExpenses, ApprovalCollections, ApprovalSales, ApprovalDeposits}
+}
+
+// ApproverRoleOptions returns the roles approval authority may be granted to
+func ApproverRoleOptions() []string {
+	return approverRoleOptions
+}
+
+// ApproverKey returns the setting key holding the approver roles for an entity type
+func ApproverKey(entity string) (string, error) {
+	key, ok := approverKeys[entity]
+	if !ok {
+		return "", fmt.Errorf("unknown approval entity: %s", entity)
+	}
+	return key, nil
+}
+
+// ApproverRoles returns the roles allowed to approve an entity type for the tenant
+func (s *Service) ApproverRoles(ctx context.Context, tenantID uuid.UUID, entity string) []string {
+	key, err := ApproverKey(entity)
+	if err != nil {
+		return nil
+	}
+	return s.GetList(ctx, tenantID, key)
+}
+
+// CanApprove reports whether role may approve the entity type. Admins can always
+// approve so a misconfiguration cannot lock a tenant out of its own approvals.
+func (s *Service) CanApprove(ctx context.Context, tenantID uuid.UUID, entity, role string) bool {
+	if role == "admin" {
+		return true
+	}
+	return containsString(s.ApproverRoles(ctx, tenantID, entity), role)
+}
+
+// ApproversRequest represents a change to who may approve each entity type
+type ApproversRequest struct {
+	Approvers map[string][]string `json:"approvers" binding:"required"`
+}
+
+// GetApprovers returns the approver roles for every entity type
+func (s *Service) GetApprovers(ctx context.Context, tenantID uuid.UUID) map[string][]string {
+	approvers := make(map[string][]string, len(approverKeys))
+	for _, entity := range ApprovalEntities() {
+		approvers[entity] = s.ApproverRoles(ctx, tenantID, entity)
+	}
+	return approvers
+}
+
+// UpdateApprovers stores approver roles per entity type through the regular
+// settings update so changes are validated and audited
+func (s *Service) UpdateApprovers(ctx context.Context, tenantID, userID uuid.UUID, approvers map[string][]string) (map[string][]string, error) {
+	changes := make(map[string]interface{}, len(approvers))
+	keyEntities := make(map[string]string, len(approvers))
+	validationErrors := make(map[string]string)
+	for entity, roles := range approvers {
+		key, err := ApproverKey(entity)
+		if err != nil {
+			validationErrors[entity] = "unknown approval entity"
+			continue
+		}
+		changes[key] = roles
+		keyEntities[key] = entity
+	}
+	if len(validationErrors) > 0 {
+		return nil, &ValidationError{Errors: validationErrors}
+	}
+
+	if _, err := s.Update(ctx, tenantID, userID, changes); err != nil {
+		// Report validation problems against the entity names the caller used
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			for key, message := range validationErr.Errors {
+				validationErrors[keyEntities[key]] = message
+			}
+			return nil, &ValidationError{Errors: validationErrors}
+		}
+		return nil, err
+	}
+
+	return s.GetApprovers(ctx, tenantID), nil
+}
@@ -0,0 +1,494 @@
+package settings
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/liquorpro/go-backend/pkg/shared/utils"
+)
+
+// Setting value types
+const (
+	TypeString = "string"
+	TypeInt    = "int"
+	TypeFloat  = "float"
+	TypeBool   = "bool"
+	TypeList   = "list"
+)
+
+// Setting keys
+const (
+	KeyTimezone                 = "timezone"
+	KeyApprovalDeadlineMinutes  = "approval_deadline_minutes"
+	KeyReturnWindowDays         = "return_window_days"
+	KeyAutoApprovalEnabled      = "auto_approval_enabled"
+	KeyStockDisplayUnit         = "stock_display_unit"
+	KeyApproverRolesExpenses    = "approver_roles_expenses"
+	KeyApproverRolesCollections = "approver_roles_collections"
+	KeyApproverRolesSales       = "approver_roles_sales"
+	KeyApproverRolesDeposits    = "approver_roles_deposits"
+	KeyReadAuditResources       = "read_audit_resources"
+	KeyCancellationMode         = "subscription_cancellation_mode"
+	KeyCancellationProrate      = "subscription_cancellation_prorate_refund"
+	KeyCollectionBalancePolicy  = "collection_balance_policy"
+	KeyWriteOffExpenseCategory  = "write_off_expense_category"
+	KeyCatalogSnapshotRetention = "catalog_snapshot_retention"
+	KeyRepeatDecisionMode       = "approval_repeat_decision_mode"
+	KeyTransferApprovalRequired = "stock_transfer_approval_required"
+	KeyReorderTargetPercent     = "reorder_target_percent"
+	KeyGSTNumber                = "gst_number"
+	KeyGSTState                 = "gst_state"
+	KeyGSTDefaultSupply         = "gst_default_supply"
+	KeyExpenseApprovalThreshold = "expense_approval_threshold"
+	KeySalesApprovalRequired    = "require_sales_approval"
+	KeyOverdueCollectionEmails  = "overdue_collection_emails"
+	KeyDailySummaryRecipients   = "daily_summary_recipients"
+	KeyDailySummarySendTime     = "daily_summary_send_time"
+	KeyValidateStockOnSale      = "validate_stock_on_sale"
+)
+
+// Policies for money collections that exceed the executive's outstanding balance
+const (
+	CollectionBalanceBlock = "block"
+	CollectionBalanceWarn  = "warn"
+	CollectionBalanceAllow = "allow"
+)
+
+// Stock display units
+const (
+	StockDisplayUnits = "units"
+	StockDisplayCases = "cases"
+)
+
+// Responses to an approve/reject on a record that has already been decided
+const (
+	RepeatDecisionConflict = "conflict"
+	RepeatDecisionReplay   = "replay"
+)
+
+// GST treatments of a supply
+const (
+	SupplyIntraState = "intra_state"
+	SupplyInterState = "inter_state"
+)
+
+// Subscription cancellation modes
+const (
+	CancellationImmediate   = "immediate"
+	CancellationEndOfPeriod = "end_of_period"
+)
+
+// Definition describes a typed, validated tenant setting
+type Definition struct {
+	Key         string      `json:"key"`
+	Type        string      `json:"type"`
+	Default     interface{} `json:"default"`
+	Description string      `json:"description"`
+	Options     []string    `json:"options,omitempty"`
+	Min         *float64    `json:"min,omitempty"`
+	Max         *float64    `json:"max,omitempty"`
+
+	validate func(value interface{}) error
+}
+
+// Sensitive resources whose reads can be audited
+const (
+	ReadAuditVendorBankAccounts = "vendor_bank_accounts"
+	ReadAuditBankAccounts       = "bank_accounts"
+	ReadAuditFinancialReports   = "financial_reports"
+	ReadAuditCommissions        = "commissions"
+)
+
+// approverRoleOptions are the roles a tenant may grant approval authority to
+var approverRoleOptions = []string{"admin", "manager", "regional_manager", "assistant_manager", "executive", "salesman"}
+
+var gstNumberPattern = regexp.MustCompile(`^[0-9]{2}[A-Z]{5}[0-9]{4}[A-Z][1-9A-Z]Z[0-9A-Z]$`)
+
+// DailySummaryTimeLayout is the HH:MM layout of the daily summary send time
+const DailySummaryTimeLayout = "15:04"
+
+func bound(v float64) *float64 {
+	return &v
+}
+
+// definitions is the registry of supported settings, in display order
+var definitions = []*Definition{
+	{
+		Key:         KeyTimezone,
+		Type:        TypeString,
+		Default:     "Asia/Kolkata",
+		Description: "IANA timezone used for business days and reports",
+		validate: func(value interface{}) error {
+			if _, err := time.LoadLocation(value.(string)); err != nil {
+				return fmt.Errorf("unknown timezone")
+			}
+			return nil
+		},
+	},
+	{
+		Key:         KeyApprovalDeadlineMinutes,
+		Type:        TypeInt,
+		Default:     15,
+		Description: "Minutes a money collection may stay pending before it is overdue",
+		Min:         bound(1),
+		Max:         bound(1440),
+	},
+	{
+		Key:         KeyReturnWindowDays,
+		Type:        TypeInt,
+		Default:     7,
+		Description: "Days after a sale during which returns are accepted",
+		Min:         bound(0),
+		Max:         bound(365),
+	},
+	{
+		Key:         KeyAutoApprovalEnabled,
+		Type:        TypeBool,
+		Default:     false,
+		Description: "Auto-approve collections and daily sales from trusted executives below their limit",
+	},
+	{
+		Key:         KeyStockDisplayUnit,
+		Type:        TypeString,
+		Default:     StockDisplayUnits,
+		Description: "Unit stock reports present quantities in",
+		Options:     []string{StockDisplayUnits, StockDisplayCases},
+	},
+	{
+		Key:         KeyApproverRolesExpenses,
+		Type:        TypeList,
+		Default:     []string{"manager", "admin"},
+		Description: "Roles that may approve expenses",
+		Options:     approverRoleOptions,
+		validate:    requireNonEmptyList,
+	},
+	{
+		Key:         KeyApproverRolesCollections,
+		Type:        TypeList,
+		Default:     []string{"manager", "admin"},
+		Description: "Roles that may approve money collections",
+		Options:     approverRoleOptions,
+		validate:    requireNonEmptyList,
+	},
+	{
+		Key:         KeyApproverRolesSales,
+		Type:        TypeList,
+		Default:     []string{"manager", "admin"},
+		Description: "Roles that may approve sales, daily sales records and returns",
+		Options:     approverRoleOptions,
+		validate:    requireNonEmptyList,
+	},
+	{
+		Key:         KeyApproverRolesDeposits,
+		Type:        TypeList,
+		Default:     []string{"manager", "admin"},
+		Description: "Roles that may approve bank deposits",
+		Options:     approverRoleOptions,
+		validate:    requireNonEmptyList,
+	},
+	{
+		Key:         KeyReadAuditResources,
+		Type:        TypeList,
+		Default:     []string{},
+		Description: "Sensitive resources whose reads are recorded in the audit log",
+		Options:     []string{ReadAuditVendorBankAccounts, ReadAuditBankAccounts, ReadAuditFinancialReports, ReadAuditCommissions},
+	},
+	{
+		Key:         KeyCancellationMode,
+		Type:        TypeString,
+		Default:     CancellationEndOfPeriod,
+		Description: "Whether cancelling the subscription ends access immediately or at the end of the paid period",
+		Options:     []string{CancellationImmediate, CancellationEndOfPeriod},
+	},
+	{
+		Key:         KeyCancellationProrate,
+		Type:        TypeBool,
+		Default:     false,
+		Description: "Refund the unused part of the paid period when a subscription is cancelled immediately",
+	},
+	{
+		Key:         KeyCollectionBalancePolicy,
+		Type:        TypeString,
+		Default:     CollectionBalanceBlock,
+		Description: "Whether money collections above the executive's uncollected sales are rejected, flagged or accepted",
+		Options:     []string{CollectionBalanceBlock, CollectionBalanceWarn, CollectionBalanceAllow},
+	},
+	{
+		Key:         KeyWriteOffExpenseCategory,
+		Type:        TypeString,
+		Default:     "Inventory Loss",
+		Description: "Expense category approved stock write-offs are booked under; created if missing",
+		validate: func(value interface{}) error {
+			if value.(string) == "" {
+				return fmt.Errorf("must not be empty")
+			}
+			return nil
+		},
+	},
+	{
+		Key:         KeyCatalogSnapshotRetention,
+		Type:        TypeInt,
+		Default:     20,
+		Description: "Number of most recent catalog snapshots kept when a new one is taken (0 keeps all)",
+		Min:         bound(0),
+		Max:         bound(500),
+	},
+	{
+		Key:         KeyRepeatDecisionMode,
+		Type:        TypeString,
+		Default:     RepeatDecisionConflict,
+		Description: "Whether repeating an approval or rejection that already took effect returns a conflict or the current record",
+		Options:     []string{RepeatDecisionConflict, RepeatDecisionReplay},
+	},
+	{
+		Key:         KeyTransferApprovalRequired,
+		Type:        TypeBool,
+		Default:     true,
+		Description: "Hold stock transfers for approval before stock moves; when off, transfers move stock immediately",
+	},
+	{
+		Key:         KeyReorderTargetPercent,
+		Type:        TypeInt,
+		Default:     200,
+		Description: "Level reorder suggestions fill stock up to when it has no maximum level, as a percentage of its minimum level",
+		Min:         bound(100),
+		Max:         bound(1000),
+	},
+	{
+		Key:         KeyGSTNumber,
+		Type:        TypeString,
+		Default:     "",
+		Description: "GSTIN printed on invoices; empty when the tenant is not GST registered",
+		validate: func(value interface{}) error {
+			if gstin := value.(string); gstin != "" && !gstNumberPattern.MatchString(gstin) {
+				return fmt.Errorf("must be a 15-character GSTIN")
+			}
+			return nil
+		},
+	},
+	{
+		Key:         KeyGSTState,
+		Type:        TypeString,
+		Default:     "",
+		Description: "State the tenant is GST registered in; vendor bills from another state are taxed as IGST instead of CGST and SGST",
+	},
+	{
+		Key:         KeyGSTDefaultSupply,
+		Type:        TypeString,
+		Default:     SupplyIntraState,
+		Description: "GST treatment of vendor bills when the tenant's or the vendor's state is unknown; such bills are flagged for review",
+		Options:     []string{SupplyIntraState, SupplyInterState},
+	},
+	{
+		Key:         KeyExpenseApprovalThreshold,
+		Type:        TypeFloat,
+		Default:     0.0,
+		Description: "Expenses above this amount wait for approval; smaller ones are approved when recorded (0 holds every expense for approval)",
+		Min:         bound(0),
+	},
+	{
+		Key:         KeySalesApprovalRequired,
+		Type:        TypeBool,
+		Default:     true,
+		Description: "Hold daily sales records for approval before stock is deducted; when off, records are approved by their creator as they are saved",
+	},
+	{
+		Key:         KeyValidateStockOnSale,
+		Type:        TypeBool,
+		Default:     false,
+		Description: "Refuse daily sales records that sell more of a product than the shop has available, not counting reserved stock",
+	},
+	{
+		Key:         KeyOverdueCollectionEmails,
+		Type:        TypeBool,
+		Default:     true,
+		Description: "Email managers a digest of money collections that miss their approval deadline",
+	},
+	{
+		Key:         KeyDailySummaryRecipients,
+		Type:        TypeList,
+		Default:     []string{},
+		Description: "Email addresses sent each morning's summary of the previous day's sales; empty sends none",
+		validate: func(value interface{}) error {
+			for _, email := range value.([]string) {
+				if !utils.IsValidEmail(email) {
+					return fmt.Errorf("%q is not a valid email address", email)
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Key:         KeyDailySummarySendTime,
+		Type:        TypeString,
+		Default:     "08:00",
+		Description: "Time of day (HH:MM, in the tenant's timezone) the daily sales summary is sent",
+		validate: func(value interface{}) error {
+			if _, err := time.Parse(DailySummaryTimeLayout, value.(string)); err != nil {
+				return fmt.Errorf("must be a time of day as HH:MM")
+			}
+			return nil
+		},
+	},
+}
+
+func requireNonEmptyList(value interface{}) error {
+	if len(value.([]string)) == 0 {
+		return fmt.Errorf("must contain at least one value")
+	}
+	return nil
+}
+
+// Definitions returns all supported setting definitions
+func Definitions() []*Definition {
+	return definitions
+}
+
+// Lookup returns the definition for a key
+func Lookup(key string) (*Definition, bool) {
+	for _, def := range definitions {
+		if def.Key == key {
+			return def, true
+		}
+	}
+	return nil, false
+}
+
+// Defaults returns the default value for every setting
+func Defaults() map[string]interface{} {
+	values := make(map[string]interface{}, len(definitions))
+	for _, def := range definitions {
+		values[def.Key] = def.Default
+	}
+	return values
+}
+
+// Normalize converts a decoded JSON value to the setting's type and validates it
+func (d *Definition) Normalize(value interface{}) (interface{}, error) {
+	var normalized interface{}
+
+	switch d.Type {
+	case TypeString:
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("must be a string")
+		}
+		normalized = strings.TrimSpace(str)
+	case TypeInt:
+		num, ok := toFloat(value)
+		if !ok || num != math.Trunc(num) {
+			return nil, fmt.Errorf("must be an integer")
+		}
+		normalized = int(num)
+	case TypeFloat:
+		num, ok := toFloat(value)
+		if !ok {
+			return nil, fmt.Errorf("must be a number")
+		}
+		normalized = num
+	case TypeBool:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("must be a boolean")
+		}
+		normalized = b
+	case TypeList:
+		items, ok := toStringList(value)
+		if !ok {
+			return nil, fmt.Errorf("must be a list of strings")
+		}
+		for _, item := range items {
+			if len(d.Options) > 0 && !containsString(d.Options, item) {
+				return nil, fmt.Errorf("values must be one of: %s", strings.Join(d.Options, ", "))
+			}
+		}
+		normalized = items
+	default:
+		return nil, fmt.Errorf("unsupported setting type %s", d.Type)
+	}
+
+	if len(d.Options) > 0 && d.Type != TypeList {
+		valid := false
+		for _, option := range d.Options {
+			if normalized == option {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("must be one of: %s", strings.Join(d.Options, ", "))
+		}
+	}
+
+	if num, ok := toFloat(normalized); ok {
+		if d.Min != nil && num < *d.Min {
+			return nil, fmt.Errorf("must be at least %v", *d.Min)
+		}
+		if d.Max != nil && num > *d.Max {
+			return nil, fmt.Errorf("must be at most %v", *d.Max)
+		}
+	}
+
+	if d.validate != nil {
+		if err := d.validate(normalized); err != nil {
+			return nil, err
+		}
+	}
+
+	return normalized, nil
+}
+
+// toStringList converts a decoded JSON array to a trimmed, de-duplicated string slice
+func toStringList(value interface{}) ([]string, bool) {
+	var raw []interface{}
+	switch v := value.(type) {
+	case []string:
+		for _, item := range v {
+			raw = append(raw, item)
+		}
+	case []interface{}:
+		raw = v
+	default:
+		return nil, false
+	}
+
+	items := make([]string, 0, len(raw))
+	for _, item := range raw {
+		str, ok := item.(string)
+		if !ok {
+			return nil, false
+		}
+		str = strings.TrimSpace(str)
+		if str != "" && !containsString(items, str) {
+			items = append(items, str)
+		}
+	}
+	return items, true
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,272 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/cache"
+	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"gorm.io/gorm"
+)
+
+const settingsCacheKey = "tenant_settings:%s"
+
+// ValidationError reports invalid setting keys or values
+type ValidationError struct {
+	Errors map[string]string `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	keys := make([]string, 0, len(e.Errors))
+	for key := range e.Errors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%s: %s", key, e.Errors[key])
+	}
+	return "invalid settings: " + strings.Join(parts, "; ")
+}
+
+// UpdateRequest represents a partial settings update
+type UpdateRequest struct {
+	Settings map[string]interface{} `json:"settings" binding:"required"`
+}
+
+// AuditResponse represents a setting change in responses
+type AuditResponse struct {
+	ID            uuid.UUID `json:"id"`
+	Key           string    `json:"key"`
+	OldValue      string    `json:"old_value"`
+	NewValue      string    `json:"new_value"`
+	ChangedByID   uuid.UUID `json:"changed_by_id"`
+	ChangedByName string    `json:"changed_by_name"`
+	ChangedAt     time.Time `json:"changed_at"`
+}
+
+// Service reads and updates per-tenant settings
+type Service struct {
+	db    *database.DB
+	cache *cache.Cache
+}
+
+// NewService creates a new settings service
+func NewService(db *database.DB, cache *cache.Cache) *Service {
+	return &Service{
+		db:    db,
+		cache: cache,
+	}
+}
+
+// Get returns every setting for the tenant with defaults applied
+func (s *Service) Get(ctx context.Context, tenantID uuid.UUID) (map[string]interface{}, error) {
+	cacheKey := fmt.Sprintf(settingsCacheKey, tenantID.String())
+
+	var cached map[string]interface{}
+	if s.cache != nil {
+		if err := s.cache.Get(ctx, cacheKey, &cached); err == nil {
+			return cached, nil
+		}
+	}
+
+	var rows []models.TenantSetting
+	if err := s.db.WithContext(ctx).Where("tenant_id = ?", tenantID).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get tenant settings: %w", err)
+	}
+
+	values := Defaults()
+	for _, row := range rows {
+		def, ok := Lookup(row.Key)
+		if !ok {
+			continue
+		}
+		var raw interface{}
+		if err := json.Unmarshal([]byte(row.Value), &raw); err != nil {
+			continue
+		}
+		// Stored values that no longer validate fall back to the default
+		if value, err := def.Normalize(raw); err == nil {
+			values[row.Key] = value
+		}
+	}
+
+	if s.cache != nil {
+		s.cache.Set(ctx, cacheKey, values, cache.DefaultTTL)
+	}
+
+	return values, nil
+}
+
+// GetString returns a string setting, falling back to its default on error
+func (s *Service) GetString(ctx context.Context, tenantID uuid.UUID, key string) string {
+	value := s.value(ctx, tenantID, key)
+	str, _ := value.(string)
+	return str
+}
+
+// GetInt returns an integer setting, falling back to its default on error
+func (s *Service) GetInt(ctx context.Context, tenantID uuid.UUID, key string) int {
+	num, _ := toFloat(s.value(ctx, tenantID, key))
+	return int(num)
+}
+
+// GetFloat returns a numeric setting, falling back to its default on error
+func (s *Service) GetFloat(ctx context.Context, tenantID uuid.UUID, key string) float64 {
+	num, _ := toFloat(s.value(ctx, tenantID, key))
+	return num
+}
+
+// GetBool returns a boolean setting, falling back to its default on error
+func (s *Service) GetBool(ctx context.Context, tenantID uuid.UUID, key string) bool {
+	b, _ := s.value(ctx, tenantID, key).(bool)
+	return b
+}
+
+// GetList returns a list setting, falling back to its default on error
+func (s *Service) GetList(ctx context.Context, tenantID uuid.UUID, key string) []string {
+	items, _ := toStringList(s.value(ctx, tenantID, key))
+	return items
+}
+
+func (s *Service) value(ctx context.Context, tenantID uuid.UUID, key string) interface{} {
+	values, err := s.Get(ctx, tenantID)
+	if err == nil {
+		if value, ok := values[key]; ok {
+			return value
+		}
+	}
+	if def, ok := Lookup(key); ok {
+		return def.Default
+	}
+	return nil
+}
+
+// Update validates and stores the given settings, recording an audit entry for each change
+func (s *Service) Update(ctx context.Context, tenantID, userID uuid.UUID, changes map[string]interface{}) (map[string]interface{}, error) {
+	if len(changes) == 0 {
+		return nil, errors.New("no settings provided")
+	}
+
+	// Validate everything before writing anything
+	validationErrors := make(map[string]string)
+	normalized := make(map[string]interface{}, len(changes))
+	for key, raw := range changes {
+		def, ok := Lookup(key)
+		if !ok {
+			validationErrors[key] = "unknown setting"
+			continue
+		}
+		value, err := def.Normalize(raw)
+		if err != nil {
+			validationErrors[key] = err.Error()
+			continue
+		}
+		normalized[key] = value
+	}
+	if len(validationErrors) > 0 {
+		return nil, &ValidationError{Errors: validationErrors}
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for key, value := range normalized {
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return fmt.Errorf("failed to encode setting %s: %w", key, err)
+			}
+
+			var setting models.TenantSetting
+			err = tx.Where("tenant_id = ? AND key = ?", tenantID, key).First(&setting).Error
+			oldValue := ""
+			switch {
+			case err == nil:
+				if setting.Value == string(encoded) {
+					continue
+				}
+				oldValue = setting.Value
+				if err := tx.Model(&setting).Update("value", string(encoded)).Error; err != nil {
+					return fmt.Errorf("failed to update setting %s: %w", key, err)
+				}
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				if def, ok := Lookup(key); ok {
+					defaultValue, _ := json.Marshal(def.Default)
+					oldValue = string(defaultValue)
+				}
+				setting = models.TenantSetting{
+					TenantModel: models.TenantModel{TenantID: tenantID},
+					Key:         key,
+					Value:       string(encoded),
+				}
+				if err := tx.Create(&setting).Error; err != nil {
+					return fmt.Errorf("failed to create setting %s: %w", key, err)
+				}
+			default:
+				return fmt.Errorf("failed to get setting %s: %w", key, err)
+			}
+
+			audit := models.TenantSettingAudit{
+				TenantModel: models.TenantModel{TenantID: tenantID},
+				Key:         key,
+				OldValue:    oldValue,
+				NewValue:    string(encoded),
+				ChangedByID: userID,
+			}
+			if err := tx.Create(&audit).Error; err != nil {
+				return fmt.Errorf("failed to record setting change: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.cache != nil {
+		s.cache.Delete(ctx, fmt.Sprintf(settingsCacheKey, tenantID.String()))
+	}
+
+	return s.Get(ctx, tenantID)
+}
+
+// GetAudit returns the tenant's setting change history, newest first
+func (s *Service) GetAudit(ctx context.Context, tenantID uuid.UUID, key string, limit, offset int) ([]*AuditResponse, int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.TenantSettingAudit{}).Where("tenant_id = ?", tenantID)
+	if key != "" {
+		query = query.Where("key = ?", key)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count setting changes: %w", err)
+	}
+
+	var audits []models.TenantSettingAudit
+	if err := query.Preload("ChangedBy").Order("created_at DESC").Limit(limit).Offset(offset).Find(&audits).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get setting changes: %w", err)
+	}
+
+	responses := make([]*AuditResponse, len(audits))
+	for i, audit := range audits {
+		responses[i] = &AuditResponse{
+			ID:          audit.ID,
+			Key:         audit.Key,
+			OldValue:    audit.OldValue,
+			NewValue:    audit.NewValue,
+			ChangedByID: audit.ChangedByID,
+			ChangedAt:   audit.CreatedAt,
+		}
+		if audit.ChangedBy != nil {
+			responses[i].ChangedByName = audit.ChangedBy.FullName()
+		}
+	}
+
+	return responses, total, nil
+}
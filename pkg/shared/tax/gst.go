@@ -0,0 +1,131 @@
+package tax
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"gorm.io/gorm"
+)
+
+// Breakdown is the GST on an amount. Within a state the tax is split equally into
+// central (CGST) and state (SGST) tax; between states it is all integrated tax (IGST).
+type Breakdown struct {
+	Rate          float64 `json:"rate"`
+	TaxableAmount float64 `json:"taxable_amount"`
+	CGST          float64 `json:"cgst"`
+	SGST          float64 `json:"sgst"`
+	IGST          float64 `json:"igst"`
+	Total         float64 `json:"total"`
+	InterState    bool    `json:"inter_state"`
+}
+
+// Add accumulates another breakdown's amounts, for totalling line items
+func (b *Breakdown) Add(other Breakdown) {
+	b.TaxableAmount = round(b.TaxableAmount + other.TaxableAmount)
+	b.CGST = round(b.CGST + other.CGST)
+	b.SGST = round(b.SGST + other.SGST)
+	b.IGST = round(b.IGST + other.IGST)
+	b.Total = round(b.Total + other.Total)
+}
+
+// Exclusive computes the tax charged on top of a taxable amount, as on a vendor's bill
+func Exclusive(taxable, rate float64, interState bool) Breakdown {
+	return Split(taxable, taxable*rate/100, rate, interState)
+}
+
+// Inclusive extracts the tax contained in an amount that already includes it, as
+// with shelf prices at MRP
+func Inclusive(amount, rate float64, interState bool) Breakdown {
+	taxable := amount
+	if rate > 0 {
+		taxable = amount * 100 / (100 + rate)
+	}
+	return Split(round(taxable), amount-round(taxable), rate, interState)
+}
+
+// Split divides a known tax amount into its GST components. The SGST half takes
+// whatever paisa rounding leaves over so the parts always add up to the total.
+func Split(taxable, total, rate float64, interState bool) Breakdown {
+	b := Breakdown{
+		Rate:          rate,
+		TaxableAmount: round(taxable),
+		Total:         round(total),
+		InterState:    interState,
+	}
+	if interState {
+		b.IGST = b.Total
+	} else {
+		b.CGST = round(b.Total / 2)
+		b.SGST = round(b.Total - b.CGST)
+	}
+	return b
+}
+
+// IsInterState reports whether a supply between two states is inter-state. States
+// are compared ignoring case and spacing. known is false when either state is
+// missing, and the caller must then decide the treatment itself.
+func IsInterState(from, to string) (interState, known bool) {
+	from, to = normalizeState(from), normalizeState(to)
+	if from == "" || to == "" {
+		return false, false
+	}
+	return from != to, true
+}
+
+// ProductRates returns the GST rate of each product. A rate set on the product
+// overrides its category's; products with neither have no entry.
+func ProductRates(tx *gorm.DB, tenantID uuid.UUID, productIDs []uuid.UUID) (map[uuid.UUID]float64, error) {
+	rates := make(map[uuid.UUID]float64, len(productIDs))
+	if len(productIDs) == 0 {
+		return rates, nil
+	}
+
+	var products []models.Product
+	if err := tx.Select("id", "category_id").
+		Where("id IN ? AND tenant_id = ?", productIDs, tenantID).
+		Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("failed to get product categories: %w", err)
+	}
+	categoryIDs := make([]uuid.UUID, 0, len(products))
+	for _, product := range products {
+		categoryIDs = append(categoryIDs, product.CategoryID)
+	}
+
+	var taxRates []models.TaxRate
+	if err := tx.Where("tenant_id = ? AND is_active = ? AND (product_id IN ? OR category_id IN ?)",
+		tenantID, true, productIDs, categoryIDs).
+		Find(&taxRates).Error; err != nil {
+		return nil, fmt.Errorf("failed to get tax rates: %w", err)
+	}
+
+	byProduct := make(map[uuid.UUID]float64)
+	byCategory := make(map[uuid.UUID]float64)
+	for _, rate := range taxRates {
+		switch {
+		case rate.ProductID != nil:
+			byProduct[*rate.ProductID] = rate.Percentage
+		case rate.CategoryID != nil:
+			byCategory[*rate.CategoryID] = rate.Percentage
+		}
+	}
+
+	for _, product := range products {
+		if rate, ok := byProduct[product.ID]; ok {
+			rates[product.ID] = rate
+		} else if rate, ok := byCategory[product.CategoryID]; ok {
+			rates[product.ID] = rate
+		}
+	}
+	return rates, nil
+}
+
+func normalizeState(state string) string {
+	return strings.Join(strings.Fields(strings.ToLower(state)), " ")
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
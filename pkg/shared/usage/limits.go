@@ -0,0 +1,207 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"gorm.io/gorm"
+)
+
+// Resources limited by a tenant's pricing plan
+const (
+	ResourceLocations = "locations"
+	ResourceUsers     = "users"
+	ResourceProducts  = "products"
+)
+
+// LiveSubscriptionStatuses are the subscription states that grant access to a plan.
+// A past due subscription keeps access while its failed payment is chased.
+var LiveSubscriptionStatuses = []string{"trial", "active", "past_due"}
+
+// ErrNoActiveSubscription is returned when a tenant's subscriptions have all ended
+var ErrNoActiveSubscription = errors.New("an active subscription is required to add more resources")
+
+// LimitError is returned when adding a resource would exceed the tenant's plan limit
+type LimitError struct {
+	Resource string
+	Limit    int
+	Plan     string
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s plan limit reached: your plan allows %d %s; upgrade your plan to add more", e.Plan, e.Limit, e.Resource)
+}
+
+// Limits are the resource limits of a tenant's current plan. A limit of zero or
+// less means unlimited.
+type Limits struct {
+	Plan         string
+	MaxLocations int
+	MaxUsers     int
+	MaxProducts  int
+}
+
+// Limit returns the plan's limit for a resource
+func (l *Limits) Limit(resource string) int {
+	switch resource {
+	case ResourceLocations:
+		return l.MaxLocations
+	case ResourceUsers:
+		return l.MaxUsers
+	case ResourceProducts:
+		return l.MaxProducts
+	}
+	return 0
+}
+
+// FreeLimits apply to a tenant that has never subscribed. A tenant that signed up
+// on its own has no subscription until it picks a plan; this is enough to set up
+// its first shop and try the product.
+var FreeLimits = Limits{
+	Plan:         "Free",
+	MaxLocations: 1,
+	MaxUsers:     2,
+	MaxProducts:  100,
+}
+
+// Counts are a tenant's current resource counts
+type Counts struct {
+	Locations int64
+	Users     int64
+	Products  int64
+}
+
+// Enforcer checks resource creation against the tenant's subscription plan.
+// Subscriptions are owned by the SaaS service; when its tables are not in the
+// database, limits are not enforced.
+type Enforcer struct {
+	db *gorm.DB
+}
+
+// NewEnforcer creates a plan limit enforcer
+func NewEnforcer(db *gorm.DB) *Enforcer {
+	return &Enforcer{db: db}
+}
+
+// Check returns ErrNoActiveSubscription or a *LimitError when the tenant may not
+// add one more of the resource
+func (e *Enforcer) Check(ctx context.Context, tenantID uuid.UUID, resource string) error {
+	limits, err := e.TenantLimits(ctx, tenantID)
+	if err != nil || limits == nil {
+		return err
+	}
+
+	limit := limits.Limit(resource)
+	if limit <= 0 {
+		return nil
+	}
+
+	count, err := Count(e.db.WithContext(ctx), tenantID, resource)
+	if err != nil {
+		return err
+	}
+	if count >= int64(limit) {
+		return &LimitError{Resource: resource, Limit: limit, Plan: limits.Plan}
+	}
+	return nil
+}
+
+// TenantLimits returns the limits of the tenant's live subscription, FreeLimits
+// when the tenant has never subscribed, or nil when subscriptions are not in use
+func (e *Enforcer) TenantLimits(ctx context.Context, tenantID uuid.UUID) (*Limits, error) {
+	if !e.db.Migrator().HasTable("subscriptions") || !e.db.Migrator().HasTable("pricing_plans") {
+		return nil, nil
+	}
+
+	var rows []struct {
+		DisplayName  string
+		MaxLocations int
+		MaxUsers     int
+		MaxProducts  int
+	}
+	if err := e.db.WithContext(ctx).Table("subscriptions").
+		Select("pricing_plans.display_name, pricing_plans.max_locations, pricing_plans.max_users, pricing_plans.max_products").
+		Joins("JOIN pricing_plans ON pricing_plans.id = subscriptions.plan_id").
+		Where("subscriptions.tenant_id = ? AND subscriptions.status IN ? AND subscriptions.deleted_at IS NULL", tenantID, LiveSubscriptionStatuses).
+		Order("subscriptions.created_at DESC").
+		Limit(1).
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get subscription plan: %w", err)
+	}
+	if len(rows) == 0 {
+		var subscriptions int64
+		if err := e.db.WithContext(ctx).Table("subscriptions").
+			Where("tenant_id = ? AND deleted_at IS NULL", tenantID).
+			Count(&subscriptions).Error; err != nil {
+			return nil, fmt.Errorf("failed to check subscriptions: %w", err)
+		}
+		if subscriptions == 0 {
+			limits := FreeLimits
+			return &limits, nil
+		}
+		return nil, ErrNoActiveSubscription
+	}
+
+	return &Limits{
+		Plan:         rows[0].DisplayName,
+		MaxLocations: rows[0].MaxLocations,
+		MaxUsers:     rows[0].MaxUsers,
+		MaxProducts:  rows[0].MaxProducts,
+	}, nil
+}
+
+// Count returns how many of a resource the tenant currently has. Deactivated
+// users do not count towards the user limit.
+func Count(db *gorm.DB, tenantID uuid.UUID, resource string) (int64, error) {
+	var query *gorm.DB
+	switch resource {
+	case ResourceLocations:
+		query = db.Model(&models.Shop{}).Where("tenant_id = ?", tenantID)
+	case ResourceUsers:
+		query = db.Model(&models.User{}).Where("tenant_id = ? AND is_active = ?", tenantID, true)
+	case ResourceProducts:
+		query = db.Model(&models.Product{}).Where("tenant_id = ?", tenantID)
+	default:
+		return 0, fmt.Errorf("unknown resource type: %s", resource)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count %s: %w", resource, err)
+	}
+	return count, nil
+}
+
+// CountAll returns all of the tenant's limited resource counts
+func CountAll(db *gorm.DB, tenantID uuid.UUID) (*Counts, error) {
+	counts := &Counts{}
+	for resource, count := range map[string]*int64{
+		ResourceLocations: &counts.Locations,
+		ResourceUsers:     &counts.Users,
+		ResourceProducts:  &counts.Products,
+	} {
+		n, err := Count(db, tenantID, resource)
+		if err != nil {
+			return nil, err
+		}
+		*count = n
+	}
+	return counts, nil
+}
+
+// HTTPStatus maps plan limit errors to a response status: 402 without an active
+// subscription, 403 when a limit is reached. ok is false for other errors.
+func HTTPStatus(err error) (status int, ok bool) {
+	var limitErr *LimitError
+	switch {
+	case errors.Is(err, ErrNoActiveSubscription):
+		return http.StatusPaymentRequired, true
+	case errors.As(err, &limitErr):
+		return http.StatusForbidden, true
+	}
+	return 0, false
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -44,6 +45,10 @@ const (
 	ErrCodeInsufficientRole = "INSUFFICIENT_ROLE"
 )
 
+// ErrTooManyItems is wrapped by errors refusing a document with more line items
+// than allowed; handlers answer it with 413 Request Entity Too Large
+var ErrTooManyItems = errors.New("too many items")
+
 // HandleError sends a standardized error response
 func HandleError(c *gin.Context, statusCode int, errorCode string, message string, details ...map[string]interface{}) {
 	response := ErrorResponse{
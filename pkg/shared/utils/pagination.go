@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// API versions a client can ask for with the X-API-Version request header. Version 2
+// made list endpoints return the PaginatedResponse envelope; version 1 clients keep
+// the fields each endpoint returned before it.
+const (
+	APIVersionHeader = "X-API-Version"
+	APIVersionLegacy = 1
+	APIVersion       = 2
+
+	// apiVersionKey holds the requested API version in the gin context
+	apiVersionKey = "api_version"
+)
+
+// PaginatedResponse is the envelope every paginated list endpoint returns
+type PaginatedResponse[T any] struct {
+	Data       []T   `json:"data"`
+	TotalCount int64 `json:"total_count"`
+	Page       int   `json:"page"`
+	PageSize   int   `json:"page_size"`
+	TotalPages int   `json:"total_pages"`
+	HasNext    bool  `json:"has_next"`
+}
+
+// NewPaginatedResponse wraps one page of results. A nil page is returned as an
+// empty list so clients always get an array.
+func NewPaginatedResponse[T any](data []T, totalCount int64, page PageRequest) *PaginatedResponse[T] {
+	if data == nil {
+		data = []T{}
+	}
+	totalPages := 0
+	if page.PageSize > 0 {
+		totalPages = int((totalCount + int64(page.PageSize) - 1) / int64(page.PageSize))
+	}
+	return &PaginatedResponse[T]{
+		Data:       data,
+		TotalCount: totalCount,
+		Page:       page.Page,
+		PageSize:   page.PageSize,
+		TotalPages: totalPages,
+		HasNext:    int64(page.Offset+len(data)) < totalCount,
+	}
+}
+
+// PageRequest is the page a list request asked for
+type PageRequest struct {
+	Page     int
+	PageSize int
+	Offset   int
+	// Explicit is false when the request sent no paging parameters at all
+	Explicit bool
+}
+
+// ParsePageRequest reads page and page_size from the query string. The older
+// limit and offset parameters are still accepted when page and page_size are
+// absent. Missing or out-of-range values fall back to the first page of
+// defaultSize items.
+func ParsePageRequest(c *gin.Context, defaultSize, maxSize int) PageRequest {
+	page := PageRequest{Page: 1, PageSize: defaultSize}
+
+	sizeStr := c.Query("page_size")
+	if sizeStr == "" {
+		sizeStr = c.Query("limit")
+	}
+	if sizeStr != "" {
+		page.Explicit = true
+		if size, err := strconv.Atoi(sizeStr); err == nil && size > 0 && size <= maxSize {
+			page.PageSize = size
+		}
+	}
+
+	if pageStr := c.Query("page"); pageStr != "" {
+		page.Explicit = true
+		if number, err := strconv.Atoi(pageStr); err == nil && number > 0 {
+			page.Page = number
+		}
+		page.Offset = (page.Page - 1) * page.PageSize
+	} else if offsetStr := c.Query("offset"); offsetStr != "" {
+		page.Explicit = true
+		if offset, err := strconv.Atoi(offsetStr); err == nil && offset >= 0 {
+			page.Offset = offset
+		}
+		page.Page = page.Offset/page.PageSize + 1
+	}
+
+	return page
+}
+
+// PaginateSlice returns one page of an already loaded list. A request without
+// paging parameters gets the whole list as a single page, as these endpoints
+// returned before they were paginated.
+func PaginateSlice[T any](items []T, page PageRequest) *PaginatedResponse[T] {
+	total := int64(len(items))
+	if !page.Explicit {
+		page.PageSize = len(items)
+		if page.PageSize == 0 {
+			page.PageSize = 1
+		}
+		return NewPaginatedResponse(items, total, page)
+	}
+
+	start := page.Offset
+	if start > len(items) {
+		start = len(items)
+	}
+	end := start + page.PageSize
+	if end > len(items) {
+		end = len(items)
+	}
+	return NewPaginatedResponse(items[start:end], total, page)
+}
+
+// RespondPaginated writes a list response. Clients on API version 2 get the
+// envelope. Older clients get legacy, the fields the endpoint returned before the
+// envelope, with the envelope's page metadata added alongside; the items are only
+// sent once, under their legacy name.
+func RespondPaginated[T any](c *gin.Context, response *PaginatedResponse[T], legacy gin.H) {
+	if RequestedAPIVersion(c) >= APIVersion || len(legacy) == 0 {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	body := gin.H{
+		"total_count": response.TotalCount,
+		"page":        response.Page,
+		"page_size":   response.PageSize,
+		"total_pages": response.TotalPages,
+		"has_next":    response.HasNext,
+	}
+	for key, value := range legacy {
+		body[key] = value
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// RequestedAPIVersion returns the API version the client asked for, defaulting to
+// the legacy version
+func RequestedAPIVersion(c *gin.Context) int {
+	if version := c.GetInt(apiVersionKey); version > 0 {
+		return version
+	}
+	return ParseAPIVersion(c.GetHeader(APIVersionHeader))
+}
+
+// ParseAPIVersion reads an X-API-Version header value such as "2" or "v2". Empty or
+// unrecognised values are the legacy version.
+func ParseAPIVersion(value string) int {
+	if len(value) > 0 && (value[0] == 'v' || value[0] == 'V') {
+		value = value[1:]
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil || version < APIVersionLegacy {
+		return APIVersionLegacy
+	}
+	return version
+}
+
+// SetRequestedAPIVersion records the API version the client asked for
+func SetRequestedAPIVersion(c *gin.Context, version int) {
+	c.Set(apiVersionKey, version)
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
@@ -71,6 +72,31 @@ func RoundToTwoDecimals(amount float64) float64 {
 	return float64(int(amount*100)) / 100
 }
 
+// Rounding modes for RoundAmount
+const (
+	RoundHalfUp = "half_up"
+	RoundDown   = "down"
+	RoundUp     = "up"
+)
+
+// RoundAmount rounds amount to the given decimal places. Modes work on the
+// magnitude: down truncates toward zero, up rounds away from zero, and
+// half_up (default) rounds halves away from zero.
+func RoundAmount(amount float64, places int, mode string) float64 {
+	factor := math.Pow(10, float64(places))
+	// Small epsilon absorbs float error so 1.005 rounds as written
+	magnitude := math.Abs(amount * factor)
+	switch mode {
+	case RoundDown:
+		magnitude = math.Floor(magnitude + 1e-9)
+	case RoundUp:
+		magnitude = math.Ceil(magnitude - 1e-9)
+	default:
+		magnitude = math.Floor(magnitude + 0.5 + 1e-9)
+	}
+	return math.Copysign(magnitude, amount) / factor
+}
+
 // Date utilities
 func FormatDate(t time.Time) string {
 	return t.Format("2006-01-02")
@@ -306,7 +306,7 @@ func (v *Validator) PasswordMatch(password, confirmPassword, field string) {
 
 // ValidRole validates user role
 func (v *Validator) ValidRole(role, field string) {
-	validRoles := []string{"admin", "manager", "executive", "salesman", "assistant_manager", "saas_admin"}
+	validRoles := []string{"admin", "manager", "executive", "salesman", "assistant_manager", "regional_manager", "saas_admin"}
 	v.In(role, validRoles, field)
 }
 
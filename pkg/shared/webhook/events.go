@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types tenants can subscribe to
+const (
+	EventSaleApproved = "sale.approved"
+	EventStockLow     = "stock.low"
+)
+
+// EventDefinition describes an event type tenants can subscribe to
+type EventDefinition struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// eventDefinitions lists every event type. A new event only needs an entry here
+// and an Enqueue call where it happens.
+var eventDefinitions = []EventDefinition{
+	{
+		Type:        EventSaleApproved,
+		Description: "A sale or daily sales record was approved, manually or automatically",
+	},
+	{
+		Type:        EventStockLow,
+		Description: "A product's stock at a shop fell to or below its minimum level",
+	},
+}
+
+// Events returns the event types tenants can subscribe to
+func Events() []EventDefinition {
+	events := make([]EventDefinition, len(eventDefinitions))
+	copy(events, eventDefinitions)
+	return events
+}
+
+// IsEvent reports whether eventType is a known event type
+func IsEvent(eventType string) bool {
+	for _, definition := range eventDefinitions {
+		if definition.Type == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is the JSON body posted to a webhook
+type Event struct {
+	ID        uuid.UUID   `json:"id"` // the same on every attempt, so receivers can drop repeats
+	Type      string      `json:"type"`
+	TenantID  uuid.UUID   `json:"tenant_id"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Sale sources of a sale.approved event
+const (
+	SaleSourceDailySalesRecord = "daily_sales_record"
+	SaleSourceSale             = "sale"
+)
+
+// SaleApprovedData is the data of a sale.approved event
+type SaleApprovedData struct {
+	Source       string     `json:"source"`
+	ID           uuid.UUID  `json:"id"`
+	ShopID       uuid.UUID  `json:"shop_id"`
+	Amount       float64    `json:"amount"`
+	ApprovedByID *uuid.UUID `json:"approved_by_id"`
+	ApprovedAt   time.Time  `json:"approved_at"`
+	AutoApproved bool       `json:"auto_approved"`
+}
+
+// StockLowData is the data of a stock.low event
+type StockLowData struct {
+	StockID      uuid.UUID `json:"stock_id"`
+	ShopID       uuid.UUID `json:"shop_id"`
+	ProductID    uuid.UUID `json:"product_id"`
+	ProductName  string    `json:"product_name"`
+	SKU          string    `json:"sku"`
+	Quantity     int       `json:"quantity"`
+	MinimumLevel int       `json:"minimum_level"`
+}
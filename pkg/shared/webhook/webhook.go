@@ -0,0 +1,533 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/liquorpro/go-backend/pkg/shared/database"
+	"github.com/liquorpro/go-backend/pkg/shared/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxAttempts is how many times a delivery is tried before it is marked failed
+	MaxAttempts = 6
+
+	// Headers sent with every delivery
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	EventIDHeader   = "X-Webhook-ID"
+
+	requestTimeout = 10 * time.Second
+	// claimLease keeps a delivery from being picked up by another worker while it
+	// is being sent
+	claimLease     = time.Minute
+	maxResponseLog = 1024
+	dueBatchSize   = 100
+)
+
+// WebhookRequest registers a webhook
+type WebhookRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	Events      []string `json:"events" binding:"required,min=1"`
+	Description string   `json:"description"`
+}
+
+// UpdateWebhookRequest changes a webhook. Only the fields that are set change.
+type UpdateWebhookRequest struct {
+	URL          *string  `json:"url"`
+	Events       []string `json:"events"`
+	Description  *string  `json:"description"`
+	IsActive     *bool    `json:"is_active"`
+	RotateSecret bool     `json:"rotate_secret"`
+}
+
+// WebhookResponse is a registered webhook. The secret is only included when it is
+// first generated or rotated.
+type WebhookResponse struct {
+	ID          uuid.UUID `json:"id"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	Description string    `json:"description"`
+	IsActive    bool      `json:"is_active"`
+	Secret      string    `json:"secret,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// DeliveryFilter narrows a delivery listing
+type DeliveryFilter struct {
+	WebhookID *uuid.UUID
+	Status    string
+	EventType string
+}
+
+// DeliveryResponse is one delivery of an event to a webhook
+type DeliveryResponse struct {
+	ID             uuid.UUID       `json:"id"`
+	WebhookID      uuid.UUID       `json:"webhook_id"`
+	WebhookURL     string          `json:"webhook_url,omitempty"`
+	EventID        uuid.UUID       `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	LastStatusCode int             `json:"last_status_code"`
+	LastError      string          `json:"last_error"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// Service manages tenant webhooks and delivers their events
+type Service struct {
+	db     *database.DB
+	client *http.Client
+}
+
+// NewService creates a new webhook service
+func NewService(db *database.DB) *Service {
+	return &Service{
+		db:     db,
+		client: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Enqueue records an event for delivery to every active webhook of the tenant that
+// subscribes to it. It writes through tx so the deliveries commit or roll back
+// with the change that raised the event; the worker sends them afterwards.
+func Enqueue(tx *gorm.DB, tenantID uuid.UUID, eventType string, data interface{}) error {
+	var hooks []models.TenantWebhook
+	if err := tx.Where("tenant_id = ? AND is_active = ?", tenantID, true).Find(&hooks).Error; err != nil {
+		return fmt.Errorf("failed to get webhooks: %w", err)
+	}
+
+	var subscribed []models.TenantWebhook
+	for _, hook := range hooks {
+		if subscribes(hook, eventType) {
+			subscribed = append(subscribed, hook)
+		}
+	}
+	if len(subscribed) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	event := Event{
+		ID:        uuid.New(),
+		Type:      eventType,
+		TenantID:  tenantID,
+		CreatedAt: now.UTC(),
+		Data:      data,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+
+	for _, hook := range subscribed {
+		delivery := models.WebhookDelivery{
+			TenantModel:   models.TenantModel{TenantID: tenantID},
+			WebhookID:     hook.ID,
+			EventID:       event.ID,
+			EventType:     eventType,
+			Payload:       string(payload),
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: &now,
+		}
+		if err := tx.Create(&delivery).Error; err != nil {
+			return fmt.Errorf("failed to queue webhook delivery: %w", err)
+		}
+	}
+	return nil
+}
+
+// CreateWebhook registers a webhook with a newly generated signing secret
+func (s *Service) CreateWebhook(ctx context.Context, tenantID, userID uuid.UUID, req WebhookRequest) (*WebhookResponse, error) {
+	if err := validateURL(req.URL); err != nil {
+		return nil, err
+	}
+	if err := validateEvents(req.Events); err != nil {
+		return nil, err
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	hook := models.TenantWebhook{
+		TenantModel: models.TenantModel{TenantID: tenantID},
+		URL:         req.URL,
+		Secret:      secret,
+		Events:      req.Events,
+		Description: req.Description,
+		IsActive:    true,
+		CreatedByID: userID,
+	}
+	if err := s.db.WithContext(ctx).Create(&hook).Error; err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	response := buildWebhookResponse(hook)
+	response.Secret = secret
+	return response, nil
+}
+
+// GetWebhooks lists the tenant's webhooks
+func (s *Service) GetWebhooks(ctx context.Context, tenantID uuid.UUID) ([]*WebhookResponse, error) {
+	var hooks []models.TenantWebhook
+	if err := s.db.WithContext(ctx).Where("tenant_id = ?", tenantID).Order("created_at ASC").Find(&hooks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+
+	responses := make([]*WebhookResponse, len(hooks))
+	for i, hook := range hooks {
+		responses[i] = buildWebhookResponse(hook)
+	}
+	return responses, nil
+}
+
+// UpdateWebhook changes a webhook's endpoint, events or state, or rotates its secret
+func (s *Service) UpdateWebhook(ctx context.Context, id, tenantID uuid.UUID, req UpdateWebhookRequest) (*WebhookResponse, error) {
+	var hook models.TenantWebhook
+	if err := s.getWebhook(ctx, &hook, id, tenantID); err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		if err := validateURL(*req.URL); err != nil {
+			return nil, err
+		}
+		hook.URL = *req.URL
+	}
+	if req.Events != nil {
+		if err := validateEvents(req.Events); err != nil {
+			return nil, err
+		}
+		hook.Events = req.Events
+	}
+	if req.Description != nil {
+		hook.Description = *req.Description
+	}
+	if req.IsActive != nil {
+		hook.IsActive = *req.IsActive
+	}
+
+	var secret string
+	if req.RotateSecret {
+		var err error
+		if secret, err = generateSecret(); err != nil {
+			return nil, err
+		}
+		hook.Secret = secret
+	}
+
+	if err := s.db.WithContext(ctx).Save(&hook).Error; err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	response := buildWebhookResponse(hook)
+	response.Secret = secret
+	return response, nil
+}
+
+// DeleteWebhook removes a webhook. Deliveries still pending for it are failed.
+func (s *Service) DeleteWebhook(ctx context.Context, id, tenantID uuid.UUID) error {
+	var hook models.TenantWebhook
+	if err := s.getWebhook(ctx, &hook, id, tenantID); err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&hook).Error; err != nil {
+			return fmt.Errorf("failed to delete webhook: %w", err)
+		}
+		if err := tx.Model(&models.WebhookDelivery{}).
+			Where("webhook_id = ? AND status = ?", hook.ID, models.WebhookDeliveryPending).
+			Updates(map[string]interface{}{
+				"status":          models.WebhookDeliveryFailed,
+				"last_error":      "webhook was deleted",
+				"next_attempt_at": nil,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to cancel webhook deliveries: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetDeliveries lists the tenant's webhook deliveries, newest first
+func (s *Service) GetDeliveries(ctx context.Context, tenantID uuid.UUID, filter DeliveryFilter, limit, offset int) ([]*DeliveryResponse, int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.WebhookDelivery{}).Where("tenant_id = ?", tenantID)
+	if filter.WebhookID != nil {
+		query = query.Where("webhook_id = ?", *filter.WebhookID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.EventType != "" {
+		query = query.Where("event_type = ?", filter.EventType)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := query.Preload("Webhook", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Order("created_at DESC").Limit(limit).Offset(offset).
+		Find(&deliveries).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+
+	responses := make([]*DeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		responses[i] = buildDeliveryResponse(delivery)
+	}
+	return responses, total, nil
+}
+
+// RetryDelivery sends a delivery again straight away, whatever its status. A failed
+// delivery gets one more attempt; a pending one carries on its retry schedule if
+// this attempt fails too.
+func (s *Service) RetryDelivery(ctx context.Context, id, tenantID uuid.UUID) (*DeliveryResponse, error) {
+	var delivery models.WebhookDelivery
+	if err := s.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, tenantID).First(&delivery).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("webhook delivery not found")
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	var hook models.TenantWebhook
+	if err := s.getWebhook(ctx, &hook, delivery.WebhookID, tenantID); err != nil {
+		return nil, errors.New("the delivery's webhook was deleted")
+	}
+
+	s.attempt(ctx, &hook, &delivery, true)
+
+	s.db.WithContext(ctx).Preload("Webhook").First(&delivery, delivery.ID)
+	return buildDeliveryResponse(delivery), nil
+}
+
+// DeliverDue sends every delivery whose next attempt is due and returns how many
+// it tried. Each delivery is claimed first, so several workers can run at once.
+func (s *Service) DeliverDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	var due []models.WebhookDelivery
+	if err := s.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+		Order("next_attempt_at ASC").
+		Limit(dueBatchSize).
+		Find(&due).Error; err != nil {
+		return 0, fmt.Errorf("failed to get due webhook deliveries: %w", err)
+	}
+
+	tried := 0
+	for i := range due {
+		delivery := &due[i]
+
+		claim := s.db.WithContext(ctx).Model(&models.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at <= ?", delivery.ID, models.WebhookDeliveryPending, now).
+			Update("next_attempt_at", now.Add(claimLease))
+		if claim.Error != nil || claim.RowsAffected == 0 {
+			continue
+		}
+
+		var hook models.TenantWebhook
+		if err := s.getWebhook(ctx, &hook, delivery.WebhookID, delivery.TenantID); err != nil || !hook.IsActive {
+			s.db.WithContext(ctx).Model(delivery).Updates(map[string]interface{}{
+				"status":          models.WebhookDeliveryFailed,
+				"last_error":      "webhook was deleted or deactivated",
+				"next_attempt_at": nil,
+			})
+			continue
+		}
+
+		s.attempt(ctx, &hook, delivery, false)
+		tried++
+	}
+	return tried, nil
+}
+
+// attempt sends a delivery once and records the outcome. Failures are retried with
+// exponential backoff until MaxAttempts; a manual retry never reschedules a
+// delivery that had already failed.
+func (s *Service) attempt(ctx context.Context, hook *models.TenantWebhook, delivery *models.WebhookDelivery, manual bool) {
+	statusCode, sendErr := s.send(ctx, hook, delivery)
+
+	attempts := delivery.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts":         attempts,
+		"last_status_code": statusCode,
+		"last_error":       "",
+	}
+
+	now := time.Now()
+	switch {
+	case sendErr == nil:
+		updates["status"] = models.WebhookDeliveryDelivered
+		updates["delivered_at"] = now
+		updates["next_attempt_at"] = nil
+	case attempts >= MaxAttempts || (manual && delivery.Status == models.WebhookDeliveryFailed):
+		updates["status"] = models.WebhookDeliveryFailed
+		updates["last_error"] = sendErr.Error()
+		updates["next_attempt_at"] = nil
+	default:
+		updates["status"] = models.WebhookDeliveryPending
+		updates["last_error"] = sendErr.Error()
+		updates["next_attempt_at"] = now.Add(backoff(attempts))
+	}
+
+	if err := s.db.WithContext(ctx).Model(delivery).Updates(updates).Error; err != nil {
+		log.Printf("Failed to record webhook delivery %s: %v", delivery.ID, err)
+	}
+}
+
+// send posts a delivery's payload, signed with the webhook's secret. Any 2xx
+// response counts as delivered.
+func (s *Service) send(ctx context.Context, hook *models.TenantWebhook, delivery *models.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("invalid webhook request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "LiquorPro-Webhooks/1.0")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(EventIDHeader, delivery.EventID.String())
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseLog))
+		return resp.StatusCode, fmt.Errorf("endpoint responded %d: %s", resp.StatusCode, string(snippet))
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the signature header value for a payload: an HMAC-SHA256 of the
+// timestamp and body joined by a dot. Receivers recompute it with their secret and
+// should reject stale timestamps.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff is the wait after the given number of failed attempts: 30 seconds,
+// doubling each time up to 8 minutes before the last attempt
+func backoff(attempts int) time.Duration {
+	return 30 * time.Second << (attempts - 1)
+}
+
+func (s *Service) getWebhook(ctx context.Context, hook *models.TenantWebhook, id, tenantID uuid.UUID) error {
+	if err := s.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, tenantID).First(hook).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("webhook not found")
+		}
+		return fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return nil
+}
+
+func subscribes(hook models.TenantWebhook, eventType string) bool {
+	for _, subscribed := range hook.Events {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func validateURL(raw string) error {
+	parsed, err := url.ParseRequestURI(raw)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return errors.New("webhook URL must be an absolute http or https URL")
+	}
+	return nil
+}
+
+func validateEvents(events []string) error {
+	if len(events) == 0 {
+		return errors.New("subscribe to at least one event")
+	}
+	for _, event := range events {
+		if !IsEvent(event) {
+			return fmt.Errorf("unknown event type: %s", event)
+		}
+	}
+	return nil
+}
+
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+func buildWebhookResponse(hook models.TenantWebhook) *WebhookResponse {
+	return &WebhookResponse{
+		ID:          hook.ID,
+		URL:         hook.URL,
+		Events:      hook.Events,
+		Description: hook.Description,
+		IsActive:    hook.IsActive,
+		CreatedAt:   hook.CreatedAt,
+		UpdatedAt:   hook.UpdatedAt,
+	}
+}
+
+func buildDeliveryResponse(delivery models.WebhookDelivery) *DeliveryResponse {
+	response := &DeliveryResponse{
+		ID:             delivery.ID,
+		WebhookID:      delivery.WebhookID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+	}
+	if json.Valid([]byte(delivery.Payload)) {
+		response.Payload = json.RawMessage(delivery.Payload)
+	}
+	if delivery.Webhook != nil {
+		response.WebhookURL = delivery.Webhook.URL
+	}
+	return response
+}
@@ -0,0 +1,50 @@
+package webhook
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Worker periodically sends webhook deliveries that are due, both new events and
+// retries of failed attempts
+type Worker struct {
+	service  *Service
+	interval time.Duration
+}
+
+// NewWorker creates a worker that checks for due deliveries at the given interval
+func NewWorker(service *Service, interval time.Duration) *Worker {
+	return &Worker{
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Start runs the worker in the background until ctx is cancelled
+func (w *Worker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				w.sweep(ctx)
+			}
+		}
+	}()
+}
+
+func (w *Worker) sweep(ctx context.Context) {
+	tried, err := w.service.DeliverDue(ctx)
+	if err != nil {
+		log.Printf("Webhook delivery sweep failed: %v", err)
+		return
+	}
+	if tried > 0 {
+		log.Printf("Webhook delivery sweep sent %d delivery(ies)", tried)
+	}
+}
@@ -6,10 +6,11 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
-	"github.com/stretchr/testify/assert"
+	"github.com/liquorpro/go-backend/pkg/shared/money"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -438,6 +439,828 @@ func (suite *IntegrationTestSuite) TestPerformance() {
 	})
 }
 
+// Test Bank Transfers
+func (suite *IntegrationTestSuite) TestConcurrentBankTransfers() {
+	createAccount := func(name string) string {
+		payload := map[string]interface{}{
+			"bank_name":           name,
+			"account_number":      fmt.Sprintf("IT%d", time.Now().UnixNano()),
+			"ifsc_code":           "TEST0000001",
+			"account_holder_name": "Integration Test",
+			"opening_balance":     1000.00,
+		}
+
+		resp := suite.makeRequest("POST", "/api/finance/bank-accounts", payload, suite.adminToken)
+		suite.Equal(201, resp.StatusCode)
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+
+		id, _ := result["id"].(string)
+		suite.NotEmpty(id)
+		return id
+	}
+
+	getBalance := func(id string) float64 {
+		resp := suite.makeRequest("GET", "/api/finance/bank-accounts/"+id, nil, suite.adminToken)
+		suite.Equal(200, resp.StatusCode)
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+
+		balance, _ := result["current_balance"].(float64)
+		return balance
+	}
+
+	accountA := createAccount("Transfer Test Bank A")
+	accountB := createAccount("Transfer Test Bank B")
+
+	suite.Run("Overdraft Rejected", func() {
+		payload := map[string]interface{}{
+			"from_account_id": accountA,
+			"to_account_id":   accountB,
+			"amount":          5000.00,
+		}
+
+		resp := suite.makeRequest("POST", "/api/finance/bank-accounts/transfer", payload, suite.adminToken)
+		suite.Equal(409, resp.StatusCode)
+		resp.Body.Close()
+	})
+
+	suite.Run("Concurrent Transfers Preserve Total Balance", func() {
+		concurrency := 20
+		var wg sync.WaitGroup
+
+		for i := 0; i < concurrency; i++ {
+			from, to := accountA, accountB
+			if i%2 == 1 {
+				from, to = accountB, accountA
+			}
+
+			wg.Add(1)
+			go func(from, to string) {
+				defer wg.Done()
+
+				payload := map[string]interface{}{
+					"from_account_id": from,
+					"to_account_id":   to,
+					"amount":          150.00,
+				}
+
+				resp := suite.makeRequest("POST", "/api/finance/bank-accounts/transfer", payload, suite.adminToken)
+				// Transfers may be rejected for insufficient balance, never anything else
+				suite.Contains([]int{201, 409}, resp.StatusCode)
+				resp.Body.Close()
+			}(from, to)
+		}
+		wg.Wait()
+
+		balanceA := getBalance(accountA)
+		balanceB := getBalance(accountB)
+
+		suite.GreaterOrEqual(balanceA, 0.0)
+		suite.GreaterOrEqual(balanceB, 0.0)
+		suite.InDelta(2000.00, balanceA+balanceB, 0.001, "Transfers must not create or destroy money")
+	})
+
+	suite.Run("Fractional Amounts Move Whole Paise", func() {
+		before := money.FromFloat(getBalance(accountA)) + money.FromFloat(getBalance(accountB))
+
+		// 1.05 and 0.29 are just below their decimal value as floats and were
+		// truncated a paisa short
+		for _, amount := range []float64{1.05, 0.29} {
+			fromBefore := money.FromFloat(getBalance(accountA))
+			payload := map[string]interface{}{
+				"from_account_id": accountA,
+				"to_account_id":   accountB,
+				"amount":          amount,
+			}
+
+			resp := suite.makeRequest("POST", "/api/finance/bank-accounts/transfer", payload, suite.adminToken)
+			suite.Equal(201, resp.StatusCode)
+			resp.Body.Close()
+
+			suite.Equal(fromBefore-money.FromFloat(amount), money.FromFloat(getBalance(accountA)))
+		}
+
+		after := money.FromFloat(getBalance(accountA)) + money.FromFloat(getBalance(accountB))
+		suite.Equal(before, after, "Transfers must not create or destroy money")
+	})
+}
+
+// Test Stock Transfer References
+func (suite *IntegrationTestSuite) TestConcurrentStockTransferReferences() {
+	createShop := func(name string) string {
+		payload := map[string]interface{}{
+			"name":           name,
+			"address":        "Integration Test Street",
+			"phone":          "9999999999",
+			"license_number": fmt.Sprintf("LIC%d", time.Now().UnixNano()),
+		}
+
+		resp := suite.makeRequest("POST", "/api/admin/shops", payload, suite.adminToken)
+		suite.Equal(201, resp.StatusCode)
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+
+		id, _ := result["id"].(string)
+		suite.NotEmpty(id)
+		return id
+	}
+
+	createEntity := func(endpoint string, payload map[string]interface{}) string {
+		resp := suite.makeRequest("POST", endpoint, payload, suite.adminToken)
+		suite.Equal(200, resp.StatusCode)
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+
+		id, _ := result["id"].(string)
+		suite.NotEmpty(id)
+		return id
+	}
+
+	suffix := time.Now().UnixNano()
+	fromShopID := createShop(fmt.Sprintf("Transfer Source %d", suffix))
+	toShopID := createShop(fmt.Sprintf("Transfer Destination %d", suffix))
+	brandID := createEntity("/api/inventory/brands", map[string]interface{}{
+		"name": fmt.Sprintf("Transfer Brand %d", suffix),
+	})
+	categoryID := createEntity("/api/inventory/categories", map[string]interface{}{
+		"name": fmt.Sprintf("Transfer Category %d", suffix),
+	})
+	productID := createEntity("/api/inventory/products", map[string]interface{}{
+		"name":          "Transfer Test Product",
+		"sku":           fmt.Sprintf("TRF-%d", suffix),
+		"brand_id":      brandID,
+		"category_id":   categoryID,
+		"size":          "750ml",
+		"selling_price": 100.00,
+		"mrp":           120.00,
+		"cost_price":    80.00,
+	})
+
+	resp := suite.makeRequest("POST", "/api/inventory/stocks/adjust", map[string]interface{}{
+		"shop_id":         fromShopID,
+		"product_id":      productID,
+		"quantity":        100,
+		"adjustment_type": "add",
+		"reason":          "Integration test stock",
+	}, suite.adminToken)
+	suite.Equal(200, resp.StatusCode)
+	resp.Body.Close()
+
+	suite.Run("Concurrent Transfers Get Unique References", func() {
+		concurrency := 20
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		references := make(map[string]int)
+
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				payload := map[string]interface{}{
+					"from_shop_id":  fromShopID,
+					"to_shop_id":    toShopID,
+					"transfer_date": time.Now().Format(time.RFC3339),
+					"items": []map[string]interface{}{
+						{"product_id": productID, "quantity": 1},
+					},
+				}
+
+				resp := suite.makeRequest("POST", "/api/inventory/stocks/transfer", payload, suite.adminToken)
+				suite.Equal(200, resp.StatusCode)
+
+				var result map[string]interface{}
+				json.NewDecoder(resp.Body).Decode(&result)
+				resp.Body.Close()
+
+				reference, _ := result["reference"].(string)
+				mu.Lock()
+				references[reference]++
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+
+		suite.Len(references, concurrency, "Every transfer should get its own reference")
+		for reference, count := range references {
+			suite.NotEmpty(reference)
+			suite.Equal(1, count, "Reference %s was issued more than once", reference)
+		}
+	})
+}
+
+// Test Concurrent Approvals
+func (suite *IntegrationTestSuite) TestConcurrentDailySalesApprovals() {
+	createEntity := func(endpoint string, payload map[string]interface{}, status int) string {
+		resp := suite.makeRequest("POST", endpoint, payload, suite.adminToken)
+		suite.Equal(status, resp.StatusCode)
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+
+		id, _ := result["id"].(string)
+		suite.NotEmpty(id)
+		return id
+	}
+
+	suffix := time.Now().UnixNano()
+	shopID := createEntity("/api/admin/shops", map[string]interface{}{
+		"name":           fmt.Sprintf("Approval Shop %d", suffix),
+		"address":        "Integration Test Street",
+		"phone":          "9999999999",
+		"license_number": fmt.Sprintf("LIC%d", suffix),
+	}, 201)
+	brandID := createEntity("/api/inventory/brands", map[string]interface{}{
+		"name": fmt.Sprintf("Approval Brand %d", suffix),
+	}, 200)
+	categoryID := createEntity("/api/inventory/categories", map[string]interface{}{
+		"name": fmt.Sprintf("Approval Category %d", suffix),
+	}, 200)
+	productID := createEntity("/api/inventory/products", map[string]interface{}{
+		"name":          "Approval Test Product",
+		"sku":           fmt.Sprintf("APR-%d", suffix),
+		"brand_id":      brandID,
+		"category_id":   categoryID,
+		"size":          "750ml",
+		"selling_price": 100.00,
+		"mrp":           120.00,
+		"cost_price":    80.00,
+	}, 200)
+
+	createRecord := func() string {
+		return createEntity("/api/sales/daily-records", map[string]interface{}{
+			"record_date":        time.Now().Format(time.RFC3339),
+			"shop_id":            shopID,
+			"total_sales_amount": 500.00,
+			"total_cash_amount":  500.00,
+			"items": []map[string]interface{}{
+				{"product_id": productID, "quantity": 5, "unit_price": 100.00, "total_amount": 500.00, "cash_amount": 500.00},
+			},
+		}, 201)
+	}
+
+	// decide fires the given approve/reject endpoints at once and tallies the status codes
+	decide := func(endpoints []string) map[int]int {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		codes := make(map[int]int)
+
+		for _, endpoint := range endpoints {
+			wg.Add(1)
+			go func(endpoint string) {
+				defer wg.Done()
+
+				resp := suite.makeRequest("POST", endpoint, map[string]interface{}{"reason": "Integration test"}, suite.adminToken)
+				resp.Body.Close()
+
+				mu.Lock()
+				codes[resp.StatusCode]++
+				mu.Unlock()
+			}(endpoint)
+		}
+		wg.Wait()
+		return codes
+	}
+
+	suite.Run("Concurrent Approvals Take Effect Once", func() {
+		recordID := createRecord()
+		concurrency := 10
+		endpoints := make([]string, concurrency)
+		for i := range endpoints {
+			endpoints[i] = "/api/sales/daily-records/" + recordID + "/approve"
+		}
+
+		codes := decide(endpoints)
+		suite.Equal(1, codes[200], "Exactly one approval should succeed")
+		suite.Equal(concurrency-1, codes[409], "Every other approval should report the record as already processed")
+	})
+
+	suite.Run("Concurrent Approve And Reject Take Effect Once", func() {
+		recordID := createRecord()
+		endpoints := []string{
+			"/api/sales/daily-records/" + recordID + "/approve",
+			"/api/sales/daily-records/" + recordID + "/reject",
+			"/api/sales/daily-records/" + recordID + "/approve",
+			"/api/sales/daily-records/" + recordID + "/reject",
+		}
+
+		codes := decide(endpoints)
+		suite.Equal(1, codes[200], "Exactly one decision should succeed")
+		suite.Equal(len(endpoints)-1, codes[409])
+	})
+
+	suite.Run("Repeated Approval Reports Current Status", func() {
+		recordID := createRecord()
+
+		resp := suite.makeRequest("POST", "/api/sales/daily-records/"+recordID+"/approve", nil, suite.adminToken)
+		suite.Equal(200, resp.StatusCode)
+		resp.Body.Close()
+
+		resp = suite.makeRequest("POST", "/api/sales/daily-records/"+recordID+"/approve", nil, suite.adminToken)
+		suite.Equal(409, resp.StatusCode)
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+
+		suite.Equal("approved", result["status"])
+	})
+
+	suite.Run("Stale Version Is Refused", func() {
+		recordID := createRecord()
+
+		resp := suite.makeRequest("GET", "/api/sales/daily-records/"+recordID, nil, suite.adminToken)
+		suite.Equal(200, resp.StatusCode)
+		var record struct {
+			Version int `json:"version"`
+			Items   []struct {
+				ID string `json:"id"`
+			} `json:"items"`
+		}
+		json.NewDecoder(resp.Body).Decode(&record)
+		resp.Body.Close()
+		suite.Require().NotEmpty(record.Items)
+		readVersion := record.Version
+
+		// Another manager corrects the record after it was read
+		resp = suite.makeRequest("PATCH", "/api/sales/daily-records/"+recordID+"/items/"+record.Items[0].ID, map[string]interface{}{
+			"version":     readVersion,
+			"quantity":    4,
+			"cash_amount": 400.00,
+		}, suite.adminToken)
+		suite.Equal(200, resp.StatusCode)
+		json.NewDecoder(resp.Body).Decode(&record)
+		resp.Body.Close()
+		suite.Equal(readVersion+1, record.Version)
+
+		resp = suite.makeRequest("POST", "/api/sales/daily-records/"+recordID+"/approve", map[string]interface{}{"version": readVersion}, suite.adminToken)
+		suite.Equal(409, resp.StatusCode, "Approving the version read before the correction should conflict")
+		resp.Body.Close()
+
+		resp = suite.makeRequest("POST", "/api/sales/daily-records/"+recordID+"/approve", map[string]interface{}{"version": record.Version}, suite.adminToken)
+		suite.Equal(200, resp.StatusCode)
+		resp.Body.Close()
+	})
+
+	suite.Run("Approved Records Are Voided", func() {
+		recordID := createRecord()
+
+		resp := suite.makeRequest("POST", "/api/sales/daily-records/"+recordID+"/approve", nil, suite.adminToken)
+		suite.Equal(200, resp.StatusCode)
+		resp.Body.Close()
+
+		resp = suite.makeRequest("POST", "/api/sales/daily-records/"+recordID+"/void", map[string]interface{}{"reason": "Entered against the wrong shop"}, suite.adminToken)
+		suite.Equal(200, resp.StatusCode)
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+
+		suite.Equal("voided", result["status"])
+		suite.Equal("Entered against the wrong shop", result["void_reason"])
+		suite.NotEmpty(result["voided_at"])
+
+		resp = suite.makeRequest("POST", "/api/sales/daily-records/"+recordID+"/void", map[string]interface{}{"reason": "Again"}, suite.adminToken)
+		suite.Equal(400, resp.StatusCode, "A record can only be voided once")
+		resp.Body.Close()
+	})
+}
+
+// Test Concurrent Sales
+func (suite *IntegrationTestSuite) TestConcurrentSalesNeverOversell() {
+	createEntity := func(endpoint string, payload map[string]interface{}, status int) string {
+		resp := suite.makeRequest("POST", endpoint, payload, suite.adminToken)
+		suite.Equal(status, resp.StatusCode)
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+
+		id, _ := result["id"].(string)
+		suite.NotEmpty(id)
+		return id
+	}
+
+	suffix := time.Now().UnixNano()
+	shopID := createEntity("/api/admin/shops", map[string]interface{}{
+		"name":           fmt.Sprintf("Oversell Shop %d", suffix),
+		"address":        "Integration Test Street",
+		"phone":          "9999999999",
+		"license_number": fmt.Sprintf("LIC%d", suffix),
+	}, 201)
+	brandID := createEntity("/api/inventory/brands", map[string]interface{}{
+		"name": fmt.Sprintf("Oversell Brand %d", suffix),
+	}, 200)
+	categoryID := createEntity("/api/inventory/categories", map[string]interface{}{
+		"name": fmt.Sprintf("Oversell Category %d", suffix),
+	}, 200)
+	productID := createEntity("/api/inventory/products", map[string]interface{}{
+		"name":          "Oversell Test Product",
+		"sku":           fmt.Sprintf("OVS-%d", suffix),
+		"brand_id":      brandID,
+		"category_id":   categoryID,
+		"size":          "750ml",
+		"selling_price": 100.00,
+		"mrp":           120.00,
+		"cost_price":    80.00,
+	}, 200)
+
+	available := 10
+	resp := suite.makeRequest("POST", "/api/inventory/stocks/adjust", map[string]interface{}{
+		"shop_id":         shopID,
+		"product_id":      productID,
+		"quantity":        available,
+		"adjustment_type": "add",
+		"reason":          "Integration test stock",
+	}, suite.adminToken)
+	suite.Equal(200, resp.StatusCode)
+	resp.Body.Close()
+
+	suite.Run("Parallel Sales Stop At Available Stock", func() {
+		concurrency := 25
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		codes := make(map[int]int)
+
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				payload := map[string]interface{}{
+					"sale_date":      time.Now().Format(time.RFC3339),
+					"shop_id":        shopID,
+					"payment_method": "cash",
+					"paid_amount":    100.00,
+					"items": []map[string]interface{}{
+						{"product_id": productID, "quantity": 1, "unit_price": 100.00},
+					},
+				}
+
+				resp := suite.makeRequest("POST", "/api/sales/sales", payload, suite.adminToken)
+				resp.Body.Close()
+
+				mu.Lock()
+				codes[resp.StatusCode]++
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+
+		suite.Equal(available, codes[201], "Only the available stock should be sold")
+		suite.Equal(concurrency-available, codes[400], "Every other sale should be refused for insufficient stock")
+
+		resp := suite.makeRequest("GET", "/api/inventory/stock?shop_id="+shopID, nil, suite.adminToken)
+		suite.Equal(200, resp.StatusCode)
+
+		var result struct {
+			Stocks []struct {
+				ProductID string `json:"product_id"`
+				Quantity  int    `json:"quantity"`
+			} `json:"stocks"`
+		}
+		json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+
+		found := false
+		for _, stock := range result.Stocks {
+			if stock.ProductID == productID {
+				found = true
+				suite.Equal(0, stock.Quantity, "Stock should be sold out, never negative")
+			}
+		}
+		suite.True(found)
+	})
+}
+
+// Test Dashboard Cache Invalidation
+func (suite *IntegrationTestSuite) TestDashboardReflectsApprovals() {
+	createEntity := func(endpoint string, payload map[string]interface{}, status int) string {
+		resp := suite.makeRequest("POST", endpoint, payload, suite.adminToken)
+		suite.Equal(status, resp.StatusCode)
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+
+		id, _ := result["id"].(string)
+		suite.NotEmpty(id)
+		return id
+	}
+
+	pendingSales := func(shopID string) float64 {
+		resp := suite.makeRequest("GET", "/api/sales/dashboard/summary?shop_id="+shopID, nil, suite.adminToken)
+		suite.Equal(200, resp.StatusCode)
+
+		var summary map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&summary)
+		resp.Body.Close()
+
+		pending, _ := summary["pending_sales"].(float64)
+		return pending
+	}
+
+	suffix := time.Now().UnixNano()
+	shopID := createEntity("/api/admin/shops", map[string]interface{}{
+		"name":           fmt.Sprintf("Dashboard Shop %d", suffix),
+		"address":        "Integration Test Street",
+		"phone":          "9999999999",
+		"license_number": fmt.Sprintf("LIC%d", suffix),
+	}, 201)
+	brandID := createEntity("/api/inventory/brands", map[string]interface{}{
+		"name": fmt.Sprintf("Dashboard Brand %d", suffix),
+	}, 200)
+	categoryID := createEntity("/api/inventory/categories", map[string]interface{}{
+		"name": fmt.Sprintf("Dashboard Category %d", suffix),
+	}, 200)
+	productID := createEntity("/api/inventory/products", map[string]interface{}{
+		"name":          "Dashboard Test Product",
+		"sku":           fmt.Sprintf("DSH-%d", suffix),
+		"brand_id":      brandID,
+		"category_id":   categoryID,
+		"size":          "750ml",
+		"selling_price": 100.00,
+		"mrp":           120.00,
+		"cost_price":    80.00,
+	}, 200)
+
+	suite.Run("Pending Count Drops Right After Approval", func() {
+		recordID := createEntity("/api/sales/daily-records", map[string]interface{}{
+			"record_date":        time.Now().Format(time.RFC3339),
+			"shop_id":            shopID,
+			"total_sales_amount": 500.00,
+			"total_cash_amount":  500.00,
+			"items": []map[string]interface{}{
+				{"product_id": productID, "quantity": 5, "unit_price": 100.00, "total_amount": 500.00, "cash_amount": 500.00},
+			},
+		}, 201)
+
+		// Reading the dashboard caches it with the record pending
+		before := pendingSales(shopID)
+		suite.GreaterOrEqual(before, 1.0)
+
+		resp := suite.makeRequest("POST", "/api/sales/daily-records/"+recordID+"/approve", nil, suite.adminToken)
+		suite.Equal(200, resp.StatusCode)
+		resp.Body.Close()
+
+		suite.Equal(before-1, pendingSales(shopID), "The approval should be visible without waiting for the cache to expire")
+	})
+}
+
+// Test Idempotent Record Creation
+func (suite *IntegrationTestSuite) TestIdempotentDailySalesRecord() {
+	createEntity := func(endpoint string, payload map[string]interface{}, status int) string {
+		resp := suite.makeRequest("POST", endpoint, payload, suite.adminToken)
+		suite.Equal(status, resp.StatusCode)
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+
+		id, _ := result["id"].(string)
+		suite.NotEmpty(id)
+		return id
+	}
+
+	suffix := time.Now().UnixNano()
+	shopID := createEntity("/api/admin/shops", map[string]interface{}{
+		"name":           fmt.Sprintf("Idempotency Shop %d", suffix),
+		"address":        "Integration Test Street",
+		"phone":          "9999999999",
+		"license_number": fmt.Sprintf("LIC%d", suffix),
+	}, 201)
+	brandID := createEntity("/api/inventory/brands", map[string]interface{}{
+		"name": fmt.Sprintf("Idempotency Brand %d", suffix),
+	}, 200)
+	categoryID := createEntity("/api/inventory/categories", map[string]interface{}{
+		"name": fmt.Sprintf("Idempotency Category %d", suffix),
+	}, 200)
+	productID := createEntity("/api/inventory/products", map[string]interface{}{
+		"name":          "Idempotency Test Product",
+		"sku":           fmt.Sprintf("IDM-%d", suffix),
+		"brand_id":      brandID,
+		"category_id":   categoryID,
+		"size":          "750ml",
+		"selling_price": 100.00,
+		"mrp":           120.00,
+		"cost_price":    80.00,
+	}, 200)
+
+	payload := map[string]interface{}{
+		"record_date":        time.Now().Format(time.RFC3339),
+		"shop_id":            shopID,
+		"total_sales_amount": 500.00,
+		"total_cash_amount":  500.00,
+		"items": []map[string]interface{}{
+			{"product_id": productID, "quantity": 5, "unit_price": 100.00, "total_amount": 500.00, "cash_amount": 500.00},
+		},
+	}
+	key := fmt.Sprintf("idem-%d", suffix)
+
+	suite.Run("Replayed Request Creates One Record", func() {
+		var ids []string
+		for i := 0; i < 3; i++ {
+			resp := suite.makeRequestWithHeaders("POST", "/api/sales/daily-records", payload, suite.adminToken,
+				map[string]string{"Idempotency-Key": key})
+			suite.Equal(201, resp.StatusCode)
+			if i > 0 {
+				suite.Equal("true", resp.Header.Get("Idempotent-Replayed"))
+			}
+
+			var result map[string]interface{}
+			json.NewDecoder(resp.Body).Decode(&result)
+			resp.Body.Close()
+
+			id, _ := result["id"].(string)
+			suite.NotEmpty(id)
+			ids = append(ids, id)
+		}
+		suite.Equal(ids[0], ids[1], "A replay should return the original record")
+		suite.Equal(ids[0], ids[2])
+
+		resp := suite.makeRequest("GET", "/api/sales/daily-records?shop_id="+shopID, nil, suite.adminToken)
+		suite.Equal(200, resp.StatusCode)
+
+		var list map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&list)
+		resp.Body.Close()
+
+		suite.Equal(1.0, list["total_count"], "Only one record should have been created")
+	})
+
+	suite.Run("Reused Key With Different Request Rejected", func() {
+		changed := map[string]interface{}{}
+		for field, value := range payload {
+			changed[field] = value
+		}
+		changed["notes"] = "Different request"
+
+		resp := suite.makeRequestWithHeaders("POST", "/api/sales/daily-records", changed, suite.adminToken,
+			map[string]string{"Idempotency-Key": key})
+		suite.Equal(422, resp.StatusCode)
+		resp.Body.Close()
+	})
+}
+
+// Test Daily Sales Amounts Add Up Exactly
+func (suite *IntegrationTestSuite) TestDailySalesAmountsAddUpExactly() {
+	createEntity := func(endpoint string, payload map[string]interface{}, status int) string {
+		resp := suite.makeRequest("POST", endpoint, payload, suite.adminToken)
+		suite.Equal(status, resp.StatusCode)
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+
+		id, _ := result["id"].(string)
+		suite.NotEmpty(id)
+		return id
+	}
+
+	suffix := time.Now().UnixNano()
+	shopID := createEntity("/api/admin/shops", map[string]interface{}{
+		"name":           fmt.Sprintf("Money Shop %d", suffix),
+		"address":        "Integration Test Street",
+		"phone":          "9999999999",
+		"license_number": fmt.Sprintf("LIC%d", suffix),
+	}, 201)
+	brandID := createEntity("/api/inventory/brands", map[string]interface{}{
+		"name": fmt.Sprintf("Money Brand %d", suffix),
+	}, 200)
+	categoryID := createEntity("/api/inventory/categories", map[string]interface{}{
+		"name": fmt.Sprintf("Money Category %d", suffix),
+	}, 200)
+	productID := createEntity("/api/inventory/products", map[string]interface{}{
+		"name":          "Money Test Product",
+		"sku":           fmt.Sprintf("MNY-%d", suffix),
+		"brand_id":      brandID,
+		"category_id":   categoryID,
+		"size":          "90ml",
+		"selling_price": 0.10,
+		"mrp":           0.10,
+		"cost_price":    0.05,
+	}, 200)
+
+	suite.Run("Many Small Items Sum Exactly", func() {
+		// 0.07 + 0.03 and 300 lots of 0.10 do not add up exactly in binary floats
+		items := make([]map[string]interface{}, 300)
+		for i := range items {
+			items[i] = map[string]interface{}{
+				"product_id": productID, "quantity": 1, "unit_price": 0.10, "total_amount": 0.10,
+				"cash_amount": 0.07, "upi_amount": 0.03,
+			}
+		}
+		resp := suite.makeRequest("POST", "/api/sales/daily-records", map[string]interface{}{
+			"record_date":        time.Now().Format(time.RFC3339),
+			"shop_id":            shopID,
+			"total_sales_amount": 30.00,
+			"total_cash_amount":  21.00,
+			"total_upi_amount":   9.00,
+			"items":              items,
+		}, suite.adminToken)
+		suite.Equal(201, resp.StatusCode)
+
+		var record map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&record)
+		resp.Body.Close()
+		suite.Equal(30.0, record["total_sales_amount"])
+	})
+
+	suite.Run("One Paisa Mismatch Rejected", func() {
+		resp := suite.makeRequest("POST", "/api/sales/daily-records", map[string]interface{}{
+			"record_date":        time.Now().AddDate(0, 0, -1).Format(time.RFC3339),
+			"shop_id":            shopID,
+			"total_sales_amount": 100.00,
+			"total_cash_amount":  99.99,
+			"items": []map[string]interface{}{
+				{"product_id": productID, "quantity": 1000, "unit_price": 0.10, "total_amount": 100.00, "cash_amount": 99.99},
+			},
+		}, suite.adminToken)
+		suite.Equal(400, resp.StatusCode)
+		resp.Body.Close()
+	})
+}
+
+// Test Vendor Invoice Payments
+func (suite *IntegrationTestSuite) TestVendorInvoicePayments() {
+	decode := func(resp *http.Response) map[string]interface{} {
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		return result
+	}
+
+	resp := suite.makeRequest("POST", "/api/finance/vendors", map[string]interface{}{
+		"name": fmt.Sprintf("Invoice Test Vendor %d", time.Now().UnixNano()),
+	}, suite.adminToken)
+	suite.Equal(201, resp.StatusCode)
+	vendorID, _ := decode(resp)["id"].(string)
+	suite.NotEmpty(vendorID)
+
+	resp = suite.makeRequest("POST", "/api/finance/vendors/"+vendorID+"/invoices", map[string]interface{}{
+		"invoice_number": fmt.Sprintf("VINV-%d", time.Now().UnixNano()),
+		"due_date":       time.Now().AddDate(0, 0, 30).Format(time.RFC3339),
+		"sub_total":      1000.00,
+	}, suite.adminToken)
+	suite.Equal(201, resp.StatusCode)
+	invoice := decode(resp)
+	invoiceID, _ := invoice["id"].(string)
+	suite.NotEmpty(invoiceID)
+	suite.Equal("pending", invoice["status"])
+	suite.Equal(1000.00, invoice["due_amount"])
+
+	pay := func(amount float64) *http.Response {
+		return suite.makeRequest("POST", "/api/finance/vendors/transactions", map[string]interface{}{
+			"vendor_id":         vendorID,
+			"vendor_invoice_id": invoiceID,
+			"transaction_type":  "payment",
+			"amount":            amount,
+			"description":       "Invoice payment",
+			"payment_method":    "bank_transfer",
+		}, suite.adminToken)
+	}
+
+	suite.Run("Partial Payment", func() {
+		resp := pay(400.00)
+		suite.Equal(201, resp.StatusCode)
+		result, _ := decode(resp)["invoice"].(map[string]interface{})
+		suite.Equal("partial", result["status"])
+		suite.Equal(400.00, result["paid_amount"])
+		suite.Equal(600.00, result["due_amount"])
+	})
+
+	suite.Run("Overpayment Rejected", func() {
+		resp := pay(600.01)
+		suite.Equal(400, resp.StatusCode)
+		resp.Body.Close()
+	})
+
+	suite.Run("Full Payment", func() {
+		resp := pay(600.00)
+		suite.Equal(201, resp.StatusCode)
+		result, _ := decode(resp)["invoice"].(map[string]interface{})
+		suite.Equal("paid", result["status"])
+		suite.Equal(1000.00, result["paid_amount"])
+		suite.Equal(0.0, result["due_amount"])
+	})
+
+	suite.Run("Paid Invoice Rejects Further Payments", func() {
+		resp := pay(1.00)
+		suite.Equal(400, resp.StatusCode)
+		resp.Body.Close()
+	})
+}
+
 // Helper methods
 
 func (suite *IntegrationTestSuite) makeRequest(method, endpoint string, payload interface{}, token string) *http.Response {
@@ -466,6 +1289,25 @@ func (suite *IntegrationTestSuite) makeRequest(method, endpoint string, payload
 	return resp
 }
 
+func (suite *IntegrationTestSuite) makeRequestWithHeaders(method, endpoint string, payload interface{}, token string, headers map[string]string) *http.Response {
+	jsonData, _ := json.Marshal(payload)
+	req, err := http.NewRequest(method, suite.baseURL+endpoint, bytes.NewBuffer(jsonData))
+	suite.NoError(err)
+
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := suite.client.Do(req)
+	suite.NoError(err)
+
+	return resp
+}
+
 func (suite *IntegrationTestSuite) cleanupTestData() {
 	// Add cleanup logic here if needed
 }
@@ -355,10 +355,10 @@ function updateDashboardStats(data) {
 }
 
 // Utility functions
-function formatCurrency(amount) {
+function formatCurrency(amount, currency = 'INR') {
     return new Intl.NumberFormat('en-IN', {
         style: 'currency',
-        currency: 'INR'
+        currency: currency || 'INR'
     }).format(amount);
 }
 